@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/events"
+	"argocd-proxy/params"
+	"argocd-proxy/types"
+)
+
+// clientStatsTopRoutes is the number of busiest routes reported per client
+const clientStatsTopRoutes = 5
+
+// ignoredProjectsNote tells admin API clients that runtime changes are not persisted
+const ignoredProjectsNote = "Runtime changes are kept in memory only; IGNORED_PROJECTS applies again after a restart"
+
+// adminRoutes registers the admin endpoints behind adminAuthMiddleware. They are only
+// registered when ADMIN_TOKEN is set, so without it they answer 404 like unknown routes.
+func (s *Server) adminRoutes() {
+	if s.config.AdminToken == "" {
+		return
+	}
+
+	admin := s.routes(s.router.Group("/admin", s.adminAuthMiddleware()))
+	admin.GET("/ignored-projects", s.getIgnoredProjects)
+	admin.HEAD("/ignored-projects", s.getIgnoredProjects)
+	admin.PUT("/ignored-projects", s.putIgnoredProjects)
+	admin.GET("/explain", s.explainFilter)
+	admin.HEAD("/explain", s.explainFilter)
+	admin.POST("/filters/simulate", s.simulateFilter)
+	admin.GET("/features", s.getFeatures)
+	admin.HEAD("/features", s.getFeatures)
+	admin.GET("/circuits", s.getCircuits)
+	admin.HEAD("/circuits", s.getCircuits)
+	admin.GET("/maintenance", s.getMaintenance)
+	admin.HEAD("/maintenance", s.getMaintenance)
+	admin.POST("/maintenance", s.postMaintenance)
+	admin.DELETE("/maintenance", s.deleteMaintenance)
+	admin.GET("/events", s.getEvents)
+	admin.HEAD("/events", s.getEvents)
+	admin.GET("/support-bundle", s.getSupportBundle)
+	admin.HEAD("/support-bundle", s.getSupportBundle)
+	admin.GET("/cache/snapshot", s.getCacheSnapshot)
+	admin.HEAD("/cache/snapshot", s.getCacheSnapshot)
+	if s.clientStats != nil {
+		admin.GET("/client-stats", s.getClientStats)
+		admin.HEAD("/client-stats", s.getClientStats)
+	}
+}
+
+// adminAuthMiddleware rejects requests without "Authorization: Bearer <ADMIN_TOKEN>"
+func (s *Server) adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.hasAdminToken(c) {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			s.errorResponse(c, http.StatusUnauthorized, types.ErrorCodeAdminUnauthorized, "A valid admin token is required", "")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasAdminToken reports whether the request presents ADMIN_TOKEN as its bearer token.
+// The token is compared in constant time; without ADMIN_TOKEN no request has it.
+func (s *Server) hasAdminToken(c *gin.Context) bool {
+	if s.config.AdminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) == 1
+}
+
+// getIgnoredProjects handles the ignored projects admin endpoint
+// @Summary Get ignored project patterns
+// @Description Get the ignore patterns in effect with the cached projects each one matches, so dead patterns can be spotted. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} config.IgnoredProjectsReport "Ignore patterns and their matches"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
+// @Router /admin/ignored-projects [get]
+func (s *Server) getIgnoredProjects(c *gin.Context) {
+	s.respondIgnoredProjects(c, s.filters(c))
+}
+
+// putIgnoredProjects handles replacing the ignore patterns through the admin API
+// @Summary Replace ignored project patterns
+// @Description Replace the ignore patterns in effect with a JSON array of patterns. The patterns are validated as a whole and take effect immediately; cached lists are dropped so that they are filtered with the new patterns. Changes are not persisted: IGNORED_PROJECTS applies again after a restart. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param patterns body []string true "Replacement ignore patterns"
+// @Success 200 {object} config.IgnoredProjectsReport "Ignore patterns now in effect and their matches"
+// @Failure 400 {object} types.ErrorResponse "Invalid pattern list"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Failure 502 {object} types.ErrorResponse "Patterns replaced, but projects could not be retrieved from ArgoCD to count matches"
+// @Router /admin/ignored-projects [put]
+func (s *Server) putIgnoredProjects(c *gin.Context) {
+	var patterns []string
+	if err := json.NewDecoder(c.Request.Body).Decode(&patterns); err != nil {
+		s.invalidParamsResponse(c, fmt.Errorf("request body must be a JSON array of patterns: %w", err))
+		return
+	}
+	if patterns == nil {
+		s.invalidParamsResponse(c, fmt.Errorf("request body must be a JSON array of patterns, got null"))
+		return
+	}
+	filters, err := s.config.SetIgnoredPatterns(patterns)
+	if err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	// Lists cached before the change were filtered with the old patterns
+	s.argocdService.InvalidateCaches()
+	if s.responseCache != nil {
+		s.responseCache.Purge()
+	}
+	log.Printf("Ignored project patterns replaced through the admin API: %v", filters.IgnoredProjects)
+	events.Record(events.ConfigReloaded, "Ignored project patterns replaced through the admin API", map[string]string{
+		"ignoredProjects": strings.Join(filters.IgnoredProjects, ","),
+	})
+
+	s.respondIgnoredProjects(c, filters)
+}
+
+// respondIgnoredProjects renders the ignore patterns of filters with their match counts
+func (s *Server) respondIgnoredProjects(c *gin.Context, filters *config.FilterSnapshot) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	projectNames, err := s.argocdService.GetProjectNames(ctx)
+	if err != nil {
+		log.Printf("Failed to get project names: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config.IgnoredProjectsReport{
+		Patterns:   filters.IgnoredPatternMatches(projectNames),
+		Overridden: filters.IgnoredOverridden,
+		Persisted:  false,
+		Note:       ignoredProjectsNote,
+	})
+}
+
+// explainFilter handles the filter decision admin endpoint
+// @Summary Explain why a project or application is visible or hidden
+// @Description Trace the ignore rules applied to a project and, optionally, one of its applications: the groups listing the project, the ignore pattern it matches, the group ignore patterns hiding the application from group listings, and the final visible or hidden verdict. Only the configured rules are applied; whether the project or application exists in ArgoCD is not checked. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param project query string true "Project name"
+// @Param application query string false "Application name, to check the group ignore patterns against it"
+// @Success 200 {object} config.FilterExplanation "Decision trace"
+// @Failure 400 {object} types.ErrorResponse "Project name is required"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/explain [get]
+func (s *Server) explainFilter(c *gin.Context) {
+	b := params.NewBinder(c.Request.URL.Query())
+	project := b.String("project")
+	application := b.String("application")
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+	if project == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Project name is required", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, s.filters(c).ExplainFilter(project, application))
+}
+
+// simulateFilter handles the filter simulation admin endpoint
+// @Summary Simulate a filter configuration change
+// @Description Preview the effect of candidate ignore patterns and project groups without applying them: the projects the candidate would newly hide or reveal, the currently visible applications it would hide, and the counts of unaffected ones. Omitted fields keep the configuration in effect. Projects and applications are read from the cached lists; applications of currently hidden projects are not known to the proxy, so newly visible applications are not reported. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param candidate body config.FilterCandidate true "Candidate filter configuration"
+// @Success 200 {object} config.FilterSimulation "Visibility changes"
+// @Failure 400 {object} types.ErrorResponse "Invalid candidate; fields names every invalid pattern"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects or applications from ArgoCD"
+// @Router /admin/filters/simulate [post]
+func (s *Server) simulateFilter(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	var candidate config.FilterCandidate
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&candidate); err != nil {
+		s.invalidParamsResponse(c, fmt.Errorf("request body must be a JSON object with ignoredProjects and projectGroups: %w", err))
+		return
+	}
+	filters := s.filters(c)
+	simulated, err := s.config.CandidateSnapshot(filters, candidate)
+	if err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	projectNames, err := s.argocdService.GetProjectNames(ctx)
+	if err != nil {
+		log.Printf("Failed to get project names: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return
+	}
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+	applicationProjects := make(map[string]string, len(applications.Items))
+	for _, app := range applications.Items {
+		applicationProjects[app.Metadata.Name] = app.Spec.Project
+	}
+
+	c.JSON(http.StatusOK, filters.SimulateFilter(simulated, projectNames, applicationProjects))
+}
+
+// getEvents handles the operational events admin endpoint
+// @Summary Get recent operational events
+// @Description Get the most recent operational events kept in memory, newest first: token refreshes and failures, cache invalidations, runtime configuration changes, circuit breaker trips, upstream compatibility changes and maintenance mode changes. The last EVENT_LOG_SIZE events are kept; sequence numbers let clients notice events that were dropped. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param limit query int false "Maximum number of events to return (default 100)"
+// @Success 200 {object} events.Report "Recent events"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/events [get]
+func (s *Server) getEvents(c *gin.Context) {
+	b := params.NewBinder(c.Request.URL.Query())
+	limit := b.Int("limit", 100, 1, math.MaxInt32)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events.Default().Recent(limit))
+}
+
+// getClientStats handles the client statistics admin endpoint
+// @Summary Get per-client request statistics
+// @Description Get rolling request, byte and error counts and the busiest routes of every client seen recently, busiest first. Clients are identified by a salted hash of their identity (the client IP unless an authentication layer names the client), matching the client label of the argocd_proxy_client_* metrics; raw identities are never reported. Counters are halved every CLIENT_STATS_HALF_LIFE. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set and CLIENT_STATS is enabled
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} clientstats.Report "Per-client statistics"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/client-stats [get]
+func (s *Server) getClientStats(c *gin.Context) {
+	c.JSON(http.StatusOK, s.clientStats.Snapshot())
+}
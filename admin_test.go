@@ -0,0 +1,570 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/clientstats"
+	"argocd-proxy/config"
+	"argocd-proxy/events"
+	"argocd-proxy/lifecycle"
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+const testAdminToken = "0123456789abcdef"
+
+// setupAdminServer builds a server with the admin API enabled over a fixed project list
+func setupAdminServer() (*Server, *MockArgocdService) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Port:            "5001",
+		ProjectGroups:   []config.ProjectGroup{{Name: "Sandbox", Projects: []string{"sandbox-shared"}}},
+		IgnoredProjects: []string{"test-*", "legacy"},
+		AdminToken:      testAdminToken,
+	}
+	mockService := &MockArgocdService{
+		config:       cfg,
+		projectNames: []string{"payments", "sandbox-alice", "sandbox-shared", "test-api", "test-web"},
+	}
+
+	server := &Server{
+		config:        cfg,
+		authService:   &MockAuthService{token: "test-token"},
+		argocdService: mockService,
+		lifecycle:     lifecycle.New(context.Background()),
+		startTime:     time.Now(),
+	}
+	server.setupRouter()
+	return server, mockService
+}
+
+// serveAdmin serves an admin request, authenticated with token unless it is empty
+func serveAdmin(server *Server, method, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/admin/ignored-projects", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"valid token", testAdminToken, http.StatusOK},
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "fedcba9876543210", http.StatusUnauthorized},
+		{"token prefix", testAdminToken[:8], http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := setupAdminServer()
+
+			for _, method := range []string{http.MethodGet, http.MethodPut} {
+				w := serveAdmin(server, method, `["legacy"]`, tt.token)
+				if w.Code != tt.wantStatus {
+					t.Fatalf("%s status = %d, want %d", method, w.Code, tt.wantStatus)
+				}
+				if tt.wantStatus != http.StatusUnauthorized {
+					continue
+				}
+
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if response.ErrorCode != types.ErrorCodeAdminUnauthorized {
+					t.Errorf("%s errorCode = %q, want %q", method, response.ErrorCode, types.ErrorCodeAdminUnauthorized)
+				}
+			}
+			if tt.wantStatus == http.StatusUnauthorized && !reflect.DeepEqual(server.config.Snapshot().IgnoredProjects, []string{"test-*", "legacy"}) {
+				t.Errorf("unauthorized PUT changed the patterns to %v", server.config.Snapshot().IgnoredProjects)
+			}
+		})
+	}
+}
+
+func TestAdminRoutesDisabledWithoutToken(t *testing.T) {
+	server := setupTestServer()
+
+	if w := serveAdmin(server, http.MethodGet, "", testAdminToken); w.Code != http.StatusNotFound {
+		t.Errorf("GET /admin/ignored-projects status = %d, want %d without ADMIN_TOKEN", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetIgnoredProjects(t *testing.T) {
+	server, _ := setupAdminServer()
+
+	w := serveAdmin(server, http.MethodGet, "", testAdminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var report config.IgnoredProjectsReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	want := []config.IgnoredPatternMatch{
+		{Pattern: "legacy", Matches: 0, Projects: []string{}},
+		{Pattern: "test-*", Matches: 2, Projects: []string{"test-api", "test-web"}},
+	}
+	if !reflect.DeepEqual(report.Patterns, want) {
+		t.Errorf("patterns = %+v, want %+v", report.Patterns, want)
+	}
+	if report.Overridden || report.Persisted || report.Note == "" {
+		t.Errorf("overridden = %v, persisted = %v, note = %q; want false, false and a note", report.Overridden, report.Persisted, report.Note)
+	}
+}
+
+func TestPutIgnoredProjects(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantPatterns []string
+		wantMatches  map[string]int
+	}{
+		{
+			name:         "replaces patterns",
+			body:         `["sandbox-*", "payments"]`,
+			wantStatus:   http.StatusOK,
+			wantPatterns: []string{"sandbox-*", "payments"},
+			wantMatches:  map[string]int{"sandbox-*": 2, "payments": 1},
+		},
+		{
+			name:         "empty list",
+			body:         `[]`,
+			wantStatus:   http.StatusOK,
+			wantPatterns: []string{},
+			wantMatches:  map[string]int{},
+		},
+		{name: "not an array", body: `{"patterns": ["legacy"]}`, wantStatus: http.StatusBadRequest},
+		{name: "null", body: `null`, wantStatus: http.StatusBadRequest},
+		{name: "malformed", body: `["legacy"`, wantStatus: http.StatusBadRequest},
+		{name: "empty pattern", body: `["legacy", ""]`, wantStatus: http.StatusBadRequest},
+		{name: "inner wildcard", body: `["team-*-dev"]`, wantStatus: http.StatusBadRequest},
+		{name: "duplicate pattern", body: `["legacy", "legacy"]`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mockService := setupAdminServer()
+
+			w := serveAdmin(server, http.MethodPut, tt.body, testAdminToken)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if response.ErrorCode != types.ErrorCodeInvalidParam {
+					t.Errorf("errorCode = %q, want %q", response.ErrorCode, types.ErrorCodeInvalidParam)
+				}
+				if got := server.config.Snapshot().IgnoredProjects; !reflect.DeepEqual(got, []string{"test-*", "legacy"}) {
+					t.Errorf("rejected PUT changed the patterns to %v", got)
+				}
+				if mockService.invalidations != 0 {
+					t.Errorf("rejected PUT invalidated caches %d times", mockService.invalidations)
+				}
+				return
+			}
+
+			var report config.IgnoredProjectsReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			matches := make(map[string]int)
+			for _, match := range report.Patterns {
+				matches[match.Pattern] = match.Matches
+			}
+			if !reflect.DeepEqual(matches, tt.wantMatches) {
+				t.Errorf("matches = %v, want %v", matches, tt.wantMatches)
+			}
+			if !report.Overridden || report.Persisted {
+				t.Errorf("overridden = %v, persisted = %v, want true and false", report.Overridden, report.Persisted)
+			}
+			if got := server.config.Snapshot().IgnoredProjects; !reflect.DeepEqual(got, tt.wantPatterns) {
+				t.Errorf("IgnoredProjects = %v, want %v", got, tt.wantPatterns)
+			}
+			if mockService.invalidations != 1 {
+				t.Errorf("InvalidateCaches() called %d times, want 1", mockService.invalidations)
+			}
+		})
+	}
+}
+
+func TestPutIgnoredProjectsAppliesToFiltering(t *testing.T) {
+	server, _ := setupAdminServer()
+
+	if server.config.Snapshot().ShouldFilterProject("sandbox-alice") || !server.config.Snapshot().ShouldFilterProject("test-api") {
+		t.Fatal("unexpected filtering before the update")
+	}
+
+	if w := serveAdmin(server, http.MethodPut, `["sandbox-*"]`, testAdminToken); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if !server.config.Snapshot().ShouldFilterProject("sandbox-alice") {
+		t.Error("ShouldFilterProject(sandbox-alice) = false after adding sandbox-*")
+	}
+	if server.config.Snapshot().ShouldFilterProject("sandbox-shared") {
+		t.Error("ShouldFilterProject(sandbox-shared) = true, grouped projects must stay visible")
+	}
+	if server.config.Snapshot().ShouldFilterProject("test-api") {
+		t.Error("ShouldFilterProject(test-api) = true after removing test-*")
+	}
+}
+
+func TestPutIgnoredProjectsConcurrentReads(t *testing.T) {
+	server, _ := setupAdminServer()
+
+	// Every response must reflect one pattern set as a whole; run with -race to check
+	// the reloads for data races as well
+	wantUngrouped := map[string]bool{
+		`["payments","sandbox-alice"]`:            true, // test-*, legacy
+		`["sandbox-alice","test-api","test-web"]`: true, // payments
+	}
+	done := make(chan struct{})
+	var writers sync.WaitGroup
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			body := `["test-*","legacy"]`
+			if i%2 == 1 {
+				body = `["payments"]`
+			}
+			if w := serveAdmin(server, http.MethodPut, body, testAdminToken); w.Code != http.StatusOK {
+				t.Errorf("PUT status = %d, want %d", w.Code, http.StatusOK)
+				return
+			}
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for range 50 {
+				w := serveMethod(server, http.MethodGet, "/project-groups", nil)
+				var response config.ProjectGroupsResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Errorf("failed to decode response: %v", err)
+					return
+				}
+				ungrouped, _ := json.Marshal(response.UngroupedProjects)
+				if !wantUngrouped[string(ungrouped)] {
+					t.Errorf("ungroupedProjects = %s, want the projects of one pattern set", ungrouped)
+				}
+			}
+		}()
+	}
+	readers.Wait()
+	close(done)
+	writers.Wait()
+}
+
+func TestExplainFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		token       string
+		wantStatus  int
+		wantVisible bool
+		wantPattern string
+		wantGroups  []config.ExplainedGroup
+	}{
+		{
+			name:        "plain ignored project",
+			query:       "project=test-api&application=api",
+			token:       testAdminToken,
+			wantStatus:  http.StatusOK,
+			wantVisible: false,
+			wantPattern: "test-*",
+			wantGroups:  []config.ExplainedGroup{},
+		},
+		{
+			name:        "grouped project matching a pattern",
+			query:       "project=test-web&application=web-preview",
+			token:       testAdminToken,
+			wantStatus:  http.StatusOK,
+			wantVisible: true,
+			wantPattern: "test-*",
+			wantGroups:  []config.ExplainedGroup{{Name: "Preview", ApplicationIgnoredBy: "*-preview"}},
+		},
+		{
+			name:        "visible project",
+			query:       "project=payments",
+			token:       testAdminToken,
+			wantStatus:  http.StatusOK,
+			wantVisible: true,
+			wantGroups:  []config.ExplainedGroup{},
+		},
+		{name: "missing project", query: "application=api", token: testAdminToken, wantStatus: http.StatusBadRequest},
+		{name: "missing token", query: "project=test-api", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := setupAdminServer()
+			server.config.ProjectGroups = append(server.config.ProjectGroups,
+				config.ProjectGroup{Name: "Preview", Projects: []string{"test-web"}, IgnoredProjects: []string{"*-preview"}})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/explain?"+tt.query, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var explanation config.FilterExplanation
+			if err := json.Unmarshal(w.Body.Bytes(), &explanation); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if explanation.Visible != tt.wantVisible || explanation.MatchedPattern != tt.wantPattern {
+				t.Errorf("visible = %v, matchedPattern = %q, want %v, %q", explanation.Visible, explanation.MatchedPattern, tt.wantVisible, tt.wantPattern)
+			}
+			if !reflect.DeepEqual(explanation.Groups, tt.wantGroups) {
+				t.Errorf("groups = %+v, want %+v", explanation.Groups, tt.wantGroups)
+			}
+			if explanation.Reason == "" {
+				t.Error("reason is empty")
+			}
+		})
+	}
+}
+
+func TestGetEvents(t *testing.T) {
+	previous := events.Default()
+	defer events.SetDefault(previous)
+	events.SetDefault(events.New(10))
+
+	server, _ := setupAdminServer()
+	events.Record(events.TokenRefreshed, "ArgoCD token refreshed", nil)
+	if w := serveAdmin(server, http.MethodPut, `["sandbox-*"]`, testAdminToken); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w := serveMethod(server, http.MethodGet, "/admin/events?limit=1", map[string]string{"Authorization": "Bearer " + testAdminToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var report events.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Recorded != 2 || report.Capacity != 10 || len(report.Events) != 1 {
+		t.Fatalf("report = %+v, want 2 recorded and the newest event", report)
+	}
+	if event := report.Events[0]; event.Type != events.ConfigReloaded || event.Attrs["ignoredProjects"] != "sandbox-*" {
+		t.Errorf("newest event = %+v, want the ignore pattern change", event)
+	}
+
+	if w = serveMethod(server, http.MethodGet, "/admin/events?limit=0", map[string]string{"Authorization": "Bearer " + testAdminToken}); w.Code != http.StatusBadRequest {
+		t.Errorf("limit=0 status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w = serveMethod(server, http.MethodGet, "/admin/events", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestClientStatsRouteDisabled(t *testing.T) {
+	server, _ := setupAdminServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/client-stats", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d without CLIENT_STATS", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetClientStats(t *testing.T) {
+	original := metrics.Default()
+	defer metrics.SetDefault(original)
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+
+	server, _ := setupAdminServer()
+	server.clientStats = clientstats.New(clientstats.Options{MaxClients: 10, HalfLife: time.Minute, Salt: "test"})
+	server.setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+	req.RemoteAddr = "192.0.2.44:5555"
+	server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/client-stats", nil)
+	req.RemoteAddr = "192.0.2.44:5555"
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if strings.Contains(w.Body.String(), "192.0.2.44") {
+		t.Errorf("client stats expose the client IP: %s", w.Body.String())
+	}
+
+	var report clientstats.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(report.Clients) != 1 || report.Clients[0].Client != server.clientStats.Hash("192.0.2.44") {
+		t.Fatalf("clients = %+v, want the hashed client IP", report.Clients)
+	}
+	if got := report.Clients[0].TopRoutes[0]; got.Route != "/projects" || got.Requests != 1 {
+		t.Errorf("top route = %+v, want /projects with 1 request", got)
+	}
+}
+
+// serveSimulate posts a filter candidate to the simulation endpoint
+func serveSimulate(server *Server, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/admin/filters/simulate", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSimulateFilterMatchesAppliedChange(t *testing.T) {
+	server, mockService := setupAdminServer()
+	mockService.applications = types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "checkout"}, Spec: types.ArgocdApplicationSpec{Project: "payments"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "alice-web"}, Spec: types.ArgocdApplicationSpec{Project: "sandbox-alice"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "shared-db"}, Spec: types.ArgocdApplicationSpec{Project: "sandbox-shared"}},
+	}}
+	visible := func() map[string]bool {
+		projects := make(map[string]bool)
+		for _, project := range mockService.projectNames {
+			projects[project] = !server.config.Snapshot().ShouldFilterProject(project)
+		}
+		return projects
+	}
+
+	before := visible()
+	w := serveSimulate(server, `{"ignoredProjects": ["sandbox-*"]}`, testAdminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var simulation config.FilterSimulation
+	if err := json.Unmarshal(w.Body.Bytes(), &simulation); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !reflect.DeepEqual(visible(), before) || mockService.invalidations != 0 {
+		t.Fatal("simulation changed the filtering in effect")
+	}
+
+	if w := serveAdmin(server, http.MethodPut, `["sandbox-*"]`, testAdminToken); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", w.Code, http.StatusOK)
+	}
+	after := visible()
+	want := config.VisibilityDiff{NewlyHidden: []string{}, NewlyVisible: []string{}}
+	for _, project := range mockService.projectNames {
+		switch {
+		case before[project] && !after[project]:
+			want.NewlyHidden = append(want.NewlyHidden, project)
+		case !before[project] && after[project]:
+			want.NewlyVisible = append(want.NewlyVisible, project)
+		case before[project]:
+			want.UnchangedVisible++
+		default:
+			want.UnchangedHidden++
+		}
+	}
+	if !reflect.DeepEqual(simulation.Projects, want) {
+		t.Errorf("simulated projects = %+v, applying the change gave %+v", simulation.Projects, want)
+	}
+
+	wantApplications := config.VisibilityDiff{NewlyHidden: []string{"alice-web"}, NewlyVisible: []string{}, UnchangedVisible: 2}
+	if !reflect.DeepEqual(simulation.Applications, wantApplications) {
+		t.Errorf("simulated applications = %+v, want %+v", simulation.Applications, wantApplications)
+	}
+}
+
+func TestSimulateFilterRejectsInvalidCandidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		token      string
+		wantStatus int
+		wantCode   types.ErrorCode
+		wantFields []string
+	}{
+		{
+			name:       "invalid patterns",
+			body:       `{"ignoredProjects": ["ok-*", "a*b", ""], "projectGroups": [{"name": "Payments", "projects": ["payments"], "ignoredProjects": ["**"]}]}`,
+			token:      testAdminToken,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   types.ErrorCodeInvalidParam,
+			wantFields: []string{"ignoredProjects[1]", "ignoredProjects[2]", "projectGroups[0].ignoredProjects[0]"},
+		},
+		{
+			name:       "unknown field",
+			body:       `{"ignored": ["legacy"]}`,
+			token:      testAdminToken,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   types.ErrorCodeInvalidParam,
+		},
+		{
+			name:       "missing token",
+			body:       `{"ignoredProjects": ["legacy"]}`,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   types.ErrorCodeAdminUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := setupAdminServer()
+
+			w := serveSimulate(server, tt.body, tt.token)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.ErrorCode != tt.wantCode {
+				t.Errorf("errorCode = %q, want %q", response.ErrorCode, tt.wantCode)
+			}
+			if tt.wantFields != nil && !reflect.DeepEqual(response.Fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", response.Fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKey is the gin context key under which authentication middleware stores
+// the caller's identity (e.g. an API key name). The client IP is used when unset.
+const ClientKey = "audit.client"
+
+// Record is a single audit log entry describing one proxied request
+type Record struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Client     string            `json:"client"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	Path       string            `json:"path"`
+	PathParams map[string]string `json:"pathParams,omitempty"`
+	Query      url.Values        `json:"query,omitempty"`
+	Status     int               `json:"status"`
+	Bytes      int               `json:"bytes"`
+}
+
+// Logger writes audit records as JSON lines to a sink
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogger creates a logger writing to w
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// Open creates a logger for the configured sink: stdout when path is empty,
+// otherwise a size-rotated file at path
+func Open(path string, maxSize int64, maxBackups int) (*Logger, error) {
+	if path == "" {
+		return NewLogger(os.Stdout), nil
+	}
+
+	file, err := NewRotatingFile(path, maxSize, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(file), nil
+}
+
+// Log writes a single record
+func (l *Logger) Log(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(data)
+	return err
+}
+
+// Close closes the underlying sink if it is closable (stdout is left open)
+func (l *Logger) Close() error {
+	if closer, ok := l.w.(io.Closer); ok && l.w != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Middleware records one audit entry per request, skipping the excluded paths
+func Middleware(logger *Logger, excludePaths []string) gin.HandlerFunc {
+	excluded := make(map[string]bool, len(excludePaths))
+	for _, path := range excludePaths {
+		excluded[strings.TrimSpace(path)] = true
+	}
+
+	return func(c *gin.Context) {
+		if excluded[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		timestamp := time.Now().UTC()
+		c.Next()
+
+		record := Record{
+			Timestamp: timestamp,
+			Client:    c.GetString(ClientKey),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			Bytes:     c.Writer.Size(),
+		}
+		if record.Client == "" {
+			record.Client = c.ClientIP()
+		}
+		if record.Bytes < 0 {
+			record.Bytes = 0
+		}
+		if len(c.Params) > 0 {
+			record.PathParams = make(map[string]string, len(c.Params))
+			for _, param := range c.Params {
+				record.PathParams[param.Key] = param.Value
+			}
+		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			record.Query = query
+		}
+
+		if err := logger.Log(record); err != nil {
+			log.Printf("Failed to write audit record: %v", err)
+		}
+	}
+}
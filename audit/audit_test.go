@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestRouter(buf *bytes.Buffer, exclude []string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if name := c.GetHeader("X-Test-Client"); name != "" {
+			c.Set(ClientKey, name)
+		}
+	})
+	router.Use(Middleware(NewLogger(buf), exclude))
+	router.GET("/applications/:name", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	router.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return router
+}
+
+func TestMiddlewareRecordContents(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		expectedClient string
+	}{
+		{"client from context", "dashboard", "dashboard"},
+		{"client falls back to IP", "", "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			router := newTestRouter(&buf, nil)
+
+			req := httptest.NewRequest("GET", "/applications/web?refresh=true&fields=a&fields=b", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Test-Client", tt.header)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			var record Record
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("invalid audit record %q: %v", buf.String(), err)
+			}
+
+			if record.Client != tt.expectedClient {
+				t.Errorf("Client = %q, want %q", record.Client, tt.expectedClient)
+			}
+			if record.Method != "GET" {
+				t.Errorf("Method = %q, want GET", record.Method)
+			}
+			if record.Route != "/applications/:name" {
+				t.Errorf("Route = %q, want /applications/:name", record.Route)
+			}
+			if record.Path != "/applications/web" {
+				t.Errorf("Path = %q, want /applications/web", record.Path)
+			}
+			if record.PathParams["name"] != "web" {
+				t.Errorf("PathParams = %v, want name=web", record.PathParams)
+			}
+			if record.Query.Get("refresh") != "true" || len(record.Query["fields"]) != 2 {
+				t.Errorf("Query = %v, want refresh=true and two fields values", record.Query)
+			}
+			if record.Status != http.StatusOK {
+				t.Errorf("Status = %d, want 200", record.Status)
+			}
+			if record.Bytes != len("hello") {
+				t.Errorf("Bytes = %d, want %d", record.Bytes, len("hello"))
+			}
+			if record.Timestamp.IsZero() {
+				t.Error("Timestamp should be set")
+			}
+		})
+	}
+}
+
+func TestMiddlewareExcludesPaths(t *testing.T) {
+	var buf bytes.Buffer
+	router := newTestRouter(&buf, []string{"/health", "/metrics"})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if buf.Len() != 0 {
+		t.Errorf("excluded path should not be audited, got %q", buf.String())
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/applications/web", nil))
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Errorf("expected 1 audit record, got %d", lines)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	file, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	// Each write is 6 bytes, so every second write forces a rotation
+	for _, line := range []string{"aaaaa\n", "bbbbb\n", "ccccc\n", "ddddd\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "ddddd\n",
+		path + ".1": "ccccc\n",
+		path + ".2": "bbbbb\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), got, want)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, found %s.3", filepath.Base(path))
+	}
+}
+
+func TestRotatingFileAppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := NewRotatingFile(path, 12, 1)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write([]byte("new\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(rotated) != "existing\n" || string(current) != "new\n" {
+		t.Errorf("existing size not counted: rotated=%q current=%q", rotated, current)
+	}
+}
+
+func TestOpenWritesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	logger, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := logger.Log(Record{Method: "GET", Path: "/projects", Status: 200}); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"path":"/projects"`) {
+		t.Errorf("audit file missing record, got %q", data)
+	}
+}
@@ -11,9 +11,14 @@ import (
 	"sync"
 	"time"
 
+	"argocd-proxy/clock"
 	"argocd-proxy/config"
+	"argocd-proxy/events"
 	"argocd-proxy/metrics"
+	"argocd-proxy/redact"
+	"argocd-proxy/timing"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
 )
 
 // TokenCache represents cached token information
@@ -25,21 +30,49 @@ type TokenCache struct {
 
 // AuthService manages ArgoCD authentication and token caching
 type AuthService struct {
-	config          *config.Config
-	httpClient      *http.Client
+	config     *config.Config
+	httpClient *http.Client
+	// endpoints are the ArgoCD API URLs the login goes to, shared with the API calls
+	endpoints       *upstream.Endpoints
 	tokenCache      *TokenCache
 	refreshMutex    sync.Mutex
 	refreshingToken bool
+
+	// refreshFailures counts consecutive failed background token checks
+	refreshFailures int
+	// refreshFailing is set once refreshFailures reaches the failure threshold
+	refreshFailing bool
+	// lastRefreshError is the redacted error of the latest failed background check
+	lastRefreshError string
+
+	// clock tells the time for token expiry and refresh checks; nil means the real clock
+	clock clock.Clock
+}
+
+// NewAuthService creates a new authentication service logging in at endpoints, which
+// should be those of the API calls so that both fail over together. Nil endpoints are
+// built from the ARGOCD_API_URL URLs of cfg. With TOKEN_CACHE_FILE set, it starts with
+// the token persisted by a previous run, if still valid.
+func NewAuthService(cfg *config.Config, endpoints *upstream.Endpoints) *AuthService {
+	return NewAuthServiceWithClock(cfg, endpoints, clock.Real)
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(cfg *config.Config) *AuthService {
-	return &AuthService{
-		config: cfg,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// NewAuthServiceWithClock creates an authentication service like NewAuthService whose
+// token expiry and refresh checks follow clk
+func NewAuthServiceWithClock(cfg *config.Config, endpoints *upstream.Endpoints, clk clock.Clock) *AuthService {
+	if endpoints == nil {
+		endpoints = upstream.NewEndpointsFor(cfg)
 	}
+	a := &AuthService{
+		config:     cfg,
+		httpClient: upstream.NewClient(cfg),
+		endpoints:  endpoints,
+		clock:      clk,
+	}
+	if cfg.TokenCacheFile != "" {
+		a.loadPersistedToken()
+	}
+	return a
 }
 
 // GetValidToken returns a valid ArgoCD token, refreshing if necessary
@@ -68,7 +101,16 @@ func (a *AuthService) GetValidToken(ctx context.Context) (string, error) {
 	}
 
 	// Refresh the token
-	return a.refreshToken(ctx)
+	token, err := a.refreshToken(ctx)
+	if err != nil {
+		events.Record(events.TokenRefreshFailed, "ArgoCD token refresh failed", map[string]string{"error": redact.String(err.Error())})
+	}
+	return token, err
+}
+
+// clk returns the clock of the service, defaulting to the real clock
+func (a *AuthService) clk() clock.Clock {
+	return clock.OrReal(a.clock)
 }
 
 // isTokenValid checks if the current token is valid and not expiring soon
@@ -79,7 +121,7 @@ func (a *AuthService) isTokenValid() bool {
 
 	// Refresh token 5 minutes before expiration
 	refreshTime := a.tokenCache.ExpiresAt.Add(-5 * time.Minute)
-	return time.Now().Before(refreshTime)
+	return a.clk().Now().Before(refreshTime)
 }
 
 // refreshToken obtains a new token from ArgoCD
@@ -89,11 +131,11 @@ func (a *AuthService) refreshToken(ctx context.Context) (string, error) {
 		a.refreshingToken = false
 	}()
 
-	start := time.Now()
+	start := a.clk().Now()
 	log.Println("Refreshing ArgoCD token...")
 
 	recordResult := func(result string) {
-		metrics.TokenRefreshDuration.Observe(time.Since(start).Seconds())
+		metrics.TokenRefreshDuration.Observe(a.clk().Since(start).Seconds())
 		metrics.TokenRefreshTotal.WithLabelValues(result).Inc()
 	}
 
@@ -109,18 +151,22 @@ func (a *AuthService) refreshToken(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("failed to marshal session request: %w", err)
 	}
 
-	// Create HTTP request
-	url := fmt.Sprintf("%s/session", a.config.ArgocdAPIURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		recordResult("failure")
-		return "", fmt.Errorf("failed to create session request: %w", err)
+	// Bound the login like any other ArgoCD call; the client has no overall timeout
+	if a.config.UpstreamTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.UpstreamTimeout)
+		defer cancel()
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	resp, err := a.httpClient.Do(req)
+	// Log in at the first ArgoCD URL that can be reached, which API calls then use as well
+	resp, err := a.endpoints.Call(ctx, func(baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/session", bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return a.httpClient.Do(req)
+	})
 	if err != nil {
 		recordResult("failure")
 		return "", fmt.Errorf("failed to execute session request: %w", err)
@@ -129,12 +175,12 @@ func (a *AuthService) refreshToken(ctx context.Context) (string, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		recordResult("failure")
-		return "", fmt.Errorf("ArgoCD authentication failed with status %d", resp.StatusCode)
+		return "", fmt.Errorf("ArgoCD authentication failed with status %d: %s", resp.StatusCode, redact.Body(upstream.ErrorBody(resp.Body)))
 	}
 
 	// Parse the response
 	var sessionResp types.ArgocdSessionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&sessionResp); err != nil {
+	if err := json.NewDecoder(upstream.LimitReader(resp.Body, a.config.UpstreamMaxBodyBytes)).Decode(&sessionResp); err != nil {
 		recordResult("failure")
 		return "", fmt.Errorf("failed to decode session response: %w", err)
 	}
@@ -144,17 +190,22 @@ func (a *AuthService) refreshToken(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("received empty token from ArgoCD")
 	}
 
+	// Mask the new token wherever it could appear in logs or error messages
+	redact.SetSecret("token", sessionResp.Token)
+
 	// Cache the new token
 	// ArgoCD tokens typically expire after 24 hours, but we'll use a conservative 23 hours
-	now := time.Now()
+	now := a.clk().Now()
 	a.tokenCache = &TokenCache{
 		Token:     sessionResp.Token,
 		ExpiresAt: now.Add(23 * time.Hour),
 		IssuedAt:  now,
 	}
+	a.persistToken()
 
 	recordResult("success")
 	log.Printf("Successfully refreshed ArgoCD token, expires at: %s", a.tokenCache.ExpiresAt.Format(time.RFC3339))
+	events.Record(events.TokenRefreshed, "ArgoCD token refreshed", map[string]string{"expiresAt": a.tokenCache.ExpiresAt.Format(time.RFC3339)})
 	return a.tokenCache.Token, nil
 }
 
@@ -166,6 +217,11 @@ func (a *AuthService) GetTokenStatus() map[string]interface{} {
 	status := map[string]interface{}{
 		"hasToken": false,
 		"isValid":  false,
+		"failing":  a.refreshFailing,
+	}
+	if a.refreshFailures > 0 {
+		status["consecutiveFailures"] = a.refreshFailures
+		status["lastError"] = a.lastRefreshError
 	}
 
 	if a.tokenCache != nil {
@@ -175,13 +231,17 @@ func (a *AuthService) GetTokenStatus() map[string]interface{} {
 		status["expiresAt"] = a.tokenCache.ExpiresAt.Format(time.RFC3339)
 
 		// Calculate time until expiration
-		timeUntilExpiry := time.Until(a.tokenCache.ExpiresAt)
+		timeUntilExpiry := a.tokenCache.ExpiresAt.Sub(a.clk().Now())
 		status["timeUntilExpiry"] = timeUntilExpiry.String()
 
 		// Check if token is expiring soon (within 5 minutes)
 		status["expiringSoon"] = timeUntilExpiry < 5*time.Minute
 	}
 
+	if a.config != nil && len(a.config.ArgocdProjectTokens) > 0 {
+		status["projectTokens"] = a.projectTokenStatus(a.clk().Now())
+	}
+
 	return status
 }
 
@@ -192,32 +252,49 @@ func (a *AuthService) InvalidateToken() {
 
 	log.Println("Invalidating cached ArgoCD token")
 	a.tokenCache = nil
+	a.removePersistedToken()
 }
 
 // StartTokenRefreshRoutine starts a background routine to automatically refresh tokens
 func (a *AuthService) StartTokenRefreshRoutine(ctx context.Context) {
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute) // Check every minute
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("Stopping token refresh routine")
-				return
-			case <-ticker.C:
-				// Try to get a valid token, which will trigger refresh if needed
-				if _, err := a.GetValidToken(ctx); err != nil {
-					log.Printf("Failed to refresh token in background routine: %v", err)
-				}
+	go a.RunTokenRefreshRoutine(ctx)
+}
+
+// RunTokenRefreshRoutine refreshes tokens in the background until ctx is cancelled,
+// checking every TOKEN_REFRESH_CHECK_INTERVAL. Consecutive failures are tracked and
+// reported through the token status and the alert webhook.
+func (a *AuthService) RunTokenRefreshRoutine(ctx context.Context) {
+	ticker := a.clk().NewTicker(a.refreshCheckInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping token refresh routine")
+			return
+		case <-ticker.C():
+			// Try to get a valid token, which will trigger refresh if needed
+			_, err := a.GetValidToken(ctx)
+			if ctx.Err() != nil {
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to refresh token in background routine: %v", err)
 			}
+			a.recordRefreshResult(ctx, err)
 		}
-	}()
+	}
 }
 
-// CreateAuthenticatedRequest creates an HTTP request with ArgoCD authentication
-func (a *AuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
-	token, err := a.GetValidToken(ctx)
+// CreateAuthenticatedRequest creates an HTTP request with ArgoCD authentication. project
+// is an optional hint naming the project the request targets: when a scoped token is
+// configured for it in ARGOCD_PROJECT_TOKENS that token is used, otherwise the session
+// token. Requests spanning projects pass "". With UPSTREAM_IMPERSONATION_HEADER set, the
+// caller named by WithCaller is sent in that header.
+func (a *AuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error) {
+	stopAuth := timing.Start(ctx, timing.PhaseAuth)
+	token, err := a.tokenForProject(ctx, project)
+	stopAuth()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get valid token: %w", err)
 	}
@@ -235,6 +312,11 @@ func (a *AuthService) CreateAuthenticatedRequest(ctx context.Context, method, ur
 	// Add ArgoCD authentication header
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("Content-Type", "application/json")
+	if header := a.config.UpstreamImpersonationHeader; header != "" {
+		if caller := CallerFromContext(ctx); caller != "" {
+			req.Header.Set(header, caller)
+		}
+	}
 
 	return req, nil
 }
@@ -2,15 +2,24 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"argocd-proxy/config"
+	"argocd-proxy/events"
+	"argocd-proxy/redact"
+	"argocd-proxy/testutils"
+	"argocd-proxy/timing"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
 )
 
 func TestNewAuthService(t *testing.T) {
@@ -20,7 +29,7 @@ func TestNewAuthService(t *testing.T) {
 		ArgocdPassword: "testpass",
 	}
 
-	authService := NewAuthService(cfg)
+	authService := NewAuthService(cfg, nil)
 
 	if authService.config != cfg {
 		t.Errorf("NewAuthService() config not set correctly")
@@ -28,58 +37,42 @@ func TestNewAuthService(t *testing.T) {
 	if authService.httpClient == nil {
 		t.Errorf("NewAuthService() httpClient not initialized")
 	}
-	if authService.httpClient.Timeout != 10*time.Second {
-		t.Errorf("NewAuthService() httpClient timeout = %v, want %v", authService.httpClient.Timeout, 10*time.Second)
+	if authService.httpClient.Timeout != 0 {
+		t.Errorf("NewAuthService() httpClient timeout = %v, want none so that calls follow their context", authService.httpClient.Timeout)
+	}
+	if upstream.BaseTransport(authService.httpClient.Transport) != upstream.Default() {
+		t.Errorf("NewAuthService() httpClient does not use the shared upstream transport")
 	}
 }
 
 func TestIsTokenValid(t *testing.T) {
-	authService := &AuthService{}
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	authService := &AuthService{clock: clk}
 
 	tests := []struct {
-		name        string
-		tokenCache  *TokenCache
-		currentTime time.Time
-		expected    bool
+		name string
+		// expiresIn places the token expiry relative to the fake clock; nil tokens have none
+		expiresIn *time.Duration
+		expected  bool
 	}{
-		{
-			name:        "no token cache",
-			tokenCache:  nil,
-			currentTime: time.Now(),
-			expected:    false,
-		},
-		{
-			name: "token valid (not expiring soon)",
-			tokenCache: &TokenCache{
-				Token:     "valid-token",
-				ExpiresAt: time.Now().Add(10 * time.Minute),
-				IssuedAt:  time.Now().Add(-1 * time.Hour),
-			},
-			expected: true,
-		},
-		{
-			name: "token expiring soon (within 5 minutes)",
-			tokenCache: &TokenCache{
-				Token:     "expiring-token",
-				ExpiresAt: time.Now().Add(3 * time.Minute),
-				IssuedAt:  time.Now().Add(-1 * time.Hour),
-			},
-			expected: false,
-		},
-		{
-			name: "token expired",
-			tokenCache: &TokenCache{
-				Token:     "expired-token",
-				ExpiresAt: time.Now().Add(-1 * time.Minute),
-				IssuedAt:  time.Now().Add(-2 * time.Hour),
-			},
-			expected: false,
-		},
+		{name: "no token cache", expected: false},
+		{name: "token valid (not expiring soon)", expiresIn: durationPtr(10 * time.Minute), expected: true},
+		{name: "token expiring soon (within 5 minutes)", expiresIn: durationPtr(3 * time.Minute), expected: false},
+		{name: "token expired", expiresIn: durationPtr(-time.Minute), expected: false},
+		{name: "token expiring exactly at the refresh margin", expiresIn: durationPtr(5 * time.Minute), expected: false},
+		{name: "token expiring just after the refresh margin", expiresIn: durationPtr(5*time.Minute + time.Nanosecond), expected: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			authService.tokenCache = tt.tokenCache
+			authService.tokenCache = nil
+			if tt.expiresIn != nil {
+				authService.tokenCache = &TokenCache{
+					Token:     "token",
+					ExpiresAt: clk.Now().Add(*tt.expiresIn),
+					IssuedAt:  clk.Now().Add(-time.Hour),
+				}
+			}
 			result := authService.isTokenValid()
 			if result != tt.expected {
 				t.Errorf("isTokenValid() = %v, want %v", result, tt.expected)
@@ -88,6 +81,58 @@ func TestIsTokenValid(t *testing.T) {
 	}
 }
 
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func TestTokenExpiresAsClockAdvances(t *testing.T) {
+	var logins int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: fmt.Sprintf("token-%d", logins)})
+	}))
+	defer server.Close()
+
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	authService := NewAuthServiceWithClock(&config.Config{
+		ArgocdAPIURL:   server.URL,
+		ArgocdUsername: "testuser",
+		ArgocdPassword: "testpass",
+	}, nil, clk)
+
+	token := func() string {
+		t.Helper()
+		token, err := authService.GetValidToken(context.Background())
+		if err != nil {
+			t.Fatalf("GetValidToken() error = %v", err)
+		}
+		return token
+	}
+
+	if got := token(); got != "token-1" {
+		t.Fatalf("first token = %q, want token-1", got)
+	}
+	if expiresAt := authService.tokenCache.ExpiresAt; !expiresAt.Equal(clk.Now().Add(23 * time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want 23 hours after the fake now", expiresAt)
+	}
+
+	// The token is reused until the refresh margin five minutes before expiry
+	clk.Advance(23*time.Hour - 5*time.Minute - time.Nanosecond)
+	if got := token(); got != "token-1" {
+		t.Errorf("token just before the refresh margin = %q, want token-1", got)
+	}
+	if status := authService.GetTokenStatus(); status["expiringSoon"] != false {
+		t.Errorf("expiringSoon just before the margin = %v, want false", status["expiringSoon"])
+	}
+	clk.Advance(time.Nanosecond)
+	if status := authService.GetTokenStatus(); status["isValid"] != false || status["timeUntilExpiry"] != (5*time.Minute).String() {
+		t.Errorf("status at the margin = %v, want an invalid token expiring in 5m0s", status)
+	}
+	if got := token(); got != "token-2" {
+		t.Errorf("token at the refresh margin = %q, want token-2", got)
+	}
+}
+
 func TestRefreshToken(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -161,7 +206,7 @@ func TestRefreshToken(t *testing.T) {
 				ArgocdPassword: "testpass",
 			}
 
-			authService := NewAuthService(cfg)
+			authService := NewAuthService(cfg, nil)
 			ctx := context.Background()
 
 			token, err := authService.refreshToken(ctx)
@@ -252,7 +297,7 @@ func TestGetValidToken(t *testing.T) {
 				cfg.ArgocdAPIURL = server.URL
 			}
 
-			authService := NewAuthService(cfg)
+			authService := NewAuthService(cfg, nil)
 			authService.tokenCache = tt.initialTokenCache
 
 			ctx := context.Background()
@@ -296,7 +341,7 @@ func TestConcurrentTokenRefresh(t *testing.T) {
 		ArgocdPassword: "testpass",
 	}
 
-	authService := NewAuthService(cfg)
+	authService := NewAuthService(cfg, nil)
 	ctx := context.Background()
 
 	// Launch multiple concurrent token requests
@@ -443,12 +488,13 @@ func TestCreateAuthenticatedRequest(t *testing.T) {
 	defer server.Close()
 
 	cfg := &config.Config{
-		ArgocdAPIURL:   server.URL,
-		ArgocdUsername: "testuser",
-		ArgocdPassword: "testpass",
+		ArgocdAPIURL:        server.URL,
+		ArgocdUsername:      "testuser",
+		ArgocdPassword:      "testpass",
+		ArgocdProjectTokens: map[string]string{"payments": "payments-token"},
 	}
 
-	authService := NewAuthService(cfg)
+	authService := NewAuthService(cfg, nil)
 	ctx := context.Background()
 
 	tests := []struct {
@@ -456,6 +502,7 @@ func TestCreateAuthenticatedRequest(t *testing.T) {
 		method         string
 		url            string
 		body           []byte
+		project        string
 		expectedMethod string
 		expectedAuth   string
 	}{
@@ -475,11 +522,27 @@ func TestCreateAuthenticatedRequest(t *testing.T) {
 			expectedMethod: "POST",
 			expectedAuth:   "Bearer test-token-123",
 		},
+		{
+			name:           "project with a scoped token",
+			method:         "GET",
+			url:            "https://api.example.com/applications/web",
+			project:        "payments",
+			expectedMethod: "GET",
+			expectedAuth:   "Bearer payments-token",
+		},
+		{
+			name:           "project without a scoped token",
+			method:         "GET",
+			url:            "https://api.example.com/applications/api",
+			project:        "platform",
+			expectedMethod: "GET",
+			expectedAuth:   "Bearer test-token-123",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, err := authService.CreateAuthenticatedRequest(ctx, tt.method, tt.url, tt.body)
+			req, err := authService.CreateAuthenticatedRequest(ctx, tt.method, tt.url, tt.body, tt.project)
 			if err != nil {
 				t.Errorf("CreateAuthenticatedRequest() error = %v", err)
 				return
@@ -506,6 +569,134 @@ func TestCreateAuthenticatedRequest(t *testing.T) {
 	}
 }
 
+func TestCreateAuthenticatedRequestRecordsAuthTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "test-token-123"})
+	}))
+	defer server.Close()
+
+	authService := NewAuthService(&config.Config{
+		ArgocdAPIURL:   server.URL,
+		ArgocdUsername: "testuser",
+		ArgocdPassword: "testpass",
+	}, nil)
+
+	ctx, recorder := timing.NewContext(context.Background())
+	if _, err := authService.CreateAuthenticatedRequest(ctx, "GET", server.URL+"/projects", nil, ""); err != nil {
+		t.Fatalf("CreateAuthenticatedRequest() error = %v", err)
+	}
+	if got := recorder.Phase(timing.PhaseAuth); got < 5*time.Millisecond {
+		t.Errorf("auth time = %v, want at least the 5ms session request", got)
+	}
+}
+
+func TestCreateAuthenticatedRequestImpersonation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "test-token-123"})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		header   string
+		caller   string
+		wantSent string
+	}{
+		{name: "caller named", header: "X-Argocd-Impersonate-User", caller: "statuspage", wantSent: "statuspage"},
+		{name: "no caller", header: "X-Argocd-Impersonate-User"},
+		{name: "invalid caller ignored", header: "X-Argocd-Impersonate-User", caller: "evil\r\nX-Injected: 1"},
+		{name: "impersonation disabled", caller: "statuspage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := NewAuthService(&config.Config{
+				ArgocdAPIURL:                server.URL,
+				ArgocdUsername:              "testuser",
+				ArgocdPassword:              "testpass",
+				UpstreamImpersonationHeader: tt.header,
+			}, nil)
+			ctx := context.Background()
+			if tt.caller != "" {
+				ctx = WithCaller(ctx, tt.caller)
+			}
+
+			req, err := authService.CreateAuthenticatedRequest(ctx, "GET", server.URL+"/projects", nil, "")
+			if err != nil {
+				t.Fatalf("CreateAuthenticatedRequest() error = %v", err)
+			}
+			if got := req.Header.Get("X-Argocd-Impersonate-User"); got != tt.wantSent {
+				t.Errorf("impersonation header = %q, want %q", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+// testJWT builds an unsigned JWT carrying the given claims
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestGetTokenStatusProjectTokens(t *testing.T) {
+	now := time.Now()
+	cfg := &config.Config{ArgocdProjectTokens: map[string]string{
+		"payments": testJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, now.Add(-time.Hour).Unix(), now.Add(24*time.Hour).Unix())),
+		"platform": testJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, now.Add(-48*time.Hour).Unix(), now.Add(-time.Hour).Unix())),
+		"legacy":   testJWT(fmt.Sprintf(`{"iat":%d}`, now.Add(-time.Hour).Unix())),
+		"opaque":   "not-a-jwt",
+	}}
+	authService := NewAuthService(cfg, nil)
+
+	statuses, ok := authService.GetTokenStatus()["projectTokens"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("GetTokenStatus() projectTokens missing")
+	}
+	if len(statuses) != len(cfg.ArgocdProjectTokens) {
+		t.Fatalf("GetTokenStatus() reports %d project tokens, want %d", len(statuses), len(cfg.ArgocdProjectTokens))
+	}
+
+	tests := []struct {
+		project      string
+		wantValid    bool
+		wantExpiry   bool
+		wantOpaque   bool
+		wantIssuedAt bool
+	}{
+		{project: "payments", wantValid: true, wantExpiry: true, wantIssuedAt: true},
+		{project: "platform", wantValid: false, wantExpiry: true, wantIssuedAt: true},
+		{project: "legacy", wantValid: true, wantIssuedAt: true},
+		{project: "opaque", wantValid: true, wantOpaque: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			status := statuses[tt.project].(map[string]interface{})
+			if status["isValid"] != tt.wantValid {
+				t.Errorf("isValid = %v, want %v", status["isValid"], tt.wantValid)
+			}
+			if _, ok := status["expiresAt"]; ok != tt.wantExpiry {
+				t.Errorf("expiresAt present = %v, want %v", ok, tt.wantExpiry)
+			}
+			if _, ok := status["issuedAt"]; ok != tt.wantIssuedAt {
+				t.Errorf("issuedAt present = %v, want %v", ok, tt.wantIssuedAt)
+			}
+			if (status["format"] == "opaque") != tt.wantOpaque {
+				t.Errorf("format = %v, want opaque %v", status["format"], tt.wantOpaque)
+			}
+			for _, value := range status {
+				if value == cfg.ArgocdProjectTokens[tt.project] {
+					t.Errorf("status exposes the token")
+				}
+			}
+		})
+	}
+
+	if _, ok := NewAuthService(&config.Config{}, nil).GetTokenStatus()["projectTokens"]; ok {
+		t.Errorf("GetTokenStatus() reports projectTokens without any configured")
+	}
+}
+
 func TestStartTokenRefreshRoutine(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -521,13 +712,14 @@ func TestStartTokenRefreshRoutine(t *testing.T) {
 		ArgocdPassword: "testpass",
 	}
 
-	authService := NewAuthService(cfg)
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	authService := NewAuthServiceWithClock(cfg, nil, clk)
 
 	// Set an expiring token to trigger refresh
 	authService.tokenCache = &TokenCache{
 		Token:     "expiring-token",
-		ExpiresAt: time.Now().Add(2 * time.Minute), // Will trigger refresh
-		IssuedAt:  time.Now().Add(-1 * time.Hour),
+		ExpiresAt: clk.Now().Add(2 * time.Minute), // Will trigger refresh
+		IssuedAt:  clk.Now().Add(-1 * time.Hour),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -536,13 +728,20 @@ func TestStartTokenRefreshRoutine(t *testing.T) {
 	// Start the refresh routine
 	authService.StartTokenRefreshRoutine(ctx)
 
-	// Wait a bit for the routine to potentially run
-	time.Sleep(500 * time.Millisecond)
-
-	// The routine should have refreshed the token
-	status := authService.GetTokenStatus()
-	if hasToken, ok := status["hasToken"].(bool); !ok || !hasToken {
-		t.Errorf("Token refresh routine should have maintained a token")
+	// Each advance by the default check interval triggers a check once the routine's
+	// ticker exists
+	refreshed := func() bool {
+		token, _ := authService.GetTokenStatus()["hasToken"].(bool)
+		authService.refreshMutex.Lock()
+		defer authService.refreshMutex.Unlock()
+		return token && authService.tokenCache.Token == "routine-token"
+	}
+	for !refreshed() {
+		if ctx.Err() != nil {
+			t.Fatal("Token refresh routine did not refresh the expiring token")
+		}
+		clk.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
 	}
 }
 
@@ -562,7 +761,7 @@ func BenchmarkGetValidToken(b *testing.B) {
 		ArgocdPassword: "testpass",
 	}
 
-	authService := NewAuthService(cfg)
+	authService := NewAuthService(cfg, nil)
 
 	// Set a valid token to avoid HTTP calls during benchmark
 	authService.tokenCache = &TokenCache{
@@ -593,3 +792,139 @@ func BenchmarkGetTokenStatus(b *testing.B) {
 		authService.GetTokenStatus()
 	}
 }
+
+func TestRefreshTokenRedactsSecrets(t *testing.T) {
+	previous := redact.Default()
+	defer redact.SetDefault(previous)
+
+	cfg := &config.Config{
+		ArgocdUsername: "deploy-bot",
+		ArgocdPassword: "hunter2-hunter2",
+	}
+	redactor := redact.New(redact.DefaultMaxBodyLength)
+	redactor.SetSecret("username", cfg.ArgocdUsername)
+	redactor.SetSecret("password", cfg.ArgocdPassword)
+	redact.SetDefault(redactor)
+	previousEvents := events.Default()
+	defer events.SetDefault(previousEvents)
+	events.SetDefault(events.New(10))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid credentials for deploy-bot with password hunter2-hunter2"}`))
+	}))
+	defer server.Close()
+	cfg.ArgocdAPIURL = server.URL
+
+	_, err := NewAuthService(cfg, nil).GetValidToken(context.Background())
+	if err == nil {
+		t.Fatal("GetValidToken() expected error for rejected credentials")
+	}
+	for _, secret := range []string{cfg.ArgocdUsername, cfg.ArgocdPassword} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("GetValidToken() error leaked %q: %v", secret, err)
+		}
+	}
+	if !strings.Contains(err.Error(), "status 401") || !strings.Contains(err.Error(), redact.Mask) {
+		t.Errorf("GetValidToken() error = %v, want status and redacted body", err)
+	}
+
+	recorded := events.Default().Recent(0).Events
+	if len(recorded) != 1 || recorded[0].Type != events.TokenRefreshFailed {
+		t.Fatalf("events = %+v, want one token refresh failure", recorded)
+	}
+	if message := recorded[0].Attrs["error"]; strings.Contains(message, cfg.ArgocdPassword) || !strings.Contains(message, "status 401") {
+		t.Errorf("event error = %q, want the redacted failure", message)
+	}
+}
+
+func TestRefreshTokenRegistersTokenSecret(t *testing.T) {
+	previous := redact.Default()
+	defer redact.SetDefault(previous)
+	redact.SetDefault(redact.New(redact.DefaultMaxBodyLength))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "session-token-value"})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, ArgocdUsername: "user", ArgocdPassword: "pass"}
+	if _, err := NewAuthService(cfg, nil).GetValidToken(context.Background()); err != nil {
+		t.Fatalf("GetValidToken() unexpected error: %v", err)
+	}
+
+	if got := redact.String("Bearer session-token-value"); got != "Bearer "+redact.Mask {
+		t.Errorf("redact.String() = %q, want the session token masked", got)
+	}
+}
+
+func TestRefreshTokenBoundsSessionResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		prefix string
+		want   error
+	}{
+		{name: "endless session response", status: http.StatusOK, prefix: `{"token":"`, want: upstream.ErrUpstreamTooLarge},
+		{name: "endless error response", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.prefix))
+				chunk := []byte(strings.Repeat("a", 1024))
+				for r.Context().Err() == nil {
+					if _, err := w.Write(chunk); err != nil {
+						return
+					}
+				}
+			}))
+			defer server.Close()
+
+			authService := NewAuthService(&config.Config{
+				ArgocdAPIURL:         server.URL,
+				ArgocdUsername:       "testuser",
+				ArgocdPassword:       "testpass",
+				UpstreamMaxBodyBytes: 64 * 1024,
+			}, nil)
+
+			_, err := authService.refreshToken(context.Background())
+			if err == nil {
+				t.Fatal("refreshToken() error = nil, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("refreshToken() error = %v, want %v", err, tt.want)
+			}
+			if len(err.Error()) > 2*upstream.MaxErrorBodyBytes {
+				t.Errorf("refreshToken() error is %d bytes long, want the body truncated", len(err.Error()))
+			}
+		})
+	}
+}
+
+func TestRefreshTokenFailsOver(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "secondary-token"})
+	}))
+	defer secondary.Close()
+
+	cfg := &config.Config{
+		ArgocdAPIURL:               down.URL,
+		ArgocdAPIURLs:              []string{down.URL, secondary.URL},
+		ArgocdPrimaryProbeInterval: time.Minute,
+		ArgocdUsername:             "testuser",
+		ArgocdPassword:             "testpass",
+	}
+	endpoints := upstream.NewEndpointsFor(cfg)
+	token, err := NewAuthService(cfg, endpoints).refreshToken(context.Background())
+	if err != nil || token != "secondary-token" {
+		t.Fatalf("refreshToken() with the primary down = %q, %v, want the secondary's token", token, err)
+	}
+	if got := endpoints.Active(); got != secondary.URL {
+		t.Errorf("active ArgoCD URL = %s, want the secondary, shared with the API calls", got)
+	}
+}
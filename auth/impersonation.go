@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// callerKey is the context key under which the identity of the calling client is stored
+type callerKey struct{}
+
+// maxCallerLength caps the identity copied into UPSTREAM_IMPERSONATION_HEADER
+const maxCallerLength = 256
+
+// WithCaller returns a context naming the client a request is made for. Upstream requests
+// created with it carry the identity in UPSTREAM_IMPERSONATION_HEADER when that is set.
+// Identities that are empty, too long or not valid header values are ignored.
+func WithCaller(ctx context.Context, identity string) context.Context {
+	if identity == "" || len(identity) > maxCallerLength || !httpguts.ValidHeaderFieldValue(identity) {
+		return ctx
+	}
+	return context.WithValue(ctx, callerKey{}, identity)
+}
+
+// CallerFromContext returns the client identity carried by ctx, or ""
+func CallerFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(callerKey{}).(string)
+	return identity
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// projectTokenClaims are the JWT claims reported for project-scoped tokens
+type projectTokenClaims struct {
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// tokenForProject returns the scoped token configured for project, falling back to the
+// session token when there is none
+func (a *AuthService) tokenForProject(ctx context.Context, project string) (string, error) {
+	if project != "" {
+		if token, ok := a.config.ArgocdProjectTokens[project]; ok {
+			return token, nil
+		}
+	}
+	return a.GetValidToken(ctx)
+}
+
+// projectTokenStatus describes every configured project-scoped token. ArgoCD project
+// tokens are JWTs; their issue and expiry times are read from the claims without
+// verifying the signature, which is ArgoCD's job. Tokens without an expiry never expire.
+func (a *AuthService) projectTokenStatus(now time.Time) map[string]interface{} {
+	statuses := make(map[string]interface{}, len(a.config.ArgocdProjectTokens))
+	for project, token := range a.config.ArgocdProjectTokens {
+		status := map[string]interface{}{
+			"hasToken": true,
+			"isValid":  true,
+		}
+
+		claims, ok := decodeTokenClaims(token)
+		if !ok {
+			status["format"] = "opaque"
+			statuses[project] = status
+			continue
+		}
+		if claims.IssuedAt > 0 {
+			status["issuedAt"] = time.Unix(claims.IssuedAt, 0).UTC().Format(time.RFC3339)
+		}
+		if claims.ExpiresAt > 0 {
+			expiresAt := time.Unix(claims.ExpiresAt, 0).UTC()
+			timeUntilExpiry := expiresAt.Sub(now)
+			status["expiresAt"] = expiresAt.Format(time.RFC3339)
+			status["timeUntilExpiry"] = timeUntilExpiry.String()
+			status["isValid"] = timeUntilExpiry > 0
+			status["expiringSoon"] = timeUntilExpiry < 5*time.Minute
+		}
+		statuses[project] = status
+	}
+	return statuses
+}
+
+// decodeTokenClaims reads the payload of a JWT, reporting false for other token formats
+func decodeTokenClaims(token string) (projectTokenClaims, bool) {
+	var claims projectTokenClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
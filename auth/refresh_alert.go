@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"argocd-proxy/redact"
+	"argocd-proxy/upstream"
+)
+
+// webhookTimeout bounds a call to ALERT_WEBHOOK_URL
+const webhookTimeout = 10 * time.Second
+
+// TokenRefreshAlert is the JSON body POSTed to ALERT_WEBHOOK_URL once background token
+// refreshes have failed TOKEN_REFRESH_FAILURE_THRESHOLD times in a row
+type TokenRefreshAlert struct {
+	Event               string `json:"event"`
+	Error               string `json:"error"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Timestamp           string `json:"timestamp"`
+}
+
+// tokenRefreshFailingEvent names the alert sent when token refreshes start failing
+const tokenRefreshFailingEvent = "token_refresh_failing"
+
+// refreshCheckInterval returns how often the background routine checks the token
+func (a *AuthService) refreshCheckInterval() time.Duration {
+	if a.config == nil || a.config.TokenRefreshCheckInterval <= 0 {
+		return time.Minute
+	}
+	return a.config.TokenRefreshCheckInterval
+}
+
+// failureThreshold returns the consecutive failures after which the token is failing
+func (a *AuthService) failureThreshold() int {
+	if a.config == nil || a.config.TokenRefreshFailureThreshold < 1 {
+		return 1
+	}
+	return a.config.TokenRefreshFailureThreshold
+}
+
+// recordRefreshResult tracks the outcome of a background token check. Reaching the
+// failure threshold marks the token as failing and, once per failure streak, calls the
+// alert webhook; a success clears both.
+func (a *AuthService) recordRefreshResult(ctx context.Context, err error) {
+	a.refreshMutex.Lock()
+	if err == nil {
+		if a.refreshFailing {
+			log.Printf("ArgoCD token refresh recovered after %d consecutive failures", a.refreshFailures)
+		}
+		a.refreshFailures = 0
+		a.refreshFailing = false
+		a.lastRefreshError = ""
+		a.refreshMutex.Unlock()
+		return
+	}
+
+	a.refreshFailures++
+	a.lastRefreshError = redact.String(err.Error())
+	alert := a.refreshFailures == a.failureThreshold()
+	if alert {
+		a.refreshFailing = true
+	}
+	failures, message := a.refreshFailures, a.lastRefreshError
+	a.refreshMutex.Unlock()
+
+	if alert {
+		log.Printf("WARNING: ArgoCD token refresh failed %d consecutive times: %s", failures, message)
+		a.sendRefreshAlert(ctx, failures, message)
+	}
+}
+
+// sendRefreshAlert POSTs a TokenRefreshAlert to ALERT_WEBHOOK_URL, when set. Delivery
+// failures are logged and not retried.
+func (a *AuthService) sendRefreshAlert(ctx context.Context, failures int, message string) {
+	if a.config == nil || a.config.AlertWebhookURL == "" {
+		return
+	}
+	if err := a.postAlert(ctx, TokenRefreshAlert{
+		Event:               tokenRefreshFailingEvent,
+		Error:               message,
+		ConsecutiveFailures: failures,
+		Timestamp:           a.clk().Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("WARNING: Failed to deliver token refresh alert: %s", redact.String(err.Error()))
+	}
+}
+
+// postAlert sends alert to the webhook, expecting a 2xx answer
+func (a *AuthService) postAlert(ctx context.Context, alert TokenRefreshAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.AlertWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute alert request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, upstream.MaxErrorBodyBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
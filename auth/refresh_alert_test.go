@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func TestTokenRefreshFailureAlerting(t *testing.T) {
+	var sessionBroken atomic.Bool
+	sessionBroken.Store(true)
+	session := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionBroken.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("invalid username or password"))
+			return
+		}
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "recovered-token"})
+	}))
+	defer session.Close()
+
+	alerts := make(chan TokenRefreshAlert, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert TokenRefreshAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("alert sent as %s %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		alerts <- alert
+	}))
+	defer webhook.Close()
+
+	authService := NewAuthService(&config.Config{
+		ArgocdAPIURL:                 session.URL,
+		ArgocdUsername:               "testuser",
+		ArgocdPassword:               "testpass",
+		TokenRefreshCheckInterval:    10 * time.Millisecond,
+		TokenRefreshFailureThreshold: 3,
+		AlertWebhookURL:              webhook.URL,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go authService.RunTokenRefreshRoutine(ctx)
+
+	select {
+	case alert := <-alerts:
+		if alert.Event != tokenRefreshFailingEvent {
+			t.Errorf("alert event = %q, want %q", alert.Event, tokenRefreshFailingEvent)
+		}
+		if alert.ConsecutiveFailures != 3 {
+			t.Errorf("alert consecutiveFailures = %d, want 3", alert.ConsecutiveFailures)
+		}
+		if alert.Error == "" || alert.Timestamp == "" {
+			t.Errorf("alert = %+v, want the error and timestamp set", alert)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no alert received after repeated refresh failures")
+	}
+
+	status := authService.GetTokenStatus()
+	if failing, _ := status["failing"].(bool); !failing {
+		t.Errorf("tokenStatus.failing = %v, want true", status["failing"])
+	}
+
+	// The alert is sent once per failure streak, not on every further failure
+	time.Sleep(50 * time.Millisecond)
+	if len(alerts) != 0 {
+		t.Errorf("received %d further alerts during the same failure streak", len(alerts))
+	}
+
+	sessionBroken.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status = authService.GetTokenStatus()
+		if failing, _ := status["failing"].(bool); !failing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tokenStatus.failing still set after a successful refresh")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := status["consecutiveFailures"]; ok {
+		t.Errorf("tokenStatus.consecutiveFailures = %v after recovery, want it reset", status["consecutiveFailures"])
+	}
+}
+
+func TestRecordRefreshResultWithoutWebhook(t *testing.T) {
+	authService := &AuthService{config: &config.Config{TokenRefreshFailureThreshold: 2}}
+	errTest := errors.New("session login failed")
+
+	authService.recordRefreshResult(context.Background(), errTest)
+	if status := authService.GetTokenStatus(); status["failing"] != false || status["consecutiveFailures"] != 1 {
+		t.Errorf("after one failure status = %v, want not failing with 1 failure", status)
+	}
+
+	authService.recordRefreshResult(context.Background(), errTest)
+	if status := authService.GetTokenStatus(); status["failing"] != true || status["consecutiveFailures"] != 2 {
+		t.Errorf("after two failures status = %v, want failing with 2 failures", status)
+	}
+
+	authService.recordRefreshResult(context.Background(), nil)
+	if status := authService.GetTokenStatus(); status["failing"] != false {
+		t.Errorf("after a success status = %v, want not failing", status)
+	}
+}
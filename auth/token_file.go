@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"argocd-proxy/redact"
+)
+
+// tokenFileMode keeps the persisted token readable by the proxy's user only
+const tokenFileMode = 0o600
+
+// persistedToken is the on-disk form of the session token
+type persistedToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	IssuedAt  time.Time `json:"issuedAt"`
+}
+
+// loadPersistedToken adopts the session token persisted in TOKEN_CACHE_FILE, so that a
+// restart does not log in to ArgoCD again. Missing, corrupt and expired files are ignored.
+func (a *AuthService) loadPersistedToken() {
+	cache, err := readTokenFile(a.config.TokenCacheFile, a.config.TokenCacheKey)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("WARNING: Ignoring token cache file %s: %v", a.config.TokenCacheFile, err)
+		}
+		return
+	}
+
+	a.tokenCache = cache
+	if !a.isTokenValid() {
+		a.tokenCache = nil
+		log.Printf("Ignoring token cache file %s: token expires at %s", a.config.TokenCacheFile, cache.ExpiresAt.Format(time.RFC3339))
+		return
+	}
+
+	redact.SetSecret("token", cache.Token)
+	log.Printf("Loaded ArgoCD token from %s, expires at: %s", a.config.TokenCacheFile, cache.ExpiresAt.Format(time.RFC3339))
+}
+
+// persistToken writes the cached session token to TOKEN_CACHE_FILE, when set. Failures
+// are logged: the token stays usable in memory.
+func (a *AuthService) persistToken() {
+	if a.config == nil || a.config.TokenCacheFile == "" || a.tokenCache == nil {
+		return
+	}
+	if err := writeTokenFile(a.config.TokenCacheFile, a.config.TokenCacheKey, a.tokenCache); err != nil {
+		log.Printf("WARNING: Failed to persist ArgoCD token to %s: %v", a.config.TokenCacheFile, err)
+	}
+}
+
+// removePersistedToken deletes TOKEN_CACHE_FILE, when set, so that a token ArgoCD
+// rejected is not adopted again after a restart
+func (a *AuthService) removePersistedToken() {
+	if a.config == nil || a.config.TokenCacheFile == "" {
+		return
+	}
+	if err := os.Remove(a.config.TokenCacheFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("WARNING: Failed to remove token cache file %s: %v", a.config.TokenCacheFile, err)
+	}
+}
+
+// writeTokenFile atomically replaces path with the token, encrypted when key is set. The
+// file is created with owner-only permissions.
+func writeTokenFile(path, key string, cache *TokenCache) error {
+	data, err := json.Marshal(persistedToken{
+		Token:     cache.Token,
+		ExpiresAt: cache.ExpiresAt,
+		IssuedAt:  cache.IssuedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	if key != "" {
+		if data, err = encryptToken(key, data); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".token-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(tokenFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to restrict file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace token cache file: %w", err)
+	}
+	return nil
+}
+
+// readTokenFile reads the token persisted in path, decrypting it when key is set
+func readTokenFile(path, key string) (*TokenCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if data, err = decryptToken(key, data); err != nil {
+			return nil, err
+		}
+	}
+
+	var persisted persistedToken
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if persisted.Token == "" {
+		return nil, fmt.Errorf("file holds no token")
+	}
+	return &TokenCache{
+		Token:     persisted.Token,
+		ExpiresAt: persisted.ExpiresAt,
+		IssuedAt:  persisted.IssuedAt,
+	}, nil
+}
+
+// tokenCipher returns AES-256-GCM keyed by the SHA-256 digest of key, so that
+// TOKEN_CACHE_KEY may be any string
+func tokenCipher(key string) (cipher.AEAD, error) {
+	digest := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptToken seals data, prefixed with its random nonce
+func encryptToken(key string, data []byte) ([]byte, error) {
+	aead, err := tokenCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// decryptToken opens data sealed by encryptToken, failing when the key does not match or
+// the file was tampered with
+func decryptToken(key string, data []byte) ([]byte, error) {
+	aead, err := tokenCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt token: file too short")
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return plain, nil
+}
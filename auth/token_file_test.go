@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func TestTokenFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "plain", key: ""},
+		{name: "encrypted", key: "token-cache-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token.json")
+			now := time.Now().Truncate(time.Second)
+			cache := &TokenCache{Token: "persisted-token-123", ExpiresAt: now.Add(time.Hour), IssuedAt: now}
+
+			if err := writeTokenFile(path, tt.key, cache); err != nil {
+				t.Fatalf("writeTokenFile() error = %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if mode := info.Mode().Perm(); mode != tokenFileMode {
+				t.Errorf("file mode = %o, want %o", mode, tokenFileMode)
+			}
+			data, _ := os.ReadFile(path)
+			if encrypted := !strings.Contains(string(data), cache.Token); encrypted != (tt.key != "") {
+				t.Errorf("token stored encrypted = %v, want %v", encrypted, tt.key != "")
+			}
+
+			got, err := readTokenFile(path, tt.key)
+			if err != nil {
+				t.Fatalf("readTokenFile() error = %v", err)
+			}
+			if got.Token != cache.Token || !got.ExpiresAt.Equal(cache.ExpiresAt) || !got.IssuedAt.Equal(cache.IssuedAt) {
+				t.Errorf("readTokenFile() = %+v, want %+v", got, cache)
+			}
+		})
+	}
+}
+
+func TestReadTokenFileRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "encrypted.json")
+	if err := writeTokenFile(encrypted, "right-key", &TokenCache{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("writeTokenFile() error = %v", err)
+	}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+		key  string
+	}{
+		{name: "missing", path: filepath.Join(dir, "missing.json")},
+		{name: "corrupt", path: write("corrupt.json", "{not json")},
+		{name: "no token", path: write("empty.json", `{"expiresAt":"2030-01-01T00:00:00Z"}`)},
+		{name: "wrong key", path: encrypted, key: "wrong-key"},
+		{name: "encrypted without key", path: encrypted},
+		{name: "plain with key", path: write("plain.json", `{"token":"t"}`), key: "right-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := readTokenFile(tt.path, tt.key); err == nil {
+				t.Errorf("readTokenFile() = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestNewAuthServiceLoadsPersistedToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		persisted  *TokenCache
+		content    string
+		wantLogins int32
+		wantToken  string
+	}{
+		{
+			name:       "valid token is reused",
+			persisted:  &TokenCache{Token: "persisted-token", ExpiresAt: time.Now().Add(time.Hour), IssuedAt: time.Now()},
+			wantLogins: 0,
+			wantToken:  "persisted-token",
+		},
+		{
+			name:       "expired token is ignored",
+			persisted:  &TokenCache{Token: "persisted-token", ExpiresAt: time.Now().Add(-time.Hour), IssuedAt: time.Now().Add(-24 * time.Hour)},
+			wantLogins: 1,
+			wantToken:  "fresh-token",
+		},
+		{
+			name:       "token expiring soon is ignored",
+			persisted:  &TokenCache{Token: "persisted-token", ExpiresAt: time.Now().Add(time.Minute), IssuedAt: time.Now()},
+			wantLogins: 1,
+			wantToken:  "fresh-token",
+		},
+		{
+			name:       "corrupt file is ignored",
+			content:    "garbage",
+			wantLogins: 1,
+			wantToken:  "fresh-token",
+		},
+		{
+			name:       "missing file",
+			wantLogins: 1,
+			wantToken:  "fresh-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logins atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logins.Add(1)
+				json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "fresh-token"})
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{
+				ArgocdAPIURL:   server.URL,
+				ArgocdUsername: "testuser",
+				ArgocdPassword: "testpass",
+				TokenCacheFile: filepath.Join(t.TempDir(), "token.json"),
+				TokenCacheKey:  "token-cache-key",
+			}
+			if tt.persisted != nil {
+				if err := writeTokenFile(cfg.TokenCacheFile, cfg.TokenCacheKey, tt.persisted); err != nil {
+					t.Fatalf("writeTokenFile() error = %v", err)
+				}
+			}
+			if tt.content != "" {
+				os.WriteFile(cfg.TokenCacheFile, []byte(tt.content), 0o600)
+			}
+
+			token, err := NewAuthService(cfg, nil).GetValidToken(context.Background())
+			if err != nil {
+				t.Fatalf("GetValidToken() error = %v", err)
+			}
+			if token != tt.wantToken {
+				t.Errorf("GetValidToken() = %q, want %q", token, tt.wantToken)
+			}
+			if got := logins.Load(); got != tt.wantLogins {
+				t.Errorf("session logins = %d, want %d", got, tt.wantLogins)
+			}
+
+			// The token in use is what the next run starts with
+			persisted, err := readTokenFile(cfg.TokenCacheFile, cfg.TokenCacheKey)
+			if err != nil {
+				t.Fatalf("readTokenFile() error = %v", err)
+			}
+			if persisted.Token != tt.wantToken {
+				t.Errorf("persisted token = %q, want %q", persisted.Token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestInvalidateTokenRemovesPersistedToken(t *testing.T) {
+	cfg := &config.Config{TokenCacheFile: filepath.Join(t.TempDir(), "token.json")}
+	if err := writeTokenFile(cfg.TokenCacheFile, "", &TokenCache{Token: "rejected-token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("writeTokenFile() error = %v", err)
+	}
+
+	authService := NewAuthService(cfg, nil)
+	authService.InvalidateToken()
+
+	if _, err := os.Stat(cfg.TokenCacheFile); !os.IsNotExist(err) {
+		t.Errorf("token cache file still present after InvalidateToken(), Stat() error = %v", err)
+	}
+}
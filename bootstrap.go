@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// Views of the applications section of /bootstrap
+const (
+	bootstrapViewSummary = "summary"
+	bootstrapViewFull    = "full"
+)
+
+// getBootstrap handles the bootstrap endpoint
+// @Summary Get dashboard bootstrap data
+// @Description Get the project groups, the filtered project names, the application list and a health snapshot in one response, so a dashboard can start with a single request. Projects and applications are each read once, from the cache when possible; the health snapshot is derived from those reads and makes no further ArgoCD call. Each section carries the time it was generated. With partial=true a failure to read projects or applications still answers 200 with the other sections and a warnings array naming the missing ones
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param view query string false "Application representation: summary (default) or full"
+// @Param fields query string false "Comma-separated summary fields to include besides name: project, cluster, namespace, health, sync, revision, healthSince, syncSince, autoSync (view=summary only). healthSince and syncSince need the background poller"
+// @Param partial query bool false "Answer with the available data and warnings when part of it cannot be read from ArgoCD (default false)"
+// @Param Accept header string false "application/json;profile=summary-v2 groups the destination and status fields of the application summaries; see /schemas"
+// @Success 200 {object} types.BootstrapResponse "Bootstrap data"
+// @Failure 406 {object} types.ErrorResponse "The Accept header names only unknown schema profiles"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve data from ArgoCD"
+// @Router /bootstrap [get]
+func (s *Server) getBootstrap(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	view := b.Enum("view", bootstrapViewSummary, bootstrapViewSummary, bootstrapViewFull)
+	if view == bootstrapViewFull {
+		b.Forbid("fields", "is only supported with view=summary")
+	}
+	fields := b.EnumSet("fields", services.ApplicationSummaryFields...)
+	partial := b.Bool(partialQueryParam, false)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	var response types.BootstrapResponse
+	filters := s.filters(c)
+
+	// Project groups and the filtered project names share one read of the projects
+	projectsPortion := &aggregatePortion{name: "projects", fetch: func(ctx context.Context) error {
+		projectNames, err := s.argocdService.GetProjectNames(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		groups, _ := s.groupings.Resolve(filters, projectNames)
+		response.ProjectGroups = &types.BootstrapProjectGroups{GeneratedAt: now, ProjectGroupsResponse: groups}
+		response.Projects = &types.BootstrapProjects{GeneratedAt: now, Names: []string{}}
+		for _, name := range projectNames {
+			if !filters.ShouldFilterProject(name) {
+				response.Projects.Names = append(response.Projects.Names, name)
+			}
+		}
+		return nil
+	}}
+	applicationsPortion := &aggregatePortion{name: "applications", fetch: func(ctx context.Context) error {
+		applications, err := s.argocdService.GetApplications(ctx)
+		if err != nil {
+			return err
+		}
+		applications = applicationListResponse(ctx, applications, services.ApplicationFilter{}, services.ApplicationSort{Field: services.SortByName})
+		section := &types.BootstrapApplications{GeneratedAt: time.Now().UTC(), View: view, Total: len(applications.Items)}
+		if view == bootstrapViewFull {
+			section.Items = applications.Items
+		} else {
+			section.Summaries = services.SummarizeApplications(applications.Items, fields, s.argocdService.ApplicationStateSince)
+		}
+		response.Applications = section
+		return nil
+	}}
+	gatherPortions(ctx, projectsPortion, applicationsPortion)
+
+	for _, portion := range []*aggregatePortion{projectsPortion, applicationsPortion} {
+		if portion.err == nil {
+			continue
+		}
+		log.Printf("Failed to get %s for bootstrap: %v", portion.name, portion.err)
+		if !partial || (projectsPortion.err != nil && applicationsPortion.err != nil) {
+			s.serviceErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("Failed to retrieve %s from ArgoCD", portion.name), portion.err)
+			return
+		}
+		response.Warnings = append(response.Warnings, portion.warning())
+	}
+
+	response.Health = s.bootstrapHealth(projectsPortion, applicationsPortion)
+	s.renderProfile(c, http.StatusOK, response)
+}
+
+// bootstrapHealth derives a health snapshot from the outcome of the bootstrap reads, the
+// token status and the latest compatibility probe, without calling ArgoCD again
+func (s *Server) bootstrapHealth(portions ...*aggregatePortion) types.BootstrapHealth {
+	health := types.BootstrapHealth{
+		GeneratedAt: time.Now().UTC(),
+		Status:      "healthy",
+		Version:     Version,
+		TokenStatus: s.authService.GetTokenStatus(),
+		ArgocdAPI:   "healthy",
+	}
+
+	var failed []string
+	for _, portion := range portions {
+		if portion.err != nil {
+			failed = append(failed, portion.name)
+		}
+	}
+	if len(failed) > 0 {
+		health.ArgocdAPI = fmt.Sprintf("error: failed to read %s", strings.Join(failed, " and "))
+		health.Status = "degraded"
+	} else if compatibility := s.argocdService.GetCompatibility(); compatibility != nil && !compatibility.Compatible {
+		health.ArgocdAPI = fmt.Sprintf("incompatible: %s", strings.Join(compatibility.Problems, "; "))
+		health.Status = "degraded"
+	}
+	if failing, _ := health.TokenStatus["failing"].(bool); failing {
+		health.Status = "degraded"
+	}
+	return health
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// bootstrapUpstream is a fake ArgoCD counting the project and application list reads
+type bootstrapUpstream struct {
+	projectCalls     atomic.Int32
+	applicationCalls atomic.Int32
+	// failApplications answers the application list with 403
+	failApplications bool
+}
+
+func (u *bootstrapUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/projects":
+		u.projectCalls.Add(1)
+		projects := types.ArgocdProjectList{}
+		for _, name := range []string{"web-app", "test-api", "backend"} {
+			projects.Items = append(projects.Items, types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: name}})
+		}
+		json.NewEncoder(w).Encode(projects)
+	case "/applications":
+		u.applicationCalls.Add(1)
+		if u.failApplications {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		app := func(name, project string) types.ArgocdApplication {
+			a := types.ArgocdApplication{
+				Metadata: types.ArgocdApplicationMetadata{Name: name},
+				Spec: types.ArgocdApplicationSpec{
+					Project:     project,
+					Destination: types.ArgocdApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: name},
+				},
+			}
+			a.Status.Health.Status = "Healthy"
+			a.Status.Sync.Status = "Synced"
+			a.Status.Sync.Revision = "abc123"
+			return a
+		}
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			app("web", "web-app"), app("api", "backend"), app("smoke", "test-api"),
+		}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// setupBootstrapServer returns a test server backed by a real ArgoCD service with a cold
+// cache talking to upstream
+func setupBootstrapServer(t *testing.T, upstream *bootstrapUpstream) *Server {
+	t.Helper()
+	fake := httptest.NewServer(upstream)
+	t.Cleanup(fake.Close)
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.CacheTTL = time.Minute
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+	return server
+}
+
+func serveBootstrap(t *testing.T, server *Server, query string) (*httptest.ResponseRecorder, types.BootstrapResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bootstrap"+query, nil))
+	var response types.BootstrapResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w, response
+}
+
+func TestBootstrapReadsUpstreamOnce(t *testing.T) {
+	upstream := &bootstrapUpstream{}
+	server := setupBootstrapServer(t, upstream)
+
+	w, response := serveBootstrap(t, server, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := upstream.projectCalls.Load(); got != 1 {
+		t.Errorf("upstream project reads = %d, want 1", got)
+	}
+	if got := upstream.applicationCalls.Load(); got != 1 {
+		t.Errorf("upstream application reads = %d, want 1", got)
+	}
+
+	// A warm cache serves the next request without reading upstream
+	serveBootstrap(t, server, "?view=full")
+	if projects, applications := upstream.projectCalls.Load(), upstream.applicationCalls.Load(); projects != 1 || applications != 1 {
+		t.Errorf("upstream reads after a warm request = %d projects, %d applications, want 1 each", projects, applications)
+	}
+
+	if response.ProjectGroups == nil || len(response.ProjectGroups.Groups) != 1 || response.ProjectGroups.GeneratedAt.IsZero() {
+		t.Errorf("projectGroups = %+v, want the configured group with generatedAt", response.ProjectGroups)
+	}
+	if response.Projects == nil || len(response.Projects.Names) != 2 || response.Projects.Names[0] != "backend" || response.Projects.Names[1] != "web-app" {
+		t.Errorf("projects = %+v, want [backend web-app]", response.Projects)
+	}
+	if apps := response.Applications; apps == nil || apps.View != bootstrapViewSummary || apps.Total != 2 || len(apps.Summaries) != 2 || apps.Items != nil {
+		t.Fatalf("applications = %+v, want 2 summaries of the visible applications", apps)
+	}
+	want := types.ApplicationSummary{Name: "api", Project: "backend", Cluster: "https://kubernetes.default.svc", Namespace: "api", Health: "Healthy", Sync: "Synced", Revision: "abc123", AutoSync: new(false)}
+	if got := response.Applications.Summaries[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("summaries[0] = %+v, want %+v", got, want)
+	}
+	if response.Health.Status != "healthy" || response.Health.ArgocdAPI != "healthy" || response.Health.GeneratedAt.IsZero() {
+		t.Errorf("health = %+v, want healthy with generatedAt", response.Health)
+	}
+	if len(response.Warnings) != 0 {
+		t.Errorf("warnings = %v, want none", response.Warnings)
+	}
+}
+
+func TestBootstrapApplicationViews(t *testing.T) {
+	server := setupBootstrapServer(t, &bootstrapUpstream{})
+
+	w, response := serveBootstrap(t, server, "?fields=health,sync")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	want := types.ApplicationSummary{Name: "api", Health: "Healthy", Sync: "Synced"}
+	if got := response.Applications.Summaries[0]; got != want {
+		t.Errorf("summaries[0] = %+v, want %+v", got, want)
+	}
+
+	w, response = serveBootstrap(t, server, "?view=full")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if apps := response.Applications; apps.View != bootstrapViewFull || len(apps.Items) != 2 || apps.Summaries != nil || apps.Items[0].Metadata.Name != "api" {
+		t.Errorf("applications = %+v, want 2 full applications", apps)
+	}
+
+	for _, query := range []string{"?view=compact", "?fields=owner", "?view=full&fields=health", "?partial=maybe"} {
+		if w, _ := serveBootstrap(t, server, query); w.Code != http.StatusBadRequest {
+			t.Errorf("GET /bootstrap%s status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestBootstrapPartial(t *testing.T) {
+	server := setupBootstrapServer(t, &bootstrapUpstream{failApplications: true})
+
+	if w, _ := serveBootstrap(t, server, ""); w.Code != http.StatusBadGateway {
+		t.Errorf("status without partial = %d, want 502", w.Code)
+	}
+
+	w, response := serveBootstrap(t, server, "?partial=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if response.Applications != nil || response.Projects == nil || response.ProjectGroups == nil {
+		t.Errorf("response = %+v, want projects without applications", response)
+	}
+	if len(response.Warnings) != 1 || response.Health.Status != "degraded" {
+		t.Errorf("warnings = %v, health = %+v, want one warning and a degraded snapshot", response.Warnings, response.Health)
+	}
+}
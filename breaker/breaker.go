@@ -0,0 +1,217 @@
+// Package breaker keeps a circuit breaker per key, so that requests for one misbehaving
+// resource fail fast after repeated failures without affecting requests for the others.
+package breaker
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+
+	"argocd-proxy/clock"
+)
+
+// Options configures a Set
+type Options struct {
+	// Threshold is the number of consecutive failures that opens a key's circuit
+	Threshold int
+	// Window is the period within which failures count as consecutive; a failure after it
+	// starts counting again
+	Window time.Duration
+	// Cooldown is how long an open circuit rejects requests. Requests after it are let
+	// through: a success closes the circuit and a failure opens it again at once.
+	Cooldown time.Duration
+	// MaxKeys bounds the number of tracked keys; the least recently used key is evicted to
+	// make room for a new one
+	MaxKeys int
+	// OnChange, if set, is called without the lock held when a key's circuit opens, or
+	// stops being open because it closed, it was evicted or a request found its cooldown
+	// passed
+	OnChange func(key string, open bool)
+}
+
+// Circuit is an open circuit
+type Circuit struct {
+	Key string
+	// OpenedAt is when the circuit last opened
+	OpenedAt time.Time
+	// OpenUntil is when the circuit lets a request through again
+	OpenUntil time.Time
+}
+
+// state is the failure record of one key
+type state struct {
+	key string
+	// failures counts the consecutive failures since firstFailure
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	openUntil    time.Time
+	// halfOpen is set once the cooldown has passed, until the next result
+	halfOpen bool
+}
+
+// open reports whether the circuit rejects requests at now
+func (st *state) open(now time.Time) bool {
+	return !st.openUntil.IsZero() && now.Before(st.openUntil)
+}
+
+// Set keeps the circuit breakers of many keys. It is safe for concurrent use.
+type Set struct {
+	opts  Options
+	clock clock.Clock
+
+	mu sync.Mutex
+	// order lists the tracked states, most recently used first
+	order *list.List
+	keys  map[string]*list.Element
+}
+
+// New returns a Set using the system clock
+func New(opts Options) *Set {
+	return NewWithClock(opts, clock.Real)
+}
+
+// NewWithClock returns a Set using clk to tell the time
+func NewWithClock(opts Options, clk clock.Clock) *Set {
+	return &Set{opts: opts, clock: clock.OrReal(clk), order: list.New(), keys: map[string]*list.Element{}}
+}
+
+// Allow reports whether a request for key may proceed and, when it may not, how long
+// until its circuit lets a request through again
+func (s *Set) Allow(key string) (time.Duration, bool) {
+	now := s.clock.Now()
+	var closed bool
+
+	s.mu.Lock()
+	element, ok := s.keys[key]
+	if !ok {
+		s.mu.Unlock()
+		return 0, true
+	}
+	st := element.Value.(*state)
+	if st.open(now) {
+		s.mu.Unlock()
+		return st.openUntil.Sub(now), false
+	}
+	if !st.openUntil.IsZero() {
+		st.openUntil = time.Time{}
+		st.halfOpen = true
+		closed = true
+	}
+	s.mu.Unlock()
+
+	if closed {
+		s.notify(key, false)
+	}
+	return 0, true
+}
+
+// Success records a successful request for key, closing its circuit
+func (s *Set) Success(key string) {
+	s.mu.Lock()
+	element, ok := s.keys[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	// openUntil is only cleared once OnChange was told the circuit is no longer open
+	reported := !element.Value.(*state).openUntil.IsZero()
+	s.remove(element)
+	s.mu.Unlock()
+
+	if reported {
+		s.notify(key, false)
+	}
+}
+
+// Failure records a failed request for key, reporting whether it opened the circuit
+func (s *Set) Failure(key string) bool {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	element, ok := s.keys[key]
+	var evicted []string
+	if ok {
+		s.order.MoveToFront(element)
+	} else {
+		evicted = s.makeRoom()
+		element = s.order.PushFront(&state{key: key})
+		s.keys[key] = element
+	}
+	st := element.Value.(*state)
+
+	opened := false
+	if !st.open(now) {
+		if st.failures == 0 || now.Sub(st.firstFailure) > s.opts.Window {
+			st.failures, st.firstFailure = 0, now
+		}
+		st.failures++
+		if st.halfOpen || st.failures >= s.opts.Threshold {
+			st.failures, st.halfOpen = 0, false
+			st.openedAt, st.openUntil = now, now.Add(s.opts.Cooldown)
+			opened = true
+		}
+	}
+	s.mu.Unlock()
+
+	for _, key := range evicted {
+		s.notify(key, false)
+	}
+	if opened {
+		s.notify(key, true)
+	}
+	return opened
+}
+
+// Open returns the open circuits, sorted by key
+func (s *Set) Open() []Circuit {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	var circuits []Circuit
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		if st := element.Value.(*state); st.open(now) {
+			circuits = append(circuits, Circuit{Key: st.key, OpenedAt: st.openedAt, OpenUntil: st.openUntil})
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(circuits, func(i, j int) bool { return circuits[i].Key < circuits[j].Key })
+	return circuits
+}
+
+// Len returns the number of tracked keys
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// makeRoom evicts the least recently used keys until a new one fits, returning the
+// evicted keys OnChange last reported as open. The caller holds the lock.
+func (s *Set) makeRoom() []string {
+	var evicted []string
+	for s.order.Len() >= max(1, s.opts.MaxKeys) {
+		element := s.order.Back()
+		st := element.Value.(*state)
+		if !st.openUntil.IsZero() {
+			evicted = append(evicted, st.key)
+		}
+		s.remove(element)
+	}
+	return evicted
+}
+
+// remove stops tracking the key of element. The caller holds the lock.
+func (s *Set) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.keys, element.Value.(*state).key)
+}
+
+// notify calls OnChange, if set
+func (s *Set) notify(key string, open bool) {
+	if s.opts.OnChange != nil {
+		s.opts.OnChange(key, open)
+	}
+}
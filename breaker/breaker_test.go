@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/testutils"
+)
+
+// changeRecorder records OnChange calls
+type changeRecorder struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (r *changeRecorder) record(key string, open bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := "closed"
+	if open {
+		state = "open"
+	}
+	r.changes = append(r.changes, key+" "+state)
+}
+
+func (r *changeRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := r.changes
+	r.changes = nil
+	return changes
+}
+
+func newTestSet(maxKeys int) (*Set, *testutils.FakeClock, *changeRecorder) {
+	clk := testutils.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	recorder := &changeRecorder{}
+	set := NewWithClock(Options{
+		Threshold: 3,
+		Window:    time.Minute,
+		Cooldown:  30 * time.Second,
+		MaxKeys:   maxKeys,
+		OnChange:  recorder.record,
+	}, clk)
+	return set, clk, recorder
+}
+
+func TestTripAndCooldown(t *testing.T) {
+	set, clk, recorder := newTestSet(10)
+
+	for i := 1; i <= 3; i++ {
+		if _, ok := set.Allow("heavy"); !ok {
+			t.Fatalf("Allow() before failure %d = false, want true", i)
+		}
+		if opened := set.Failure("heavy"); opened != (i == 3) {
+			t.Errorf("Failure() %d opened = %t, want %t", i, opened, i == 3)
+		}
+	}
+	if got := recorder.take(); !reflect.DeepEqual(got, []string{"heavy open"}) {
+		t.Errorf("changes = %v, want heavy open", got)
+	}
+
+	// Open: rejected with the time left, other keys unaffected
+	clk.Advance(10 * time.Second)
+	if retryAfter, ok := set.Allow("heavy"); ok || retryAfter != 20*time.Second {
+		t.Errorf("Allow(heavy) = %s, %t; want 20s, false", retryAfter, ok)
+	}
+	if _, ok := set.Allow("light"); !ok {
+		t.Error("Allow(light) = false, want true")
+	}
+	wantOpen := []Circuit{{Key: "heavy", OpenedAt: clk.Now().Add(-10 * time.Second), OpenUntil: clk.Now().Add(20 * time.Second)}}
+	if got := set.Open(); !reflect.DeepEqual(got, wantOpen) {
+		t.Errorf("Open() = %+v, want %+v", got, wantOpen)
+	}
+
+	// After the cooldown a request goes through; one failure opens the circuit again
+	clk.Advance(20 * time.Second)
+	if _, ok := set.Allow("heavy"); !ok {
+		t.Fatal("Allow(heavy) after the cooldown = false, want true")
+	}
+	if got := set.Open(); len(got) != 0 {
+		t.Errorf("Open() after the cooldown = %+v, want none", got)
+	}
+	if !set.Failure("heavy") {
+		t.Error("Failure() after the cooldown did not open the circuit again")
+	}
+	if got := recorder.take(); !reflect.DeepEqual(got, []string{"heavy closed", "heavy open"}) {
+		t.Errorf("changes = %v, want heavy closed then open", got)
+	}
+
+	// A success after the cooldown closes the circuit and forgets the key
+	clk.Advance(30 * time.Second)
+	if _, ok := set.Allow("heavy"); !ok {
+		t.Fatal("Allow(heavy) after the second cooldown = false, want true")
+	}
+	set.Success("heavy")
+	if set.Len() != 0 {
+		t.Errorf("Len() after success = %d, want 0", set.Len())
+	}
+	if set.Failure("heavy") {
+		t.Error("first failure after closing opened the circuit")
+	}
+}
+
+func TestFailuresOutsideWindow(t *testing.T) {
+	set, clk, _ := newTestSet(10)
+
+	set.Failure("flaky")
+	set.Failure("flaky")
+	clk.Advance(2 * time.Minute)
+	if set.Failure("flaky") {
+		t.Error("failure after the window opened the circuit")
+	}
+
+	// A success resets the count
+	set.Failure("flaky")
+	set.Success("flaky")
+	set.Failure("flaky")
+	if set.Failure("flaky") {
+		t.Error("failures after a success opened the circuit")
+	}
+	if !set.Failure("flaky") {
+		t.Error("third consecutive failure did not open the circuit")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	set, _, recorder := newTestSet(2)
+
+	for range 3 {
+		set.Failure("a")
+	}
+	set.Failure("b")
+	recorder.take()
+
+	// "c" evicts "a", the least recently used key, whose open circuit is reported closed
+	set.Failure("c")
+	if set.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", set.Len())
+	}
+	if got := recorder.take(); !reflect.DeepEqual(got, []string{"a closed"}) {
+		t.Errorf("changes = %v, want a closed", got)
+	}
+	if _, ok := set.Allow("a"); !ok {
+		t.Error("Allow(a) after eviction = false, want true")
+	}
+}
@@ -3,6 +3,8 @@ package cache
 import (
 	"sync"
 	"time"
+
+	"argocd-proxy/clock"
 )
 
 // Cache is a generic thread-safe in-memory cache with TTL expiration.
@@ -12,11 +14,17 @@ type Cache[T any] struct {
 	cachedAt  time.Time
 	ttl       time.Duration
 	populated bool
+	clock     clock.Clock
 }
 
 // New creates a new Cache with the given TTL. A TTL of 0 disables caching.
 func New[T any](ttl time.Duration) *Cache[T] {
-	return &Cache[T]{ttl: ttl}
+	return NewWithClock[T](ttl, clock.Real)
+}
+
+// NewWithClock creates a new Cache with the given TTL whose entries age by clk
+func NewWithClock[T any](ttl time.Duration, clk clock.Clock) *Cache[T] {
+	return &Cache[T]{ttl: ttl, clock: clk}
 }
 
 // Get returns the cached value and true if it exists and has not expired.
@@ -35,7 +43,26 @@ func (c *Cache[T]) Get() (T, bool) {
 		return zero, false
 	}
 
-	if time.Since(c.cachedAt) > c.ttl {
+	if c.clock.Since(c.cachedAt) > c.ttl {
+		var zero T
+		return zero, false
+	}
+
+	return c.value, true
+}
+
+// GetStale returns the last stored value even if it has expired, and true if a
+// value has been stored. It lets callers revalidate an expired entry upstream.
+func (c *Cache[T]) GetStale() (T, bool) {
+	if c.ttl <= 0 {
+		var zero T
+		return zero, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.populated {
 		var zero T
 		return zero, false
 	}
@@ -43,8 +70,44 @@ func (c *Cache[T]) Get() (T, bool) {
 	return c.value, true
 }
 
+// CachedAt returns when the stored value was set or last touched, and true if a value
+// has been stored
+func (c *Cache[T]) CachedAt() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cachedAt, c.populated
+}
+
+// Age returns how long ago the stored value was set or last touched, or 0 when the
+// cache is empty
+func (c *Cache[T]) Age() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.populated {
+		return 0
+	}
+	return c.clock.Since(c.cachedAt)
+}
+
+// Touch resets the timestamp of the stored value, extending it for another TTL.
+// It is a no-op when the cache is empty.
+func (c *Cache[T]) Touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.populated {
+		c.cachedAt = c.clock.Now()
+	}
+}
+
 // Set stores a value in the cache with the current timestamp.
 func (c *Cache[T]) Set(value T) {
+	c.SetAt(value, c.clock.Now())
+}
+
+// SetAt stores a value in the cache as if it had been set at cachedAt, so that it
+// expires one TTL after that time.
+func (c *Cache[T]) SetAt(value T, cachedAt time.Time) {
 	if c.ttl <= 0 {
 		return
 	}
@@ -53,7 +116,7 @@ func (c *Cache[T]) Set(value T) {
 	defer c.mu.Unlock()
 
 	c.value = value
-	c.cachedAt = time.Now()
+	c.cachedAt = cachedAt
 	c.populated = true
 }
 
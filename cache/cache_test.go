@@ -4,8 +4,13 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"argocd-proxy/testutils"
 )
 
+// epoch is the start time of the fake clocks used by the tests
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func TestGetOnEmptyCache(t *testing.T) {
 	c := New[string](30 * time.Second)
 
@@ -33,7 +38,8 @@ func TestSetAndGet(t *testing.T) {
 }
 
 func TestExpiry(t *testing.T) {
-	c := New[int](50 * time.Millisecond)
+	clk := testutils.NewFakeClock(epoch)
+	c := NewWithClock[int](50*time.Millisecond, clk)
 
 	c.Set(42)
 
@@ -42,7 +48,7 @@ func TestExpiry(t *testing.T) {
 		t.Fatalf("expected hit with value 42, got ok=%v val=%v", ok, val)
 	}
 
-	time.Sleep(60 * time.Millisecond)
+	clk.Advance(60 * time.Millisecond)
 
 	_, ok = c.Get()
 	if ok {
@@ -50,6 +56,98 @@ func TestExpiry(t *testing.T) {
 	}
 }
 
+func TestExpiryBoundary(t *testing.T) {
+	clk := testutils.NewFakeClock(epoch)
+	c := NewWithClock[int](time.Minute, clk)
+	c.Set(42)
+
+	// An entry is served for exactly its TTL and expires just after
+	clk.Advance(time.Minute)
+	if _, ok := c.Get(); !ok {
+		t.Error("expected hit at exactly the TTL")
+	}
+	clk.Advance(time.Nanosecond)
+	if _, ok := c.Get(); ok {
+		t.Error("expected miss one nanosecond after the TTL")
+	}
+
+	// Touching restarts the TTL from the current time
+	c.Touch()
+	clk.Advance(time.Minute)
+	if _, ok := c.Get(); !ok {
+		t.Error("expected hit a TTL after Touch")
+	}
+}
+
+func TestGetStaleAndTouch(t *testing.T) {
+	clk := testutils.NewFakeClock(epoch)
+	c := NewWithClock[int](50*time.Millisecond, clk)
+
+	if _, ok := c.GetStale(); ok {
+		t.Fatal("expected no stale value on empty cache")
+	}
+
+	c.Set(42)
+	clk.Advance(60 * time.Millisecond)
+
+	if _, ok := c.Get(); ok {
+		t.Fatal("expected cache miss after TTL expiry")
+	}
+	val, ok := c.GetStale()
+	if !ok || val != 42 {
+		t.Fatalf("expected stale value 42, got ok=%v val=%v", ok, val)
+	}
+
+	c.Touch()
+
+	val, ok = c.Get()
+	if !ok || val != 42 {
+		t.Errorf("expected hit with value 42 after Touch, got ok=%v val=%v", ok, val)
+	}
+}
+
+func TestSetAt(t *testing.T) {
+	clk := testutils.NewFakeClock(epoch)
+	c := NewWithClock[int](30*time.Second, clk)
+
+	c.SetAt(42, epoch.Add(-20*time.Second))
+	if val, ok := c.Get(); !ok || val != 42 {
+		t.Fatalf("expected hit with value 42, got ok=%v val=%v", ok, val)
+	}
+	if age := c.Age(); age != 20*time.Second {
+		t.Errorf("Age() = %v, want 20s", age)
+	}
+
+	// The value expires one TTL after its own timestamp, not after SetAt
+	clk.Advance(11 * time.Second)
+	if _, ok := c.Get(); ok {
+		t.Error("expected cache miss 31s after the value's timestamp")
+	}
+	if val, ok := c.GetStale(); !ok || val != 42 {
+		t.Errorf("expected stale value 42, got ok=%v val=%v", ok, val)
+	}
+}
+
+func TestCachedAt(t *testing.T) {
+	clk := testutils.NewFakeClock(epoch)
+	c := NewWithClock[int](30*time.Second, clk)
+
+	if _, ok := c.CachedAt(); ok {
+		t.Fatal("expected no timestamp on empty cache")
+	}
+
+	c.Set(42)
+	if cachedAt, ok := c.CachedAt(); !ok || !cachedAt.Equal(epoch) {
+		t.Fatalf("CachedAt() = %v, %v, want %v", cachedAt, ok, epoch)
+	}
+
+	clk.Advance(5 * time.Second)
+	c.Touch()
+	if touched, _ := c.CachedAt(); !touched.Equal(epoch.Add(5 * time.Second)) {
+		t.Errorf("CachedAt() after Touch = %v, want %v", touched, epoch.Add(5*time.Second))
+	}
+}
+
 func TestInvalidate(t *testing.T) {
 	c := New[string](30 * time.Second)
 
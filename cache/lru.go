@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"argocd-proxy/clock"
+)
+
+// LRU is a bounded, thread-safe least-recently-used cache whose entries expire after a TTL.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	items    map[K]*list.Element
+	clock    clock.Clock
+}
+
+// lruEntry is a single LRU element
+type lruEntry[K comparable, V any] struct {
+	key      K
+	value    V
+	cachedAt time.Time
+}
+
+// NewLRU creates an LRU holding at most capacity entries for ttl each.
+// A capacity or TTL of 0 disables caching.
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return NewLRUWithClock[K, V](capacity, ttl, clock.Real)
+}
+
+// NewLRUWithClock creates an LRU like NewLRU whose entries age by clk
+func NewLRUWithClock[K comparable, V any](capacity int, ttl time.Duration, clk clock.Clock) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+		clock:    clk,
+	}
+}
+
+// Get returns the value for key and true if present and not expired, marking it most recently used.
+func (l *LRU[K, V]) Get(key K) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var zero V
+	element, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+	if l.clock.Since(entry.cachedAt) > l.ttl {
+		l.removeElement(element)
+		return zero, false
+	}
+
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full.
+func (l *LRU[K, V]) Set(key K, value V) {
+	if l.capacity <= 0 || l.ttl <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.items[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.cachedAt = l.clock.Now()
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&lruEntry[K, V]{key: key, value: value, cachedAt: l.clock.Now()})
+	for l.order.Len() > l.capacity {
+		l.removeElement(l.order.Back())
+	}
+}
+
+// Remove deletes key from the cache.
+func (l *LRU[K, V]) Remove(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.items[key]; ok {
+		l.removeElement(element)
+	}
+}
+
+// Purge removes all entries.
+func (l *LRU[K, V]) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order.Init()
+	l.items = make(map[K]*list.Element)
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted.
+func (l *LRU[K, V]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// removeElement unlinks an element; the caller must hold the lock.
+func (l *LRU[K, V]) removeElement(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.items, element.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"argocd-proxy/testutils"
+)
+
+func TestLRUSetAndGet(t *testing.T) {
+	l := NewLRU[string, int](2, time.Minute)
+
+	l.Set("a", 1)
+	val, ok := l.Get("a")
+	if !ok || val != 1 {
+		t.Errorf("expected hit with value 1, got ok=%v val=%v", ok, val)
+	}
+
+	if _, ok := l.Get("missing"); ok {
+		t.Error("expected miss for unknown key")
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	l := NewLRU[string, int](2, time.Minute)
+
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a") // a becomes most recently used
+	l.Set("c", 3)
+
+	if _, ok := l.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := l.Get("a"); !ok {
+		t.Error("expected a to survive eviction")
+	}
+	if _, ok := l.Get("c"); !ok {
+		t.Error("expected c to be present")
+	}
+	if l.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", l.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	clk := testutils.NewFakeClock(epoch)
+	l := NewLRUWithClock[string, int](2, 50*time.Millisecond, clk)
+
+	l.Set("a", 1)
+	clk.Advance(50 * time.Millisecond)
+	if _, ok := l.Get("a"); !ok {
+		t.Error("expected hit at exactly the TTL")
+	}
+	clk.Advance(time.Nanosecond)
+	if _, ok := l.Get("a"); ok {
+		t.Error("expected miss after TTL expiry")
+	}
+	if l.Len() != 0 {
+		t.Errorf("expected expired entry to be removed, got %d entries", l.Len())
+	}
+}
+
+func TestLRUOverwriteRemoveAndPurge(t *testing.T) {
+	l := NewLRU[string, int](3, time.Minute)
+
+	l.Set("a", 1)
+	l.Set("a", 2)
+	if val, _ := l.Get("a"); val != 2 {
+		t.Errorf("expected overwritten value 2, got %d", val)
+	}
+
+	l.Set("b", 3)
+	l.Remove("a")
+	if _, ok := l.Get("a"); ok {
+		t.Error("expected miss after Remove")
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Errorf("expected empty cache after Purge, got %d entries", l.Len())
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	for _, l := range []*LRU[string, int]{NewLRU[string, int](0, time.Minute), NewLRU[string, int](10, 0)} {
+		l.Set("a", 1)
+		if _, ok := l.Get("a"); ok {
+			t.Error("expected disabled LRU to never hit")
+		}
+	}
+}
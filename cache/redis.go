@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"argocd-proxy/clock"
+	"argocd-proxy/metrics"
+)
+
+// redisTimeout bounds every Redis command, so an unreachable server degrades to cache
+// misses instead of stalling requests
+const redisTimeout = 2 * time.Second
+
+// Fields of the Redis hash holding a RedisStore entry. The timestamp is kept apart from
+// the value so that it can be read without transferring and decoding the value.
+const (
+	redisFieldCachedAt = "cachedAt"
+	redisFieldValue    = "value"
+)
+
+// RedisStore is a Store kept in Redis as a hash under a single key, shared by every
+// replica using the same key. A value is fresh for the TTL and its key is kept for
+// staleWindow longer, so that GetStale, CachedAt and Age still find an expired value as
+// they do with Cache. The last decoded value is reused while the stored timestamp is
+// unchanged. Redis errors are logged, counted in cache_backend_errors_total and treated
+// as misses.
+type RedisStore[T any] struct {
+	client      redis.UniversalClient
+	name        string
+	key         string
+	ttl         time.Duration
+	staleWindow time.Duration
+	clock       clock.Clock
+
+	// mu guards the last value read or written, with its timestamp
+	mu       sync.Mutex
+	memo     T
+	memoAt   time.Time
+	memoized bool
+}
+
+// NewRedisStore creates a RedisStore for the cache called name, stored under
+// prefix+name and kept staleWindow past the TTL. A TTL of 0 disables caching.
+func NewRedisStore[T any](client redis.UniversalClient, prefix, name string, ttl, staleWindow time.Duration, clk clock.Clock) *RedisStore[T] {
+	return &RedisStore[T]{client: client, name: name, key: prefix + name, ttl: ttl, staleWindow: staleWindow, clock: clk}
+}
+
+// Get returns the cached value and true if it exists and has not expired
+func (r *RedisStore[T]) Get() (T, bool) {
+	cachedAt, ok := r.CachedAt()
+	if !ok || r.clock.Since(cachedAt) > r.ttl {
+		var zero T
+		return zero, false
+	}
+	return r.value(cachedAt)
+}
+
+// GetStale returns the stored value and true while its key has not expired in Redis,
+// up to staleWindow past the TTL
+func (r *RedisStore[T]) GetStale() (T, bool) {
+	cachedAt, ok := r.CachedAt()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return r.value(cachedAt)
+}
+
+// CachedAt returns when the stored value was set or last touched, and true if a value
+// is stored
+func (r *RedisStore[T]) CachedAt() (time.Time, bool) {
+	if r.ttl <= 0 {
+		return time.Time{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	raw, err := r.client.HGet(ctx, r.key, redisFieldCachedAt).Result()
+	if err != nil {
+		r.readFailed(err)
+		return time.Time{}, false
+	}
+	cachedAt, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		r.failed("decode", err)
+		return time.Time{}, false
+	}
+	return cachedAt, true
+}
+
+// Age returns how long ago the stored value was set or last touched, or 0 when empty
+func (r *RedisStore[T]) Age() time.Duration {
+	cachedAt, ok := r.CachedAt()
+	if !ok {
+		return 0
+	}
+	return r.clock.Since(cachedAt)
+}
+
+// Touch resets the timestamp of the stored value, extending it for another TTL.
+// It is a no-op when the store is empty.
+func (r *RedisStore[T]) Touch() {
+	if value, ok := r.GetStale(); ok {
+		r.Set(value)
+	}
+}
+
+// Set stores a value with the current timestamp
+func (r *RedisStore[T]) Set(value T) {
+	r.SetAt(value, r.clock.Now())
+}
+
+// SetAt stores a value as if it had been set at cachedAt; its key expires staleWindow
+// after the TTL that follows that time. A value already older than that is not stored.
+func (r *RedisStore[T]) SetAt(value T, cachedAt time.Time) {
+	retention := r.ttl + r.staleWindow
+	expiration := retention - r.clock.Since(cachedAt)
+	if r.ttl <= 0 || expiration <= 0 {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		r.failed("encode", err)
+		return
+	}
+
+	// The key is replaced as a whole, so readers never see the fields of two writes
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, r.key)
+		pipe.HSet(ctx, r.key, redisFieldCachedAt, cachedAt.UTC().Format(time.RFC3339Nano), redisFieldValue, data)
+		pipe.PExpire(ctx, r.key, min(expiration, retention))
+		return nil
+	})
+	if err != nil {
+		r.failed("write", err)
+		return
+	}
+	r.remember(value, cachedAt)
+}
+
+// Invalidate deletes the stored value
+func (r *RedisStore[T]) Invalidate() {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := r.client.Del(ctx, r.key).Err(); err != nil {
+		r.failed("delete", err)
+	}
+}
+
+// value returns the value stored at cachedAt, decoding it only when it is not the
+// value last read or written
+func (r *RedisStore[T]) value(cachedAt time.Time) (T, bool) {
+	r.mu.Lock()
+	if r.memoized && r.memoAt.Equal(cachedAt) {
+		defer r.mu.Unlock()
+		return r.memo, true
+	}
+	r.mu.Unlock()
+
+	var zero T
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	fields, err := r.client.HMGet(ctx, r.key, redisFieldCachedAt, redisFieldValue).Result()
+	if err != nil {
+		r.readFailed(err)
+		return zero, false
+	}
+	// Another replica may have replaced the value since its timestamp was read
+	rawAt, atOK := fields[0].(string)
+	data, dataOK := fields[1].(string)
+	if !atOK || !dataOK {
+		return zero, false
+	}
+	storedAt, err := time.Parse(time.RFC3339Nano, rawAt)
+	if err != nil {
+		r.failed("decode", err)
+		return zero, false
+	}
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		r.failed("decode", err)
+		return zero, false
+	}
+	r.remember(value, storedAt)
+	return value, true
+}
+
+// remember keeps value as the one stored at cachedAt
+func (r *RedisStore[T]) remember(value T, cachedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.memo, r.memoAt, r.memoized = value, cachedAt, true
+}
+
+// readFailed handles a failed read: a missing key is a miss, and so is a key of another
+// type, such as one written by an older version, which the next write replaces
+func (r *RedisStore[T]) readFailed(err error) {
+	if errors.Is(err, redis.Nil) || strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		return
+	}
+	r.failed("read", err)
+}
+
+// failed logs and counts a Redis failure
+func (r *RedisStore[T]) failed(operation string, err error) {
+	log.Printf("Redis cache %s: failed to %s %s: %v", r.name, operation, r.key, err)
+	metrics.CacheBackendErrorsTotal.WithLabelValues(r.name).Inc()
+}
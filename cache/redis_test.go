@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/testutils"
+)
+
+type redisTestValue struct {
+	Names []string `json:"names"`
+	Count int      `json:"count"`
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestRedisStoreRoundTrip(t *testing.T) {
+	server, client := newTestRedis(t)
+	clk := testutils.NewFakeClock(epoch)
+	store := NewRedisStore[redisTestValue](client, "test:", "projects", 30*time.Second, 0, clk)
+
+	if _, ok := store.Get(); ok {
+		t.Fatal("expected miss on an empty store")
+	}
+
+	want := redisTestValue{Names: []string{"web", "api"}, Count: 2}
+	store.Set(want)
+	if !server.Exists("test:projects") {
+		t.Fatal("expected the value under the prefixed key")
+	}
+	if ttl := server.TTL("test:projects"); ttl != 30*time.Second {
+		t.Errorf("key TTL = %v, want 30s", ttl)
+	}
+
+	got, ok := store.Get()
+	if !ok || len(got.Names) != 2 || got.Names[1] != "api" || got.Count != 2 {
+		t.Fatalf("Get() = %+v, %v, want %+v", got, ok, want)
+	}
+	if cachedAt, ok := store.CachedAt(); !ok || !cachedAt.Equal(epoch) {
+		t.Errorf("CachedAt() = %v, %v, want %v", cachedAt, ok, epoch)
+	}
+
+	clk.Advance(10 * time.Second)
+	if age := store.Age(); age != 10*time.Second {
+		t.Errorf("Age() = %v, want 10s", age)
+	}
+	store.Touch()
+	if age := store.Age(); age != 0 {
+		t.Errorf("Age() after Touch = %v, want 0", age)
+	}
+}
+
+func TestRedisStoreExpiry(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "applications", 30*time.Second, 0, testutils.NewFakeClock(epoch))
+
+	store.Set(42)
+	server.FastForward(31 * time.Second)
+
+	if _, ok := store.Get(); ok {
+		t.Error("expected miss after the key expired")
+	}
+	if _, ok := store.GetStale(); ok {
+		t.Error("expected no stale value after the key expired")
+	}
+	if age := store.Age(); age != 0 {
+		t.Errorf("Age() = %v, want 0 for an empty store", age)
+	}
+}
+
+func TestRedisStoreSetAt(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "applications", 30*time.Second, 0, testutils.NewFakeClock(epoch))
+
+	// The key expires one TTL after the value's timestamp
+	store.SetAt(42, epoch.Add(-20*time.Second))
+	if ttl := server.TTL("test:applications"); ttl != 10*time.Second {
+		t.Errorf("key TTL = %v, want 10s", ttl)
+	}
+	if val, ok := store.Get(); !ok || val != 42 {
+		t.Errorf("Get() = %v, %v, want 42", val, ok)
+	}
+	if age := store.Age(); age != 20*time.Second {
+		t.Errorf("Age() = %v, want 20s", age)
+	}
+
+	// A timestamp ahead of the clock does not extend the key beyond the TTL
+	store.SetAt(43, epoch.Add(time.Minute))
+	if ttl := server.TTL("test:applications"); ttl != 30*time.Second {
+		t.Errorf("key TTL = %v, want 30s", ttl)
+	}
+
+	// A value already older than the TTL is not stored
+	store.Invalidate()
+	store.SetAt(44, epoch.Add(-time.Minute))
+	if server.Exists("test:applications") {
+		t.Error("expected an expired value not to be stored")
+	}
+}
+
+func TestRedisStoreInvalidate(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "projects", 30*time.Second, 0, testutils.NewFakeClock(epoch))
+
+	store.Set(42)
+	store.Invalidate()
+
+	if _, ok := store.Get(); ok {
+		t.Error("expected miss after Invalidate")
+	}
+	if server.Exists("test:projects") {
+		t.Error("expected Invalidate to delete the key")
+	}
+}
+
+func TestRedisStoreSharedBetweenStores(t *testing.T) {
+	_, client := newTestRedis(t)
+	clk := testutils.NewFakeClock(epoch)
+	first := NewRedisStore[string](client, "test:", "projects", 30*time.Second, 0, clk)
+	second := NewRedisStore[string](client, "test:", "projects", 30*time.Second, 0, clk)
+	other := NewRedisStore[string](client, "other:", "projects", 30*time.Second, 0, clk)
+
+	first.Set("shared")
+	if got, ok := second.Get(); !ok || got != "shared" {
+		t.Errorf("second.Get() = %q, %v, want the value set through first", got, ok)
+	}
+	if _, ok := other.Get(); ok {
+		t.Error("expected a store with another prefix to miss")
+	}
+}
+
+func TestRedisStoreZeroTTL(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "projects", 0, 0, testutils.NewFakeClock(epoch))
+
+	store.Set(42)
+	if _, ok := store.Get(); ok {
+		t.Error("expected miss with a zero TTL")
+	}
+	if server.Exists("test:projects") {
+		t.Error("expected nothing written with a zero TTL")
+	}
+}
+
+func TestRedisStoreFailureIsMiss(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "failing", 30*time.Second, 0, testutils.NewFakeClock(epoch))
+	store.Set(42)
+
+	server.Close()
+	before := testutil.ToFloat64(metrics.CacheBackendErrorsTotal.WithLabelValues("failing"))
+
+	if _, ok := store.Get(); ok {
+		t.Error("expected miss when Redis is unreachable")
+	}
+	store.Set(43)
+
+	if got := testutil.ToFloat64(metrics.CacheBackendErrorsTotal.WithLabelValues("failing")) - before; got != 2 {
+		t.Errorf("cache_backend_errors_total increased by %v, want 2", got)
+	}
+}
+
+func TestRedisStoreStaleWindow(t *testing.T) {
+	server, client := newTestRedis(t)
+	clk := testutils.NewFakeClock(epoch)
+	store := NewRedisStore[int](client, "test:", "applications", 30*time.Second, time.Hour, clk)
+
+	store.Set(42)
+	if ttl := server.TTL("test:applications"); ttl != time.Hour+30*time.Second {
+		t.Errorf("key TTL = %v, want the TTL plus the stale window", ttl)
+	}
+
+	// Past the TTL the value is expired but still there for stale reads and revalidation
+	clk.Advance(31 * time.Second)
+	server.FastForward(31 * time.Second)
+	if _, ok := store.Get(); ok {
+		t.Error("expected miss after the TTL")
+	}
+	if val, ok := store.GetStale(); !ok || val != 42 {
+		t.Errorf("GetStale() = %v, %v, want 42", val, ok)
+	}
+	if age := store.Age(); age != 31*time.Second {
+		t.Errorf("Age() = %v, want 31s", age)
+	}
+	store.Touch()
+	if val, ok := store.Get(); !ok || val != 42 {
+		t.Errorf("Get() after Touch = %v, %v, want 42", val, ok)
+	}
+
+	// A value older than the TTL but within the stale window can be imported
+	store.Invalidate()
+	store.SetAt(43, clk.Now().Add(-10*time.Minute))
+	if val, ok := store.GetStale(); !ok || val != 43 {
+		t.Errorf("GetStale() of an imported expired value = %v, %v, want 43", val, ok)
+	}
+
+	// Once the stale window has passed the key is gone
+	server.FastForward(time.Hour)
+	if _, ok := store.GetStale(); ok {
+		t.Error("expected no stale value after the stale window")
+	}
+}
+
+func TestRedisStoreReusesDecodedValue(t *testing.T) {
+	server, client := newTestRedis(t)
+	clk := testutils.NewFakeClock(epoch)
+	writer := NewRedisStore[int](client, "test:", "projects", 30*time.Second, 0, clk)
+	reader := NewRedisStore[int](client, "test:", "projects", 30*time.Second, 0, clk)
+
+	writer.Set(42)
+	if val, ok := reader.Get(); !ok || val != 42 {
+		t.Fatalf("Get() = %v, %v, want 42", val, ok)
+	}
+
+	// While the timestamp is unchanged the value is not read again
+	server.HSet("test:projects", "value", "43")
+	if val, ok := reader.Get(); !ok || val != 42 {
+		t.Errorf("Get() with the same timestamp = %v, %v, want the decoded 42", val, ok)
+	}
+
+	// A write with a new timestamp is read and decoded
+	clk.Advance(time.Second)
+	writer.Set(44)
+	if val, ok := reader.Get(); !ok || val != 44 {
+		t.Errorf("Get() after a new write = %v, %v, want 44", val, ok)
+	}
+}
+
+func TestRedisStoreReplacesOldLayout(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisStore[int](client, "test:", "layout", 30*time.Second, 0, testutils.NewFakeClock(epoch))
+	// Older versions stored the entry as a JSON string
+	server.Set("test:layout", `{"value":42,"cachedAt":"2025-01-01T00:00:00Z"}`)
+	before := testutil.ToFloat64(metrics.CacheBackendErrorsTotal.WithLabelValues("layout"))
+
+	if _, ok := store.GetStale(); ok {
+		t.Error("expected an entry of the old layout to read as a miss")
+	}
+	store.Set(43)
+	if val, ok := store.Get(); !ok || val != 43 {
+		t.Errorf("Get() after Set = %v, %v, want 43", val, ok)
+	}
+	if got := testutil.ToFloat64(metrics.CacheBackendErrorsTotal.WithLabelValues("layout")) - before; got != 0 {
+		t.Errorf("cache_backend_errors_total increased by %v, want 0", got)
+	}
+}
@@ -0,0 +1,29 @@
+package cache
+
+import "time"
+
+// Store holds a single cached value with TTL expiration. Cache keeps it in memory;
+// RedisStore shares it between replicas.
+type Store[T any] interface {
+	// Get returns the cached value and true if it exists and has not expired
+	Get() (T, bool)
+	// GetStale returns the last stored value even if it has expired, and true if the
+	// store still holds one
+	GetStale() (T, bool)
+	// CachedAt returns when the stored value was set or last touched, and true if a
+	// value is stored
+	CachedAt() (time.Time, bool)
+	// Age returns how long ago the stored value was set or last touched, or 0 when empty
+	Age() time.Duration
+	// Touch resets the timestamp of the stored value, extending it for another TTL
+	Touch()
+	// Set stores a value with the current timestamp
+	Set(value T)
+	// SetAt stores a value as if it had been set at cachedAt, such as a value carried
+	// over from another process
+	SetAt(value T, cachedAt time.Time)
+	// Invalidate clears the stored value
+	Invalidate()
+}
+
+var _ Store[int] = (*Cache[int])(nil)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// cacheSnapshotTimeout bounds fetching CACHE_SNAPSHOT_URL at startup
+const cacheSnapshotTimeout = 10 * time.Second
+
+// cacheSnapshotImporter pre-warms caches from a snapshot, as services.ArgocdService does
+type cacheSnapshotImporter interface {
+	ImportCacheSnapshot(snapshot types.CacheSnapshot) ([]string, error)
+}
+
+// getCacheSnapshot handles the cache snapshot admin endpoint
+// @Summary Export the cache snapshot
+// @Description Export the cached project and application lists with the times they were fetched, even expired ones, as one JSON document for another replica to import through CACHE_SNAPSHOT_URL or CACHE_SNAPSHOT_FILE. Never calls ArgoCD. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} types.CacheSnapshot "Cache snapshot"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/cache/snapshot [get]
+func (s *Server) getCacheSnapshot(c *gin.Context) {
+	c.JSON(http.StatusOK, s.argocdService.ExportCacheSnapshot())
+}
+
+// importCacheSnapshot pre-warms the caches from CACHE_SNAPSHOT_URL or CACHE_SNAPSHOT_FILE
+// before the first ArgoCD call. A warm start is an optimisation only: a snapshot that
+// cannot be read or has another schema version is logged and the caches start cold.
+func importCacheSnapshot(ctx context.Context, cfg *config.Config, importer cacheSnapshotImporter) {
+	source := cfg.CacheSnapshotURL
+	if source == "" {
+		source = cfg.CacheSnapshotFile
+	}
+	if source == "" {
+		return
+	}
+
+	snapshot, err := readCacheSnapshot(ctx, cfg)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No cache snapshot at %s, starting with cold caches", source)
+		return
+	}
+	if err == nil {
+		var imported []string
+		if imported, err = importer.ImportCacheSnapshot(snapshot); err == nil {
+			if len(imported) == 0 {
+				log.Printf("Cache snapshot from %s holds no fresh lists, starting with cold caches", source)
+			} else {
+				log.Printf("Pre-warmed the %s caches from the snapshot at %s", strings.Join(imported, " and "), source)
+			}
+			return
+		}
+	}
+	log.Printf("WARNING: Failed to import the cache snapshot from %s, starting with cold caches: %v", source, err)
+}
+
+// readCacheSnapshot reads and decodes the snapshot of CACHE_SNAPSHOT_URL or
+// CACHE_SNAPSHOT_FILE
+func readCacheSnapshot(ctx context.Context, cfg *config.Config) (types.CacheSnapshot, error) {
+	var body io.ReadCloser
+	if cfg.CacheSnapshotURL != "" {
+		ctx, cancel := context.WithTimeout(ctx, cacheSnapshotTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.CacheSnapshotURL, nil)
+		if err != nil {
+			return types.CacheSnapshot{}, err
+		}
+		// Replicas share ADMIN_TOKEN, which the snapshot endpoint requires
+		if cfg.AdminToken != "" {
+			req.Header.Set("Authorization", "Bearer "+cfg.AdminToken)
+		}
+		// The shared upstream client reaches the other replica through the same outbound
+		// proxy and TLS settings as ArgoCD
+		resp, err := upstream.NewClient(cfg).Do(req)
+		if err != nil {
+			return types.CacheSnapshot{}, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return types.CacheSnapshot{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(cfg.CacheSnapshotFile)
+		if err != nil {
+			return types.CacheSnapshot{}, err
+		}
+		body = file
+	}
+	defer body.Close()
+
+	var snapshot types.CacheSnapshot
+	if err := json.NewDecoder(body).Decode(&snapshot); err != nil {
+		return types.CacheSnapshot{}, fmt.Errorf("invalid cache snapshot: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// recordingImporter records the snapshots it is asked to import
+type recordingImporter struct {
+	snapshots []types.CacheSnapshot
+}
+
+func (r *recordingImporter) ImportCacheSnapshot(snapshot types.CacheSnapshot) ([]string, error) {
+	r.snapshots = append(r.snapshots, snapshot)
+	if snapshot.SchemaVersion != types.CacheSnapshotSchemaVersion {
+		return nil, errors.New("unsupported schema version")
+	}
+	return []string{services.ResourceApplications}, nil
+}
+
+// testCacheSnapshot is a snapshot holding one application
+func testCacheSnapshot() types.CacheSnapshot {
+	cachedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return types.CacheSnapshot{
+		SchemaVersion: types.CacheSnapshotSchemaVersion,
+		ExportedAt:    cachedAt.Add(10 * time.Second),
+		Applications: &types.CachedApplications{
+			CachedAt: cachedAt,
+			List:     types.ArgocdApplicationList{Items: []types.ArgocdApplication{{Metadata: types.ArgocdApplicationMetadata{Name: "web"}}}},
+		},
+	}
+}
+
+// captureLogs returns the log output written while fn runs
+func captureLogs(fn func()) string {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return logs.String()
+}
+
+func TestGetCacheSnapshot(t *testing.T) {
+	server, mockService := setupAdminServer()
+	mockService.cacheSnapshot = testCacheSnapshot()
+
+	w := serveMethod(server, http.MethodGet, "/admin/cache/snapshot", map[string]string{"Authorization": "Bearer " + testAdminToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var response types.CacheSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(response, mockService.cacheSnapshot) {
+		t.Errorf("snapshot = %+v, want %+v", response, mockService.cacheSnapshot)
+	}
+
+	if w = serveMethod(server, http.MethodGet, "/admin/cache/snapshot", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without admin token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestImportCacheSnapshotFromURL(t *testing.T) {
+	// The starting replica fetches the snapshot endpoint of a running one with ADMIN_TOKEN
+	running, mockService := setupAdminServer()
+	mockService.cacheSnapshot = testCacheSnapshot()
+	peer := httptest.NewServer(running.router)
+	defer peer.Close()
+
+	tests := []struct {
+		name         string
+		adminToken   string
+		wantImported bool
+		wantLog      string
+	}{
+		{name: "imported", adminToken: testAdminToken, wantImported: true, wantLog: "Pre-warmed the applications caches from the snapshot at " + peer.URL},
+		{name: "wrong admin token", adminToken: "other", wantLog: "WARNING: Failed to import the cache snapshot from " + peer.URL + "/admin/cache/snapshot, starting with cold caches: unexpected status 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{CacheSnapshotURL: peer.URL + "/admin/cache/snapshot", AdminToken: tt.adminToken}
+			importer := &recordingImporter{}
+			logs := captureLogs(func() { importCacheSnapshot(context.Background(), cfg, importer) })
+
+			if got := len(importer.snapshots) == 1; got != tt.wantImported {
+				t.Fatalf("imported = %v, want %v", got, tt.wantImported)
+			}
+			if tt.wantImported && !reflect.DeepEqual(importer.snapshots[0], mockService.cacheSnapshot) {
+				t.Errorf("imported snapshot = %+v, want %+v", importer.snapshots[0], mockService.cacheSnapshot)
+			}
+			if !strings.Contains(logs, tt.wantLog) {
+				t.Errorf("logs = %q, want %q", logs, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestImportCacheSnapshotFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid, _ := json.Marshal(testCacheSnapshot())
+	future := testCacheSnapshot()
+	future.SchemaVersion++
+	unsupported, _ := json.Marshal(future)
+
+	tests := []struct {
+		name         string
+		path         string
+		wantImported int
+		wantLog      string
+	}{
+		{name: "valid", path: write("valid.json", string(valid)), wantImported: 1, wantLog: "Pre-warmed the applications caches"},
+		{name: "missing", path: filepath.Join(dir, "missing.json"), wantLog: "No cache snapshot at " + filepath.Join(dir, "missing.json") + ", starting with cold caches"},
+		{name: "invalid JSON", path: write("invalid.json", "{"), wantLog: "WARNING: Failed to import the cache snapshot from " + filepath.Join(dir, "invalid.json") + ", starting with cold caches: invalid cache snapshot"},
+		{name: "other schema version", path: write("unsupported.json", string(unsupported)), wantImported: 1, wantLog: "WARNING: Failed to import the cache snapshot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := &recordingImporter{}
+			logs := captureLogs(func() {
+				importCacheSnapshot(context.Background(), &config.Config{CacheSnapshotFile: tt.path}, importer)
+			})
+			if len(importer.snapshots) != tt.wantImported {
+				t.Errorf("import attempts = %d, want %d", len(importer.snapshots), tt.wantImported)
+			}
+			if !strings.Contains(logs, tt.wantLog) {
+				t.Errorf("logs = %q, want %q", logs, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestImportCacheSnapshotUnset(t *testing.T) {
+	importer := &recordingImporter{}
+	if logs := captureLogs(func() { importCacheSnapshot(context.Background(), &config.Config{}, importer) }); logs != "" || len(importer.snapshots) != 0 {
+		t.Errorf("import without CACHE_SNAPSHOT_URL or CACHE_SNAPSHOT_FILE logged %q and made %d attempts, want nothing", logs, len(importer.snapshots))
+	}
+}
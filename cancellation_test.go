@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+func TestClientDisconnectAbortsUpstreamCall(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	defer metrics.SetDefault(original)
+
+	received := make(chan struct{})
+	dropped := make(chan struct{})
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		select {
+		case <-r.Context().Done():
+			close(dropped)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer fake.Close()
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.CacheTTL = 0
+	server.config.UpstreamTimeout = time.Minute
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+	proxy := httptest.NewServer(server)
+	defer proxy.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxy.URL+"/applications", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		errs <- err
+	}()
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream never received the request")
+	}
+	cancel()
+	if err := <-errs; err == nil {
+		t.Fatal("request succeeded, want it canceled")
+	}
+
+	select {
+	case <-dropped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream call kept running after the client disconnected")
+	}
+
+	// The handler returns once the upstream call fails; wait for the metrics middleware
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(metrics.HTTPClientAbortedTotal.WithLabelValues("/applications")) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("http_client_aborted_requests_total{path=\"/applications\"} was not incremented")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// deadlineRecordingService records the deadline of the context application lists are
+// fetched with
+type deadlineRecordingService struct {
+	*MockArgocdService
+	deadline time.Time
+}
+
+func (d *deadlineRecordingService) GetApplicationsSnapshot(ctx context.Context) (types.ArgocdApplicationList, string, error) {
+	d.deadline, _ = ctx.Deadline()
+	return d.MockArgocdService.GetApplicationsSnapshot(ctx)
+}
+
+func TestRequestDeadlineHonoursLongUpstreamTimeout(t *testing.T) {
+	t.Setenv("ARGOCD_API_URL", "http://argocd.invalid/api/v1")
+	t.Setenv("ARGOCD_USERNAME", "admin")
+	t.Setenv("ARGOCD_PASSWORD", "secret")
+	t.Setenv("UPSTREAM_TIMEOUT", "30s")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	server := setupTestServer()
+	server.config = cfg
+	recorder := &deadlineRecordingService{MockArgocdService: &MockArgocdService{config: cfg}}
+	server.argocdService = recorder
+	server.setupRouter()
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/applications", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if recorder.deadline.IsZero() {
+		t.Fatal("applications were fetched without a deadline")
+	}
+	if remaining := recorder.deadline.Sub(start); remaining < cfg.UpstreamTimeout {
+		t.Errorf("request deadline = %v, want at least UPSTREAM_TIMEOUT (%v)", remaining, cfg.UpstreamTimeout)
+	}
+}
@@ -0,0 +1,129 @@
+// Package canonical encodes JSON canonically, so that equal content always has the same
+// bytes: object keys sorted, timestamps in UTC and no insignificant whitespace. It is
+// used wherever content is hashed or compared, such as ETags, snapshot tokens and the
+// project groups export; responses are still written with encoding/json.
+package canonical
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Marshal returns the canonical JSON encoding of v
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+// Hash returns the hex-encoded SHA-256 digest of the canonical JSON encoding of v
+func Hash(v any) (string, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Canonicalize re-encodes the JSON document data canonically. Object keys are sorted by
+// their bytes, HTML characters are not escaped, and strings holding an RFC 3339
+// timestamp are rewritten in UTC with trailing zeros of the fraction dropped, as
+// time.RFC3339Nano formats them. Numbers are kept as written.
+func Canonicalize(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: data after the top-level value")
+	}
+
+	e := &encoder{}
+	e.buf.Grow(len(data))
+	e.strings = json.NewEncoder(&e.buf)
+	e.strings.SetEscapeHTML(false)
+	e.encode(value)
+	return e.buf.Bytes(), nil
+}
+
+// encoder writes canonical JSON to buf
+type encoder struct {
+	buf bytes.Buffer
+	// strings writes JSON strings to buf without escaping HTML characters
+	strings *json.Encoder
+}
+
+// encode writes the canonical encoding of a value decoded with UseNumber
+func (e *encoder) encode(value any) {
+	buf := &e.buf
+	switch value := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			e.encodeString(key)
+			buf.WriteByte(':')
+			e.encode(value[key])
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			e.encode(item)
+		}
+		buf.WriteByte(']')
+	case string:
+		e.encodeString(timestamp(value))
+	case json.Number:
+		buf.WriteString(value.String())
+	case bool:
+		if value {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// encodeString writes s as a JSON string
+func (e *encoder) encodeString(s string) {
+	// Encoding a string into a buffer cannot fail; the newline Encode adds is dropped
+	e.strings.Encode(s)
+	e.buf.Truncate(e.buf.Len() - 1)
+}
+
+// timestamp returns s in UTC when it is an RFC 3339 timestamp, and s otherwise
+func timestamp(s string) string {
+	// Most strings are ruled out without parsing
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[10] != 'T' {
+		return s
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,78 @@
+package canonical
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "sorted keys", input: `{"b":1,"a":{"z":true,"y":null}}`, want: `{"a":{"y":null,"z":true},"b":1}`},
+		{name: "whitespace", input: " [ 1 , \"two\" ,\n\t{ } ] ", want: `[1,"two",{}]`},
+		{name: "numbers kept as written", input: `[1.50,-0,1e3,12345678901234567890]`, want: `[1.50,-0,1e3,12345678901234567890]`},
+		{name: "escapes", input: `{"k":"<a&b> é \"q\""}`, want: `{"k":"<a&b> é \"q\""}`},
+		{name: "timestamp offset", input: `{"at":"2026-03-01T10:30:00+02:00"}`, want: `{"at":"2026-03-01T08:30:00Z"}`},
+		{name: "timestamp fraction", input: `["2026-03-01T08:30:00.500000000Z","2026-03-01T08:30:00.000Z"]`, want: `["2026-03-01T08:30:00.5Z","2026-03-01T08:30:00Z"]`},
+		{name: "not timestamps", input: `["2026-13-01T08:30:00Z","2026-03-01 08:30:00","2026-03-01T08:30:00Z trailing"]`,
+			want: `["2026-13-01T08:30:00Z","2026-03-01 08:30:00","2026-03-01T08:30:00Z trailing"]`},
+		{name: "invalid", input: `{"a":`, wantErr: true},
+		{name: "trailing data", input: `{} {}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Canonicalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("Canonicalize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalNormalizesTimes(t *testing.T) {
+	type event struct {
+		At     time.Time         `json:"at"`
+		Labels map[string]string `json:"labels"`
+	}
+	at := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	utc := event{At: at, Labels: map[string]string{"b": "2", "a": "1"}}
+	zoned := event{At: at.In(time.FixedZone("CET", 3600)), Labels: map[string]string{"a": "1", "b": "2"}}
+
+	standardUTC, _ := json.Marshal(utc)
+	standardZoned, _ := json.Marshal(zoned)
+	if string(standardUTC) == string(standardZoned) {
+		t.Fatal("expected encoding/json to encode the time zones differently")
+	}
+
+	got, err := Marshal(zoned)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"at":"2026-03-01T08:30:00Z","labels":{"a":"1","b":"2"}}`; string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	utcHash, _ := Hash(utc)
+	zonedHash, _ := Hash(zoned)
+	if utcHash != zonedHash || len(utcHash) != 64 {
+		t.Errorf("Hash() = %s and %s, want equal SHA-256 digests", utcHash, zonedHash)
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	if _, err := Marshal(func() {}); err == nil {
+		t.Error("Marshal() of a function error = nil, want an error")
+	}
+	if _, err := Hash(make(chan int)); err == nil {
+		t.Error("Hash() of a channel error = nil, want an error")
+	}
+}
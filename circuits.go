@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/types"
+)
+
+// setCircuitRetryAfter sets Retry-After to the seconds until the open circuit breaker of
+// the named application lets reads through again
+func (s *Server) setCircuitRetryAfter(c *gin.Context, application string) {
+	for _, circuit := range s.argocdService.OpenCircuits() {
+		if circuit.Application == application {
+			c.Header("Retry-After", strconv.Itoa(circuit.RetryAfterSeconds))
+			return
+		}
+	}
+}
+
+// getCircuits handles the application circuit breakers admin endpoint
+// @Summary List open application circuit breakers
+// @Description List the applications whose reads fail fast with 503 after APP_CIRCUIT_THRESHOLD consecutive failures within APP_CIRCUIT_WINDOW, and until when, with the breaker settings. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} types.ApplicationCircuitsResponse "Open circuits"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/circuits [get]
+func (s *Server) getCircuits(c *gin.Context) {
+	response := types.ApplicationCircuitsResponse{
+		Enabled:   s.config.AppCircuitThreshold > 0,
+		Threshold: s.config.AppCircuitThreshold,
+		Window:    s.config.AppCircuitWindow.String(),
+		Cooldown:  s.config.AppCircuitCooldown.String(),
+		Open:      s.argocdService.OpenCircuits(),
+	}
+	if response.Open == nil {
+		response.Open = []types.ApplicationCircuit{}
+	}
+	c.JSON(http.StatusOK, response)
+}
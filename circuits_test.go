@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+func TestApplicationCircuitOpenResponse(t *testing.T) {
+	server, mockService := setupAdminServer()
+	mockService.err = &services.CircuitOpenError{Application: "heavy", RetryAfter: 25 * time.Second}
+	mockService.openCircuits = []types.ApplicationCircuit{{Application: "heavy", RetryAfterSeconds: 25}}
+
+	for _, path := range []string{"/applications/heavy", "/applications/heavy/notifications"} {
+		w := serveMethod(server, http.MethodGet, path, nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusServiceUnavailable)
+		}
+		if got := w.Header().Get("Retry-After"); got != "25" {
+			t.Errorf("GET %s Retry-After = %q, want %q", path, got, "25")
+		}
+		var response types.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode error response: %v", err)
+		}
+		if response.ErrorCode != types.ErrorCodeCircuitOpen {
+			t.Errorf("GET %s errorCode = %q, want %q", path, response.ErrorCode, types.ErrorCodeCircuitOpen)
+		}
+	}
+}
+
+func TestGetCircuits(t *testing.T) {
+	server, mockService := setupAdminServer()
+	server.config.AppCircuitThreshold = 5
+	server.config.AppCircuitWindow = time.Minute
+	server.config.AppCircuitCooldown = 30 * time.Second
+	openUntil := time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC)
+	mockService.openCircuits = []types.ApplicationCircuit{{Application: "heavy", OpenedAt: openUntil.Add(-30 * time.Second), OpenUntil: openUntil, RetryAfterSeconds: 30}}
+
+	w := serveMethod(server, http.MethodGet, "/admin/circuits", map[string]string{"Authorization": "Bearer " + testAdminToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var response types.ApplicationCircuitsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.Enabled || response.Threshold != 5 || response.Window != "1m0s" || response.Cooldown != "30s" {
+		t.Errorf("settings = %+v, want enabled with threshold 5, window 1m0s and cooldown 30s", response)
+	}
+	if len(response.Open) != 1 || response.Open[0] != mockService.openCircuits[0] {
+		t.Errorf("open = %+v, want %+v", response.Open, mockService.openCircuits)
+	}
+
+	// No open circuit is an empty list
+	mockService.openCircuits = nil
+	w = serveMethod(server, http.MethodGet, "/admin/circuits", map[string]string{"Authorization": "Bearer " + testAdminToken})
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Open == nil {
+		t.Errorf("open = %v (%v), want an empty list", response.Open, err)
+	}
+
+	if w = serveMethod(server, http.MethodGet, "/admin/circuits", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,346 @@
+// Package client is a typed Go client for the proxy's HTTP API. Responses decode into the
+// types the server itself encodes, so client and server cannot disagree on their shape.
+//
+// Responses carrying an ETag are remembered, and repeating a request sends If-None-Match
+// so that an unchanged response costs the proxy a 304 and is decoded from the copy kept
+// here. The proxy does not paginate lists: it returns them whole, or cut to
+// MAX_RESPONSE_ITEMS with Meta describing the truncation.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// Headers understood by the proxy
+const (
+	apiKeyHeader    = "X-API-Key"
+	requestIDHeader = "X-Request-ID"
+)
+
+// maxCachedResponses is the number of ETag-tagged responses kept for revalidation
+const maxCachedResponses = 64
+
+// Options configures a Client
+type Options struct {
+	// BaseURL is the proxy's URL, such as http://argocd-proxy:8080
+	BaseURL string
+	// APIKey is sent as X-API-Key, required once the proxy sets API_KEYS_JSON
+	APIKey string
+	// Timeout bounds each request including reading the response; 0 leaves it to the
+	// request context
+	Timeout time.Duration
+	// HTTPClient makes the requests; nil uses a new http.Client
+	HTTPClient *http.Client
+}
+
+// Client calls the proxy. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	timeout    time.Duration
+	httpClient *http.Client
+
+	// mu guards the responses kept for ETag revalidation, oldest first in order
+	mu        sync.Mutex
+	responses map[string]cachedResponse
+	order     []string
+}
+
+// cachedResponse is a response body kept with its ETag
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// New returns a Client for the proxy at opts.BaseURL
+func New(opts Options) (*Client, error) {
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("base URL must be an absolute http or https URL, got %q", opts.BaseURL)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &Client{
+		baseURL:    base,
+		apiKey:     opts.APIKey,
+		timeout:    opts.Timeout,
+		httpClient: httpClient,
+		responses:  make(map[string]cachedResponse),
+	}, nil
+}
+
+// Error is a request the proxy answered with an error status. Response holds the decoded
+// ErrorResponse, or an application/problem+json document mapped onto one, and its
+// ErrorCode classifies the failure.
+type Error struct {
+	StatusCode int
+	Response   types.ErrorResponse
+	// RequestID is the proxy's X-Request-ID for the request, to find it in the proxy logs
+	RequestID string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	message := e.Response.Message
+	if message == "" {
+		message = e.Response.Error
+	}
+	if e.Response.ErrorCode == "" {
+		return fmt.Sprintf("argocd-proxy: %d: %s", e.StatusCode, message)
+	}
+	return fmt.Sprintf("argocd-proxy: %d %s: %s", e.StatusCode, e.Response.ErrorCode, message)
+}
+
+// ErrorCode returns the error code of err if it is or wraps an *Error, and "" otherwise
+func ErrorCode(err error) types.ErrorCode {
+	var clientErr *Error
+	if errors.As(err, &clientErr) {
+		return clientErr.Response.ErrorCode
+	}
+	return ""
+}
+
+// ApplicationListOptions selects and filters the applications returned by Applications.
+// Empty fields apply no filter.
+type ApplicationListOptions struct {
+	// Group or Project lists the applications of one project group or project instead
+	// of all visible applications; at most one may be set
+	Group   string
+	Project string
+
+	NamePrefix string
+	Image      string
+	// ImageMatch is substring (the default) or exact
+	ImageMatch string
+	// Health and Sync keep applications whose status is any of the listed values
+	Health []string
+	Sync   []string
+	// ExcludeDeleting drops applications pending deletion
+	ExcludeDeleting bool
+	// DestCluster keeps applications deploying to the cluster, by name or server URL
+	DestCluster string
+	// Owner requires OWNER_ANNOTATIONS on the proxy
+	Owner string
+	// LabelSelector and AnnotationSelector use Kubernetes label selector syntax
+	LabelSelector      string
+	AnnotationSelector string
+	// AutoSync keeps the applications ArgoCD syncs automatically when true and the
+	// manually synced ones when false
+	AutoSync *bool
+	// Sort is name or created; Descending reverses the order
+	Sort       string
+	Descending bool
+}
+
+// path returns the path segments of the endpoint listing the selected applications
+func (o ApplicationListOptions) path() ([]string, error) {
+	switch {
+	case o.Group != "" && o.Project != "":
+		return nil, errors.New("at most one of Group and Project may be set")
+	case o.Group != "":
+		return []string{"groups", o.Group, "applications"}, nil
+	case o.Project != "":
+		return []string{"projects", o.Project, "applications"}, nil
+	}
+	return []string{"applications"}, nil
+}
+
+// query returns the query parameters of the filters that are set
+func (o ApplicationListOptions) query() url.Values {
+	query := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	set("namePrefix", o.NamePrefix)
+	set("image", o.Image)
+	set("imageMatch", o.ImageMatch)
+	set("health", strings.Join(o.Health, ","))
+	set("sync", strings.Join(o.Sync, ","))
+	if o.ExcludeDeleting {
+		query.Set("includeDeleting", "false")
+	}
+	set("destCluster", o.DestCluster)
+	set("owner", o.Owner)
+	set("labelSelector", o.LabelSelector)
+	set("annotationSelector", o.AnnotationSelector)
+	if o.AutoSync != nil {
+		query.Set("autoSync", strconv.FormatBool(*o.AutoSync))
+	}
+	set("sort", o.Sort)
+	if o.Descending {
+		query.Set("order", "desc")
+	}
+	return query
+}
+
+// Applications lists the visible applications, or those of a project group or project,
+// matching opts
+func (c *Client) Applications(ctx context.Context, opts ApplicationListOptions) (types.ArgocdApplicationList, error) {
+	var list types.ArgocdApplicationList
+	path, err := opts.path()
+	if err != nil {
+		return list, err
+	}
+	err = c.get(ctx, path, opts.query(), &list)
+	return list, err
+}
+
+// Application returns the named application
+func (c *Client) Application(ctx context.Context, name string) (types.ArgocdApplication, error) {
+	var app types.ArgocdApplication
+	err := c.get(ctx, []string{"applications", name}, nil, &app)
+	return app, err
+}
+
+// ProjectGroups returns the configured project groups and the ungrouped projects
+func (c *Client) ProjectGroups(ctx context.Context) (config.ProjectGroupsResponse, error) {
+	var groups config.ProjectGroupsResponse
+	err := c.get(ctx, []string{"project-groups"}, nil, &groups)
+	return groups, err
+}
+
+// Health returns the proxy's health. A degraded proxy answers 503 with the same document,
+// which is returned without an error; check Status.
+func (c *Client) Health(ctx context.Context) (types.HealthResponse, error) {
+	var health types.HealthResponse
+	err := c.get(ctx, []string{"health"}, nil, &health, http.StatusServiceUnavailable)
+	return health, err
+}
+
+// get requests the path of the unescaped segments with query and decodes the response
+// into v. Statuses other than 200 and those in accept are returned as *Error.
+func (c *Client) get(ctx context.Context, segments []string, query url.Values, v any, accept ...int) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	endpoint := c.baseURL.JoinPath(segments...)
+	endpoint.RawQuery = query.Encode()
+	path := "/" + strings.Join(segments, "/")
+	key := endpoint.String()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	cached, haveCached := c.cached(key)
+	if haveCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading %s response: %w", path, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && haveCached:
+		body = cached.body
+	case resp.StatusCode == http.StatusOK || containsStatus(accept, resp.StatusCode):
+		if etag := resp.Header.Get("ETag"); etag != "" && resp.StatusCode == http.StatusOK {
+			c.remember(key, cachedResponse{etag: etag, body: body})
+		}
+	default:
+		return responseError(resp, body)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding %s response: %w", path, err)
+	}
+	return nil
+}
+
+// cached returns the response kept for key
+func (c *Client) cached(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.responses[key]
+	return response, ok
+}
+
+// remember keeps a response for key, forgetting the oldest beyond maxCachedResponses
+func (c *Client) remember(key string, response cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.responses[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.responses[key] = response
+	for len(c.order) > maxCachedResponses {
+		delete(c.responses, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// responseError builds the *Error of a failed response from its ErrorResponse or
+// problem details body, or from the status alone when the body is neither
+func responseError(resp *http.Response, body []byte) *Error {
+	clientErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestIDHeader)}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		var problem types.ProblemDetails
+		if err := json.Unmarshal(body, &problem); err == nil {
+			clientErr.Response = types.ErrorResponse{
+				Error:     problem.Title,
+				Message:   problem.Detail,
+				Code:      problem.Status,
+				ErrorCode: problem.ErrorCode,
+				Fields:    problem.Fields,
+				Profiles:  problem.Profiles,
+				Upstream:  problem.Upstream,
+			}
+			return clientErr
+		}
+	}
+	if err := json.Unmarshal(body, &clientErr.Response); err == nil && clientErr.Response.Code != 0 {
+		return clientErr
+	}
+
+	message := string(bytes.TrimSpace(body))
+	if len(message) > 256 {
+		message = message[:256]
+	}
+	clientErr.Response = types.ErrorResponse{Error: http.StatusText(resp.StatusCode), Message: message, Code: resp.StatusCode}
+	return clientErr
+}
+
+// containsStatus reports whether status is one of statuses
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// newTestClient returns a client for handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(Options{BaseURL: server.URL + "/", APIKey: "key", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	for _, baseURL := range []string{"", "argocd-proxy:8080", "ftp://argocd-proxy", "http://", "://bad"} {
+		if _, err := New(Options{BaseURL: baseURL}); err == nil {
+			t.Errorf("New(%q) error = nil, want an invalid base URL error", baseURL)
+		}
+	}
+	if _, err := New(Options{BaseURL: "https://argocd-proxy.example.com/api"}); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+}
+
+func TestApplicationsRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      ApplicationListOptions
+		wantPath  string
+		wantQuery url.Values
+	}{
+		{name: "all", wantPath: "/applications", wantQuery: url.Values{}},
+		{name: "group", opts: ApplicationListOptions{Group: "Platform Team"}, wantPath: "/groups/Platform Team/applications", wantQuery: url.Values{}},
+		{name: "project", opts: ApplicationListOptions{Project: "web"}, wantPath: "/projects/web/applications", wantQuery: url.Values{}},
+		{
+			name: "filters",
+			opts: ApplicationListOptions{
+				NamePrefix: "web-", Health: []string{"Degraded", "Missing"}, Sync: []string{"OutOfSync"},
+				ExcludeDeleting: true, LabelSelector: "team in (a,b)", AutoSync: new(false), Sort: "created", Descending: true,
+			},
+			wantPath: "/applications",
+			wantQuery: url.Values{
+				"namePrefix": {"web-"}, "health": {"Degraded,Missing"}, "sync": {"OutOfSync"},
+				"includeDeleting": {"false"}, "labelSelector": {"team in (a,b)"}, "autoSync": {"false"}, "sort": {"created"}, "order": {"desc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				fmt.Fprint(w, `{"items":[{"metadata":{"name":"web"}}]}`)
+			})
+			list, err := c.Applications(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Applications() error = %v", err)
+			}
+			if len(list.Items) != 1 || list.Items[0].Metadata.Name != "web" {
+				t.Errorf("Applications() = %+v, want the decoded list", list)
+			}
+			if got.URL.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", got.URL.Path, tt.wantPath)
+			}
+			if query := got.URL.Query(); query.Encode() != tt.wantQuery.Encode() {
+				t.Errorf("query = %v, want %v", query, tt.wantQuery)
+			}
+			if key := got.Header.Get(apiKeyHeader); key != "key" {
+				t.Errorf("%s = %q, want the API key", apiKeyHeader, key)
+			}
+		})
+	}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) { t.Error("unexpected request") })
+	if _, err := c.Applications(context.Background(), ApplicationListOptions{Group: "a", Project: "b"}); err == nil {
+		t.Error("Applications() with a group and a project error = nil, want an error")
+	}
+}
+
+func TestETagRevalidation(t *testing.T) {
+	const etag = `"v1"`
+	var requests, notModified int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `{"metadata":{"name":"web"}}`)
+	})
+
+	for i := 0; i < 3; i++ {
+		app, err := c.Application(context.Background(), "web")
+		if err != nil {
+			t.Fatalf("Application() call %d error = %v", i+1, err)
+		}
+		if app.Metadata.Name != "web" {
+			t.Errorf("Application() call %d = %+v, want the cached application", i+1, app)
+		}
+	}
+	if requests != 3 || notModified != 2 {
+		t.Errorf("requests = %d with %d not modified, want 3 with 2", requests, notModified)
+	}
+}
+
+func TestRememberIsBounded(t *testing.T) {
+	c, _ := New(Options{BaseURL: "http://argocd-proxy"})
+	for i := 0; i < maxCachedResponses+10; i++ {
+		c.remember(fmt.Sprintf("key-%d", i), cachedResponse{etag: "e"})
+	}
+	if len(c.responses) != maxCachedResponses || len(c.order) != maxCachedResponses {
+		t.Errorf("kept %d responses in order %d, want %d", len(c.responses), len(c.order), maxCachedResponses)
+	}
+	if _, ok := c.cached("key-0"); ok {
+		t.Error("expected the oldest response to be forgotten")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+		body        any
+		wantCode    types.ErrorCode
+		wantMessage string
+		wantFields  []string
+	}{
+		{
+			name:        "error response",
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+			body: types.ErrorResponse{
+				Error: "Bad Request", Message: "Invalid query parameters", Code: http.StatusBadRequest,
+				ErrorCode: types.ErrorCodeInvalidParam, Fields: []string{"sort"},
+			},
+			wantCode:    types.ErrorCodeInvalidParam,
+			wantMessage: "Invalid query parameters",
+			wantFields:  []string{"sort"},
+		},
+		{
+			name:        "problem details",
+			contentType: "application/problem+json",
+			status:      http.StatusNotFound,
+			body: types.ProblemDetails{
+				Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound,
+				Detail: "application 'web' not found", ErrorCode: types.ErrorCodeNotFound,
+			},
+			wantCode:    types.ErrorCodeNotFound,
+			wantMessage: "application 'web' not found",
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			status:      http.StatusBadGateway,
+			body:        "upstream connect error",
+			wantMessage: "upstream connect error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set(requestIDHeader, "req-1")
+				w.WriteHeader(tt.status)
+				if text, ok := tt.body.(string); ok {
+					fmt.Fprint(w, text)
+					return
+				}
+				json.NewEncoder(w).Encode(tt.body)
+			})
+
+			_, err := c.Application(context.Background(), "web")
+			var clientErr *Error
+			if !errors.As(err, &clientErr) {
+				t.Fatalf("Application() error = %v, want *Error", err)
+			}
+			if clientErr.StatusCode != tt.status || clientErr.Response.Code != tt.status || clientErr.RequestID != "req-1" {
+				t.Errorf("error status %d code %d request ID %q, want %d and req-1", clientErr.StatusCode, clientErr.Response.Code, clientErr.RequestID, tt.status)
+			}
+			if got := ErrorCode(fmt.Errorf("wrapped: %w", err)); got != tt.wantCode {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.wantCode)
+			}
+			if clientErr.Response.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", clientErr.Response.Message, tt.wantMessage)
+			}
+			if !reflect.DeepEqual(clientErr.Response.Fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", clientErr.Response.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestHealthDegraded(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"status":"degraded"}`)
+	})
+	health, err := c.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health() error = %v, want the degraded health without an error", err)
+	}
+	if health.Status != "degraded" {
+		t.Errorf("Health().Status = %q, want degraded", health.Status)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer server.Close()
+	defer close(release)
+
+	c, _ := New(Options{BaseURL: server.URL, Timeout: 20 * time.Millisecond})
+	if _, err := c.ProjectGroups(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ProjectGroups() error = %v, want the deadline exceeded", err)
+	}
+}
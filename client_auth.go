@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/audit"
+	"argocd-proxy/config"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// apiKeyHeader carries the client API key when API_KEYS_JSON or API_KEYS is set
+const apiKeyHeader = "X-API-Key"
+
+// anonymousReadOnlyRoutes are the summary endpoints ANONYMOUS_READ_ONLY serves to callers
+// without an API key. Full lists and per-application details still require a key.
+var anonymousReadOnlyRoutes = map[string]bool{
+	"/health":                     true,
+	"/health/history":             true,
+	"/readyz":                     true,
+	"/info":                       true,
+	"/permissions":                true,
+	"/project-groups":             true,
+	"/groups/:group/drift":        true,
+	"/groups/:group/deploy-stats": true,
+}
+
+// clientAuthMiddleware requires a valid X-API-Key header once API keys are set, and stores
+// the key's name as the caller identity for the audit log and client statistics and its
+// scope for the handlers. Keys restricted by API_KEY_GROUPS get 403 outside their groups.
+// Paths matching AUTH_EXEMPT_ROUTES, and with ANONYMOUS_READ_ONLY the summary endpoints,
+// are served without a key. Admin endpoints are guarded by ADMIN_TOKEN instead, and
+// Swagger and CORS preflight requests cannot carry the header.
+func (s *Server) clientAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := s.apiKey(c.GetHeader(apiKeyHeader)); ok {
+			c.Set(audit.ClientKey, key.Name)
+			c.Set(callerScopeKey, callerScope{Client: key.Name, Groups: key.Groups})
+			if !s.scopeAllowsRequest(key.Groups, c) {
+				s.errorResponse(c, http.StatusForbidden, types.ErrorCodeClientForbidden, fmt.Sprintf("API key %q is restricted to project groups %s", key.Name, strings.Join(key.Groups, ", ")), "")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if s.authExempt(c) {
+			c.Next()
+			return
+		}
+
+		s.errorResponse(c, http.StatusUnauthorized, types.ErrorCodeClientUnauthorized, "A valid API key is required", "")
+		c.Abort()
+	}
+}
+
+// apiKey returns the API key presented, comparing it against every configured key in
+// constant time
+func (s *Server) apiKey(presented string) (config.APIKey, bool) {
+	if presented == "" {
+		return config.APIKey{}, false
+	}
+	var match config.APIKey
+	found := false
+	for _, key := range s.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(key.Key)) == 1 {
+			match, found = key, true
+		}
+	}
+	return match, found
+}
+
+// authExempt reports whether a request without a valid API key may be served anyway
+func (s *Server) authExempt(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	switch {
+	case c.Request.Method == http.MethodOptions,
+		strings.HasPrefix(path, "/admin/"),
+		s.isSwaggerPath(path):
+		return true
+	case s.config.AnonymousReadOnly && isReadMethod(c.Request.Method) && anonymousReadOnlyRoutes[c.FullPath()]:
+		return true
+	}
+	return s.matchesAuthExemptRoute(path)
+}
+
+// matchesAuthExemptRoute reports whether path matches one of AUTH_EXEMPT_ROUTES. Route
+// templates match too, their parameters standing for the * of a pattern.
+func (s *Server) matchesAuthExemptRoute(path string) bool {
+	for _, pattern := range s.config.AuthExemptRoutes {
+		if services.WildcardMatch(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadMethod reports whether method only reads
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
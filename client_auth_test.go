@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/audit"
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+const testAPIKey = "statuspage-key-0123456789"
+
+// setupClientAuthServer builds a server requiring API keys, with exempt routes and the
+// anonymous read-only mode as given
+func setupClientAuthServer(exempt []string, anonymousReadOnly bool) *Server {
+	server := setupTestServer()
+	server.config.APIKeys = []config.APIKey{{Name: "statuspage", Key: testAPIKey}}
+	server.config.AuthExemptRoutes = exempt
+	server.config.AnonymousReadOnly = anonymousReadOnly
+	server.setupRouter()
+	return server
+}
+
+func TestClientAuth(t *testing.T) {
+	exempt := []string{"/health", "/applications/*/sync-windows"}
+
+	tests := []struct {
+		name              string
+		method            string
+		path              string
+		key               string
+		anonymousReadOnly bool
+		wantUnauthorized  bool
+	}{
+		{name: "list without key", method: http.MethodGet, path: "/applications", wantUnauthorized: true},
+		{name: "list with key", method: http.MethodGet, path: "/applications", key: testAPIKey},
+		{name: "list with wrong key", method: http.MethodGet, path: "/applications", key: "wrong-key-0123456789", wantUnauthorized: true},
+		{name: "exempt route", method: http.MethodGet, path: "/health"},
+		{name: "exempt wildcard route", method: http.MethodGet, path: "/applications/web/sync-windows"},
+		{name: "wildcard does not exempt siblings", method: http.MethodGet, path: "/applications/web/diff", wantUnauthorized: true},
+		{name: "exempt route is not a prefix", method: http.MethodGet, path: "/health/history", wantUnauthorized: true},
+		{name: "unknown route without key", method: http.MethodGet, path: "/does-not-exist", wantUnauthorized: true},
+		{name: "CORS preflight", method: http.MethodOptions, path: "/applications"},
+		{name: "summary without anonymous mode", method: http.MethodGet, path: "/info", wantUnauthorized: true},
+		{name: "anonymous summary", method: http.MethodGet, path: "/info", anonymousReadOnly: true},
+		{name: "anonymous summary HEAD", method: http.MethodHead, path: "/project-groups", anonymousReadOnly: true},
+		{name: "anonymous group summary", method: http.MethodGet, path: "/groups/frontend/drift", anonymousReadOnly: true},
+		{name: "anonymous full list", method: http.MethodGet, path: "/applications", anonymousReadOnly: true, wantUnauthorized: true},
+		{name: "anonymous application details", method: http.MethodGet, path: "/applications/web", anonymousReadOnly: true, wantUnauthorized: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupClientAuthServer(exempt, tt.anonymousReadOnly)
+
+			headers := map[string]string{"Origin": "https://status.example.com", "Access-Control-Request-Method": "GET"}
+			if tt.key != "" {
+				headers[apiKeyHeader] = tt.key
+			}
+			w := serveMethod(server, tt.method, tt.path, headers)
+
+			if unauthorized := w.Code == http.StatusUnauthorized; unauthorized != tt.wantUnauthorized {
+				t.Errorf("%s %s status = %d, want unauthorized %v", tt.method, tt.path, w.Code, tt.wantUnauthorized)
+			}
+			if tt.wantUnauthorized && tt.method == http.MethodGet {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if response.ErrorCode != types.ErrorCodeClientUnauthorized {
+					t.Errorf("errorCode = %q, want %q", response.ErrorCode, types.ErrorCodeClientUnauthorized)
+				}
+			}
+		})
+	}
+}
+
+func TestClientAuthDisabledWithoutKeys(t *testing.T) {
+	server := setupTestServer()
+
+	if w := serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /applications status = %d, want %d without API_KEYS", w.Code, http.StatusOK)
+	}
+}
+
+func TestClientAuthIdentity(t *testing.T) {
+	server := setupClientAuthServer(nil, false)
+
+	var identity string
+	server.router.GET("/identity", func(c *gin.Context) {
+		identity = c.GetString(audit.ClientKey)
+	})
+	serveMethod(server, http.MethodGet, "/identity", map[string]string{apiKeyHeader: testAPIKey})
+
+	if identity != "statuspage" {
+		t.Errorf("client identity = %q, want statuspage", identity)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/client"
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// Contract tests run the client package against the real router, so a change to a route,
+// parameter or response type that the client does not follow fails here
+
+// contractApplications are served by the mock service behind the contract tests
+var contractApplications = types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+	{
+		Metadata: types.ArgocdApplicationMetadata{Name: "web-frontend", Labels: map[string]string{"team": "web"}},
+		Spec: types.ArgocdApplicationSpec{
+			Project:    "web-app",
+			SyncPolicy: &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{Prune: true}},
+		},
+		Status: types.ArgocdApplicationStatus{
+			Health: types.ArgocdApplicationHealth{Status: "Healthy"},
+			Sync:   types.ArgocdApplicationSync{Status: "Synced"},
+		},
+	},
+	{
+		Metadata: types.ArgocdApplicationMetadata{Name: "payments", Labels: map[string]string{"team": "billing"}},
+		Spec:     types.ArgocdApplicationSpec{Project: "billing"},
+		Status: types.ArgocdApplicationStatus{
+			Health: types.ArgocdApplicationHealth{Status: "Degraded"},
+			Sync:   types.ArgocdApplicationSync{Status: "OutOfSync"},
+		},
+	},
+}}
+
+// newContractClient serves server's router and returns a client for it with apiKey
+func newContractClient(t *testing.T, server *Server, apiKey string) *client.Client {
+	t.Helper()
+	proxy := httptest.NewServer(server.router)
+	t.Cleanup(proxy.Close)
+	c, err := client.New(client.Options{BaseURL: proxy.URL, APIKey: apiKey, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	return c
+}
+
+// applicationNames returns the names of the listed applications
+func applicationNames(list types.ArgocdApplicationList) []string {
+	names := []string{}
+	for _, app := range list.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	return names
+}
+
+func TestClientContractApplications(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = contractApplications
+	mockService.projectNames = []string{"web-app", "billing"}
+	c := newContractClient(t, server, "")
+
+	tests := []struct {
+		name      string
+		opts      client.ApplicationListOptions
+		wantNames []string
+	}{
+		{name: "all", wantNames: []string{"web-frontend", "payments"}},
+		{name: "sorted descending", opts: client.ApplicationListOptions{Sort: "name", Descending: true}, wantNames: []string{"web-frontend", "payments"}},
+		{name: "sorted", opts: client.ApplicationListOptions{Sort: "name"}, wantNames: []string{"payments", "web-frontend"}},
+		{name: "health", opts: client.ApplicationListOptions{Health: []string{"Degraded", "Missing"}}, wantNames: []string{"payments"}},
+		{name: "sync", opts: client.ApplicationListOptions{Sync: []string{"Synced"}}, wantNames: []string{"web-frontend"}},
+		{name: "name prefix", opts: client.ApplicationListOptions{NamePrefix: "pay"}, wantNames: []string{"payments"}},
+		{name: "label selector", opts: client.ApplicationListOptions{LabelSelector: "team in (web, platform)"}, wantNames: []string{"web-frontend"}},
+		{name: "auto-sync", opts: client.ApplicationListOptions{AutoSync: new(true)}, wantNames: []string{"web-frontend"}},
+		{name: "manual", opts: client.ApplicationListOptions{AutoSync: new(false)}, wantNames: []string{"payments"}},
+		{name: "project", opts: client.ApplicationListOptions{Project: "billing"}, wantNames: []string{"payments"}},
+		{name: "group", opts: client.ApplicationListOptions{Group: "Frontend"}, wantNames: []string{"web-frontend", "payments"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := c.Applications(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Applications() error = %v", err)
+			}
+			if got := applicationNames(list); !reflect.DeepEqual(got, tt.wantNames) {
+				t.Errorf("Applications() = %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestClientContractApplication(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	c := newContractClient(t, server, "")
+
+	mockService.application = contractApplications.Items[0]
+	app, err := c.Application(context.Background(), "web-frontend")
+	if err != nil {
+		t.Fatalf("Application() error = %v", err)
+	}
+	if !reflect.DeepEqual(app, contractApplications.Items[0]) {
+		t.Errorf("Application() = %+v, want %+v", app, contractApplications.Items[0])
+	}
+
+	mockService.application = types.ArgocdApplication{}
+	if _, err := c.Application(context.Background(), "missing"); client.ErrorCode(err) != types.ErrorCodeNotFound {
+		t.Errorf("Application() of a missing application error = %v, want %s", err, types.ErrorCodeNotFound)
+	}
+}
+
+func TestClientContractErrors(t *testing.T) {
+	for _, format := range []string{config.ErrorFormatJSON, config.ErrorFormatProblem} {
+		t.Run(format, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.ErrorFormat = format
+			mockService := server.argocdService.(*MockArgocdService)
+			c := newContractClient(t, server, "")
+
+			_, err := c.Applications(context.Background(), client.ApplicationListOptions{Sort: "size"})
+			var clientErr *client.Error
+			if !errors.As(err, &clientErr) {
+				t.Fatalf("Applications() with an invalid sort error = %v, want *client.Error", err)
+			}
+			if clientErr.StatusCode != http.StatusBadRequest || clientErr.Response.ErrorCode != types.ErrorCodeInvalidParam {
+				t.Errorf("error = %d %s, want %d %s", clientErr.StatusCode, clientErr.Response.ErrorCode, http.StatusBadRequest, types.ErrorCodeInvalidParam)
+			}
+			if !reflect.DeepEqual(clientErr.Response.Fields, []string{"sort"}) {
+				t.Errorf("error fields = %v, want [sort]", clientErr.Response.Fields)
+			}
+			if clientErr.RequestID == "" {
+				t.Error("expected the error to carry the request ID")
+			}
+
+			if _, err := c.Applications(context.Background(), client.ApplicationListOptions{Group: "Unknown"}); client.ErrorCode(err) != types.ErrorCodeNotFound {
+				t.Errorf("Applications() of an unknown group error = %v, want %s", err, types.ErrorCodeNotFound)
+			}
+
+			mockService.err = fmt.Errorf("connection refused")
+			if _, err := c.Applications(context.Background(), client.ApplicationListOptions{}); client.ErrorCode(err) != types.ErrorCodeUpstreamDown {
+				t.Errorf("Applications() with ArgoCD down error = %v, want %s", err, types.ErrorCodeUpstreamDown)
+			}
+		})
+	}
+}
+
+func TestClientContractAPIKey(t *testing.T) {
+	server := setupClientAuthServer(nil, false)
+	server.argocdService.(*MockArgocdService).applications = contractApplications
+
+	if _, err := newContractClient(t, server, "").Applications(context.Background(), client.ApplicationListOptions{}); client.ErrorCode(err) != types.ErrorCodeClientUnauthorized {
+		t.Errorf("Applications() without an API key error = %v, want %s", err, types.ErrorCodeClientUnauthorized)
+	}
+	list, err := newContractClient(t, server, testAPIKey).Applications(context.Background(), client.ApplicationListOptions{})
+	if err != nil || len(list.Items) != len(contractApplications.Items) {
+		t.Errorf("Applications() with the API key = %v, %v, want all applications", applicationNames(list), err)
+	}
+}
+
+func TestClientContractETagRevalidation(t *testing.T) {
+	server, mockService := setupResponseCacheServer()
+	var notModified int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, r)
+		if recorder.Code == http.StatusNotModified {
+			notModified++
+		}
+		for name, values := range recorder.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	}))
+	defer proxy.Close()
+	c, err := client.New(client.Options{BaseURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		list, err := c.Applications(context.Background(), client.ApplicationListOptions{})
+		if err != nil {
+			t.Fatalf("Applications() call %d error = %v", i+1, err)
+		}
+		if got := applicationNames(list); !reflect.DeepEqual(got, []string{"web"}) {
+			t.Errorf("Applications() call %d = %v, want [web]", i+1, got)
+		}
+	}
+	if notModified != 1 {
+		t.Errorf("not modified responses = %d, want the second call revalidated", notModified)
+	}
+	if mockService.applicationsCalls != 1 {
+		t.Errorf("service calls = %d, want 1", mockService.applicationsCalls)
+	}
+}
+
+func TestClientContractHealthAndProjectGroups(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.projectNames = []string{"web-app", "billing"}
+	c := newContractClient(t, server, "")
+
+	health, err := c.Health(context.Background())
+	if err != nil || health.Status != "healthy" {
+		t.Errorf("Health() = %q, %v, want healthy", health.Status, err)
+	}
+
+	groups, err := c.ProjectGroups(context.Background())
+	if err != nil {
+		t.Fatalf("ProjectGroups() error = %v", err)
+	}
+	if len(groups.Groups) != 1 || groups.Groups[0].Name != "Frontend" {
+		t.Errorf("ProjectGroups().Groups = %+v, want Frontend", groups.Groups)
+	}
+}
@@ -0,0 +1,290 @@
+// Package clientstats keeps rolling request statistics per API consumer, so operators
+// can see which clients generate the most load. Clients are identified by a salted hash
+// of their identity; raw identities are never stored, reported or used as metric labels.
+package clientstats
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/audit"
+	"argocd-proxy/metrics"
+)
+
+// idLength is the number of hex characters kept from the client identity hash
+const idLength = 16
+
+// unmatchedRoute is the route recorded for requests that matched no route
+const unmatchedRoute = "unmatched"
+
+// Options configures a Tracker
+type Options struct {
+	// MaxClients bounds the number of tracked clients; the least recently seen client is
+	// evicted to make room for a new one
+	MaxClients int
+	// HalfLife is the decay interval: every HalfLife all counters are halved
+	HalfLife time.Duration
+	// IdleTimeout evicts clients not seen for this long at the next decay
+	IdleTimeout time.Duration
+	// TopRoutes is the number of busiest routes reported per client
+	TopRoutes int
+	// Salt keys the identity hash. An empty salt is replaced with a random one, so
+	// identifiers are only stable for the life of the process.
+	Salt string
+}
+
+// RouteStats is the decayed request count of one route template
+type RouteStats struct {
+	Route    string `json:"route"`
+	Requests int64  `json:"requests"`
+}
+
+// ClientStats are the decayed counters of one client
+type ClientStats struct {
+	// Client is the hashed client identifier, matching the client metric label
+	Client    string       `json:"client"`
+	Requests  int64        `json:"requests"`
+	Bytes     int64        `json:"bytes"`
+	Errors    int64        `json:"errors"`
+	LastSeen  time.Time    `json:"lastSeen"`
+	TopRoutes []RouteStats `json:"topRoutes"`
+}
+
+// Report is a snapshot of every tracked client, busiest first
+type Report struct {
+	Clients    []ClientStats `json:"clients"`
+	MaxClients int           `json:"maxClients"`
+	// HalfLife is how often the counters are halved, as a Go duration
+	HalfLife string `json:"halfLife"`
+}
+
+// counters are the decayed totals of one client
+type counters struct {
+	requests float64
+	bytes    float64
+	errors   float64
+	routes   map[string]float64
+	lastSeen time.Time
+}
+
+// Tracker maintains per-client counters. It is safe for concurrent use.
+type Tracker struct {
+	opts Options
+	salt []byte
+	now  func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*counters
+}
+
+// New creates a tracker. Non-positive limits are raised to one.
+func New(opts Options) *Tracker {
+	opts.MaxClients = max(opts.MaxClients, 1)
+	opts.TopRoutes = max(opts.TopRoutes, 1)
+
+	salt := []byte(opts.Salt)
+	if len(salt) == 0 {
+		salt = make([]byte, 32)
+		_, _ = rand.Read(salt)
+	}
+	opts.Salt = ""
+
+	return &Tracker{
+		opts:    opts,
+		salt:    salt,
+		now:     time.Now,
+		clients: make(map[string]*counters),
+	}
+}
+
+// Hash returns the client identifier for identity: a truncated HMAC-SHA256 keyed with
+// the tracker's salt, so identities cannot be recovered or guessed without the salt
+func (t *Tracker) Hash(identity string) string {
+	return HashIdentity(t.salt, identity)
+}
+
+// HashIdentity returns the client identifier of identity under salt
+func HashIdentity(salt []byte, identity string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(identity))
+	return hex.EncodeToString(mac.Sum(nil))[:idLength]
+}
+
+// Record counts one request of the client with the given identity
+func (t *Tracker) Record(identity, route string, status, bytes int) {
+	id := t.Hash(identity)
+	if route == "" {
+		route = unmatchedRoute
+	}
+	bytes = max(bytes, 0)
+	failed := status >= 400
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	client, ok := t.clients[id]
+	if !ok {
+		if len(t.clients) >= t.opts.MaxClients {
+			t.evictLeastRecentLocked()
+		}
+		client = &counters{routes: make(map[string]float64)}
+		t.clients[id] = client
+	}
+	client.requests++
+	client.bytes += float64(bytes)
+	if failed {
+		client.errors++
+	}
+	client.routes[route]++
+	client.lastSeen = t.now()
+
+	// Metrics are updated under the lock so an eviction cannot interleave and leave a
+	// series behind for a forgotten client
+	metrics.ClientRequestsTotal.WithLabelValues(id).Inc()
+	metrics.ClientResponseBytesTotal.WithLabelValues(id).Add(float64(bytes))
+	if failed {
+		metrics.ClientErrorsTotal.WithLabelValues(id).Inc()
+	}
+}
+
+// Decay halves every counter and evicts clients idle for longer than the idle timeout
+func (t *Tracker) Decay() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := t.now().Add(-t.opts.IdleTimeout)
+	for id, client := range t.clients {
+		if t.opts.IdleTimeout > 0 && client.lastSeen.Before(cutoff) {
+			t.evictLocked(id)
+			continue
+		}
+		client.requests /= 2
+		client.bytes /= 2
+		client.errors /= 2
+		for route, requests := range client.routes {
+			// Routes that decayed below a single request no longer rank
+			if requests /= 2; requests < 0.5 {
+				delete(client.routes, route)
+			} else {
+				client.routes[route] = requests
+			}
+		}
+	}
+}
+
+// Run decays the counters every half-life until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	if t.opts.HalfLife <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(t.opts.HalfLife)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Decay()
+		}
+	}
+}
+
+// Snapshot returns the counters of every tracked client, busiest first
+func (t *Tracker) Snapshot() Report {
+	t.mu.Lock()
+	clients := make([]ClientStats, 0, len(t.clients))
+	for id, client := range t.clients {
+		clients = append(clients, ClientStats{
+			Client:    id,
+			Requests:  round(client.requests),
+			Bytes:     round(client.bytes),
+			Errors:    round(client.errors),
+			LastSeen:  client.lastSeen.UTC(),
+			TopRoutes: topRoutes(client.routes, t.opts.TopRoutes),
+		})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Requests != clients[j].Requests {
+			return clients[i].Requests > clients[j].Requests
+		}
+		return clients[i].Client < clients[j].Client
+	})
+
+	return Report{
+		Clients:    clients,
+		MaxClients: t.opts.MaxClients,
+		HalfLife:   t.opts.HalfLife.String(),
+	}
+}
+
+// Middleware records every request against its client: the identity stored under
+// audit.ClientKey by authentication middleware, or the client IP
+func Middleware(tracker *Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		identity := c.GetString(audit.ClientKey)
+		if identity == "" {
+			identity = c.ClientIP()
+		}
+		tracker.Record(identity, c.FullPath(), c.Writer.Status(), c.Writer.Size())
+	}
+}
+
+// evictLeastRecentLocked evicts the client seen least recently
+func (t *Tracker) evictLeastRecentLocked() {
+	var oldestID string
+	var oldest time.Time
+	for id, client := range t.clients {
+		if oldestID == "" || client.lastSeen.Before(oldest) {
+			oldestID, oldest = id, client.lastSeen
+		}
+	}
+	if oldestID != "" {
+		t.evictLocked(oldestID)
+	}
+}
+
+// evictLocked forgets a client and deletes its metric series, keeping the label
+// cardinality bounded by MaxClients
+func (t *Tracker) evictLocked(id string) {
+	delete(t.clients, id)
+	metrics.ClientRequestsTotal.DeleteLabelValues(id)
+	metrics.ClientResponseBytesTotal.DeleteLabelValues(id)
+	metrics.ClientErrorsTotal.DeleteLabelValues(id)
+}
+
+// topRoutes returns the n routes with the most requests, ties broken by route
+func topRoutes(routes map[string]float64, n int) []RouteStats {
+	top := make([]RouteStats, 0, len(routes))
+	for route, requests := range routes {
+		top = append(top, RouteStats{Route: route, Requests: round(requests)})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Requests != top[j].Requests {
+			return top[i].Requests > top[j].Requests
+		}
+		return top[i].Route < top[j].Route
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// round converts a decayed counter to a whole count
+func round(value float64) int64 {
+	return int64(math.Round(value))
+}
@@ -0,0 +1,245 @@
+package clientstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/audit"
+	"argocd-proxy/metrics"
+)
+
+// fixedClock returns a tracker clock that reports *now
+func fixedClock(now *time.Time) func() time.Time {
+	return func() time.Time { return *now }
+}
+
+// useTestMetrics points the package-level metrics at a fresh instance for one test
+func useTestMetrics(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	t.Cleanup(func() { metrics.SetDefault(original) })
+}
+
+func TestHashIdentity(t *testing.T) {
+	salt := []byte("salt-a")
+
+	id := HashIdentity(salt, "10.0.0.12")
+	if len(id) != idLength {
+		t.Errorf("HashIdentity() = %q, want %d hex characters", id, idLength)
+	}
+	if again := HashIdentity(salt, "10.0.0.12"); again != id {
+		t.Errorf("HashIdentity() is not stable: %q then %q", id, again)
+	}
+	if other := HashIdentity(salt, "10.0.0.13"); other == id {
+		t.Errorf("HashIdentity() gave %q to two identities", id)
+	}
+	if salted := HashIdentity([]byte("salt-b"), "10.0.0.12"); salted == id {
+		t.Errorf("HashIdentity() ignores the salt: %q", id)
+	}
+	if strings.Contains(id, "10.0.0.12") {
+		t.Errorf("HashIdentity() = %q contains the identity", id)
+	}
+}
+
+func TestNewRandomSalt(t *testing.T) {
+	a := New(Options{})
+	b := New(Options{})
+	if a.Hash("client") == b.Hash("client") {
+		t.Error("trackers without a salt produced the same identifier")
+	}
+
+	if New(Options{Salt: "fixed"}).Hash("client") != New(Options{Salt: "fixed"}).Hash("client") {
+		t.Error("trackers with the same salt produced different identifiers")
+	}
+}
+
+func TestRecordAndSnapshot(t *testing.T) {
+	useTestMetrics(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tracker := New(Options{MaxClients: 10, TopRoutes: 2, HalfLife: time.Minute, Salt: "test"})
+	tracker.now = fixedClock(&now)
+
+	tracker.Record("ci-bot", "/applications", http.StatusOK, 100)
+	tracker.Record("ci-bot", "/applications", http.StatusOK, 100)
+	tracker.Record("ci-bot", "/applications/:name", http.StatusNotFound, 20)
+	tracker.Record("ci-bot", "/projects", http.StatusOK, 30)
+	tracker.Record("dashboard", "", http.StatusBadGateway, 10)
+
+	want := Report{
+		Clients: []ClientStats{
+			{
+				Client:   tracker.Hash("ci-bot"),
+				Requests: 4,
+				Bytes:    250,
+				Errors:   1,
+				LastSeen: now,
+				TopRoutes: []RouteStats{
+					{Route: "/applications", Requests: 2},
+					{Route: "/applications/:name", Requests: 1},
+				},
+			},
+			{
+				Client:    tracker.Hash("dashboard"),
+				Requests:  1,
+				Bytes:     10,
+				Errors:    1,
+				LastSeen:  now,
+				TopRoutes: []RouteStats{{Route: unmatchedRoute, Requests: 1}},
+			},
+		},
+		MaxClients: 10,
+		HalfLife:   "1m0s",
+	}
+	if got := tracker.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %+v, want %+v", got, want)
+	}
+
+	id := tracker.Hash("ci-bot")
+	if got := testutil.ToFloat64(metrics.ClientRequestsTotal.WithLabelValues(id)); got != 4 {
+		t.Errorf("client requests metric = %v, want 4", got)
+	}
+	if got := testutil.ToFloat64(metrics.ClientResponseBytesTotal.WithLabelValues(id)); got != 250 {
+		t.Errorf("client bytes metric = %v, want 250", got)
+	}
+	if got := testutil.ToFloat64(metrics.ClientErrorsTotal.WithLabelValues(id)); got != 1 {
+		t.Errorf("client errors metric = %v, want 1", got)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	useTestMetrics(t)
+	tracker := New(Options{TopRoutes: 5, Salt: "test"})
+
+	for range 4 {
+		tracker.Record("ci-bot", "/applications", http.StatusOK, 100)
+	}
+	tracker.Record("ci-bot", "/projects", http.StatusInternalServerError, 10)
+
+	tracker.Decay()
+	stats := tracker.Snapshot().Clients[0]
+	if stats.Requests != 3 || stats.Bytes != 205 || stats.Errors != 1 {
+		t.Errorf("after one decay requests = %d, bytes = %d, errors = %d; want 3, 205 and 1 (rounded)", stats.Requests, stats.Bytes, stats.Errors)
+	}
+	if want := []RouteStats{{Route: "/applications", Requests: 2}, {Route: "/projects", Requests: 1}}; !reflect.DeepEqual(stats.TopRoutes, want) {
+		t.Errorf("after one decay routes = %+v, want %+v", stats.TopRoutes, want)
+	}
+
+	tracker.Decay()
+	stats = tracker.Snapshot().Clients[0]
+	if want := []RouteStats{{Route: "/applications", Requests: 1}}; !reflect.DeepEqual(stats.TopRoutes, want) {
+		t.Errorf("after two decays routes = %+v, want %+v", stats.TopRoutes, want)
+	}
+
+	// Prometheus counters stay monotonic
+	if got := testutil.ToFloat64(metrics.ClientRequestsTotal.WithLabelValues(tracker.Hash("ci-bot"))); got != 5 {
+		t.Errorf("client requests metric after decay = %v, want 5", got)
+	}
+}
+
+func TestEvictIdleClients(t *testing.T) {
+	useTestMetrics(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tracker := New(Options{MaxClients: 10, IdleTimeout: time.Hour, Salt: "test"})
+	tracker.now = fixedClock(&now)
+
+	tracker.Record("idle", "/applications", http.StatusOK, 10)
+	now = now.Add(50 * time.Minute)
+	tracker.Record("active", "/applications", http.StatusOK, 10)
+	now = now.Add(20 * time.Minute)
+
+	tracker.Decay()
+
+	report := tracker.Snapshot()
+	if len(report.Clients) != 1 || report.Clients[0].Client != tracker.Hash("active") {
+		t.Fatalf("Snapshot() after decay = %+v, want only the active client", report.Clients)
+	}
+	if got := testutil.CollectAndCount(metrics.ClientRequestsTotal); got != 1 {
+		t.Errorf("client request series = %d, want 1 after evicting the idle client", got)
+	}
+}
+
+func TestEvictLeastRecentAtCapacity(t *testing.T) {
+	useTestMetrics(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tracker := New(Options{MaxClients: 2, Salt: "test"})
+	tracker.now = fixedClock(&now)
+
+	for _, client := range []string{"first", "second", "first", "third"} {
+		tracker.Record(client, "/applications", http.StatusOK, 10)
+		now = now.Add(time.Second)
+	}
+
+	var got []string
+	for _, stats := range tracker.Snapshot().Clients {
+		got = append(got, stats.Client)
+	}
+	want := []string{tracker.Hash("first"), tracker.Hash("third")}
+	if len(got) != 2 || !(got[0] == want[0] && got[1] == want[1]) {
+		t.Errorf("tracked clients = %v, want first and third %v", got, want)
+	}
+	if got := testutil.CollectAndCount(metrics.ClientRequestsTotal); got != 2 {
+		t.Errorf("client request series = %d, want MaxClients", got)
+	}
+}
+
+func TestMiddlewareNeverExposesIdentity(t *testing.T) {
+	useTestMetrics(t)
+	gin.SetMode(gin.TestMode)
+	tracker := New(Options{MaxClients: 10, Salt: "test"})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Client"); key != "" {
+			c.Set(audit.ClientKey, key)
+		}
+	}, Middleware(tracker))
+	router.GET("/applications", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	for _, client := range []string{"secret-api-key-1234", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/applications", nil)
+		req.RemoteAddr = "192.0.2.44:5555"
+		if client != "" {
+			req.Header.Set("X-Test-Client", client)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	report := tracker.Snapshot()
+	if len(report.Clients) != 2 {
+		t.Fatalf("tracked %d clients, want the named client and the IP", len(report.Clients))
+	}
+	snapshot, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Failed to marshal report: %v", err)
+	}
+
+	var exposition bytes.Buffer
+	families, err := metrics.Default().Registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		exposition.WriteString(family.String())
+	}
+
+	for _, raw := range []string{"secret-api-key-1234", "192.0.2.44"} {
+		if bytes.Contains(snapshot, []byte(raw)) {
+			t.Errorf("stats output contains the raw identity %q", raw)
+		}
+		if strings.Contains(exposition.String(), raw) {
+			t.Errorf("metrics contain the raw identity %q", raw)
+		}
+		if !strings.Contains(exposition.String(), tracker.Hash(raw)) {
+			t.Errorf("metrics lack the hashed identity of %q", raw)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Package clock abstracts the current time so that time-based logic, such as token
+// expiry and cache TTLs, can be tested without sleeping.
+package clock
+
+import "time"
+
+// Clock tells the time and creates tickers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+// realClock implements Clock with the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker adapts time.Ticker to Ticker
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// OrReal returns c, or Real when c is nil, so that zero-value structs use the system clock
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealClock(t *testing.T) {
+	before := time.Now()
+	if now := Real.Now(); now.Before(before) {
+		t.Errorf("Now() = %v, want a time after %v", now, before)
+	}
+	if since := Real.Since(before.Add(-time.Hour)); since < time.Hour {
+		t.Errorf("Since(an hour ago) = %v, want at least an hour", since)
+	}
+
+	ticker := Real.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatal("ticker did not tick")
+	}
+}
+
+func TestOrReal(t *testing.T) {
+	if OrReal(nil) != Real {
+		t.Error("OrReal(nil) is not the real clock")
+	}
+	custom := realClock{}
+	if OrReal(custom) != custom {
+		t.Error("OrReal() replaced a non-nil clock")
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/params"
+)
+
+// Ways for a client to request a compatibility response format; the query parameter wins
+const (
+	compatQueryParam     = "compat"
+	responseFormatHeader = "X-Response-Format"
+)
+
+// responseTransformers re-encode a serialized JSON response into a compatibility format
+var responseTransformers = map[string]func([]byte) ([]byte, error){
+	config.ResponseFormatLegacy: legacyJSON,
+}
+
+// legacyDataKeys name the objects holding data, such as label keys and values, which are
+// kept as they are in the legacy format
+var legacyDataKeys = map[string]bool{"labels": true, "annotations": true, "ownership": true}
+
+// responseFormat returns the compatibility format the request asks for, or "" for none
+func responseFormat(c *gin.Context) string {
+	if format := c.Query(compatQueryParam); format != "" {
+		return format
+	}
+	return c.GetHeader(responseFormatHeader)
+}
+
+// compatMiddleware re-encodes JSON responses into the compatibility format requested with
+// ?compat= or X-Response-Format, when RESPONSE_FORMATS enables it. Handlers are unaware of
+// it; requests that ask for no format are passed through untouched.
+func (s *Server) compatMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", responseFormatHeader)
+		format := responseFormat(c)
+		if format == "" {
+			c.Next()
+			return
+		}
+		if !slices.Contains(s.config.ResponseFormats, format) {
+			s.invalidParamsResponse(c, params.Errors{{
+				Field:  compatQueryParam,
+				Reason: fmt.Sprintf("response format %q is not enabled, want one of %s", format, strings.Join(s.config.ResponseFormats, ", ")),
+			}})
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+
+		body := buffered.body.Bytes()
+		if len(body) > 0 && isJSONContentType(original.Header().Get("Content-Type")) {
+			converted, err := responseTransformers[format](body)
+			if err != nil {
+				log.Printf("WARNING: Failed to convert %s response to the %s format: %v", c.FullPath(), format, err)
+			} else {
+				body = converted
+				original.Header().Del("Content-Length")
+			}
+		}
+		original.Write(body)
+	}
+}
+
+// isJSONContentType reports whether a Content-Type is JSON, including +json media types
+// such as application/problem+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// legacyJSON re-encodes a JSON document the way the legacy proxy served it: lowerCamelCase
+// object keys become snake_case and RFC 3339 timestamps become epoch milliseconds, with the
+// zero time as null. Labels, annotations and ownership are data and are kept as they are,
+// as are key order and every other value.
+func legacyJSON(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	out.Grow(len(body))
+	if err := writeLegacyValue(dec, &out, true); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	// Keep the trailing newline encoding/json adds
+	if bytes.HasSuffix(body, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// writeLegacyValue converts the next JSON value read from dec into out. convert is false
+// for values nested in data objects, which are copied without converting keys or
+// timestamps.
+func writeLegacyValue(dec *json.Decoder, out *bytes.Buffer, convert bool) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		closing := json.Delim(']')
+		if value == '{' {
+			closing = '}'
+		}
+		out.WriteByte(byte(value))
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			convertChild := convert
+			if value == '{' {
+				token, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := token.(string)
+				if convert {
+					convertChild = !legacyDataKeys[key]
+					key = snakeCase(key)
+				}
+				writeJSONString(out, key)
+				out.WriteByte(':')
+			}
+			if err := writeLegacyValue(dec, out, convertChild); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		out.WriteByte(byte(closing))
+	case string:
+		if millis, ok := epochMillis(value); ok && convert {
+			out.WriteString(millis)
+		} else {
+			writeJSONString(out, value)
+		}
+	case json.Number:
+		out.WriteString(value.String())
+	case bool:
+		out.WriteString(strconv.FormatBool(value))
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeJSONString writes s as a JSON string, escaped like encoding/json
+func writeJSONString(out *bytes.Buffer, s string) {
+	encoded, _ := json.Marshal(s)
+	out.Write(encoded)
+}
+
+// epochMillis returns the epoch milliseconds of an RFC 3339 timestamp, or null for the
+// zero time. Other strings are reported as not being timestamps.
+func epochMillis(s string) (string, bool) {
+	// Cheap shape check before parsing: 2006-01-02T15:04:05Z is the shortest form
+	if len(s) < 20 || s[4] != '-' || s[10] != 'T' {
+		return "", false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return "", false
+	}
+	if t.IsZero() {
+		return "null", true
+	}
+	return strconv.FormatInt(t.UnixMilli(), 10), true
+}
+
+// snakeCase converts a lowerCamelCase key such as resourceVersion or requestID to
+// snake_case. Other keys, such as label keys, annotation keys and names used as map keys,
+// are returned unchanged.
+func snakeCase(key string) string {
+	if key == "" || key[0] < 'a' || key[0] > 'z' {
+		return key
+	}
+	for i := 0; i < len(key); i++ {
+		if b := key[i]; !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9') {
+			return key
+		}
+	}
+
+	var out strings.Builder
+	out.Grow(len(key) + 4)
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		if b >= 'A' && b <= 'Z' {
+			// Start a word at a lower-to-upper boundary, and before the last capital of an
+			// acronym followed by a lowercase word (apiURLPath becomes api_url_path), but not
+			// before a plural acronym (externalURLs becomes external_urls)
+			previousUpper := unicode.IsUpper(rune(key[i-1]))
+			nextLower := i+1 < len(key) && unicode.IsLower(rune(key[i+1]))
+			pluralAcronym := previousUpper && nextLower && key[i+1] == 's' && (i+2 == len(key) || unicode.IsUpper(rune(key[i+2])))
+			if !previousUpper || nextLower && !pluralAcronym {
+				out.WriteByte('_')
+			}
+			b += 'a' - 'A'
+		}
+		out.WriteByte(b)
+	}
+	return out.String()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// compatFixtureApplication is an application exercising nested keys, acronyms, label keys
+// and set, unset and pointer timestamps. It has no creation timestamp, so that its
+// computed age does not change the output.
+func compatFixtureApplication() types.ArgocdApplication {
+	deployedAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	finishedAt := time.Date(2024, 3, 1, 12, 31, 15, 250_000_000, time.UTC)
+	limit := int64(5)
+
+	app := types.ArgocdApplication{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: types.ArgocdApplicationMetadata{
+			Name:      "guestbook",
+			Namespace: "argocd",
+			Labels:    map[string]string{"app.kubernetes.io/name": "guestbook", "teamName": "web"},
+			UID:       "0f6c1a7e-3b1d-4c52-9b8e-5a2f7c9d1e00",
+		},
+		Spec: types.ArgocdApplicationSpec{
+			Project: "web-app",
+			Source: types.ArgocdApplicationSource{
+				RepoURL:        "https://github.com/argoproj/argocd-example-apps.git",
+				Path:           "guestbook",
+				TargetRevision: "HEAD",
+			},
+			Destination:          types.ArgocdApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: "guestbook"},
+			RevisionHistoryLimit: &limit,
+		},
+		Status: types.ArgocdApplicationStatus{
+			Health:       types.ArgocdApplicationHealth{Status: "Healthy"},
+			ReconciledAt: finishedAt,
+			Sync:         types.ArgocdApplicationSync{Status: "Synced", Revision: "4e5f6a7"},
+			Summary:      &types.ArgocdApplicationSummary{ExternalURLs: []string{"https://guestbook.example.com"}},
+			OperationState: &types.ArgocdOperationState{
+				Phase:      "Succeeded",
+				Message:    "successfully synced <all> resources",
+				StartedAt:  &deployedAt,
+				FinishedAt: &finishedAt,
+			},
+			History: []types.ArgocdRevisionHistory{{ID: 3, Revision: "4e5f6a7", DeployedAt: deployedAt}},
+		},
+	}
+	return app
+}
+
+func TestCompatLegacyGolden(t *testing.T) {
+	server := setupTestServer()
+	server.config.ResponseFormats = []string{config.ResponseFormatLegacy}
+	server.setupRouter()
+	server.argocdService.(*MockArgocdService).application = compatFixtureApplication()
+
+	w := serveMethod(server, http.MethodGet, "/applications/guestbook?compat=legacy", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, w.Body.Bytes(), "", "  "); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	indented.WriteByte('\n')
+
+	golden := filepath.Join("testdata", "compat_legacy_application.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, indented.Bytes(), 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read %s (run with -update to create it): %v", golden, err)
+	}
+	if !bytes.Equal(indented.Bytes(), want) {
+		t.Errorf("legacy response differs from %s:\n%s", golden, indented.String())
+	}
+}
+
+func TestCompatFormatSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		formats    []string
+		path       string
+		headers    map[string]string
+		wantStatus int
+		wantLegacy bool
+	}{
+		{name: "query parameter", formats: []string{"legacy"}, path: "/applications/guestbook?compat=legacy", wantStatus: http.StatusOK, wantLegacy: true},
+		{name: "header", formats: []string{"legacy"}, path: "/applications/guestbook", headers: map[string]string{responseFormatHeader: "legacy"}, wantStatus: http.StatusOK, wantLegacy: true},
+		{name: "not requested", formats: []string{"legacy"}, path: "/applications/guestbook", wantStatus: http.StatusOK},
+		{name: "unknown format", formats: []string{"legacy"}, path: "/applications/guestbook?compat=v0", wantStatus: http.StatusBadRequest},
+		{name: "layer disabled", path: "/applications/guestbook", headers: map[string]string{responseFormatHeader: "legacy"}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.ResponseFormats = tt.formats
+			server.setupRouter()
+			server.argocdService.(*MockArgocdService).application = compatFixtureApplication()
+
+			w := serveMethod(server, http.MethodGet, tt.path, tt.headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if response.ErrorCode != types.ErrorCodeInvalidParam {
+					t.Errorf("errorCode = %q, want %q", response.ErrorCode, types.ErrorCodeInvalidParam)
+				}
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			_, legacy := body["api_version"]
+			if legacy != tt.wantLegacy {
+				t.Errorf("legacy keys = %v, want %v in %s", legacy, tt.wantLegacy, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCompatResponseCacheKeepsFormatsApart(t *testing.T) {
+	server := setupTestServer()
+	server.config.ResponseFormats = []string{config.ResponseFormatLegacy}
+	server.config.ResponseCacheTTL = time.Minute
+	server.config.ResponseCacheSize = 16
+	server.setupRouter()
+	server.argocdService.(*MockArgocdService).applications = types.ArgocdApplicationList{
+		Items: []types.ArgocdApplication{compatFixtureApplication()},
+	}
+
+	plain := serveMethod(server, http.MethodGet, "/applications", nil)
+	legacy := serveMethod(server, http.MethodGet, "/applications", map[string]string{responseFormatHeader: "legacy"})
+	if bytes.Equal(plain.Body.Bytes(), legacy.Body.Bytes()) {
+		t.Fatal("the cached response was served for both formats")
+	}
+	if !bytes.Contains(legacy.Body.Bytes(), []byte(`"api_version"`)) || !bytes.Contains(plain.Body.Bytes(), []byte(`"apiVersion"`)) {
+		t.Errorf("plain = %s, legacy = %s; want camelCase and snake_case keys", plain.Body.String(), legacy.Body.String())
+	}
+	if plain.Header().Get("ETag") == legacy.Header().Get("ETag") {
+		t.Errorf("both formats share the ETag %s", plain.Header().Get("ETag"))
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"apiVersion":             "api_version",
+		"repoURL":                "repo_url",
+		"externalURLs":           "external_urls",
+		"apiURLPath":             "api_url_path",
+		"requestID":              "request_id",
+		"sha256Sum":              "sha256_sum",
+		"status":                 "status",
+		"app.kubernetes.io/name": "app.kubernetes.io/name",
+		"Team A":                 "Team A",
+		"TeamA":                  "TeamA",
+	}
+	for key, want := range tests {
+		if got := snakeCase(key); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLegacyJSONTimestamps(t *testing.T) {
+	got, err := legacyJSON([]byte(`{"deployedAt":"2024-03-01T12:30:00Z","reconciledAt":"0001-01-01T00:00:00Z","note":"2024-03-01","count":12.50}`))
+	if err != nil {
+		t.Fatalf("legacyJSON() error = %v", err)
+	}
+	want := `{"deployed_at":1709296200000,"reconciled_at":null,"note":"2024-03-01","count":12.50}`
+	if string(got) != want {
+		t.Errorf("legacyJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestLegacyJSONKeepsDataValues(t *testing.T) {
+	got, err := legacyJSON([]byte(`{"labels":{"releasedAt":"2024-03-01T12:30:00Z"},"annotations":{"example.com/deployedAt":"2024-03-01T12:30:00Z"},"ownership":{"team":"2024-03-01T12:30:00Z"},"items":[{"syncedAt":"2024-03-01T12:30:00Z"}]}`))
+	if err != nil {
+		t.Fatalf("legacyJSON() error = %v", err)
+	}
+	want := `{"labels":{"releasedAt":"2024-03-01T12:30:00Z"},"annotations":{"example.com/deployedAt":"2024-03-01T12:30:00Z"},"ownership":{"team":"2024-03-01T12:30:00Z"},"items":[{"synced_at":1709296200000}]}`
+	if string(got) != want {
+		t.Errorf("legacyJSON() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// deprecatedKey is an environment variable that has been replaced but is still read when
+// its replacement is not set
+type deprecatedKey struct {
+	key         string
+	replacement string
+}
+
+// deprecatedKeys lists the renamed environment variables. An entry stays until the old
+// key stops being read.
+var deprecatedKeys = []deprecatedKey{
+	{key: "API_KEYS", replacement: "API_KEYS_JSON"},
+}
+
+// Deprecation is a deprecated environment variable found set at startup
+type Deprecation struct {
+	Key         string `json:"key"`
+	Replacement string `json:"replacement"`
+	// Ignored is true when the replacement is set as well and is used instead
+	Ignored bool `json:"ignored"`
+}
+
+// findDeprecations returns the deprecated keys set in the environment, in the order of
+// deprecatedKeys
+func findDeprecations() []Deprecation {
+	deprecations := []Deprecation{}
+	for _, deprecated := range deprecatedKeys {
+		if os.Getenv(deprecated.key) == "" {
+			continue
+		}
+		deprecations = append(deprecations, Deprecation{
+			Key:         deprecated.key,
+			Replacement: deprecated.replacement,
+			Ignored:     os.Getenv(deprecated.replacement) != "",
+		})
+	}
+	return deprecations
+}
+
+// strictDeprecationError reports the deprecated keys set while CONFIG_STRICT is enabled
+func strictDeprecationError(deprecations []Deprecation) error {
+	keys := make([]string, len(deprecations))
+	for i, deprecation := range deprecations {
+		keys[i] = fmt.Sprintf("%s (use %s)", deprecation.Key, deprecation.Replacement)
+	}
+	return fmt.Errorf("CONFIG_STRICT forbids deprecated configuration: %s", strings.Join(keys, ", "))
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExplainedGroup describes a project group that lists the explained project
+type ExplainedGroup struct {
+	Name string `json:"name"`
+	// ApplicationIgnoredBy is the group's own ignore pattern hiding the application from
+	// the group's listing, if any
+	ApplicationIgnoredBy string `json:"applicationIgnoredBy,omitempty"`
+}
+
+// FilterExplanation is the trace of the ignore rules applied to a project and,
+// optionally, one of its applications, as served by the admin API
+type FilterExplanation struct {
+	Project     string `json:"project"`
+	Application string `json:"application,omitempty"`
+	// Groups are the project groups listing the project; grouped projects are never
+	// hidden by the ignore patterns
+	Groups []ExplainedGroup `json:"groups"`
+	// MatchedPattern is the first ignore pattern in effect matching the project, reported
+	// even when a group keeps the project visible
+	MatchedPattern string `json:"matchedPattern,omitempty"`
+	// Visible is the final verdict: whether the project and its applications are served
+	Visible bool `json:"visible"`
+	// Reason explains the verdict
+	Reason string `json:"reason"`
+}
+
+// ExplainFilter traces how the ignore rules treat a project and, when application is not
+// empty, an application of it. It only applies the configured rules; whether the project
+// or application exists in ArgoCD is not checked.
+func (s *FilterSnapshot) ExplainFilter(project, application string) FilterExplanation {
+	explanation := FilterExplanation{
+		Project:     project,
+		Application: application,
+		Groups:      []ExplainedGroup{},
+	}
+	explanation.MatchedPattern, _ = s.MatchIgnoredPattern(project)
+
+	var groupNames, hidingGroups []string
+	for _, group := range s.GroupsListing(project) {
+		explained := ExplainedGroup{Name: group.Name}
+		if application != "" {
+			explained.ApplicationIgnoredBy, _ = group.MatchApplicationIgnore(application, project)
+		}
+		if explained.ApplicationIgnoredBy != "" {
+			hidingGroups = append(hidingGroups, fmt.Sprintf("%s (pattern %q)", group.Name, explained.ApplicationIgnoredBy))
+		}
+		groupNames = append(groupNames, group.Name)
+		explanation.Groups = append(explanation.Groups, explained)
+	}
+
+	switch {
+	case len(groupNames) > 0 && explanation.MatchedPattern != "":
+		explanation.Visible = true
+		explanation.Reason = fmt.Sprintf("project is listed in %s, which keeps it visible although it matches ignore pattern %q",
+			groupList(groupNames), explanation.MatchedPattern)
+	case len(groupNames) > 0:
+		explanation.Visible = true
+		explanation.Reason = fmt.Sprintf("project is listed in %s and matches no ignore pattern", groupList(groupNames))
+	case explanation.MatchedPattern != "":
+		explanation.Reason = fmt.Sprintf("project matches ignore pattern %q and is not listed in any group", explanation.MatchedPattern)
+	default:
+		explanation.Visible = true
+		explanation.Reason = "project matches no ignore pattern"
+	}
+
+	if len(hidingGroups) > 0 {
+		explanation.Reason += fmt.Sprintf("; the application is hidden from the listing of %s", groupList(hidingGroups))
+	}
+	return explanation
+}
+
+// groupList names one or more groups in a reason
+func groupList(names []string) string {
+	if len(names) == 1 {
+		return "group " + names[0]
+	}
+	return "groups " + strings.Join(names, ", ")
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"slices"
+	"sync"
+)
+
+// ProjectGroupsMeta describes the page of groups in a ProjectGroupsResponse
+type ProjectGroupsMeta struct {
+	// Total is the number of groups matching ?name=, before ?offset= and ?limit= apply
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	// Limit is the largest number of groups returned; 0 means unlimited
+	Limit int `json:"limit"`
+}
+
+// GroupingCache remembers the last resolved project grouping and its export hash, so
+// the ungrouped projects are only recomputed when the rules or the project names
+// change rather than on every request. The zero value is ready to use.
+type GroupingCache struct {
+	mu   sync.Mutex
+	last *resolvedGrouping
+}
+
+// resolvedGrouping is a grouping resolved from the rules of snapshot over projects
+type resolvedGrouping struct {
+	snapshot *FilterSnapshot
+	projects []string
+	groups   ProjectGroupsResponse
+	hash     string
+}
+
+// Resolve returns the grouping of projects under the rules of snapshot, with the hash of
+// its export. Neither the response nor its slices may be modified.
+func (c *GroupingCache) Resolve(snapshot *FilterSnapshot, projects []string) (ProjectGroupsResponse, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last := c.last; last != nil && last.snapshot.sameRules(snapshot) && slices.Equal(last.projects, projects) {
+		return last.groups, last.hash
+	}
+
+	c.last = &resolvedGrouping{
+		snapshot: snapshot,
+		projects: slices.Clone(projects),
+		groups:   snapshot.GetProjectGroups(projects),
+		hash:     snapshot.ExportProjectGroups(projects).Hash,
+	}
+	return c.last.groups, c.last.hash
+}
+
+// sameRules reports whether s and other hold the same groups and ignore patterns.
+// Snapshots are immutable and Store replaces the slices it changes, so the rules are
+// the same exactly when the slices are.
+func (s *FilterSnapshot) sameRules(other *FilterSnapshot) bool {
+	return s == other || (sameSlice(s.ProjectGroups, other.ProjectGroups) &&
+		sameSlice(s.IgnoredProjects, other.IgnoredProjects) &&
+		s.IgnoredOverridden == other.IgnoredOverridden)
+}
+
+// sameSlice reports whether a and b are the same slice of the same backing array
+func sameSlice[T any](a, b []T) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Page returns the groups whose names match namePattern, in r's order, skipping the
+// first offset and keeping at most limit of them (0 keeps all), with Meta describing the
+// page. The pattern uses the IGNORED_PROJECTS wildcards and ignores case; an empty
+// pattern matches every group. The ungrouped projects are kept whole.
+func (r ProjectGroupsResponse) Page(namePattern string, offset, limit int) ProjectGroupsResponse {
+	groups := r.Groups
+	if namePattern != "" {
+		pattern := normalizeGroupName(namePattern)
+		groups = nil
+		for _, group := range r.Groups {
+			if matchesPattern(normalizeGroupName(group.Name), pattern) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	page := ProjectGroupsResponse{
+		UngroupedProjects: r.UngroupedProjects,
+		Meta:              &ProjectGroupsMeta{Total: len(groups), Offset: offset, Limit: limit},
+	}
+	groups = groups[min(offset, len(groups)):]
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+	page.Groups = append([]ProjectGroup{}, groups...)
+	return page
+}
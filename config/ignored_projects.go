@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IgnoredPatternMatch reports how many known projects an ignore pattern matches.
+// Patterns with no matches are dead and can usually be removed.
+type IgnoredPatternMatch struct {
+	Pattern string `json:"pattern"`
+	Matches int    `json:"matches"`
+	// Projects are the matched project names, sorted
+	Projects []string `json:"projects"`
+}
+
+// IgnoredProjectsReport describes the ignore patterns in effect and the projects each
+// one matches, as served by the admin API
+type IgnoredProjectsReport struct {
+	Patterns []IgnoredPatternMatch `json:"patterns"`
+	// Overridden is true when the patterns were replaced at runtime rather than loaded
+	// from IGNORED_PROJECTS
+	Overridden bool `json:"overridden"`
+	// Persisted is always false: runtime replacements are lost on restart
+	Persisted bool   `json:"persisted"`
+	Note      string `json:"note"`
+}
+
+// SetIgnoredPatterns validates patterns and atomically replaces the ignore patterns in
+// effect, keeping the project groups, and returns the snapshot now in effect. The
+// replacement lives in memory only; a restart reverts to IGNORED_PROJECTS. On error the
+// patterns in effect are left unchanged.
+func (c *Config) SetIgnoredPatterns(patterns []string) (*FilterSnapshot, error) {
+	replacement := make([]string, 0, len(patterns))
+	seen := make(map[string]bool, len(patterns))
+	for i, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if err := ValidateIgnoredPattern(pattern); err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i, err)
+		}
+		if seen[pattern] {
+			return nil, fmt.Errorf("pattern %d: %q is listed more than once", i, pattern)
+		}
+		seen[pattern] = true
+		replacement = append(replacement, pattern)
+	}
+
+	return c.update(func(next *FilterSnapshot) {
+		next.IgnoredProjects = replacement
+		next.IgnoredOverridden = true
+	}), nil
+}
+
+// ValidateIgnoredPattern rejects patterns the matcher cannot apply: empty patterns,
+// wildcards other than a leading or trailing "*", and "**", which matches nothing
+func ValidateIgnoredPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern must not be empty")
+	}
+	if pattern == "*" {
+		return nil
+	}
+	text := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+	if text == "" {
+		return fmt.Errorf("%q matches no project", pattern)
+	}
+	if strings.Contains(text, "*") {
+		return fmt.Errorf("%q: wildcards are only supported at the start or end of a pattern", pattern)
+	}
+	return nil
+}
+
+// IgnoredPatternMatches counts, for every ignore pattern in effect, the projects among
+// projects that it matches. Projects listed in a group are counted too, although groups
+// keep them visible.
+func (s *FilterSnapshot) IgnoredPatternMatches(projects []string) []IgnoredPatternMatch {
+	matches := make([]IgnoredPatternMatch, 0, len(s.IgnoredProjects))
+	for _, pattern := range s.IgnoredProjects {
+		match := IgnoredPatternMatch{Pattern: pattern, Projects: []string{}}
+		for _, project := range uniqueSorted(projects) {
+			if matchesPattern(project, pattern) {
+				match.Projects = append(match.Projects, project)
+			}
+		}
+		match.Matches = len(match.Projects)
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Pattern < matches[j].Pattern
+	})
+	return matches
+}
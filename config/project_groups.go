@@ -2,10 +2,25 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/net/http/httpguts"
+
+	"argocd-proxy/canonical"
+	"argocd-proxy/features"
 )
 
 // ProjectGroup represents a group of projects with metadata
@@ -13,25 +28,318 @@ type ProjectGroup struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Projects    []string `json:"projects"`
+	// Order positions the group in responses; groups with equal order sort by name
+	Order int `json:"order,omitempty"`
+	// IgnoredProjects are patterns hiding applications from this group's endpoints only,
+	// matched against the application name and its project like IGNORED_PROJECTS
+	IgnoredProjects []string `json:"ignoredProjects,omitempty"`
 }
 
 // ProjectGroupsResponse represents the response for project groups endpoint
 type ProjectGroupsResponse struct {
 	Groups            []ProjectGroup `json:"groups"`
 	UngroupedProjects []string       `json:"ungroupedProjects"`
+	// Meta describes the page of groups returned by /project-groups
+	Meta *ProjectGroupsMeta `json:"meta,omitempty"`
+}
+
+// ProjectGroupsExport is the canonical, fully-resolved grouping suitable for committing
+// to version control. It contains no timestamps and every list is sorted, so the same
+// configuration and project set always serialize to the same bytes.
+type ProjectGroupsExport struct {
+	Groups            []ProjectGroup `json:"groups"`
+	UngroupedProjects []string       `json:"ungroupedProjects"`
+	// IgnoredPatterns are the configured IGNORED_PROJECTS patterns
+	IgnoredPatterns []string `json:"ignoredPatterns"`
+	// IgnoredProjects are the discovered projects hidden by IgnoredPatterns
+	IgnoredProjects []string `json:"ignoredProjects"`
+	// Hash is "sha256:" followed by the hex digest of the document in canonical JSON,
+	// without this field
+	Hash string `json:"hash,omitempty"`
 }
 
 // Config holds the application configuration
 type Config struct {
-	Port            string
-	ArgocdAPIURL    string
-	ArgocdUsername  string
-	ArgocdPassword  string
+	Port           string
+	ArgocdAPIURL   string
+	ArgocdUsername string
+	ArgocdPassword string
+	// ArgocdProjectTokens maps project names to static project-scoped ArgoCD tokens, used
+	// instead of the session token for requests targeting that project
+	ArgocdProjectTokens map[string]string
+	// TokenCacheFile persists the session token across restarts when set
+	TokenCacheFile string
+	// TokenCacheKey encrypts the persisted session token when set
+	TokenCacheKey string
+	// TokenRefreshCheckInterval is how often the background routine checks the session token
+	TokenRefreshCheckInterval time.Duration
+	// TokenRefreshFailureThreshold is the number of consecutive background refresh failures
+	// after which the token is reported as failing and the alert webhook is called
+	TokenRefreshFailureThreshold int
+	// AlertWebhookURL receives a JSON POST when token refreshes start failing, when set
+	AlertWebhookURL string
+	// StartupRequireAuth makes startup obtain an ArgoCD token before listening, exiting
+	// when it cannot within StartupAuthTimeout. Otherwise /readyz reports not ready until
+	// the first token is obtained.
+	StartupRequireAuth bool
+	// StartupAuthTimeout bounds the startup token retries of StartupRequireAuth
+	StartupAuthTimeout time.Duration
+	// ProjectGroups and IgnoredProjects are the PROJECT_GROUPS and IGNORED_PROJECTS
+	// loaded at startup; read the rules in effect from Snapshot, which honours runtime
+	// replacements
 	ProjectGroups   []ProjectGroup
 	IgnoredProjects []string
-	CacheTTL        time.Duration
+	// filters holds the rules stored at runtime, if any; storeMu serializes writers
+	filters  atomic.Pointer[FilterSnapshot]
+	storeMu  sync.Mutex
+	CacheTTL time.Duration
+	// CacheBackend stores the projects and applications caches: "memory" or "redis"
+	CacheBackend string
+	// RedisAddr, RedisPassword and RedisKeyPrefix locate the shared cache when
+	// CacheBackend is "redis"
+	RedisAddr      string
+	RedisPassword  string
+	RedisKeyPrefix string
+	// RedisStaleWindow is how long past CACHE_TTL Redis keeps an expired entry, for
+	// stale reads and resourceVersion revalidation
+	RedisStaleWindow time.Duration
+	// CacheSnapshotURL and CacheSnapshotFile name a cache snapshot exported by another
+	// replica, imported at startup to pre-warm the caches; at most one is set
+	CacheSnapshotURL  string
+	CacheSnapshotFile string
+	WatchMaxWait      time.Duration
+	// PollInterval enables the background application poller when positive
+	PollInterval time.Duration
+	// ReconcileAgeThreshold is the reconcile age above which the poller counts an
+	// application as exceeding it in argocd_proxy_apps_reconcile_age_exceeded
+	ReconcileAgeThreshold time.Duration
+	// SnapshotHistoryDepth is the number of application set snapshots kept for
+	// /applications?since= delta responses; 0 disables snapshot tokens
+	SnapshotHistoryDepth int
+	// UpstreamConcurrency caps the ArgoCD calls one fan-out makes at once
+	UpstreamConcurrency int
+	// UpstreamTimeout is the hard deadline of each ArgoCD call attempt, body included
+	UpstreamTimeout time.Duration
+	// RequestTimeout is the deadline of the ArgoCD calls an API request makes, at least
+	// UpstreamTimeout; 0 leaves requests bounded by UpstreamTimeout alone
+	RequestTimeout time.Duration
+	// UpstreamSoftTimeout is the duration above which an ArgoCD call is logged and counted
+	// as slow without being aborted; 0 disables it
+	UpstreamSoftTimeout time.Duration
+	// UpstreamErrorBodyLimit is the number of upstream response body bytes kept in error messages
+	UpstreamErrorBodyLimit int
+	// UpstreamErrorPassthrough includes ArgoCD's status and body excerpt in error responses
+	// to failed calls without ?upstreamError=true
+	UpstreamErrorPassthrough bool
+	// UpstreamMaxBodyBytes caps the size of an ArgoCD response body; larger bodies fail the call
+	UpstreamMaxBodyBytes int64
+	// UpstreamStrictDecode is "warn" or "error" to check refreshed ArgoCD lists for fields
+	// the proxy's types do not declare, or "off"
+	UpstreamStrictDecode string
+	// HealthHistorySize is the number of recent health checks kept for /health/history
+	HealthHistorySize int
+	// HealthFailureThreshold is the number of consecutive failed ArgoCD checks before
+	// /health reports degraded
+	HealthFailureThreshold int
+	// HealthRecoveryThreshold is the number of consecutive passed ArgoCD checks before a
+	// degraded /health reports healthy again
+	HealthRecoveryThreshold int
+	// DeepHealth makes /health also report the status of ArgoCD's internal components. It
+	// is the default of the deep-health feature flag.
+	DeepHealth bool
+	// ResolveClusterNames adds the friendly cluster name from ArgoCD's clusters API to
+	// application destinations
+	ResolveClusterNames bool
+	// OwnerAnnotations are the annotation keys copied into each application's ownership
+	// map; the first one identifies the owner for ?owner= and /owners
+	OwnerAnnotations []string
+	// LogsEndpointEnabled registers /applications/{name}/logs
+	LogsEndpointEnabled bool
+	// LogsMaxTailLines caps the log lines one /applications/{name}/logs request reads
+	LogsMaxTailLines int
+	// CompatCheckInterval repeats the startup ArgoCD compatibility probe; 0 probes only at startup
+	CompatCheckInterval time.Duration
+	// MetricsNamespace is prepended to all Prometheus metric names
+	MetricsNamespace string
+	// MetricsBuckets overrides the HTTP and upstream latency histogram buckets (seconds)
+	MetricsBuckets []float64
+	// MetricsExemplars attaches trace IDs as exemplars to latency histograms
+	MetricsExemplars bool
+	// StrictQueryParams rejects query parameters a route does not understand. It is the
+	// default of the strict-query-params feature flag.
+	StrictQueryParams bool
+	// Features are the feature flag states set by FEATURES, over the flags' defaults
+	Features features.Overrides
+	// FeatureWarnings describes the FEATURES entries ignored because they name unknown flags
+	FeatureWarnings []string
+	// Deprecations are the deprecated environment variables set, still honoured unless
+	// their replacement is set too
+	Deprecations []Deprecation
+	// ConfigStrict rejects deprecated environment variables instead of warning about them
+	ConfigStrict bool
+	// MaxQueryLength caps the raw query string length; zero disables the cap
+	MaxQueryLength int
+	// MaxResponseItems caps the items returned by list endpoints; zero disables the cap
+	MaxResponseItems int
+	// ResponseCacheTTL enables the handler-level response cache when positive
+	ResponseCacheTTL time.Duration
+	// ResponseCacheSize is the maximum number of cached responses
+	ResponseCacheSize int
+	// AuditLog enables one structured audit record per request
+	AuditLog bool
+	// AuditLogPath is the audit log file; empty writes JSON lines to stdout
+	AuditLogPath string
+	// AuditLogMaxSize is the size in bytes at which the audit log file is rotated
+	AuditLogMaxSize int64
+	// AuditLogMaxBackups is the number of rotated audit log files to keep
+	AuditLogMaxBackups int
+	// AuditLogExclude lists request paths that are never audited
+	AuditLogExclude []string
+	// AppCircuitThreshold is the number of consecutive failed reads of one application
+	// within AppCircuitWindow that opens its circuit breaker; 0 disables the breakers
+	AppCircuitThreshold int
+	// AppCircuitWindow is the period within which failures count as consecutive
+	AppCircuitWindow time.Duration
+	// AppCircuitCooldown is how long reads of an application fail fast once its circuit opens
+	AppCircuitCooldown time.Duration
+	// AppCircuitMaxApps bounds the number of applications whose failures are tracked at once
+	AppCircuitMaxApps int
+	// ShadowMode is "compare" to run candidate implementations of selected service calls
+	// in the background and report where they disagree with the ones in use, or "off"
+	ShadowMode string
+	// ShadowTimeout bounds each background candidate run
+	ShadowTimeout time.Duration
+	// ShadowBudget is the longest a call waits for a free shadow slot before skipping its
+	// shadow, and so the most latency shadowing adds to a request
+	ShadowBudget time.Duration
+	// ClientStats enables rolling per-client request statistics
+	ClientStats bool
+	// ClientStatsMaxClients bounds the number of clients tracked at once
+	ClientStatsMaxClients int
+	// ClientStatsHalfLife is how often the per-client counters are halved
+	ClientStatsHalfLife time.Duration
+	// ClientStatsIdleTimeout evicts clients not seen for this long
+	ClientStatsIdleTimeout time.Duration
+	// ClientStatsSalt keys the client identifier hash; empty uses a random salt per process
+	ClientStatsSalt string
+	// TrustedProxies lists the proxy CIDRs or IPs whose X-Forwarded-For and X-Real-IP
+	// headers are honoured when deriving the client IP; empty trusts no proxy
+	TrustedProxies []string
+	// MaxProjectGroups caps the number of PROJECT_GROUPS entries; 0 disables the limit
+	MaxProjectGroups int
+	// MaxProjectsPerGroup caps the projects listed in one group; 0 disables the limit
+	MaxProjectsPerGroup int
+	// Debug is set when GIN_MODE is debug; it logs why resources are hidden and serves the
+	// Swagger UI unless SWAGGER_ENABLED says otherwise
+	Debug bool
+	// SwaggerEnabled registers the documentation route under SwaggerPath
+	SwaggerEnabled bool
+	// SwaggerPath is the path the Swagger UI is mounted under, without a trailing slash
+	SwaggerPath string
+	// ExternalURL is the address clients use to reach the proxy, e.g. behind an ingress
+	ExternalURL string
+	// BasePath is the path prefix the proxy is served under; it overrides the path of ExternalURL
+	BasePath string
+	// OutboundProxy is the forward proxy used to reach ArgoCD; empty honours HTTPS_PROXY,
+	// HTTP_PROXY and NO_PROXY instead
+	OutboundProxy string
+	// ArgocdRedirectHosts are the hosts ArgoCD may redirect requests to with the proxy's
+	// credentials re-attached; redirects to other hosts fail
+	ArgocdRedirectHosts []string
+	// ArgocdAPIURLs are the URLs listed in ARGOCD_API_URL in order of preference; the
+	// first is ArgocdAPIURL and the others are tried when it is unreachable
+	ArgocdAPIURLs []string
+	// ArgocdPrimaryProbeInterval is how long calls stay on a fallback ArgoCD URL before the
+	// primary is tried again
+	ArgocdPrimaryProbeInterval time.Duration
+	// OutboundProxyUsername and OutboundProxyPassword authenticate to OutboundProxy,
+	// overriding credentials embedded in its URL
+	OutboundProxyUsername string
+	OutboundProxyPassword string
+	// ErrorFormat selects the error body: "json" (ErrorResponse) or "problem" (RFC 7807)
+	ErrorFormat string
+	// ResponseFormats are the compatibility response formats clients may request with
+	// ?compat= or X-Response-Format; empty disables the compatibility layer
+	ResponseFormats []string
+	// SlowRequestThreshold is the duration above which a request counts as slow; 0 disables it
+	SlowRequestThreshold time.Duration
+	// SlowRequestRouteThresholds overrides SlowRequestThreshold per route template
+	SlowRequestRouteThresholds map[string]time.Duration
+	// AdminToken enables the /admin endpoints, which require it as a bearer token
+	AdminToken string
+	// EventLogSize is the number of operational events kept in memory for /admin/events
+	EventLogSize int
+	// SecurityHeadersDisabled turns off the standard security response headers
+	SecurityHeadersDisabled bool
+	// HSTSMaxAge is the Strict-Transport-Security max-age sent on TLS requests; 0 disables HSTS
+	HSTSMaxAge time.Duration
+	// APIKeys are the client API keys; when any are set, clients must present one
+	APIKeys []APIKey
+	// DisabledEndpoints are route template patterns whose routes are not registered
+	DisabledEndpoints []string
+	// AuthExemptRoutes are request path patterns served without an API key
+	AuthExemptRoutes []string
+	// AnonymousReadOnly serves the summary endpoints to callers without an API key
+	AnonymousReadOnly bool
+	// UpstreamImpersonationHeader is the ArgoCD request header naming the client a read is
+	// made for, for ArgoCD's audit trail; empty disables impersonation
+	UpstreamImpersonationHeader string
+	// UpstreamImpersonationIdentityHeader is the request header naming callers without an
+	// API key, honoured only from TrustedProxies
+	UpstreamImpersonationIdentityHeader string
 }
 
+// APIKey is a named client API key from API_KEYS
+type APIKey struct {
+	Name string
+	Key  string
+	// Groups restricts the key to these project groups, from API_KEY_GROUPS; nil means
+	// the key is unrestricted
+	Groups []string
+}
+
+// DefaultSwaggerPath is the path the Swagger UI is mounted under unless SWAGGER_PATH is set
+const DefaultSwaggerPath = "/swagger"
+
+// reservedSwaggerPaths are the first path segments of the API routes, which SWAGGER_PATH
+// must not shadow. TestSwaggerPathCannotShadowRoutes checks it against the router.
+var reservedSwaggerPaths = []string{"admin", "applications", "bootstrap", "groups", "health", "info", "inventory", "metrics", "openapi.json", "openapi.yaml", "owners", "permissions", "project-groups", "projects", "readyz", "schemas", "topology"}
+
+// minAdminTokenLength is the shortest ADMIN_TOKEN accepted
+const minAdminTokenLength = 16
+
+// minAPIKeyLength is the shortest API key accepted in API_KEYS
+const minAPIKeyLength = 16
+
+// Error body formats accepted by ERROR_FORMAT
+const (
+	ErrorFormatJSON    = "json"
+	ErrorFormatProblem = "problem"
+)
+
+// Cache backends accepted by CACHE_BACKEND
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// Shadow modes accepted by SHADOW_MODE
+const (
+	ShadowModeOff     = "off"
+	ShadowModeCompare = "compare"
+)
+
+// Strict decode modes accepted by UPSTREAM_STRICT_DECODE
+const (
+	StrictDecodeOff   = "off"
+	StrictDecodeWarn  = "warn"
+	StrictDecodeError = "error"
+)
+
+// ResponseFormatLegacy re-encodes JSON responses with snake_case keys and timestamps in
+// epoch milliseconds
+const ResponseFormatLegacy = "legacy"
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	config := &Config{
@@ -45,6 +353,22 @@ func LoadConfig() (*Config, error) {
 	if config.ArgocdAPIURL == "" {
 		return nil, fmt.Errorf("ARGOCD_API_URL environment variable is required")
 	}
+	// ARGOCD_API_URL may list fallback URLs after the primary, e.g. an ingress behind the
+	// cluster-internal service
+	config.ArgocdAPIURLs = splitAndTrim(config.ArgocdAPIURL)
+	if len(config.ArgocdAPIURLs) == 0 {
+		return nil, fmt.Errorf("ARGOCD_API_URL environment variable is required")
+	}
+	for i, apiURL := range config.ArgocdAPIURLs {
+		u, err := url.Parse(apiURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("ARGOCD_API_URL entry %d must be an absolute http or https URL", i+1)
+		}
+		if slices.Contains(config.ArgocdAPIURLs[:i], apiURL) {
+			return nil, fmt.Errorf("ARGOCD_API_URL lists %s more than once", apiURL)
+		}
+	}
+	config.ArgocdAPIURL = config.ArgocdAPIURLs[0]
 	if config.ArgocdUsername == "" {
 		return nil, fmt.Errorf("ARGOCD_USERNAME environment variable is required")
 	}
@@ -52,21 +376,532 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("ARGOCD_PASSWORD environment variable is required")
 	}
 
+	var err error
+	// Deprecated keys keep working with a warning, or fail startup in CI with CONFIG_STRICT
+	if config.ConfigStrict, err = getBoolEnv("CONFIG_STRICT", "false"); err != nil {
+		return nil, err
+	}
+	config.Deprecations = findDeprecations()
+	if config.ConfigStrict && len(config.Deprecations) > 0 {
+		return nil, strictDeprecationError(config.Deprecations)
+	}
+
+	if config.ArgocdProjectTokens, err = parseProjectTokens(os.Getenv("ARGOCD_PROJECT_TOKENS")); err != nil {
+		return nil, err
+	}
+
+	// Load session token persistence settings
+	config.TokenCacheFile = os.Getenv("TOKEN_CACHE_FILE")
+	config.TokenCacheKey = os.Getenv("TOKEN_CACHE_KEY")
+	if config.TokenCacheKey != "" && config.TokenCacheFile == "" {
+		return nil, fmt.Errorf("TOKEN_CACHE_KEY requires TOKEN_CACHE_FILE")
+	}
+
+	// Load background token refresh and failure alerting settings
+	if config.TokenRefreshCheckInterval, err = getDurationEnv("TOKEN_REFRESH_CHECK_INTERVAL", "1m"); err != nil {
+		return nil, err
+	}
+	if config.TokenRefreshCheckInterval <= 0 {
+		return nil, fmt.Errorf("TOKEN_REFRESH_CHECK_INTERVAL must be positive, got %s", config.TokenRefreshCheckInterval)
+	}
+	if config.TokenRefreshFailureThreshold, err = getIntEnv("TOKEN_REFRESH_FAILURE_THRESHOLD", "3"); err != nil {
+		return nil, err
+	}
+	if config.TokenRefreshFailureThreshold < 1 {
+		return nil, fmt.Errorf("TOKEN_REFRESH_FAILURE_THRESHOLD must be at least 1, got %d", config.TokenRefreshFailureThreshold)
+	}
+	if config.StartupRequireAuth, err = getBoolEnv("STARTUP_REQUIRE_AUTH", "false"); err != nil {
+		return nil, err
+	}
+	if config.StartupAuthTimeout, err = getDurationEnv("STARTUP_AUTH_TIMEOUT", "60s"); err != nil {
+		return nil, err
+	}
+	if config.StartupAuthTimeout <= 0 {
+		return nil, fmt.Errorf("STARTUP_AUTH_TIMEOUT must be positive, got %s", config.StartupAuthTimeout)
+	}
+	if config.AlertWebhookURL = os.Getenv("ALERT_WEBHOOK_URL"); config.AlertWebhookURL != "" {
+		u, err := url.Parse(config.AlertWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			// Never echo the value: webhook URLs commonly embed a secret
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL must be an absolute http or https URL")
+		}
+	}
+
+	// Load project group size limits (default: 100 groups of up to 1000 projects, 0 = unlimited)
+	if config.MaxProjectGroups, err = getIntEnv("PROJECT_GROUPS_MAX_GROUPS", "100"); err != nil {
+		return nil, err
+	}
+	if config.MaxProjectGroups < 0 {
+		return nil, fmt.Errorf("PROJECT_GROUPS_MAX_GROUPS must not be negative, got %d", config.MaxProjectGroups)
+	}
+	if config.MaxProjectsPerGroup, err = getIntEnv("PROJECT_GROUPS_MAX_PROJECTS", "1000"); err != nil {
+		return nil, err
+	}
+	if config.MaxProjectsPerGroup < 0 {
+		return nil, fmt.Errorf("PROJECT_GROUPS_MAX_PROJECTS must not be negative, got %d", config.MaxProjectsPerGroup)
+	}
+
 	// Load project groups from environment variable
 	if projectGroupsJSON := os.Getenv("PROJECT_GROUPS"); projectGroupsJSON != "" {
-		if err := json.Unmarshal([]byte(projectGroupsJSON), &config.ProjectGroups); err != nil {
-			return nil, fmt.Errorf("failed to parse PROJECT_GROUPS: %w", err)
+		if config.ProjectGroups, err = parseProjectGroups(projectGroupsJSON); err != nil {
+			return nil, err
+		}
+		if err := validateProjectGroups(config.ProjectGroups, config.MaxProjectGroups, config.MaxProjectsPerGroup); err != nil {
+			return nil, fmt.Errorf("invalid PROJECT_GROUPS: %w", err)
 		}
 	}
 
 	// Load cache TTL from environment variable (default: 30s)
-	cacheTTLStr := getEnvOrDefault("CACHE_TTL", "30s")
-	cacheTTL, err := time.ParseDuration(cacheTTLStr)
+	cacheTTL, err := getDurationEnv("CACHE_TTL", "30s")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CACHE_TTL %q: %w", cacheTTLStr, err)
+		return nil, err
 	}
 	config.CacheTTL = cacheTTL
 
+	// Load the cache backend; redis shares the projects and applications caches
+	// between replicas
+	config.CacheBackend = getEnvOrDefault("CACHE_BACKEND", CacheBackendMemory)
+	switch config.CacheBackend {
+	case CacheBackendMemory:
+	case CacheBackendRedis:
+		config.RedisAddr = os.Getenv("REDIS_ADDR")
+		if config.RedisAddr == "" {
+			return nil, fmt.Errorf("REDIS_ADDR environment variable is required when CACHE_BACKEND=%s", CacheBackendRedis)
+		}
+		config.RedisPassword = os.Getenv("REDIS_PASSWORD")
+		config.RedisKeyPrefix = getEnvOrDefault("REDIS_KEY_PREFIX", "argocd-proxy:")
+		config.RedisStaleWindow, err = getDurationEnv("REDIS_STALE_WINDOW", "24h")
+		if err != nil {
+			return nil, err
+		}
+		if config.RedisStaleWindow < 0 {
+			return nil, fmt.Errorf("REDIS_STALE_WINDOW must not be negative, got %s", config.RedisStaleWindow)
+		}
+	default:
+		return nil, fmt.Errorf("CACHE_BACKEND must be %q or %q, got %q", CacheBackendMemory, CacheBackendRedis, config.CacheBackend)
+	}
+
+	// Load the cache snapshot imported at startup, from another replica or a file
+	config.CacheSnapshotURL = os.Getenv("CACHE_SNAPSHOT_URL")
+	config.CacheSnapshotFile = os.Getenv("CACHE_SNAPSHOT_FILE")
+	if config.CacheSnapshotURL != "" && config.CacheSnapshotFile != "" {
+		return nil, fmt.Errorf("CACHE_SNAPSHOT_URL and CACHE_SNAPSHOT_FILE cannot both be set")
+	}
+	if config.CacheSnapshotURL != "" {
+		u, err := url.Parse(config.CacheSnapshotURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("CACHE_SNAPSHOT_URL must be an absolute http or https URL, got %q", config.CacheSnapshotURL)
+		}
+	}
+
+	// Load maximum long-poll wait for ?watchAfter= requests (default: 30s)
+	watchMaxWait, err := getDurationEnv("WATCH_MAX_WAIT", "30s")
+	if err != nil {
+		return nil, err
+	}
+	config.WatchMaxWait = watchMaxWait
+
+	// Load background application poller interval (default: 0s = disabled)
+	if config.PollInterval, err = getDurationEnv("POLL_INTERVAL", "0s"); err != nil {
+		return nil, err
+	}
+	if config.PollInterval < 0 {
+		return nil, fmt.Errorf("POLL_INTERVAL must not be negative, got %s", config.PollInterval)
+	}
+	if config.ReconcileAgeThreshold, err = getDurationEnv("RECONCILE_AGE_THRESHOLD", "10m"); err != nil {
+		return nil, err
+	}
+	if config.ReconcileAgeThreshold <= 0 {
+		return nil, fmt.Errorf("RECONCILE_AGE_THRESHOLD must be positive, got %s", config.ReconcileAgeThreshold)
+	}
+
+	// Load the number of application snapshots kept for delta responses (default: 10)
+	if config.SnapshotHistoryDepth, err = getIntEnv("SNAPSHOT_HISTORY_DEPTH", "10"); err != nil {
+		return nil, err
+	}
+	if config.SnapshotHistoryDepth < 0 {
+		return nil, fmt.Errorf("SNAPSHOT_HISTORY_DEPTH must not be negative, got %d", config.SnapshotHistoryDepth)
+	}
+
+	// Load the number of concurrent ArgoCD calls per fan-out (default: 8)
+	if config.UpstreamConcurrency, err = getIntEnv("UPSTREAM_CONCURRENCY", "8"); err != nil {
+		return nil, err
+	}
+	if config.UpstreamConcurrency < 1 {
+		return nil, fmt.Errorf("UPSTREAM_CONCURRENCY must be at least 1, got %d", config.UpstreamConcurrency)
+	}
+
+	// Load the upstream call deadlines (default: 10s hard, no soft deadline)
+	if config.UpstreamTimeout, err = getDurationEnv("UPSTREAM_TIMEOUT", "10s"); err != nil {
+		return nil, err
+	}
+	if config.UpstreamTimeout <= 0 {
+		return nil, fmt.Errorf("UPSTREAM_TIMEOUT must be positive, got %s", config.UpstreamTimeout)
+	}
+	if config.UpstreamSoftTimeout, err = getDurationEnv("UPSTREAM_SOFT_TIMEOUT", "0s"); err != nil {
+		return nil, err
+	}
+	if config.UpstreamSoftTimeout < 0 {
+		return nil, fmt.Errorf("UPSTREAM_SOFT_TIMEOUT must not be negative, got %s", config.UpstreamSoftTimeout)
+	}
+	if config.UpstreamSoftTimeout >= config.UpstreamTimeout {
+		return nil, fmt.Errorf("UPSTREAM_SOFT_TIMEOUT (%s) must be below UPSTREAM_TIMEOUT (%s)", config.UpstreamSoftTimeout, config.UpstreamTimeout)
+	}
+
+	// Load the API request deadline (default: 10s, or UPSTREAM_TIMEOUT when longer)
+	if config.RequestTimeout, err = getDurationEnv("REQUEST_TIMEOUT", max(10*time.Second, config.UpstreamTimeout).String()); err != nil {
+		return nil, err
+	}
+	if config.RequestTimeout < config.UpstreamTimeout {
+		return nil, fmt.Errorf("REQUEST_TIMEOUT (%s) must not be below UPSTREAM_TIMEOUT (%s)", config.RequestTimeout, config.UpstreamTimeout)
+	}
+
+	// Load the upstream error body limit (default: 512 bytes)
+	if config.UpstreamErrorBodyLimit, err = getIntEnv("UPSTREAM_ERROR_BODY_LIMIT", "512"); err != nil {
+		return nil, err
+	}
+	if config.UpstreamErrorBodyLimit < 0 {
+		return nil, fmt.Errorf("UPSTREAM_ERROR_BODY_LIMIT must not be negative, got %d", config.UpstreamErrorBodyLimit)
+	}
+
+	// Load whether error responses include ArgoCD's answer by default (default: false)
+	if config.UpstreamErrorPassthrough, err = getBoolEnv("UPSTREAM_ERROR_PASSTHROUGH", "false"); err != nil {
+		return nil, err
+	}
+
+	// Load the upstream response body size cap (default: 50 MB)
+	upstreamMaxBodyBytes, err := getIntEnv("UPSTREAM_MAX_BODY_BYTES", "52428800")
+	if err != nil {
+		return nil, err
+	}
+	if upstreamMaxBodyBytes < 1 {
+		return nil, fmt.Errorf("UPSTREAM_MAX_BODY_BYTES must be at least 1, got %d", upstreamMaxBodyBytes)
+	}
+	config.UpstreamMaxBodyBytes = int64(upstreamMaxBodyBytes)
+
+	// Load the unknown upstream field check (default: off)
+	config.UpstreamStrictDecode = strings.ToLower(getEnvOrDefault("UPSTREAM_STRICT_DECODE", StrictDecodeOff))
+	switch config.UpstreamStrictDecode {
+	case StrictDecodeOff, StrictDecodeWarn, StrictDecodeError:
+	default:
+		return nil, fmt.Errorf("UPSTREAM_STRICT_DECODE must be %q, %q or %q, got %q",
+			StrictDecodeOff, StrictDecodeWarn, StrictDecodeError, config.UpstreamStrictDecode)
+	}
+
+	// Load the number of health checks kept in the health history (default: 100)
+	if config.HealthHistorySize, err = getIntEnv("HEALTH_HISTORY_SIZE", "100"); err != nil {
+		return nil, err
+	}
+	if config.HealthHistorySize < 0 {
+		return nil, fmt.Errorf("HEALTH_HISTORY_SIZE must not be negative, got %d", config.HealthHistorySize)
+	}
+	if config.HealthFailureThreshold, err = getIntEnv("HEALTH_FAILURE_THRESHOLD", "1"); err != nil {
+		return nil, err
+	}
+	if config.HealthFailureThreshold < 1 {
+		return nil, fmt.Errorf("HEALTH_FAILURE_THRESHOLD must be at least 1, got %d", config.HealthFailureThreshold)
+	}
+	if config.HealthRecoveryThreshold, err = getIntEnv("HEALTH_RECOVERY_THRESHOLD", "1"); err != nil {
+		return nil, err
+	}
+	if config.HealthRecoveryThreshold < 1 {
+		return nil, fmt.Errorf("HEALTH_RECOVERY_THRESHOLD must be at least 1, got %d", config.HealthRecoveryThreshold)
+	}
+	if config.DeepHealth, err = getBoolEnv("DEEP_HEALTH", "false"); err != nil {
+		return nil, err
+	}
+	if config.ResolveClusterNames, err = getBoolEnv("RESOLVE_CLUSTER_NAMES", "true"); err != nil {
+		return nil, err
+	}
+	config.OwnerAnnotations = splitAndTrim(os.Getenv("OWNER_ANNOTATIONS"))
+	if config.LogsEndpointEnabled, err = getBoolEnv("LOGS_ENDPOINT_ENABLED", "false"); err != nil {
+		return nil, err
+	}
+	if config.LogsMaxTailLines, err = getIntEnv("LOGS_MAX_TAIL_LINES", "1000"); err != nil {
+		return nil, err
+	}
+	if config.LogsMaxTailLines < 1 {
+		return nil, fmt.Errorf("LOGS_MAX_TAIL_LINES must be at least 1, got %d", config.LogsMaxTailLines)
+	}
+	if config.CompatCheckInterval, err = getDurationEnv("COMPAT_CHECK_INTERVAL", "10m"); err != nil {
+		return nil, err
+	}
+	if config.CompatCheckInterval < 0 {
+		return nil, fmt.Errorf("COMPAT_CHECK_INTERVAL must not be negative, got %s", config.CompatCheckInterval)
+	}
+
+	// Load metrics naming and histogram bucket overrides
+	config.MetricsNamespace = os.Getenv("METRICS_NAMESPACE")
+	if bucketsStr := os.Getenv("METRICS_BUCKETS"); bucketsStr != "" {
+		buckets, err := parseBuckets(bucketsStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse METRICS_BUCKETS %q: %w", bucketsStr, err)
+		}
+		config.MetricsBuckets = buckets
+	}
+	metricsExemplars, err := getBoolEnv("METRICS_EXEMPLARS", "false")
+	if err != nil {
+		return nil, err
+	}
+	config.MetricsExemplars = metricsExemplars
+
+	// Load query parameter validation settings
+	strictQueryParams, err := getBoolEnv("STRICT_QUERY_PARAMS", "false")
+	if err != nil {
+		return nil, err
+	}
+	config.StrictQueryParams = strictQueryParams
+
+	// Load the feature flag overrides
+	if config.Features, config.FeatureWarnings, err = features.Parse(os.Getenv("FEATURES")); err != nil {
+		return nil, fmt.Errorf("failed to parse FEATURES: %w", err)
+	}
+
+	maxQueryLength, err := getIntEnv("MAX_QUERY_LENGTH", "2048")
+	if err != nil {
+		return nil, err
+	}
+	if maxQueryLength < 0 {
+		return nil, fmt.Errorf("MAX_QUERY_LENGTH must not be negative, got %d", maxQueryLength)
+	}
+	config.MaxQueryLength = maxQueryLength
+
+	// Load the list response size cap (disabled by default)
+	if config.MaxResponseItems, err = getIntEnv("MAX_RESPONSE_ITEMS", "0"); err != nil {
+		return nil, err
+	}
+	if config.MaxResponseItems < 0 {
+		return nil, fmt.Errorf("MAX_RESPONSE_ITEMS must not be negative, got %d", config.MaxResponseItems)
+	}
+
+	// Load handler-level response cache settings (disabled by default)
+	if config.ResponseCacheTTL, err = getDurationEnv("RESPONSE_CACHE_TTL", "0s"); err != nil {
+		return nil, err
+	}
+	if config.ResponseCacheSize, err = getIntEnv("RESPONSE_CACHE_SIZE", "256"); err != nil {
+		return nil, err
+	}
+
+	// Load audit logging settings
+	if config.AuditLog, err = getBoolEnv("AUDIT_LOG", "false"); err != nil {
+		return nil, err
+	}
+	config.AuditLogPath = os.Getenv("AUDIT_LOG_PATH")
+	auditMaxSizeMB, err := getIntEnv("AUDIT_LOG_MAX_SIZE_MB", "100")
+	if err != nil {
+		return nil, err
+	}
+	config.AuditLogMaxSize = int64(auditMaxSizeMB) * 1024 * 1024
+	if config.AuditLogMaxBackups, err = getIntEnv("AUDIT_LOG_MAX_BACKUPS", "5"); err != nil {
+		return nil, err
+	}
+	config.AuditLogExclude = splitAndTrim(getEnvOrDefault("AUDIT_LOG_EXCLUDE", "/health,/metrics"))
+
+	// Load per-application circuit breaker settings
+	if config.AppCircuitThreshold, err = getIntEnv("APP_CIRCUIT_THRESHOLD", "5"); err != nil {
+		return nil, err
+	}
+	if config.AppCircuitThreshold < 0 {
+		return nil, fmt.Errorf("APP_CIRCUIT_THRESHOLD must not be negative, got %d", config.AppCircuitThreshold)
+	}
+	if config.AppCircuitWindow, err = getDurationEnv("APP_CIRCUIT_WINDOW", "1m"); err != nil {
+		return nil, err
+	}
+	if config.AppCircuitWindow <= 0 {
+		return nil, fmt.Errorf("APP_CIRCUIT_WINDOW must be positive, got %s", config.AppCircuitWindow)
+	}
+	if config.AppCircuitCooldown, err = getDurationEnv("APP_CIRCUIT_COOLDOWN", "30s"); err != nil {
+		return nil, err
+	}
+	if config.AppCircuitCooldown <= 0 {
+		return nil, fmt.Errorf("APP_CIRCUIT_COOLDOWN must be positive, got %s", config.AppCircuitCooldown)
+	}
+	if config.AppCircuitMaxApps, err = getIntEnv("APP_CIRCUIT_MAX_APPS", "1000"); err != nil {
+		return nil, err
+	}
+	if config.AppCircuitMaxApps < 1 {
+		return nil, fmt.Errorf("APP_CIRCUIT_MAX_APPS must be at least 1, got %d", config.AppCircuitMaxApps)
+	}
+
+	// Load shadow comparison settings
+	config.ShadowMode = strings.ToLower(getEnvOrDefault("SHADOW_MODE", ShadowModeOff))
+	if config.ShadowMode != ShadowModeOff && config.ShadowMode != ShadowModeCompare {
+		return nil, fmt.Errorf("SHADOW_MODE must be %q or %q, got %q", ShadowModeOff, ShadowModeCompare, config.ShadowMode)
+	}
+	if config.ShadowTimeout, err = getDurationEnv("SHADOW_TIMEOUT", "10s"); err != nil {
+		return nil, err
+	}
+	if config.ShadowTimeout <= 0 {
+		return nil, fmt.Errorf("SHADOW_TIMEOUT must be positive, got %s", config.ShadowTimeout)
+	}
+	if config.ShadowBudget, err = getDurationEnv("SHADOW_BUDGET", "5ms"); err != nil {
+		return nil, err
+	}
+	if config.ShadowBudget < 0 {
+		return nil, fmt.Errorf("SHADOW_BUDGET must not be negative, got %s", config.ShadowBudget)
+	}
+
+	// Load per-client statistics settings
+	if config.ClientStats, err = getBoolEnv("CLIENT_STATS", "false"); err != nil {
+		return nil, err
+	}
+	if config.ClientStatsMaxClients, err = getIntEnv("CLIENT_STATS_MAX_CLIENTS", "1000"); err != nil {
+		return nil, err
+	}
+	if config.ClientStatsMaxClients < 1 {
+		return nil, fmt.Errorf("CLIENT_STATS_MAX_CLIENTS must be at least 1, got %d", config.ClientStatsMaxClients)
+	}
+	if config.ClientStatsHalfLife, err = getDurationEnv("CLIENT_STATS_HALF_LIFE", "10m"); err != nil {
+		return nil, err
+	}
+	if config.ClientStatsHalfLife <= 0 {
+		return nil, fmt.Errorf("CLIENT_STATS_HALF_LIFE must be positive, got %s", config.ClientStatsHalfLife)
+	}
+	if config.ClientStatsIdleTimeout, err = getDurationEnv("CLIENT_STATS_IDLE_TIMEOUT", "1h"); err != nil {
+		return nil, err
+	}
+	if config.ClientStatsIdleTimeout < 0 {
+		return nil, fmt.Errorf("CLIENT_STATS_IDLE_TIMEOUT must not be negative, got %s", config.ClientStatsIdleTimeout)
+	}
+	config.ClientStatsSalt = os.Getenv("CLIENT_STATS_SALT")
+
+	config.Debug = os.Getenv("GIN_MODE") == "debug"
+	// Swagger UI is served by default only in debug mode so release builds do not publish the route map
+	if config.SwaggerEnabled, err = getBoolEnv("SWAGGER_ENABLED", strconv.FormatBool(config.Debug)); err != nil {
+		return nil, err
+	}
+	if config.SwaggerPath, err = parseSwaggerPath(getEnvOrDefault("SWAGGER_PATH", DefaultSwaggerPath)); err != nil {
+		return nil, err
+	}
+	config.ExternalURL = strings.TrimSuffix(os.Getenv("EXTERNAL_URL"), "/")
+	if config.ExternalURL != "" {
+		u, err := url.Parse(config.ExternalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("EXTERNAL_URL must be an absolute http or https URL, got %q", config.ExternalURL)
+		}
+	}
+	// Security headers are sent by default; HSTS only applies to requests served over TLS
+	if config.SecurityHeadersDisabled, err = getBoolEnv("SECURITY_HEADERS_DISABLED", "false"); err != nil {
+		return nil, err
+	}
+	if config.HSTSMaxAge, err = getDurationEnv("HSTS_MAX_AGE", "8760h"); err != nil {
+		return nil, err
+	}
+	if config.HSTSMaxAge < 0 {
+		return nil, fmt.Errorf("HSTS_MAX_AGE must not be negative, got %s", config.HSTSMaxAge)
+	}
+
+	config.BasePath = os.Getenv("BASE_PATH")
+	if config.BasePath != "" && !strings.HasPrefix(config.BasePath, "/") {
+		return nil, fmt.Errorf("BASE_PATH must start with /, got %q", config.BasePath)
+	}
+
+	config.OutboundProxy = os.Getenv("ARGOCD_OUTBOUND_PROXY")
+	if config.OutboundProxy != "" {
+		u, err := url.Parse(config.OutboundProxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			// Never echo the value: it may embed proxy credentials
+			return nil, fmt.Errorf("ARGOCD_OUTBOUND_PROXY must be an absolute http, https or socks5 URL")
+		}
+	}
+	config.OutboundProxyUsername = os.Getenv("ARGOCD_OUTBOUND_PROXY_USERNAME")
+	config.OutboundProxyPassword = os.Getenv("ARGOCD_OUTBOUND_PROXY_PASSWORD")
+	if config.OutboundProxyUsername != "" && config.OutboundProxy == "" {
+		return nil, fmt.Errorf("ARGOCD_OUTBOUND_PROXY_USERNAME requires ARGOCD_OUTBOUND_PROXY")
+	}
+	if config.OutboundProxyPassword != "" && config.OutboundProxyUsername == "" {
+		return nil, fmt.Errorf("ARGOCD_OUTBOUND_PROXY_PASSWORD requires ARGOCD_OUTBOUND_PROXY_USERNAME")
+	}
+
+	// Load how long calls stay on a fallback ArgoCD URL (default: 30s)
+	if config.ArgocdPrimaryProbeInterval, err = getDurationEnv("ARGOCD_PRIMARY_PROBE_INTERVAL", "30s"); err != nil {
+		return nil, err
+	}
+	if config.ArgocdPrimaryProbeInterval <= 0 {
+		return nil, fmt.Errorf("ARGOCD_PRIMARY_PROBE_INTERVAL must be positive, got %s", config.ArgocdPrimaryProbeInterval)
+	}
+
+	// Load the hosts ArgoCD may redirect to (default: none)
+	config.ArgocdRedirectHosts = splitAndTrim(os.Getenv("ARGOCD_REDIRECT_HOSTS"))
+	for _, host := range config.ArgocdRedirectHosts {
+		if strings.ContainsAny(host, "/:@*") {
+			return nil, fmt.Errorf("ARGOCD_REDIRECT_HOSTS entries must be host names without scheme, port or path, got %q", host)
+		}
+	}
+
+	config.TrustedProxies = splitAndTrim(os.Getenv("TRUSTED_PROXIES"))
+	for _, proxy := range config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("failed to parse TRUSTED_PROXIES %q: not a CIDR or IP address", proxy)
+		}
+	}
+
+	config.AdminToken = os.Getenv("ADMIN_TOKEN")
+	if config.AdminToken != "" && len(config.AdminToken) < minAdminTokenLength {
+		return nil, fmt.Errorf("ADMIN_TOKEN must be at least %d characters", minAdminTokenLength)
+	}
+	if config.EventLogSize, err = getIntEnv("EVENT_LOG_SIZE", "500"); err != nil {
+		return nil, err
+	}
+	if config.EventLogSize < 1 {
+		return nil, fmt.Errorf("EVENT_LOG_SIZE must be at least 1, got %d", config.EventLogSize)
+	}
+
+	// API_KEYS_JSON replaces API_KEYS, which is only read when it is unset
+	if value := os.Getenv("API_KEYS_JSON"); value != "" {
+		config.APIKeys, err = parseAPIKeysJSON(value)
+	} else {
+		config.APIKeys, err = parseAPIKeys(os.Getenv("API_KEYS"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := config.parseAPIKeyGroups(os.Getenv("API_KEY_GROUPS")); err != nil {
+		return nil, err
+	}
+	config.UpstreamImpersonationHeader = os.Getenv("UPSTREAM_IMPERSONATION_HEADER")
+	config.UpstreamImpersonationIdentityHeader = os.Getenv("UPSTREAM_IMPERSONATION_IDENTITY_HEADER")
+	if header := config.UpstreamImpersonationHeader; header != "" && !httpguts.ValidHeaderFieldName(header) {
+		return nil, fmt.Errorf("UPSTREAM_IMPERSONATION_HEADER must be a valid HTTP header name, got %q", header)
+	}
+	if header := config.UpstreamImpersonationIdentityHeader; header != "" && !httpguts.ValidHeaderFieldName(header) {
+		return nil, fmt.Errorf("UPSTREAM_IMPERSONATION_IDENTITY_HEADER must be a valid HTTP header name, got %q", header)
+	}
+	if config.UpstreamImpersonationIdentityHeader != "" && config.UpstreamImpersonationHeader == "" {
+		return nil, fmt.Errorf("UPSTREAM_IMPERSONATION_IDENTITY_HEADER requires UPSTREAM_IMPERSONATION_HEADER")
+	}
+	config.DisabledEndpoints = splitAndTrim(os.Getenv("DISABLED_ENDPOINTS"))
+	for _, pattern := range config.DisabledEndpoints {
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("DISABLED_ENDPOINTS entries must be route templates starting with /, got %q", pattern)
+		}
+	}
+	config.AuthExemptRoutes = splitAndTrim(getEnvOrDefault("AUTH_EXEMPT_ROUTES", "/health,/readyz,/metrics"))
+	if config.AnonymousReadOnly, err = getBoolEnv("ANONYMOUS_READ_ONLY", "false"); err != nil {
+		return nil, err
+	}
+
+	config.ErrorFormat = getEnvOrDefault("ERROR_FORMAT", ErrorFormatJSON)
+	if config.ErrorFormat != ErrorFormatJSON && config.ErrorFormat != ErrorFormatProblem {
+		return nil, fmt.Errorf("ERROR_FORMAT must be %q or %q, got %q", ErrorFormatJSON, ErrorFormatProblem, config.ErrorFormat)
+	}
+	config.ResponseFormats = splitAndTrim(os.Getenv("RESPONSE_FORMATS"))
+	for _, format := range config.ResponseFormats {
+		if format != ResponseFormatLegacy {
+			return nil, fmt.Errorf("RESPONSE_FORMATS entries must be %q, got %q", ResponseFormatLegacy, format)
+		}
+	}
+
+	if config.SlowRequestThreshold, err = getDurationEnv("SLOW_REQUEST_THRESHOLD", "0s"); err != nil {
+		return nil, err
+	}
+	if config.SlowRequestThreshold < 0 {
+		return nil, fmt.Errorf("SLOW_REQUEST_THRESHOLD must not be negative, got %s", config.SlowRequestThreshold)
+	}
+	if config.SlowRequestRouteThresholds, err = parseRouteThresholds(os.Getenv("SLOW_REQUEST_ROUTE_THRESHOLDS")); err != nil {
+		return nil, fmt.Errorf("failed to parse SLOW_REQUEST_ROUTE_THRESHOLDS: %w", err)
+	}
+
 	// Load ignored projects from environment variable
 	if ignoredProjectsStr := os.Getenv("IGNORED_PROJECTS"); ignoredProjectsStr != "" {
 		config.IgnoredProjects = strings.Split(ignoredProjectsStr, ",")
@@ -87,33 +922,483 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getDurationEnv parses a Go duration from an environment variable or a default value
+func getDurationEnv(key, defaultValue string) (time.Duration, error) {
+	value := getEnvOrDefault(key, defaultValue)
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s %q: %w", key, value, err)
+	}
+	return duration, nil
+}
+
+// splitAndTrim splits a comma-separated list, trimming whitespace and dropping empty entries
+func splitAndTrim(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getBoolEnv parses a boolean from an environment variable or a default value
+func getBoolEnv(key, defaultValue string) (bool, error) {
+	value := getEnvOrDefault(key, defaultValue)
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s %q: %w", key, value, err)
+	}
+	return b, nil
+}
+
+// getIntEnv parses an integer from an environment variable or a default value
+func getIntEnv(key, defaultValue string) (int, error) {
+	value := getEnvOrDefault(key, defaultValue)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// parseSwaggerPath validates SWAGGER_PATH and strips its trailing slash. The path must be
+// absolute, free of route wildcards and outside the API routes.
+func parseSwaggerPath(value string) (string, error) {
+	swaggerPath := strings.TrimSuffix(strings.TrimSpace(value), "/")
+	if !strings.HasPrefix(swaggerPath, "/") || strings.ContainsAny(swaggerPath, ":*?#") || path.Clean(swaggerPath) != swaggerPath {
+		return "", fmt.Errorf("SWAGGER_PATH must be an absolute path such as %s, got %q", DefaultSwaggerPath, value)
+	}
+	first, _, _ := strings.Cut(swaggerPath[1:], "/")
+	if slices.Contains(reservedSwaggerPaths, first) {
+		return "", fmt.Errorf("SWAGGER_PATH must not be under the /%s API routes, got %q", first, value)
+	}
+	return swaggerPath, nil
+}
+
+// parseRouteThresholds parses a comma-separated list of route=duration pairs such as
+// "/applications=2s,/projects/:project/applications=500ms"
+func parseRouteThresholds(value string) (map[string]time.Duration, error) {
+	thresholds := make(map[string]time.Duration)
+	for _, entry := range splitAndTrim(value) {
+		route, rawDuration, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("entry %q must have the form /route=duration", entry)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(rawDuration))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for route %s: %w", route, err)
+		}
+		if duration < 0 {
+			return nil, fmt.Errorf("duration for route %s must not be negative, got %s", route, duration)
+		}
+		thresholds[route] = duration
+	}
+	return thresholds, nil
+}
+
+// SlowRequestThresholdFor returns the slow request threshold of a route template,
+// preferring a per-route override over SlowRequestThreshold; 0 means disabled
+func (c *Config) SlowRequestThresholdFor(route string) time.Duration {
+	if threshold, ok := c.SlowRequestRouteThresholds[route]; ok {
+		return threshold
+	}
+	return c.SlowRequestThreshold
+}
+
+// APIURLs returns the URLs of ARGOCD_API_URL, the primary first. A configuration built
+// without ArgocdAPIURLs has ArgocdAPIURL as its only URL.
+func (c *Config) APIURLs() []string {
+	if len(c.ArgocdAPIURLs) == 0 {
+		return []string{c.ArgocdAPIURL}
+	}
+	return c.ArgocdAPIURLs
+}
+
+// parseBuckets parses a comma-separated list of strictly increasing positive bucket boundaries
+func parseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, part := range strings.Split(value, ",") {
+		bucket, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %w", strings.TrimSpace(part), err)
+		}
+		if bucket <= 0 {
+			return nil, fmt.Errorf("bucket %v must be positive", bucket)
+		}
+		if len(buckets) > 0 && bucket <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("buckets must be strictly increasing, got %v after %v", bucket, buckets[len(buckets)-1])
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// parseProjectTokens decodes the ARGOCD_PROJECT_TOKENS JSON object mapping project
+// names to tokens. Empty input configures no tokens.
+func parseProjectTokens(data string) (map[string]string, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+
+	var tokens map[string]string
+	if err := json.Unmarshal([]byte(data), &tokens); err != nil {
+		// The error must not echo the input, which holds the tokens
+		return nil, fmt.Errorf("ARGOCD_PROJECT_TOKENS must be a JSON object mapping project names to tokens")
+	}
+	for project, token := range tokens {
+		if strings.TrimSpace(project) == "" {
+			return nil, fmt.Errorf("ARGOCD_PROJECT_TOKENS contains an empty project name")
+		}
+		if strings.TrimSpace(token) == "" {
+			return nil, fmt.Errorf("ARGOCD_PROJECT_TOKENS has an empty token for project %q", project)
+		}
+	}
+	return tokens, nil
+}
+
+// parseAPIKeys parses the deprecated API_KEYS, a comma-separated list of name:key pairs.
+// Names and keys must be unique and keys at least minAPIKeyLength characters long.
+func parseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	for i, entry := range splitAndTrim(value) {
+		// Errors must not echo the entries, which hold the keys
+		name, key, ok := strings.Cut(entry, ":")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			return nil, fmt.Errorf("API_KEYS entry %d must be a name:key pair", i)
+		}
+		keys = append(keys, APIKey{Name: name, Key: strings.TrimSpace(key)})
+	}
+	return keys, validateAPIKeys("API_KEYS", keys)
+}
+
+// parseAPIKeysJSON parses API_KEYS_JSON, a JSON array of {"name": ..., "key": ...}
+// objects, under the rules of API_KEYS
+func parseAPIKeysJSON(value string) ([]APIKey, error) {
+	var entries []struct {
+		Name string `json:"name"`
+		Key  string `json:"key"`
+	}
+	// Decoding errors may quote the keys, so they are not wrapped
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil, errors.New(`API_KEYS_JSON must be a JSON array of {"name": ..., "key": ...} objects`)
+	}
+	keys := make([]APIKey, len(entries))
+	for i, entry := range entries {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("API_KEYS_JSON entry %d must have a name", i)
+		}
+		keys[i] = APIKey{Name: entry.Name, Key: entry.Key}
+	}
+	return keys, validateAPIKeys("API_KEYS_JSON", keys)
+}
+
+// validateAPIKeys checks that the names and keys read from variable are unique and the
+// keys at least minAPIKeyLength characters long
+func validateAPIKeys(variable string, keys []APIKey) error {
+	names := make(map[string]bool)
+	values := make(map[string]bool)
+	for _, key := range keys {
+		switch {
+		case len(key.Key) < minAPIKeyLength:
+			return fmt.Errorf("%s key %q must be at least %d characters", variable, key.Name, minAPIKeyLength)
+		case names[key.Name]:
+			return fmt.Errorf("%s name %q is listed more than once", variable, key.Name)
+		case values[key.Key]:
+			return fmt.Errorf("%s key %q reuses the key of another entry", variable, key.Name)
+		}
+		names[key.Name], values[key.Key] = true, true
+	}
+	return nil
+}
+
+// parseAPIKeyGroups parses API_KEY_GROUPS, a comma-separated list of name:group|group
+// entries restricting API keys to project groups. Every name must be an API_KEYS entry
+// and every group a configured project group; groups are stored by their canonical name.
+func (c *Config) parseAPIKeyGroups(value string) error {
+	scoped := make(map[string]bool)
+	for i, entry := range splitAndTrim(value) {
+		name, list, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("API_KEY_GROUPS entry %d must be a name:group|group pair", i)
+		}
+		index := slices.IndexFunc(c.APIKeys, func(key APIKey) bool { return key.Name == name })
+		switch {
+		case index < 0:
+			return fmt.Errorf("API_KEY_GROUPS names %q, which is not an API_KEYS entry", name)
+		case scoped[name]:
+			return fmt.Errorf("API_KEY_GROUPS name %q is listed more than once", name)
+		}
+		scoped[name] = true
+
+		groups := []string{}
+		for _, groupName := range strings.Split(list, "|") {
+			group, found := c.Snapshot().FindProjectGroup(groupName)
+			if !found {
+				return fmt.Errorf("API_KEY_GROUPS key %q names unknown project group %q", name, strings.TrimSpace(groupName))
+			}
+			if !slices.Contains(groups, group.Name) {
+				groups = append(groups, group.Name)
+			}
+		}
+		c.APIKeys[index].Groups = groups
+	}
+	return nil
+}
+
+// parseProjectGroups decodes the PROJECT_GROUPS JSON array. Errors name the line and
+// column of the problem and, for entries of the wrong shape, the index of the group.
+func parseProjectGroups(data string) ([]ProjectGroup, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+
+	// offset counts the bytes read up to and including the offending one, as in the
+	// offsets reported by encoding/json
+	positionError := func(offset int64, format string, args ...interface{}) error {
+		line, column := jsonPosition(data, offset)
+		return fmt.Errorf("failed to parse PROJECT_GROUPS at line %d, column %d (byte offset %d): %s",
+			line, column, max(offset-1, 0), fmt.Sprintf(format, args...))
+	}
+	decodeError := func(err error) error {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return positionError(syntaxErr.Offset, "%v", err)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return positionError(int64(len(data)), "unexpected end of JSON input")
+		}
+		return fmt.Errorf("failed to parse PROJECT_GROUPS: %w", err)
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, positionError(dec.InputOffset(), "expected a JSON array of groups, got %s", jsonTokenKind(tok))
+	}
+
+	groups := []ProjectGroup{}
+	for index := 0; dec.More(); index++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, decodeError(err)
+		}
+		start := dec.InputOffset() - int64(len(raw))
+
+		var group ProjectGroup
+		if err := json.Unmarshal(raw, &group); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				return nil, decodeError(err)
+			}
+			if typeErr.Field == "" {
+				return nil, positionError(start+1, "group %d must be an object, got %s", index, typeErr.Value)
+			}
+			return nil, positionError(start+typeErr.Offset, "group %d: %q must be %s, got %s",
+				index, typeErr.Field, jsonTypeName(typeErr.Type.String()), typeErr.Value)
+		}
+		groups = append(groups, group)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, decodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		return nil, positionError(dec.InputOffset(), "unexpected data after the groups array")
+	}
+	return groups, nil
+}
+
+// jsonPosition returns the 1-based line and column of the last of the first offset
+// bytes of data
+func jsonPosition(data string, offset int64) (line, column int) {
+	offset = min(max(offset, 1), int64(len(data)))
+	prefix := data[:offset]
+	line = strings.Count(prefix[:len(prefix)-1], "\n") + 1
+	column = len(prefix) - 1 - strings.LastIndex(prefix[:len(prefix)-1], "\n")
+	return line, column
+}
+
+// jsonTokenKind describes a top-level JSON token in an error message
+func jsonTokenKind(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		return "object"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// jsonTypeName maps the Go type expected by the decoder to its JSON name
+func jsonTypeName(goType string) string {
+	switch goType {
+	case "string":
+		return "a string"
+	case "[]string":
+		return "an array of strings"
+	case "int":
+		return "an integer"
+	default:
+		return goType
+	}
+}
+
+// validateProjectGroups rejects groups without a name or with empty project entries,
+// groups exceeding the configured size limits, and groups whose names are identical
+// once trimmed and compared case-insensitively, since group lookup could not tell them
+// apart. Errors name the index of the offending group.
+func validateProjectGroups(groups []ProjectGroup, maxGroups, maxProjects int) error {
+	if maxGroups > 0 && len(groups) > maxGroups {
+		return fmt.Errorf("%d groups configured, more than PROJECT_GROUPS_MAX_GROUPS=%d", len(groups), maxGroups)
+	}
+
+	seen := make(map[string]string, len(groups))
+	for index, group := range groups {
+		if strings.TrimSpace(group.Name) == "" {
+			return fmt.Errorf("group %d: name must not be empty", index)
+		}
+		if maxProjects > 0 && len(group.Projects) > maxProjects {
+			return fmt.Errorf("group %d (%q): %d projects listed, more than PROJECT_GROUPS_MAX_PROJECTS=%d",
+				index, group.Name, len(group.Projects), maxProjects)
+		}
+		for i, project := range group.Projects {
+			if strings.TrimSpace(project) == "" {
+				return fmt.Errorf("group %d (%q): project %d must not be empty", index, group.Name, i)
+			}
+		}
+		for i, pattern := range group.IgnoredProjects {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("group %d (%q): ignored project pattern %d must not be empty", index, group.Name, i)
+			}
+		}
+
+		key := normalizeGroupName(group.Name)
+		if previous, ok := seen[key]; ok {
+			return fmt.Errorf("project groups %q and %q differ only by case or surrounding whitespace", previous, group.Name)
+		}
+		seen[key] = group.Name
+	}
+	return nil
+}
+
+// normalizeGroupName returns the form of a group name used for lookups
+func normalizeGroupName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// FindProjectGroup looks up a project group by name, ignoring case and surrounding
+// whitespace. The returned group carries the canonical configured name.
+func (s *FilterSnapshot) FindProjectGroup(name string) (*ProjectGroup, bool) {
+	key := normalizeGroupName(name)
+	for i := range s.ProjectGroups {
+		if normalizeGroupName(s.ProjectGroups[i].Name) == key {
+			return &s.ProjectGroups[i], true
+		}
+	}
+	return nil, false
+}
+
 // IsProjectIgnored checks if a project should be ignored based on pattern matching
 // Supports exact match, prefix (*suffix), suffix (prefix*), and contains (*contains*)
-func (c *Config) IsProjectIgnored(projectName string) bool {
-	for _, ignored := range c.IgnoredProjects {
+func (s *FilterSnapshot) IsProjectIgnored(projectName string) bool {
+	_, ignored := s.MatchIgnoredPattern(projectName)
+	return ignored
+}
+
+// MatchIgnoredPattern returns the first ignore pattern in effect that matches the
+// project, and whether one does
+func (s *FilterSnapshot) MatchIgnoredPattern(projectName string) (string, bool) {
+	for _, ignored := range s.IgnoredProjects {
 		if matchesPattern(projectName, ignored) {
-			return true
+			return ignored, true
 		}
 	}
-	return false
+	return "", false
+}
+
+// IsApplicationIgnored reports whether the group's own ignore patterns hide an
+// application, matching either its name or its project. The global IGNORED_PROJECTS
+// patterns are applied before applications reach a group and are not consulted here.
+func (g *ProjectGroup) IsApplicationIgnored(appName, projectName string) bool {
+	_, ignored := g.MatchApplicationIgnore(appName, projectName)
+	return ignored
+}
+
+// MatchApplicationIgnore returns the first of the group's own ignore patterns that hides
+// an application, and whether one does
+func (g *ProjectGroup) MatchApplicationIgnore(appName, projectName string) (string, bool) {
+	for _, pattern := range g.IgnoredProjects {
+		if matchesPattern(appName, pattern) || matchesPattern(projectName, pattern) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// ProjectGroupWarnings describes suspicious but valid group configuration: group-level
+// ignore patterns that hide a project listed in the same group, and with it all of the
+// project's applications.
+func (c *Config) ProjectGroupWarnings() []string {
+	var warnings []string
+	for _, group := range c.ProjectGroups {
+		for _, project := range group.Projects {
+			for _, pattern := range group.IgnoredProjects {
+				if matchesPattern(project, pattern) {
+					warnings = append(warnings, fmt.Sprintf("group %q: ignored project pattern %q hides listed project %q",
+						group.Name, pattern, project))
+					break
+				}
+			}
+		}
+	}
+	return warnings
 }
 
 // ShouldFilterProject checks if a project should be filtered out.
 // Projects that are part of configured groups are never filtered, even if they match ignored patterns.
 // Other projects are filtered based on the ignored projects patterns.
-func (c *Config) ShouldFilterProject(projectName string) bool {
+func (s *FilterSnapshot) ShouldFilterProject(projectName string) bool {
+	_, filtered := s.FilteringPattern(projectName)
+	return filtered
+}
+
+// FilteringPattern returns the ignore pattern that filters the project out, and whether
+// the project is filtered, following the rules of ShouldFilterProject
+func (s *FilterSnapshot) FilteringPattern(projectName string) (string, bool) {
 	// First check if this project is part of any configured group
-	for _, group := range c.ProjectGroups {
-		for _, groupProject := range group.Projects {
+	if len(s.GroupsListing(projectName)) > 0 {
+		// Project is part of a group, never filter it
+		return "", false
+	}
+
+	// Project is not part of any group, check if it should be ignored
+	return s.MatchIgnoredPattern(projectName)
+}
+
+// GroupsListing returns the configured groups that list the project, in configuration order
+func (s *FilterSnapshot) GroupsListing(projectName string) []*ProjectGroup {
+	var groups []*ProjectGroup
+	for i := range s.ProjectGroups {
+		for _, groupProject := range s.ProjectGroups[i].Projects {
 			if groupProject == projectName {
-				// Project is part of a group, never filter it
-				return false
+				groups = append(groups, &s.ProjectGroups[i])
+				break
 			}
 		}
 	}
-
-	// Project is not part of any group, check if it should be ignored
-	return c.IsProjectIgnored(projectName)
+	return groups
 }
 
 // matchesPattern checks if a project name matches an ignore pattern
@@ -152,15 +1437,41 @@ func matchesPattern(projectName, pattern string) bool {
 	return false
 }
 
-// GetProjectGroups returns the configured project groups and ungrouped projects
-func (c *Config) GetProjectGroups(allProjects []string) ProjectGroupsResponse {
+// SortedProjectGroups returns copies of the configured groups ordered by Order and then
+// case-insensitively by name, each with its projects sorted alphabetically. The
+// configuration itself is left untouched.
+func (s *FilterSnapshot) SortedProjectGroups() []ProjectGroup {
+	groups := make([]ProjectGroup, len(s.ProjectGroups))
+	for i, group := range s.ProjectGroups {
+		group.Projects = append([]string(nil), group.Projects...)
+		sort.Strings(group.Projects)
+		if group.IgnoredProjects != nil {
+			group.IgnoredProjects = append([]string(nil), group.IgnoredProjects...)
+			sort.Strings(group.IgnoredProjects)
+		}
+		groups[i] = group
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Order != groups[j].Order {
+			return groups[i].Order < groups[j].Order
+		}
+		return normalizeGroupName(groups[i].Name) < normalizeGroupName(groups[j].Name)
+	})
+	return groups
+}
+
+// GetProjectGroups returns the configured project groups and the ungrouped projects.
+// Groups are ordered as by SortedProjectGroups and ungrouped projects alphabetically,
+// so responses do not depend on configuration or upstream ordering.
+func (s *FilterSnapshot) GetProjectGroups(allProjects []string) ProjectGroupsResponse {
 	response := ProjectGroupsResponse{
-		Groups: c.ProjectGroups,
+		Groups: s.SortedProjectGroups(),
 	}
 
 	// Create a map of all grouped projects
 	groupedProjects := make(map[string]bool)
-	for _, group := range c.ProjectGroups {
+	for _, group := range s.ProjectGroups {
 		for _, project := range group.Projects {
 			groupedProjects[project] = true
 		}
@@ -168,19 +1479,76 @@ func (c *Config) GetProjectGroups(allProjects []string) ProjectGroupsResponse {
 
 	// Find ungrouped projects (excluding ignored ones)
 	for _, project := range allProjects {
-		if !groupedProjects[project] && !c.IsProjectIgnored(project) {
+		if !groupedProjects[project] && !s.IsProjectIgnored(project) {
 			response.UngroupedProjects = append(response.UngroupedProjects, project)
 		}
 	}
+	sort.Strings(response.UngroupedProjects)
 
 	return response
 }
 
+// ExportProjectGroups resolves the grouping of allProjects into its canonical export form
+// and computes its content hash. Duplicate project names are collapsed and empty lists
+// serialize as [] rather than null, so equivalent inputs always hash identically.
+func (s *FilterSnapshot) ExportProjectGroups(allProjects []string) ProjectGroupsExport {
+	resolved := s.GetProjectGroups(uniqueSorted(allProjects))
+
+	export := ProjectGroupsExport{
+		Groups:            resolved.Groups,
+		UngroupedProjects: nonNil(resolved.UngroupedProjects),
+		IgnoredPatterns:   uniqueSorted(s.IgnoredProjects),
+		IgnoredProjects:   []string{},
+	}
+	for i := range export.Groups {
+		export.Groups[i].Projects = uniqueSorted(export.Groups[i].Projects)
+	}
+
+	grouped := make(map[string]bool)
+	for _, group := range export.Groups {
+		for _, project := range group.Projects {
+			grouped[project] = true
+		}
+	}
+	for _, project := range uniqueSorted(allProjects) {
+		if !grouped[project] && s.IsProjectIgnored(project) {
+			export.IgnoredProjects = append(export.IgnoredProjects, project)
+		}
+	}
+
+	// Marshalling a struct of strings, ints and slices cannot fail
+	hash, _ := canonical.Hash(export)
+	export.Hash = "sha256:" + hash
+	return export
+}
+
+// uniqueSorted returns a sorted copy of values without duplicates, never nil
+func uniqueSorted(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// nonNil returns values, or an empty slice when values is nil
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 // FilterProjects returns a list of projects that are not ignored
-func (c *Config) FilterProjects(projects []string) []string {
+func (s *FilterSnapshot) FilterProjects(projects []string) []string {
 	var filtered []string
 	for _, project := range projects {
-		if !c.IsProjectIgnored(project) {
+		if !s.IsProjectIgnored(project) {
 			filtered = append(filtered, project)
 		}
 	}
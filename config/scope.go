@@ -0,0 +1,58 @@
+package config
+
+import "slices"
+
+// A scope is the list of project groups an API key is restricted to, from
+// API_KEY_GROUPS. A nil scope is unrestricted: the caller sees every project not hidden
+// by the ignore rules.
+
+// ScopeAllowsGroup reports whether a caller with the given scope may see the group
+func (s *FilterSnapshot) ScopeAllowsGroup(scope []string, group string) bool {
+	if scope == nil {
+		return true
+	}
+	return slices.ContainsFunc(scope, func(name string) bool {
+		return normalizeGroupName(name) == normalizeGroupName(group)
+	})
+}
+
+// ScopeAllowsProject reports whether a caller with the given scope may see the project:
+// it must not be filtered and, for a restricted caller, be listed by one of its groups
+func (s *FilterSnapshot) ScopeAllowsProject(scope []string, project string) bool {
+	if s.ShouldFilterProject(project) {
+		return false
+	}
+	if scope == nil {
+		return true
+	}
+	for _, group := range s.GroupsListing(project) {
+		if s.ScopeAllowsGroup(scope, group.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleGroups returns the names of the project groups a caller with the given scope
+// may see, ordered as by SortedProjectGroups
+func (s *FilterSnapshot) VisibleGroups(scope []string) []string {
+	groups := []string{}
+	for _, group := range s.SortedProjectGroups() {
+		if s.ScopeAllowsGroup(scope, group.Name) {
+			groups = append(groups, group.Name)
+		}
+	}
+	return groups
+}
+
+// VisibleProjects returns the sorted, deduplicated projects a caller with the given
+// scope may see among projects
+func (s *FilterSnapshot) VisibleProjects(scope []string, projects []string) []string {
+	visible := []string{}
+	for _, project := range uniqueSorted(projects) {
+		if s.ScopeAllowsProject(scope, project) {
+			visible = append(visible, project)
+		}
+	}
+	return visible
+}
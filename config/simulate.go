@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterCandidate is a candidate filter configuration to simulate. Omitted fields keep
+// the configuration in effect.
+type FilterCandidate struct {
+	IgnoredProjects *[]string       `json:"ignoredProjects,omitempty"`
+	ProjectGroups   *[]ProjectGroup `json:"projectGroups,omitempty"`
+}
+
+// CandidateError describes one invalid entry of a filter candidate
+type CandidateError struct {
+	// Field locates the entry, e.g. ignoredProjects[2] or projectGroups[0].ignoredProjects[1]
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// CandidateErrors lists every invalid entry of a filter candidate
+type CandidateErrors []CandidateError
+
+// Error implements the error interface
+func (e CandidateErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = fmt.Sprintf("%s: %s", err.Field, err.Reason)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields returns the locations of the invalid entries in order of appearance
+func (e CandidateErrors) Fields() []string {
+	fields := make([]string, len(e))
+	for i, err := range e {
+		fields[i] = err.Field
+	}
+	return fields
+}
+
+// VisibilityDiff compares which items are visible under the configuration in effect and
+// under a candidate
+type VisibilityDiff struct {
+	// NewlyHidden are visible now and hidden by the candidate, sorted
+	NewlyHidden []string `json:"newlyHidden"`
+	// NewlyVisible are hidden now and visible with the candidate, sorted
+	NewlyVisible []string `json:"newlyVisible"`
+	// UnchangedVisible and UnchangedHidden count the items the candidate does not affect
+	UnchangedVisible int `json:"unchangedVisible"`
+	UnchangedHidden  int `json:"unchangedHidden"`
+}
+
+// FilterSimulation is the outcome of simulating a filter candidate, as served by the
+// admin API
+type FilterSimulation struct {
+	Projects VisibilityDiff `json:"projects"`
+	// Applications only covers the currently visible applications, since the proxy does
+	// not keep the applications of hidden projects; NewlyVisible and UnchangedHidden are
+	// always empty
+	Applications VisibilityDiff `json:"applications"`
+}
+
+// CandidateSnapshot validates a filter candidate against the group limits and returns a
+// snapshot applying it over current, leaving current untouched. Every invalid entry is
+// reported in a CandidateErrors.
+func (c *Config) CandidateSnapshot(current *FilterSnapshot, candidate FilterCandidate) (*FilterSnapshot, error) {
+	simulated := &FilterSnapshot{
+		IgnoredProjects: append([]string(nil), current.IgnoredProjects...),
+		ProjectGroups:   current.ProjectGroups,
+	}
+
+	var errs CandidateErrors
+	if candidate.IgnoredProjects != nil {
+		simulated.IgnoredProjects = make([]string, 0, len(*candidate.IgnoredProjects))
+		seen := make(map[string]bool)
+		for i, pattern := range *candidate.IgnoredProjects {
+			pattern = strings.TrimSpace(pattern)
+			field := fmt.Sprintf("ignoredProjects[%d]", i)
+			if err := ValidateIgnoredPattern(pattern); err != nil {
+				errs = append(errs, CandidateError{Field: field, Reason: err.Error()})
+				continue
+			}
+			if seen[pattern] {
+				errs = append(errs, CandidateError{Field: field, Reason: fmt.Sprintf("%q is listed more than once", pattern)})
+				continue
+			}
+			seen[pattern] = true
+			simulated.IgnoredProjects = append(simulated.IgnoredProjects, pattern)
+		}
+	}
+
+	if candidate.ProjectGroups != nil {
+		groups := *candidate.ProjectGroups
+		if err := validateProjectGroups(groups, c.MaxProjectGroups, c.MaxProjectsPerGroup); err != nil {
+			errs = append(errs, CandidateError{Field: "projectGroups", Reason: err.Error()})
+		}
+		for i, group := range groups {
+			for j, pattern := range group.IgnoredProjects {
+				if err := ValidateIgnoredPattern(strings.TrimSpace(pattern)); err != nil {
+					errs = append(errs, CandidateError{Field: fmt.Sprintf("projectGroups[%d].ignoredProjects[%d]", i, j), Reason: err.Error()})
+				}
+			}
+		}
+		simulated.ProjectGroups = groups
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return simulated, nil
+}
+
+// SimulateFilter compares the visibility of projects, and of the currently visible
+// applications keyed by name with their project, under s and under candidate
+func (s *FilterSnapshot) SimulateFilter(candidate *FilterSnapshot, projects []string, applications map[string]string) FilterSimulation {
+	simulation := FilterSimulation{
+		Projects:     VisibilityDiff{NewlyHidden: []string{}, NewlyVisible: []string{}},
+		Applications: VisibilityDiff{NewlyHidden: []string{}, NewlyVisible: []string{}},
+	}
+
+	for _, project := range uniqueSorted(projects) {
+		simulation.Projects.add(project, !s.ShouldFilterProject(project), !candidate.ShouldFilterProject(project))
+	}
+	for name, project := range applications {
+		if !s.ShouldFilterProject(project) {
+			simulation.Applications.add(name, true, !candidate.ShouldFilterProject(project))
+		}
+	}
+	sort.Strings(simulation.Applications.NewlyHidden)
+	return simulation
+}
+
+// add records an item visible before and after as given
+func (d *VisibilityDiff) add(name string, before, after bool) {
+	switch {
+	case before && !after:
+		d.NewlyHidden = append(d.NewlyHidden, name)
+	case !before && after:
+		d.NewlyVisible = append(d.NewlyVisible, name)
+	case before:
+		d.UnchangedVisible++
+	default:
+		d.UnchangedHidden++
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import "context"
+
+// FilterSnapshot is an immutable view of the configuration that can change at runtime:
+// the project groups and the ignore patterns. A request takes one with Config.Snapshot
+// and makes every lookup against it, so it sees one consistent set of rules while
+// reloads Store new ones. Neither a snapshot nor its slices may be modified once taken.
+type FilterSnapshot struct {
+	ProjectGroups []ProjectGroup
+	// IgnoredProjects are the project ignore patterns in effect
+	IgnoredProjects []string
+	// IgnoredOverridden is set when the patterns were replaced at runtime rather than
+	// loaded from IGNORED_PROJECTS
+	IgnoredOverridden bool
+}
+
+// Snapshot returns the project groups and ignore patterns in effect. Until Store is
+// first called they are the ProjectGroups and IgnoredProjects loaded at startup.
+func (c *Config) Snapshot() *FilterSnapshot {
+	if snapshot := c.filters.Load(); snapshot != nil {
+		return snapshot
+	}
+	return &FilterSnapshot{ProjectGroups: c.ProjectGroups, IgnoredProjects: c.IgnoredProjects}
+}
+
+// snapshotKey is the context key under which a request's filter snapshot is stored
+type snapshotKey struct{}
+
+// WithSnapshot returns a context carrying the snapshot a request is served with, so
+// that code further down the call chain filters with the same rules as the handler
+func WithSnapshot(ctx context.Context, snapshot *FilterSnapshot) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, snapshot)
+}
+
+// SnapshotFor returns the snapshot carried by ctx, or the one in effect when ctx
+// carries none, as for background refreshes
+func (c *Config) SnapshotFor(ctx context.Context) *FilterSnapshot {
+	if snapshot, ok := ctx.Value(snapshotKey{}).(*FilterSnapshot); ok && snapshot != nil {
+		return snapshot
+	}
+	return c.Snapshot()
+}
+
+// Store atomically replaces the project groups and ignore patterns in effect. Requests
+// already holding a snapshot keep using it; later ones see the new rules. The caller
+// must not modify snapshot afterwards.
+func (c *Config) Store(snapshot *FilterSnapshot) {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	c.filters.Store(snapshot)
+}
+
+// update replaces the snapshot in effect with the result of applying change to a copy
+// of it and returns the new snapshot. Updates are serialized with Store, so concurrent
+// reloads do not lose each other's changes.
+func (c *Config) update(change func(next *FilterSnapshot)) *FilterSnapshot {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	next := *c.Snapshot()
+	change(&next)
+	c.filters.Store(&next)
+	return &next
+}
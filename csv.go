@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"iter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvContentType is the media type of CSV exports
+const csvContentType = "text/csv"
+
+// csvFlushRows is the number of rows written between flushes of a streamed CSV export
+const csvFlushRows = 500
+
+// prefersCSV reports whether the Accept header ranks text/csv above JSON. Routes offering
+// a CSV export respond with it then, and with JSON otherwise; they should vary on Accept.
+func prefersCSV(c *gin.Context) bool {
+	for _, r := range parseAccept(c.GetHeader("Accept")) {
+		if r.quality <= 0 {
+			continue
+		}
+		switch r.mediaType {
+		case csvContentType, "text/*":
+			return true
+		case gin.MIMEJSON, "application/*", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// renderCSV responds with a CSV document of a header row followed by rows
+func renderCSV(c *gin.Context, status int, header []string, rows [][]string) {
+	var body bytes.Buffer
+	w := csv.NewWriter(&body)
+	w.Write(header)
+	w.WriteAll(rows)
+	c.Data(status, csvContentType+"; charset=utf-8", body.Bytes())
+}
+
+// streamCSV responds with a CSV document of a header row followed by rows, writing the
+// rows to the client as they are produced instead of building the document first, for
+// exports that can be large
+func streamCSV(c *gin.Context, status int, header []string, rows iter.Seq[[]string]) {
+	c.Header("Content-Type", csvContentType+"; charset=utf-8")
+	c.Status(status)
+	w := csv.NewWriter(c.Writer)
+	w.Write(header)
+	written := 0
+	for row := range rows {
+		w.Write(row)
+		if written++; written%csvFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/services"
+)
+
+// routeRegistrar registers routes on a router group, leaving out the routes whose
+// template matches DISABLED_ENDPOINTS so that they answer 404 like unknown paths
+type routeRegistrar struct {
+	server *Server
+	group  *gin.RouterGroup
+}
+
+// routes returns a registrar for group
+func (s *Server) routes(group *gin.RouterGroup) routeRegistrar {
+	return routeRegistrar{server: s, group: group}
+}
+
+func (r routeRegistrar) GET(relativePath string, handler gin.HandlerFunc) {
+	r.handle(http.MethodGet, relativePath, handler)
+}
+
+func (r routeRegistrar) HEAD(relativePath string, handler gin.HandlerFunc) {
+	r.handle(http.MethodHead, relativePath, handler)
+}
+
+func (r routeRegistrar) PUT(relativePath string, handler gin.HandlerFunc) {
+	r.handle(http.MethodPut, relativePath, handler)
+}
+
+func (r routeRegistrar) POST(relativePath string, handler gin.HandlerFunc) {
+	r.handle(http.MethodPost, relativePath, handler)
+}
+
+func (r routeRegistrar) DELETE(relativePath string, handler gin.HandlerFunc) {
+	r.handle(http.MethodDelete, relativePath, handler)
+}
+
+// handle registers handler for method on relativePath unless the route is disabled
+func (r routeRegistrar) handle(method, relativePath string, handler gin.HandlerFunc) {
+	template := path.Join(r.group.BasePath(), relativePath)
+	if r.server.endpointDisabled(template) {
+		if !slices.Contains(r.server.disabledEndpoints, template) {
+			r.server.disabledEndpoints = append(r.server.disabledEndpoints, template)
+		}
+		return
+	}
+	r.group.Handle(method, relativePath, handler)
+}
+
+// endpointDisabled reports whether a route template matches one of DISABLED_ENDPOINTS.
+// Patterns are route templates in which '*' matches any sequence of characters.
+func (s *Server) endpointDisabled(template string) bool {
+	for _, pattern := range s.config.DisabledEndpoints {
+		if services.WildcardMatch(pattern, template) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportDisabledEndpoints logs the routes left out by DISABLED_ENDPOINTS and warns about
+// the patterns that matched none, which are likely typos or name routes not compiled in
+// or not enabled by the rest of the configuration
+func (s *Server) reportDisabledEndpoints() {
+	slices.Sort(s.disabledEndpoints)
+	if len(s.disabledEndpoints) > 0 {
+		log.Printf("Disabled endpoints: %s", strings.Join(s.disabledEndpoints, ", "))
+	}
+	for _, pattern := range s.config.DisabledEndpoints {
+		matched := slices.ContainsFunc(s.disabledEndpoints, func(template string) bool {
+			return services.WildcardMatch(pattern, template)
+		})
+		if !matched {
+			log.Printf("WARNING: DISABLED_ENDPOINTS pattern %q matches no route", pattern)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// setupDisabledEndpointsServer returns a test server with the logs endpoint and the admin
+// API enabled and patterns disabled, along with what setting up its router logged
+func setupDisabledEndpointsServer(t *testing.T, patterns ...string) (*Server, string) {
+	t.Helper()
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	server := setupTestServer()
+	server.config.LogsEndpointEnabled = true
+	server.config.AdminToken = "disabled-endpoints-admin-token"
+	server.config.DisabledEndpoints = patterns
+	server.setupRouter()
+	return server, logs.String()
+}
+
+func TestDisabledEndpoints(t *testing.T) {
+	server, logs := setupDisabledEndpointsServer(t, "/applications/:name/logs", "/applications/:name/diff", "/admin/*", "/topology/*")
+
+	disabled := []string{"/applications/web/logs", "/applications/web/diff", "/admin/features", "/admin/events", "/topology/repositories"}
+	for _, path := range disabled {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			if w := serveMethod(server, method, path, nil); w.Code != http.StatusNotFound {
+				t.Errorf("%s %s status = %d, want %d", method, path, w.Code, http.StatusNotFound)
+			}
+		}
+	}
+	// Every method of a disabled route is gone, so none answers 405 either
+	if w := serveMethod(server, http.MethodPost, "/admin/maintenance", nil); w.Code != http.StatusNotFound {
+		t.Errorf("POST /admin/maintenance status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	for _, path := range []string{"/applications", "/applications/names", "/projects"} {
+		if w := serveMethod(server, http.MethodGet, path, nil); w.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+
+	if !strings.Contains(logs, "Disabled endpoints: /admin/cache/snapshot, /admin/circuits, /admin/events,") || !strings.Contains(logs, "/applications/:name/diff, /applications/:name/logs, /topology/repositories\n") {
+		t.Errorf("logs = %q, want the sorted disabled route templates", logs)
+	}
+	if strings.Contains(logs, "WARNING") {
+		t.Errorf("logs = %q, want no warnings when every pattern matches", logs)
+	}
+
+	w := serveMethod(server, http.MethodGet, "/info", nil)
+	var info types.InfoResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("GET /info: invalid JSON response: %v", err)
+	}
+	if len(info.DisabledEndpoints) != len(server.disabledEndpoints) || info.DisabledEndpoints[0] != "/admin/cache/snapshot" {
+		t.Errorf("info.disabledEndpoints = %v, want %v", info.DisabledEndpoints, server.disabledEndpoints)
+	}
+}
+
+func TestDisabledEndpointsUnknownPattern(t *testing.T) {
+	server, logs := setupDisabledEndpointsServer(t, "/clusters", "/applications/:name/logs")
+
+	if !strings.Contains(logs, `WARNING: DISABLED_ENDPOINTS pattern "/clusters" matches no route`) {
+		t.Errorf("logs = %q, want a warning about /clusters", logs)
+	}
+	if !reflect.DeepEqual(server.disabledEndpoints, []string{"/applications/:name/logs"}) {
+		t.Errorf("disabled endpoints = %v, want only the logs route", server.disabledEndpoints)
+	}
+	if w := serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /applications status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestDisabledEndpointsNone(t *testing.T) {
+	server, logs := setupDisabledEndpointsServer(t)
+
+	if strings.Contains(logs, "Disabled endpoints") {
+		t.Errorf("logs = %q, want nothing about disabled endpoints", logs)
+	}
+	w := serveMethod(server, http.MethodGet, "/info", nil)
+	if !strings.Contains(w.Body.String(), `"disabledEndpoints":[]`) {
+		t.Errorf("GET /info = %s, want an empty disabledEndpoints list", w.Body.String())
+	}
+}
@@ -15,108 +15,230 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/applications": {
+        "/admin/cache/snapshot": {
             "get": {
-                "description": "Get applications from ArgoCD with filtering applied based on ignored projects configuration",
-                "consumes": [
+                "description": "Export the cached project and application lists with the times they were fetched, even expired ones, as one JSON document for another replica to import through CACHE_SNAPSHOT_URL or CACHE_SNAPSHOT_FILE. Never calls ArgoCD. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export the cache snapshot",
+                "responses": {
+                    "200": {
+                        "description": "Cache snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/types.CacheSnapshot"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/circuits": {
+            "get": {
+                "description": "List the applications whose reads fail fast with 503 after APP_CIRCUIT_THRESHOLD consecutive failures within APP_CIRCUIT_WINDOW, and until when, with the breaker settings. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "applications"
+                    "admin"
                 ],
-                "summary": "Get filtered applications",
+                "summary": "List open application circuit breakers",
                 "responses": {
                     "200": {
-                        "description": "Filtered applications list"
+                        "description": "Open circuits",
+                        "schema": {
+                            "$ref": "#/definitions/types.ApplicationCircuitsResponse"
+                        }
                     },
-                    "502": {
-                        "description": "Failed to retrieve applications from ArgoCD"
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/applications/{name}": {
+        "/admin/client-stats": {
             "get": {
-                "description": "Get a specific application by name from ArgoCD",
-                "consumes": [
+                "description": "Get rolling request, byte and error counts and the busiest routes of every client seen recently, busiest first. Clients are identified by a salted hash of their identity (the client IP unless an authentication layer names the client), matching the client label of the argocd_proxy_client_* metrics; raw identities are never reported. Counters are halved every CLIENT_STATS_HALF_LIFE. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set and CLIENT_STATS is enabled",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get per-client request statistics",
+                "responses": {
+                    "200": {
+                        "description": "Per-client statistics",
+                        "schema": {
+                            "$ref": "#/definitions/clientstats.Report"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/events": {
+            "get": {
+                "description": "Get the most recent operational events kept in memory, newest first: token refreshes and failures, cache invalidations, runtime configuration changes, circuit breaker trips, upstream compatibility changes and maintenance mode changes. The last EVENT_LOG_SIZE events are kept; sequence numbers let clients notice events that were dropped. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "applications"
+                    "admin"
                 ],
-                "summary": "Get specific application",
+                "summary": "Get recent operational events",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Application name",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Maximum number of events to return (default 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Application details"
+                        "description": "Recent events",
+                        "schema": {
+                            "$ref": "#/definitions/events.Report"
+                        }
                     },
                     "400": {
-                        "description": "Application name is required"
-                    },
-                    "404": {
-                        "description": "Application not found"
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     },
-                    "502": {
-                        "description": "Failed to retrieve application from ArgoCD"
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/groups/{group}/applications": {
+        "/admin/explain": {
             "get": {
-                "description": "Get all applications from a configured project group",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Trace the ignore rules applied to a project and, optionally, one of its applications: the groups listing the project, the ignore pattern it matches, the group ignore patterns hiding the application from group listings, and the final visible or hidden verdict. Only the configured rules are applied; whether the project or application exists in ArgoCD is not checked. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "applications"
+                    "admin"
                 ],
-                "summary": "Get applications by project group",
+                "summary": "Explain why a project or application is visible or hidden",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Project group name",
-                        "name": "group",
-                        "in": "path",
+                        "description": "Project name",
+                        "name": "project",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Application name, to check the group ignore patterns against it",
+                        "name": "application",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Applications from the specified group"
+                        "description": "Decision trace",
+                        "schema": {
+                            "$ref": "#/definitions/config.FilterExplanation"
+                        }
                     },
                     "400": {
-                        "description": "Invalid group name"
-                    },
-                    "404": {
-                        "description": "Project group not found"
+                        "description": "Project name is required",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     },
-                    "502": {
-                        "description": "Failed to retrieve applications from ArgoCD"
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/health": {
+        "/admin/features": {
             "get": {
-                "description": "Get the health status of the ArgoCD proxy server",
+                "description": "Get every registered feature flag with its description, its default before FEATURES (from the flag's legacy setting where it has one), its state on routes without an override and its per-route overrides. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get feature flags",
+                "responses": {
+                    "200": {
+                        "description": "Feature flags and their states",
+                        "schema": {
+                            "$ref": "#/definitions/features.Report"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/filters/simulate": {
+            "post": {
+                "description": "Preview the effect of candidate ignore patterns and project groups without applying them: the projects the candidate would newly hide or reveal, the currently visible applications it would hide, and the counts of unaffected ones. Omitted fields keep the configuration in effect. Projects and applications are read from the cached lists; applications of currently hidden projects are not known to the proxy, so newly visible applications are not reported. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "consumes": [
                     "application/json"
                 ],
@@ -124,22 +246,91 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "Simulate a filter configuration change",
+                "parameters": [
+                    {
+                        "description": "Candidate filter configuration",
+                        "name": "candidate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/config.FilterCandidate"
+                        }
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "Server is healthy"
+                        "description": "Visibility changes",
+                        "schema": {
+                            "$ref": "#/definitions/config.FilterSimulation"
+                        }
                     },
-                    "503": {
-                        "description": "Server is degraded"
+                    "400": {
+                        "description": "Invalid candidate; fields names every invalid pattern",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects or applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/project-groups": {
+        "/admin/ignored-projects": {
             "get": {
-                "description": "Get configured project groups and ungrouped projects from ArgoCD",
+                "description": "Get the ignore patterns in effect with the cached projects each one matches, so dead patterns can be spotted. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get ignored project patterns",
+                "responses": {
+                    "200": {
+                        "description": "Ignore patterns and their matches",
+                        "schema": {
+                            "$ref": "#/definitions/config.IgnoredProjectsReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Replace the ignore patterns in effect with a JSON array of patterns. The patterns are validated as a whole and take effect immediately; cached lists are dropped so that they are filtered with the new patterns. Changes are not persisted: IGNORED_PROJECTS applies again after a restart. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "consumes": [
                     "application/json"
                 ],
@@ -147,22 +338,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "admin"
+                ],
+                "summary": "Replace ignored project patterns",
+                "parameters": [
+                    {
+                        "description": "Replacement ignore patterns",
+                        "name": "patterns",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 ],
-                "summary": "Get project groups",
                 "responses": {
                     "200": {
-                        "description": "Project groups response"
+                        "description": "Ignore patterns now in effect and their matches",
+                        "schema": {
+                            "$ref": "#/definitions/config.IgnoredProjectsReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pattern list",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     },
                     "502": {
-                        "description": "Failed to retrieve projects from ArgoCD"
+                        "description": "Patterns replaced, but projects could not be retrieved from ArgoCD to count matches",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/projects": {
+        "/admin/maintenance": {
             "get": {
-                "description": "Get projects from ArgoCD with filtering applied based on ignored projects configuration",
+                "description": "Report whether the proxy is in maintenance mode, and until when. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the maintenance mode",
+                "responses": {
+                    "200": {
+                        "description": "Maintenance mode",
+                        "schema": {
+                            "$ref": "#/definitions/types.MaintenanceStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Put the proxy into maintenance mode until the given time, e.g. while ArgoCD is upgraded. Data endpoints answer 503 with the message and a Retry-After header counting down to until; with serveStale they keep answering from cached data, even expired, without calling ArgoCD, and answer 503 when the data is not cached. /health reports status maintenance with 200. Posting again replaces the window. The mode ends by itself at until, through DELETE, or on restart, as it is kept in memory only. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,22 +427,125 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "admin"
+                ],
+                "summary": "Enter maintenance mode",
+                "parameters": [
+                    {
+                        "description": "Maintenance window",
+                        "name": "window",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.MaintenanceRequest"
+                        }
+                    }
                 ],
-                "summary": "Get filtered projects",
                 "responses": {
                     "200": {
-                        "description": "Filtered projects list"
+                        "description": "Maintenance mode entered",
+                        "schema": {
+                            "$ref": "#/definitions/types.MaintenanceStatus"
+                        }
                     },
-                    "502": {
-                        "description": "Failed to retrieve projects from ArgoCD"
+                    "400": {
+                        "description": "Invalid maintenance window",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "End maintenance mode before its until time. Leaving when not in maintenance is not an error. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Leave maintenance mode",
+                "responses": {
+                    "200": {
+                        "description": "Maintenance mode left",
+                        "schema": {
+                            "$ref": "#/definitions/types.MaintenanceStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/projects/{project}/applications": {
+        "/admin/support-bundle": {
+            "get": {
+                "description": "Get the information support requests usually need in one document: version and build, the configuration with secrets masked, health check history, token status, cache states, recent operational events and the last failed ArgoCD call. Every section is present even when empty. The bundle is built from state the proxy already holds and never calls ArgoCD. With format=zip the sections are returned as pretty-printed JSON files in a zip archive. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a support bundle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "json (default) or zip",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Support bundle",
+                        "schema": {
+                            "$ref": "#/definitions/types.SupportBundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/applications": {
             "get": {
-                "description": "Get all applications from a specific ArgoCD project",
+                "description": "Get applications from ArgoCD with filtering applied based on ignored projects configuration. The list carries a snapshotToken; passing it back as since returns only the applications added or changed after that snapshot and the names of those removed, or the whole list with full=true when the token is unknown or older than SNAPSHOT_HISTORY_DEPTH snapshots",
                 "consumes": [
                     "application/json"
                 ],
@@ -195,28 +555,4009 @@ const docTemplate = `{
                 "tags": [
                     "applications"
                 ],
-                "summary": "Get applications by project",
+                "summary": "Get filtered applications",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Project name",
-                        "name": "project",
-                        "in": "path",
-                        "required": true
+                        "description": "Only include applications whose name starts with this prefix (case-insensitive)",
+                        "name": "namePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by name or created; applications without a creation timestamp sort last",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hold the request until the application set differs from this X-Resource-Version (or upstream resourceVersion)",
+                        "name": "watchAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "snapshotToken of a previous response; only the changes since that snapshot are returned (not with watchAfter)",
+                        "name": "since",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Applications from the specified project"
+                        "description": "Filtered applications list"
+                    },
+                    "304": {
+                        "description": "Application set unchanged before the watch deadline"
                     },
                     "400": {
-                        "description": "Invalid project name"
+                        "description": "Invalid filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     },
                     "502": {
-                        "description": "Failed to retrieve applications from ArgoCD"
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
                     }
                 }
             }
+        },
+        "/applications/auto-sync": {
+            "get": {
+                "description": "Get the filtered applications ArgoCD syncs automatically with their prune, selfHeal and allowEmpty settings and sync options, sorted by name, together with how many of them prune or self-heal and how many filtered applications only sync manually",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get auto-sync applications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose name starts with this prefix (case-insensitive)",
+                        "name": "namePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Auto-sync applications",
+                        "schema": {
+                            "$ref": "#/definitions/types.AutoSyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/names": {
+            "get": {
+                "description": "Get the sorted names of the filtered applications, without the application details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application names",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose name starts with this prefix (case-insensitive)",
+                        "name": "namePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sorted application names",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/recent": {
+            "get": {
+                "description": "Get the filtered applications created within the given window, newest first, each with its age",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get recently created applications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Creation window such as 24h or 7d (default 72h, at most 365d)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose name starts with this prefix (case-insensitive)",
+                        "name": "namePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recently created applications"
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}": {
+            "get": {
+                "description": "Get a specific application by name from ArgoCD. With raw=true the upstream JSON is returned verbatim, including fields the proxy does not model, and without the proxy's derived fields such as ingressUrls",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get specific application",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the upstream application object verbatim (default false)",
+                        "name": "raw",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Application details"
+                    },
+                    "400": {
+                        "description": "Application name is required or invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve application from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}/deploy-stats": {
+            "get": {
+                "description": "Get how often an application was deployed within the window, from its ArgoCD revision history: deployment count, deployments per day, mean interval and last deployment. truncated is set when ArgoCD's retained history may not cover the whole window.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application deployment statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time window such as 7d or 72h (default 30d, at most 365d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deployment statistics",
+                        "schema": {
+                            "$ref": "#/definitions/types.ApplicationDeployStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve application from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}/diff": {
+            "get": {
+                "description": "Get which managed resources a sync would add, modify or prune, without the manifests themselves",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application diff summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Application diff summary",
+                        "schema": {
+                            "$ref": "#/definitions/types.ApplicationDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Application name is required",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve managed resources from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}/logs": {
+            "get": {
+                "description": "Get the container logs of an application's pods from ArgoCD as plain text, one line per log entry. The logs are relayed while ArgoCD sends them rather than buffered. Following the logs is not supported, so follow must be false when set. tailLines defaults to and is capped by LOGS_MAX_TAIL_LINES. Applications in filtered projects are reported as not found. Only available when LOGS_ENDPOINT_ENABLED is true",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Container to read the logs of",
+                        "name": "container",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pod to read the logs of",
+                        "name": "podName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of most recent lines to read per container",
+                        "name": "tailLines",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Must be false; following logs is not supported",
+                        "name": "follow",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Log lines",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve logs from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}/notifications": {
+            "get": {
+                "description": "Get the ArgoCD notifications subscriptions of an application, parsed from its notifications.argoproj.io/subscribe.\u003ctrigger\u003e.\u003cservice\u003e and subscribe.\u003cservice\u003e annotations with one entry per channel. hasSubscriptions tells whether alerting is wired up; annotations that cannot be parsed are listed under malformed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application notification subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Application notification subscriptions",
+                        "schema": {
+                            "$ref": "#/definitions/types.ApplicationNotifications"
+                        }
+                    },
+                    "400": {
+                        "description": "Application name is required",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve application from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{name}/sync-windows": {
+            "get": {
+                "description": "Get the sync windows assigned to an application, the currently active allow and deny windows, and whether it can sync now",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application sync windows",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Application sync windows"
+                    },
+                    "400": {
+                        "description": "Application name is required",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Application not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve sync windows from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bootstrap": {
+            "get": {
+                "description": "Get the project groups, the filtered project names, the application list and a health snapshot in one response, so a dashboard can start with a single request. Projects and applications are each read once, from the cache when possible; the health snapshot is derived from those reads and makes no further ArgoCD call. Each section carries the time it was generated. With partial=true a failure to read projects or applications still answers 200 with the other sections and a warnings array naming the missing ones",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get dashboard bootstrap data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application representation: summary (default) or full",
+                        "name": "view",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated summary fields to include besides name: project, cluster, namespace, health, sync, revision, healthSince, syncSince, autoSync (view=summary only). healthSince and syncSince need the background poller",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Answer with the available data and warnings when part of it cannot be read from ArgoCD (default false)",
+                        "name": "partial",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/json;profile=summary-v2 groups the destination and status fields of the application summaries; see /schemas",
+                        "name": "Accept",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Bootstrap data",
+                        "schema": {
+                            "$ref": "#/definitions/types.BootstrapResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "The Accept header names only unknown schema profiles",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve data from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{group}/applications": {
+            "get": {
+                "description": "Get all applications from a configured project group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get applications by project group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by name or created; applications without a creation timestamp sort last",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Applications from the specified group"
+                    },
+                    "400": {
+                        "description": "Invalid group name",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project group not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{group}/deploy-stats": {
+            "get": {
+                "description": "Get how often the applications of a project group were deployed within the window, per application and combined across the group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get group deployment statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time window such as 7d or 72h (default 30d, at most 365d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deployment statistics for the group",
+                        "schema": {
+                            "$ref": "#/definitions/types.GroupDeployStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid group name or query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project group not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{group}/drift": {
+            "get": {
+                "description": "Get the out-of-sync applications of a project group with their revisions and last operation time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get group drift report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Drift report for the specified group"
+                    },
+                    "400": {
+                        "description": "Invalid group name",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project group not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the health status of the ArgoCD proxy server. During maintenance mode status is maintenance, with the window in maintenance, and ArgoCD is not checked. A failed ArgoCD check only fails the probe after HEALTH_FAILURE_THRESHOLD consecutive failures, and a failing probe passes again after HEALTH_RECOVERY_THRESHOLD consecutive passed checks; healthState reports the debounced state, the consecutive counts and the recent transitions. With DEEP_HEALTH enabled, argocdComponents reports the API server, repo server and application controller; a degraded component sets status to degraded without failing the probe. Repeated background token refresh failures set tokenStatus.failing and fail the probe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Include last successful upstream fetch times and the latest compatibility probe",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Report the result of this ArgoCD check alone instead of the debounced state, for debugging",
+                        "name": "raw",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Server is healthy"
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is degraded"
+                    }
+                }
+            }
+        },
+        "/health/history": {
+            "get": {
+                "description": "Get the most recent health checks, oldest first, with the uptime percentage over the window and the last status transition time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check history",
+                "responses": {
+                    "200": {
+                        "description": "Recent health checks",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthHistoryResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/info": {
+            "get": {
+                "description": "Get static information about this proxy instance: version, build, Go version, start time and uptime, the ArgoCD host, configuration counts, enabled features, disabled endpoints and deprecated configuration in use. No ArgoCD requests are made.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Runtime information",
+                "responses": {
+                    "200": {
+                        "description": "Runtime information",
+                        "schema": {
+                            "$ref": "#/definitions/types.InfoResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/inventory": {
+            "get": {
+                "description": "Get the images, external URLs, source repository, revision and health of every visible application, by project, from one read of the application list. group limits the inventory to the applications a project group lists and project to one project. Clients sending \"Accept: text/csv\" get one row per application instead, streamed as it is written, with the images and URLs of an application separated by spaces",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get the application inventory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include the applications of this project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include the applications listed by this project group",
+                        "name": "group",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Application inventory",
+                        "schema": {
+                            "$ref": "#/definitions/types.InventoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project group not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/openapi.json": {
+            "get": {
+                "description": "Get the OpenAPI (Swagger 2.0) document of this API as JSON, with the host and base path the proxy is reached at. Only served when SWAGGER_ENABLED is true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get the OpenAPI document",
+                "responses": {
+                    "200": {
+                        "description": "OpenAPI document"
+                    }
+                }
+            }
+        },
+        "/openapi.yaml": {
+            "get": {
+                "description": "Get the OpenAPI (Swagger 2.0) document of this API as YAML, with the host and base path the proxy is reached at. Only served when SWAGGER_ENABLED is true",
+                "produces": [
+                    "application/yaml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get the OpenAPI document as YAML",
+                "responses": {
+                    "200": {
+                        "description": "OpenAPI document"
+                    }
+                }
+            }
+        },
+        "/owners": {
+            "get": {
+                "description": "Get the distinct owners of the filtered applications, read from the first OWNER_ANNOTATIONS annotation, with the number of applications each owns. Only registered when OWNER_ANNOTATIONS is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get application owners",
+                "responses": {
+                    "200": {
+                        "description": "Application owners",
+                        "schema": {
+                            "$ref": "#/definitions/types.OwnersResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/permissions": {
+            "get": {
+                "description": "Get what the caller is allowed to see: the project groups and projects visible to its API key, after the ignore rules, and the endpoints disabled for it. Keys restricted by API_KEY_GROUPS only see their groups and the projects they list; callers without a key, or without API keys set, see every project not hidden by the ignore rules. With ANONYMOUS_READ_ONLY this endpoint is served without a key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get caller permissions",
+                "responses": {
+                    "200": {
+                        "description": "Caller permissions",
+                        "schema": {
+                            "$ref": "#/definitions/types.PermissionsResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project-groups": {
+            "get": {
+                "description": "Get configured project groups and ungrouped projects from ArgoCD. Groups can be narrowed by name and paged with offset and limit; meta.total counts the groups matching the name before paging, and every page carries all ungrouped projects. The grouping is resolved once per change of the rules or the project names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include groups whose name matches this pattern, with * wildcards as in IGNORED_PROJECTS (case-insensitive)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of matching groups to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of groups to return (default 0 = all)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Project groups response",
+                        "schema": {
+                            "$ref": "#/definitions/config.ProjectGroupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project-groups/export": {
+            "get": {
+                "description": "Get the fully-resolved grouping as a canonical JSON document with a content hash: groups with sorted, de-duplicated projects, ungrouped projects, ignored patterns and the projects they hide. The document has no timestamps, so it can be committed to git and diffed; the hash matches the X-Project-Groups-Hash header of /project-groups",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Export resolved project groups",
+                "responses": {
+                    "200": {
+                        "description": "Resolved project groups",
+                        "schema": {
+                            "$ref": "#/definitions/config.ProjectGroupsExport"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects": {
+            "get": {
+                "description": "Get projects from ArgoCD with filtering applied based on ignored projects configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get filtered projects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include projects whose name starts with this prefix (case-insensitive)",
+                        "name": "namePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered projects list"
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project}": {
+            "get": {
+                "description": "Get a project by name, including its RBAC roles and cluster resource whitelist. Projects hidden by the ignore rules are reported as not found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get specific project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project name",
+                        "name": "project",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Project details",
+                        "schema": {
+                            "$ref": "#/definitions/types.ArgocdProject"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project}/applications": {
+            "get": {
+                "description": "Get all applications from a specific ArgoCD project. Unknown projects are reported as not found, with a suggestion when the name is close to a visible project; projects hidden by the ignore rules are reported as not found without one. With strict=false the project is not checked and an unknown project yields an empty list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get applications by project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project name",
+                        "name": "project",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Answer 404 for unknown projects (default true)",
+                        "name": "strict",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications running an image matching this reference",
+                        "name": "image",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image match mode: substring (default) or exact",
+                        "name": "imageMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated health statuses to include (e.g. Degraded,Progressing)",
+                        "name": "health",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sync statuses to include (e.g. OutOfSync)",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include applications pending deletion (default true)",
+                        "name": "includeDeleting",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications deploying to this cluster, by name or server URL",
+                        "name": "destCluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include applications whose annotations match this selector, in the labelSelector syntax",
+                        "name": "annotationSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)",
+                        "name": "autoSync",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by name or created; applications without a creation timestamp sort last",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token",
+                        "name": "maxItems",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Applications from the specified project"
+                    },
+                    "400": {
+                        "description": "Invalid project name",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project}/destinations": {
+            "get": {
+                "description": "Get the deduplicated destinations declared by a project merged with those its applications deploy to; destinations not permitted by the project spec are flagged as undeclared. With partial=true a failure to read either the project or its applications still answers 200 with the portion that could be read and a warnings array naming the missing one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project destinations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project name",
+                        "name": "project",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Answer with the available data and warnings when part of it cannot be read from ArgoCD (default false)",
+                        "name": "partial",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reconciled project destinations"
+                    },
+                    "400": {
+                        "description": "Invalid project name",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve data from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project}/roles": {
+            "get": {
+                "description": "Get the RBAC roles a project defines, with their descriptions, SSO groups and policies, for review without ArgoCD access. With flattenPolicies=true each policy line is parsed into subject, resource, action, object and effect; lines that are not six-field \"p, ...\" policies are returned with malformed set. Projects hidden by the ignore rules are reported as not found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get project roles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project name",
+                        "name": "project",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Parse policy lines into structured rules (default false)",
+                        "name": "flattenPolicies",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Project roles",
+                        "schema": {
+                            "$ref": "#/definitions/types.ProjectRolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve projects from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the server accepts traffic; returns 503 as soon as shutdown begins, and until ArgoCD issues the first token unless STARTUP_REQUIRE_AUTH made startup wait for it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Server is ready"
+                    },
+                    "503": {
+                        "description": "Server is shutting down or awaiting its first ArgoCD token",
+                        "schema": {
+                            "$ref": "#/definitions/types.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schemas": {
+            "get": {
+                "description": "List the routes serving several response shapes, with the profiles each one serves. A profile is selected with the profile parameter of the Accept header, e.g. \"Accept: application/json;profile=summary-v2\"; requests naming none get the default profile. Requests naming only unknown profiles get 406 with the supported ones in profiles",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "List schema profiles",
+                "responses": {
+                    "200": {
+                        "description": "Schema profiles by route",
+                        "schema": {
+                            "$ref": "#/definitions/types.SchemasResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topology/repositories": {
+            "get": {
+                "description": "Group the filtered applications by the repositories they deploy from, including every source of multi-source applications. Repository URLs are normalized to host/path, so https, ssh and scp-like URLs of one repository are grouped together. Clients sending \"Accept: text/csv\" get one row per application source instead",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "applications"
+                ],
+                "summary": "Get applications by source repository",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only include repositories used by at least this many applications (default 1); 2 lists shared repositories",
+                        "name": "minApps",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Applications by repository",
+                        "schema": {
+                            "$ref": "#/definitions/types.RepositoryTopologyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to retrieve applications from ArgoCD",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "clientstats.ClientStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "client": {
+                    "description": "Client is the hashed client identifier, matching the client metric label",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "integer"
+                },
+                "lastSeen": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "topRoutes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/clientstats.RouteStats"
+                    }
+                }
+            }
+        },
+        "clientstats.Report": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/clientstats.ClientStats"
+                    }
+                },
+                "halfLife": {
+                    "description": "HalfLife is how often the counters are halved, as a Go duration",
+                    "type": "string"
+                },
+                "maxClients": {
+                    "type": "integer"
+                }
+            }
+        },
+        "clientstats.RouteStats": {
+            "type": "object",
+            "properties": {
+                "requests": {
+                    "type": "integer"
+                },
+                "route": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.Deprecation": {
+            "type": "object",
+            "properties": {
+                "ignored": {
+                    "description": "Ignored is true when the replacement is set as well and is used instead",
+                    "type": "boolean"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "replacement": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.ExplainedGroup": {
+            "type": "object",
+            "properties": {
+                "applicationIgnoredBy": {
+                    "description": "ApplicationIgnoredBy is the group's own ignore pattern hiding the application from\nthe group's listing, if any",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.FilterCandidate": {
+            "type": "object",
+            "properties": {
+                "ignoredProjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "projectGroups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.ProjectGroup"
+                    }
+                }
+            }
+        },
+        "config.FilterExplanation": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "type": "string"
+                },
+                "groups": {
+                    "description": "Groups are the project groups listing the project; grouped projects are never\nhidden by the ignore patterns",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.ExplainedGroup"
+                    }
+                },
+                "matchedPattern": {
+                    "description": "MatchedPattern is the first ignore pattern in effect matching the project, reported\neven when a group keeps the project visible",
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason explains the verdict",
+                    "type": "string"
+                },
+                "visible": {
+                    "description": "Visible is the final verdict: whether the project and its applications are served",
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.FilterSimulation": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "description": "Applications only covers the currently visible applications, since the proxy does\nnot keep the applications of hidden projects; NewlyVisible and UnchangedHidden are\nalways empty",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/config.VisibilityDiff"
+                        }
+                    ]
+                },
+                "projects": {
+                    "$ref": "#/definitions/config.VisibilityDiff"
+                }
+            }
+        },
+        "config.IgnoredPatternMatch": {
+            "type": "object",
+            "properties": {
+                "matches": {
+                    "type": "integer"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "projects": {
+                    "description": "Projects are the matched project names, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "config.IgnoredProjectsReport": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "overridden": {
+                    "description": "Overridden is true when the patterns were replaced at runtime rather than loaded\nfrom IGNORED_PROJECTS",
+                    "type": "boolean"
+                },
+                "patterns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.IgnoredPatternMatch"
+                    }
+                },
+                "persisted": {
+                    "description": "Persisted is always false: runtime replacements are lost on restart",
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.ProjectGroup": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "ignoredProjects": {
+                    "description": "IgnoredProjects are patterns hiding applications from this group's endpoints only,\nmatched against the application name and its project like IGNORED_PROJECTS",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "description": "Order positions the group in responses; groups with equal order sort by name",
+                    "type": "integer"
+                },
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "config.ProjectGroupsExport": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.ProjectGroup"
+                    }
+                },
+                "hash": {
+                    "description": "Hash is \"sha256:\" followed by the hex digest of the document in canonical JSON,\nwithout this field",
+                    "type": "string"
+                },
+                "ignoredPatterns": {
+                    "description": "IgnoredPatterns are the configured IGNORED_PROJECTS patterns",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ignoredProjects": {
+                    "description": "IgnoredProjects are the discovered projects hidden by IgnoredPatterns",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ungroupedProjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "config.ProjectGroupsMeta": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Limit is the largest number of groups returned; 0 means unlimited",
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Total is the number of groups matching ?name=, before ?offset= and ?limit= apply",
+                    "type": "integer"
+                }
+            }
+        },
+        "config.ProjectGroupsResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.ProjectGroup"
+                    }
+                },
+                "meta": {
+                    "description": "Meta describes the page of groups returned by /project-groups",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/config.ProjectGroupsMeta"
+                        }
+                    ]
+                },
+                "ungroupedProjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "config.VisibilityDiff": {
+            "type": "object",
+            "properties": {
+                "newlyHidden": {
+                    "description": "NewlyHidden are visible now and hidden by the candidate, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "newlyVisible": {
+                    "description": "NewlyVisible are hidden now and visible with the candidate, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unchangedHidden": {
+                    "type": "integer"
+                },
+                "unchangedVisible": {
+                    "description": "UnchangedVisible and UnchangedHidden count the items the candidate does not affect",
+                    "type": "integer"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "attrs": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "description": "Sequence numbers events from 1 in recording order, so gaps show dropped events",
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/events.Type"
+                }
+            }
+        },
+        "events.Report": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "description": "Capacity is the number of events the log keeps",
+                    "type": "integer"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/events.Event"
+                    }
+                },
+                "recorded": {
+                    "description": "Recorded is the number of events recorded since startup, including those the ring\nno longer holds",
+                    "type": "integer"
+                }
+            }
+        },
+        "events.Type": {
+            "type": "string",
+            "enum": [
+                "token_refreshed",
+                "token_refresh_failed",
+                "cache_invalidated",
+                "config_reloaded",
+                "circuit_opened",
+                "upstream_incompatible",
+                "upstream_compatible",
+                "upstream_failed_over",
+                "maintenance_entered",
+                "maintenance_left"
+            ],
+            "x-enum-varnames": [
+                "TokenRefreshed",
+                "TokenRefreshFailed",
+                "CacheInvalidated",
+                "ConfigReloaded",
+                "CircuitOpened",
+                "UpstreamIncompatible",
+                "UpstreamCompatible",
+                "UpstreamFailedOver",
+                "MaintenanceEntered",
+                "MaintenanceLeft"
+            ]
+        },
+        "features.Report": {
+            "type": "object",
+            "properties": {
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/features.Status"
+                    }
+                }
+            }
+        },
+        "features.Status": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is the state before FEATURES, from the legacy setting or the registry",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Enabled is the state on routes without an override",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "routes": {
+                    "description": "Routes holds the per-route overrides, keyed by route template",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                }
+            }
+        },
+        "types.ApplicationAge": {
+            "type": "object",
+            "properties": {
+                "human": {
+                    "description": "Human is the age formatted like kubectl's AGE column (e.g. 45s, 5h12m, 3d4h, 400d)",
+                    "type": "string"
+                },
+                "seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ApplicationCircuit": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "type": "string"
+                },
+                "openUntil": {
+                    "description": "OpenUntil is when reads of the application are let through again",
+                    "type": "string"
+                },
+                "openedAt": {
+                    "type": "string"
+                },
+                "retryAfterSeconds": {
+                    "description": "RetryAfterSeconds is the whole seconds until OpenUntil, at least 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ApplicationCircuitsResponse": {
+            "type": "object",
+            "properties": {
+                "cooldown": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Enabled is false when APP_CIRCUIT_THRESHOLD is 0",
+                    "type": "boolean"
+                },
+                "open": {
+                    "description": "Open lists the open circuits, sorted by application",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ApplicationCircuit"
+                    }
+                },
+                "threshold": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ApplicationDeployStats": {
+            "type": "object",
+            "properties": {
+                "deployments": {
+                    "type": "integer"
+                },
+                "deploymentsPerDay": {
+                    "type": "number"
+                },
+                "lastDeployedAt": {
+                    "type": "string"
+                },
+                "meanIntervalSeconds": {
+                    "description": "MeanIntervalSeconds is the mean time between consecutive deployments; null with fewer than two",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "truncated": {
+                    "description": "Truncated is set when ArgoCD's retained history may not reach back to the window start,\nso the counts are a lower bound",
+                    "type": "boolean"
+                },
+                "windowSeconds": {
+                    "type": "integer"
+                },
+                "windowStart": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ApplicationDiff": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "integer"
+                },
+                "application": {
+                    "type": "string"
+                },
+                "hasChanges": {
+                    "type": "boolean"
+                },
+                "modified": {
+                    "type": "integer"
+                },
+                "pruned": {
+                    "type": "integer"
+                },
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ApplicationDiffEntry"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ApplicationDiffEntry": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "type": "string",
+                    "enum": [
+                        "added",
+                        "modified",
+                        "pruned",
+                        "unchanged"
+                    ]
+                },
+                "group": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "modified": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ApplicationNotifications": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "type": "string"
+                },
+                "hasSubscriptions": {
+                    "type": "boolean"
+                },
+                "malformed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.MalformedNotificationAnnotation"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.NotificationSubscription"
+                    }
+                }
+            }
+        },
+        "types.ApplicationRef": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ApplicationSummary": {
+            "type": "object",
+            "properties": {
+                "autoSync": {
+                    "description": "AutoSync is whether ArgoCD syncs the application automatically; a pointer so that\nmanual applications report false",
+                    "type": "boolean"
+                },
+                "cluster": {
+                    "description": "Cluster is the destination cluster name, falling back to its server URL",
+                    "type": "string"
+                },
+                "health": {
+                    "type": "string"
+                },
+                "healthSince": {
+                    "description": "HealthSince and SyncSince are when the background poller first saw the current\nhealth and sync status; omitted while the poller has not seen it",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "revision": {
+                    "type": "string"
+                },
+                "sync": {
+                    "type": "string"
+                },
+                "syncSince": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplication": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "description": "Age is the time since creation, computed by the proxy; null without a creation timestamp",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ApplicationAge"
+                        }
+                    ]
+                },
+                "apiVersion": {
+                    "type": "string"
+                },
+                "deleting": {
+                    "description": "Deleting is set by the proxy while the application has a deletion timestamp",
+                    "type": "boolean"
+                },
+                "ingressUrls": {
+                    "description": "Enhanced with ingress URLs",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "matchedImages": {
+                    "description": "MatchedImages lists the images that satisfied an ?image= filter, when one was supplied",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/types.ArgocdApplicationMetadata"
+                },
+                "ownership": {
+                    "description": "Ownership maps each OWNER_ANNOTATIONS key the application is annotated with to its\nvalue; empty when it has none, and omitted when OWNER_ANNOTATIONS is not set",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "spec": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSpec"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ArgocdApplicationStatus"
+                }
+            }
+        },
+        "types.ArgocdApplicationComparedTo": {
+            "type": "object",
+            "properties": {
+                "destination": {
+                    "$ref": "#/definitions/types.ArgocdApplicationDestination"
+                },
+                "source": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSource"
+                }
+            }
+        },
+        "types.ArgocdApplicationDestination": {
+            "type": "object",
+            "properties": {
+                "clusterName": {
+                    "description": "ClusterName is the display name of the destination cluster, computed by the proxy\nfrom ArgoCD's clusters; it falls back to the server URL when the name is unknown",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "server": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationHealth": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationList": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "type": "string"
+                },
+                "full": {
+                    "description": "Full is set on a ?since= response when the token was unknown or too old, so the\nitems are the whole list rather than the changes",
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdApplication"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "meta": {
+                    "description": "Meta is set by the proxy when the list was truncated",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ListMeta"
+                        }
+                    ]
+                },
+                "metadata": {
+                    "type": "object",
+                    "properties": {
+                        "resourceVersion": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "removed": {
+                    "description": "Removed lists the applications removed since the ?since= snapshot",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ApplicationRef"
+                    }
+                },
+                "snapshotToken": {
+                    "description": "SnapshotToken names the application set this list was read from; pass it as\n?since= to receive only the changes made after it",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationMetadata": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "creationTimestamp": {
+                    "type": "string"
+                },
+                "deletionTimestamp": {
+                    "description": "DeletionTimestamp is set once deletion was requested, while finalizers are pending",
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationSource": {
+            "type": "object",
+            "properties": {
+                "helm": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSourceHelm"
+                },
+                "kustomize": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSourceKustomize"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "repoURL": {
+                    "type": "string"
+                },
+                "targetRevision": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationSourceHelm": {
+            "type": "object",
+            "properties": {
+                "valueFiles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ArgocdApplicationSourceKustomize": {
+            "type": "object",
+            "properties": {
+                "namePrefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdApplicationSpec": {
+            "type": "object",
+            "properties": {
+                "destination": {
+                    "$ref": "#/definitions/types.ArgocdApplicationDestination"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "revisionHistoryLimit": {
+                    "description": "RevisionHistoryLimit is the number of history entries ArgoCD keeps; nil means ArgoCD's default of 10",
+                    "type": "integer"
+                },
+                "source": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSource"
+                },
+                "sources": {
+                    "description": "Sources are the sources of a multi-source application, which has no Source",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdApplicationSource"
+                    }
+                },
+                "syncPolicy": {
+                    "$ref": "#/definitions/types.ArgocdSyncPolicy"
+                }
+            }
+        },
+        "types.ArgocdApplicationStatus": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {}
+                },
+                "health": {
+                    "$ref": "#/definitions/types.ArgocdApplicationHealth"
+                },
+                "history": {
+                    "description": "History lists the application's most recent deployments, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdRevisionHistory"
+                    }
+                },
+                "operationState": {
+                    "$ref": "#/definitions/types.ArgocdOperationState"
+                },
+                "reconciledAt": {
+                    "type": "string"
+                },
+                "resources": {
+                    "type": "array",
+                    "items": {}
+                },
+                "summary": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSummary"
+                },
+                "sync": {
+                    "$ref": "#/definitions/types.ArgocdApplicationSync"
+                }
+            }
+        },
+        "types.ArgocdApplicationSummary": {
+            "type": "object",
+            "properties": {
+                "externalURLs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ArgocdApplicationSync": {
+            "type": "object",
+            "properties": {
+                "comparedTo": {
+                    "$ref": "#/definitions/types.ArgocdApplicationComparedTo"
+                },
+                "revision": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdGroupKind": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdOperationState": {
+            "type": "object",
+            "properties": {
+                "finishedAt": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "syncResult": {
+                    "$ref": "#/definitions/types.ArgocdSyncOperationResult"
+                }
+            }
+        },
+        "types.ArgocdProject": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/types.ArgocdProjectMetadata"
+                },
+                "spec": {
+                    "$ref": "#/definitions/types.ArgocdProjectSpec"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ArgocdProjectStatus"
+                }
+            }
+        },
+        "types.ArgocdProjectDestination": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "server": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdProjectList": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdProject"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "properties": {
+                        "resourceVersion": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "types.ArgocdProjectMetadata": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "creationTimestamp": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdProjectRole": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "groups": {
+                    "description": "Groups are the SSO groups bound to the role",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "policies": {
+                    "description": "Policies are Casbin policy lines such as\n\"p, proj:my-project:read-only, applications, get, my-project/*, allow\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ArgocdProjectSpec": {
+            "type": "object",
+            "properties": {
+                "clusterResourceWhitelist": {
+                    "description": "ClusterResourceWhitelist lists the cluster-scoped resources the project may deploy",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdGroupKind"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdProjectDestination"
+                    }
+                },
+                "roles": {
+                    "description": "Roles are the project's RBAC roles",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdProjectRole"
+                    }
+                },
+                "sourceRepos": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ArgocdProjectStatus": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdRevisionHistory": {
+            "type": "object",
+            "properties": {
+                "deployStartedAt": {
+                    "type": "string"
+                },
+                "deployedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "revision": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdSyncOperationResult": {
+            "type": "object",
+            "properties": {
+                "revision": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ArgocdSyncPolicy": {
+            "type": "object",
+            "properties": {
+                "automated": {
+                    "description": "Automated is set when ArgoCD syncs the application without a manual trigger",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ArgocdSyncPolicyAutomated"
+                        }
+                    ]
+                },
+                "retry": {
+                    "$ref": "#/definitions/types.ArgocdSyncPolicyRetry"
+                },
+                "syncOptions": {
+                    "description": "SyncOptions are the sync options of every sync, e.g. CreateNamespace=true",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ArgocdSyncPolicyAutomated": {
+            "type": "object",
+            "properties": {
+                "allowEmpty": {
+                    "description": "AllowEmpty allows an automated sync to prune every resource of the application",
+                    "type": "boolean"
+                },
+                "enabled": {
+                    "description": "Enabled turns automated sync off when false, keeping the other settings; nil means on",
+                    "type": "boolean"
+                },
+                "prune": {
+                    "description": "Prune deletes resources no longer in git during automated syncs",
+                    "type": "boolean"
+                },
+                "selfHeal": {
+                    "description": "SelfHeal reverts changes made in the cluster outside of git",
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.ArgocdSyncPolicyRetry": {
+            "type": "object",
+            "properties": {
+                "backoff": {
+                    "$ref": "#/definitions/types.ArgocdSyncPolicyRetryBackoff"
+                },
+                "limit": {
+                    "description": "Limit is the number of retries; a negative limit retries forever",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ArgocdSyncPolicyRetryBackoff": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Duration and MaxDuration are Go durations such as 5s or 3m",
+                    "type": "string"
+                },
+                "factor": {
+                    "type": "integer"
+                },
+                "maxDuration": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.AutoSyncApplication": {
+            "type": "object",
+            "properties": {
+                "allowEmpty": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "prune": {
+                    "type": "boolean"
+                },
+                "selfHeal": {
+                    "type": "boolean"
+                },
+                "syncOptions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.AutoSyncResponse": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.AutoSyncApplication"
+                    }
+                },
+                "autoSync": {
+                    "description": "AutoSync counts the applications listed, and Prune and SelfHeal those of them with\nthe setting enabled",
+                    "type": "integer"
+                },
+                "manual": {
+                    "description": "Manual counts the filtered applications that are not listed because they only sync\nwhen triggered",
+                    "type": "integer"
+                },
+                "prune": {
+                    "type": "integer"
+                },
+                "selfHeal": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.BootstrapApplications": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArgocdApplication"
+                    }
+                },
+                "summaries": {
+                    "description": "Summaries is set with view=summary, Items with view=full",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ApplicationSummary"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "view": {
+                    "description": "View is summary or full",
+                    "type": "string"
+                }
+            }
+        },
+        "types.BootstrapHealth": {
+            "type": "object",
+            "properties": {
+                "argocdApiStatus": {
+                    "type": "string"
+                },
+                "generatedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tokenStatus": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BootstrapProjectGroups": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.ProjectGroup"
+                    }
+                },
+                "meta": {
+                    "description": "Meta describes the page of groups returned by /project-groups",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/config.ProjectGroupsMeta"
+                        }
+                    ]
+                },
+                "ungroupedProjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.BootstrapProjects": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.BootstrapResponse": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "$ref": "#/definitions/types.BootstrapApplications"
+                },
+                "health": {
+                    "$ref": "#/definitions/types.BootstrapHealth"
+                },
+                "projectGroups": {
+                    "$ref": "#/definitions/types.BootstrapProjectGroups"
+                },
+                "projects": {
+                    "$ref": "#/definitions/types.BootstrapProjects"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.CacheSnapshot": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "$ref": "#/definitions/types.CachedApplications"
+                },
+                "exportedAt": {
+                    "type": "string"
+                },
+                "projects": {
+                    "description": "Projects and Applications are omitted when the cache is empty",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.CachedProjects"
+                        }
+                    ]
+                },
+                "schemaVersion": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CacheStatus": {
+            "type": "object",
+            "properties": {
+                "ageSeconds": {
+                    "type": "number"
+                },
+                "backend": {
+                    "description": "Backend is memory or redis",
+                    "type": "string",
+                    "enum": [
+                        "memory",
+                        "redis"
+                    ]
+                },
+                "cachedAt": {
+                    "type": "string"
+                },
+                "fresh": {
+                    "description": "Fresh is set when the stored list is younger than the TTL",
+                    "type": "boolean"
+                },
+                "items": {
+                    "description": "Items is the number of entries in the stored list",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "populated": {
+                    "type": "boolean"
+                },
+                "ttl": {
+                    "description": "TTL is the cache TTL as a Go duration; 0s means caching is disabled",
+                    "type": "string"
+                }
+            }
+        },
+        "types.CachedApplications": {
+            "type": "object",
+            "properties": {
+                "cachedAt": {
+                    "type": "string"
+                },
+                "list": {
+                    "$ref": "#/definitions/types.ArgocdApplicationList"
+                }
+            }
+        },
+        "types.CachedProjects": {
+            "type": "object",
+            "properties": {
+                "cachedAt": {
+                    "type": "string"
+                },
+                "list": {
+                    "$ref": "#/definitions/types.ArgocdProjectList"
+                }
+            }
+        },
+        "types.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "upstream_down",
+                "not_found",
+                "filtered",
+                "invalid_param",
+                "unauthorized",
+                "upstream_too_large",
+                "upstream_redirect",
+                "method_not_allowed",
+                "admin_unauthorized",
+                "client_unauthorized",
+                "client_forbidden",
+                "not_acceptable",
+                "circuit_open",
+                "maintenance"
+            ],
+            "x-enum-varnames": [
+                "ErrorCodeUpstreamDown",
+                "ErrorCodeNotFound",
+                "ErrorCodeFiltered",
+                "ErrorCodeInvalidParam",
+                "ErrorCodeUnauthorized",
+                "ErrorCodeUpstreamTooLarge",
+                "ErrorCodeUpstreamRedirect",
+                "ErrorCodeMethodNotAllowed",
+                "ErrorCodeAdminUnauthorized",
+                "ErrorCodeClientUnauthorized",
+                "ErrorCodeClientForbidden",
+                "ErrorCodeNotAcceptable",
+                "ErrorCodeCircuitOpen",
+                "ErrorCodeMaintenance"
+            ]
+        },
+        "types.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorCode": {
+                    "enum": [
+                        "upstream_down",
+                        "not_found",
+                        "filtered",
+                        "invalid_param",
+                        "unauthorized",
+                        "admin_unauthorized",
+                        "client_unauthorized"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ErrorCode"
+                        }
+                    ]
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "profiles": {
+                    "description": "Profiles lists the schema profiles the route serves on not_acceptable errors",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "upstream": {
+                    "description": "Upstream is ArgoCD's own answer to the failed call, included on request",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.UpstreamErrorDetails"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.GroupDeployStats": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ApplicationDeployStats"
+                    }
+                },
+                "deployments": {
+                    "type": "integer"
+                },
+                "deploymentsPerDay": {
+                    "type": "number"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "lastDeployedAt": {
+                    "type": "string"
+                },
+                "meanIntervalSeconds": {
+                    "description": "MeanIntervalSeconds is the mean time between consecutive deployments; null with fewer than two",
+                    "type": "number"
+                },
+                "truncated": {
+                    "description": "Truncated is set when ArgoCD's retained history may not reach back to the window start,\nso the counts are a lower bound",
+                    "type": "boolean"
+                },
+                "windowSeconds": {
+                    "type": "integer"
+                },
+                "windowStart": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.HealthCheckRecord": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "type": "number"
+                },
+                "status": {
+                    "description": "Status is the overall status reported by /health: healthy or degraded",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.HealthHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "description": "Capacity is the maximum number of checks kept",
+                    "type": "integer"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.HealthCheckRecord"
+                    }
+                },
+                "lastTransition": {
+                    "description": "LastTransition is when the status last changed within the window",
+                    "type": "string"
+                },
+                "uptimePercent": {
+                    "description": "UptimePercent is the share of healthy checks in the window",
+                    "type": "number"
+                }
+            }
+        },
+        "types.InfoFeatures": {
+            "type": "object",
+            "properties": {
+                "adminApi": {
+                    "type": "boolean"
+                },
+                "auditLog": {
+                    "type": "boolean"
+                },
+                "cache": {
+                    "type": "boolean"
+                },
+                "clientStats": {
+                    "type": "boolean"
+                },
+                "deepHealth": {
+                    "type": "boolean"
+                },
+                "poller": {
+                    "type": "boolean"
+                },
+                "responseCache": {
+                    "type": "boolean"
+                },
+                "slowRequestLog": {
+                    "type": "boolean"
+                },
+                "strictQueryParams": {
+                    "type": "boolean"
+                },
+                "swagger": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.InfoResponse": {
+            "type": "object",
+            "properties": {
+                "argocdHost": {
+                    "description": "ArgocdHost is the host of ARGOCD_API_URL, without scheme, path or credentials",
+                    "type": "string"
+                },
+                "authMode": {
+                    "description": "AuthMode is how the proxy authenticates to ArgoCD",
+                    "type": "string"
+                },
+                "buildTime": {
+                    "type": "string"
+                },
+                "deprecatedConfig": {
+                    "description": "DeprecatedConfig are the deprecated environment variables set, with their replacements",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.Deprecation"
+                    }
+                },
+                "disabledEndpoints": {
+                    "description": "DisabledEndpoints are the route templates not served because of DISABLED_ENDPOINTS",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "features": {
+                    "$ref": "#/definitions/types.InfoFeatures"
+                },
+                "goVersion": {
+                    "type": "string"
+                },
+                "ignorePatterns": {
+                    "type": "integer"
+                },
+                "projectGroups": {
+                    "type": "integer"
+                },
+                "startTime": {
+                    "type": "string"
+                },
+                "uptimeSeconds": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.InventoryApplication": {
+            "type": "object",
+            "properties": {
+                "health": {
+                    "type": "string"
+                },
+                "images": {
+                    "description": "Images are the container images the application runs, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ingressUrls": {
+                    "description": "IngressURLs are the external URLs of the application, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "repo": {
+                    "description": "Repo is the repository of the application's first source",
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision is the revision the application last synced, or the target revision of its\nfirst source when it never synced",
+                    "type": "string"
+                }
+            }
+        },
+        "types.InventoryProject": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.InventoryApplication"
+                    }
+                }
+            }
+        },
+        "types.InventoryResponse": {
+            "type": "object",
+            "properties": {
+                "applicationCount": {
+                    "description": "ApplicationCount is the number of applications listed",
+                    "type": "integer"
+                },
+                "generatedAt": {
+                    "type": "string"
+                },
+                "projectCount": {
+                    "description": "ProjectCount is the number of projects with at least one listed application",
+                    "type": "integer"
+                },
+                "projects": {
+                    "description": "Projects is keyed by project name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/types.InventoryProject"
+                    }
+                }
+            }
+        },
+        "types.ListMeta": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Limit is the maximum number of items returned",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Total is the number of items before truncation",
+                    "type": "integer"
+                },
+                "truncated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.MaintenanceRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "Message is returned to clients of data endpoints during maintenance",
+                    "type": "string"
+                },
+                "serveStale": {
+                    "description": "ServeStale keeps data endpoints serving cached data, even expired, without calling ArgoCD",
+                    "type": "boolean"
+                },
+                "until": {
+                    "description": "Until is when maintenance mode ends by itself, in RFC 3339 format",
+                    "type": "string"
+                }
+            }
+        },
+        "types.MaintenanceStatus": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "serveStale": {
+                    "type": "boolean"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.MalformedNotificationAnnotation": {
+            "type": "object",
+            "properties": {
+                "annotation": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.NotificationSubscription": {
+            "type": "object",
+            "properties": {
+                "annotation": {
+                    "description": "Annotation is the annotation the subscription was parsed from",
+                    "type": "string"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "service": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "description": "Trigger is the notification trigger; empty means the default triggers of the service",
+                    "type": "string"
+                }
+            }
+        },
+        "types.OwnerCount": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OwnersResponse": {
+            "type": "object",
+            "properties": {
+                "annotation": {
+                    "description": "Annotation is the annotation key identifying owners, the first of OWNER_ANNOTATIONS",
+                    "type": "string"
+                },
+                "owners": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.OwnerCount"
+                    }
+                },
+                "unowned": {
+                    "description": "Unowned counts the applications without the owner annotation",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PermissionsResponse": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "description": "Access is open, key or anonymous",
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "key",
+                        "anonymous"
+                    ]
+                },
+                "client": {
+                    "description": "Client is the name of the caller's API key",
+                    "type": "string"
+                },
+                "disabledEndpoints": {
+                    "description": "DisabledEndpoints are the route templates the caller may not call, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "groups": {
+                    "description": "Groups are the project groups the caller can see",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "projects": {
+                    "description": "Projects are the sorted names of the projects the caller can see",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "restricted": {
+                    "description": "Restricted is set when the API key is limited to Groups by API_KEY_GROUPS",
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.ProjectPolicyRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "effect": {
+                    "type": "string"
+                },
+                "malformed": {
+                    "description": "Malformed marks lines that are not a six-field \"p, ...\" policy; only Raw is set",
+                    "type": "boolean"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "raw": {
+                    "description": "Raw is the policy line as defined in the project",
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ProjectRole": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "policies": {
+                    "description": "Policies are the policy lines as defined, omitted with flattenPolicies=true",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rules": {
+                    "description": "Rules are the parsed policy lines, only set with flattenPolicies=true",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ProjectPolicyRule"
+                    }
+                }
+            }
+        },
+        "types.ProjectRolesResponse": {
+            "type": "object",
+            "properties": {
+                "project": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ProjectRole"
+                    }
+                }
+            }
+        },
+        "types.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.RepositoryApplication": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "targetRevision": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.RepositoryApplications": {
+            "type": "object",
+            "properties": {
+                "applications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.RepositoryApplication"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.RepositoryTopologyResponse": {
+            "type": "object",
+            "properties": {
+                "applicationCount": {
+                    "description": "ApplicationCount is the number of distinct applications deploying from them",
+                    "type": "integer"
+                },
+                "repositories": {
+                    "description": "Repositories is keyed by normalized repository URL, e.g. github.com/company/api",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/types.RepositoryApplications"
+                    }
+                },
+                "repositoryCount": {
+                    "description": "RepositoryCount is the number of repositories listed",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.SchemaProfile": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is set on the profile served when the request names none",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.SchemaRoute": {
+            "type": "object",
+            "properties": {
+                "profiles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SchemaProfile"
+                    }
+                },
+                "route": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.SchemasResponse": {
+            "type": "object",
+            "properties": {
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SchemaRoute"
+                    }
+                }
+            }
+        },
+        "types.SupportBundle": {
+            "type": "object",
+            "properties": {
+                "caches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.CacheStatus"
+                    }
+                },
+                "config": {
+                    "description": "Config is the loaded configuration by field name, with secrets masked",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "events": {
+                    "$ref": "#/definitions/events.Report"
+                },
+                "generatedAt": {
+                    "type": "string"
+                },
+                "healthHistory": {
+                    "$ref": "#/definitions/types.HealthHistoryResponse"
+                },
+                "info": {
+                    "$ref": "#/definitions/types.InfoResponse"
+                },
+                "lastUpstreamError": {
+                    "description": "LastUpstreamError is null when no ArgoCD call has failed since startup",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.UpstreamError"
+                        }
+                    ]
+                },
+                "tokenStatus": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "types.UpstreamError": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Message describes the failure, redacted",
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the response status, or 0 when no response was received",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.UpstreamErrorDetails": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body is ArgoCD's response body, redacted and truncated to UPSTREAM_ERROR_BODY_LIMIT\nbytes; it usually holds the gRPC code and ArgoCD's message",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the HTTP status ArgoCD answered with",
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "\"Bearer \" followed by ADMIN_TOKEN; only used by the /admin endpoints",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
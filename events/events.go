@@ -0,0 +1,145 @@
+// Package events keeps a bounded in-memory log of significant operational events, such
+// as token refreshes, cache invalidations and circuit breaker trips, so that what
+// happened recently can be read back from a running instance after its logs have
+// rotated out. The log is a ring: once full, each new event replaces the oldest one.
+package events
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"argocd-proxy/clock"
+)
+
+// DefaultCapacity is the number of events kept by the default log until SetDefault
+// replaces it
+const DefaultCapacity = 500
+
+// Type is the category of an event
+type Type string
+
+// Event types
+const (
+	// TokenRefreshed means a new ArgoCD session token was obtained
+	TokenRefreshed Type = "token_refreshed"
+	// TokenRefreshFailed means obtaining an ArgoCD session token failed
+	TokenRefreshFailed Type = "token_refresh_failed"
+	// CacheInvalidated means the cached ArgoCD lists were dropped
+	CacheInvalidated Type = "cache_invalidated"
+	// ConfigReloaded means part of the configuration was replaced at runtime
+	ConfigReloaded Type = "config_reloaded"
+	// CircuitOpened means reads of an application started failing fast
+	CircuitOpened Type = "circuit_opened"
+	// UpstreamIncompatible means the compatibility probe found the ArgoCD API incompatible
+	UpstreamIncompatible Type = "upstream_incompatible"
+	// UpstreamCompatible means the ArgoCD API became compatible again
+	UpstreamCompatible Type = "upstream_compatible"
+	// UpstreamFailedOver means ArgoCD calls moved to another URL of ARGOCD_API_URL
+	UpstreamFailedOver Type = "upstream_failed_over"
+	// MaintenanceEntered and MaintenanceLeft mean maintenance mode was entered or left
+	MaintenanceEntered Type = "maintenance_entered"
+	MaintenanceLeft    Type = "maintenance_left"
+)
+
+// Event is one recorded operational event
+type Event struct {
+	// Sequence numbers events from 1 in recording order, so gaps show dropped events
+	Sequence  uint64            `json:"sequence"`
+	Timestamp time.Time         `json:"timestamp"`
+	Type      Type              `json:"type"`
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+// Report lists the most recent events, newest first
+type Report struct {
+	Events []Event `json:"events"`
+	// Capacity is the number of events the log keeps
+	Capacity int `json:"capacity"`
+	// Recorded is the number of events recorded since startup, including those the ring
+	// no longer holds
+	Recorded uint64 `json:"recorded"`
+}
+
+// Log is a bounded, concurrency-safe event log
+type Log struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	ring   []Event
+	next   int
+	total  uint64
+	filled bool
+}
+
+// New returns a log keeping the last capacity events; capacity is at least 1
+func New(capacity int) *Log {
+	return NewWithClock(capacity, clock.Real)
+}
+
+// NewWithClock returns a log timestamping events with clk
+func NewWithClock(capacity int, clk clock.Clock) *Log {
+	return &Log{clock: clk, ring: make([]Event, max(capacity, 1))}
+}
+
+// Record appends an event, replacing the oldest one when the log is full. attrs is
+// copied, so the caller may reuse it.
+func (l *Log) Record(eventType Type, message string, attrs map[string]string) {
+	event := Event{Type: eventType, Message: message}
+	if len(attrs) > 0 {
+		event.Attrs = maps.Clone(attrs)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total++
+	event.Sequence = l.total
+	event.Timestamp = l.clock.Now()
+	l.ring[l.next] = event
+	l.next = (l.next + 1) % len(l.ring)
+	l.filled = l.filled || l.next == 0
+}
+
+// Recent returns up to limit of the most recent events, newest first; a limit of 0 or
+// less returns every event held
+func (l *Log) Recent(limit int) Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held := l.next
+	if l.filled {
+		held = len(l.ring)
+	}
+	if limit <= 0 || limit > held {
+		limit = held
+	}
+	report := Report{Events: make([]Event, 0, limit), Capacity: len(l.ring), Recorded: l.total}
+	for i := 1; i <= limit; i++ {
+		report.Events = append(report.Events, l.ring[(l.next-i+len(l.ring))%len(l.ring)])
+	}
+	return report
+}
+
+// defaultLog is the log Record writes to, so callers need not thread a *Log through
+// every layer
+var defaultLog atomic.Pointer[Log]
+
+func init() {
+	defaultLog.Store(New(DefaultCapacity))
+}
+
+// SetDefault replaces the log Record writes to, during startup
+func SetDefault(l *Log) {
+	defaultLog.Store(l)
+}
+
+// Default returns the log Record writes to
+func Default() *Log {
+	return defaultLog.Load()
+}
+
+// Record appends an event to the default log
+func Record(eventType Type, message string, attrs map[string]string) {
+	Default().Record(eventType, message, attrs)
+}
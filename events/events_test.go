@@ -0,0 +1,109 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/clock"
+)
+
+// stepClock is a manually advanced clock; testutils cannot be imported here, as it
+// depends on types, which depends on this package
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time                       { return c.now }
+func (c *stepClock) Since(t time.Time) time.Duration      { return c.now.Sub(t) }
+func (c *stepClock) NewTicker(time.Duration) clock.Ticker { panic("stepClock has no tickers") }
+func (c *stepClock) Advance(d time.Duration)              { c.now = c.now.Add(d) }
+
+func TestLogKeepsMostRecentEvents(t *testing.T) {
+	clk := &stepClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	log := NewWithClock(3, clk)
+
+	attrs := map[string]string{"application": "api"}
+	for i := 1; i <= 5; i++ {
+		attrs["attempt"] = fmt.Sprint(i)
+		log.Record(CircuitOpened, fmt.Sprintf("event %d", i), attrs)
+		clk.Advance(time.Second)
+	}
+
+	report := log.Recent(0)
+	if report.Capacity != 3 || report.Recorded != 5 || len(report.Events) != 3 {
+		t.Fatalf("report = capacity %d, recorded %d, %d events, want 3, 5 and 3", report.Capacity, report.Recorded, len(report.Events))
+	}
+	for i, event := range report.Events {
+		want := uint64(5 - i)
+		if event.Sequence != want || event.Message != fmt.Sprintf("event %d", want) {
+			t.Errorf("events[%d] = #%d %q, want #%d, newest first", i, event.Sequence, event.Message, want)
+		}
+		// The caller's attrs map was reused after each call
+		if event.Attrs["attempt"] != fmt.Sprint(want) {
+			t.Errorf("events[%d] attempt = %q, want %d", i, event.Attrs["attempt"], want)
+		}
+	}
+	if got := report.Events[0].Timestamp; !got.Equal(clk.Now().Add(-time.Second)) {
+		t.Errorf("newest timestamp = %s, want %s", got, clk.Now().Add(-time.Second))
+	}
+
+	if got := log.Recent(2).Events; len(got) != 2 || got[0].Sequence != 5 || got[1].Sequence != 4 {
+		t.Errorf("Recent(2) = %+v, want events 5 and 4", got)
+	}
+}
+
+func TestLogBeforeFull(t *testing.T) {
+	log := New(10)
+	if got := log.Recent(5).Events; len(got) != 0 {
+		t.Errorf("empty log returned %d events", len(got))
+	}
+	log.Record(TokenRefreshed, "refreshed", nil)
+	log.Record(TokenRefreshFailed, "failed", nil)
+	if got := log.Recent(5).Events; len(got) != 2 || got[0].Type != TokenRefreshFailed || got[1].Type != TokenRefreshed {
+		t.Errorf("Recent(5) = %+v, want the failure then the refresh", got)
+	}
+}
+
+func TestLogConcurrentRecords(t *testing.T) {
+	const writers, perWriter, capacity = 8, 500, 64
+	log := New(capacity)
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWriter {
+				log.Record(CacheInvalidated, "invalidated", map[string]string{"writer": fmt.Sprint(w), "i": fmt.Sprint(i)})
+				if i%50 == 0 {
+					log.Recent(10)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := log.Recent(0)
+	if report.Recorded != writers*perWriter || len(report.Events) != capacity {
+		t.Fatalf("recorded %d, holding %d, want %d and %d", report.Recorded, len(report.Events), writers*perWriter, capacity)
+	}
+	for i, event := range report.Events {
+		if want := uint64(writers*perWriter - i); event.Sequence != want {
+			t.Fatalf("events[%d].Sequence = %d, want %d", i, event.Sequence, want)
+		}
+	}
+}
+
+func TestDefaultLog(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	log := New(5)
+	SetDefault(log)
+	Record(ConfigReloaded, "patterns replaced", map[string]string{"ignoredProjects": "sandbox-*"})
+	if got := log.Recent(0).Events; len(got) != 1 || got[0].Type != ConfigReloaded {
+		t.Errorf("default log events = %+v, want the recorded event", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/features"
+)
+
+// newFeatureSet resolves the feature flags from FEATURES over the legacy settings of the
+// flags that had their own
+func (s *Server) newFeatureSet() *features.Set {
+	return features.New(map[features.Name]bool{
+		features.DeepHealth:        s.config.DeepHealth,
+		features.StrictQueryParams: s.config.StrictQueryParams,
+	}, s.config.Features)
+}
+
+// featuresMiddleware makes the feature flags of the matched route available to
+// features.Enabled through the request context
+func (s *Server) featuresMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(features.NewContext(c.Request.Context(), s.features, c.FullPath()))
+		c.Next()
+	}
+}
+
+// warnUnknownFeatureRoutes logs the FEATURES route overrides naming no registered route,
+// which never apply
+func (s *Server) warnUnknownFeatureRoutes() {
+	registered := make(map[string]bool)
+	for _, route := range s.router.Routes() {
+		registered[route.Path] = true
+	}
+	for _, route := range s.features.Routes() {
+		if !registered[route] {
+			log.Printf("WARNING: FEATURES overrides flags on unknown route %q", route)
+		}
+	}
+}
+
+// getFeatures handles the feature flags admin endpoint
+// @Summary Get feature flags
+// @Description Get every registered feature flag with its description, its default before FEATURES (from the flag's legacy setting where it has one), its state on routes without an override and its per-route overrides. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} features.Report "Feature flags and their states"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/features [get]
+func (s *Server) getFeatures(c *gin.Context) {
+	c.JSON(http.StatusOK, features.Report{Flags: s.features.List()})
+}
@@ -0,0 +1,239 @@
+// Package features resolves optional proxy behaviors from one place. Flags are registered
+// with a default, switched globally or per route template by the FEATURES setting, and
+// queried through the request context, so handlers do not each read their own setting.
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Name identifies a feature flag
+type Name string
+
+// Registered flags
+const (
+	// DeepHealth makes /health also report the status of ArgoCD's internal components
+	DeepHealth Name = "deep-health"
+	// StrictQueryParams rejects query parameters a route does not understand
+	StrictQueryParams Name = "strict-query-params"
+)
+
+// Flag describes a registered feature flag
+type Flag struct {
+	Name        Name
+	Description string
+	// Default is the state of the flag when neither its legacy setting nor FEATURES sets it
+	Default bool
+}
+
+// Registry lists the known flags, sorted by name
+var Registry = []Flag{
+	{Name: DeepHealth, Description: "Report the status of ArgoCD's internal components on /health (legacy setting DEEP_HEALTH)"},
+	{Name: StrictQueryParams, Description: "Reject query parameters a route does not understand with 400 (legacy setting STRICT_QUERY_PARAMS)"},
+}
+
+// lookup returns the registered flag called name
+func lookup(name Name) (Flag, bool) {
+	for _, flag := range Registry {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Overrides are the flag states set by FEATURES
+type Overrides struct {
+	// Global holds the states applying to every route
+	Global map[Name]bool
+	// Routes holds the states applying to single route templates, keyed by template
+	Routes map[string]map[Name]bool
+}
+
+// Parse parses a FEATURES value. It is either a comma-separated list or a JSON object
+// with boolean values, whose keys or entries name a flag, optionally scoped to a route
+// template with "@": "deep-health,strict-query-params@/applications=false" equals
+// {"deep-health": true, "strict-query-params@/applications": false}. A bare name enables
+// the flag and "-name" disables it. Unknown flags are ignored and reported as warnings.
+func Parse(spec string) (Overrides, []string, error) {
+	overrides := Overrides{Global: map[Name]bool{}, Routes: map[string]map[Name]bool{}}
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return overrides, nil, nil
+	}
+
+	var entries map[string]bool
+	if strings.HasPrefix(spec, "{") {
+		if err := json.Unmarshal([]byte(spec), &entries); err != nil {
+			return Overrides{}, nil, fmt.Errorf("invalid JSON object of flags: %w", err)
+		}
+	} else {
+		entries = map[string]bool{}
+		for _, entry := range strings.Split(spec, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			key, value, hasValue := strings.Cut(entry, "=")
+			enabled := true
+			switch {
+			case hasValue:
+				parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+				if err != nil {
+					return Overrides{}, nil, fmt.Errorf("invalid value %q for %q: must be true or false", value, key)
+				}
+				enabled = parsed
+			case strings.HasPrefix(key, "-"):
+				key, enabled = key[1:], false
+			}
+			entries[strings.TrimSpace(key)] = enabled
+		}
+	}
+
+	var warnings []string
+	for _, key := range sortedKeys(entries) {
+		name, route, scoped := strings.Cut(key, "@")
+		if _, ok := lookup(Name(name)); !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown flag %q ignored", name))
+			continue
+		}
+		if !scoped {
+			overrides.Global[Name(name)] = entries[key]
+			continue
+		}
+		if !strings.HasPrefix(route, "/") {
+			return Overrides{}, nil, fmt.Errorf("invalid route %q for flag %q: must be a route template starting with /", route, name)
+		}
+		if overrides.Routes[route] == nil {
+			overrides.Routes[route] = map[Name]bool{}
+		}
+		overrides.Routes[route][Name(name)] = entries[key]
+	}
+	return overrides, warnings, nil
+}
+
+// sortedKeys returns the keys of m in order, so that warnings are reported stably
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set resolves the state of every flag: a route override wins over a global override,
+// which wins over the flag's default
+type Set struct {
+	defaults  map[Name]bool
+	overrides Overrides
+}
+
+// New returns the flag states built from overrides over the registered defaults. defaults
+// replaces the registered default of some flags, for flags that have a legacy setting.
+func New(defaults map[Name]bool, overrides Overrides) *Set {
+	set := &Set{defaults: map[Name]bool{}, overrides: overrides}
+	for _, flag := range Registry {
+		set.defaults[flag.Name] = flag.Default
+	}
+	for name, enabled := range defaults {
+		set.defaults[name] = enabled
+	}
+	return set
+}
+
+// Enabled reports whether the flag is enabled on route; an empty route only considers
+// the global state. Unknown flags are disabled.
+func (s *Set) Enabled(name Name, route string) bool {
+	if enabled, ok := s.overrides.Routes[route][name]; ok {
+		return enabled
+	}
+	return s.EnabledGlobally(name)
+}
+
+// EnabledGlobally reports whether the flag is enabled on routes without an override
+func (s *Set) EnabledGlobally(name Name) bool {
+	if enabled, ok := s.overrides.Global[name]; ok {
+		return enabled
+	}
+	return s.defaults[name]
+}
+
+// Routes returns the route templates with overrides, sorted
+func (s *Set) Routes() []string {
+	routes := make([]string, 0, len(s.overrides.Routes))
+	for route := range s.overrides.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Status is the state of a flag as listed by the admin API
+type Status struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Default is the state before FEATURES, from the legacy setting or the registry
+	Default bool `json:"default"`
+	// Enabled is the state on routes without an override
+	Enabled bool `json:"enabled"`
+	// Routes holds the per-route overrides, keyed by route template
+	Routes map[string]bool `json:"routes,omitempty"`
+}
+
+// Report lists the feature flags for the admin API
+type Report struct {
+	Flags []Status `json:"flags"`
+}
+
+// List returns the state of every registered flag, sorted by name
+func (s *Set) List() []Status {
+	statuses := make([]Status, 0, len(Registry))
+	for _, flag := range Registry {
+		status := Status{
+			Name:        string(flag.Name),
+			Description: flag.Description,
+			Default:     s.defaults[flag.Name],
+			Enabled:     s.EnabledGlobally(flag.Name),
+		}
+		for route, flags := range s.overrides.Routes {
+			if enabled, ok := flags[flag.Name]; ok {
+				if status.Routes == nil {
+					status.Routes = map[string]bool{}
+				}
+				status.Routes[route] = enabled
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// contextKey is the context key under which a request's flag scope is stored
+type contextKey struct{}
+
+// scope is the flag set and route template of a request
+type scope struct {
+	set   *Set
+	route string
+}
+
+// NewContext returns a context resolving flags from set for route
+func NewContext(ctx context.Context, set *Set, route string) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{set: set, route: route})
+}
+
+// Enabled reports whether the flag is enabled for the request carried by ctx. Contexts
+// without a flag set, such as background work, get the flag's registered default.
+func Enabled(ctx context.Context, name Name) bool {
+	if s, ok := ctx.Value(contextKey{}).(scope); ok && s.set != nil {
+		return s.set.Enabled(name, s.route)
+	}
+	flag, _ := lookup(name)
+	return flag.Default
+}
@@ -0,0 +1,146 @@
+package features
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         string
+		want         Overrides
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "empty",
+			want: Overrides{Global: map[Name]bool{}, Routes: map[string]map[Name]bool{}},
+		},
+		{
+			name: "comma-separated",
+			spec: "deep-health, -strict-query-params, strict-query-params@/applications=true",
+			want: Overrides{
+				Global: map[Name]bool{DeepHealth: true, StrictQueryParams: false},
+				Routes: map[string]map[Name]bool{"/applications": {StrictQueryParams: true}},
+			},
+		},
+		{
+			name: "explicit values",
+			spec: "deep-health=false,strict-query-params=1",
+			want: Overrides{
+				Global: map[Name]bool{DeepHealth: false, StrictQueryParams: true},
+				Routes: map[string]map[Name]bool{},
+			},
+		},
+		{
+			name: "JSON",
+			spec: `{"deep-health": true, "strict-query-params@/projects/:project/applications": false}`,
+			want: Overrides{
+				Global: map[Name]bool{DeepHealth: true},
+				Routes: map[string]map[Name]bool{"/projects/:project/applications": {StrictQueryParams: false}},
+			},
+		},
+		{
+			name: "unknown flags are warned about",
+			spec: "stale-serving,deep-health,raw-mode@/applications",
+			want: Overrides{
+				Global: map[Name]bool{DeepHealth: true},
+				Routes: map[string]map[Name]bool{},
+			},
+			wantWarnings: []string{`unknown flag "raw-mode" ignored`, `unknown flag "stale-serving" ignored`},
+		},
+		{name: "invalid value", spec: "deep-health=yes please", wantErr: true},
+		{name: "invalid JSON", spec: `{"deep-health": "on"}`, wantErr: true},
+		{name: "route without slash", spec: "deep-health@health", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("Parse() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	overrides, _, err := Parse("strict-query-params,strict-query-params@/health=false,deep-health@/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := New(map[Name]bool{StrictQueryParams: false}, overrides)
+
+	tests := []struct {
+		name  Name
+		route string
+		want  bool
+	}{
+		{StrictQueryParams, "/applications", true},
+		{StrictQueryParams, "/health", false},
+		{StrictQueryParams, "", true},
+		{DeepHealth, "/health", true},
+		{DeepHealth, "/applications", false},
+		{"stale-serving", "/applications", false},
+	}
+	for _, tt := range tests {
+		if got := set.Enabled(tt.name, tt.route); got != tt.want {
+			t.Errorf("Enabled(%q, %q) = %v, want %v", tt.name, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	empty, _, _ := Parse("")
+
+	if New(nil, empty).EnabledGlobally(DeepHealth) {
+		t.Error("deep-health enabled without a legacy setting, want its registered default")
+	}
+	if !New(map[Name]bool{DeepHealth: true}, empty).EnabledGlobally(DeepHealth) {
+		t.Error("deep-health disabled with its legacy setting enabled")
+	}
+
+	overrides, _, _ := Parse("-deep-health")
+	if New(map[Name]bool{DeepHealth: true}, overrides).EnabledGlobally(DeepHealth) {
+		t.Error("deep-health enabled, want FEATURES to win over the legacy setting")
+	}
+}
+
+func TestEnabledFromContext(t *testing.T) {
+	overrides, _, _ := Parse("strict-query-params@/applications")
+	set := New(nil, overrides)
+
+	if !Enabled(NewContext(context.Background(), set, "/applications"), StrictQueryParams) {
+		t.Error("Enabled() = false on the overridden route, want true")
+	}
+	if Enabled(NewContext(context.Background(), set, "/projects"), StrictQueryParams) {
+		t.Error("Enabled() = true on another route, want false")
+	}
+	if Enabled(context.Background(), StrictQueryParams) {
+		t.Error("Enabled() = true without a flag set, want the registered default")
+	}
+}
+
+func TestSetList(t *testing.T) {
+	overrides, _, _ := Parse("deep-health,strict-query-params@/applications=false")
+	got := New(map[Name]bool{StrictQueryParams: true}, overrides).List()
+
+	want := []Status{
+		{Name: "deep-health", Description: Registry[0].Description, Default: false, Enabled: true},
+		{Name: "strict-query-params", Description: Registry[1].Description, Default: true, Enabled: true, Routes: map[string]bool{"/applications": false}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
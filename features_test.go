@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"argocd-proxy/features"
+)
+
+func TestGetFeatures(t *testing.T) {
+	server, _ := setupAdminServer()
+	server.config.DeepHealth = true
+	overrides, _, err := features.Parse("strict-query-params@/applications")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.config.Features = overrides
+	server.setupRouter()
+
+	if w := serveMethod(server, http.MethodGet, "/admin/features", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status without token = %d, want 401", w.Code)
+	}
+
+	w := serveMethod(server, http.MethodGet, "/admin/features", map[string]string{"Authorization": "Bearer " + testAdminToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var report features.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	got := map[string]features.Status{}
+	for _, status := range report.Flags {
+		got[status.Name] = status
+	}
+	if status := got["deep-health"]; !status.Default || !status.Enabled || status.Routes != nil {
+		t.Errorf("deep-health = %+v, want enabled by its legacy setting", status)
+	}
+	if status := got["strict-query-params"]; status.Default || status.Enabled || !reflect.DeepEqual(status.Routes, map[string]bool{"/applications": true}) {
+		t.Errorf("strict-query-params = %+v, want disabled except on /applications", status)
+	}
+}
+
+func TestFeatureRouteOverrides(t *testing.T) {
+	server := setupTestServer()
+	server.config.StrictQueryParams = true
+	overrides, _, err := features.Parse("strict-query-params@/applications=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.config.Features = overrides
+	server.setupRouter()
+
+	for _, tt := range []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/applications?unknown=1", http.StatusOK},
+		{"/projects?unknown=1", http.StatusBadRequest},
+	} {
+		if w := serveMethod(server, http.MethodGet, tt.path, nil); w.Code != tt.wantStatus {
+			t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+	}
+}
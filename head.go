@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// discardWriter drops the response body while counting its length, so a HEAD response
+// can report the Content-Length the matching GET response would have
+type discardWriter struct {
+	gin.ResponseWriter
+	length int
+}
+
+// Write counts the body instead of sending it
+func (w *discardWriter) Write(data []byte) (int, error) {
+	w.length += len(data)
+	return len(data), nil
+}
+
+// WriteString counts the body instead of sending it
+func (w *discardWriter) WriteString(s string) (int, error) {
+	w.length += len(s)
+	return len(s), nil
+}
+
+// headMiddleware runs HEAD requests through the GET handler of the route and sends only
+// its headers: status, Content-Type, ETag and the rest are kept, the body is discarded
+// and Content-Length is set to the length of the body that was produced.
+func headMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		discard := &discardWriter{ResponseWriter: original}
+		c.Writer = discard
+		c.Next()
+		c.Writer = original
+
+		if discard.length > 0 && original.Header().Get("Content-Length") == "" {
+			original.Header().Set("Content-Length", strconv.Itoa(discard.length))
+		}
+		original.WriteHeaderNow()
+	}
+}
+
+// readRoute registers handler for GET requests on path and for the equivalent HEAD requests
+func (s *Server) readRoute(path string, handler gin.HandlerFunc) {
+	routes := s.routes(&s.router.RouterGroup)
+	routes.GET(path, handler)
+	routes.HEAD(path, handler)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// serveMethod serves a request with the given method and headers
+func serveMethod(server *Server, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestHeadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		healthErr  error
+		wantStatus int
+		wantETag   bool
+	}{
+		{"health", "/health", nil, http.StatusOK, false},
+		{"degraded health", "/health", fmt.Errorf("ArgoCD unavailable"), http.StatusServiceUnavailable, false},
+		{"applications", "/applications", nil, http.StatusOK, true},
+		{"filtered applications", "/applications?health=Healthy", nil, http.StatusOK, true},
+		{"invalid query", "/applications?health=Broken", nil, http.StatusBadRequest, false},
+		{"unknown route", "/does-not-exist", nil, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mockService := setupResponseCacheServer()
+			mockService.healthErr = tt.healthErr
+
+			get := serveMethod(server, http.MethodGet, tt.path, nil)
+			head := serveMethod(server, http.MethodHead, tt.path, nil)
+
+			if head.Code != tt.wantStatus || get.Code != tt.wantStatus {
+				t.Fatalf("status GET = %d, HEAD = %d, want %d", get.Code, head.Code, tt.wantStatus)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("HEAD body = %q, want empty", head.Body.String())
+			}
+			if got, want := head.Header().Get("Content-Type"), get.Header().Get("Content-Type"); got == "" || got != want {
+				t.Errorf("HEAD Content-Type = %q, want %q", got, want)
+			}
+			if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+				t.Errorf("HEAD Content-Length = %q, want %q", got, want)
+			}
+			if etag := head.Header().Get("ETag"); (etag != "") != tt.wantETag || etag != get.Header().Get("ETag") {
+				t.Errorf("HEAD ETag = %q, GET ETag = %q", etag, get.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestHeadConditionalRequest(t *testing.T) {
+	server, _ := setupResponseCacheServer()
+
+	etag := serveMethod(server, http.MethodHead, "/applications", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("HEAD /applications returned no ETag")
+	}
+
+	w := serveMethod(server, http.MethodHead, "/applications", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("conditional HEAD = %d with %d body bytes, want %d and none", w.Code, w.Body.Len(), http.StatusNotModified)
+	}
+}
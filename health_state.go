@@ -0,0 +1,36 @@
+package main
+
+import (
+	"argocd-proxy/healthstate"
+	"argocd-proxy/types"
+)
+
+// newHealthState returns the state machine suppressing flaps of /health, which starts
+// healthy
+func (s *Server) newHealthState() *healthstate.Machine {
+	return healthstate.New(healthstate.Options{
+		FailureThreshold:  s.config.HealthFailureThreshold,
+		RecoveryThreshold: s.config.HealthRecoveryThreshold,
+	})
+}
+
+// healthStateResponse converts a health state machine status to its response form
+func healthStateResponse(status healthstate.Status) *types.HealthState {
+	response := &types.HealthState{
+		State:                status.State,
+		ConsecutiveFailures:  status.ConsecutiveFailures,
+		ConsecutiveSuccesses: status.ConsecutiveSuccesses,
+		FailureThreshold:     status.FailureThreshold,
+		RecoveryThreshold:    status.RecoveryThreshold,
+		Since:                status.Since,
+		Transitions:          make([]types.HealthStateTransition, 0, len(status.Transitions)),
+	}
+	for _, transition := range status.Transitions {
+		response.Transitions = append(response.Transitions, types.HealthStateTransition{From: transition.From, To: transition.To, At: transition.At})
+	}
+	if len(response.Transitions) > 0 {
+		last := response.Transitions[len(response.Transitions)-1]
+		response.LastTransition = &last
+	}
+	return response
+}
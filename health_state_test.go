@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"argocd-proxy/healthstate"
+	"argocd-proxy/types"
+)
+
+// checkHealth requests path and decodes the health response
+func checkHealth(t *testing.T, server *Server, path string) (int, types.HealthResponse) {
+	t.Helper()
+	w := serveMethod(server, http.MethodGet, path, nil)
+	var response types.HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GET %s: invalid JSON response: %v", path, err)
+	}
+	return w.Code, response
+}
+
+func TestHealthFlapSuppression(t *testing.T) {
+	server := setupTestServer()
+	server.config.HealthFailureThreshold = 3
+	server.config.HealthRecoveryThreshold = 2
+	server.setupRouter()
+	mockService := server.argocdService.(*MockArgocdService)
+	unreachable := errors.New("dial tcp: connection refused")
+
+	steps := []struct {
+		err        error
+		wantCode   int
+		wantState  string
+		failures   int
+		successes  int
+		wantStatus string
+	}{
+		// Failures below the threshold keep the probe passing
+		{err: unreachable, wantCode: http.StatusOK, wantState: healthstate.Healthy, failures: 1, wantStatus: "healthy"},
+		{err: unreachable, wantCode: http.StatusOK, wantState: healthstate.Healthy, failures: 2, wantStatus: "healthy"},
+		{err: nil, wantCode: http.StatusOK, wantState: healthstate.Healthy, successes: 1, wantStatus: "healthy"},
+		{err: unreachable, wantCode: http.StatusOK, wantState: healthstate.Healthy, failures: 1, wantStatus: "healthy"},
+		{err: unreachable, wantCode: http.StatusOK, wantState: healthstate.Healthy, failures: 2, wantStatus: "healthy"},
+		// The third consecutive failure degrades
+		{err: unreachable, wantCode: http.StatusServiceUnavailable, wantState: healthstate.Degraded, failures: 3, wantStatus: "degraded"},
+		// A single success does not recover
+		{err: nil, wantCode: http.StatusServiceUnavailable, wantState: healthstate.Degraded, successes: 1, wantStatus: "degraded"},
+		{err: unreachable, wantCode: http.StatusServiceUnavailable, wantState: healthstate.Degraded, failures: 1, wantStatus: "degraded"},
+		{err: nil, wantCode: http.StatusServiceUnavailable, wantState: healthstate.Degraded, successes: 1, wantStatus: "degraded"},
+		// The second consecutive success recovers
+		{err: nil, wantCode: http.StatusOK, wantState: healthstate.Healthy, successes: 2, wantStatus: "healthy"},
+	}
+
+	for i, step := range steps {
+		mockService.healthErr = step.err
+		code, response := checkHealth(t, server, "/health")
+		state := response.HealthState
+		if code != step.wantCode || response.Status != step.wantStatus || state == nil || state.State != step.wantState {
+			t.Fatalf("step %d: status %d %q, healthState %+v, want %d %q in state %s", i+1, code, response.Status, state, step.wantCode, step.wantStatus, step.wantState)
+		}
+		if state.ConsecutiveFailures != step.failures || state.ConsecutiveSuccesses != step.successes {
+			t.Errorf("step %d: consecutive failures, successes = %d, %d, want %d, %d", i+1, state.ConsecutiveFailures, state.ConsecutiveSuccesses, step.failures, step.successes)
+		}
+		// A suppressed failure is still visible in the ArgoCD status
+		if step.err != nil && response.ArgocdAPI != "error: dial tcp: connection refused" {
+			t.Errorf("step %d: argocdApiStatus = %q, want the check error", i+1, response.ArgocdAPI)
+		}
+	}
+
+	_, response := checkHealth(t, server, "/health")
+	state := response.HealthState
+	if state.FailureThreshold != 3 || state.RecoveryThreshold != 2 || len(state.Transitions) != 2 {
+		t.Fatalf("healthState = %+v, want thresholds 3 and 2 with two transitions", state)
+	}
+	if state.Transitions[0].From != healthstate.Healthy || state.Transitions[0].To != healthstate.Degraded || state.Transitions[1].To != healthstate.Healthy {
+		t.Errorf("transitions = %+v, want healthy to degraded and back", state.Transitions)
+	}
+	if state.LastTransition == nil || *state.LastTransition != state.Transitions[1] || !state.Since.Equal(state.LastTransition.At) {
+		t.Errorf("lastTransition = %+v, since = %s, want the recovery", state.LastTransition, state.Since)
+	}
+}
+
+func TestHealthRaw(t *testing.T) {
+	server := setupTestServer()
+	server.config.HealthFailureThreshold = 3
+	server.config.HealthRecoveryThreshold = 2
+	server.setupRouter()
+	mockService := server.argocdService.(*MockArgocdService)
+
+	// The raw result follows this check alone
+	mockService.healthErr = errors.New("dial tcp: connection refused")
+	if code, response := checkHealth(t, server, "/health?raw=true"); code != http.StatusServiceUnavailable || response.Status != "degraded" || response.HealthState.State != healthstate.Healthy {
+		t.Errorf("raw failed check = %d %q in state %s, want 503 degraded while the debounced state stays healthy", code, response.Status, response.HealthState.State)
+	}
+	// Raw checks still count towards the threshold
+	checkHealth(t, server, "/health?raw=true")
+	if code, _ := checkHealth(t, server, "/health"); code != http.StatusServiceUnavailable {
+		t.Errorf("third consecutive failure status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	mockService.healthErr = nil
+	if code, response := checkHealth(t, server, "/health?raw=true"); code != http.StatusOK || response.HealthState.State != healthstate.Degraded {
+		t.Errorf("raw passed check = %d in state %s, want 200 while the debounced state stays degraded", code, response.HealthState.State)
+	}
+
+	if w := serveMethod(server, http.MethodGet, "/health?raw=maybe", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("?raw=maybe status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,138 @@
+// Package healthstate debounces a stream of pass/fail health checks into a healthy or
+// degraded state, so that a single failed check does not flip the reported health and
+// the next one flip it back.
+package healthstate
+
+import (
+	"sync"
+	"time"
+
+	"argocd-proxy/clock"
+)
+
+// States reported by a Machine
+const (
+	Healthy  = "healthy"
+	Degraded = "degraded"
+)
+
+// maxTransitions is the number of recent transitions kept
+const maxTransitions = 10
+
+// Options configures a Machine
+type Options struct {
+	// FailureThreshold is the number of consecutive failed checks that turns a healthy
+	// state degraded; values below 1 count as 1
+	FailureThreshold int
+	// RecoveryThreshold is the number of consecutive passed checks that turns a degraded
+	// state healthy again; values below 1 count as 1
+	RecoveryThreshold int
+}
+
+// Transition is a change of state
+type Transition struct {
+	From string
+	To   string
+	At   time.Time
+}
+
+// Status is the state of a Machine
+type Status struct {
+	State string
+	// ConsecutiveFailures and ConsecutiveSuccesses count the latest run of failed or passed
+	// checks; at most one of them is not zero
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+	FailureThreshold     int
+	RecoveryThreshold    int
+	// Since is when the current state was entered, or when the Machine was created
+	Since time.Time
+	// Transitions are the most recent state changes, oldest first
+	Transitions []Transition
+}
+
+// LastTransition returns the most recent state change, if there was one
+func (s Status) LastTransition() (Transition, bool) {
+	if len(s.Transitions) == 0 {
+		return Transition{}, false
+	}
+	return s.Transitions[len(s.Transitions)-1], true
+}
+
+// Machine is a health state machine. It starts healthy and is safe for concurrent use.
+type Machine struct {
+	opts  Options
+	clock clock.Clock
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	successes   int
+	since       time.Time
+	transitions []Transition
+}
+
+// New returns a Machine using the system clock
+func New(opts Options) *Machine {
+	return NewWithClock(opts, clock.Real)
+}
+
+// NewWithClock returns a Machine using clk to tell the time
+func NewWithClock(opts Options, clk clock.Clock) *Machine {
+	opts.FailureThreshold = max(opts.FailureThreshold, 1)
+	opts.RecoveryThreshold = max(opts.RecoveryThreshold, 1)
+	clk = clock.OrReal(clk)
+	return &Machine{opts: opts, clock: clk, state: Healthy, since: clk.Now()}
+}
+
+// Observe records the result of a check and returns the resulting status
+func (m *Machine) Observe(passed bool) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if passed {
+		m.successes++
+		m.failures = 0
+		if m.state == Degraded && m.successes >= m.opts.RecoveryThreshold {
+			m.transition(Healthy)
+		}
+	} else {
+		m.failures++
+		m.successes = 0
+		if m.state == Healthy && m.failures >= m.opts.FailureThreshold {
+			m.transition(Degraded)
+		}
+	}
+	return m.status()
+}
+
+// Status returns the current status
+func (m *Machine) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status()
+}
+
+// transition enters state, keeping the most recent transitions
+func (m *Machine) transition(state string) {
+	now := m.clock.Now()
+	m.transitions = append(m.transitions, Transition{From: m.state, To: state, At: now})
+	if len(m.transitions) > maxTransitions {
+		m.transitions = m.transitions[len(m.transitions)-maxTransitions:]
+	}
+	m.state = state
+	m.since = now
+}
+
+// status returns the current status; m.mu must be held
+func (m *Machine) status() Status {
+	return Status{
+		State:                m.state,
+		ConsecutiveFailures:  m.failures,
+		ConsecutiveSuccesses: m.successes,
+		FailureThreshold:     m.opts.FailureThreshold,
+		RecoveryThreshold:    m.opts.RecoveryThreshold,
+		Since:                m.since,
+		Transitions:          append([]Transition(nil), m.transitions...),
+	}
+}
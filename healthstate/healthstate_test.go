@@ -0,0 +1,121 @@
+package healthstate
+
+import (
+	"testing"
+	"time"
+
+	"argocd-proxy/testutils"
+)
+
+func TestMachineThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		checks   string
+		want     []string
+		failures int
+		passes   int
+	}{
+		{
+			name:   "single failures are suppressed",
+			opts:   Options{FailureThreshold: 3, RecoveryThreshold: 2},
+			checks: "pfpffpf",
+			want:   []string{Healthy, Healthy, Healthy, Healthy, Healthy, Healthy, Healthy},
+			// The last run is a single failure
+			failures: 1,
+		},
+		{
+			name:   "degrades on the threshold",
+			opts:   Options{FailureThreshold: 3, RecoveryThreshold: 2},
+			checks: "fff",
+			want:   []string{Healthy, Healthy, Degraded},
+			// The run keeps counting past the threshold
+			failures: 3,
+		},
+		{
+			name:     "single successes do not recover",
+			opts:     Options{FailureThreshold: 3, RecoveryThreshold: 2},
+			checks:   "fffpfpf",
+			want:     []string{Healthy, Healthy, Degraded, Degraded, Degraded, Degraded, Degraded},
+			failures: 1,
+		},
+		{
+			name:   "recovers on the threshold",
+			opts:   Options{FailureThreshold: 3, RecoveryThreshold: 2},
+			checks: "fffppp",
+			want:   []string{Healthy, Healthy, Degraded, Degraded, Healthy, Healthy},
+			passes: 3,
+		},
+		{
+			name:   "thresholds of one follow every check",
+			opts:   Options{},
+			checks: "fpfp",
+			want:   []string{Degraded, Healthy, Degraded, Healthy},
+			passes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(tt.opts)
+			var status Status
+			for i, check := range tt.checks {
+				status = m.Observe(check == 'p')
+				if status.State != tt.want[i] {
+					t.Fatalf("state after check %d (%c) = %s, want %s", i+1, check, status.State, tt.want[i])
+				}
+			}
+			if status.ConsecutiveFailures != tt.failures || status.ConsecutiveSuccesses != tt.passes {
+				t.Errorf("consecutive failures, successes = %d, %d, want %d, %d", status.ConsecutiveFailures, status.ConsecutiveSuccesses, tt.failures, tt.passes)
+			}
+		})
+	}
+}
+
+func TestMachineTransitions(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := testutils.NewFakeClock(start)
+	m := NewWithClock(Options{FailureThreshold: 2, RecoveryThreshold: 2}, clk)
+
+	if status := m.Status(); status.State != Healthy || !status.Since.Equal(start) || len(status.Transitions) != 0 {
+		t.Fatalf("initial status = %+v, want healthy since creation without transitions", status)
+	}
+	if _, ok := m.Status().LastTransition(); ok {
+		t.Error("LastTransition() found one before any state change")
+	}
+
+	m.Observe(false)
+	clk.Advance(time.Minute)
+	degradedAt := clk.Now()
+	m.Observe(false)
+	clk.Advance(time.Minute)
+	m.Observe(true)
+	clk.Advance(time.Minute)
+	recoveredAt := clk.Now()
+	status := m.Observe(true)
+
+	want := []Transition{
+		{From: Healthy, To: Degraded, At: degradedAt},
+		{From: Degraded, To: Healthy, At: recoveredAt},
+	}
+	if len(status.Transitions) != len(want) || status.Transitions[0] != want[0] || status.Transitions[1] != want[1] {
+		t.Errorf("transitions = %+v, want %+v", status.Transitions, want)
+	}
+	if last, ok := status.LastTransition(); !ok || last != want[1] {
+		t.Errorf("LastTransition() = %+v, %v, want %+v", last, ok, want[1])
+	}
+	if !status.Since.Equal(recoveredAt) {
+		t.Errorf("since = %s, want %s", status.Since, recoveredAt)
+	}
+
+	// Only the most recent transitions are kept
+	for range maxTransitions {
+		m.Observe(false)
+		m.Observe(false)
+		m.Observe(true)
+		m.Observe(true)
+	}
+	if got := len(m.Status().Transitions); got != maxTransitions {
+		t.Errorf("transitions kept = %d, want %d", got, maxTransitions)
+	}
+}
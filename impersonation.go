@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/audit"
+	"argocd-proxy/auth"
+)
+
+// impersonationMiddleware names the caller of each request in its context, so the ArgoCD
+// requests made for it carry UPSTREAM_IMPERSONATION_HEADER. Callers with an API key are
+// named by the key; others only by UPSTREAM_IMPERSONATION_IDENTITY_HEADER when the request
+// comes from a trusted proxy. Anonymous callers are never named.
+func (s *Server) impersonationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := c.GetString(audit.ClientKey)
+		if identity == "" && s.config.UpstreamImpersonationIdentityHeader != "" && s.fromTrustedProxy(c) {
+			identity = c.GetHeader(s.config.UpstreamImpersonationIdentityHeader)
+		}
+		if identity != "" {
+			c.Request = c.Request.WithContext(auth.WithCaller(c.Request.Context(), identity))
+		}
+		c.Next()
+	}
+}
+
+// fromTrustedProxy reports whether the request's direct peer is one of TRUSTED_PROXIES
+func (s *Server) fromTrustedProxy(c *gin.Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.config.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
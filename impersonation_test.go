@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"argocd-proxy/auth"
+	"argocd-proxy/config"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+const testImpersonationHeader = "X-Argocd-Impersonate-User"
+
+// impersonationUpstream is a fake ArgoCD recording the impersonation header of the last
+// non-session read
+type impersonationUpstream struct {
+	mu       sync.Mutex
+	received []string
+}
+
+func (u *impersonationUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/session":
+		json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "test-token"})
+		return
+	case "/projects":
+		json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{{Metadata: types.ArgocdProjectMetadata{Name: "web-app"}}}})
+	case "/applications":
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	u.mu.Lock()
+	u.received = r.Header.Values(testImpersonationHeader)
+	u.mu.Unlock()
+}
+
+func TestUpstreamImpersonation(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKeys      bool
+		path         string
+		headers      map[string]string
+		remoteAddr   string
+		wantStatus   int
+		wantReceived []string
+	}{
+		{
+			name:         "API key names the caller",
+			apiKeys:      true,
+			headers:      map[string]string{apiKeyHeader: testAPIKey},
+			wantStatus:   http.StatusOK,
+			wantReceived: []string{"statuspage"},
+		},
+		{
+			name:         "API key wins over the identity header",
+			apiKeys:      true,
+			headers:      map[string]string{apiKeyHeader: testAPIKey, "X-Forwarded-User": "alice"},
+			remoteAddr:   "10.0.0.5:4321",
+			wantStatus:   http.StatusOK,
+			wantReceived: []string{"statuspage"},
+		},
+		{
+			name:       "anonymous caller is not named",
+			apiKeys:    true,
+			path:       "/project-groups",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "caller header is never forwarded",
+			apiKeys:    true,
+			path:       "/project-groups",
+			headers:    map[string]string{testImpersonationHeader: "admin"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "identity header from a trusted proxy",
+			headers:      map[string]string{"X-Forwarded-User": "alice"},
+			remoteAddr:   "10.0.0.5:4321",
+			wantStatus:   http.StatusOK,
+			wantReceived: []string{"alice"},
+		},
+		{
+			name:       "identity header from an untrusted peer",
+			headers:    map[string]string{"X-Forwarded-User": "alice"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no identity",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := &impersonationUpstream{}
+			fake := httptest.NewServer(upstream)
+			defer fake.Close()
+
+			server := setupTestServer()
+			server.config.ArgocdAPIURL = fake.URL
+			server.config.UpstreamImpersonationHeader = testImpersonationHeader
+			server.config.UpstreamImpersonationIdentityHeader = "X-Forwarded-User"
+			server.config.TrustedProxies = []string{"10.0.0.0/8"}
+			if tt.apiKeys {
+				server.config.APIKeys = []config.APIKey{{Name: "statuspage", Key: testAPIKey}}
+				server.config.AnonymousReadOnly = true
+			}
+			server.authService = auth.NewAuthService(server.config, nil)
+			server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+			server.setupRouter()
+
+			path := tt.path
+			if path == "" {
+				path = "/projects/web-app/applications"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			upstream.mu.Lock()
+			defer upstream.mu.Unlock()
+			if len(upstream.received) != len(tt.wantReceived) || (len(tt.wantReceived) > 0 && upstream.received[0] != tt.wantReceived[0]) {
+				t.Errorf("upstream %s = %v, want %v", testImpersonationHeader, upstream.received, tt.wantReceived)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/features"
+	"argocd-proxy/types"
+)
+
+// authModeSession is the only ArgoCD authentication mode: a session token obtained with
+// ARGOCD_USERNAME and ARGOCD_PASSWORD
+const authModeSession = "session"
+
+// runtimeInfo summarizes the build, configuration and enabled features of the proxy.
+// It is derived from static state only, so it is cheap to serve and safe to log.
+func (s *Server) runtimeInfo(now time.Time) types.InfoResponse {
+	filters := s.config.Snapshot()
+	return types.InfoResponse{
+		Version:        Version,
+		BuildTime:      BuildTime,
+		GoVersion:      runtime.Version(),
+		StartTime:      s.startTime.Format(time.RFC3339),
+		UptimeSeconds:  int64(max(now.Sub(s.startTime), 0) / time.Second),
+		ArgocdHost:     urlHost(s.config.ArgocdAPIURL),
+		ProjectGroups:  len(filters.ProjectGroups),
+		IgnorePatterns: len(filters.IgnoredProjects),
+		AuthMode:       authModeSession,
+		Features: types.InfoFeatures{
+			Cache:             s.config.CacheTTL > 0,
+			ResponseCache:     s.config.ResponseCacheTTL > 0,
+			Poller:            s.config.PollInterval > 0,
+			DeepHealth:        s.features.EnabledGlobally(features.DeepHealth),
+			AuditLog:          s.config.AuditLog,
+			StrictQueryParams: s.features.EnabledGlobally(features.StrictQueryParams),
+			SlowRequestLog:    s.config.SlowRequestThreshold > 0 || len(s.config.SlowRequestRouteThresholds) > 0,
+			Swagger:           s.config.SwaggerEnabled,
+			AdminAPI:          s.config.AdminToken != "",
+			ClientStats:       s.config.ClientStats,
+		},
+		DisabledEndpoints: append([]string{}, s.disabledEndpoints...),
+		DeprecatedConfig:  append([]config.Deprecation{}, s.config.Deprecations...),
+	}
+}
+
+// urlHost returns the host of rawURL, dropping the scheme, credentials and path
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// startupSummary formats the runtime information as a single key=value log line
+func startupSummary(port string, info types.InfoResponse) string {
+	var features []string
+	for name, enabled := range map[string]bool{
+		"cache":             info.Features.Cache,
+		"responseCache":     info.Features.ResponseCache,
+		"poller":            info.Features.Poller,
+		"deepHealth":        info.Features.DeepHealth,
+		"auditLog":          info.Features.AuditLog,
+		"strictQueryParams": info.Features.StrictQueryParams,
+		"slowRequestLog":    info.Features.SlowRequestLog,
+		"swagger":           info.Features.Swagger,
+		"adminApi":          info.Features.AdminAPI,
+		"clientStats":       info.Features.ClientStats,
+	} {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+
+	return fmt.Sprintf("port=%s version=%s buildTime=%s goVersion=%s argocdHost=%s projectGroups=%d ignorePatterns=%d authMode=%s features=%s",
+		port, info.Version, info.BuildTime, info.GoVersion, info.ArgocdHost,
+		info.ProjectGroups, info.IgnorePatterns, info.AuthMode, strings.Join(features, ","))
+}
+
+// getInfo handles the runtime info endpoint
+// @Summary Runtime information
+// @Description Get static information about this proxy instance: version, build, Go version, start time and uptime, the ArgoCD host, configuration counts, enabled features, disabled endpoints and deprecated configuration in use. No ArgoCD requests are made.
+// @Tags health
+// @Produce json
+// @Success 200 {object} types.InfoResponse "Runtime information"
+// @Router /info [get]
+func (s *Server) getInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, s.runtimeInfo(time.Now()))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// inventoryCSVHeader is the header row of the CSV export of /inventory
+var inventoryCSVHeader = []string{"project", "application", "namespace", "repo", "revision", "health", "images", "ingressUrls"}
+
+// getInventory handles the application inventory endpoint
+// @Summary Get the application inventory
+// @Description Get the images, external URLs, source repository, revision and health of every visible application, by project, from one read of the application list. group limits the inventory to the applications a project group lists and project to one project. Clients sending "Accept: text/csv" get one row per application instead, streamed as it is written, with the images and URLs of an application separated by spaces
+// @Tags applications
+// @Produce json
+// @Produce text/csv
+// @Param project query string false "Only include the applications of this project"
+// @Param group query string false "Only include the applications listed by this project group"
+// @Success 200 {object} types.InventoryResponse "Application inventory"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 404 {object} types.ErrorResponse "Project group not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /inventory [get]
+func (s *Server) getInventory(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	project := strings.TrimSpace(b.String("project"))
+	groupName := strings.TrimSpace(b.String("group"))
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	var group *config.ProjectGroup
+	if groupName != "" {
+		var ok bool
+		if group, ok = s.filters(c).FindProjectGroup(groupName); !ok {
+			s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, fmt.Sprintf("Project group '%s' not found", groupName), "")
+			return
+		}
+	}
+
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	inventory := services.BuildInventory(applications, group, project, time.Now())
+	c.Writer.Header().Add("Vary", "Accept")
+	if prefersCSV(c) {
+		streamCSV(c, http.StatusOK, inventoryCSVHeader, inventoryRows(inventory))
+		return
+	}
+	c.JSON(http.StatusOK, inventory)
+}
+
+// inventoryRows yields one CSV row per application, sorted by project
+func inventoryRows(inventory types.InventoryResponse) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		projects := make([]string, 0, len(inventory.Projects))
+		for project := range inventory.Projects {
+			projects = append(projects, project)
+		}
+		sort.Strings(projects)
+
+		for _, project := range projects {
+			for _, app := range inventory.Projects[project].Applications {
+				row := []string{
+					project, app.Name, app.Namespace, app.Repo, app.Revision, app.Health,
+					strings.Join(app.Images, " "), strings.Join(app.IngressURLs, " "),
+				}
+				if !yield(row) {
+					return
+				}
+			}
+		}
+	}
+}
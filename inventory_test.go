@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// setupInventoryServer builds a server with applications in two projects of the Frontend
+// group and one project outside it, with values that need CSV quoting
+func setupInventoryServer() *Server {
+	server := setupTestServer()
+	server.config.ProjectGroups = []config.ProjectGroup{
+		{Name: "Frontend", Description: "Frontend apps", Projects: []string{"web-app", "mobile-app"}, IgnoredProjects: []string{"*-preview"}},
+	}
+	server.setupRouter()
+	mockService := server.argocdService.(*MockArgocdService)
+
+	app := func(name, project, repoURL, health string, images, urls []string) types.ArgocdApplication {
+		a := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"}, IngressURLs: urls}
+		a.Spec.Project = project
+		a.Spec.Source = types.ArgocdApplicationSource{RepoURL: repoURL, TargetRevision: "main"}
+		a.Status.Summary = &types.ArgocdApplicationSummary{Images: images}
+		a.Status.Health.Status = health
+		a.Status.Sync.Revision = "4f1c2d3"
+		return a
+	}
+	mockService.applications.Items = []types.ArgocdApplication{
+		app("web", "web-app", "https://github.com/company/web.git", "Healthy",
+			[]string{"nginx:1.27", "company/web:2.1.0"}, []string{"https://web.example.com", "https://web.example.com/search?q=a,b"}),
+		app("web-preview", "web-app", "https://github.com/company/web.git", "Progressing", []string{"company/web:2.2.0-rc1"}, nil),
+		app("ios", "mobile-app", `https://git.example.com/mobile/"ios"`, "Degraded", nil, nil),
+		app("billing", "payments", "git@github.com:company/billing.git", "Healthy", []string{"company/billing:1.0"}, nil),
+	}
+	return server
+}
+
+func TestGetInventory(t *testing.T) {
+	server := setupInventoryServer()
+
+	w := serveMethod(server, http.MethodGet, "/inventory?group=frontend", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response types.InventoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if response.ProjectCount != 2 || response.ApplicationCount != 2 {
+		t.Errorf("counts = %d projects, %d applications, want 2 and 2", response.ProjectCount, response.ApplicationCount)
+	}
+	web := response.Projects["web-app"].Applications
+	if len(web) != 1 || web[0].Name != "web" || len(web[0].Images) != 2 || len(web[0].IngressURLs) != 2 {
+		t.Errorf("web-app applications = %+v, want web with its images and URLs", web)
+	}
+	if _, ok := response.Projects["payments"]; ok {
+		t.Error("payments is outside the Frontend group and should not be listed")
+	}
+
+	w = serveMethod(server, http.MethodGet, "/inventory?project=payments", nil)
+	var payments types.InventoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &payments); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if len(payments.Projects) != 1 || len(payments.Projects["payments"].Applications) != 1 {
+		t.Errorf("projects = %+v, want only payments", payments.Projects)
+	}
+
+	w = serveMethod(server, http.MethodGet, "/inventory?group=unknown", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown group status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetInventoryCSVGolden(t *testing.T) {
+	server := setupInventoryServer()
+
+	w := serveMethod(server, http.MethodGet, "/inventory", map[string]string{"Accept": "text/csv"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv; charset=utf-8", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+
+	golden := filepath.Join("testdata", "inventory.golden.csv")
+	if *updateGolden {
+		if err := os.WriteFile(golden, w.Body.Bytes(), 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read %s (run with -update to create it): %v", golden, err)
+	}
+	if !bytes.Equal(w.Body.Bytes(), want) {
+		t.Errorf("CSV inventory differs from %s:\n%s", golden, w.Body.String())
+	}
+}
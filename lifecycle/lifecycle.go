@@ -0,0 +1,143 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Manager owns the server's background goroutines and coordinates an orderly shutdown:
+// long-lived handlers are told to drain first, then stop hooks run (e.g. the HTTP
+// server drains in-flight requests), background goroutines are cancelled and awaited,
+// and finally close hooks release what both of them used.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	drainOnce sync.Once
+	draining  chan struct{}
+
+	mu      sync.Mutex
+	hooks   []stopHook
+	closers []closeHook
+}
+
+// closeHook is a named function releasing a resource once everything has stopped
+type closeHook struct {
+	name string
+	fn   func() error
+}
+
+// stopHook is a named function run during shutdown
+type stopHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New creates a manager whose background context derives from parent
+func New(parent context.Context) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{
+		ctx:      ctx,
+		cancel:   cancel,
+		draining: make(chan struct{}),
+	}
+}
+
+// Context returns the context handed to background goroutines; it is cancelled on shutdown
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in a tracked goroutine; fn must return once its context is cancelled
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		fn(m.ctx)
+		log.Printf("Stopped %s", name)
+	}()
+}
+
+// OnStop registers a hook run during shutdown, after draining starts and before
+// background goroutines are cancelled. Hooks run in registration order.
+func (m *Manager) OnStop(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, stopHook{name: name, fn: fn})
+}
+
+// OnClose registers a hook run at the end of shutdown, once the stop hooks have run and
+// background goroutines have exited, such as closing a connection pool that requests and
+// background routines share. Hooks run in reverse registration order.
+func (m *Manager) OnClose(name string, fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, closeHook{name: name, fn: fn})
+}
+
+// Draining returns a channel closed as soon as shutdown begins. Long-lived handlers
+// select on it to finish their response before the grace period ends.
+func (m *Manager) Draining() <-chan struct{} {
+	return m.draining
+}
+
+// IsDraining reports whether shutdown has begun
+func (m *Manager) IsDraining() bool {
+	select {
+	case <-m.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// BeginShutdown signals long-lived handlers and readiness probes that shutdown has begun
+func (m *Manager) BeginShutdown() {
+	m.drainOnce.Do(func() {
+		close(m.draining)
+	})
+}
+
+// Shutdown drains handlers, runs the stop hooks, cancels background goroutines and
+// waits for them to exit. It returns an error if a hook failed or ctx expired first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.BeginShutdown()
+
+	m.mu.Lock()
+	hooks := append([]stopHook(nil), m.hooks...)
+	closers := append([]closeHook(nil), m.closers...)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+		}
+	}
+
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("background routines did not stop: %w", ctx.Err()))
+	}
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", closers[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
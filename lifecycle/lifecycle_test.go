@@ -0,0 +1,113 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	m := New(context.Background())
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	m.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		record("worker stopped")
+	})
+	m.OnStop("first", func(ctx context.Context) error {
+		if !m.IsDraining() {
+			t.Error("hooks should run after draining has begun")
+		}
+		if m.Context().Err() != nil {
+			t.Error("hooks should run before background goroutines are cancelled")
+		}
+		record("first hook")
+		return nil
+	})
+	m.OnStop("second", func(ctx context.Context) error {
+		record("second hook")
+		return nil
+	})
+	m.OnClose("pool", func() error {
+		record("pool closed")
+		return nil
+	})
+	m.OnClose("client", func() error {
+		record("client closed")
+		return nil
+	})
+
+	if m.IsDraining() {
+		t.Fatal("manager should not be draining before shutdown")
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := []string{"first hook", "second hook", "worker stopped", "client closed", "pool closed"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("shutdown events = %v, want %v", events, want)
+	}
+	select {
+	case <-m.Draining():
+	default:
+		t.Error("Draining() channel should be closed after shutdown")
+	}
+}
+
+func TestShutdownReportsHookErrors(t *testing.T) {
+	m := New(context.Background())
+	m.OnStop("http server", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	m.OnClose("cache backend", func() error {
+		return errors.New("closed twice")
+	})
+
+	err := m.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "http server: boom") || !strings.Contains(err.Error(), "cache backend: closed twice") {
+		t.Errorf("Shutdown() error = %v, want both hook errors", err)
+	}
+}
+
+func TestShutdownTimesOutOnStuckGoroutine(t *testing.T) {
+	m := New(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+
+	m.Go("stuck", func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestBeginShutdownIsIdempotent(t *testing.T) {
+	m := New(context.Background())
+	m.BeginShutdown()
+	m.BeginShutdown()
+
+	if !m.IsDraining() {
+		t.Error("IsDraining() should be true after BeginShutdown")
+	}
+	if m.Context().Err() != nil {
+		t.Error("BeginShutdown should not cancel background goroutines")
+	}
+}
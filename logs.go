@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// logsQueryParams are the query parameters of the application logs endpoint
+var logsQueryParams = []string{"container", "podName", "tailLines", "follow"}
+
+// getApplicationLogs handles the application logs endpoint
+// @Summary Get application logs
+// @Description Get the container logs of an application's pods from ArgoCD as plain text, one line per log entry. The logs are relayed while ArgoCD sends them rather than buffered. Following the logs is not supported, so follow must be false when set. tailLines defaults to and is capped by LOGS_MAX_TAIL_LINES. Applications in filtered projects are reported as not found. Only available when LOGS_ENDPOINT_ENABLED is true
+// @Tags applications
+// @Produce plain
+// @Param name path string true "Application name"
+// @Param container query string false "Container to read the logs of"
+// @Param podName query string false "Pod to read the logs of"
+// @Param tailLines query int false "Number of most recent lines to read per container"
+// @Param follow query bool false "Must be false; following logs is not supported"
+// @Success 200 {string} string "Log lines"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve logs from ArgoCD"
+// @Router /applications/{name}/logs [get]
+func (s *Server) getApplicationLogs(c *gin.Context) {
+	appName := c.Param("name")
+	if appName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Application name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	opts := types.ApplicationLogOptions{
+		Container: b.String("container"),
+		PodName:   b.String("podName"),
+		TailLines: b.Int("tailLines", s.config.LogsMaxTailLines, 1, s.config.LogsMaxTailLines),
+	}
+	// Followed logs would hold a connection to ArgoCD open indefinitely
+	b.Enum("follow", "false", "false")
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	// The read is bounded by UPSTREAM_TIMEOUT rather than a handler deadline, since
+	// relaying long logs can take a while
+	body, err := s.argocdService.StreamApplicationLogs(c.Request.Context(), appName, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get logs for application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve logs from ArgoCD", err)
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	buf := make([]byte, 32*1024)
+	for {
+		// Each read returns what ArgoCD has sent so far, which is flushed to the client
+		n, err := body.Read(buf)
+		if n > 0 {
+			c.Writer.Write(buf[:n])
+			c.Writer.Flush()
+		}
+		if err != nil {
+			// The status is already sent, so a failure can only end the response early. A
+			// client going away is not worth a log line.
+			if !errors.Is(err, io.EOF) && c.Request.Context().Err() == nil {
+				log.Printf("Failed to relay logs for application %s: %v", appName, err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+func setupLogsServer(maxTailLines int) (*Server, *MockArgocdService) {
+	server := setupTestServer()
+	server.config.LogsEndpointEnabled = true
+	server.config.LogsMaxTailLines = maxTailLines
+	mock := &MockArgocdService{config: server.config}
+	server.argocdService = mock
+	server.setupRouter()
+	return server, mock
+}
+
+func TestApplicationLogsStreamsChunks(t *testing.T) {
+	// ArgoCD sends the first chunk, then waits until the proxy's client has received it
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/applications/web":
+			json.NewEncoder(w).Encode(types.ArgocdApplication{
+				Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+				Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+			})
+		case "/applications/web/logs":
+			fmt.Fprintln(w, `{"result":{"content":"first line","podName":"web-1"}}`)
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintln(w, `{"result":{"content":"second line","podName":"web-1"}}`)
+			w.(http.Flusher).Flush()
+			fmt.Fprintln(w, `{"result":{"content":"third line","podName":"web-1"}}`)
+			fmt.Fprintln(w, `{"result":{"content":"","last":true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = upstream.URL
+	server.config.LogsEndpointEnabled = true
+	server.config.LogsMaxTailLines = 100
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+	proxy := httptest.NewServer(server.router)
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + "/applications/web/logs?container=web")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain; charset=utf-8", got)
+	}
+	if resp.ContentLength != -1 {
+		t.Errorf("Content-Length = %d, want a chunked response", resp.ContentLength)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	lines := make(chan string)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		if line != "first line\n" {
+			t.Fatalf("first line = %q, want %q", line, "first line\n")
+		}
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("the first chunk was not relayed before ArgoCD finished the response")
+	}
+
+	close(release)
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading the rest failed: %v", err)
+	}
+	if string(rest) != "second line\nthird line\n" {
+		t.Errorf("rest = %q, want the second and third lines", rest)
+	}
+}
+
+func TestApplicationLogsParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantOpts   types.ApplicationLogOptions
+	}{
+		{name: "defaults to the tail line cap", wantStatus: http.StatusOK, wantOpts: types.ApplicationLogOptions{TailLines: 200}},
+		{
+			name:       "selects the container and pod",
+			query:      "?container=web&podName=web-1&tailLines=20&follow=false",
+			wantStatus: http.StatusOK,
+			wantOpts:   types.ApplicationLogOptions{Container: "web", PodName: "web-1", TailLines: 20},
+		},
+		{name: "tail lines over the cap", query: "?tailLines=201", wantStatus: http.StatusBadRequest},
+		{name: "zero tail lines", query: "?tailLines=0", wantStatus: http.StatusBadRequest},
+		{name: "follow is refused", query: "?follow=true", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mock := setupLogsServer(200)
+			mock.application = types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "web"}}
+			mock.logs = "first line\nsecond line\n"
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/applications/web/logs"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(w.Body.String(), string(types.ErrorCodeInvalidParam)) {
+					t.Errorf("body = %s, want error code %s", w.Body.String(), types.ErrorCodeInvalidParam)
+				}
+				return
+			}
+			if mock.logOptions != tt.wantOpts {
+				t.Errorf("options = %+v, want %+v", mock.logOptions, tt.wantOpts)
+			}
+			if w.Body.String() != mock.logs {
+				t.Errorf("body = %q, want %q", w.Body.String(), mock.logs)
+			}
+		})
+	}
+}
+
+func TestApplicationLogsNotFound(t *testing.T) {
+	for name, err := range map[string]error{
+		"missing":  fmt.Errorf("application 'web' %w", services.ErrNotFound),
+		"filtered": fmt.Errorf("application 'web' belongs to filtered project 'test-api': %w", services.ErrFiltered),
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, mock := setupLogsServer(100)
+			mock.err = err
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/applications/web/logs", nil))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApplicationLogsDisabled(t *testing.T) {
+	server := setupTestServer()
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/applications/web/logs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without LOGS_ENDPOINT_ENABLED", w.Code)
+	}
+}
@@ -21,16 +21,28 @@
 // @host localhost:5001
 // @BasePath /
 
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description "Bearer " followed by ADMIN_TOKEN; only used by the /admin endpoints
+
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -40,12 +52,22 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"argocd-proxy/audit"
 	"argocd-proxy/auth"
+	"argocd-proxy/cache"
+	"argocd-proxy/clientstats"
 	"argocd-proxy/config"
-	_ "argocd-proxy/docs" // Import generated docs
+	"argocd-proxy/events"
+	"argocd-proxy/features"
+	"argocd-proxy/healthstate"
+	"argocd-proxy/lifecycle"
 	"argocd-proxy/metrics"
+	"argocd-proxy/params"
+	"argocd-proxy/redact"
 	"argocd-proxy/services"
+	"argocd-proxy/timing"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
 )
 
 // Version and build information, injected at build time via ldflags.
@@ -54,20 +76,56 @@ var (
 	BuildTime = "unknown"
 )
 
+// shutdownGracePeriod bounds how long shutdown waits for requests and background routines
+const shutdownGracePeriod = 30 * time.Second
+
 // Server holds the main server components
 type Server struct {
 	config        *config.Config
 	authService   types.AuthServiceInterface
 	argocdService types.ArgocdServiceInterface
+	// endpoints are the ARGOCD_API_URL URLs the services call, for /health
+	endpoints     *upstream.Endpoints
 	router        *gin.Engine
+	auditLogger   *audit.Logger
+	clientStats   *clientstats.Tracker
+	lifecycle     *lifecycle.Manager
+	responseCache *cache.LRU[string, *cachedResponse]
+	features      *features.Set
+	// healthState suppresses flaps of /health between degraded and healthy
+	healthState *healthstate.Machine
+	// groupings keeps the last resolved project grouping for /project-groups and /bootstrap
+	groupings config.GroupingCache
+	// disabledEndpoints are the route templates left out by DISABLED_ENDPOINTS
+	disabledEndpoints []string
+	maintenance       maintenanceMode
+	startTime         time.Time
+	// awaitingToken makes /readyz report not ready until ArgoCD issues the first token
+	awaitingToken atomic.Bool
 }
 
 func main() {
+	dumpSpec := flag.Bool("dump-openapi", false, "print the OpenAPI document as JSON and exit")
+	selfTest := flag.Bool("self-test", false, "probe every route against built-in ArgoCD fixtures, print a report and exit non-zero on failure")
+	flag.Parse()
+	if *dumpSpec {
+		if err := dumpOpenAPI(os.Stdout); err != nil {
+			log.Fatalf("Failed to write the OpenAPI document: %v", err)
+		}
+		return
+	}
+
 	// Load environment variables from .env file (for development)
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// The self-test uses the configuration from the environment with ArgoCD replaced by
+	// fixtures, so it runs without credentials or network access
+	if *selfTest {
+		os.Exit(selfTestMain(os.Stdout))
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -79,74 +137,253 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Mask credentials and tokens in all log output and upstream error bodies
+	redactor := redact.New(cfg.UpstreamErrorBodyLimit)
+	redactor.SetSecret("username", cfg.ArgocdUsername)
+	redactor.SetSecret("password", cfg.ArgocdPassword)
+	redactor.SetSecret("outbound proxy password", cfg.OutboundProxyPassword)
+	redactor.SetSecret("admin token", cfg.AdminToken)
+	redactor.SetSecret("token cache key", cfg.TokenCacheKey)
+	redactor.SetSecret("alert webhook URL", cfg.AlertWebhookURL)
+	for _, key := range cfg.APIKeys {
+		redactor.SetSecret("API key "+key.Name, key.Key)
+	}
+	for project, token := range cfg.ArgocdProjectTokens {
+		redactor.SetSecret("project token "+project, token)
+	}
+	redactor.SetSecret("client stats salt", cfg.ClientStatsSalt)
+	redactor.SetSecret("Redis password", cfg.RedisPassword)
+	redact.SetDefault(redactor)
+	log.SetOutput(redactor.Writer(os.Stderr))
+
+	for _, warning := range cfg.ProjectGroupWarnings() {
+		log.Printf("WARNING: PROJECT_GROUPS %s", warning)
+	}
+	for _, warning := range cfg.FeatureWarnings {
+		log.Printf("WARNING: FEATURES %s", warning)
+	}
+
+	// Configure metrics naming and buckets, then register build info
+	metrics.SetDefault(metrics.New(metrics.Options{
+		Namespace:       cfg.MetricsNamespace,
+		HTTPBuckets:     cfg.MetricsBuckets,
+		UpstreamBuckets: cfg.MetricsBuckets,
+		Exemplars:       cfg.MetricsExemplars,
+	}))
+	metrics.SetBuildInfo(Version, BuildTime)
+	for _, deprecation := range cfg.Deprecations {
+		log.Printf("WARNING: deprecated configuration key=%s replacement=%s ignored=%t", deprecation.Key, deprecation.Replacement, deprecation.Ignored)
+		metrics.SetConfigDeprecatedKey(deprecation.Key, deprecation.Replacement)
+	}
+	events.SetDefault(events.New(cfg.EventLogSize))
+
 	// Create server instance
 	server := &Server{
-		config: cfg,
+		config:    cfg,
+		lifecycle: lifecycle.New(context.Background()),
+		startTime: time.Now(),
 	}
 
-	// Initialize services
-	authSvc := auth.NewAuthService(cfg)
+	// Route all ArgoCD traffic through one transport honouring the outbound proxy settings
+	upstream.SetDefault(upstream.NewTransport(cfg))
+	log.Printf("ArgoCD requests use %s", upstream.DescribeProxy(cfg))
+
+	// Initialize services. The login and the API calls share the ArgoCD URLs, so that
+	// they fail over together.
+	server.endpoints = upstream.NewEndpointsFor(cfg)
+	authSvc := auth.NewAuthService(cfg, server.endpoints)
 	server.authService = authSvc
-	server.argocdService = services.NewArgocdService(cfg, authSvc)
+	argocdSvc := services.NewArgocdService(cfg, authSvc, server.endpoints)
+	server.argocdService = argocdSvc
+	server.lifecycle.OnClose("cache backend", argocdSvc.Close)
 
-	// Register build info metric
-	metrics.SetBuildInfo(Version, BuildTime)
+	// Pre-warm the caches from another replica's snapshot before the first ArgoCD call
+	importCacheSnapshot(server.lifecycle.Context(), cfg, argocdSvc)
+
+	// Refuse to start with credentials ArgoCD does not accept, or stay unready until it
+	// accepts them
+	if cfg.StartupRequireAuth {
+		if err := server.requireFirstToken(); err != nil {
+			log.Fatalf("Failed to authenticate with ArgoCD: %v", err)
+		}
+	} else {
+		server.gateReadinessOnFirstToken()
+	}
+
+	// Open the audit log sink when auditing is enabled
+	if cfg.AuditLog {
+		server.auditLogger, err = audit.Open(cfg.AuditLogPath, cfg.AuditLogMaxSize, cfg.AuditLogMaxBackups)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer server.auditLogger.Close()
+	}
+
+	// Track per-client request statistics, decaying them in the background
+	if cfg.ClientStats {
+		server.clientStats = clientstats.New(clientstats.Options{
+			MaxClients:  cfg.ClientStatsMaxClients,
+			HalfLife:    cfg.ClientStatsHalfLife,
+			IdleTimeout: cfg.ClientStatsIdleTimeout,
+			TopRoutes:   clientStatsTopRoutes,
+			Salt:        cfg.ClientStatsSalt,
+		})
+		server.lifecycle.Go("client stats decay", server.clientStats.Run)
+	}
 
 	// Setup router and middleware
 	server.setupRouter()
 
 	// Start background token refresh routine
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	server.authService.StartTokenRefreshRoutine(ctx)
+	server.lifecycle.Go("token refresh routine", server.authService.RunTokenRefreshRoutine)
+
+	// Probe upstream API compatibility at startup and every COMPAT_CHECK_INTERVAL
+	server.lifecycle.Go("compatibility probe", func(ctx context.Context) {
+		argocdSvc.RunCompatibilityChecks(ctx, cfg.CompatCheckInterval)
+	})
+
+	// Start the background application poller when POLL_INTERVAL is set
+	if cfg.PollInterval > 0 {
+		server.lifecycle.Go("application poller", services.NewPoller(argocdSvc, cfg.PollInterval).Run)
+	}
 
 	// Start server with graceful shutdown
-	server.start(ctx, cancel)
+	server.start()
 }
 
 // setupRouter configures the Gin router with all routes and middleware
 func (s *Server) setupRouter() {
+	s.features = s.newFeatureSet()
+	s.healthState = s.newHealthState()
 	s.router = gin.New()
+	s.disabledEndpoints = nil
+	// Trailing slashes are handled by ServeHTTP and route segments match case-sensitively,
+	// so gin never answers with a redirect
+	s.router.RedirectTrailingSlash = false
+	s.router.RedirectFixedPath = false
+
+	// Forwarded client IP headers are only honoured from configured proxies, so the access
+	// log, audit log and anything else using c.ClientIP() cannot be spoofed by clients
+	if err := s.router.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies %v, trusting none: %v", s.config.TrustedProxies, err)
+		_ = s.router.SetTrustedProxies(nil)
+	}
 
 	// Add middleware
+	if !s.config.SecurityHeadersDisabled {
+		s.router.Use(s.securityHeadersMiddleware())
+	}
+	s.router.Use(requestIDMiddleware())
 	s.router.Use(gin.Logger())
 	s.router.Use(gin.Recovery())
 	s.router.Use(metrics.GinMiddleware())
+	s.router.Use(s.requestTimingMiddleware())
+	s.router.Use(cacheProvenanceMiddleware())
+	s.router.Use(s.featuresMiddleware())
+	s.router.Use(s.filtersMiddleware())
+	if s.auditLogger != nil {
+		s.router.Use(audit.Middleware(s.auditLogger, s.config.AuditLogExclude))
+	}
+	if s.clientStats != nil {
+		s.router.Use(clientstats.Middleware(s.clientStats))
+	}
+	if len(s.config.APIKeys) > 0 {
+		s.router.Use(s.clientAuthMiddleware())
+	}
+	if s.config.UpstreamImpersonationHeader != "" {
+		s.router.Use(s.impersonationMiddleware())
+	}
+	s.router.Use(headMiddleware())
+	s.router.Use(s.queryParamsMiddleware())
+	s.router.Use(s.maintenanceMiddleware())
+	s.router.Use(s.schemaProfileMiddleware())
+	if s.config.ResponseCacheTTL > 0 {
+		s.responseCache = cache.NewLRU[string, *cachedResponse](s.config.ResponseCacheSize, s.config.ResponseCacheTTL)
+		s.router.Use(s.responseCacheMiddleware())
+	}
+	// Runs inside the response cache, which stores each format's output separately
+	if len(s.config.ResponseFormats) > 0 {
+		s.router.Use(s.compatMiddleware())
+	}
 
 	// CORS configuration
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowMethods = []string{"GET", "HEAD", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	corsConfig.ExposeHeaders = []string{"Content-Length"}
+	corsConfig.AllowMethods = []string{"GET", "HEAD", "PUT", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", apiKeyHeader, responseFormatHeader}
+	corsConfig.ExposeHeaders = []string{"Content-Length", "ETag", requestIDHeader, resourceVersionHeader, projectGroupsHashHeader, cacheHeader, cacheAgeHeader, truncatedHeader, totalCountHeader, canonicalLinkHeader}
 	s.router.Use(cors.New(corsConfig))
 
 	// API routes (no prefix)
-	s.router.GET("/health", s.healthCheck)
-	s.router.GET("/project-groups", s.getProjectGroups)
-	s.router.GET("/projects", s.getProjects)
-	s.router.GET("/applications", s.getApplications)
-	s.router.GET("/applications/:name", s.getApplication)
-	s.router.GET("/groups/:group/applications", s.getApplicationsByGroup)
-	s.router.GET("/projects/:project/applications", s.getApplicationsByProject)
+	s.readRoute("/health", s.healthCheck)
+	s.readRoute("/health/history", s.healthHistory)
+	s.readRoute("/readyz", s.readinessCheck)
+	s.readRoute("/info", s.getInfo)
+	s.readRoute("/schemas", s.getSchemas)
+	s.readRoute("/project-groups", s.getProjectGroups)
+	s.readRoute("/project-groups/export", s.exportProjectGroups)
+	s.readRoute("/projects", s.getProjects)
+	s.readRoute("/applications", s.getApplications)
+	s.readRoute("/applications/names", s.getApplicationNames)
+	s.readRoute("/applications/recent", s.getRecentApplications)
+	s.readRoute("/applications/auto-sync", s.getAutoSyncApplications)
+	s.readRoute("/applications/:name", s.getApplication)
+	s.readRoute("/applications/:name/sync-windows", s.getApplicationSyncWindows)
+	s.readRoute("/applications/:name/diff", s.getApplicationDiff)
+	s.readRoute("/applications/:name/deploy-stats", s.getApplicationDeployStats)
+	s.readRoute("/applications/:name/notifications", s.getApplicationNotifications)
+	s.readRoute("/groups/:group/applications", s.getApplicationsByGroup)
+	s.readRoute("/groups/:group/drift", s.getGroupDrift)
+	s.readRoute("/groups/:group/deploy-stats", s.getGroupDeployStats)
+	s.readRoute("/projects/:project", s.getProject)
+	s.readRoute("/projects/:project/roles", s.getProjectRoles)
+	s.readRoute("/projects/:project/applications", s.getApplicationsByProject)
+	s.readRoute("/projects/:project/destinations", s.getProjectDestinations)
+	s.readRoute("/bootstrap", s.getBootstrap)
+	s.readRoute("/permissions", s.getPermissions)
+	if s.ownerAnnotation() != "" {
+		s.readRoute("/owners", s.getOwners)
+	}
+	s.readRoute("/topology/repositories", s.getRepositoryTopology)
+	s.readRoute("/inventory", s.getInventory)
+	if s.config.LogsEndpointEnabled {
+		s.readRoute("/applications/:name/logs", s.getApplicationLogs)
+	}
+
+	// Admin endpoints, only registered when ADMIN_TOKEN is set
+	s.adminRoutes()
 
 	// Prometheus metrics
-	s.router.GET("/metrics", metrics.Handler())
+	s.readRoute("/metrics", metrics.Handler())
 
-	// Swagger documentation
-	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Swagger documentation, served for the externally visible address
+	if s.config.SwaggerEnabled {
+		configureSwaggerInfo(s.config)
+		s.routes(&s.router.RouterGroup).GET(s.swaggerPath()+"/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		s.readRoute(openAPIJSONPath, s.getOpenAPIJSON)
+		s.readRoute(openAPIYAMLPath, s.getOpenAPIYAML)
+	}
 
-	// Handle non-existent API routes
+	// Known paths called with a method they do not serve get 405 with an Allow header,
+	// which gin fills in; unknown paths get 404
+	s.router.HandleMethodNotAllowed = true
+	s.router.NoMethod(s.handleMethodNotAllowed)
 	s.router.NoRoute(s.handleNotFound)
+
+	s.warnUnknownFeatureRoutes()
+	s.reportDisabledEndpoints()
 }
 
 // healthCheck handles the health check endpoint
 // @Summary Health check
-// @Description Get the health status of the ArgoCD proxy server
+// @Description Get the health status of the ArgoCD proxy server. During maintenance mode status is maintenance, with the window in maintenance, and ArgoCD is not checked. A failed ArgoCD check only fails the probe after HEALTH_FAILURE_THRESHOLD consecutive failures, and a failing probe passes again after HEALTH_RECOVERY_THRESHOLD consecutive passed checks; healthState reports the debounced state, the consecutive counts and the recent transitions. With DEEP_HEALTH enabled, argocdComponents reports the API server, repo server and application controller; a degraded component sets status to degraded without failing the probe. Repeated background token refresh failures set tokenStatus.failing and fail the probe
 // @Tags health
 // @Accept json
 // @Produce json
+// @Param verbose query bool false "Include last successful upstream fetch times and the latest compatibility probe"
+// @Param raw query bool false "Report the result of this ArgoCD check alone instead of the debounced state, for debugging"
 // @Success 200 "Server is healthy"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
 // @Success 503 "Server is degraded"
 // @Router /health [get]
 func (s *Server) healthCheck(c *gin.Context) {
@@ -162,64 +399,224 @@ func (s *Server) healthCheck(c *gin.Context) {
 		ArgocdAPI:   "unknown",
 	}
 
-	// Check ArgoCD API connectivity
-	if err := s.argocdService.HealthCheck(ctx); err != nil {
-		log.Printf("ArgoCD health check failed: %v", err)
-		response.ArgocdAPI = fmt.Sprintf("error: %v", err)
+	b := params.NewBinder(c.Request.URL.Query())
+	verbose := b.Bool("verbose", false)
+	raw := b.Bool("raw", false)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	// ArgoCD is expected to be unavailable during maintenance, so it is not checked and
+	// the probe keeps passing
+	if window := s.maintenance.current(); window != nil {
+		maintenance := window.status()
+		response.Status = "maintenance"
+		response.Maintenance = &maintenance
+		if verbose {
+			upstream := s.argocdService.GetUpstreamStatus()
+			response.Upstream = &upstream
+			response.Compatibility = s.argocdService.GetCompatibility()
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Component checks run alongside the connectivity check so they share its 5-second budget
+	var components chan map[string]string
+	if features.Enabled(ctx, features.DeepHealth) {
+		components = make(chan map[string]string, 1)
+		go func() {
+			components <- s.argocdService.CheckComponents(ctx)
+		}()
+	}
+
+	// Check ArgoCD API connectivity. The check feeds the health state machine, which only
+	// turns degraded after repeated failures, unless the raw result was asked for.
+	healthErr := s.argocdService.HealthCheck(ctx)
+	if s.endpoints != nil {
+		response.ArgocdEndpoint = urlHost(s.endpoints.Active())
+	}
+	state := s.healthState.Observe(healthErr == nil)
+	response.HealthState = healthStateResponse(state)
+	degraded := state.State == healthstate.Degraded
+	if raw {
+		degraded = healthErr != nil
+	}
+	if components != nil {
+		response.ArgocdComponents = <-components
+		for _, status := range response.ArgocdComponents {
+			if status == services.ComponentStatusDegraded {
+				response.Status = "degraded"
+			}
+		}
+	}
+	if verbose {
+		upstream := s.argocdService.GetUpstreamStatus()
+		response.Upstream = &upstream
+		response.Compatibility = s.argocdService.GetCompatibility()
+	}
+	if healthErr != nil {
+		log.Printf("ArgoCD health check failed: %v", healthErr)
+		response.ArgocdAPI = fmt.Sprintf("error: %s", redact.String(healthErr.Error()))
+	} else {
+		// A degraded ArgoCD component or an incompatible ArgoCD API is reported in the body
+		// only; the proxy itself can still serve, so the probe keeps returning 200
+		response.ArgocdAPI = "healthy"
+		compatibility := s.argocdService.GetCompatibility()
+		if compatibility != nil && !compatibility.Compatible {
+			response.ArgocdAPI = fmt.Sprintf("incompatible: %s", strings.Join(compatibility.Problems, "; "))
+			response.Status = "degraded"
+		}
+	}
+	if degraded {
 		response.Status = "degraded"
 		c.JSON(http.StatusServiceUnavailable, response)
 		return
 	}
 
-	response.ArgocdAPI = "healthy"
+	// Background token refreshes failing repeatedly mean the credentials are broken and
+	// requests will fail once the current token expires, so the probe fails
+	if failing, _ := response.TokenStatus["failing"].(bool); failing {
+		response.Status = "degraded"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// healthHistory handles the health history endpoint
+// @Summary Health check history
+// @Description Get the most recent health checks, oldest first, with the uptime percentage over the window and the last status transition time
+// @Tags health
+// @Produce json
+// @Success 200 {object} types.HealthHistoryResponse "Recent health checks"
+// @Router /health/history [get]
+func (s *Server) healthHistory(c *gin.Context) {
+	c.JSON(http.StatusOK, s.argocdService.GetHealthHistory())
+}
+
+// readinessCheck handles the readiness probe endpoint
+// @Summary Readiness probe
+// @Description Reports whether the server accepts traffic; returns 503 as soon as shutdown begins, and until ArgoCD issues the first token unless STARTUP_REQUIRE_AUTH made startup wait for it
+// @Tags health
+// @Produce json
+// @Success 200 "Server is ready"
+// @Failure 503 {object} types.ReadinessResponse "Server is shutting down or awaiting its first ArgoCD token"
+// @Router /readyz [get]
+func (s *Server) readinessCheck(c *gin.Context) {
+	if s.lifecycle.IsDraining() {
+		c.JSON(http.StatusServiceUnavailable, types.ReadinessResponse{Status: "shutting down"})
+		return
+	}
+	if s.awaitingToken.Load() {
+		c.JSON(http.StatusServiceUnavailable, types.ReadinessResponse{Status: "awaiting authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, types.ReadinessResponse{Status: "ready"})
+}
+
+// projectGroupsHashHeader carries the content hash of the resolved project grouping so
+// clients can detect configuration drift without downloading the export
+const projectGroupsHashHeader = "X-Project-Groups-Hash"
+
 // getProjectGroups handles the project groups endpoint
 // @Summary Get project groups
-// @Description Get configured project groups and ungrouped projects from ArgoCD
+// @Description Get configured project groups and ungrouped projects from ArgoCD. Groups can be narrowed by name and paged with offset and limit; meta.total counts the groups matching the name before paging, and every page carries all ungrouped projects. The grouping is resolved once per change of the rules or the project names
 // @Tags projects
 // @Accept json
 // @Produce json
-// @Success 200 "Project groups response"
-// @Failure 502 "Failed to retrieve projects from ArgoCD"
+// @Param name query string false "Only include groups whose name matches this pattern, with * wildcards as in IGNORED_PROJECTS (case-insensitive)"
+// @Param offset query int false "Number of matching groups to skip (default 0)"
+// @Param limit query int false "Maximum number of groups to return (default 0 = all)"
+// @Success 200 {object} config.ProjectGroupsResponse "Project groups response"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
 // @Router /project-groups [get]
 func (s *Server) getProjectGroups(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
+	b := params.NewBinder(c.Request.URL.Query())
+	namePattern := b.String("name")
+	offset := b.Int("offset", 0, 0, math.MaxInt32)
+	limit := b.Int("limit", 0, 0, math.MaxInt32)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
 	// Get all project names for grouping
 	projectNames, err := s.argocdService.GetProjectNames(ctx)
 	if err != nil {
 		log.Printf("Failed to get project names: %v", err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
 		return
 	}
 
 	// Get project groups with ungrouped projects
-	response := s.config.GetProjectGroups(projectNames)
+	groups, hash := s.groupings.Resolve(s.filters(c), projectNames)
+	response := groups.Page(namePattern, offset, limit)
+	c.Header(projectGroupsHashHeader, hash)
+	c.Header(totalCountHeader, strconv.Itoa(response.Meta.Total))
 	c.JSON(http.StatusOK, response)
 }
 
+// exportProjectGroups handles the project groups export endpoint
+// @Summary Export resolved project groups
+// @Description Get the fully-resolved grouping as a canonical JSON document with a content hash: groups with sorted, de-duplicated projects, ungrouped projects, ignored patterns and the projects they hide. The document has no timestamps, so it can be committed to git and diffed; the hash matches the X-Project-Groups-Hash header of /project-groups
+// @Tags projects
+// @Produce json
+// @Success 200 {object} config.ProjectGroupsExport "Resolved project groups"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
+// @Router /project-groups/export [get]
+func (s *Server) exportProjectGroups(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	projectNames, err := s.argocdService.GetProjectNames(ctx)
+	if err != nil {
+		log.Printf("Failed to get project names: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return
+	}
+
+	export := s.filters(c).ExportProjectGroups(projectNames)
+	c.Header(projectGroupsHashHeader, export.Hash)
+	c.JSON(http.StatusOK, export)
+}
+
 // getProjects handles the projects endpoint (proxy to ArgoCD)
 // @Summary Get filtered projects
 // @Description Get projects from ArgoCD with filtering applied based on ignored projects configuration
 // @Tags projects
 // @Accept json
 // @Produce json
+// @Param namePrefix query string false "Only include projects whose name starts with this prefix (case-insensitive)"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
 // @Success 200 "Filtered projects list"
-// @Failure 502 "Failed to retrieve projects from ArgoCD"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
 // @Router /projects [get]
 func (s *Server) getProjects(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
+	b := params.NewBinder(c.Request.URL.Query())
+	namePrefix := b.String("namePrefix")
+	limit := s.bindItemLimit(c, b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
 	projects, err := s.argocdService.GetFilteredProjects(ctx)
 	if err != nil {
 		log.Printf("Failed to get projects: %v", err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
 		return
 	}
+	projects = services.FilterProjectsByNamePrefix(projects, namePrefix)
 
 	// Return projects in the same format as ArgoCD
 	response := map[string]interface{}{
@@ -227,69 +624,363 @@ func (s *Server) getProjects(c *gin.Context) {
 		"kind":       "List",
 		"items":      projects,
 	}
+	if meta, ok := truncated(c, len(projects), limit); ok {
+		response["items"] = projects[:limit]
+		response["meta"] = meta
+	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // getApplications handles the applications endpoint (proxy to ArgoCD with filtering)
 // @Summary Get filtered applications
-// @Description Get applications from ArgoCD with filtering applied based on ignored projects configuration
+// @Description Get applications from ArgoCD with filtering applied based on ignored projects configuration. The list carries a snapshotToken; passing it back as since returns only the applications added or changed after that snapshot and the names of those removed, or the whole list with full=true when the token is unknown or older than SNAPSHOT_HISTORY_DEPTH snapshots
 // @Tags applications
 // @Accept json
 // @Produce json
+// @Param namePrefix query string false "Only include applications whose name starts with this prefix (case-insensitive)"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Param sort query string false "Sort by name or created; applications without a creation timestamp sort last"
+// @Param order query string false "Sort order: asc (default) or desc"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
+// @Param watchAfter query string false "Hold the request until the application set differs from this X-Resource-Version (or upstream resourceVersion)"
+// @Param since query string false "snapshotToken of a previous response; only the changes since that snapshot are returned (not with watchAfter)"
 // @Success 200 "Filtered applications list"
-// @Failure 502 "Failed to retrieve applications from ArgoCD"
+// @Success 304 "Application set unchanged before the watch deadline"
+// @Failure 400 {object} types.ErrorResponse "Invalid filter parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
 // @Router /applications [get]
 func (s *Server) getApplications(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	filter := s.bindApplicationFilter(b)
+	order := bindApplicationSort(b)
+	limit := s.bindItemLimit(c, b)
+	watchAfter := b.String("watchAfter")
+	if watchAfter != "" {
+		b.Forbid(sinceQueryParam, "cannot be combined with watchAfter")
+	}
+	since := b.String(sinceQueryParam)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	if watchAfter != "" {
+		s.watchApplications(c, filter, order, limit, watchAfter)
+		return
+	}
+
+	applications, token, err := s.argocdService.GetApplicationsSnapshot(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+	c.Header(resourceVersionHeader, services.ApplicationsHash(applications))
+
+	// Changes are computed on the filtered lists, so applications leaving the filter are
+	// reported as removed and those entering it as added
+	var removed []types.ApplicationRef
+	full := false
+	if since != "" {
+		if previous, ok := s.argocdService.ApplicationsSnapshot(since); ok {
+			applications.Items, removed = services.DiffApplications(
+				filterApplications(ctx, filter, previous).Items, filterApplications(ctx, filter, applications).Items)
+		} else {
+			full = true
+		}
+	}
+
+	response := applicationListResponse(ctx, applications, filter, order)
+	response.SnapshotToken = token
+	response.Full = full
+	response.Removed = removed
+	c.JSON(http.StatusOK, truncateApplications(c, response, limit))
+}
+
+// sinceQueryParam requests the application changes since a snapshotToken
+const sinceQueryParam = "since"
+
+// getApplicationNames handles the application names endpoint used for completion
+// @Summary Get application names
+// @Description Get the sorted names of the filtered applications, without the application details
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param namePrefix query string false "Only include applications whose name starts with this prefix (case-insensitive)"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
+// @Success 200 {array} string "Sorted application names"
+// @Failure 400 {object} types.ErrorResponse "Invalid filter parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /applications/names [get]
+func (s *Server) getApplicationNames(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	filter := s.bindApplicationFilter(b)
+	limit := s.bindItemLimit(c, b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, truncateNames(c, services.ApplicationNames(filterApplications(ctx, filter, applications)), limit))
+}
+
+// getRecentApplications handles the recently created applications endpoint
+// @Summary Get recently created applications
+// @Description Get the filtered applications created within the given window, newest first, each with its age
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param since query string false "Creation window such as 24h or 7d (default 72h, at most 365d)"
+// @Param namePrefix query string false "Only include applications whose name starts with this prefix (case-insensitive)"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
+// @Success 200 "Recently created applications"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /applications/recent [get]
+func (s *Server) getRecentApplications(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
+	b := params.NewBinder(c.Request.URL.Query())
+	filter := s.bindApplicationFilter(b)
+	since := b.Duration("since", 72*time.Hour, time.Second, 365*24*time.Hour)
+	limit := s.bindItemLimit(c, b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
 	applications, err := s.argocdService.GetApplications(ctx)
 	if err != nil {
 		log.Printf("Failed to get applications: %v", err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, applications)
+	now := time.Now()
+	recent := services.RecentApplications(filterApplications(ctx, filter, applications), since, now)
+	c.JSON(http.StatusOK, truncateApplications(c, services.WithApplicationAges(recent, now), limit))
 }
 
 // getApplication handles the specific application endpoint (proxy to ArgoCD)
 // @Summary Get specific application
-// @Description Get a specific application by name from ArgoCD
+// @Description Get a specific application by name from ArgoCD. With raw=true the upstream JSON is returned verbatim, including fields the proxy does not model, and without the proxy's derived fields such as ingressUrls
 // @Tags applications
 // @Accept json
 // @Produce json
 // @Param name path string true "Application name"
+// @Param raw query bool false "Return the upstream application object verbatim (default false)"
 // @Success 200 "Application details"
-// @Failure 400 "Application name is required"
-// @Failure 404 "Application not found"
-// @Failure 502 "Failed to retrieve application from ArgoCD"
+// @Failure 400 {object} types.ErrorResponse "Application name is required or invalid query parameters"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve application from ArgoCD"
 // @Router /applications/{name} [get]
 func (s *Server) getApplication(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
 	appName := c.Param("name")
 	if appName == "" {
-		s.errorResponse(c, http.StatusBadRequest, "Application name is required", "")
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Application name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	raw := b.Bool(rawQueryParam, false)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+	if raw {
+		s.getApplicationRaw(ctx, c, appName)
 		return
 	}
 
 	application, err := s.argocdService.GetApplication(ctx, appName)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "ignored project") {
-			s.errorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err.Error())
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve application from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.WithApplicationAge(application, time.Now()))
+}
+
+// rawQueryParam requests the upstream application object verbatim
+const rawQueryParam = "raw"
+
+// getApplicationRaw answers with the upstream JSON of an application, bypassing the
+// typed decode
+func (s *Server) getApplicationRaw(ctx context.Context, c *gin.Context, appName string) {
+	application, err := s.argocdService.GetApplicationRaw(ctx, appName)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
 			return
 		}
 		log.Printf("Failed to get application %s: %v", appName, err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve application from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve application from ArgoCD", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, application)
 }
 
+// getApplicationSyncWindows handles the application sync windows endpoint
+// @Summary Get application sync windows
+// @Description Get the sync windows assigned to an application, the currently active allow and deny windows, and whether it can sync now
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param name path string true "Application name"
+// @Success 200 "Application sync windows"
+// @Failure 400 {object} types.ErrorResponse "Application name is required"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve sync windows from ArgoCD"
+// @Router /applications/{name}/sync-windows [get]
+func (s *Server) getApplicationSyncWindows(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	appName := c.Param("name")
+	if appName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Application name is required", "")
+		return
+	}
+
+	windows, err := s.argocdService.GetApplicationSyncWindows(ctx, appName)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get sync windows for application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve sync windows from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// getApplicationDiff handles the application diff summary endpoint
+// @Summary Get application diff summary
+// @Description Get which managed resources a sync would add, modify or prune, without the manifests themselves
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param name path string true "Application name"
+// @Success 200 {object} types.ApplicationDiff "Application diff summary"
+// @Failure 400 {object} types.ErrorResponse "Application name is required"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve managed resources from ArgoCD"
+// @Router /applications/{name}/diff [get]
+func (s *Server) getApplicationDiff(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	appName := c.Param("name")
+	if appName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Application name is required", "")
+		return
+	}
+
+	diff, err := s.argocdService.GetApplicationDiff(ctx, appName)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get diff for application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve managed resources from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// getApplicationNotifications handles the application notifications endpoint
+// @Summary Get application notification subscriptions
+// @Description Get the ArgoCD notifications subscriptions of an application, parsed from its notifications.argoproj.io/subscribe.<trigger>.<service> and subscribe.<service> annotations with one entry per channel. hasSubscriptions tells whether alerting is wired up; annotations that cannot be parsed are listed under malformed
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param name path string true "Application name"
+// @Success 200 {object} types.ApplicationNotifications "Application notification subscriptions"
+// @Failure 400 {object} types.ErrorResponse "Application name is required"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve application from ArgoCD"
+// @Router /applications/{name}/notifications [get]
+func (s *Server) getApplicationNotifications(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	appName := c.Param("name")
+	if appName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Application name is required", "")
+		return
+	}
+
+	application, err := s.argocdService.GetApplication(ctx, appName)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve application from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.ApplicationNotifications(application))
+}
+
 // getApplicationsByGroup handles getting applications from a specific project group
 // @Summary Get applications by project group
 // @Description Get all applications from a configured project group
@@ -297,128 +988,598 @@ func (s *Server) getApplication(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param group path string true "Project group name"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Param sort query string false "Sort by name or created; applications without a creation timestamp sort last"
+// @Param order query string false "Sort order: asc (default) or desc"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
 // @Success 200 "Applications from the specified group"
-// @Failure 400 "Invalid group name"
-// @Failure 404 "Project group not found"
-// @Failure 502 "Failed to retrieve applications from ArgoCD"
+// @Failure 400 {object} types.ErrorResponse "Invalid group name"
+// @Failure 404 {object} types.ErrorResponse "Project group not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
 // @Router /groups/{group}/applications [get]
 func (s *Server) getApplicationsByGroup(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
-	groupName := c.Param("group")
+	groupName := strings.TrimSpace(c.Param("group"))
 	if groupName == "" {
-		s.errorResponse(c, http.StatusBadRequest, "Group name is required", "")
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Group name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	filter := s.bindApplicationFilter(b)
+	order := bindApplicationSort(b)
+	limit := s.bindItemLimit(c, b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
 		return
 	}
 
-	applications, err := s.argocdService.GetApplicationsByGroup(ctx, groupName, s.config)
+	applications, err := s.argocdService.GetApplicationsByGroup(ctx, groupName)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			s.errorResponse(c, http.StatusNotFound, fmt.Sprintf("Project group '%s' not found", groupName), err.Error())
+		if errors.Is(err, services.ErrGroupNotFound) {
+			s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, fmt.Sprintf("Project group '%s' not found", groupName), err.Error())
 			return
 		}
 		log.Printf("Failed to get applications for group %s: %v", groupName, err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, truncateApplications(c, applicationListResponse(ctx, applications, filter, order), limit))
+}
+
+// getGroupDrift handles the group drift report endpoint
+// @Summary Get group drift report
+// @Description Get the out-of-sync applications of a project group with their revisions and last operation time
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param group path string true "Project group name"
+// @Success 200 "Drift report for the specified group"
+// @Failure 400 {object} types.ErrorResponse "Invalid group name"
+// @Failure 404 {object} types.ErrorResponse "Project group not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /groups/{group}/drift [get]
+func (s *Server) getGroupDrift(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	groupName := strings.TrimSpace(c.Param("group"))
+	if groupName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Group name is required", "")
+		return
+	}
+
+	applications, err := s.argocdService.GetApplicationsByGroup(ctx, groupName)
+	if err != nil {
+		if errors.Is(err, services.ErrGroupNotFound) {
+			s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, fmt.Sprintf("Project group '%s' not found", groupName), err.Error())
+			return
+		}
+		log.Printf("Failed to get drift report for group %s: %v", groupName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	// Report the canonical configured group name rather than the requested spelling
+	if group, ok := s.filters(c).FindProjectGroup(groupName); ok {
+		groupName = group.Name
+	}
+
+	c.JSON(http.StatusOK, services.BuildGroupDriftReport(groupName, applications))
+}
+
+// Default and maximum ?window= of the deployment statistics endpoints
+const (
+	defaultDeployStatsWindow = 30 * 24 * time.Hour
+	maxDeployStatsWindow     = 365 * 24 * time.Hour
+)
+
+// getApplicationDeployStats handles the application deployment statistics endpoint
+// @Summary Get application deployment statistics
+// @Description Get how often an application was deployed within the window, from its ArgoCD revision history: deployment count, deployments per day, mean interval and last deployment. truncated is set when ArgoCD's retained history may not cover the whole window.
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param name path string true "Application name"
+// @Param window query string false "Time window such as 7d or 72h (default 30d, at most 365d)"
+// @Success 200 {object} types.ApplicationDeployStats "Deployment statistics"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 404 {object} types.ErrorResponse "Application not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve application from ArgoCD"
+// @Router /applications/{name}/deploy-stats [get]
+func (s *Server) getApplicationDeployStats(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	appName := c.Param("name")
+	b := params.NewBinder(c.Request.URL.Query())
+	window := b.Duration("window", defaultDeployStatsWindow, time.Hour, maxDeployStatsWindow)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	application, err := s.argocdService.GetApplication(ctx, appName)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Application '%s' not found", appName), err)
+			return
+		}
+		log.Printf("Failed to get deployment statistics for application %s: %v", appName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve application from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.BuildApplicationDeployStats(application, window, time.Now()))
+}
+
+// getGroupDeployStats handles the group deployment statistics endpoint
+// @Summary Get group deployment statistics
+// @Description Get how often the applications of a project group were deployed within the window, per application and combined across the group
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Param group path string true "Project group name"
+// @Param window query string false "Time window such as 7d or 72h (default 30d, at most 365d)"
+// @Success 200 {object} types.GroupDeployStats "Deployment statistics for the group"
+// @Failure 400 {object} types.ErrorResponse "Invalid group name or query parameters"
+// @Failure 404 {object} types.ErrorResponse "Project group not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /groups/{group}/deploy-stats [get]
+func (s *Server) getGroupDeployStats(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	groupName := strings.TrimSpace(c.Param("group"))
+	if groupName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Group name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	window := b.Duration("window", defaultDeployStatsWindow, time.Hour, maxDeployStatsWindow)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	applications, err := s.argocdService.GetApplicationsByGroup(ctx, groupName)
+	if err != nil {
+		if errors.Is(err, services.ErrGroupNotFound) {
+			s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, fmt.Sprintf("Project group '%s' not found", groupName), err.Error())
+			return
+		}
+		log.Printf("Failed to get deployment statistics for group %s: %v", groupName, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, applications)
+	// Report the canonical configured group name rather than the requested spelling
+	if group, ok := s.filters(c).FindProjectGroup(groupName); ok {
+		groupName = group.Name
+	}
+
+	c.JSON(http.StatusOK, services.BuildGroupDeployStats(groupName, applications, window, time.Now()))
 }
 
 // getApplicationsByProject handles getting applications from a specific project
 // @Summary Get applications by project
-// @Description Get all applications from a specific ArgoCD project
+// @Description Get all applications from a specific ArgoCD project. Unknown projects are reported as not found, with a suggestion when the name is close to a visible project; projects hidden by the ignore rules are reported as not found without one. With strict=false the project is not checked and an unknown project yields an empty list
 // @Tags applications
 // @Accept json
 // @Produce json
 // @Param project path string true "Project name"
+// @Param strict query bool false "Answer 404 for unknown projects (default true)"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Param sort query string false "Sort by name or created; applications without a creation timestamp sort last"
+// @Param order query string false "Sort order: asc (default) or desc"
+// @Param maxItems query int false "Override MAX_RESPONSE_ITEMS for this request (0 = unlimited); requires the admin token"
 // @Success 200 "Applications from the specified project"
-// @Failure 400 "Invalid project name"
-// @Failure 502 "Failed to retrieve applications from ArgoCD"
+// @Failure 400 {object} types.ErrorResponse "Invalid project name"
+// @Failure 404 {object} types.ErrorResponse "Project not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
 // @Router /projects/{project}/applications [get]
 func (s *Server) getApplicationsByProject(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	ctx, cancel := s.requestContext(c.Request.Context())
 	defer cancel()
 
 	projectName := c.Param("project")
 	if projectName == "" {
-		s.errorResponse(c, http.StatusBadRequest, "Project name is required", "")
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Project name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	strict := b.Bool(strictQueryParam, true)
+	filter := s.bindApplicationFilter(b)
+	order := bindApplicationSort(b)
+	limit := s.bindItemLimit(c, b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	if strict && !s.requireProject(ctx, c, projectName) {
 		return
 	}
 
 	applications, err := s.argocdService.GetApplicationsByProject(ctx, projectName)
 	if err != nil {
 		log.Printf("Failed to get applications for project %s: %v", projectName, err)
-		s.errorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err.Error())
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, applications)
+	c.JSON(http.StatusOK, truncateApplications(c, applicationListResponse(ctx, applications, filter, order), limit))
+}
+
+// applicationFilterQueryParams lists the query parameters consumed by bindApplicationFilter
+var applicationFilterQueryParams = []string{"namePrefix", "image", "imageMatch", "health", "sync", "includeDeleting", "destCluster", "owner", "labelSelector", "annotationSelector", "autoSync"}
+
+// getProjectDestinations handles the project destinations endpoint
+// @Summary Get project destinations
+// @Description Get the deduplicated destinations declared by a project merged with those its applications deploy to; destinations not permitted by the project spec are flagged as undeclared. With partial=true a failure to read either the project or its applications still answers 200 with the portion that could be read and a warnings array naming the missing one
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project path string true "Project name"
+// @Param partial query bool false "Answer with the available data and warnings when part of it cannot be read from ArgoCD (default false)"
+// @Success 200 "Reconciled project destinations"
+// @Failure 400 {object} types.ErrorResponse "Invalid project name"
+// @Failure 404 {object} types.ErrorResponse "Project not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve data from ArgoCD"
+// @Router /projects/{project}/destinations [get]
+func (s *Server) getProjectDestinations(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	projectName := c.Param("project")
+	if projectName == "" {
+		s.errorResponse(c, http.StatusBadRequest, types.ErrorCodeInvalidParam, "Project name is required", "")
+		return
+	}
+
+	b := params.NewBinder(c.Request.URL.Query())
+	partial := b.Bool(partialQueryParam, false)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	var (
+		project      types.ArgocdProject
+		applications types.ArgocdApplicationList
+	)
+	projectPortion := &aggregatePortion{name: "project", fetch: func(ctx context.Context) (err error) {
+		project, err = s.argocdService.GetProject(ctx, projectName)
+		return err
+	}}
+	applicationsPortion := &aggregatePortion{name: "applications", fetch: func(ctx context.Context) (err error) {
+		applications, err = s.argocdService.GetApplicationsByProject(ctx, projectName)
+		return err
+	}}
+	gatherPortions(ctx, projectPortion, applicationsPortion)
+
+	// A missing project is not a partial failure
+	if err := projectPortion.err; errors.Is(err, services.ErrNotFound) {
+		s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, fmt.Sprintf("Project '%s' not found", projectName), err.Error())
+		return
+	}
+	if err := projectPortion.err; err != nil {
+		log.Printf("Failed to get project %s: %v", projectName, err)
+		if !partial || applicationsPortion.err != nil {
+			s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+			return
+		}
+	}
+	if err := applicationsPortion.err; err != nil {
+		log.Printf("Failed to get applications for project %s: %v", projectName, err)
+		if !partial {
+			s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+			return
+		}
+	}
+
+	response := services.ReconcileProjectDestinations(project, applications.Items)
+	if projectPortion.err != nil {
+		// Without the project spec, declared destinations are unknown and nothing can be
+		// flagged as undeclared
+		response.Project = projectName
+		for i := range response.Destinations {
+			response.Destinations[i].Undeclared = false
+		}
+	}
+	for _, portion := range []*aggregatePortion{projectPortion, applicationsPortion} {
+		if portion.err != nil {
+			response.Warnings = append(response.Warnings, portion.warning())
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// bindApplicationFilter builds an application filter from the request query parameters
+func (s *Server) bindApplicationFilter(b *params.Binder) services.ApplicationFilter {
+	// Raw upstream objects would make list responses huge
+	b.Forbid(rawQueryParam, "is only supported on /applications/{name}")
+
+	imageMatch := b.Enum("imageMatch", string(services.ImageMatchSubstring),
+		string(services.ImageMatchSubstring), string(services.ImageMatchExact))
+
+	return services.ApplicationFilter{
+		NamePrefix: b.String("namePrefix"),
+		Image:      b.String("image"),
+		ImageMatch: services.ImageMatchMode(imageMatch),
+		Health:     b.EnumSet("health", params.HealthStatuses...),
+		Sync:       b.EnumSet("sync", params.SyncStatuses...),
+		// Applications pending deletion are listed unless ?includeDeleting=false
+		ExcludeDeleting: !b.Bool("includeDeleting", true),
+		DestCluster:     b.String("destCluster"),
+		Owner:           s.bindOwner(b),
+		OwnerAnnotation: s.ownerAnnotation(),
+		Labels:          b.LabelSelector("labelSelector"),
+		Annotations:     b.LabelSelector("annotationSelector"),
+		AutoSync:        b.OptionalBool("autoSync"),
+	}
+}
+
+// applicationSortQueryParams lists the query parameters consumed by bindApplicationSort
+var applicationSortQueryParams = []string{"sort", "order"}
+
+// bindApplicationSort builds an application sort from the ?sort= and ?order= parameters
+func bindApplicationSort(b *params.Binder) services.ApplicationSort {
+	field := b.Enum("sort", "", services.SortByName, services.SortByCreated)
+	order := b.Enum("order", "asc", "asc", "desc")
+	return services.ApplicationSort{Field: field, Descending: order == "desc"}
+}
+
+// applicationListResponse applies the client filter and sort to applications and stamps
+// each remaining application with its age
+func applicationListResponse(ctx context.Context, applications types.ArgocdApplicationList, filter services.ApplicationFilter, order services.ApplicationSort) types.ArgocdApplicationList {
+	return services.WithApplicationAges(order.Apply(filterApplications(ctx, filter, applications)), time.Now())
+}
+
+// filterApplications applies the client filter, timed as the request's filter phase
+func filterApplications(ctx context.Context, filter services.ApplicationFilter, applications types.ArgocdApplicationList) types.ArgocdApplicationList {
+	defer timing.Start(ctx, timing.PhaseFilter)()
+	return filter.Apply(applications)
+}
+
+// routeQueryParams lists the query parameters each route accepts when
+// STRICT_QUERY_PARAMS is enabled; routes not listed accept none
+var routeQueryParams = map[string][]string{
+	"/health":                          {"verbose", "raw"},
+	"/projects":                        {"namePrefix", maxItemsQueryParam},
+	"/applications/names":              append([]string{maxItemsQueryParam}, applicationFilterQueryParams...),
+	"/applications/recent":             append([]string{"since", maxItemsQueryParam}, applicationFilterQueryParams...),
+	"/applications/auto-sync":          applicationFilterQueryParams,
+	"/applications":                    append([]string{"watchAfter", sinceQueryParam, maxItemsQueryParam}, append(applicationFilterQueryParams, applicationSortQueryParams...)...),
+	"/groups/:group/applications":      append([]string{maxItemsQueryParam}, append(applicationSortQueryParams, applicationFilterQueryParams...)...),
+	"/applications/:name/deploy-stats": {"window"},
+	"/groups/:group/deploy-stats":      {"window"},
+	"/projects/:project/applications":  append([]string{maxItemsQueryParam, strictQueryParam}, append(applicationSortQueryParams, applicationFilterQueryParams...)...),
+	"/projects/:project/destinations":  {partialQueryParam},
+	"/projects/:project/roles":         {flattenPoliciesQueryParam},
+	"/bootstrap":                       {"view", "fields", partialQueryParam},
+	"/admin/explain":                   {"project", "application"},
+	"/admin/events":                    {"limit"},
+	"/admin/support-bundle":            {"format"},
+	"/applications/:name":              {rawQueryParam},
+	"/topology/repositories":           {"minApps"},
+	"/applications/:name/logs":         logsQueryParams,
+	"/inventory":                       {"project", "group"},
+	"/project-groups":                  {"name", "offset", "limit"},
+}
+
+// queryParamsMiddleware caps the query string length, validates ?upstreamError= and, in
+// strict mode, rejects query parameters the matched route does not understand
+func (s *Server) queryParamsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var allowed []string
+		// Unmatched routes fall through to the 404 handler untouched
+		if features.Enabled(c.Request.Context(), features.StrictQueryParams) && c.FullPath() != "" && !s.isSwaggerPath(c.FullPath()) {
+			allowed = routeQueryParams[c.FullPath()]
+			if allowed == nil {
+				allowed = []string{}
+			}
+			allowed = append(slices.Clip(allowed), upstreamErrorQueryParam)
+			if len(s.config.ResponseFormats) > 0 {
+				allowed = append(allowed, compatQueryParam)
+			}
+		}
+
+		if err := params.CheckQuery(c.Request.URL.RawQuery, s.config.MaxQueryLength, allowed); err != nil {
+			s.invalidParamsResponse(c, err)
+			c.Abort()
+			return
+		}
+		// ?upstreamError= is accepted by every route, so it is checked here rather than by
+		// each handler
+		b := params.NewBinder(c.Request.URL.Query())
+		b.Bool(upstreamErrorQueryParam, false)
+		if err := b.Err(); err != nil {
+			s.invalidParamsResponse(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestContext returns the context of an API request's ArgoCD calls, derived from the
+// inbound request and bounded by REQUEST_TIMEOUT
+func (s *Server) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.RequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.config.RequestTimeout)
 }
 
 // handleNotFound handles 404 errors for non-existent routes
 func (s *Server) handleNotFound(c *gin.Context) {
-	// Check if the requested path is likely an API route (not swagger or static files)
-	if !strings.HasPrefix(c.Request.URL.Path, "/swagger/") {
-		s.errorResponse(c, http.StatusNotFound, "Endpoint not found", "")
-		return
+	// Unknown swagger assets are answered by the swagger handler itself, so only API
+	// routes and, with SWAGGER_ENABLED=false, paths under SWAGGER_PATH end up here
+	message := "Endpoint not found"
+	path := c.Request.URL.Path
+	suggestion, ok := s.matchRoute(path, true)
+	if !ok && len(path) > 1 {
+		suggestion, ok = s.matchRoute(strings.TrimSuffix(path, "/"), true)
+	}
+	if ok && suggestion != path {
+		message = fmt.Sprintf("Endpoint not found, route paths are case-sensitive: did you mean %s?", suggestion)
 	}
+	s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, message, "")
+}
 
-	// For swagger routes, return a simple 404
-	c.String(http.StatusNotFound, "404 page not found")
+// handleMethodNotAllowed handles requests to an existing path with a method it does not
+// serve. Gin has already set the Allow header to the path's methods.
+func (s *Server) handleMethodNotAllowed(c *gin.Context) {
+	allowed := c.Writer.Header().Get("Allow")
+	s.errorResponse(c, http.StatusMethodNotAllowed, types.ErrorCodeMethodNotAllowed,
+		fmt.Sprintf("Method %s not allowed, use %s", c.Request.Method, allowed), "")
 }
 
 // errorResponse sends a standardized error response
-func (s *Server) errorResponse(c *gin.Context, statusCode int, message, details string) {
+func (s *Server) errorResponse(c *gin.Context, statusCode int, code types.ErrorCode, message, details string) {
+	s.writeErrorResponse(c, statusCode, code, message, details, nil)
+}
+
+// writeErrorResponse renders an error response, including upstream when it is not nil
+func (s *Server) writeErrorResponse(c *gin.Context, statusCode int, code types.ErrorCode, message, details string, upstream *types.UpstreamErrorDetails) {
+	// Reads needing ArgoCD while maintenance mode suppresses calls are not upstream failures
+	if code == types.ErrorCodeMaintenance {
+		if window := s.maintenance.current(); window != nil {
+			s.maintenanceResponse(c, window)
+			return
+		}
+		statusCode = http.StatusServiceUnavailable
+	}
+	// Applications whose circuit breaker is open are retried after the cooldown
+	if code == types.ErrorCodeCircuitOpen {
+		statusCode = http.StatusServiceUnavailable
+		s.setCircuitRetryAfter(c, c.Param("name"))
+	}
+
 	response := types.ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
-		Code:    statusCode,
+		Error:     http.StatusText(statusCode),
+		Message:   message,
+		Code:      statusCode,
+		ErrorCode: code,
+		Upstream:  upstream,
 	}
 
 	if details != "" && gin.Mode() == gin.DebugMode {
-		response.Message = fmt.Sprintf("%s: %s", message, details)
+		response.Message = fmt.Sprintf("%s: %s", message, redact.String(details))
 	}
 
-	c.JSON(statusCode, response)
+	s.renderError(c, response)
 }
 
-// start starts the HTTP server with graceful shutdown
-func (s *Server) start(ctx context.Context, cancel context.CancelFunc) {
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", s.config.Port),
-		Handler: s.router,
+// serviceErrorCode classifies an error returned by the ArgoCD service; anything not
+// recognized as a typed service error is reported as an upstream failure
+func serviceErrorCode(err error) types.ErrorCode {
+	switch {
+	case errors.Is(err, services.ErrFiltered):
+		return types.ErrorCodeFiltered
+	case errors.Is(err, services.ErrNotFound):
+		return types.ErrorCodeNotFound
+	case errors.Is(err, services.ErrUnauthorized):
+		return types.ErrorCodeUnauthorized
+	case errors.Is(err, services.ErrUpstreamTooLarge):
+		return types.ErrorCodeUpstreamTooLarge
+	case errors.Is(err, services.ErrRedirectNotAllowed):
+		return types.ErrorCodeUpstreamRedirect
+	case errors.Is(err, services.ErrSuppressed):
+		return types.ErrorCodeMaintenance
+	case errors.Is(err, services.ErrCircuitOpen):
+		return types.ErrorCodeCircuitOpen
+	default:
+		return types.ErrorCodeUpstreamDown
 	}
+}
 
-	// Start server in a goroutine
+// invalidParamsResponse sends a 400 response listing the offending query parameters
+func (s *Server) invalidParamsResponse(c *gin.Context, err error) {
+	response := types.ErrorResponse{
+		Error:     http.StatusText(http.StatusBadRequest),
+		Message:   err.Error(),
+		Code:      http.StatusBadRequest,
+		ErrorCode: types.ErrorCodeInvalidParam,
+	}
+
+	// params.Errors and config.CandidateErrors name the offending fields
+	var validationErrs interface{ Fields() []string }
+	if errors.As(err, &validationErrs) {
+		response.Fields = validationErrs.Fields()
+	}
+
+	s.renderError(c, response)
+}
+
+// start starts the HTTP server and shuts it down gracefully on SIGINT or SIGTERM
+func (s *Server) start() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.config.Port))
+	if err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+
+	log.Printf("Starting ArgoCD Proxy server %s", startupSummary(s.config.Port, s.runtimeInfo(time.Now())))
+
+	if err := s.serve(ctx, listener); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+		return
+	}
+	log.Println("Server exited gracefully")
+}
+
+// serve handles requests on listener until ctx is cancelled, then shuts down in order:
+// /readyz flips to 503 and long-lived handlers finish their responses, the HTTP server
+// drains in-flight requests, and finally background routines are stopped and awaited.
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{Handler: s}
+	s.lifecycle.OnStop("http server", srv.Shutdown)
+
+	serveErr := make(chan error, 1)
 	go func() {
-		log.Printf("Starting ArgoCD Proxy server on port %s", s.config.Port)
-		log.Printf("Health check available at: http://localhost:%s/health", s.config.Port)
-		log.Printf("Prometheus metrics available at: http://localhost:%s/metrics", s.config.Port)
-		log.Printf("Swagger documentation available at: http://localhost:%s/swagger/index.html", s.config.Port)
-		log.Printf("ArgoCD API URL: %s", s.config.ArgocdAPIURL)
-
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
+		serveErr <- srv.Serve(listener)
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		s.lifecycle.Shutdown(context.Background())
+		return fmt.Errorf("failed to serve: %w", err)
+	}
 
 	log.Println("Shutting down server...")
-	cancel() // Cancel the context to stop background routines
 
-	// Give outstanding requests 30 seconds to complete
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
+	// Give outstanding requests and background routines time to complete
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
-	} else {
-		log.Println("Server exited gracefully")
-	}
+	return s.lifecycle.Shutdown(shutdownCtx)
 }
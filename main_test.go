@@ -4,39 +4,57 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"argocd-proxy/audit"
 	"argocd-proxy/config"
+	"argocd-proxy/docs"
+	"argocd-proxy/lifecycle"
 	"argocd-proxy/metrics"
+	"argocd-proxy/redact"
+	"argocd-proxy/services"
+	"argocd-proxy/timing"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
 )
 
 // MockAuthService for testing HTTP handlers
 type MockAuthService struct {
 	token     string
 	err       error
-	callCount int
+	callCount atomic.Int32
+	// failing reports background token refreshes as failing
+	failing bool
 }
 
 func (m *MockAuthService) GetValidToken(ctx context.Context) (string, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	return m.token, m.err
 }
 
-func (m *MockAuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
-	m.callCount++
+func (m *MockAuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error) {
+	m.callCount.Add(1)
 	if m.err != nil {
 		return nil, m.err
 	}
 
-	var reqBody *bytes.Buffer
+	var reqBody io.Reader
 	if body != nil {
 		reqBody = bytes.NewBuffer(body)
 	}
@@ -56,11 +74,12 @@ func (m *MockAuthService) GetTokenStatus() map[string]interface{} {
 	return map[string]interface{}{
 		"hasToken": m.token != "",
 		"isValid":  m.err == nil,
+		"failing":  m.failing,
 	}
 }
 
-func (m *MockAuthService) StartTokenRefreshRoutine(ctx context.Context) {
-	// Mock implementation - do nothing
+func (m *MockAuthService) RunTokenRefreshRoutine(ctx context.Context) {
+	<-ctx.Done()
 }
 
 // MockArgocdService for testing
@@ -71,6 +90,34 @@ type MockArgocdService struct {
 	projectNames []string
 	err          error
 	healthErr    error
+
+	applicationsChanged chan struct{}
+	upstreamStatus      types.UpstreamStatus
+	lastUpstreamError   *types.UpstreamError
+	cacheStats          []types.CacheStatus
+	cacheSnapshot       types.CacheSnapshot
+	syncWindows         types.ApplicationSyncWindows
+	diff                types.ApplicationDiff
+	logs                string
+	logOptions          types.ApplicationLogOptions
+	healthHistory       types.HealthHistoryResponse
+	components          map[string]string
+	compatibility       *types.CompatibilityReport
+	applicationsCalls   int
+	invalidations       int
+	openCircuits        []types.ApplicationCircuit
+	config              *config.Config
+	// delay simulates slow upstream calls, reported to the request timing like real ones
+	delay time.Duration
+	// projectErr and projectAppsErr fail only GetProject or GetApplicationsByProject,
+	// for aggregates where one portion fails and another succeeds
+	projectErr     error
+	projectAppsErr error
+	// rawApplication is the upstream JSON returned by GetApplicationRaw
+	rawApplication json.RawMessage
+	// snapshotToken names the current application list; snapshots are the retained ones
+	snapshotToken string
+	snapshots     map[string]types.ArgocdApplicationList
 }
 
 func (m *MockArgocdService) GetProjects(ctx context.Context) ([]types.ArgocdProject, error) {
@@ -82,6 +129,11 @@ func (m *MockArgocdService) GetFilteredProjects(ctx context.Context) ([]types.Ar
 }
 
 func (m *MockArgocdService) GetApplications(ctx context.Context) (types.ArgocdApplicationList, error) {
+	m.applicationsCalls++
+	if m.delay > 0 {
+		time.Sleep(m.delay)
+		timing.AddUpstream(ctx, m.delay)
+	}
 	return m.applications, m.err
 }
 
@@ -90,11 +142,22 @@ func (m *MockArgocdService) GetApplication(ctx context.Context, name string) (ty
 		return types.ArgocdApplication{}, m.err
 	}
 	if m.application.Metadata.Name == "" {
-		return types.ArgocdApplication{}, fmt.Errorf("application '%s' not found", name)
+		return types.ArgocdApplication{}, fmt.Errorf("application '%s' %w", name, services.ErrNotFound)
 	}
 	return m.application, nil
 }
 
+func (m *MockArgocdService) GetApplicationRaw(ctx context.Context, name string) (json.RawMessage, error) {
+	application, err := m.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if m.rawApplication != nil {
+		return m.rawApplication, nil
+	}
+	return json.Marshal(application)
+}
+
 func (m *MockArgocdService) GetProjectNames(ctx context.Context) ([]string, error) {
 	return m.projectNames, m.err
 }
@@ -103,6 +166,18 @@ func (m *MockArgocdService) HealthCheck(ctx context.Context) error {
 	return m.healthErr
 }
 
+func (m *MockArgocdService) GetHealthHistory() types.HealthHistoryResponse {
+	return m.healthHistory
+}
+
+func (m *MockArgocdService) CheckComponents(ctx context.Context) map[string]string {
+	return m.components
+}
+
+func (m *MockArgocdService) GetCompatibility() *types.CompatibilityReport {
+	return m.compatibility
+}
+
 func (m *MockArgocdService) ExtractIngressURLs(ctx context.Context, appName string) ([]string, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -111,17 +186,20 @@ func (m *MockArgocdService) ExtractIngressURLs(ctx context.Context, appName stri
 	return []string{"https://example.com", "https://app.example.com"}, nil
 }
 
-func (m *MockArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string, cfg interface{}) (types.ArgocdApplicationList, error) {
+func (m *MockArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string) (types.ArgocdApplicationList, error) {
 	if m.err != nil {
 		return types.ArgocdApplicationList{}, m.err
 	}
+	if _, ok := m.config.Snapshot().FindProjectGroup(groupName); !ok {
+		return types.ArgocdApplicationList{}, fmt.Errorf("%w: '%s'", services.ErrGroupNotFound, groupName)
+	}
 	// Return mock applications for testing - in a real scenario this would filter by group
 	return m.applications, nil
 }
 
 func (m *MockArgocdService) GetApplicationsByProject(ctx context.Context, projectName string) (types.ArgocdApplicationList, error) {
-	if m.err != nil {
-		return types.ArgocdApplicationList{}, m.err
+	if err := errors.Join(m.err, m.projectAppsErr); err != nil {
+		return types.ArgocdApplicationList{}, err
 	}
 	// Filter applications by project for testing
 	var filteredApps []types.ArgocdApplication
@@ -138,6 +216,82 @@ func (m *MockArgocdService) GetApplicationsByProject(ctx context.Context, projec
 	}, nil
 }
 
+func (m *MockArgocdService) GetApplicationsSnapshot(ctx context.Context) (types.ArgocdApplicationList, string, error) {
+	applications, err := m.GetApplications(ctx)
+	return applications, m.snapshotToken, err
+}
+
+func (m *MockArgocdService) ApplicationsSnapshot(token string) (types.ArgocdApplicationList, bool) {
+	list, ok := m.snapshots[token]
+	return list, ok
+}
+
+func (m *MockArgocdService) ApplicationsChanged() <-chan struct{} {
+	return m.applicationsChanged
+}
+
+func (m *MockArgocdService) GetUpstreamStatus() types.UpstreamStatus {
+	return m.upstreamStatus
+}
+
+func (m *MockArgocdService) LastUpstreamError() *types.UpstreamError {
+	return m.lastUpstreamError
+}
+
+func (m *MockArgocdService) CacheStats() []types.CacheStatus {
+	return m.cacheStats
+}
+
+func (m *MockArgocdService) ExportCacheSnapshot() types.CacheSnapshot {
+	return m.cacheSnapshot
+}
+
+func (m *MockArgocdService) GetProject(ctx context.Context, name string) (types.ArgocdProject, error) {
+	if err := errors.Join(m.err, m.projectErr); err != nil {
+		return types.ArgocdProject{}, err
+	}
+	for _, project := range m.projects {
+		if project.Metadata.Name == name {
+			return project, nil
+		}
+	}
+	return types.ArgocdProject{}, fmt.Errorf("project '%s' %w", name, services.ErrNotFound)
+}
+
+func (m *MockArgocdService) GetApplicationSyncWindows(ctx context.Context, name string) (types.ApplicationSyncWindows, error) {
+	if _, err := m.GetApplication(ctx, name); err != nil {
+		return types.ApplicationSyncWindows{}, err
+	}
+	return m.syncWindows, nil
+}
+
+func (m *MockArgocdService) GetApplicationDiff(ctx context.Context, name string) (types.ApplicationDiff, error) {
+	if _, err := m.GetApplication(ctx, name); err != nil {
+		return types.ApplicationDiff{}, err
+	}
+	return m.diff, nil
+}
+
+func (m *MockArgocdService) StreamApplicationLogs(ctx context.Context, name string, opts types.ApplicationLogOptions) (io.ReadCloser, error) {
+	if _, err := m.GetApplication(ctx, name); err != nil {
+		return nil, err
+	}
+	m.logOptions = opts
+	return io.NopCloser(strings.NewReader(m.logs)), nil
+}
+
+func (m *MockArgocdService) InvalidateCaches() {
+	m.invalidations++
+}
+
+func (m *MockArgocdService) OpenCircuits() []types.ApplicationCircuit {
+	return m.openCircuits
+}
+
+func (m *MockArgocdService) ApplicationStateSince(namespace, name string) (types.ApplicationStateSince, bool) {
+	return types.ApplicationStateSince{}, false
+}
+
 func setupTestServer() *Server {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -151,11 +305,13 @@ func setupTestServer() *Server {
 	}
 
 	server := &Server{
-		config: cfg,
+		config:    cfg,
+		lifecycle: lifecycle.New(context.Background()),
+		startTime: time.Now(),
 	}
 
 	server.authService = &MockAuthService{token: "test-token"}
-	server.argocdService = &MockArgocdService{}
+	server.argocdService = &MockArgocdService{config: cfg}
 
 	server.setupRouter()
 
@@ -184,6 +340,13 @@ func TestHealthCheck(t *testing.T) {
 			expectedStatus: http.StatusServiceUnavailable,
 			expectedHealth: "degraded",
 		},
+		{
+			name:           "degraded service - token refresh failing",
+			authService:    &MockAuthService{token: "test-token", failing: true},
+			argocdService:  &MockArgocdService{},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: "degraded",
+		},
 	}
 
 	for _, tt := range tests {
@@ -218,6 +381,145 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestHealthCheckDeepComponents(t *testing.T) {
+	components := map[string]string{"api": "ok", "repoServer": "degraded", "applicationController": "ok"}
+
+	tests := []struct {
+		name           string
+		deepHealth     bool
+		argocdService  *MockArgocdService
+		expectedStatus int
+		expectedHealth string
+		wantComponents map[string]string
+	}{
+		{
+			name:           "disabled by default",
+			argocdService:  &MockArgocdService{components: components},
+			expectedStatus: http.StatusOK,
+			expectedHealth: "healthy",
+		},
+		{
+			name:           "all components ok",
+			deepHealth:     true,
+			argocdService:  &MockArgocdService{components: map[string]string{"api": "ok", "repoServer": "ok", "applicationController": "unknown"}},
+			expectedStatus: http.StatusOK,
+			expectedHealth: "healthy",
+			wantComponents: map[string]string{"api": "ok", "repoServer": "ok", "applicationController": "unknown"},
+		},
+		{
+			name:           "degraded component keeps probe passing",
+			deepHealth:     true,
+			argocdService:  &MockArgocdService{components: components},
+			expectedStatus: http.StatusOK,
+			expectedHealth: "degraded",
+			wantComponents: components,
+		},
+		{
+			name:           "api failure still reports components",
+			deepHealth:     true,
+			argocdService:  &MockArgocdService{healthErr: fmt.Errorf("ArgoCD unavailable"), components: components},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: "degraded",
+			wantComponents: components,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.DeepHealth = tt.deepHealth
+			server.argocdService = tt.argocdService
+			server.setupRouter()
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("healthCheck() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+
+			var response types.HealthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("healthCheck() invalid JSON response: %v", err)
+			}
+			if response.Status != tt.expectedHealth {
+				t.Errorf("healthCheck() health = %v, want %v", response.Status, tt.expectedHealth)
+			}
+			if !reflect.DeepEqual(response.ArgocdComponents, tt.wantComponents) {
+				t.Errorf("healthCheck() components = %v, want %v", response.ArgocdComponents, tt.wantComponents)
+			}
+		})
+	}
+}
+
+func TestHealthCheckCompatibility(t *testing.T) {
+	incompatible := &types.CompatibilityReport{
+		ArgocdVersion:  "v4.1.0",
+		SupportedRange: ">=2.4.0 <4.0.0",
+		Problems:       []string{"ArgoCD version v4.1.0 is outside the supported range >=2.4.0 <4.0.0"},
+	}
+
+	tests := []struct {
+		name              string
+		compatibility     *types.CompatibilityReport
+		query             string
+		expectedHealth    string
+		expectedAPIStatus string
+		wantReport        bool
+	}{
+		{"not probed yet", nil, "", "healthy", "healthy", false},
+		{"compatible", &types.CompatibilityReport{ArgocdVersion: "v2.10.4", Compatible: true}, "", "healthy", "healthy", false},
+		{"incompatible", incompatible, "", "degraded", "incompatible: " + incompatible.Problems[0], false},
+		{"verbose includes report", incompatible, "?verbose=true", "degraded", "incompatible: " + incompatible.Problems[0], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.argocdService = &MockArgocdService{compatibility: tt.compatibility}
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", "/health"+tt.query, nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("healthCheck() status = %v, want %v", w.Code, http.StatusOK)
+			}
+
+			var response types.HealthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("healthCheck() invalid JSON response: %v", err)
+			}
+			if response.Status != tt.expectedHealth {
+				t.Errorf("healthCheck() health = %v, want %v", response.Status, tt.expectedHealth)
+			}
+			if response.ArgocdAPI != tt.expectedAPIStatus {
+				t.Errorf("healthCheck() argocdApiStatus = %q, want %q", response.ArgocdAPI, tt.expectedAPIStatus)
+			}
+			if (response.Compatibility != nil) != tt.wantReport {
+				t.Errorf("healthCheck() compatibility = %+v, want present %v", response.Compatibility, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestHealthCheckReportsArgocdEndpoint(t *testing.T) {
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = "https://argocd.example.com/api/v1"
+	server.config.ArgocdAPIURLs = []string{server.config.ArgocdAPIURL, "https://argocd-ingress.example.com/api/v1"}
+	server.endpoints = upstream.NewEndpointsFor(server.config)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+	var response types.HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("healthCheck() invalid JSON response: %v", err)
+	}
+	if response.ArgocdEndpoint != "argocd.example.com" {
+		t.Errorf("healthCheck() argocdEndpoint = %q, want the host of the active ArgoCD URL", response.ArgocdEndpoint)
+	}
+}
+
 func TestGetProjectGroups(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -276,6 +578,102 @@ func TestGetProjectGroups(t *testing.T) {
 	}
 }
 
+func TestExportProjectGroups(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.projectNames = []string{"web-app", "other-service", "test-app", "api-service"}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %v, want %v", path, w.Code, http.StatusOK)
+		}
+		return w
+	}
+
+	first := get("/project-groups/export")
+	var export config.ProjectGroupsExport
+	if err := json.Unmarshal(first.Body.Bytes(), &export); err != nil {
+		t.Fatalf("exportProjectGroups() invalid JSON response: %v", err)
+	}
+	if !reflect.DeepEqual(export.UngroupedProjects, []string{"api-service", "other-service"}) ||
+		!reflect.DeepEqual(export.IgnoredProjects, []string{"test-app"}) {
+		t.Errorf("exportProjectGroups() = %+v, want resolved ungrouped and ignored projects", export)
+	}
+	if export.Hash == "" || first.Header().Get("X-Project-Groups-Hash") != export.Hash {
+		t.Errorf("exportProjectGroups() hash header = %q, body hash = %q", first.Header().Get("X-Project-Groups-Hash"), export.Hash)
+	}
+
+	// Upstream ordering must not leak into the export
+	mockService.projectNames = []string{"api-service", "test-app", "web-app", "other-service"}
+	if second := get("/project-groups/export"); second.Body.String() != first.Body.String() {
+		t.Errorf("exportProjectGroups() not deterministic:\n%s\n%s", first.Body.String(), second.Body.String())
+	}
+
+	if hash := get("/project-groups").Header().Get("X-Project-Groups-Hash"); hash != export.Hash {
+		t.Errorf("getProjectGroups() hash header = %q, want %q", hash, export.Hash)
+	}
+}
+
+func TestGetProjectGroupsPaging(t *testing.T) {
+	server := setupTestServer()
+	server.config.ProjectGroups = []config.ProjectGroup{
+		{Name: "Backend", Projects: []string{"api"}},
+		{Name: "Frontend", Projects: []string{"web-app"}},
+		{Name: "Frontend Mobile", Projects: []string{"ios"}},
+	}
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.projectNames = []string{"api", "ios", "sandbox", "web-app"}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantGroups []string
+		wantMeta   config.ProjectGroupsMeta
+	}{
+		{name: "all", wantStatus: http.StatusOK, wantGroups: []string{"Backend", "Frontend", "Frontend Mobile"}, wantMeta: config.ProjectGroupsMeta{Total: 3}},
+		{name: "page", query: "?offset=1&limit=1", wantStatus: http.StatusOK, wantGroups: []string{"Frontend"}, wantMeta: config.ProjectGroupsMeta{Total: 3, Offset: 1, Limit: 1}},
+		{name: "name", query: "?name=front*", wantStatus: http.StatusOK, wantGroups: []string{"Frontend", "Frontend Mobile"}, wantMeta: config.ProjectGroupsMeta{Total: 2}},
+		{name: "negative offset", query: "?offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "?limit=many", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveMethod(server, http.MethodGet, "/project-groups"+tt.query, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response config.ProjectGroupsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			names := []string{}
+			for _, group := range response.Groups {
+				names = append(names, group.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", names, tt.wantGroups)
+			}
+			if response.Meta == nil || *response.Meta != tt.wantMeta {
+				t.Errorf("meta = %+v, want %+v", response.Meta, tt.wantMeta)
+			}
+			if total := w.Header().Get("X-Total-Count"); total != strconv.Itoa(tt.wantMeta.Total) {
+				t.Errorf("X-Total-Count = %q, want %d", total, tt.wantMeta.Total)
+			}
+			if !reflect.DeepEqual(response.UngroupedProjects, []string{"sandbox"}) {
+				t.Errorf("ungrouped = %v, want [sandbox] on every page", response.UngroupedProjects)
+			}
+		})
+	}
+}
+
 func TestGetProjects(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -423,7 +821,7 @@ func TestGetApplication(t *testing.T) {
 		{
 			name:           "application not found",
 			appName:        "nonexistent",
-			serviceErr:     fmt.Errorf("application 'nonexistent' not found"),
+			serviceErr:     fmt.Errorf("application 'nonexistent' %w", services.ErrNotFound),
 			expectedStatus: http.StatusNotFound,
 		},
 		{
@@ -433,9 +831,9 @@ func TestGetApplication(t *testing.T) {
 			expectedStatus: http.StatusBadGateway,
 		},
 		{
-			name:           "empty app name",
+			name:           "empty app name serves the application list",
 			appName:        "",
-			expectedStatus: http.StatusMovedPermanently, // Gin redirects empty param to base path
+			expectedStatus: http.StatusOK,
 		},
 	}
 
@@ -446,11 +844,7 @@ func TestGetApplication(t *testing.T) {
 			mockService.application = tt.application
 			mockService.err = tt.serviceErr
 
-			url := fmt.Sprintf("/applications/%s", tt.appName)
-			req := httptest.NewRequest("GET", url, nil)
-			w := httptest.NewRecorder()
-
-			server.router.ServeHTTP(w, req)
+			w := serveMethod(server, http.MethodGet, fmt.Sprintf("/applications/%s", tt.appName), nil)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("getApplication() status = %v, want %v", w.Code, tt.expectedStatus)
@@ -471,6 +865,49 @@ func TestGetApplication(t *testing.T) {
 	}
 }
 
+func TestGetApplicationRaw(t *testing.T) {
+	raw := json.RawMessage(`{"metadata":{"name":"my-app"},"spec":{"project":"production"},"status":{"futureField":{"nested":true}}}`)
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		expectedStatus int
+		wantRaw        bool
+	}{
+		{name: "raw application", path: "/applications/my-app?raw=true", expectedStatus: http.StatusOK, wantRaw: true},
+		{name: "typed application", path: "/applications/my-app?raw=false", expectedStatus: http.StatusOK},
+		{name: "filtered application", path: "/applications/my-app?raw=true", serviceErr: fmt.Errorf("application 'my-app' %w", services.ErrFiltered), expectedStatus: http.StatusNotFound},
+		{name: "invalid raw value", path: "/applications/my-app?raw=yes-please", expectedStatus: http.StatusBadRequest},
+		{name: "raw list rejected", path: "/applications?raw=true", expectedStatus: http.StatusBadRequest},
+		{name: "raw group list rejected", path: "/groups/frontend/applications?raw=true", expectedStatus: http.StatusBadRequest},
+		{name: "raw project list rejected", path: "/projects/production/applications?raw=true", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.application = types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "my-app"}}
+			mockService.rawApplication = raw
+			mockService.err = tt.serviceErr
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET %s status = %v, want %v: %s", tt.path, w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if gotRaw := strings.Contains(w.Body.String(), "futureField"); gotRaw != tt.wantRaw {
+				t.Errorf("GET %s unknown fields present = %v, want %v", tt.path, gotRaw, tt.wantRaw)
+			}
+		})
+	}
+}
+
 func TestCORSHeaders(t *testing.T) {
 	server := setupTestServer()
 
@@ -564,48 +1001,191 @@ func TestErrorResponse(t *testing.T) {
 	}
 }
 
-func TestMetricsEndpoint(t *testing.T) {
-	metrics.SetBuildInfo("test", "test")
-	server := setupTestServer()
-
-	req := httptest.NewRequest("GET", "/metrics", nil)
-	w := httptest.NewRecorder()
-
-	server.router.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("metrics endpoint status = %v, want %v", w.Code, http.StatusOK)
-	}
-
-	body := w.Body.String()
-	if !strings.Contains(body, "build_info") {
-		t.Errorf("metrics response missing build_info")
-	}
-	if !strings.Contains(body, "go_goroutines") {
-		t.Errorf("metrics response missing go runtime metrics")
-	}
-}
-
-func TestContentTypes(t *testing.T) {
-	server := setupTestServer()
-
+func TestErrorCodes(t *testing.T) {
 	tests := []struct {
-		name         string
-		path         string
-		expectedType string
+		name           string
+		path           string
+		application    types.ArgocdApplication
+		serviceErr     error
+		expectedStatus int
+		expectedCode   types.ErrorCode
 	}{
 		{
-			name:         "health endpoint JSON",
-			path:         "/health",
-			expectedType: "application/json",
+			name:           "upstream down",
+			path:           "/applications",
+			serviceErr:     fmt.Errorf("failed to execute request to ArgoCD: connection refused"),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   types.ErrorCodeUpstreamDown,
 		},
 		{
-			name:         "projects endpoint JSON",
-			path:         "/projects",
-			expectedType: "application/json",
+			name:           "application not found",
+			path:           "/applications/missing",
+			serviceErr:     fmt.Errorf("application 'missing' %w", services.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeNotFound,
 		},
-	}
-
+		{
+			name:           "unknown endpoint",
+			path:           "/nonexistent",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeNotFound,
+		},
+		{
+			name:           "application in filtered project",
+			path:           "/applications/web",
+			serviceErr:     fmt.Errorf("application 'web' belongs to filtered project 'test-app': %w", services.ErrFiltered),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeFiltered,
+		},
+		{
+			name:           "invalid query parameter",
+			path:           "/applications?health=Broken",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeInvalidParam,
+		},
+		{
+			name:           "unknown project group",
+			path:           "/groups/NonExistent/applications",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeNotFound,
+		},
+		{
+			name:           "credentials rejected by ArgoCD",
+			path:           "/projects",
+			serviceErr:     fmt.Errorf("%w: ArgoCD API returned status 401: ", services.ErrUnauthorized),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   types.ErrorCodeUnauthorized,
+		},
+		{
+			name:           "oversized ArgoCD response",
+			path:           "/applications",
+			serviceErr:     fmt.Errorf("%w: /applications exceeds 52428800 bytes", services.ErrUpstreamTooLarge),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   types.ErrorCodeUpstreamTooLarge,
+		},
+		{
+			name:           "ArgoCD redirect to a host not allowed",
+			path:           "/applications",
+			serviceErr:     fmt.Errorf("failed to get applications: %w", &upstream.RedirectError{From: "http://argocd/api/v1/applications", Location: "https://sso.example.com/login"}),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   types.ErrorCodeUpstreamRedirect,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.err = tt.serviceErr
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if response.ErrorCode != tt.expectedCode {
+				t.Errorf("errorCode = %q, want %q", response.ErrorCode, tt.expectedCode)
+			}
+			if response.Code != tt.expectedStatus {
+				t.Errorf("code = %v, want %v", response.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	metrics.SetBuildInfo("test", "test")
+	server := setupTestServer()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("metrics endpoint status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "build_info") {
+		t.Errorf("metrics response missing build_info")
+	}
+	if !strings.Contains(body, "go_goroutines") {
+		t.Errorf("metrics response missing go runtime metrics")
+	}
+}
+
+func TestMetricsExemplarsFromTraceparent(t *testing.T) {
+	original := metrics.Default()
+	opts := metrics.DefaultOptions()
+	opts.Exemplars = true
+	metrics.SetDefault(metrics.New(opts))
+	defer metrics.SetDefault(original)
+
+	tests := []struct {
+		name        string
+		traceparent string
+		wantTraceID string
+	}{
+		{name: "traced", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "untraced"},
+		{name: "malformed", traceparent: "00-not-a-trace-01"},
+		{name: "zero trace ID", traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.router.GET("/traced", func(c *gin.Context) {
+				got := metrics.ExemplarFromContext(c.Request.Context())
+				if got["trace_id"] != tt.wantTraceID {
+					t.Errorf("exemplar trace_id = %q, want %q", got["trace_id"], tt.wantTraceID)
+				}
+				if tt.wantTraceID != "" && got["request_id"] != "req-1" {
+					t.Errorf("exemplar request_id = %q, want req-1", got["request_id"])
+				}
+			})
+			serveMethod(server, http.MethodGet, "/traced", map[string]string{"traceparent": tt.traceparent, requestIDHeader: "req-1"})
+		})
+	}
+
+	// The traced request's latency carries its exemplar in the OpenMetrics scrape
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	setupTestServer().router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("OpenMetrics scrape missing the trace exemplar:\n%s", w.Body.String())
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	server := setupTestServer()
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedType string
+	}{
+		{
+			name:         "health endpoint JSON",
+			path:         "/health",
+			expectedType: "application/json",
+		},
+		{
+			name:         "projects endpoint JSON",
+			path:         "/projects",
+			expectedType: "application/json",
+		},
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest("GET", tt.path, nil)
@@ -648,9 +1228,29 @@ func TestGetApplicationsByGroup(t *testing.T) {
 		{
 			name:           "group not found",
 			groupName:      "NonExistent",
-			serviceErr:     fmt.Errorf("project group 'NonExistent' not found"),
+			serviceErr:     fmt.Errorf("%w: 'NonExistent'", services.ErrGroupNotFound),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "mixed-case group name",
+			groupName:      "frontEND",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "encoded surrounding spaces",
+			groupName:      "%20frontend%20",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unconfigured group",
+			groupName:      "Backend",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:           "only encoded spaces",
+			groupName:      "%20%20",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -714,6 +1314,7 @@ func TestGetApplicationsByProject(t *testing.T) {
 			mockService := server.argocdService.(*MockArgocdService)
 			mockService.applications = tt.applications
 			mockService.err = tt.serviceErr
+			mockService.projectNames = []string{"production", "staging"}
 
 			url := fmt.Sprintf("/projects/%s/applications", tt.projectName)
 			req := httptest.NewRequest("GET", url, nil)
@@ -808,44 +1409,124 @@ func TestHealthCheckDefaultVersionInfo(t *testing.T) {
 	}
 }
 
-func TestHandleNotFound(t *testing.T) {
+func TestMethodNotAllowed(t *testing.T) {
+	server, _ := setupAdminServer()
+	server.config.SwaggerEnabled = true
+	server.config.OwnerAnnotations = []string{"team"}
+	server.setupRouter()
+
+	// Every route family, with the parameters filled in, answers a method it does not
+	// serve with 405 and the methods it does serve
+	methods := make(map[string][]string)
+	for _, route := range server.router.Routes() {
+		methods[route.Path] = append(methods[route.Path], route.Method)
+	}
+	for route, want := range methods {
+		path := strings.NewReplacer(":name", "guestbook", ":group", "Frontend", ":project", "web-app", "*any", "index.html").Replace(route)
+		t.Run(route, func(t *testing.T) {
+			w := serveMethod(server, http.MethodPatch, path, nil)
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("PATCH %s status = %d, want %d", path, w.Code, http.StatusMethodNotAllowed)
+			}
+
+			allow := strings.Split(w.Header().Get("Allow"), ", ")
+			sort.Strings(allow)
+			sort.Strings(want)
+			if !reflect.DeepEqual(allow, want) {
+				t.Errorf("Allow = %v, want %v", allow, want)
+			}
+
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Code != http.StatusMethodNotAllowed || response.ErrorCode != types.ErrorCodeMethodNotAllowed {
+				t.Errorf("response = %+v, want code 405 and errorCode %q", response, types.ErrorCodeMethodNotAllowed)
+			}
+		})
+	}
+
+	t.Run("unknown path", func(t *testing.T) {
+		if w := serveMethod(server, http.MethodDelete, "/nonexistent", nil); w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+			t.Errorf("DELETE /nonexistent status = %d, Allow = %q; want 404 without Allow", w.Code, w.Header().Get("Allow"))
+		}
+	})
+}
+
+func TestCORSPreflightOnEveryRoute(t *testing.T) {
+	server, _ := setupAdminServer()
+
+	for _, path := range []string{"/applications", "/applications/guestbook", "/groups/Frontend/applications", "/admin/ignored-projects"} {
+		w := serveMethod(server, http.MethodOptions, path, map[string]string{
+			"Origin":                        "http://localhost:3000",
+			"Access-Control-Request-Method": http.MethodGet,
+		})
+		if w.Code != http.StatusNoContent {
+			t.Errorf("OPTIONS %s status = %d, want %d", path, w.Code, http.StatusNoContent)
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") == "" {
+			t.Errorf("OPTIONS %s is missing Access-Control-Allow-Origin", path)
+		}
+	}
+}
+
+func TestSwaggerPath(t *testing.T) {
 	server := setupTestServer()
+	server.config.SwaggerEnabled = true
+	server.config.SwaggerPath = "/docs/api"
+	server.setupRouter()
 
+	if w := serveMethod(server, http.MethodGet, "/docs/api/index.html", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /docs/api/index.html status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveMethod(server, http.MethodGet, "/swagger/index.html", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /swagger/index.html status = %d, want %d once SWAGGER_PATH moves the UI", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleNotFound(t *testing.T) {
 	tests := []struct {
 		name           string
 		path           string
-		expectedStatus int
+		swaggerEnabled bool
 		expectJSON     bool
 	}{
 		{
-			name:           "non-swagger route returns JSON error",
-			path:           "/nonexistent",
-			expectedStatus: http.StatusNotFound,
-			expectJSON:     true,
+			name:       "non-swagger route returns JSON error",
+			path:       "/nonexistent",
+			expectJSON: true,
 		},
 		{
-			name:           "API-like route returns JSON error",
-			path:           "/some/api/path",
-			expectedStatus: http.StatusNotFound,
-			expectJSON:     true,
+			name:       "API-like route returns JSON error",
+			path:       "/some/api/path",
+			expectJSON: true,
 		},
 		{
-			name:           "swagger route returns non-JSON 404",
+			name:           "swagger handler answers unknown swagger assets",
 			path:           "/swagger/nonexistent",
-			expectedStatus: http.StatusNotFound,
+			swaggerEnabled: true,
 			expectJSON:     false,
 		},
+		{
+			name:       "swagger path returns JSON error when swagger is disabled",
+			path:       "/swagger/index.html",
+			expectJSON: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.SwaggerEnabled = tt.swaggerEnabled
+			server.setupRouter()
+
 			req := httptest.NewRequest("GET", tt.path, nil)
 			w := httptest.NewRecorder()
 
 			server.router.ServeHTTP(w, req)
 
-			if w.Code != tt.expectedStatus {
-				t.Errorf("handleNotFound() status = %v, want %v", w.Code, tt.expectedStatus)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("handleNotFound() status = %v, want %v", w.Code, http.StatusNotFound)
 			}
 
 			if tt.expectJSON {
@@ -871,6 +1552,82 @@ func TestHandleNotFound(t *testing.T) {
 	}
 }
 
+func TestSwaggerRoute(t *testing.T) {
+	previous := *docs.SwaggerInfo
+	defer func() { *docs.SwaggerInfo = previous }()
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		externalURL    string
+		basePath       string
+		expectedStatus int
+		wantHost       string
+		wantBasePath   string
+		wantSchemes    []interface{}
+	}{
+		{name: "disabled", expectedStatus: http.StatusNotFound},
+		{name: "enabled without external URL", enabled: true, expectedStatus: http.StatusOK, wantHost: "", wantBasePath: "/"},
+		{
+			name:           "external URL behind ingress",
+			enabled:        true,
+			externalURL:    "https://tools.example.com/argocd-proxy",
+			expectedStatus: http.StatusOK,
+			wantHost:       "tools.example.com",
+			wantBasePath:   "/argocd-proxy",
+			wantSchemes:    []interface{}{"https"},
+		},
+		{
+			name:           "base path overrides external URL path",
+			enabled:        true,
+			externalURL:    "http://proxy.internal:8080",
+			basePath:       "/api/",
+			expectedStatus: http.StatusOK,
+			wantHost:       "proxy.internal:8080",
+			wantBasePath:   "/api",
+			wantSchemes:    []interface{}{"http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.SwaggerEnabled = tt.enabled
+			server.config.ExternalURL = tt.externalURL
+			server.config.BasePath = tt.basePath
+			server.setupRouter()
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", "/swagger/doc.json", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET /swagger/doc.json status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.ErrorCode != types.ErrorCodeNotFound {
+					t.Errorf("disabled swagger should return a JSON 404, got %s", w.Body.String())
+				}
+				return
+			}
+
+			var doc map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("invalid swagger document: %v", err)
+			}
+			if host, _ := doc["host"].(string); host != tt.wantHost {
+				t.Errorf("swagger host = %q, want %q", host, tt.wantHost)
+			}
+			if basePath, _ := doc["basePath"].(string); basePath != tt.wantBasePath {
+				t.Errorf("swagger basePath = %q, want %q", basePath, tt.wantBasePath)
+			}
+			if schemes, _ := doc["schemes"].([]interface{}); len(tt.wantSchemes) > 0 && !reflect.DeepEqual(schemes, tt.wantSchemes) {
+				t.Errorf("swagger schemes = %v, want %v", schemes, tt.wantSchemes)
+			}
+		})
+	}
+}
+
 func TestErrorResponseDebugMode(t *testing.T) {
 	// Test that details are included in debug mode
 	server := setupTestServer()
@@ -964,3 +1721,1443 @@ func BenchmarkGetProjects(b *testing.B) {
 		server.router.ServeHTTP(w, req)
 	}
 }
+
+func TestApplicationsImageFilter(t *testing.T) {
+	applications := types.ArgocdApplicationList{
+		APIVersion: "v1",
+		Kind:       "List",
+		Items: []types.ArgocdApplication{
+			{
+				Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+				Spec:     types.ArgocdApplicationSpec{Project: "production"},
+				Status: types.ArgocdApplicationStatus{
+					Summary: &types.ArgocdApplicationSummary{Images: []string{"registry.example.com/team/web:1.4.2"}},
+				},
+			},
+			{
+				Metadata: types.ArgocdApplicationMetadata{Name: "api"},
+				Spec:     types.ArgocdApplicationSpec{Project: "staging"},
+				Status: types.ArgocdApplicationStatus{
+					Summary: &types.ArgocdApplicationSummary{Images: []string{"registry.example.com/team/web:1.4.2"}},
+				},
+			},
+			{
+				Metadata: types.ArgocdApplicationMetadata{Name: "worker"},
+				Spec:     types.ArgocdApplicationSpec{Project: "production"},
+				Status: types.ArgocdApplicationStatus{
+					Summary: &types.ArgocdApplicationSummary{Images: []string{"ghcr.io/company/worker:2.0.0"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedApps   []string
+	}{
+		{
+			name:           "substring image filter",
+			path:           "/applications?image=team/web",
+			expectedStatus: http.StatusOK,
+			expectedApps:   []string{"web", "api"},
+		},
+		{
+			name:           "exact image filter",
+			path:           "/applications?image=ghcr.io/company/worker:2.0.0&imageMatch=exact",
+			expectedStatus: http.StatusOK,
+			expectedApps:   []string{"worker"},
+		},
+		{
+			name:           "image filter composes with project",
+			path:           "/projects/production/applications?image=team/web",
+			expectedStatus: http.StatusOK,
+			expectedApps:   []string{"web"},
+		},
+		{
+			name:           "image filter on group",
+			path:           "/groups/Frontend/applications?image=worker",
+			expectedStatus: http.StatusOK,
+			expectedApps:   []string{"worker"},
+		},
+		{
+			name:           "invalid match mode",
+			path:           "/applications?image=web&imageMatch=regex",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.applications = applications
+			mockService.projectNames = []string{"production"}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("image filter status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ArgocdApplicationList
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("image filter invalid JSON response: %v", err)
+			}
+
+			var names []string
+			for _, app := range response.Items {
+				names = append(names, app.Metadata.Name)
+				if len(app.MatchedImages) == 0 {
+					t.Errorf("image filter app %s missing matchedImages", app.Metadata.Name)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedApps, ",") {
+				t.Errorf("image filter apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationsIncludeDeleting(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "web-app"}, Spec: types.ArgocdApplicationSpec{Project: "web-app"}},
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "legacy-reports", DeletionTimestamp: &deletedAt},
+			Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+			Deleting: true,
+		},
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedApps   []string
+	}{
+		{"listed by default", "/applications", http.StatusOK, []string{"web-app", "legacy-reports"}},
+		{"explicitly included", "/applications?includeDeleting=true", http.StatusOK, []string{"web-app", "legacy-reports"}},
+		{"hidden on request", "/applications?includeDeleting=false", http.StatusOK, []string{"web-app"}},
+		{"hidden on group endpoint", "/groups/Frontend/applications?includeDeleting=false", http.StatusOK, []string{"web-app"}},
+		{"hidden on project endpoint", "/projects/web-app/applications?includeDeleting=false", http.StatusOK, []string{"web-app"}},
+		{"invalid value", "/applications?includeDeleting=maybe", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.argocdService.(*MockArgocdService).applications = applications
+			server.argocdService.(*MockArgocdService).projectNames = []string{"web-app"}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET %s status = %v, want %v", tt.path, w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Items []struct {
+					Metadata types.ArgocdApplicationMetadata `json:"metadata"`
+					Deleting *bool                           `json:"deleting"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+			}
+
+			var names []string
+			for _, app := range response.Items {
+				names = append(names, app.Metadata.Name)
+				if deleting := app.Metadata.DeletionTimestamp != nil; deleting != (app.Deleting != nil && *app.Deleting) {
+					t.Errorf("GET %s app %s deleting = %v, want %v", tt.path, app.Metadata.Name, app.Deleting, deleting)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedApps, ",") {
+				t.Errorf("GET %s apps = %v, want %v", tt.path, names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationsDestClusterFilter(t *testing.T) {
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "web-app"},
+			Spec: types.ArgocdApplicationSpec{Project: "web-app", Destination: types.ArgocdApplicationDestination{
+				Server: "https://10.0.0.1:6443", ClusterName: "prod-eu",
+			}},
+		},
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "api-service"},
+			Spec: types.ArgocdApplicationSpec{Project: "api-service", Destination: types.ArgocdApplicationDestination{
+				Server: "https://10.0.0.2:6443", ClusterName: "https://10.0.0.2:6443",
+			}},
+		},
+	}}
+
+	tests := []struct {
+		path         string
+		expectedApps []string
+	}{
+		{"/applications?destCluster=prod-eu", []string{"web-app"}},
+		{"/applications?destCluster=https://10.0.0.2:6443", []string{"api-service"}},
+		{"/projects/web-app/applications?destCluster=prod-eu", []string{"web-app"}},
+		{"/applications/names?destCluster=unknown", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.StrictQueryParams = true
+			server.setupRouter()
+			server.argocdService.(*MockArgocdService).applications = applications
+			server.argocdService.(*MockArgocdService).projectNames = []string{"web-app"}
+
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s status = %v, want %v", tt.path, w.Code, http.StatusOK)
+			}
+
+			var names []string
+			if strings.HasPrefix(tt.path, "/applications/names") {
+				if err := json.Unmarshal(w.Body.Bytes(), &names); err != nil {
+					t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+				}
+			} else {
+				var response types.ArgocdApplicationList
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+				}
+				for _, app := range response.Items {
+					names = append(names, app.Metadata.Name)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedApps, ",") {
+				t.Errorf("GET %s apps = %v, want %v", tt.path, names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationsSelectorFilters(t *testing.T) {
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "web-app", Labels: map[string]string{"team": "payments", "tier": "public"}, Annotations: map[string]string{"deploy.freeze": "true"}},
+			Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+		},
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "api-service", Labels: map[string]string{"team": "payments", "tier": "internal"}},
+			Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+		},
+	}}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedApps   []string
+	}{
+		{"/applications/names?labelSelector=team%3Dpayments,tier!%3Dinternal", http.StatusOK, []string{"web-app"}},
+		{"/applications/names?labelSelector=tier%20in%20(internal,private)", http.StatusOK, []string{"api-service"}},
+		{"/applications/names?annotationSelector=deploy.freeze%3Dtrue", http.StatusOK, []string{"web-app"}},
+		{"/applications/names?annotationSelector=!deploy.freeze&labelSelector=team", http.StatusOK, []string{"api-service"}},
+		{"/applications/names?labelSelector=team&namePrefix=web", http.StatusOK, []string{"web-app"}},
+		{"/applications/names?labelSelector=tier%20in%20internal", http.StatusBadRequest, nil},
+		{"/applications?annotationSelector=owner%3D%22payments", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.StrictQueryParams = true
+			server.setupRouter()
+			server.argocdService.(*MockArgocdService).applications = applications
+
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET %s status = %v, want %v: %s", tt.path, w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if w.Code == http.StatusBadRequest {
+				if !strings.Contains(w.Body.String(), "Selector") || !strings.Contains(w.Body.String(), "at position") {
+					t.Errorf("GET %s body = %s, want the parameter and position of the syntax error", tt.path, w.Body.String())
+				}
+				return
+			}
+
+			var names []string
+			if err := json.Unmarshal(w.Body.Bytes(), &names); err != nil {
+				t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedApps, ",") {
+				t.Errorf("GET %s apps = %v, want %v", tt.path, names, tt.expectedApps)
+			}
+		})
+	}
+}
+func TestGetGroupDrift(t *testing.T) {
+	finishedAt := time.Date(2024, 5, 1, 10, 2, 30, 0, time.UTC)
+	applications := types.ArgocdApplicationList{
+		Items: []types.ArgocdApplication{
+			{
+				Metadata: types.ArgocdApplicationMetadata{Name: "web-app"},
+				Spec: types.ArgocdApplicationSpec{
+					Project: "web-app",
+					Source:  types.ArgocdApplicationSource{TargetRevision: "v2"},
+				},
+				Status: types.ArgocdApplicationStatus{
+					Sync:           types.ArgocdApplicationSync{Status: "OutOfSync", Revision: "abc123"},
+					OperationState: &types.ArgocdOperationState{FinishedAt: &finishedAt},
+				},
+				IngressURLs: []string{"https://web.example.com"},
+			},
+			{
+				Metadata: types.ArgocdApplicationMetadata{Name: "web-docs"},
+				Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+				Status:   types.ArgocdApplicationStatus{Sync: types.ArgocdApplicationSync{Status: "Synced"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		groupName         string
+		serviceErr        error
+		expectedStatus    int
+		expectedOutOfSync int
+	}{
+		{
+			name:              "mixed sync states",
+			groupName:         "Frontend",
+			expectedStatus:    http.StatusOK,
+			expectedOutOfSync: 1,
+		},
+		{
+			name:              "mixed-case group name with encoded space",
+			groupName:         "frontend%20",
+			expectedStatus:    http.StatusOK,
+			expectedOutOfSync: 1,
+		},
+		{
+			name:           "unknown group",
+			groupName:      "Payments",
+			serviceErr:     fmt.Errorf("%w: 'Payments'", services.ErrGroupNotFound),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "service error",
+			groupName:      "Frontend",
+			serviceErr:     fmt.Errorf("ArgoCD error"),
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.applications = applications
+			mockService.err = tt.serviceErr
+
+			req := httptest.NewRequest("GET", fmt.Sprintf("/groups/%s/drift", tt.groupName), nil)
+			w := httptest.NewRecorder()
+
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getGroupDrift() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.GroupDriftReport
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getGroupDrift() invalid JSON response: %v", err)
+			}
+			if response.Group != "Frontend" {
+				t.Errorf("getGroupDrift() group = %v, want canonical name Frontend", response.Group)
+			}
+			if response.TotalApplications != 2 {
+				t.Errorf("getGroupDrift() totalApplications = %v, want 2", response.TotalApplications)
+			}
+			if response.OutOfSync != tt.expectedOutOfSync {
+				t.Errorf("getGroupDrift() outOfSync = %v, want %v", response.OutOfSync, tt.expectedOutOfSync)
+			}
+			if len(response.Applications) != 1 || response.Applications[0].Name != "web-app" {
+				t.Fatalf("getGroupDrift() applications = %+v", response.Applications)
+			}
+			entry := response.Applications[0]
+			if entry.TargetRevision != "v2" || entry.CurrentRevision != "abc123" {
+				t.Errorf("getGroupDrift() revisions = %s -> %s", entry.CurrentRevision, entry.TargetRevision)
+			}
+			if entry.LastOperationFinishedAt == nil || !entry.LastOperationFinishedAt.Equal(finishedAt) {
+				t.Errorf("getGroupDrift() lastOperationFinishedAt = %v, want %v", entry.LastOperationFinishedAt, finishedAt)
+			}
+		})
+	}
+}
+
+func TestHealthCheckVerboseUpstreamStatus(t *testing.T) {
+	fetchedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectUpstream bool
+	}{
+		{name: "default omits upstream status", path: "/health", expectUpstream: false},
+		{name: "verbose includes upstream status", path: "/health?verbose=true", expectUpstream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.upstreamStatus = types.UpstreamStatus{Applications: &fetchedAt}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			var raw map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+				t.Fatalf("healthCheck() invalid JSON response: %v", err)
+			}
+
+			upstream, present := raw["upstream"].(map[string]interface{})
+			if present != tt.expectUpstream {
+				t.Fatalf("healthCheck() upstream present = %v, want %v", present, tt.expectUpstream)
+			}
+			if !tt.expectUpstream {
+				return
+			}
+
+			if upstream["applications"] != fetchedAt.Format(time.RFC3339) {
+				t.Errorf("healthCheck() upstream.applications = %v, want %v", upstream["applications"], fetchedAt.Format(time.RFC3339))
+			}
+			if value, ok := upstream["projects"]; !ok || value != nil {
+				t.Errorf("healthCheck() upstream.projects = %v, want null", value)
+			}
+		})
+	}
+}
+
+func TestQueryParameterValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		strict         bool
+		maxQueryLength int
+		path           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "valid health and sync filters",
+			path:           "/applications?health=degraded,Progressing&sync=OutOfSync",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid health status",
+			path:           "/applications?health=Broken",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"health"},
+		},
+		{
+			name:           "multiple invalid parameters",
+			path:           "/groups/Frontend/applications?imageMatch=regex&sync=Drifting",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"imageMatch", "sync"},
+		},
+		{
+			name:           "invalid verbose flag",
+			path:           "/health?verbose=perhaps",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"verbose"},
+		},
+		{
+			name:           "unknown parameter ignored by default",
+			path:           "/applications?colour=blue",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown parameter rejected in strict mode",
+			strict:         true,
+			path:           "/applications?image=web&colour=blue",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"colour"},
+		},
+		{
+			name:           "known parameters accepted in strict mode",
+			strict:         true,
+			path:           "/projects/production/applications?image=web&health=Healthy",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "route without parameters rejects any in strict mode",
+			strict:         true,
+			path:           "/projects?limit=10",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"limit"},
+		},
+		{
+			name:           "unknown route still returns 404 in strict mode",
+			strict:         true,
+			path:           "/nonexistent?foo=bar",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "oversized query string",
+			maxQueryLength: 16,
+			path:           "/applications?image=" + strings.Repeat("a", 32),
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"query"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.StrictQueryParams = tt.strict
+			server.config.MaxQueryLength = tt.maxQueryLength
+			server.setupRouter()
+			server.argocdService.(*MockArgocdService).projectNames = []string{"production"}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("query validation status = %v, want %v (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedFields == nil {
+				return
+			}
+
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("query validation invalid JSON response: %v", err)
+			}
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("query validation code = %v, want %v", response.Code, http.StatusBadRequest)
+			}
+			if strings.Join(response.Fields, ",") != strings.Join(tt.expectedFields, ",") {
+				t.Errorf("query validation fields = %v, want %v", response.Fields, tt.expectedFields)
+			}
+		})
+	}
+}
+
+func TestAuditLogging(t *testing.T) {
+	var buf bytes.Buffer
+	server := setupTestServer()
+	server.auditLogger = audit.NewLogger(&buf)
+	server.config.AuditLogExclude = []string{"/health"}
+	server.setupRouter()
+
+	for _, path := range []string{"/health", "/projects/production/applications?health=Broken"} {
+		server.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 audit record, got %d: %q", len(lines), buf.String())
+	}
+
+	var record audit.Record
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("invalid audit record: %v", err)
+	}
+	if record.Route != "/projects/:project/applications" || record.PathParams["project"] != "production" {
+		t.Errorf("unexpected route in audit record: %+v", record)
+	}
+	if record.Status != http.StatusBadRequest || record.Query.Get("health") != "Broken" {
+		t.Errorf("rejected request not audited with status and query: %+v", record)
+	}
+}
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		wantClient     string
+	}{
+		{"default ignores forged header", nil, "203.0.113.5:4321", "198.51.100.7", "203.0.113.5"},
+		{"trusted proxy forwards client", []string{"10.0.0.0/8"}, "10.1.2.3:4321", "198.51.100.7", "198.51.100.7"},
+		{"untrusted source ignored", []string{"10.0.0.0/8"}, "203.0.113.5:4321", "198.51.100.7", "203.0.113.5"},
+		{"forged hop before trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:4321", "1.2.3.4, 198.51.100.7", "198.51.100.7"},
+		{"chain of trusted proxies", []string{"10.0.0.0/8"}, "10.1.2.3:4321", "198.51.100.7, 10.9.9.9", "198.51.100.7"},
+		{"single trusted ip", []string{"192.168.1.10"}, "192.168.1.10:4321", "198.51.100.7", "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			server := setupTestServer()
+			server.auditLogger = audit.NewLogger(&buf)
+			server.config.AuditLogExclude = nil
+			server.config.TrustedProxies = tt.trustedProxies
+			server.setupRouter()
+
+			req := httptest.NewRequest("GET", "/projects", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+			var record audit.Record
+			if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &record); err != nil {
+				t.Fatalf("invalid audit record %q: %v", buf.String(), err)
+			}
+			if record.Client != tt.wantClient {
+				t.Errorf("audited client = %q, want %q", record.Client, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestHealthHistoryEndpoint(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockService.healthHistory = types.HealthHistoryResponse{
+		Capacity: 10,
+		Checks: []types.HealthCheckRecord{
+			{Timestamp: checkedAt, Status: "healthy", LatencyMs: 12.5},
+			{Timestamp: checkedAt.Add(time.Minute), Status: "degraded", LatencyMs: 5000, Error: "health check request failed"},
+		},
+		UptimePercent:  50,
+		LastTransition: &checkedAt,
+	}
+
+	req := httptest.NewRequest("GET", "/health/history", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("healthHistory() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response types.HealthHistoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("healthHistory() invalid JSON response: %v", err)
+	}
+	if len(response.Checks) != 2 || response.Checks[1].Error == "" {
+		t.Errorf("healthHistory() checks = %+v, want both checks with the failure error", response.Checks)
+	}
+	if response.UptimePercent != 50 || response.Capacity != 10 {
+		t.Errorf("healthHistory() aggregates = %v%%/%d, want 50%%/10", response.UptimePercent, response.Capacity)
+	}
+}
+
+func TestReadinessCheck(t *testing.T) {
+	server := setupTestServer()
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("readyz status before shutdown = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	server.lifecycle.BeginShutdown()
+
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz status during shutdown = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	var response types.ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("readyz invalid JSON response: %v", err)
+	}
+	if response.Status != "shutting down" {
+		t.Errorf("readyz status = %q, want %q", response.Status, "shutting down")
+	}
+}
+
+func TestServeGracefulShutdown(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 30 * time.Second
+	server.config.CacheTTL = 0
+	subscribed := make(chan struct{})
+	mockService := &changingArgocdService{subscribed: subscribed}
+	mockService.applications = watchTestApplications("app1")
+	mockService.applicationsChanged = make(chan struct{})
+	server.argocdService = mockService
+	current := services.ApplicationsHash(mockService.applications)
+
+	var routineStopped atomic.Bool
+	server.lifecycle.Go("test routine", func(ctx context.Context) {
+		<-ctx.Done()
+		routineStopped.Store(true)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	baseURL := "http://" + listener.Addr().String()
+
+	ctx, triggerShutdown := context.WithCancel(context.Background())
+	defer triggerShutdown()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.serve(ctx, listener)
+	}()
+
+	// Open a long-poll watch and wait until it is parked on change notifications
+	type watchResult struct {
+		status int
+		err    error
+	}
+	watched := make(chan watchResult, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/applications?watchAfter=" + current)
+		if err != nil {
+			watched <- watchResult{err: err}
+			return
+		}
+		resp.Body.Close()
+		watched <- watchResult{status: resp.StatusCode}
+	}()
+
+	select {
+	case <-subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch request never started waiting")
+	}
+
+	triggerShutdown()
+
+	select {
+	case result := <-watched:
+		if result.err != nil {
+			t.Fatalf("watch request failed during shutdown: %v", result.err)
+		}
+		if result.status != http.StatusNotModified {
+			t.Errorf("watch status during shutdown = %v, want %v", result.status, http.StatusNotModified)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch request was not released at shutdown")
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serve() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve() did not return after shutdown")
+	}
+
+	if !routineStopped.Load() {
+		t.Error("background routine should have stopped before serve() returned")
+	}
+	if !server.lifecycle.IsDraining() {
+		t.Error("lifecycle should report draining after shutdown")
+	}
+	if _, err := http.Get(baseURL + "/readyz"); err == nil {
+		t.Error("server should no longer accept connections after shutdown")
+	}
+}
+
+func TestGetDeployStats(t *testing.T) {
+	now := time.Now().UTC()
+	application := types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: "web-app"},
+		Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+		Status: types.ArgocdApplicationStatus{History: []types.ArgocdRevisionHistory{
+			{ID: 1, Revision: "a1", DeployedAt: now.Add(-40 * 24 * time.Hour)},
+			{ID: 2, Revision: "a2", DeployedAt: now.Add(-6 * 24 * time.Hour)},
+			{ID: 3, Revision: "a3", DeployedAt: now.Add(-2 * 24 * time.Hour)},
+		}},
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		application     types.ArgocdApplication
+		serviceErr      error
+		expectedStatus  int
+		wantDeployments int
+	}{
+		{"application default window", "/applications/web-app/deploy-stats", application, nil, http.StatusOK, 2},
+		{"application custom window", "/applications/web-app/deploy-stats?window=3d", application, nil, http.StatusOK, 1},
+		{"application window too short", "/applications/web-app/deploy-stats?window=5m", application, nil, http.StatusBadRequest, 0},
+		{"application window not a duration", "/applications/web-app/deploy-stats?window=month", application, nil, http.StatusBadRequest, 0},
+		{"application not found", "/applications/web-app/deploy-stats", types.ArgocdApplication{}, nil, http.StatusNotFound, 0},
+		{"application service error", "/applications/web-app/deploy-stats", application, fmt.Errorf("ArgoCD error"), http.StatusBadGateway, 0},
+		{"group default window", "/groups/frontend/deploy-stats", application, nil, http.StatusOK, 2},
+		{"group window too long", "/groups/Frontend/deploy-stats?window=400d", application, nil, http.StatusBadRequest, 0},
+		{"unknown group", "/groups/Payments/deploy-stats", application, nil, http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.application = tt.application
+			mockService.applications = types.ArgocdApplicationList{Items: []types.ArgocdApplication{tt.application}}
+			mockService.err = tt.serviceErr
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("deploy stats status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var stats types.GroupDeployStats
+			if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+				t.Fatalf("deploy stats invalid JSON response: %v", err)
+			}
+			if stats.Deployments != tt.wantDeployments {
+				t.Errorf("deploy stats deployments = %v, want %v", stats.Deployments, tt.wantDeployments)
+			}
+			if strings.HasPrefix(tt.path, "/groups/") && (stats.Group != "Frontend" || len(stats.Applications) != 1) {
+				t.Errorf("group deploy stats = %s with %d applications, want Frontend with 1", stats.Group, len(stats.Applications))
+			}
+		})
+	}
+}
+
+func TestGetApplicationSyncWindows(t *testing.T) {
+	tests := []struct {
+		name           string
+		application    types.ArgocdApplication
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "successful retrieval",
+			application:    types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "web"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "application not found",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "application in filtered project",
+			err:            fmt.Errorf("application 'web' belongs to filtered project 'test-app': %w", services.ErrFiltered),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "upstream error",
+			err:            fmt.Errorf("ArgoCD API returned status 500"),
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.application = tt.application
+			mockService.err = tt.err
+			mockService.syncWindows = types.ApplicationSyncWindows{
+				Application:  "web",
+				CanSync:      false,
+				ActiveDenies: []types.ArgocdSyncWindow{{Kind: "deny", Schedule: "0 22 * * *", Duration: "8h"}},
+			}
+
+			req := httptest.NewRequest("GET", "/applications/web/sync-windows", nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getApplicationSyncWindows() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ApplicationSyncWindows
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getApplicationSyncWindows() invalid JSON response: %v", err)
+			}
+			if response.CanSync || len(response.ActiveDenies) != 1 {
+				t.Errorf("getApplicationSyncWindows() = %+v, want one active deny and canSync false", response)
+			}
+		})
+	}
+}
+
+func TestGetApplicationDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		application    types.ArgocdApplication
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "successful retrieval",
+			application:    types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "web"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "application not found",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "application in filtered project",
+			err:            fmt.Errorf("application 'web' belongs to filtered project 'test-app': %w", services.ErrFiltered),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "upstream error",
+			err:            fmt.Errorf("ArgoCD API returned status 500"),
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.application = tt.application
+			mockService.err = tt.err
+			mockService.diff = types.ApplicationDiff{
+				Application: "web",
+				HasChanges:  true,
+				Modified:    1,
+				Resources: []types.ApplicationDiffEntry{
+					{Kind: "Deployment", Name: "web", Namespace: "default", Change: types.ResourceChangeModified, Modified: true},
+				},
+			}
+
+			req := httptest.NewRequest("GET", "/applications/web/diff", nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getApplicationDiff() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ApplicationDiff
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getApplicationDiff() invalid JSON response: %v", err)
+			}
+			if !response.HasChanges || response.Modified != 1 || len(response.Resources) != 1 {
+				t.Errorf("getApplicationDiff() = %+v, want one modified resource", response)
+			}
+		})
+	}
+}
+
+func TestGetApplicationNotifications(t *testing.T) {
+	tests := []struct {
+		name              string
+		application       types.ArgocdApplication
+		err               error
+		expectedStatus    int
+		wantSubscriptions int
+	}{
+		{
+			name: "subscribed application",
+			application: types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{
+				Name: "web",
+				Annotations: map[string]string{
+					"notifications.argoproj.io/subscribe.on-sync-failed.slack": "deploys;web-team",
+					"notifications.argoproj.io/subscribe..slack":               "broken",
+				},
+			}},
+			expectedStatus:    http.StatusOK,
+			wantSubscriptions: 2,
+		},
+		{
+			name:           "application without subscriptions",
+			application:    types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "web"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "application not found",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "application in filtered project",
+			err:            fmt.Errorf("application 'web' belongs to filtered project 'test-app': %w", services.ErrFiltered),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "upstream error",
+			err:            fmt.Errorf("ArgoCD API returned status 500"),
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.application = tt.application
+			mockService.err = tt.err
+
+			w := serveMethod(server, http.MethodGet, "/applications/web/notifications", nil)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getApplicationNotifications() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ApplicationNotifications
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getApplicationNotifications() invalid JSON response: %v", err)
+			}
+			if response.Application != "web" || len(response.Subscriptions) != tt.wantSubscriptions || response.HasSubscriptions != (tt.wantSubscriptions > 0) {
+				t.Errorf("getApplicationNotifications() = %+v, want %d subscriptions", response, tt.wantSubscriptions)
+			}
+		})
+	}
+}
+
+func TestGetProjectDestinations(t *testing.T) {
+	project := types.ArgocdProject{
+		Metadata: types.ArgocdProjectMetadata{Name: "production"},
+		Spec: types.ArgocdProjectSpec{Destinations: []types.ArgocdProjectDestination{
+			{Server: "https://kubernetes.default.svc", Namespace: "production"},
+		}},
+	}
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+			Spec: types.ArgocdApplicationSpec{Project: "production", Destination: types.ArgocdApplicationDestination{
+				Server: "https://kubernetes.default.svc", Namespace: "production",
+			}},
+		},
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "debug"},
+			Spec: types.ArgocdApplicationSpec{Project: "production", Destination: types.ArgocdApplicationDestination{
+				Server: "https://kubernetes.default.svc", Namespace: "kube-system",
+			}},
+		},
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		err            error
+		expectedStatus int
+	}{
+		{"reconciled destinations", "/projects/production/destinations", nil, http.StatusOK},
+		{"unknown project", "/projects/ledger/destinations", nil, http.StatusNotFound},
+		{"upstream error", "/projects/production/destinations", fmt.Errorf("ArgoCD API returned status 500"), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.projects = []types.ArgocdProject{project}
+			mockService.applications = applications
+			mockService.err = tt.err
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getProjectDestinations() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ProjectDestinationsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getProjectDestinations() invalid JSON response: %v", err)
+			}
+			if len(response.Destinations) != 2 {
+				t.Fatalf("getProjectDestinations() destinations = %+v, want 2", response.Destinations)
+			}
+			if response.Destinations[0].Undeclared || !response.Destinations[1].Undeclared {
+				t.Errorf("getProjectDestinations() undeclared flags = %+v", response.Destinations)
+			}
+		})
+	}
+}
+
+func TestGetProjectDestinationsPartial(t *testing.T) {
+	project := types.ArgocdProject{
+		Metadata: types.ArgocdProjectMetadata{Name: "production"},
+		Spec: types.ArgocdProjectSpec{Destinations: []types.ArgocdProjectDestination{
+			{Server: "https://kubernetes.default.svc", Namespace: "production"},
+		}},
+	}
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "debug"},
+			Spec: types.ArgocdApplicationSpec{Project: "production", Destination: types.ArgocdApplicationDestination{
+				Server: "https://kubernetes.default.svc", Namespace: "kube-system",
+			}},
+		},
+	}}
+	timeout := fmt.Errorf("failed to execute request to ArgoCD: %w", context.DeadlineExceeded)
+	upstreamDown := errors.New("ArgoCD API returned status 500")
+
+	tests := []struct {
+		name             string
+		query            string
+		projectErr       error
+		appsErr          error
+		expectedStatus   int
+		wantDestinations []string
+		wantWarnings     []string
+	}{
+		{"all-or-nothing by default", "", upstreamDown, nil, http.StatusBadGateway, nil, nil},
+		{"explicitly all-or-nothing", "?partial=false", nil, upstreamDown, http.StatusBadGateway, nil, nil},
+		{
+			"project unavailable", "?partial=true", timeout, nil, http.StatusOK,
+			[]string{"kube-system"}, []string{"project unavailable: upstream timeout"},
+		},
+		{
+			"applications unavailable", "?partial=true", nil, upstreamDown, http.StatusOK,
+			[]string{"production"}, []string{"applications unavailable: upstream error"},
+		},
+		{"every portion unavailable", "?partial=true", upstreamDown, timeout, http.StatusBadGateway, nil, nil},
+		{"nothing missing", "?partial=true", nil, nil, http.StatusOK, []string{"production", "kube-system"}, nil},
+		{"invalid value", "?partial=maybe", nil, nil, http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.projects = []types.ArgocdProject{project}
+			mockService.applications = applications
+			mockService.projectErr = tt.projectErr
+			mockService.projectAppsErr = tt.appsErr
+
+			w := serveMethod(server, http.MethodGet, "/projects/production/destinations"+tt.query, nil)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getProjectDestinations() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ProjectDestinationsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getProjectDestinations() invalid JSON response: %v", err)
+			}
+			if response.Project != "production" {
+				t.Errorf("getProjectDestinations() project = %q, want production", response.Project)
+			}
+			var namespaces []string
+			for _, dest := range response.Destinations {
+				namespaces = append(namespaces, dest.Namespace)
+				if tt.projectErr != nil && dest.Undeclared {
+					t.Errorf("destination %s flagged undeclared without the project spec", dest.Namespace)
+				}
+			}
+			if !reflect.DeepEqual(namespaces, tt.wantDestinations) {
+				t.Errorf("getProjectDestinations() destinations = %v, want %v", namespaces, tt.wantDestinations)
+			}
+			if !reflect.DeepEqual(response.Warnings, tt.wantWarnings) {
+				t.Errorf("getProjectDestinations() warnings = %q, want %q", response.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestNamePrefixSearch(t *testing.T) {
+	applications := types.ArgocdApplicationList{
+		APIVersion: "v1",
+		Kind:       "List",
+		Items: []types.ArgocdApplication{
+			{Metadata: types.ArgocdApplicationMetadata{Name: "web-Frontend"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+			{Metadata: types.ArgocdApplicationMetadata{Name: "Web-api"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+			{Metadata: types.ArgocdApplicationMetadata{Name: "worker"}, Spec: types.ArgocdApplicationSpec{Project: "staging"}},
+		},
+	}
+	projects := []types.ArgocdProject{
+		{Metadata: types.ArgocdProjectMetadata{Name: "Production"}},
+		{Metadata: types.ArgocdProjectMetadata{Name: "staging"}},
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedNames []string
+	}{
+		{"applications by mixed-case prefix", "/applications?namePrefix=WEB", []string{"web-Frontend", "Web-api"}},
+		{"projects by mixed-case prefix", "/projects?namePrefix=prod", []string{"Production"}},
+		{"projects without prefix", "/projects", []string{"Production", "staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.applications = applications
+			mockService.projects = projects
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("namePrefix status = %v, want %v", w.Code, http.StatusOK)
+			}
+
+			var response struct {
+				Items []struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("namePrefix invalid JSON response: %v", err)
+			}
+
+			var names []string
+			for _, item := range response.Items {
+				names = append(names, item.Metadata.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedNames, ",") {
+				t.Errorf("namePrefix names = %v, want %v", names, tt.expectedNames)
+			}
+		})
+	}
+}
+
+func TestGetApplicationNames(t *testing.T) {
+	applications := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "worker"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "Web-api"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "web-frontend"}},
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"all names sorted", "/applications/names", nil, http.StatusOK, `["Web-api","web-frontend","worker"]`},
+		{"names by prefix", "/applications/names?namePrefix=WEB-F", nil, http.StatusOK, `["web-frontend"]`},
+		{"no matches", "/applications/names?namePrefix=db", nil, http.StatusOK, `[]`},
+		{"service error", "/applications/names", fmt.Errorf("ArgoCD error"), http.StatusBadGateway, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.applications = applications
+			mockService.err = tt.serviceErr
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getApplicationNames() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("getApplicationNames() body = %s, want %s", w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+// ageFixtureApplications returns applications with fixed creation timestamps, one of them unset
+func ageFixtureApplications() types.ArgocdApplicationList {
+	app := func(name string, created time.Time) types.ArgocdApplication {
+		return types.ArgocdApplication{
+			Metadata: types.ArgocdApplicationMetadata{Name: name, CreationTimestamp: created},
+			Spec:     types.ArgocdApplicationSpec{Project: "production"},
+		}
+	}
+	return types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		app("legacy", time.Time{}),
+		app("web", time.Date(2023, 3, 1, 9, 0, 0, 0, time.UTC)),
+		app("api", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)),
+		app("cron", time.Date(2021, 7, 1, 9, 0, 0, 0, time.UTC)),
+	}}
+}
+
+func TestApplicationSortAndAge(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{"upstream order by default", "/applications", http.StatusOK, []string{"legacy", "web", "api", "cron"}},
+		{"oldest first", "/applications?sort=created", http.StatusOK, []string{"cron", "web", "api", "legacy"}},
+		{"newest first", "/applications?sort=created&order=desc", http.StatusOK, []string{"api", "web", "cron", "legacy"}},
+		{"by name", "/applications?sort=name", http.StatusOK, []string{"api", "cron", "legacy", "web"}},
+		{"project list", "/projects/production/applications?sort=created&order=desc", http.StatusOK, []string{"api", "web", "cron", "legacy"}},
+		{"group list", "/groups/Frontend/applications?sort=created", http.StatusOK, []string{"cron", "web", "api", "legacy"}},
+		{"invalid sort", "/applications?sort=age", http.StatusBadRequest, nil},
+		{"invalid order", "/applications?sort=created&order=newest", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			mockService := server.argocdService.(*MockArgocdService)
+			mockService.applications = ageFixtureApplications()
+			mockService.projectNames = []string{"production"}
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedNames == nil {
+				return
+			}
+
+			var response types.ArgocdApplicationList
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			var names []string
+			for _, app := range response.Items {
+				names = append(names, app.Metadata.Name)
+
+				created := app.Metadata.CreationTimestamp
+				if created.IsZero() {
+					if app.Age != nil {
+						t.Errorf("%s age = %+v, want null", app.Metadata.Name, app.Age)
+					}
+					continue
+				}
+				if app.Age == nil || app.Age.Human == "" || app.Age.Seconds < int64(time.Since(created)/time.Second)-60 {
+					t.Errorf("%s age = %+v, want about %s", app.Metadata.Name, app.Age, time.Since(created))
+				}
+			}
+			if !reflect.DeepEqual(names, tt.expectedNames) {
+				t.Errorf("names = %v, want %v", names, tt.expectedNames)
+			}
+		})
+	}
+
+	// Zero creation timestamps are serialized as an explicit null age
+	server := setupTestServer()
+	server.argocdService.(*MockArgocdService).applications = ageFixtureApplications()
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/applications?namePrefix=legacy", nil))
+	if !strings.Contains(w.Body.String(), `"age":null`) {
+		t.Errorf("application without creation timestamp should report age: null, got %s", w.Body.String())
+	}
+}
+
+func TestGetRecentApplications(t *testing.T) {
+	applications := ageFixtureApplications()
+	applications.Items = append(applications.Items, types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: "fresh", CreationTimestamp: time.Now().Add(-time.Hour).Truncate(time.Second)},
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{"default window", "/applications/recent", http.StatusOK, []string{"fresh"}},
+		{"filtered out", "/applications/recent?since=72h&namePrefix=web", http.StatusOK, nil},
+		{"invalid window", "/applications/recent?since=soon", http.StatusBadRequest, nil},
+		{"window too large", "/applications/recent?since=400d", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.argocdService.(*MockArgocdService).applications = applications
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("getRecentApplications() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response types.ArgocdApplicationList
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("getRecentApplications() invalid JSON response: %v", err)
+			}
+			var names []string
+			for _, app := range response.Items {
+				names = append(names, app.Metadata.Name)
+				if app.Age == nil || app.Age.Seconds < 3600 {
+					t.Errorf("%s age = %+v, want at least an hour", app.Metadata.Name, app.Age)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.expectedNames) {
+				t.Errorf("getRecentApplications() names = %v, want %v", names, tt.expectedNames)
+			}
+		})
+	}
+}
+
+func TestSecretsRedactedFromResponsesAndLogs(t *testing.T) {
+	const secret = "hunter2-hunter2"
+
+	previous := redact.Default()
+	defer redact.SetDefault(previous)
+	redactor := redact.New(redact.DefaultMaxBodyLength)
+	redactor.SetSecret("password", secret)
+	redact.SetDefault(redactor)
+
+	var logs bytes.Buffer
+	log.SetOutput(redactor.Writer(&logs))
+	defer log.SetOutput(os.Stderr)
+
+	server := setupTestServer()
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.err = fmt.Errorf("ArgoCD API returned status 500: invalid password %s", secret)
+	mockService.healthErr = fmt.Errorf("health check failed for password %s", secret)
+
+	for _, path := range []string{"/applications", "/projects", "/health"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		if strings.Contains(w.Body.String(), secret) {
+			t.Errorf("%s response leaked the secret: %s", path, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), redact.Mask) {
+			t.Errorf("%s response = %s, want masked details", path, w.Body.String())
+		}
+	}
+
+	if strings.Contains(logs.String(), secret) {
+		t.Errorf("log output leaked the secret: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), redact.Mask) {
+		t.Errorf("log output = %q, want masked error", logs.String())
+	}
+}
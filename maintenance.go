@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/clock"
+	"argocd-proxy/events"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// defaultMaintenanceMessage is returned to clients when maintenance is entered without a message
+const defaultMaintenanceMessage = "The proxy is in maintenance mode"
+
+// maintenanceExemptRoutes are the routes served normally during maintenance, besides the
+// admin API and the Swagger UI: probes, metrics and documentation do not read ArgoCD data
+var maintenanceExemptRoutes = map[string]bool{
+	"/health":         true,
+	"/health/history": true,
+	"/readyz":         true,
+	"/info":           true,
+	"/schemas":        true,
+	"/metrics":        true,
+	openAPIJSONPath:   true,
+	openAPIYAMLPath:   true,
+}
+
+// maintenanceWindow is a maintenance mode entered through the admin API. Windows are not
+// modified once entered, so they can be read without holding the lock.
+type maintenanceWindow struct {
+	since      time.Time
+	until      time.Time
+	message    string
+	serveStale bool
+}
+
+// status reports the window for the admin API and /health
+func (w *maintenanceWindow) status() types.MaintenanceStatus {
+	since, until := w.since, w.until
+	return types.MaintenanceStatus{
+		Active:     true,
+		Since:      &since,
+		Until:      &until,
+		Message:    w.message,
+		ServeStale: w.serveStale,
+	}
+}
+
+// maintenanceMode holds the maintenance window in memory only, so a restart ends it. The
+// zero value is not in maintenance.
+type maintenanceMode struct {
+	mu     sync.Mutex
+	window *maintenanceWindow
+	// clock tells when the window ends
+	clock clock.Clock
+}
+
+// current returns the active window, or nil. A window that has reached its end is dropped.
+func (m *maintenanceMode) current() *maintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.window != nil && !clock.OrReal(m.clock).Now().Before(m.window.until) {
+		log.Printf("Maintenance mode ended at %s", m.window.until.Format(time.RFC3339))
+		m.window = nil
+	}
+	return m.window
+}
+
+// enter starts or replaces the maintenance window. Replacing an active window keeps the
+// time maintenance started.
+func (m *maintenanceMode) enter(until time.Time, message string, serveStale bool) *maintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := clock.OrReal(m.clock).Now()
+	since := now
+	if m.window != nil && now.Before(m.window.until) {
+		since = m.window.since
+	}
+	m.window = &maintenanceWindow{since: since, until: until, message: message, serveStale: serveStale}
+	return m.window
+}
+
+// exit ends the maintenance window, reporting whether one was active
+func (m *maintenanceMode) exit() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := m.window != nil && clock.OrReal(m.clock).Now().Before(m.window.until)
+	m.window = nil
+	return active
+}
+
+// retryAfter returns the whole seconds until the window ends, at least 1, for Retry-After
+func (m *maintenanceMode) retryAfter(w *maintenanceWindow) int {
+	seconds := math.Ceil(w.until.Sub(clock.OrReal(m.clock).Now()).Seconds())
+	return max(1, int(seconds))
+}
+
+// maintenanceExempt reports whether route is served normally during maintenance
+func (s *Server) maintenanceExempt(route string) bool {
+	return maintenanceExemptRoutes[route] ||
+		strings.HasPrefix(route, "/admin/") ||
+		(s.config.SwaggerEnabled && strings.HasPrefix(route, s.swaggerPath()+"/"))
+}
+
+// maintenanceMiddleware answers data endpoints with 503 during maintenance. When the
+// window serves stale data, requests go through with ArgoCD calls suppressed instead, so
+// they are answered from the caches, expired or not, and fail with 503 when the data is
+// not cached. Unknown routes still get 404.
+func (s *Server) maintenanceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || s.maintenanceExempt(route) {
+			c.Next()
+			return
+		}
+		window := s.maintenance.current()
+		if window == nil {
+			c.Next()
+			return
+		}
+		if window.serveStale {
+			c.Request = c.Request.WithContext(upstream.Suppress(c.Request.Context()))
+			c.Next()
+			return
+		}
+		s.maintenanceResponse(c, window)
+		c.Abort()
+	}
+}
+
+// maintenanceResponse answers 503 with the window's message and a Retry-After header
+// pointing at its end
+func (s *Server) maintenanceResponse(c *gin.Context, window *maintenanceWindow) {
+	c.Header("Retry-After", strconv.Itoa(s.maintenance.retryAfter(window)))
+	s.renderError(c, types.ErrorResponse{
+		Error:     http.StatusText(http.StatusServiceUnavailable),
+		Message:   window.message,
+		Code:      http.StatusServiceUnavailable,
+		ErrorCode: types.ErrorCodeMaintenance,
+	})
+}
+
+// maintenanceStatus reports the current maintenance mode
+func (s *Server) maintenanceStatus() types.MaintenanceStatus {
+	if window := s.maintenance.current(); window != nil {
+		return window.status()
+	}
+	return types.MaintenanceStatus{}
+}
+
+// getMaintenance handles the maintenance mode admin endpoint
+// @Summary Get the maintenance mode
+// @Description Report whether the proxy is in maintenance mode, and until when. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} types.MaintenanceStatus "Maintenance mode"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/maintenance [get]
+func (s *Server) getMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, s.maintenanceStatus())
+}
+
+// postMaintenance handles entering maintenance mode through the admin API
+// @Summary Enter maintenance mode
+// @Description Put the proxy into maintenance mode until the given time, e.g. while ArgoCD is upgraded. Data endpoints answer 503 with the message and a Retry-After header counting down to until; with serveStale they keep answering from cached data, even expired, without calling ArgoCD, and answer 503 when the data is not cached. /health reports status maintenance with 200. Posting again replaces the window. The mode ends by itself at until, through DELETE, or on restart, as it is kept in memory only. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param window body types.MaintenanceRequest true "Maintenance window"
+// @Success 200 {object} types.MaintenanceStatus "Maintenance mode entered"
+// @Failure 400 {object} types.ErrorResponse "Invalid maintenance window"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/maintenance [post]
+func (s *Server) postMaintenance(c *gin.Context) {
+	var request types.MaintenanceRequest
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&request); err != nil {
+		s.invalidParamsResponse(c, fmt.Errorf("request body must be a JSON object with until, message and serveStale: %w", err))
+		return
+	}
+	if request.Until.IsZero() {
+		s.invalidParamsResponse(c, errors.New("until is required"))
+		return
+	}
+	if !request.Until.After(clock.OrReal(s.maintenance.clock).Now()) {
+		s.invalidParamsResponse(c, fmt.Errorf("until %s is not in the future", request.Until.Format(time.RFC3339)))
+		return
+	}
+	message := strings.TrimSpace(request.Message)
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+
+	window := s.maintenance.enter(request.Until, message, request.ServeStale)
+	log.Printf("Maintenance mode entered through the admin API until %s (serve stale: %t): %s",
+		window.until.Format(time.RFC3339), window.serveStale, window.message)
+	events.Record(events.MaintenanceEntered, "Maintenance mode entered through the admin API", map[string]string{
+		"until":      window.until.Format(time.RFC3339),
+		"serveStale": strconv.FormatBool(window.serveStale),
+		"message":    window.message,
+	})
+
+	c.JSON(http.StatusOK, window.status())
+}
+
+// deleteMaintenance handles leaving maintenance mode through the admin API
+// @Summary Leave maintenance mode
+// @Description End maintenance mode before its until time. Leaving when not in maintenance is not an error. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} types.MaintenanceStatus "Maintenance mode left"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/maintenance [delete]
+func (s *Server) deleteMaintenance(c *gin.Context) {
+	if s.maintenance.exit() {
+		log.Printf("Maintenance mode left through the admin API")
+		events.Record(events.MaintenanceLeft, "Maintenance mode left through the admin API", nil)
+	}
+	c.JSON(http.StatusOK, types.MaintenanceStatus{})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/auth"
+	"argocd-proxy/services"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+// serveMaintenance calls the maintenance admin endpoint with the admin token
+func serveMaintenance(server *Server, method, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/admin/maintenance", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// decodeHealth decodes a /health response body
+func decodeHealth(t *testing.T, w *httptest.ResponseRecorder) types.HealthResponse {
+	t.Helper()
+	var health types.HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatalf("failed to decode health response: %v", err)
+	}
+	return health
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	server, _ := setupAdminServer()
+	clk := testutils.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	server.maintenance.clock = clk
+
+	w := serveMaintenance(server, http.MethodPost, `{"until": "2026-03-01T12:01:30Z", "message": "ArgoCD upgrade in progress"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var status types.MaintenanceStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to decode maintenance status: %v", err)
+	}
+	if !status.Active || status.Message != "ArgoCD upgrade in progress" || status.ServeStale {
+		t.Errorf("status = %+v, want an active window with the message, not serving stale data", status)
+	}
+
+	// Data endpoints answer 503 with the message and the time left
+	for _, path := range []string{"/applications", "/projects/payments", "/project-groups"} {
+		w = serveMethod(server, http.MethodGet, path, nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusServiceUnavailable)
+		}
+		if got := w.Header().Get("Retry-After"); got != "90" {
+			t.Errorf("GET %s Retry-After = %q, want %q", path, got, "90")
+		}
+		var response types.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode error response: %v", err)
+		}
+		if response.ErrorCode != types.ErrorCodeMaintenance || response.Message != "ArgoCD upgrade in progress" {
+			t.Errorf("GET %s response = %+v, want the maintenance error with the message", path, response)
+		}
+	}
+
+	// Probes and unknown routes are not affected
+	w = serveMethod(server, http.MethodGet, "/health", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /health status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if health := decodeHealth(t, w); health.Status != "maintenance" || health.Maintenance == nil || !health.Maintenance.Active {
+		t.Errorf("health = %+v, want status maintenance with the window", health)
+	}
+	if w = serveMethod(server, http.MethodGet, "/info", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /info status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w = serveMethod(server, http.MethodGet, "/unknown", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /unknown status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// The time left counts down
+	clk.Advance(time.Minute)
+	if got := serveMethod(server, http.MethodGet, "/applications", nil).Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After after a minute = %q, want %q", got, "30")
+	}
+
+	// DELETE ends maintenance
+	if w = serveMaintenance(server, http.MethodDelete, ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w = serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /applications after DELETE status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if health := decodeHealth(t, serveMethod(server, http.MethodGet, "/health", nil)); health.Status != "healthy" || health.Maintenance != nil {
+		t.Errorf("health after DELETE = %+v, want healthy without a window", health)
+	}
+	if got := serveMaintenance(server, http.MethodGet, "").Body.String(); got != `{"active":false}` {
+		t.Errorf("GET after DELETE = %s, want an inactive status", got)
+	}
+}
+
+func TestMaintenanceExpiry(t *testing.T) {
+	server, _ := setupAdminServer()
+	clk := testutils.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	server.maintenance.clock = clk
+
+	if w := serveMaintenance(server, http.MethodPost, `{"until": "2026-03-01T12:10:00Z"}`); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	w := serveMethod(server, http.MethodGet, "/applications", nil)
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), defaultMaintenanceMessage) {
+		t.Fatalf("GET /applications = %d %s, want 503 with the default message", w.Code, w.Body.String())
+	}
+
+	clk.Advance(10 * time.Minute)
+	if w = serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /applications at until status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if health := decodeHealth(t, serveMethod(server, http.MethodGet, "/health", nil)); health.Status != "healthy" {
+		t.Errorf("health status at until = %q, want healthy", health.Status)
+	}
+}
+
+func TestMaintenanceValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing until", `{"message": "upgrade"}`},
+		{"until in the past", `{"until": "2026-03-01T11:59:59Z"}`},
+		{"until now", `{"until": "2026-03-01T12:00:00Z"}`},
+		{"until not RFC 3339", `{"until": "tomorrow"}`},
+		{"unknown field", `{"until": "2026-03-01T13:00:00Z", "reason": "upgrade"}`},
+		{"not an object", `["2026-03-01T13:00:00Z"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := setupAdminServer()
+			server.maintenance.clock = testutils.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+			w := serveMaintenance(server, http.MethodPost, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if w = serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+				t.Errorf("GET /applications status = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+
+	t.Run("admin token required", func(t *testing.T) {
+		server, _ := setupAdminServer()
+		req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(`{"until": "2099-01-01T00:00:00Z"}`))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if w = serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+			t.Errorf("GET /applications status = %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestMaintenanceServeStale(t *testing.T) {
+	var calls atomic.Int32
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/session" {
+			json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "test-token"})
+			return
+		}
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/applications":
+			json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+				{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "web-app"}},
+			}})
+		case "/clusters":
+			json.NewEncoder(w).Encode(types.ArgocdClusterList{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fake.Close()
+
+	server, _ := setupAdminServer()
+	server.config.ArgocdAPIURL = fake.URL
+	// Every cached list is expired by the time it is read again
+	server.config.CacheTTL = time.Nanosecond
+	server.authService = auth.NewAuthService(server.config, nil)
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+
+	if w := serveMethod(server, http.MethodGet, "/applications", nil); w.Code != http.StatusOK {
+		t.Fatalf("warming GET /applications status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if w := serveMaintenance(server, http.MethodPost, `{"until": "2099-01-01T00:00:00Z", "serveStale": true}`); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	warmCalls := calls.Load()
+
+	// Cached data is served as is, without calling ArgoCD
+	w := serveMethod(server, http.MethodGet, "/applications", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"web"`) {
+		t.Fatalf("GET /applications = %d %s, want the cached applications", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(cacheHeader); got != "STALE" {
+		t.Errorf("GET /applications %s = %q, want STALE", cacheHeader, got)
+	}
+	if w = serveMethod(server, http.MethodGet, "/applications/web", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /applications/web status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// Data that is not cached cannot be served
+	w = serveMethod(server, http.MethodGet, "/projects", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("GET /projects = %d with Retry-After %q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(w.Body.String(), string(types.ErrorCodeMaintenance)) {
+		t.Errorf("GET /projects body = %s, want the maintenance error code", w.Body.String())
+	}
+	if w = serveMethod(server, http.MethodGet, "/applications/api", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /applications/api status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	if got := calls.Load(); got != warmCalls {
+		t.Errorf("ArgoCD calls during maintenance = %d, want none", got-warmCalls)
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exemplarKey is the context key under which the exemplar labels of a request are stored
+type exemplarKey struct{}
+
+// WithExemplar returns a context whose latency observations carry labels as their exemplar,
+// such as the trace ID of the request. Labels exceeding prometheus.ExemplarMaxRunes in
+// total are dropped rather than rejected by Prometheus.
+func WithExemplar(ctx context.Context, labels prometheus.Labels) context.Context {
+	runes := 0
+	for name, value := range labels {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	if len(labels) == 0 || runes > prometheus.ExemplarMaxRunes {
+		return ctx
+	}
+	return context.WithValue(ctx, exemplarKey{}, labels)
+}
+
+// ExemplarFromContext returns the exemplar labels carried by ctx, or nil
+func ExemplarFromContext(ctx context.Context) prometheus.Labels {
+	labels, _ := ctx.Value(exemplarKey{}).(prometheus.Labels)
+	return labels
+}
+
+// Observe records v, with the exemplar carried by ctx when exemplars are enabled
+func (m *Metrics) Observe(ctx context.Context, observer prometheus.Observer, v float64) {
+	if m.exemplars {
+		if labels := ExemplarFromContext(ctx); labels != nil {
+			if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+				exemplarObserver.ObserveWithExemplar(v, labels)
+				return
+			}
+		}
+	}
+	observer.Observe(v)
+}
+
+// Observe records v on the default instance, with the exemplar carried by ctx when
+// exemplars are enabled
+func Observe(ctx context.Context, observer prometheus.Observer, v float64) {
+	defaultMetrics.Observe(ctx, observer, v)
+}
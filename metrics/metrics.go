@@ -1,112 +1,526 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// HTTP metrics
-var (
-	HTTPRequestsTotal = promauto.NewCounterVec(
+// Options configures the metric namespace and histogram buckets used by New.
+type Options struct {
+	// Namespace is prepended to every metric name (e.g. "company" -> company_http_requests_total).
+	Namespace string
+	// HTTPBuckets are the histogram buckets for inbound request durations; nil uses prometheus.DefBuckets.
+	HTTPBuckets []float64
+	// UpstreamBuckets are the histogram buckets for ArgoCD API durations; nil uses prometheus.DefBuckets.
+	UpstreamBuckets []float64
+	// Exemplars attaches the trace and request IDs of a request to its latency observations
+	// and serves the OpenMetrics format, the only one carrying exemplars, to scrapers asking for it.
+	Exemplars bool
+}
+
+// DefaultOptions returns unprefixed metric names with Prometheus default buckets.
+func DefaultOptions() Options {
+	return Options{}
+}
+
+// Metrics bundles a Prometheus registry with the instruments registered in it.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	// exemplars enables exemplars on latency observations
+	exemplars bool
+
+	HTTPRequestsTotal    *prometheus.CounterVec
+	HTTPRequestDuration  *prometheus.HistogramVec
+	HTTPRequestsInFlight prometheus.Gauge
+	// HTTPSlowRequestsTotal counts requests that exceeded their route's slow request threshold
+	HTTPSlowRequestsTotal *prometheus.CounterVec
+	// ResponsesTruncatedTotal counts list responses cut to the maximum number of items
+	ResponsesTruncatedTotal *prometheus.CounterVec
+	// HTTPClientAbortedTotal counts requests whose client went away before the response
+	HTTPClientAbortedTotal *prometheus.CounterVec
+
+	ArgocdAPIRequestsTotal   *prometheus.CounterVec
+	ArgocdAPIRequestDuration *prometheus.HistogramVec
+	// ArgocdAPISlowRequestsTotal counts ArgoCD calls slower than the soft upstream deadline
+	ArgocdAPISlowRequestsTotal *prometheus.CounterVec
+	// ArgocdAPIUnknownFieldsTotal counts fields of refreshed ArgoCD lists that the proxy's
+	// types do not declare, per field path, with UPSTREAM_STRICT_DECODE
+	ArgocdAPIUnknownFieldsTotal *prometheus.CounterVec
+	// AppCircuitOpen is 1 per application whose circuit breaker is open; series are
+	// deleted when the circuit stops being open
+	AppCircuitOpen *prometheus.GaugeVec
+	// AppCircuitRejectedTotal counts application reads failed fast by an open circuit
+	AppCircuitRejectedTotal prometheus.Counter
+
+	TokenRefreshTotal    *prometheus.CounterVec
+	TokenRefreshDuration prometheus.Histogram
+
+	CacheHitsTotal   *prometheus.CounterVec
+	CacheMissesTotal *prometheus.CounterVec
+	// CacheRefreshSkippedTotal counts expired cache entries revalidated by resourceVersion
+	CacheRefreshSkippedTotal *prometheus.CounterVec
+	// CacheBackendErrorsTotal counts failed reads and writes of a shared cache backend
+	CacheBackendErrorsTotal *prometheus.CounterVec
+
+	LastSuccessfulFetch *prometheus.GaugeVec
+
+	// AppTransitionsTotal counts health and sync status changes seen by the background poller
+	AppTransitionsTotal *prometheus.CounterVec
+	// AppStateDuration is how long each application has been in its health status, as of
+	// the latest background poll; series are deleted on transitions and removals
+	AppStateDuration *prometheus.GaugeVec
+	// ReconcileAge exports the reconcile age distribution and the applications over the
+	// threshold per project group, as of the latest background poll
+	ReconcileAge *ReconcileAgeCollector
+
+	// DuplicateProjectsTotal counts duplicate project entries returned by ArgoCD
+	DuplicateProjectsTotal prometheus.Counter
+
+	// WorkpoolQueueDepth and WorkpoolWaitDuration track work waiting for an upstream slot
+	WorkpoolQueueDepth   *prometheus.GaugeVec
+	WorkpoolWaitDuration *prometheus.HistogramVec
+
+	// ClientRequestsTotal, ClientResponseBytesTotal and ClientErrorsTotal are labeled by a
+	// hashed client identifier; series are deleted when the client is evicted
+	ClientRequestsTotal      *prometheus.CounterVec
+	ClientResponseBytesTotal *prometheus.CounterVec
+	ClientErrorsTotal        *prometheus.CounterVec
+
+	// ShadowComparisonsTotal counts shadowed calls by method and comparison result
+	ShadowComparisonsTotal *prometheus.CounterVec
+
+	BuildInfo *prometheus.GaugeVec
+	// ConfigDeprecatedKeys is 1 for each deprecated environment variable set at startup
+	ConfigDeprecatedKeys *prometheus.GaugeVec
+}
+
+// New creates a registry with Go runtime and process collectors plus all proxy instruments.
+func New(opts Options) *Metrics {
+	httpBuckets := opts.HTTPBuckets
+	if len(httpBuckets) == 0 {
+		httpBuckets = prometheus.DefBuckets
+	}
+	upstreamBuckets := opts.UpstreamBuckets
+	if len(upstreamBuckets) == 0 {
+		upstreamBuckets = prometheus.DefBuckets
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	factory := promauto.With(registry)
+
+	m := &Metrics{Registry: registry, exemplars: opts.Exemplars}
+
+	// HTTP metrics
+	m.HTTPRequestsTotal = factory.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests.",
+			Namespace: opts.Namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests.",
 		},
 		[]string{"method", "path", "status"},
 	)
-
-	HTTPRequestDuration = promauto.NewHistogramVec(
+	m.HTTPRequestDuration = factory.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "Duration of HTTP requests in seconds.",
-			Buckets: prometheus.DefBuckets,
+			Namespace: opts.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests in seconds.",
+			Buckets:   httpBuckets,
 		},
 		[]string{"method", "path", "status"},
 	)
-
-	HTTPRequestsInFlight = promauto.NewGauge(
+	m.HTTPRequestsInFlight = factory.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "http_requests_in_flight",
-			Help: "Number of HTTP requests currently being served.",
+			Namespace: opts.Namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
 		},
 	)
-)
 
-// ArgoCD upstream API metrics
-var (
-	ArgocdAPIRequestsTotal = promauto.NewCounterVec(
+	m.HTTPSlowRequestsTotal = factory.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "argocd_api_requests_total",
-			Help: "Total number of requests made to the ArgoCD API.",
+			Namespace: opts.Namespace,
+			Name:      "http_slow_requests_total",
+			Help:      "Total number of HTTP requests slower than the configured slow request threshold.",
 		},
-		[]string{"endpoint", "status"},
+		[]string{"path"},
 	)
 
-	ArgocdAPIRequestDuration = promauto.NewHistogramVec(
+	m.ResponsesTruncatedTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "responses_truncated_total",
+			Help:      "Total number of list responses truncated to the maximum number of items.",
+		},
+		[]string{"path"},
+	)
+
+	m.HTTPClientAbortedTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "http_client_aborted_requests_total",
+			Help:      "Total number of HTTP requests whose client disconnected before the response was complete.",
+		},
+		[]string{"path"},
+	)
+
+	// ArgoCD upstream API metrics
+	m.ArgocdAPIRequestsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_api_requests_total",
+			Help:      "Total number of requests made to the ArgoCD API.",
+		},
+		[]string{"endpoint", "status"},
+	)
+	m.ArgocdAPIRequestDuration = factory.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "argocd_api_request_duration_seconds",
-			Help:    "Duration of requests to the ArgoCD API in seconds.",
-			Buckets: prometheus.DefBuckets,
+			Namespace: opts.Namespace,
+			Name:      "argocd_api_request_duration_seconds",
+			Help:      "Duration of requests to the ArgoCD API in seconds.",
+			Buckets:   upstreamBuckets,
 		},
 		[]string{"endpoint"},
 	)
-)
+	m.ArgocdAPISlowRequestsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_api_slow_requests_total",
+			Help:      "Total number of requests to the ArgoCD API slower than the soft upstream deadline.",
+		},
+		[]string{"endpoint"},
+	)
+	m.ArgocdAPIUnknownFieldsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_api_unknown_fields_total",
+			Help:      "Total number of ArgoCD response fields not declared by the proxy's types, found by the strict decode check.",
+		},
+		[]string{"endpoint", "field"},
+	)
+	m.AppCircuitOpen = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_app_circuit_open",
+			Help:      "Set to 1 for each application whose circuit breaker is open.",
+		},
+		[]string{"application"},
+	)
+	m.AppCircuitRejectedTotal = factory.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_app_circuit_rejected_total",
+			Help:      "Total number of application reads failed fast by an open circuit breaker.",
+		},
+	)
 
-// Token metrics
-var (
-	TokenRefreshTotal = promauto.NewCounterVec(
+	// Token metrics
+	m.TokenRefreshTotal = factory.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "token_refresh_total",
-			Help: "Total number of token refresh attempts.",
+			Namespace: opts.Namespace,
+			Name:      "token_refresh_total",
+			Help:      "Total number of token refresh attempts.",
 		},
 		[]string{"result"},
 	)
-
-	TokenRefreshDuration = promauto.NewHistogram(
+	m.TokenRefreshDuration = factory.NewHistogram(
 		prometheus.HistogramOpts{
-			Name:    "token_refresh_duration_seconds",
-			Help:    "Duration of token refresh operations in seconds.",
-			Buckets: prometheus.DefBuckets,
+			Namespace: opts.Namespace,
+			Name:      "token_refresh_duration_seconds",
+			Help:      "Duration of token refresh operations in seconds.",
+			Buckets:   prometheus.DefBuckets,
 		},
 	)
-)
 
-// Cache metrics
-var (
-	CacheHitsTotal = promauto.NewCounterVec(
+	// Cache metrics
+	m.CacheHitsTotal = factory.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "cache_hits_total",
-			Help: "Total number of cache hits.",
+			Namespace: opts.Namespace,
+			Name:      "cache_hits_total",
+			Help:      "Total number of cache hits.",
 		},
 		[]string{"cache"},
 	)
-
-	CacheMissesTotal = promauto.NewCounterVec(
+	m.CacheMissesTotal = factory.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "cache_misses_total",
-			Help: "Total number of cache misses.",
+			Namespace: opts.Namespace,
+			Name:      "cache_misses_total",
+			Help:      "Total number of cache misses.",
 		},
 		[]string{"cache"},
 	)
-)
+	m.CacheRefreshSkippedTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "cache_refresh_skipped_total",
+			Help:      "Total number of cache refreshes skipped because the upstream resourceVersion was unchanged.",
+		},
+		[]string{"cache"},
+	)
+	m.CacheBackendErrorsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "cache_backend_errors_total",
+			Help:      "Total number of failed reads and writes of the shared cache backend.",
+		},
+		[]string{"cache"},
+	)
+
+	// Upstream freshness metrics; a resource's series only exists after its first successful fetch
+	m.LastSuccessfulFetch = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_last_successful_fetch_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful fetch from the ArgoCD API per resource.",
+		},
+		[]string{"resource"},
+	)
+
+	// Application status transitions observed by the background poller
+	m.AppTransitionsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_app_transitions_total",
+			Help:      "Total number of application health and sync status transitions observed by the background poller.",
+		},
+		[]string{"from", "to"},
+	)
+	m.AppStateDuration = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_app_state_duration_seconds",
+			Help:      "Seconds each application has been in its current health status, as of the latest background poll.",
+		},
+		[]string{"app", "project", "health"},
+	)
+	m.ReconcileAge = newReconcileAgeCollector(opts.Namespace)
+	registry.MustRegister(m.ReconcileAge)
 
-// Build info metric
-var BuildInfo = promauto.NewGaugeVec(
-	prometheus.GaugeOpts{
-		Name: "build_info",
-		Help: "Build information for the argocd-proxy.",
-	},
-	[]string{"version", "build_time"},
+	// Duplicate project entries in upstream project lists
+	m.DuplicateProjectsTotal = factory.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_duplicate_projects_total",
+			Help:      "Total number of duplicate project entries returned by the ArgoCD API.",
+		},
+	)
+
+	// Bounded upstream work pool metrics
+	m.WorkpoolQueueDepth = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_workpool_queue_depth",
+			Help:      "Number of tasks waiting for a free slot in the work pool.",
+		},
+		[]string{"pool"},
+	)
+	m.WorkpoolWaitDuration = factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_workpool_wait_duration_seconds",
+			Help:      "Time tasks waited for a free slot in the work pool in seconds.",
+			Buckets:   upstreamBuckets,
+		},
+		[]string{"pool"},
+	)
+
+	// Per-client request metrics
+	m.ClientRequestsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_client_requests_total",
+			Help:      "Total number of requests served per hashed client identifier.",
+		},
+		[]string{"client"},
+	)
+	m.ClientResponseBytesTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_client_response_bytes_total",
+			Help:      "Total number of response body bytes served per hashed client identifier.",
+		},
+		[]string{"client"},
+	)
+	m.ClientErrorsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_client_errors_total",
+			Help:      "Total number of requests answered with a 4xx or 5xx status per hashed client identifier.",
+		},
+		[]string{"client"},
+	)
+
+	// Shadow comparisons of candidate implementations
+	m.ShadowComparisonsTotal = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "argocd_proxy_shadow_comparisons_total",
+			Help:      "Total number of shadowed calls by method and comparison result.",
+		},
+		[]string{"method", "result"},
+	)
+
+	// Build info metric
+	m.BuildInfo = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "build_info",
+			Help:      "Build information for the argocd-proxy.",
+		},
+		[]string{"version", "build_time"},
+	)
+
+	m.ConfigDeprecatedKeys = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Name:      "config_deprecated_keys",
+			Help:      "Deprecated configuration keys set at startup, by key and replacement.",
+		},
+		[]string{"key", "replacement"},
+	)
+
+	return m
+}
+
+// Package-level instruments backed by the default Metrics instance. They are kept
+// so callers can record metrics without threading a *Metrics through every layer;
+// SetDefault repoints them at a configured instance during startup.
+var (
+	defaultMetrics = New(DefaultOptions())
+
+	HTTPRequestsTotal    = defaultMetrics.HTTPRequestsTotal
+	HTTPRequestDuration  = defaultMetrics.HTTPRequestDuration
+	HTTPRequestsInFlight = defaultMetrics.HTTPRequestsInFlight
+
+	HTTPSlowRequestsTotal   = defaultMetrics.HTTPSlowRequestsTotal
+	ResponsesTruncatedTotal = defaultMetrics.ResponsesTruncatedTotal
+	HTTPClientAbortedTotal  = defaultMetrics.HTTPClientAbortedTotal
+
+	ArgocdAPIRequestsTotal      = defaultMetrics.ArgocdAPIRequestsTotal
+	ArgocdAPIRequestDuration    = defaultMetrics.ArgocdAPIRequestDuration
+	ArgocdAPISlowRequestsTotal  = defaultMetrics.ArgocdAPISlowRequestsTotal
+	ArgocdAPIUnknownFieldsTotal = defaultMetrics.ArgocdAPIUnknownFieldsTotal
+
+	AppCircuitOpen          = defaultMetrics.AppCircuitOpen
+	AppCircuitRejectedTotal = defaultMetrics.AppCircuitRejectedTotal
+
+	TokenRefreshTotal    = defaultMetrics.TokenRefreshTotal
+	TokenRefreshDuration = defaultMetrics.TokenRefreshDuration
+
+	CacheHitsTotal   = defaultMetrics.CacheHitsTotal
+	CacheMissesTotal = defaultMetrics.CacheMissesTotal
+
+	CacheRefreshSkippedTotal = defaultMetrics.CacheRefreshSkippedTotal
+	CacheBackendErrorsTotal  = defaultMetrics.CacheBackendErrorsTotal
+
+	LastSuccessfulFetch = defaultMetrics.LastSuccessfulFetch
+
+	AppTransitionsTotal = defaultMetrics.AppTransitionsTotal
+	AppStateDuration    = defaultMetrics.AppStateDuration
+	ReconcileAge        = defaultMetrics.ReconcileAge
+
+	DuplicateProjectsTotal = defaultMetrics.DuplicateProjectsTotal
+
+	WorkpoolQueueDepth   = defaultMetrics.WorkpoolQueueDepth
+	WorkpoolWaitDuration = defaultMetrics.WorkpoolWaitDuration
+
+	ClientRequestsTotal      = defaultMetrics.ClientRequestsTotal
+	ClientResponseBytesTotal = defaultMetrics.ClientResponseBytesTotal
+	ClientErrorsTotal        = defaultMetrics.ClientErrorsTotal
+
+	ShadowComparisonsTotal = defaultMetrics.ShadowComparisonsTotal
+
+	BuildInfo            = defaultMetrics.BuildInfo
+	ConfigDeprecatedKeys = defaultMetrics.ConfigDeprecatedKeys
 )
 
+// Default returns the instance currently behind the package-level instruments.
+func Default() *Metrics {
+	return defaultMetrics
+}
+
+// SetDefault makes m the instance behind the package-level instruments and helpers.
+// It must be called before the router is set up and requests are served.
+func SetDefault(m *Metrics) {
+	defaultMetrics = m
+
+	HTTPRequestsTotal = m.HTTPRequestsTotal
+	HTTPRequestDuration = m.HTTPRequestDuration
+	HTTPRequestsInFlight = m.HTTPRequestsInFlight
+
+	HTTPSlowRequestsTotal = m.HTTPSlowRequestsTotal
+	ResponsesTruncatedTotal = m.ResponsesTruncatedTotal
+	HTTPClientAbortedTotal = m.HTTPClientAbortedTotal
+
+	ArgocdAPIRequestsTotal = m.ArgocdAPIRequestsTotal
+	ArgocdAPIRequestDuration = m.ArgocdAPIRequestDuration
+	ArgocdAPISlowRequestsTotal = m.ArgocdAPISlowRequestsTotal
+	ArgocdAPIUnknownFieldsTotal = m.ArgocdAPIUnknownFieldsTotal
+
+	AppCircuitOpen = m.AppCircuitOpen
+	AppCircuitRejectedTotal = m.AppCircuitRejectedTotal
+
+	TokenRefreshTotal = m.TokenRefreshTotal
+	TokenRefreshDuration = m.TokenRefreshDuration
+
+	CacheHitsTotal = m.CacheHitsTotal
+	CacheMissesTotal = m.CacheMissesTotal
+
+	CacheRefreshSkippedTotal = m.CacheRefreshSkippedTotal
+	CacheBackendErrorsTotal = m.CacheBackendErrorsTotal
+
+	LastSuccessfulFetch = m.LastSuccessfulFetch
+
+	AppTransitionsTotal = m.AppTransitionsTotal
+	AppStateDuration = m.AppStateDuration
+	ReconcileAge = m.ReconcileAge
+
+	DuplicateProjectsTotal = m.DuplicateProjectsTotal
+
+	WorkpoolQueueDepth = m.WorkpoolQueueDepth
+	WorkpoolWaitDuration = m.WorkpoolWaitDuration
+
+	ClientRequestsTotal = m.ClientRequestsTotal
+	ClientResponseBytesTotal = m.ClientResponseBytesTotal
+	ClientErrorsTotal = m.ClientErrorsTotal
+
+	ShadowComparisonsTotal = m.ShadowComparisonsTotal
+
+	BuildInfo = m.BuildInfo
+	ConfigDeprecatedKeys = m.ConfigDeprecatedKeys
+}
+
 // SetBuildInfo sets the build info gauge to 1 with the given labels.
+func (m *Metrics) SetBuildInfo(version, buildTime string) {
+	m.BuildInfo.WithLabelValues(version, buildTime).Set(1)
+}
+
+// SetBuildInfo sets the build info gauge of the default instance.
 func SetBuildInfo(version, buildTime string) {
-	BuildInfo.WithLabelValues(version, buildTime).Set(1)
+	defaultMetrics.SetBuildInfo(version, buildTime)
+}
+
+// SetConfigDeprecatedKey marks key as a deprecated configuration key in use, replaced by
+// replacement.
+func (m *Metrics) SetConfigDeprecatedKey(key, replacement string) {
+	m.ConfigDeprecatedKeys.WithLabelValues(key, replacement).Set(1)
+}
+
+// SetConfigDeprecatedKey marks a deprecated configuration key on the default instance.
+func SetConfigDeprecatedKey(key, replacement string) {
+	defaultMetrics.SetConfigDeprecatedKey(key, replacement)
 }
 
 // normalizePath collapses path parameters to reduce cardinality.
@@ -119,34 +533,49 @@ func normalizePath(c *gin.Context) string {
 }
 
 // GinMiddleware returns a Gin middleware that records Prometheus metrics.
-func GinMiddleware() gin.HandlerFunc {
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.URL.Path == "/metrics" {
 			c.Next()
 			return
 		}
 
-		HTTPRequestsInFlight.Inc()
+		m.HTTPRequestsInFlight.Inc()
 		start := time.Now()
 
 		c.Next()
 
-		HTTPRequestsInFlight.Dec()
+		m.HTTPRequestsInFlight.Dec()
 
 		status := strconv.Itoa(c.Writer.Status())
 		path := normalizePath(c)
 		method := c.Request.Method
 		duration := time.Since(start).Seconds()
 
-		HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
-		HTTPRequestDuration.WithLabelValues(method, path, status).Observe(duration)
+		m.HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
+		m.Observe(c.Request.Context(), m.HTTPRequestDuration.WithLabelValues(method, path, status), duration)
+
+		// The server cancels the request context once the client disconnects
+		if errors.Is(c.Request.Context().Err(), context.Canceled) {
+			m.HTTPClientAbortedTotal.WithLabelValues(path).Inc()
+		}
 	}
 }
 
+// GinMiddleware returns the metrics middleware of the default instance.
+func GinMiddleware() gin.HandlerFunc {
+	return defaultMetrics.GinMiddleware()
+}
+
 // Handler returns the Prometheus metrics HTTP handler for use with Gin.
-func Handler() gin.HandlerFunc {
-	h := promhttp.Handler()
+func (m *Metrics) Handler() gin.HandlerFunc {
+	h := promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{EnableOpenMetrics: m.exemplars})
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
+
+// Handler returns the metrics handler of the default instance.
+func Handler() gin.HandlerFunc {
+	return defaultMetrics.Handler()
+}
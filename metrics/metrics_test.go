@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -108,6 +109,20 @@ func TestSetBuildInfo(t *testing.T) {
 	}
 }
 
+func TestSetConfigDeprecatedKey(t *testing.T) {
+	m := New(DefaultOptions())
+	m.SetConfigDeprecatedKey("API_KEYS", "API_KEYS_JSON")
+
+	router := gin.New()
+	router.GET("/metrics", m.Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if want := `config_deprecated_keys{key="API_KEYS",replacement="API_KEYS_JSON"} 1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected %s in output, got:\n%s", want, w.Body.String())
+	}
+}
+
 func TestNormalizePath(t *testing.T) {
 	router := gin.New()
 	router.Use(GinMiddleware())
@@ -136,3 +151,142 @@ func TestNormalizePath(t *testing.T) {
 		t.Errorf("expected normalized path label, got:\n%s", body)
 	}
 }
+
+func TestNewWithCustomNamespaceAndBuckets(t *testing.T) {
+	m := New(Options{
+		Namespace:       "company",
+		HTTPBuckets:     []float64{0.05, 0.1, 0.25},
+		UpstreamBuckets: []float64{0.5, 1},
+	})
+	m.SetBuildInfo("v1.0.0", "now")
+	m.ArgocdAPIRequestDuration.WithLabelValues("/applications").Observe(0.3)
+
+	router := gin.New()
+	router.Use(m.GinMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/metrics", m.Handler())
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	for _, expected := range []string{
+		`company_http_request_duration_seconds_bucket{method="GET",path="/test",status="200",le="0.05"}`,
+		`company_http_request_duration_seconds_bucket{method="GET",path="/test",status="200",le="0.1"}`,
+		`company_http_request_duration_seconds_bucket{method="GET",path="/test",status="200",le="0.25"}`,
+		`company_argocd_api_request_duration_seconds_bucket{endpoint="/applications",le="0.5"}`,
+		`company_http_requests_total{method="GET",path="/test",status="200"} 1`,
+		`company_build_info{build_time="now",version="v1.0.0"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in scrape output, got:\n%s", expected, body)
+		}
+	}
+
+	if strings.Contains(body, `company_http_request_duration_seconds_bucket{method="GET",path="/test",status="200",le="0.005"}`) {
+		t.Errorf("default bucket boundaries should not be present with custom buckets")
+	}
+}
+
+func TestNewInstancesAreIsolated(t *testing.T) {
+	first := New(DefaultOptions())
+	second := New(DefaultOptions())
+
+	first.CacheHitsTotal.WithLabelValues("projects").Inc()
+
+	router := gin.New()
+	router.GET("/metrics", second.Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if strings.Contains(w.Body.String(), `cache_hits_total{cache="projects"}`) {
+		t.Errorf("metrics from one instance leaked into another registry")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	original := defaultMetrics
+	defer SetDefault(original)
+
+	custom := New(Options{Namespace: "custom"})
+	SetDefault(custom)
+
+	CacheMissesTotal.WithLabelValues("applications").Inc()
+
+	router := gin.New()
+	router.GET("/metrics", Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.Contains(w.Body.String(), `custom_cache_misses_total{cache="applications"} 1`) {
+		t.Errorf("package-level instruments should record into the default instance, got:\n%s", w.Body.String())
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	tests := []struct {
+		name          string
+		exemplars     bool
+		accept        string
+		wantExemplars bool
+	}{
+		{name: "OpenMetrics scrape", exemplars: true, accept: "application/openmetrics-text; version=1.0.0", wantExemplars: true},
+		{name: "text format scrape", exemplars: true, accept: "text/plain"},
+		{name: "disabled", accept: "application/openmetrics-text; version=1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Exemplars = tt.exemplars
+			m := New(opts)
+
+			labels := prometheus.Labels{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"}
+			m.Observe(WithExemplar(context.Background(), labels), m.ArgocdAPIRequestDuration.WithLabelValues("/applications"), 0.3)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(WithExemplar(c.Request.Context(), labels))
+			})
+			router.Use(m.GinMiddleware())
+			router.GET("/test", func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			router.GET("/metrics", m.Handler())
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			body := w.Body.String()
+
+			if got := strings.HasPrefix(w.Header().Get("Content-Type"), "application/openmetrics-text"); got != tt.wantExemplars {
+				t.Errorf("Content-Type = %q, want OpenMetrics %v", w.Header().Get("Content-Type"), tt.wantExemplars)
+			}
+			for _, metric := range []string{"http_request_duration_seconds_bucket", "argocd_api_request_duration_seconds_bucket"} {
+				found := false
+				for _, line := range strings.Split(body, "\n") {
+					if strings.HasPrefix(line, metric) && strings.Contains(line, `# {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"}`) {
+						found = true
+					}
+				}
+				if found != tt.wantExemplars {
+					t.Errorf("%s exemplar present = %v, want %v", metric, found, tt.wantExemplars)
+				}
+			}
+		})
+	}
+}
+
+func TestWithExemplarDropsOversizedLabels(t *testing.T) {
+	labels := prometheus.Labels{"trace_id": strings.Repeat("a", prometheus.ExemplarMaxRunes)}
+	if got := ExemplarFromContext(WithExemplar(context.Background(), labels)); got != nil {
+		t.Errorf("ExemplarFromContext() = %v, want oversized labels dropped", got)
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReconcileAgeBuckets are the reconcile age histogram buckets in seconds, from 30s to a day
+var ReconcileAgeBuckets = []float64{30, 60, 120, 300, 600, 900, 1800, 3600, 7200, 21600, 86400}
+
+// GroupReconcileAges are the reconcile ages of the applications of one project group
+type GroupReconcileAges struct {
+	// Ages holds the seconds since each application was last reconciled; applications
+	// never reconciled are +Inf
+	Ages []float64
+	// Exceeding is the number of applications over the reconcile age threshold
+	Exceeding int
+}
+
+// ReconcileAgeCollector exports the reconcile ages of the latest application snapshot per
+// project group. Unlike a regular histogram it does not accumulate: every Update replaces
+// the distribution, so bucket counts are numbers of applications and groups missing from
+// the latest snapshot have no series.
+type ReconcileAgeCollector struct {
+	ageDesc       *prometheus.Desc
+	exceedingDesc *prometheus.Desc
+	buckets       []float64
+
+	mu     sync.RWMutex
+	groups map[string]GroupReconcileAges
+}
+
+func newReconcileAgeCollector(namespace string) *ReconcileAgeCollector {
+	return &ReconcileAgeCollector{
+		ageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "argocd_proxy_app_reconcile_age_seconds"),
+			"Seconds since each application was last reconciled by ArgoCD per project group, as of the latest background poll.",
+			[]string{"group"}, nil,
+		),
+		exceedingDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "argocd_proxy_apps_reconcile_age_exceeded"),
+			"Number of applications per project group not reconciled within the reconcile age threshold, as of the latest background poll.",
+			[]string{"group"}, nil,
+		),
+		buckets: ReconcileAgeBuckets,
+		groups:  map[string]GroupReconcileAges{},
+	}
+}
+
+// Update replaces the exported snapshot with groups, keyed by group name
+func (c *ReconcileAgeCollector) Update(groups map[string]GroupReconcileAges) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups = groups
+}
+
+// Describe implements prometheus.Collector
+func (c *ReconcileAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ageDesc
+	ch <- c.exceedingDesc
+}
+
+// Collect implements prometheus.Collector. Applications never reconciled fall only in
+// the +Inf bucket and add nothing to the sum.
+func (c *ReconcileAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.groups))
+	for name := range c.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		group := c.groups[name]
+		counts := make(map[float64]uint64, len(c.buckets))
+		for _, bound := range c.buckets {
+			counts[bound] = 0
+		}
+		sum := 0.0
+		for _, age := range group.Ages {
+			if !math.IsInf(age, 1) {
+				sum += age
+			}
+			for _, bound := range c.buckets {
+				if age <= bound {
+					counts[bound]++
+				}
+			}
+		}
+		ch <- prometheus.MustNewConstHistogram(c.ageDesc, uint64(len(group.Ages)), sum, counts, name)
+		ch <- prometheus.MustNewConstMetric(c.exceedingDesc, prometheus.GaugeValue, float64(group.Exceeding), name)
+	}
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReconcileAgeCollector(t *testing.T) {
+	c := newReconcileAgeCollector("")
+	c.Update(map[string]GroupReconcileAges{
+		"production": {Ages: []float64{10, 45, 590, 1200, math.Inf(1)}, Exceeding: 2},
+		"staging":    {Ages: []float64{}},
+	})
+
+	want := `
+# HELP argocd_proxy_app_reconcile_age_seconds Seconds since each application was last reconciled by ArgoCD per project group, as of the latest background poll.
+# TYPE argocd_proxy_app_reconcile_age_seconds histogram
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="30"} 1
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="60"} 2
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="120"} 2
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="300"} 2
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="600"} 3
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="900"} 3
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="1800"} 4
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="3600"} 4
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="7200"} 4
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="21600"} 4
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="86400"} 4
+argocd_proxy_app_reconcile_age_seconds_bucket{group="production",le="+Inf"} 5
+argocd_proxy_app_reconcile_age_seconds_sum{group="production"} 1845
+argocd_proxy_app_reconcile_age_seconds_count{group="production"} 5
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="30"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="60"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="120"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="300"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="600"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="900"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="1800"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="3600"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="7200"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="21600"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="86400"} 0
+argocd_proxy_app_reconcile_age_seconds_bucket{group="staging",le="+Inf"} 0
+argocd_proxy_app_reconcile_age_seconds_sum{group="staging"} 0
+argocd_proxy_app_reconcile_age_seconds_count{group="staging"} 0
+# HELP argocd_proxy_apps_reconcile_age_exceeded Number of applications per project group not reconciled within the reconcile age threshold, as of the latest background poll.
+# TYPE argocd_proxy_apps_reconcile_age_exceeded gauge
+argocd_proxy_apps_reconcile_age_exceeded{group="production"} 2
+argocd_proxy_apps_reconcile_age_exceeded{group="staging"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+
+	// Each update replaces the previous snapshot rather than adding to it
+	c.Update(map[string]GroupReconcileAges{"staging": {Ages: []float64{5}}})
+	if got := testutil.CollectAndCount(c, "argocd_proxy_app_reconcile_age_seconds"); got != 1 {
+		t.Errorf("histograms after update = %d, want 1: the production series must be gone", got)
+	}
+}
+
+func TestReconcileAgeCollectorNamespace(t *testing.T) {
+	m := New(Options{Namespace: "company"})
+	m.ReconcileAge.Update(map[string]GroupReconcileAges{"production": {Ages: []float64{5}}})
+
+	families, err := m.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() == "company_argocd_proxy_apps_reconcile_age_exceeded" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("company_argocd_proxy_apps_reconcile_age_exceeded not registered")
+	}
+}
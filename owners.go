@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+)
+
+// ownerAnnotation returns the annotation key identifying application owners, the first
+// of OWNER_ANNOTATIONS, or "" when ownership is not configured
+func (s *Server) ownerAnnotation() string {
+	if len(s.config.OwnerAnnotations) == 0 {
+		return ""
+	}
+	return s.config.OwnerAnnotations[0]
+}
+
+// bindOwner reads the ?owner= filter, which requires OWNER_ANNOTATIONS
+func (s *Server) bindOwner(b *params.Binder) string {
+	if s.ownerAnnotation() == "" {
+		b.Forbid("owner", "requires OWNER_ANNOTATIONS")
+		return ""
+	}
+	return b.String("owner")
+}
+
+// getOwners handles the application owners endpoint
+// @Summary Get application owners
+// @Description Get the distinct owners of the filtered applications, read from the first OWNER_ANNOTATIONS annotation, with the number of applications each owns. Only registered when OWNER_ANNOTATIONS is set
+// @Tags applications
+// @Produce json
+// @Success 200 {object} types.OwnersResponse "Application owners"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /owners [get]
+func (s *Server) getOwners(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.CountOwners(applications, s.ownerAnnotation()))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// setupOwnersServer builds a server extracting ownership from OWNER_ANNOTATIONS, with
+// applications owned as given by company.io/owner
+func setupOwnersServer(ownerAnnotations []string, owners map[string]string) *Server {
+	server := setupTestServer()
+	server.config.OwnerAnnotations = ownerAnnotations
+	mockService := server.argocdService.(*MockArgocdService)
+	for name, owner := range owners {
+		app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name}}
+		if owner != "" {
+			app.Metadata.Annotations = map[string]string{"company.io/owner": owner}
+		}
+		mockService.applications.Items = append(mockService.applications.Items, app)
+	}
+	server.setupRouter()
+	return server
+}
+
+func TestGetOwners(t *testing.T) {
+	server := setupOwnersServer([]string{"company.io/owner", "company.io/slack-channel"},
+		map[string]string{"api": "payments", "worker": "payments", "web": "web-team", "legacy": ""})
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/owners", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /owners status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response types.OwnersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GET /owners invalid JSON response: %v", err)
+	}
+	want := types.OwnersResponse{
+		Annotation: "company.io/owner",
+		Owners:     []types.OwnerCount{{Owner: "payments", Applications: 2}, {Owner: "web-team", Applications: 1}},
+		Unowned:    1,
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("GET /owners = %+v, want %+v", response, want)
+	}
+}
+
+func TestGetOwnersDisabled(t *testing.T) {
+	server := setupOwnersServer(nil, map[string]string{"api": "payments"})
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/owners", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /owners status = %d, want %d without OWNER_ANNOTATIONS", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestApplicationsOwnerFilter(t *testing.T) {
+	owners := map[string]string{"api": "payments", "web": "web-team", "legacy": ""}
+
+	tests := []struct {
+		name             string
+		ownerAnnotations []string
+		path             string
+		expectedStatus   int
+		expectedApps     []string
+	}{
+		{name: "filter by owner", ownerAnnotations: []string{"company.io/owner"}, path: "/applications/names?owner=payments", expectedStatus: http.StatusOK, expectedApps: []string{"api"}},
+		{name: "unknown owner", ownerAnnotations: []string{"company.io/owner"}, path: "/applications/names?owner=nobody", expectedStatus: http.StatusOK, expectedApps: []string{}},
+		{name: "names by owner", ownerAnnotations: []string{"company.io/owner"}, path: "/applications/names?owner=web-team", expectedStatus: http.StatusOK, expectedApps: []string{"web"}},
+		{name: "owner without OWNER_ANNOTATIONS", path: "/applications?owner=payments", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupOwnersServer(tt.ownerAnnotations, owners)
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET %s status = %d, want %d: %s", tt.path, w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var names []string
+			if err := json.Unmarshal(w.Body.Bytes(), &names); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("GET %s apps = %v, want %v", tt.path, names, tt.expectedApps)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package params
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"argocd-proxy/selector"
+)
+
+// Known ArgoCD health statuses accepted by ?health= filters
+var HealthStatuses = []string{"Healthy", "Progressing", "Degraded", "Suspended", "Missing", "Unknown"}
+
+// Known ArgoCD sync statuses accepted by ?sync= filters
+var SyncStatuses = []string{"Synced", "OutOfSync", "Unknown"}
+
+// MaxValueLength caps the length of any single query parameter value
+const MaxValueLength = 512
+
+// ValidationError describes a single rejected query parameter
+type ValidationError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid query parameter %q: %s", e.Field, e.Reason)
+}
+
+// Errors is a list of validation errors collected while binding a request
+type Errors []*ValidationError
+
+// Error implements the error interface
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields returns the names of the offending parameters in order of appearance
+func (e Errors) Fields() []string {
+	fields := make([]string, len(e))
+	for i, err := range e {
+		fields[i] = err.Field
+	}
+	return fields
+}
+
+// Binder reads typed values from query parameters and collects validation errors,
+// so a handler can bind every parameter and report all problems at once
+type Binder struct {
+	query url.Values
+	errs  Errors
+}
+
+// NewBinder creates a binder over the given query values
+func NewBinder(query url.Values) *Binder {
+	return &Binder{query: query}
+}
+
+// Err returns the collected validation errors, or nil when all parameters were valid
+func (b *Binder) Err() error {
+	if len(b.errs) == 0 {
+		return nil
+	}
+	return b.errs
+}
+
+// fail records a validation error for a parameter
+func (b *Binder) fail(field, format string, args ...interface{}) {
+	b.errs = append(b.errs, &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+// raw returns the trimmed value of a parameter, rejecting oversized values
+func (b *Binder) raw(name string) string {
+	value := strings.TrimSpace(b.query.Get(name))
+	if len(value) > MaxValueLength {
+		b.fail(name, "value exceeds %d characters", MaxValueLength)
+		return ""
+	}
+	return value
+}
+
+// String returns a free-form string parameter
+func (b *Binder) String(name string) string {
+	return b.raw(name)
+}
+
+// Enum returns a parameter restricted to one of the allowed values, or def when unset
+func (b *Binder) Enum(name, def string, allowed ...string) string {
+	value := b.raw(name)
+	if value == "" {
+		return def
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value
+		}
+	}
+	b.fail(name, "must be one of %s", strings.Join(allowed, ", "))
+	return def
+}
+
+// EnumSet returns a comma-separated parameter whose entries must all be allowed values.
+// Matching is case-insensitive and entries are returned in their canonical casing.
+func (b *Binder) EnumSet(name string, allowed ...string) []string {
+	value := b.raw(name)
+	if value == "" {
+		return nil
+	}
+
+	var result []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		canonical := ""
+		for _, candidate := range allowed {
+			if strings.EqualFold(entry, candidate) {
+				canonical = candidate
+				break
+			}
+		}
+		if canonical == "" {
+			b.fail(name, "%q is not one of %s", entry, strings.Join(allowed, ", "))
+			return nil
+		}
+		result = append(result, canonical)
+	}
+	return result
+}
+
+// Forbid rejects a parameter the caller is not allowed to use, if it is set
+func (b *Binder) Forbid(name, reason string) {
+	if b.query.Has(name) {
+		b.fail(name, "%s", reason)
+	}
+}
+
+// Int returns an integer parameter bounded to [min, max], or def when unset
+func (b *Binder) Int(name string, def, min, max int) int {
+	value := b.raw(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		b.fail(name, "must be an integer")
+		return def
+	}
+	if n < min || n > max {
+		b.fail(name, "must be between %d and %d", min, max)
+		return def
+	}
+	return n
+}
+
+// Bool returns a boolean parameter, or def when unset
+func (b *Binder) Bool(name string, def bool) bool {
+	value := b.raw(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		b.fail(name, "must be true or false")
+		return def
+	}
+	return parsed
+}
+
+// OptionalBool returns a boolean parameter, or nil when unset or invalid
+func (b *Binder) OptionalBool(name string) *bool {
+	value := b.raw(name)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		b.fail(name, "must be true or false")
+		return nil
+	}
+	return &parsed
+}
+
+// Duration returns a Go duration parameter bounded to [min, max], or def when unset.
+// A trailing "d" is accepted as a number of days (e.g. "30d").
+func (b *Binder) Duration(name string, def, min, max time.Duration) time.Duration {
+	value := b.raw(name)
+	if value == "" {
+		return def
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		b.fail(name, "must be a duration such as 30s, 72h or 30d")
+		return def
+	}
+	if d < min || d > max {
+		b.fail(name, "must be between %s and %s", min, max)
+		return def
+	}
+	return d
+}
+
+// LabelSelector returns a parameter in Kubernetes label selector syntax, such as
+// "team=payments,tier!=internal,env in (prod,staging)", or nil when unset
+func (b *Binder) LabelSelector(name string) selector.Selector {
+	parsed, err := selector.Parse(b.raw(name))
+	if err != nil {
+		b.fail(name, "%v", err)
+		return nil
+	}
+	return parsed
+}
+
+// ParseDuration parses a Go duration, additionally accepting whole days such as "30d"
+func ParseDuration(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid day duration %q", value)
+		}
+		if int64(n) > math.MaxInt64/int64(24*time.Hour) {
+			return 0, fmt.Errorf("day duration %q out of range", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// CheckQuery validates a raw query string against a total length cap and, when
+// allowed is non-nil, rejects parameters that are not in the allowed list
+func CheckQuery(rawQuery string, maxLength int, allowed []string) error {
+	if maxLength > 0 && len(rawQuery) > maxLength {
+		return Errors{{Field: "query", Reason: fmt.Sprintf("query string exceeds %d characters", maxLength)}}
+	}
+	if allowed == nil {
+		return nil
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Errors{{Field: "query", Reason: "malformed query string"}}
+	}
+
+	permitted := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		permitted[name] = true
+	}
+
+	var unknown []string
+	for name := range query {
+		if !permitted[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	var errs Errors
+	for _, name := range unknown {
+		errs = append(errs, &ValidationError{Field: name, Reason: "unknown parameter"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
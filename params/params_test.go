@@ -0,0 +1,338 @@
+package params
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/selector"
+)
+
+func bind(rawQuery string) *Binder {
+	query, _ := url.ParseQuery(rawQuery)
+	return NewBinder(query)
+}
+
+// assertFields checks that binding failed exactly for the expected fields
+func assertFields(t *testing.T, b *Binder, expected []string) {
+	t.Helper()
+	err := b.Err()
+	if len(expected) == 0 {
+		if err != nil {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+		return
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	if !reflect.DeepEqual(errs.Fields(), expected) {
+		t.Errorf("failed fields = %v, want %v", errs.Fields(), expected)
+	}
+}
+
+func TestBinderString(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    []string
+	}{
+		{"unset", "", "", nil},
+		{"trimmed", "q=%20value%20", "value", nil},
+		{"oversized", "q=" + strings.Repeat("a", MaxValueLength+1), "", []string{"q"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.String("q"); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderEnum(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    []string
+	}{
+		{"unset uses default", "", "substring", nil},
+		{"allowed value", "mode=exact", "exact", nil},
+		{"disallowed value", "mode=regex", "substring", []string{"mode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.Enum("mode", "substring", "substring", "exact"); got != tt.expected {
+				t.Errorf("Enum() = %q, want %q", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderEnumSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+		fails    []string
+	}{
+		{"unset", "", nil, nil},
+		{"single", "health=Healthy", []string{"Healthy"}, nil},
+		{"multiple canonicalized", "health=degraded,%20progressing", []string{"Degraded", "Progressing"}, nil},
+		{"unknown entry", "health=Healthy,Sad", nil, []string{"health"}},
+		{"empty entry", "health=Healthy,,Degraded", nil, []string{"health"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.EnumSet("health", HealthStatuses...); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("EnumSet() = %v, want %v", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		fails    []string
+	}{
+		{"unset uses default", "", 50, nil},
+		{"within bounds", "limit=10", 10, nil},
+		{"lower bound", "limit=1", 1, nil},
+		{"upper bound", "limit=500", 500, nil},
+		{"below min", "limit=0", 50, []string{"limit"}},
+		{"above max", "limit=501", 50, []string{"limit"}},
+		{"not a number", "limit=ten", 50, []string{"limit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.Int("limit", 50, 1, 500); got != tt.expected {
+				t.Errorf("Int() = %d, want %d", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderForbid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		fails []string
+	}{
+		{"unset", "", nil},
+		{"set", "maxItems=10", []string{"maxItems"}},
+		{"set without a value", "maxItems=", []string{"maxItems"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			b.Forbid("maxItems", "requires the admin token")
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderBool(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected bool
+		fails    []string
+	}{
+		{"unset uses default", "", false, nil},
+		{"true", "verbose=true", true, nil},
+		{"numeric", "verbose=1", true, nil},
+		{"false", "verbose=false", false, nil},
+		{"invalid", "verbose=yes", false, []string{"verbose"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.Bool("verbose", false); got != tt.expected {
+				t.Errorf("Bool() = %v, want %v", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderOptionalBool(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected *bool
+		fails    []string
+	}{
+		{"unset", "", nil, nil},
+		{"true", "autoSync=true", new(true), nil},
+		{"false", "autoSync=0", new(false), nil},
+		{"invalid", "autoSync=maybe", nil, []string{"autoSync"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.OptionalBool("autoSync"); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("OptionalBool() = %v, want %v", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected time.Duration
+		fails    []string
+	}{
+		{"unset uses default", "", time.Hour, nil},
+		{"go duration", "since=72h", 72 * time.Hour, nil},
+		{"days", "since=30d", 30 * 24 * time.Hour, nil},
+		{"below min", "since=1s", time.Hour, []string{"since"}},
+		{"above max", "since=400d", time.Hour, []string{"since"}},
+		{"invalid", "since=soon", time.Hour, []string{"since"}},
+		{"negative days", "since=-1d", time.Hour, []string{"since"}},
+		{"overflowing days", "since=213504d", time.Hour, []string{"since"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.Duration("since", time.Hour, time.Minute, 365*24*time.Hour); got != tt.expected {
+				t.Errorf("Duration() = %v, want %v", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderLabelSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected selector.Selector
+		fails    []string
+	}{
+		{"unset", "", nil, nil},
+		{"blank", "labelSelector=%20", nil, nil},
+		{"requirements", "labelSelector=team%3Dpayments,tier%20in%20(web,api)", selector.Selector{
+			{Key: "team", Operator: selector.Equals, Values: []string{"payments"}},
+			{Key: "tier", Operator: selector.In, Values: []string{"web", "api"}},
+		}, nil},
+		{"syntax error", "labelSelector=team%3D%3D%3Dpayments", nil, []string{"labelSelector"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(tt.query)
+			if got := b.LabelSelector("labelSelector"); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("LabelSelector() = %v, want %v", got, tt.expected)
+			}
+			assertFields(t, b, tt.fails)
+		})
+	}
+}
+
+func TestBinderCollectsAllErrors(t *testing.T) {
+	b := bind("limit=0&health=Sad&verbose=maybe")
+	b.Int("limit", 10, 1, 100)
+	b.EnumSet("health", HealthStatuses...)
+	b.Bool("verbose", false)
+
+	assertFields(t, b, []string{"limit", "health", "verbose"})
+	if !strings.Contains(b.Err().Error(), `invalid query parameter "limit"`) {
+		t.Errorf("Err() message = %q, should name the offending field", b.Err().Error())
+	}
+}
+
+func TestCheckQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawQuery  string
+		maxLength int
+		allowed   []string
+		fails     []string
+	}{
+		{"empty query", "", 100, []string{}, nil},
+		{"within length, lenient", "anything=1", 100, nil, nil},
+		{"too long", "image=" + strings.Repeat("a", 100), 50, nil, []string{"query"}},
+		{"no length cap", "image=" + strings.Repeat("a", 100), 0, nil, nil},
+		{"allowed parameters", "image=web&imageMatch=exact", 100, []string{"image", "imageMatch"}, nil},
+		{"unknown parameters sorted", "zeta=1&image=web&alpha=2", 100, []string{"image"}, []string{"alpha", "zeta"}},
+		{"malformed", "image=%zz", 100, []string{"image"}, []string{"query"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckQuery(tt.rawQuery, tt.maxLength, tt.allowed)
+			if len(tt.fails) == 0 {
+				if err != nil {
+					t.Errorf("CheckQuery() unexpected error: %v", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("CheckQuery() expected Errors, got %v", err)
+			}
+			if !reflect.DeepEqual(errs.Fields(), tt.fails) {
+				t.Errorf("CheckQuery() fields = %v, want %v", errs.Fields(), tt.fails)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"90m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"106751d", 106751 * 24 * time.Hour, false},
+		{"213504d", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			d, err := ParseDuration(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDuration(%q) expected error", tt.value)
+				}
+				return
+			}
+			if err != nil || d != tt.expected {
+				t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.value, d, err, tt.expected)
+			}
+		})
+	}
+}
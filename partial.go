@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+
+	"argocd-proxy/redact"
+	"argocd-proxy/services"
+)
+
+// partialQueryParam opts an aggregate endpoint into partial responses
+const partialQueryParam = "partial"
+
+// aggregatePortion is one independently fetched part of an aggregate response
+type aggregatePortion struct {
+	// name describes the portion in warnings, e.g. "projects"
+	name  string
+	fetch func(ctx context.Context) error
+	err   error
+}
+
+// gatherPortions fetches every portion concurrently and waits for all of them. Unlike a
+// fail-fast errgroup, no portion cancels the others: each keeps its own error, so the
+// caller can either fail the request or answer with whatever was gathered.
+func gatherPortions(ctx context.Context, portions ...*aggregatePortion) {
+	var g errgroup.Group
+	for _, portion := range portions {
+		g.Go(func() error {
+			portion.err = portion.fetch(ctx)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// warning describes a failed portion for the warnings array of a partial response
+func (p *aggregatePortion) warning() string {
+	reason := "upstream error"
+	switch {
+	case errors.Is(p.err, context.DeadlineExceeded):
+		reason = "upstream timeout"
+	case errors.Is(p.err, services.ErrUnauthorized):
+		reason = "unauthorized by ArgoCD"
+	}
+	// Error details are only exposed in debug mode, as in error responses
+	if gin.Mode() == gin.DebugMode {
+		reason = fmt.Sprintf("%s (%s)", reason, redact.String(p.err.Error()))
+	}
+	return fmt.Sprintf("%s unavailable: %s", p.name, reason)
+}
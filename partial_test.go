@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGatherPortionsDoesNotFailFast(t *testing.T) {
+	failed := errors.New("ArgoCD API returned status 500")
+	failing := &aggregatePortion{name: "projects", fetch: func(ctx context.Context) error {
+		return failed
+	}}
+	slow := &aggregatePortion{name: "applications", fetch: func(ctx context.Context) error {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+
+	gatherPortions(context.Background(), failing, slow)
+
+	if !errors.Is(failing.err, failed) {
+		t.Errorf("failing portion error = %v, want %v", failing.err, failed)
+	}
+	if slow.err != nil {
+		t.Errorf("slow portion error = %v, want it to complete despite the other failure", slow.err)
+	}
+	if got, want := failing.warning(), "projects unavailable: upstream error"; got != want {
+		t.Errorf("warning() = %q, want %q", got, want)
+	}
+}
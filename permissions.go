@@ -0,0 +1,156 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// filtersKey is the gin context key holding the filter snapshot a request is served with
+const filtersKey = "filters"
+
+// filters returns the project groups and ignore patterns the request is served with. The
+// snapshot is taken on first use and kept for the rest of the request, so that the API
+// key check and the handler apply the same rules even if they are replaced meanwhile.
+func (s *Server) filters(c *gin.Context) *config.FilterSnapshot {
+	if value, ok := c.Get(filtersKey); ok {
+		return value.(*config.FilterSnapshot)
+	}
+	snapshot := s.config.Snapshot()
+	c.Set(filtersKey, snapshot)
+	return snapshot
+}
+
+// filtersMiddleware takes the request's filter snapshot up front and carries it in the
+// request context, so that the service filters with the same rules as the handler
+func (s *Server) filtersMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(config.WithSnapshot(c.Request.Context(), s.filters(c)))
+		c.Next()
+	}
+}
+
+// callerScopeKey is the gin context key under which the client auth middleware stores
+// the callerScope of requests carrying a valid API key
+const callerScopeKey = "callerScope"
+
+// callerScope is what an API key caller may see
+type callerScope struct {
+	// Client is the name of the API key
+	Client string
+	// Groups restricts the caller to these project groups; nil means unrestricted
+	Groups []string
+}
+
+// scopedKeyRoutes are the routes an API key restricted by API_KEY_GROUPS may call. Group
+// and project routes are further limited to the key's groups; the other routes span
+// every project and are disabled for it.
+var scopedKeyRoutes = map[string]bool{
+	"/health":                         true,
+	"/health/history":                 true,
+	"/readyz":                         true,
+	"/info":                           true,
+	"/schemas":                        true,
+	"/permissions":                    true,
+	"/groups/:group/applications":     true,
+	"/groups/:group/drift":            true,
+	"/groups/:group/deploy-stats":     true,
+	"/projects/:project":              true,
+	"/projects/:project/roles":        true,
+	"/projects/:project/applications": true,
+	"/projects/:project/destinations": true,
+}
+
+// scopeAllowsRequest reports whether a caller with the given scope may make the request.
+// Unmatched paths fall through to the 404 handler, and admin, Swagger and CORS preflight
+// requests are not subject to API keys.
+func (s *Server) scopeAllowsRequest(scope []string, c *gin.Context) bool {
+	route := c.FullPath()
+	if scope == nil || route == "" || c.Request.Method == http.MethodOptions ||
+		strings.HasPrefix(route, "/admin/") || s.isSwaggerPath(route) {
+		return true
+	}
+	if !scopedKeyRoutes[route] {
+		return false
+	}
+	if group := c.Param("group"); group != "" && !s.filters(c).ScopeAllowsGroup(scope, group) {
+		return false
+	}
+	if project := c.Param("project"); project != "" && !s.filters(c).ScopeAllowsProject(scope, project) {
+		return false
+	}
+	return true
+}
+
+// callerPermissions resolves the access level, identity and scope of the caller
+func (s *Server) callerPermissions(c *gin.Context) (types.PermissionsResponse, []string) {
+	if len(s.config.APIKeys) == 0 {
+		return types.PermissionsResponse{Access: types.AccessOpen}, nil
+	}
+	if value, ok := c.Get(callerScopeKey); ok {
+		scope := value.(callerScope)
+		return types.PermissionsResponse{Access: types.AccessKey, Client: scope.Client, Restricted: scope.Groups != nil}, scope.Groups
+	}
+	return types.PermissionsResponse{Access: types.AccessAnonymous}, nil
+}
+
+// routeEnabled reports whether a caller with the given access and scope may call route
+func (s *Server) routeEnabled(access string, scope []string, route string) bool {
+	switch {
+	case access == types.AccessAnonymous:
+		return (s.config.AnonymousReadOnly && anonymousReadOnlyRoutes[route]) || s.matchesAuthExemptRoute(route)
+	case scope != nil:
+		return scopedKeyRoutes[route]
+	default:
+		return true
+	}
+}
+
+// disabledRoutes lists the read routes the caller may not call, sorted. Admin and Swagger
+// routes are guarded separately and left out.
+func (s *Server) disabledRoutes(access string, scope []string) []string {
+	disabled := []string{}
+	for _, route := range s.router.Routes() {
+		if route.Method != http.MethodGet || strings.HasPrefix(route.Path, "/admin/") || s.isSwaggerPath(route.Path) {
+			continue
+		}
+		if !s.routeEnabled(access, scope, route.Path) {
+			disabled = append(disabled, route.Path)
+		}
+	}
+	sort.Strings(disabled)
+	return disabled
+}
+
+// getPermissions handles the permissions endpoint
+// @Summary Get caller permissions
+// @Description Get what the caller is allowed to see: the project groups and projects visible to its API key, after the ignore rules, and the endpoints disabled for it. Keys restricted by API_KEY_GROUPS only see their groups and the projects they list; callers without a key, or without API keys set, see every project not hidden by the ignore rules. With ANONYMOUS_READ_ONLY this endpoint is served without a key
+// @Tags projects
+// @Produce json
+// @Success 200 {object} types.PermissionsResponse "Caller permissions"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
+// @Router /permissions [get]
+func (s *Server) getPermissions(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	projectNames, err := s.argocdService.GetProjectNames(ctx)
+	if err != nil {
+		log.Printf("Failed to get project names: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return
+	}
+
+	response, scope := s.callerPermissions(c)
+	filters := s.filters(c)
+	response.Groups = filters.VisibleGroups(scope)
+	response.Projects = filters.VisibleProjects(scope, projectNames)
+	response.DisabledEndpoints = s.disabledRoutes(response.Access, scope)
+	c.JSON(http.StatusOK, response)
+}
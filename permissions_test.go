@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+const testScopedAPIKey = "frontend-ci-key-0123456789"
+
+// setupPermissionsServer builds a server with a Frontend and a Backend group, an
+// unrestricted key and a key restricted to Frontend
+func setupPermissionsServer(anonymousReadOnly bool) *Server {
+	server := setupTestServer()
+	server.config.ProjectGroups = []config.ProjectGroup{
+		{Name: "Frontend", Projects: []string{"web-app", "test-web"}, Order: 1},
+		{Name: "Backend", Projects: []string{"api-service"}, Order: 2},
+	}
+	server.config.APIKeys = []config.APIKey{
+		{Name: "statuspage", Key: testAPIKey},
+		{Name: "frontend-ci", Key: testScopedAPIKey, Groups: []string{"Frontend"}},
+	}
+	server.config.AuthExemptRoutes = []string{"/health"}
+	server.config.AnonymousReadOnly = anonymousReadOnly
+	server.argocdService.(*MockArgocdService).projectNames = []string{"api-service", "test-api", "test-web", "tools", "web-app"}
+	server.setupRouter()
+	return server
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		open         bool
+		want         types.PermissionsResponse
+		wantDisabled []string
+		wantEnabled  []string
+	}{
+		{
+			name:         "open",
+			open:         true,
+			want:         types.PermissionsResponse{Access: types.AccessOpen, Groups: []string{"Frontend", "Backend"}, Projects: []string{"api-service", "test-web", "tools", "web-app"}},
+			wantEnabled:  []string{"/applications", "/bootstrap"},
+			wantDisabled: []string{},
+		},
+		{
+			name:         "unscoped key",
+			key:          testAPIKey,
+			want:         types.PermissionsResponse{Access: types.AccessKey, Client: "statuspage", Groups: []string{"Frontend", "Backend"}, Projects: []string{"api-service", "test-web", "tools", "web-app"}},
+			wantEnabled:  []string{"/applications", "/groups/:group/applications"},
+			wantDisabled: []string{},
+		},
+		{
+			name:         "scoped key",
+			key:          testScopedAPIKey,
+			want:         types.PermissionsResponse{Access: types.AccessKey, Client: "frontend-ci", Restricted: true, Groups: []string{"Frontend"}, Projects: []string{"test-web", "web-app"}},
+			wantEnabled:  []string{"/groups/:group/applications", "/projects/:project/applications", "/permissions"},
+			wantDisabled: []string{"/applications", "/bootstrap", "/project-groups", "/projects"},
+		},
+		{
+			name:         "anonymous",
+			want:         types.PermissionsResponse{Access: types.AccessAnonymous, Groups: []string{"Frontend", "Backend"}, Projects: []string{"api-service", "test-web", "tools", "web-app"}},
+			wantEnabled:  []string{"/health", "/project-groups", "/permissions"},
+			wantDisabled: []string{"/applications", "/applications/:name", "/bootstrap", "/projects"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupPermissionsServer(true)
+			if tt.open {
+				server.config.APIKeys = nil
+				server.setupRouter()
+			}
+
+			headers := map[string]string{}
+			if tt.key != "" {
+				headers[apiKeyHeader] = tt.key
+			}
+			w := serveMethod(server, http.MethodGet, "/permissions", headers)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /permissions status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+
+			var got types.PermissionsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			disabled := got.DisabledEndpoints
+			got.DisabledEndpoints = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GET /permissions = %+v, want %+v", got, tt.want)
+			}
+			if len(tt.wantDisabled) == 0 && len(disabled) != 0 {
+				t.Errorf("disabledEndpoints = %v, want none", disabled)
+			}
+			for _, route := range tt.wantDisabled {
+				if !slices.Contains(disabled, route) {
+					t.Errorf("disabledEndpoints = %v, want %s listed", disabled, route)
+				}
+			}
+			for _, route := range tt.wantEnabled {
+				if slices.Contains(disabled, route) {
+					t.Errorf("disabledEndpoints = %v, want %s not listed", disabled, route)
+				}
+			}
+		})
+	}
+}
+
+func TestScopedAPIKeyEnforcement(t *testing.T) {
+	tests := []struct {
+		path          string
+		wantForbidden bool
+	}{
+		{path: "/groups/frontend/applications"},
+		{path: "/groups/Frontend/drift"},
+		{path: "/projects/web-app/applications"},
+		{path: "/health"},
+		{path: "/permissions"},
+		{path: "/groups/backend/applications", wantForbidden: true},
+		{path: "/projects/api-service/applications", wantForbidden: true},
+		{path: "/projects/tools/destinations", wantForbidden: true},
+		{path: "/applications", wantForbidden: true},
+		{path: "/applications/web", wantForbidden: true},
+		{path: "/project-groups", wantForbidden: true},
+	}
+
+	server := setupPermissionsServer(false)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serveMethod(server, http.MethodGet, tt.path, map[string]string{apiKeyHeader: testScopedAPIKey})
+			if forbidden := w.Code == http.StatusForbidden; forbidden != tt.wantForbidden {
+				t.Fatalf("GET %s status = %d, want forbidden %v", tt.path, w.Code, tt.wantForbidden)
+			}
+			if tt.wantForbidden {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if response.ErrorCode != types.ErrorCodeClientForbidden {
+					t.Errorf("errorCode = %q, want %q", response.ErrorCode, types.ErrorCodeClientForbidden)
+				}
+			}
+
+			// The unrestricted key is never forbidden
+			if w := serveMethod(server, http.MethodGet, tt.path, map[string]string{apiKeyHeader: testAPIKey}); w.Code == http.StatusForbidden {
+				t.Errorf("GET %s with the unrestricted key status = 403", tt.path)
+			}
+		})
+	}
+}
+
+func TestPermissionsUpstreamFailure(t *testing.T) {
+	server := setupPermissionsServer(false)
+	server.argocdService.(*MockArgocdService).err = errors.New("ArgoCD API returned status 500")
+
+	if w := serveMethod(server, http.MethodGet, "/permissions", map[string]string{apiKeyHeader: testAPIKey}); w.Code != http.StatusBadGateway {
+		t.Errorf("GET /permissions status = %d, want 502", w.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// problemContentType is the RFC 7807 media type of problem-details error bodies
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the errorCode in the type URI of problem-details documents
+const problemTypePrefix = "urn:argocd-proxy:error:"
+
+// renderError writes an error response in the configured ERROR_FORMAT. Clients that list
+// application/problem+json in their Accept header get problem details in either format.
+func (s *Server) renderError(c *gin.Context, response types.ErrorResponse) {
+	if s.config.ErrorFormat != config.ErrorFormatProblem && !acceptsProblemJSON(c) {
+		c.JSON(response.Code, response)
+		return
+	}
+
+	problem := types.ProblemDetails{
+		Type:      problemTypePrefix + string(response.ErrorCode),
+		Title:     response.Error,
+		Status:    response.Code,
+		Detail:    response.Message,
+		ErrorCode: response.ErrorCode,
+		Fields:    response.Fields,
+		Profiles:  response.Profiles,
+		Upstream:  response.Upstream,
+	}
+	if id := requestID(c); id != "" {
+		problem.Instance = "urn:request:" + id
+	}
+
+	// gin keeps a Content-Type that is already set when rendering JSON
+	c.Header("Content-Type", problemContentType)
+	c.JSON(response.Code, problem)
+}
+
+// acceptsProblemJSON reports whether the client's Accept header lists application/problem+json
+func acceptsProblemJSON(c *gin.Context) bool {
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), problemContentType) {
+			return true
+		}
+	}
+	return false
+}
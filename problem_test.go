@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func TestErrorResponseFormats(t *testing.T) {
+	tests := []struct {
+		name            string
+		errorFormat     string
+		accept          string
+		path            string
+		wantStatus      int
+		wantProblem     bool
+		wantErrorCode   types.ErrorCode
+		wantFields      []string
+		wantContentType string
+	}{
+		{
+			name:            "default format",
+			errorFormat:     config.ErrorFormatJSON,
+			path:            "/applications/missing",
+			wantStatus:      http.StatusNotFound,
+			wantErrorCode:   types.ErrorCodeNotFound,
+			wantContentType: "application/json; charset=utf-8",
+		},
+		{
+			name:            "problem format",
+			errorFormat:     config.ErrorFormatProblem,
+			path:            "/applications/missing",
+			wantStatus:      http.StatusNotFound,
+			wantProblem:     true,
+			wantErrorCode:   types.ErrorCodeNotFound,
+			wantContentType: problemContentType,
+		},
+		{
+			name:            "problem format for invalid parameters",
+			errorFormat:     config.ErrorFormatProblem,
+			path:            "/applications?health=Broken",
+			wantStatus:      http.StatusBadRequest,
+			wantProblem:     true,
+			wantErrorCode:   types.ErrorCodeInvalidParam,
+			wantFields:      []string{"health"},
+			wantContentType: problemContentType,
+		},
+		{
+			name:            "problem format for unknown routes",
+			errorFormat:     config.ErrorFormatProblem,
+			path:            "/does-not-exist",
+			wantStatus:      http.StatusNotFound,
+			wantProblem:     true,
+			wantErrorCode:   types.ErrorCodeNotFound,
+			wantContentType: problemContentType,
+		},
+		{
+			name:            "Accept header requests problem details",
+			errorFormat:     config.ErrorFormatJSON,
+			accept:          "application/json;q=0.9, application/problem+json",
+			path:            "/applications/missing",
+			wantStatus:      http.StatusNotFound,
+			wantProblem:     true,
+			wantErrorCode:   types.ErrorCodeNotFound,
+			wantContentType: problemContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.ErrorFormat = tt.errorFormat
+
+			headers := map[string]string{requestIDHeader: "req-42"}
+			if tt.accept != "" {
+				headers["Accept"] = tt.accept
+			}
+			w := serveMethod(server, http.MethodGet, tt.path, headers)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+
+			if !tt.wantProblem {
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("invalid JSON response: %v", err)
+				}
+				if response.Code != tt.wantStatus || response.ErrorCode != tt.wantErrorCode {
+					t.Errorf("response = %+v, want code %d and errorCode %s", response, tt.wantStatus, tt.wantErrorCode)
+				}
+				return
+			}
+
+			var problem map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			want := map[string]interface{}{
+				"type":      "urn:argocd-proxy:error:" + string(tt.wantErrorCode),
+				"title":     http.StatusText(tt.wantStatus),
+				"status":    float64(tt.wantStatus),
+				"instance":  "urn:request:req-42",
+				"errorCode": string(tt.wantErrorCode),
+			}
+			for key, value := range want {
+				if problem[key] != value {
+					t.Errorf("problem %s = %v, want %v", key, problem[key], value)
+				}
+			}
+			if detail, _ := problem["detail"].(string); detail == "" {
+				t.Error("problem detail is empty")
+			}
+			if _, ok := problem["code"]; ok {
+				t.Error("problem document contains the ErrorResponse code field")
+			}
+			if tt.wantFields != nil {
+				fields, _ := json.Marshal(problem["fields"])
+				if wantFields, _ := json.Marshal(tt.wantFields); string(fields) != string(wantFields) {
+					t.Errorf("problem fields = %s, want %s", fields, wantFields)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"generated when missing", "", false},
+		{"client ID reused", "gateway-7f3a:1", true},
+		{"malformed client ID replaced", "bad id\twith spaces", false},
+		{"overlong client ID replaced", strings.Repeat("a", 129), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			headers := map[string]string{}
+			if tt.incoming != "" {
+				headers[requestIDHeader] = tt.incoming
+			}
+
+			got := serveMethod(server, http.MethodGet, "/health", headers).Header().Get(requestIDHeader)
+			if tt.wantSame && got != tt.incoming {
+				t.Errorf("%s = %q, want %q", requestIDHeader, got, tt.incoming)
+			}
+			if !tt.wantSame && (got == "" || got == tt.incoming) {
+				t.Errorf("%s = %q, want a generated ID", requestIDHeader, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// flattenPoliciesQueryParam parses role policy lines into rules
+const flattenPoliciesQueryParam = "flattenPolicies"
+
+// strictQueryParam set to false lists the applications of a project without checking
+// that the project exists
+const strictQueryParam = "strict"
+
+// getProject handles the single project endpoint
+// @Summary Get specific project
+// @Description Get a project by name, including its RBAC roles and cluster resource whitelist. Projects hidden by the ignore rules are reported as not found
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project path string true "Project name"
+// @Success 200 {object} types.ArgocdProject "Project details"
+// @Failure 404 {object} types.ErrorResponse "Project not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
+// @Router /projects/{project} [get]
+func (s *Server) getProject(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	project, ok := s.lookupProject(ctx, c, c.Param("project"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, project)
+}
+
+// getProjectRoles handles the project roles endpoint
+// @Summary Get project roles
+// @Description Get the RBAC roles a project defines, with their descriptions, SSO groups and policies, for review without ArgoCD access. With flattenPolicies=true each policy line is parsed into subject, resource, action, object and effect; lines that are not six-field "p, ..." policies are returned with malformed set. Projects hidden by the ignore rules are reported as not found
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project path string true "Project name"
+// @Param flattenPolicies query bool false "Parse policy lines into structured rules (default false)"
+// @Success 200 {object} types.ProjectRolesResponse "Project roles"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 404 {object} types.ErrorResponse "Project not found"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve projects from ArgoCD"
+// @Router /projects/{project}/roles [get]
+func (s *Server) getProjectRoles(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	flatten := b.Bool(flattenPoliciesQueryParam, false)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	project, ok := s.lookupProject(ctx, c, c.Param("project"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, services.ProjectRoles(project, flatten))
+}
+
+// lookupProject fetches a project, answering 404 or 502 and returning false on failure
+func (s *Server) lookupProject(ctx context.Context, c *gin.Context, name string) (types.ArgocdProject, bool) {
+	project, err := s.argocdService.GetProject(ctx, name)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) || errors.Is(err, services.ErrFiltered) {
+			s.serviceErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project '%s' not found", name), err)
+			return types.ArgocdProject{}, false
+		}
+		log.Printf("Failed to get project %s: %v", name, err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return types.ArgocdProject{}, false
+	}
+	return project, true
+}
+
+// requireProject answers 404 and returns false unless name is a project known to ArgoCD
+// and not hidden by the ignore rules. Unknown names get a "did you mean" suggestion among
+// the projects visible to the caller; hidden projects get none, so that their existence is
+// not revealed. A failure to list the projects answers 502.
+func (s *Server) requireProject(ctx context.Context, c *gin.Context, name string) bool {
+	names, err := s.argocdService.GetProjectNames(ctx)
+	if err != nil {
+		log.Printf("Failed to get project names: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve projects from ArgoCD", err)
+		return false
+	}
+
+	filters := s.filters(c)
+	if !filters.ShouldFilterProject(name) && slices.Contains(names, name) {
+		return true
+	}
+
+	message := fmt.Sprintf("Project '%s' not found", name)
+	if !filters.ShouldFilterProject(name) {
+		_, scope := s.callerPermissions(c)
+		if suggestion := closestName(name, filters.VisibleProjects(scope, names)); suggestion != "" {
+			message = fmt.Sprintf("Project '%s' not found, did you mean '%s'?", name, suggestion)
+		}
+	}
+	s.errorResponse(c, http.StatusNotFound, types.ErrorCodeNotFound, message, "")
+	return false
+}
+
+// closestName returns the candidate with the smallest case-insensitive edit distance to
+// name, preferring the first on ties, or "" when none is close enough to be a likely typo:
+// at most two edits, or a third of the name's length for longer names
+func closestName(name string, candidates []string) string {
+	maxDistance := max(2, utf8.RuneCountInString(name)/3)
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// setupProjectsServer returns a test server backed by a real ArgoCD service whose
+// upstream serves a project with roles and a project hidden by IGNORED_PROJECTS
+func setupProjectsServer(t *testing.T) *Server {
+	t.Helper()
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payments := types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: "payments"}}
+		payments.Spec.Roles = []types.ArgocdProjectRole{{
+			Name:        "read-only",
+			Description: "Read-only access for auditors",
+			Policies:    []string{"p, proj:payments:read-only, applications, get, payments/*, allow", "g, auditors, role:readonly"},
+			Groups:      []string{"example:auditors"},
+		}}
+		payments.Spec.ClusterResourceWhitelist = []types.ArgocdGroupKind{{Group: "", Kind: "Namespace"}}
+		hidden := types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: "test-web"}}
+		hidden.Spec.Roles = []types.ArgocdProjectRole{{Name: "admin"}}
+		json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{payments, hidden}})
+	}))
+	t.Cleanup(fake.Close)
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.IgnoredProjects = []string{"test-*"}
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+	return server
+}
+
+func TestGetProject(t *testing.T) {
+	server := setupProjectsServer(t)
+
+	w := serveMethod(server, http.MethodGet, "/projects/payments", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var project types.ArgocdProject
+	if err := json.Unmarshal(w.Body.Bytes(), &project); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(project.Spec.Roles) != 1 || project.Spec.Roles[0].Name != "read-only" || len(project.Spec.ClusterResourceWhitelist) != 1 {
+		t.Errorf("spec = %+v, want the read-only role and the Namespace whitelist entry", project.Spec)
+	}
+
+	for _, path := range []string{"/projects/test-web", "/projects/missing"} {
+		if w := serveMethod(server, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestGetProjectRoles(t *testing.T) {
+	server := setupProjectsServer(t)
+
+	w := serveMethod(server, http.MethodGet, "/projects/payments/roles", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var response types.ProjectRolesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Project != "payments" || len(response.Roles) != 1 || len(response.Roles[0].Policies) != 2 || response.Roles[0].Rules != nil {
+		t.Errorf("response = %+v, want the read-only role with verbatim policies", response)
+	}
+
+	w = serveMethod(server, http.MethodGet, "/projects/payments/roles?flattenPolicies=true", nil)
+	response = types.ProjectRolesResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	rules := response.Roles[0].Rules
+	if len(rules) != 2 || response.Roles[0].Policies != nil {
+		t.Fatalf("role = %+v, want 2 rules and no verbatim policies", response.Roles[0])
+	}
+	if rules[0].Subject != "proj:payments:read-only" || rules[0].Action != "get" || rules[0].Effect != "allow" || rules[0].Malformed {
+		t.Errorf("rules[0] = %+v, want the parsed get policy", rules[0])
+	}
+	if !rules[1].Malformed || rules[1].Raw != "g, auditors, role:readonly" {
+		t.Errorf("rules[1] = %+v, want the group binding flagged malformed", rules[1])
+	}
+
+	for path, want := range map[string]int{
+		"/projects/test-web/roles":                      http.StatusNotFound,
+		"/projects/missing/roles":                       http.StatusNotFound,
+		"/projects/payments/roles?flattenPolicies=most": http.StatusBadRequest,
+	} {
+		if w := serveMethod(server, http.MethodGet, path, nil); w.Code != want {
+			t.Errorf("GET %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestGetApplicationsByProjectRequiresProject(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "known project", path: "/projects/web-app/applications", wantStatus: http.StatusOK},
+		{name: "typo", path: "/projects/web-ap/applications", wantStatus: http.StatusNotFound, wantMessage: "Project 'web-ap' not found, did you mean 'web-app'?"},
+		{name: "wrong case", path: "/projects/API-Service/applications", wantStatus: http.StatusNotFound, wantMessage: "Project 'API-Service' not found, did you mean 'api-service'?"},
+		{name: "no close project", path: "/projects/payments/applications", wantStatus: http.StatusNotFound, wantMessage: "Project 'payments' not found"},
+		{name: "ignored project", path: "/projects/test-app/applications", wantStatus: http.StatusNotFound, wantMessage: "Project 'test-app' not found"},
+		{name: "ignored projects are never suggested", path: "/projects/tst-app/applications", wantStatus: http.StatusNotFound, wantMessage: "Project 'tst-app' not found"},
+		{name: "lenient", path: "/projects/web-ap/applications?strict=false", wantStatus: http.StatusOK},
+		{name: "invalid strict", path: "/projects/web-app/applications?strict=maybe", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.argocdService.(*MockArgocdService).projectNames = []string{"api-service", "test-app", "web-app"}
+
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d: %s", tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantMessage == "" {
+				return
+			}
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Message != tt.wantMessage || response.ErrorCode != types.ErrorCodeNotFound {
+				t.Errorf("message = %q, errorCode = %q, want %q, %q", response.Message, response.ErrorCode, tt.wantMessage, types.ErrorCodeNotFound)
+			}
+		})
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	candidates := []string{"api-service", "payments", "web-app"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"web-ap", "web-app"},
+		{"wep-apps", "web-app"},
+		{"PAYMENTS", "payments"},
+		{"api-servce", "api-service"},
+		{"ap", ""},
+		{"frontend", ""},
+	}
+	for _, tt := range tests {
+		if got := closestName(tt.name, candidates); got != tt.want {
+			t.Errorf("closestName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
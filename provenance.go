@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"argocd-proxy/provenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers describing where the data behind a response came from
+const (
+	cacheHeader    = "X-Cache"
+	cacheAgeHeader = "X-Cache-Age"
+)
+
+// provenanceWriter sets the X-Cache headers just before the response headers are sent,
+// once the handler has read its data
+type provenanceWriter struct {
+	gin.ResponseWriter
+	recorder *provenance.Recorder
+	done     bool
+}
+
+// setProvenanceHeaders adds the X-Cache headers once, unless the headers are already
+// sent or the request read no cached or upstream data
+func (w *provenanceWriter) setProvenanceHeaders() {
+	if w.done {
+		return
+	}
+	w.done = true
+	source, age, ok := w.recorder.Get()
+	if !ok || w.ResponseWriter.Written() {
+		return
+	}
+	w.Header().Set(cacheHeader, string(source))
+	w.Header().Set(cacheAgeHeader, strconv.FormatInt(int64(age/time.Second), 10))
+}
+
+// WriteHeaderNow sets the X-Cache headers before sending the headers
+func (w *provenanceWriter) WriteHeaderNow() {
+	w.setProvenanceHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write sets the X-Cache headers before sending the first body bytes
+func (w *provenanceWriter) Write(data []byte) (int, error) {
+	w.setProvenanceHeaders()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString sets the X-Cache headers before sending the first body bytes
+func (w *provenanceWriter) WriteString(s string) (int, error) {
+	w.setProvenanceHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// cacheProvenanceMiddleware reports where a response's data came from: X-Cache is HIT
+// for unexpired cache entries, MISS when the data was fetched because the cache was
+// empty or expired, STALE for an expired entry ArgoCD confirmed unchanged, and BYPASS
+// for data read from ArgoCD without a cache. X-Cache-Age is the age of the data in
+// whole seconds. When a response combines several pieces of data, the oldest is reported.
+func cacheProvenanceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, recorder := provenance.NewContext(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &provenanceWriter{ResponseWriter: c.Writer, recorder: recorder}
+		c.Writer = writer
+		c.Next()
+		// Responses without a body (e.g. 304) have not sent their headers yet
+		writer.setProvenanceHeaders()
+		c.Writer = writer.ResponseWriter
+	}
+}
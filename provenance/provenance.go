@@ -0,0 +1,71 @@
+// Package provenance records where the data behind a response came from. The services
+// layer reports each cache lookup through the request context, so the HTTP layer can
+// tell clients whether they got cached, revalidated or freshly fetched data.
+package provenance
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Source says where data came from
+type Source string
+
+// Sources reported in the X-Cache header
+const (
+	// Hit is data served from an unexpired cache entry
+	Hit Source = "HIT"
+	// Miss is data fetched from ArgoCD because the cache was empty or expired
+	Miss Source = "MISS"
+	// Stale is an expired cache entry served after ArgoCD confirmed it is unchanged
+	Stale Source = "STALE"
+	// Bypass is data fetched from ArgoCD without consulting a cache
+	Bypass Source = "BYPASS"
+)
+
+// recorderKey is the context key under which a request's Recorder is stored
+type recorderKey struct{}
+
+// Recorder keeps the provenance of the oldest data a request used
+type Recorder struct {
+	mu       sync.Mutex
+	recorded bool
+	source   Source
+	age      time.Duration
+}
+
+// NewContext returns a context carrying a new, empty Recorder
+func NewContext(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// FromContext returns the Recorder carried by ctx, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Record reports data from source that is age old to the Recorder carried by ctx, if
+// any. When a request uses several pieces of data, the oldest one is kept, and the first
+// one among equally old pieces.
+func Record(ctx context.Context, source Source, age time.Duration) {
+	r := FromContext(ctx)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recorded || age > r.age {
+		r.recorded, r.source, r.age = true, source, max(age, 0)
+	}
+}
+
+// Get returns the recorded source and age, and false when nothing was recorded
+func (r *Recorder) Get() (Source, time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.source, r.age, r.recorded
+}
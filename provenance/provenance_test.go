@@ -0,0 +1,52 @@
+package provenance
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	type record struct {
+		source Source
+		age    time.Duration
+	}
+	tests := []struct {
+		name       string
+		records    []record
+		wantSource Source
+		wantAge    time.Duration
+	}{
+		{"single record", []record{{Miss, 0}}, Miss, 0},
+		{"oldest data wins", []record{{Miss, 0}, {Hit, 5 * time.Second}, {Stale, 2 * time.Second}}, Hit, 5 * time.Second},
+		{"first of equally old data wins", []record{{Bypass, 0}, {Miss, 0}}, Bypass, 0},
+		{"negative age is clamped", []record{{Hit, -time.Second}}, Hit, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, recorder := NewContext(context.Background())
+			for _, r := range tt.records {
+				Record(ctx, r.source, r.age)
+			}
+
+			source, age, ok := recorder.Get()
+			if !ok || source != tt.wantSource || age != tt.wantAge {
+				t.Errorf("Get() = %s, %v, %v, want %s, %v, true", source, age, ok, tt.wantSource, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestRecordWithoutRecorder(t *testing.T) {
+	// Background work such as the poller has no recorder; recording must be a no-op
+	Record(context.Background(), Hit, time.Second)
+
+	if FromContext(context.Background()) != nil {
+		t.Error("FromContext() = non-nil for a context without a recorder")
+	}
+	_, recorder := NewContext(context.Background())
+	if _, _, ok := recorder.Get(); ok {
+		t.Error("Get() on an empty recorder reported a record")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// setupProvenanceServer returns a test server backed by a real ArgoCD service talking
+// to a fake ArgoCD whose application list always has the same resourceVersion
+func setupProvenanceServer(t *testing.T, cacheTTL, responseCacheTTL time.Duration) *Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		web := types.ArgocdApplication{
+			Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+			Spec:     types.ArgocdApplicationSpec{Project: "production"},
+		}
+		switch r.URL.Path {
+		case "/applications":
+			list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{web}}
+			list.Metadata.ResourceVersion = "42"
+			json.NewEncoder(w).Encode(list)
+		case "/applications/web":
+			json.NewEncoder(w).Encode(web)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = upstream.URL
+	server.config.CacheTTL = cacheTTL
+	server.config.ResponseCacheTTL = responseCacheTTL
+	server.config.ResponseCacheSize = 16
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	server.setupRouter()
+	return server
+}
+
+// cacheHeaders returns the X-Cache header and the X-Cache-Age header in seconds
+func cacheHeaders(t *testing.T, w *httptest.ResponseRecorder) (string, int) {
+	t.Helper()
+	age, err := strconv.Atoi(w.Header().Get(cacheAgeHeader))
+	if err != nil {
+		t.Fatalf("%s = %q, want whole seconds", cacheAgeHeader, w.Header().Get(cacheAgeHeader))
+	}
+	return w.Header().Get(cacheHeader), age
+}
+
+func TestCacheProvenanceHeaders(t *testing.T) {
+	tests := []struct {
+		name             string
+		cacheTTL         time.Duration
+		responseCacheTTL time.Duration
+		path             string
+		// wait is slept between the priming request and the checked one
+		wait    time.Duration
+		prime   bool
+		want    string
+		wantAge int
+	}{
+		{name: "cold cache", cacheTTL: time.Minute, path: "/applications", want: "MISS"},
+		{name: "warm cache", cacheTTL: time.Minute, path: "/applications", prime: true, want: "HIT"},
+		{name: "warm cache on group endpoint", cacheTTL: time.Minute, path: "/groups/frontend/applications", prime: true, want: "HIT"},
+		{name: "caching disabled", path: "/applications", prime: true, want: "BYPASS"},
+		{name: "single application", cacheTTL: time.Minute, path: "/applications/web", prime: true, want: "BYPASS"},
+		{name: "expired entry revalidated", cacheTTL: 20 * time.Millisecond, path: "/applications", prime: true, wait: 50 * time.Millisecond, want: "STALE"},
+		{name: "response cache", cacheTTL: time.Minute, responseCacheTTL: time.Minute, path: "/applications", prime: true, want: "HIT"},
+		{name: "response cache reports data age", cacheTTL: time.Minute, responseCacheTTL: time.Minute, path: "/applications", prime: true, wait: 1100 * time.Millisecond, want: "HIT", wantAge: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupProvenanceServer(t, tt.cacheTTL, tt.responseCacheTTL)
+			if tt.prime {
+				serve(server, tt.path, nil)
+				time.Sleep(tt.wait)
+			}
+
+			w := serve(server, tt.path, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s status = %d, want %d", tt.path, w.Code, http.StatusOK)
+			}
+			source, age := cacheHeaders(t, w)
+			if source != tt.want || age != tt.wantAge {
+				t.Errorf("GET %s X-Cache = %s, X-Cache-Age = %d, want %s, %d", tt.path, source, age, tt.want, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestCacheProvenanceHeadersOmitted(t *testing.T) {
+	server := setupProvenanceServer(t, time.Minute, 0)
+
+	for _, path := range []string{"/info", "/does-not-exist"} {
+		w := serve(server, path, nil)
+		if got := w.Header().Get(cacheHeader); got != "" {
+			t.Errorf("GET %s X-Cache = %q, want none for responses without ArgoCD data", path, got)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package redact
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every occurrence of a secret value
+const Mask = "[REDACTED]"
+
+// DefaultMaxBodyLength is the number of upstream body bytes kept in error messages by default
+const DefaultMaxBodyLength = 512
+
+// minSecretLength is the shortest value masked; shorter values would garble unrelated text
+const minSecretLength = 4
+
+// Redactor masks registered secret values in log output and error messages and
+// truncates upstream response bodies before they are interpolated into errors.
+type Redactor struct {
+	mu            sync.RWMutex
+	secrets       map[string]string
+	maxBodyLength int
+}
+
+// New creates a redactor keeping at most maxBodyLength bytes of upstream bodies.
+// A maxBodyLength of 0 drops bodies entirely.
+func New(maxBodyLength int) *Redactor {
+	return &Redactor{
+		secrets:       make(map[string]string),
+		maxBodyLength: maxBodyLength,
+	}
+}
+
+// SetSecret registers value under name, replacing the value previously registered under
+// that name (e.g. a rotated token). An empty value unregisters the name.
+func (r *Redactor) SetSecret(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if value == "" {
+		delete(r.secrets, name)
+		return
+	}
+	r.secrets[name] = value
+}
+
+// String returns s with every registered secret replaced by Mask
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Mask longer secrets first so one secret containing another is masked whole
+	values := make([]string, 0, len(r.secrets))
+	for _, value := range r.secrets {
+		if len(value) >= minSecretLength {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, Mask)
+	}
+	return s
+}
+
+// Body returns an upstream response body suitable for an error message: redacted, then
+// truncated to the configured length. Redacting first ensures truncation never leaves
+// part of a secret behind.
+func (r *Redactor) Body(body []byte) string {
+	s := r.String(string(body))
+	if len(s) <= r.maxBodyLength {
+		return s
+	}
+	return s[:r.maxBodyLength] + "...(truncated)"
+}
+
+// ReadLimit returns the number of bytes of an upstream body to read for Body: the kept
+// length plus the longest registered secret, so that a secret starting in the kept part
+// is read whole and masked, and one more byte so that Body can tell the body was cut.
+func (r *Redactor) ReadLimit() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	longest := 0
+	for _, value := range r.secrets {
+		longest = max(longest, len(value))
+	}
+	return int64(r.maxBodyLength + longest + 1)
+}
+
+// Writer returns a writer that redacts everything written to w. It is meant for log
+// output, where each write carries a complete message.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &writer{redactor: r, out: w}
+}
+
+// writer redacts each write before forwarding it
+type writer struct {
+	redactor *Redactor
+	out      io.Writer
+}
+
+// Write redacts p and writes it to the underlying writer, reporting p as fully written
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, w.redactor.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// defaultRedactor backs the package-level helpers so that callers can redact without
+// threading a *Redactor through every layer; SetDefault replaces it during startup.
+var defaultRedactor = New(DefaultMaxBodyLength)
+
+// Default returns the redactor behind the package-level helpers
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// SetDefault makes r the redactor behind the package-level helpers.
+// It must be called before requests are served.
+func SetDefault(r *Redactor) {
+	defaultRedactor = r
+}
+
+// SetSecret registers a secret with the default redactor
+func SetSecret(name, value string) {
+	defaultRedactor.SetSecret(name, value)
+}
+
+// String redacts s with the default redactor
+func String(s string) string {
+	return defaultRedactor.String(s)
+}
+
+// ReadLimit returns the read limit of the default redactor
+func ReadLimit() int64 {
+	return defaultRedactor.ReadLimit()
+}
+
+// Body truncates and redacts an upstream body with the default redactor
+func Body(body []byte) string {
+	return defaultRedactor.Body(body)
+}
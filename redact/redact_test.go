@@ -0,0 +1,111 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedactorString(t *testing.T) {
+	r := New(DefaultMaxBodyLength)
+	r.SetSecret("password", "s3cr3t-pass")
+	r.SetSecret("token", "eyJhbGciOi.payload.sig")
+	r.SetSecret("short", "abc")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"password in message", "login failed for s3cr3t-pass", "login failed for " + Mask},
+		{"token repeated", "Bearer eyJhbGciOi.payload.sig and eyJhbGciOi.payload.sig", "Bearer " + Mask + " and " + Mask},
+		{"short values are not masked", "abc def", "abc def"},
+		{"no secrets", "connection refused", "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.String(tt.input); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorSetSecretReplacesAndRemoves(t *testing.T) {
+	r := New(DefaultMaxBodyLength)
+	r.SetSecret("token", "first-token")
+	r.SetSecret("token", "second-token")
+
+	if got := r.String("first-token second-token"); got != "first-token "+Mask {
+		t.Errorf("String() after rotation = %q, want only the current token masked", got)
+	}
+
+	r.SetSecret("token", "")
+	if got := r.String("second-token"); got != "second-token" {
+		t.Errorf("String() after removal = %q, want unmasked", got)
+	}
+}
+
+func TestRedactorOverlappingSecrets(t *testing.T) {
+	r := New(DefaultMaxBodyLength)
+	r.SetSecret("username", "admin")
+	r.SetSecret("password", "admin-password")
+
+	if got := r.String("admin-password"); got != Mask {
+		t.Errorf("String() = %q, want the longer secret masked whole", got)
+	}
+}
+
+func TestRedactorBody(t *testing.T) {
+	r := New(16)
+	r.SetSecret("username", "deploy-bot")
+
+	if got := r.Body([]byte("user deploy-bot")); got != "user "+Mask {
+		t.Errorf("Body() = %q, want redacted body", got)
+	}
+
+	got := r.Body([]byte("unauthorized: deploy-bot is not allowed"))
+	if strings.Contains(got, "deploy-bot") || !strings.HasSuffix(got, "...(truncated)") {
+		t.Errorf("Body() = %q, want truncated and redacted body", got)
+	}
+
+	if got := New(0).Body([]byte("anything")); got != "...(truncated)" {
+		t.Errorf("Body() with limit 0 = %q, want body dropped", got)
+	}
+}
+
+func TestRedactorReadLimit(t *testing.T) {
+	r := New(16)
+	if got := r.ReadLimit(); got != 17 {
+		t.Errorf("ReadLimit() = %d, want 17", got)
+	}
+
+	// A secret straddling the kept length is read whole and masked
+	r.SetSecret("username", "deploy-bot")
+	if got := r.ReadLimit(); got != 27 {
+		t.Errorf("ReadLimit() with secret = %d, want 27", got)
+	}
+	body := "unauthorized: deploy-bot is not allowed"
+	got := r.Body([]byte(body[:r.ReadLimit()]))
+	if strings.Contains(got, "deploy") || !strings.HasSuffix(got, "...(truncated)") {
+		t.Errorf("Body() = %q, want truncated and redacted body", got)
+	}
+}
+
+func TestRedactorWriter(t *testing.T) {
+	r := New(DefaultMaxBodyLength)
+	r.SetSecret("password", "hunter22")
+
+	var buf bytes.Buffer
+	logger := log.New(r.Writer(&buf), "", 0)
+	logger.Printf("ArgoCD API returned status 401: invalid password hunter22")
+
+	if strings.Contains(buf.String(), "hunter22") {
+		t.Errorf("log output leaked secret: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), Mask) {
+		t.Errorf("log output = %q, want masked secret", buf.String())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"argocd-proxy/metrics"
+)
+
+// requestIDHeader carries the request ID, taken from the client or generated by the proxy
+const requestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "requestID"
+
+// validRequestID limits client-supplied request IDs to safe, bounded tokens
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// traceparentHeader carries the W3C trace context of a traced caller
+const traceparentHeader = "traceparent"
+
+// validTraceparent matches a version 00 W3C traceparent, capturing the trace ID
+var validTraceparent = regexp.MustCompile(`^00-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// zeroTraceID is the invalid all-zero trace ID
+const zeroTraceID = "00000000000000000000000000000000"
+
+// requestIDMiddleware assigns every request an ID, reusing a well-formed X-Request-ID sent
+// by the client or an upstream gateway, and echoes it in the response headers. For traced
+// callers, the trace and request IDs label the request's latency exemplars.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(metrics.WithExemplar(c.Request.Context(), exemplarLabels(c, id)))
+		c.Next()
+	}
+}
+
+// newRequestID returns a random 128-bit hex request ID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// exemplarLabels returns the exemplar labels of a traced request: the trace ID from a valid
+// traceparent header, plus the request ID when it fits within the exemplar size limit.
+// Untraced requests get none. Request IDs are ASCII, so their length counts runes.
+func exemplarLabels(c *gin.Context, id string) prometheus.Labels {
+	match := validTraceparent.FindStringSubmatch(c.GetHeader(traceparentHeader))
+	if match == nil || match[1] == zeroTraceID {
+		return nil
+	}
+	labels := prometheus.Labels{"trace_id": match[1]}
+	if len("trace_id")+len(match[1])+len("request_id")+len(id) <= prometheus.ExemplarMaxRunes {
+		labels["request_id"] = id
+	}
+	return labels
+}
+
+// requestID returns the ID assigned to the request by requestIDMiddleware
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"argocd-proxy/canonical"
+	"argocd-proxy/metrics"
+	"argocd-proxy/provenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseCacheRoutes are the routes whose responses depend only on the cached
+// application set, the request path and its query parameters
+var responseCacheRoutes = map[string]bool{
+	"/applications":                   true,
+	"/applications/names":             true,
+	"/groups/:group/applications":     true,
+	"/groups/:group/drift":            true,
+	"/projects/:project/applications": true,
+}
+
+// cachedResponseHeaders are the handler-set headers stored with a cached response
+var cachedResponseHeaders = []string{resourceVersionHeader, truncatedHeader, totalCountHeader}
+
+// cachedResponse is a serialized response stored in the response cache
+type cachedResponse struct {
+	status      int
+	contentType string
+	headers     map[string]string
+	body        []byte
+	etag        string
+	// changed is the application change channel observed before the response was
+	// built; once it is closed the entry no longer reflects the cached application set
+	changed <-chan struct{}
+	// dataAge is the age of the data when the response was built at storedAt
+	dataAge  time.Duration
+	storedAt time.Time
+}
+
+// stale reports whether the application set changed after the entry was built
+func (r *cachedResponse) stale() bool {
+	select {
+	case <-r.changed:
+		return true
+	default:
+		return false
+	}
+}
+
+// bufferedWriter holds back the response body so headers such as ETag can still be set
+// once the handler has finished
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write buffers the body instead of sending it
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// WriteString buffers the body instead of sending it
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// responseCacheMiddleware serves repeated identical requests to application list routes
+// from a bounded cache of serialized responses. Entries expire after RESPONSE_CACHE_TTL
+// and are discarded as soon as the service cache refreshes with a different application set.
+func (s *Server) responseCacheMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Per-request item limits are reserved for admin token holders, so their responses
+		// must never be served to other callers
+		if (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) || !responseCacheRoutes[c.FullPath()] ||
+			c.Query("watchAfter") != "" || c.Request.URL.Query().Has(maxItemsQueryParam) {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c)
+		if entry, ok := s.responseCache.Get(key); ok && !entry.stale() {
+			provenance.Record(c.Request.Context(), provenance.Hit, entry.dataAge+time.Since(entry.storedAt))
+			if entry.headers[truncatedHeader] != "" {
+				metrics.ResponsesTruncatedTotal.WithLabelValues(c.FullPath()).Inc()
+			}
+			writeCachedResponse(c, entry)
+			c.Abort()
+			return
+		}
+
+		// Subscribe before the handler reads the application set so a concurrent refresh
+		// marks the new entry stale instead of being missed
+		changed := s.argocdService.ApplicationsChanged()
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+
+		status := buffered.Status()
+		if status != http.StatusOK {
+			original.Write(buffered.body.Bytes())
+			return
+		}
+
+		entry := &cachedResponse{
+			status:      status,
+			contentType: original.Header().Get("Content-Type"),
+			headers:     map[string]string{},
+			body:        buffered.body.Bytes(),
+			etag:        responseETag(buffered.body.Bytes()),
+			changed:     changed,
+			storedAt:    time.Now(),
+		}
+		if recorder := provenance.FromContext(c.Request.Context()); recorder != nil {
+			_, entry.dataAge, _ = recorder.Get()
+		}
+		for _, name := range cachedResponseHeaders {
+			if value := original.Header().Get(name); value != "" {
+				entry.headers[name] = value
+			}
+		}
+		s.responseCache.Set(key, entry)
+
+		writeCachedResponse(c, entry)
+	}
+}
+
+// writeCachedResponse sends a cached response, answering 304 when the client already has it
+func writeCachedResponse(c *gin.Context, entry *cachedResponse) {
+	for name, value := range entry.headers {
+		c.Header(name, value)
+	}
+	c.Header("ETag", entry.etag)
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, entry.etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Data(entry.status, entry.contentType, entry.body)
+}
+
+// responseCacheKey builds the cache key from the request path, the query parameters sorted
+// by name and value, the negotiated response format, the X-Response-Format header and the
+// negotiated schema profile
+func responseCacheKey(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	for name := range query {
+		sort.Strings(query[name])
+	}
+
+	format := c.NegotiateFormat(gin.MIMEJSON)
+	return c.Request.URL.Path + "?" + query.Encode() + "#" + url.QueryEscape(format) + "#" + url.QueryEscape(c.GetHeader(responseFormatHeader)) +
+		"#" + url.QueryEscape(c.GetString(schemaProfileKey))
+}
+
+// responseETag returns an ETag derived from the response body. A JSON body is hashed in
+// canonical form, so that equal content always gets the same tag. The tag is weak when
+// canonicalization changed the bytes, since equal tags then promise equal content but
+// not byte-identical bodies; only a body hashed as it is gets a strong tag.
+func responseETag(body []byte) string {
+	weak := ""
+	if canonicalBody, err := canonical.Canonicalize(body); err == nil && !bytes.Equal(canonicalBody, body) {
+		body = canonicalBody
+		weak = "W/"
+	}
+	sum := sha256.Sum256(body)
+	return weak + `"` + hex.EncodeToString(sum[:])[:16] + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag. Like
+// If-None-Match itself, it uses the weak comparison, which ignores the W/ prefix.
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// setupResponseCacheServer returns a test server with the response cache enabled
+func setupResponseCacheServer() (*Server, *MockArgocdService) {
+	server := setupTestServer()
+	server.config.ResponseCacheTTL = time.Minute
+	server.config.ResponseCacheSize = 16
+	server.setupRouter()
+
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{
+			Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+			Spec:     types.ArgocdApplicationSpec{Project: "production"},
+			Status: types.ArgocdApplicationStatus{
+				Health: types.ArgocdApplicationHealth{Status: "Healthy"},
+				Sync:   types.ArgocdApplicationSync{Status: "Synced"},
+			},
+		},
+	}}
+	mockService.applicationsChanged = make(chan struct{})
+	return server, mockService
+}
+
+func serve(server *Server, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponseCacheServesIdenticalRequests(t *testing.T) {
+	server, mockService := setupResponseCacheServer()
+
+	first := serve(server, "/applications?health=Healthy&sync=Synced", nil)
+	second := serve(server, "/applications?sync=Synced&health=Healthy", nil)
+
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatalf("response cache statuses = %d, %d, want 200", first.Code, second.Code)
+	}
+	if mockService.applicationsCalls != 1 {
+		t.Errorf("GetApplications() called %d times, want 1 (second request from response cache)", mockService.applicationsCalls)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("cached body differs:\n%s\n%s", first.Body.String(), second.Body.String())
+	}
+	if etag := second.Header().Get("ETag"); etag == "" || etag != first.Header().Get("ETag") {
+		t.Errorf("ETag = %q, want %q", etag, first.Header().Get("ETag"))
+	}
+	if second.Header().Get(resourceVersionHeader) == "" {
+		t.Errorf("cached response missing %s header", resourceVersionHeader)
+	}
+	if second.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("cached Content-Type = %q, want %q", second.Header().Get("Content-Type"), first.Header().Get("Content-Type"))
+	}
+
+	// A different filter is a different cache entry
+	serve(server, "/applications?health=Degraded", nil)
+	if mockService.applicationsCalls != 2 {
+		t.Errorf("GetApplications() called %d times, want 2 after a new filter", mockService.applicationsCalls)
+	}
+}
+
+func TestResponseCacheInvalidatedOnRefresh(t *testing.T) {
+	server, mockService := setupResponseCacheServer()
+
+	serve(server, "/applications?health=Healthy", nil)
+	serve(server, "/applications?health=Healthy", nil)
+	if mockService.applicationsCalls != 1 {
+		t.Fatalf("GetApplications() called %d times before refresh, want 1", mockService.applicationsCalls)
+	}
+
+	// Simulate the service cache refreshing with a different application set
+	close(mockService.applicationsChanged)
+	mockService.applicationsChanged = make(chan struct{})
+
+	serve(server, "/applications?health=Healthy", nil)
+	if mockService.applicationsCalls != 2 {
+		t.Errorf("GetApplications() called %d times after refresh, want 2", mockService.applicationsCalls)
+	}
+	serve(server, "/applications?health=Healthy", nil)
+	if mockService.applicationsCalls != 2 {
+		t.Errorf("GetApplications() called %d times, want the rebuilt entry to be reused", mockService.applicationsCalls)
+	}
+}
+
+func TestResponseCacheConditionalRequest(t *testing.T) {
+	server, _ := setupResponseCacheServer()
+
+	first := serve(server, "/applications", nil)
+	etag := first.Header().Get("ETag")
+
+	w := serve(server, "/applications", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional request status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("304 response should have an empty body, got %q", w.Body.String())
+	}
+
+	w = serve(server, "/applications", map[string]string{"If-None-Match": `"other"`})
+	if w.Code != http.StatusOK {
+		t.Errorf("mismatched ETag status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestResponseETagIsCanonical(t *testing.T) {
+	compact := responseETag([]byte(`{"labels":{"a":"1","b":"2"},"reconciledAt":"2026-03-01T08:30:00Z"}`))
+	equal := responseETag([]byte(`{ "reconciledAt": "2026-03-01T09:30:00+01:00", "labels": {"b": "2", "a": "1"} }`))
+	if !etagMatches(equal, compact) {
+		t.Errorf("responseETag() = %s and %s, want equal JSON bodies to share a tag", compact, equal)
+	}
+	// Only the body that was hashed byte for byte gets a strong tag
+	if strings.HasPrefix(compact, "W/") || !strings.HasPrefix(equal, "W/") {
+		t.Errorf("responseETag() = %s and %s, want a strong tag for the canonical body and a weak one otherwise", compact, equal)
+	}
+	if changed := responseETag([]byte(`{"labels":{"a":"1","b":"3"},"reconciledAt":"2026-03-01T08:30:00Z"}`)); changed == compact {
+		t.Error("expected a changed body to get another tag")
+	}
+	if responseETag([]byte("name: web\n")) == responseETag([]byte("name: api\n")) {
+		t.Error("expected bodies that are not JSON to be hashed as they are")
+	}
+}
+
+func TestResponseCacheSkipsErrorsAndUncachedRoutes(t *testing.T) {
+	server, mockService := setupResponseCacheServer()
+	mockService.err = fmt.Errorf("ArgoCD API returned status 500")
+
+	for i := 0; i < 2; i++ {
+		if w := serve(server, "/applications", nil); w.Code != http.StatusBadGateway {
+			t.Fatalf("error status = %d, want %d", w.Code, http.StatusBadGateway)
+		}
+	}
+	if mockService.applicationsCalls != 2 {
+		t.Errorf("GetApplications() called %d times, want error responses not to be cached", mockService.applicationsCalls)
+	}
+
+	if w := serve(server, "/health", nil); w.Header().Get("ETag") != "" {
+		t.Error("routes outside the response cache should not carry an ETag")
+	}
+}
+
+func TestResponseCacheDisabledByDefault(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+
+	serve(server, "/applications", nil)
+	serve(server, "/applications", nil)
+
+	if mockService.applicationsCalls != 2 {
+		t.Errorf("GetApplications() called %d times, want 2 with the response cache disabled", mockService.applicationsCalls)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// canonicalLinkHeader points a trailing-slash request at the canonical URL it was served as
+const canonicalLinkHeader = "Link"
+
+// ServeHTTP routes a request through the gin router. A path with a trailing slash that
+// only matches a route without it is served as that route, with a Link header naming the
+// canonical URL, instead of gin's 301 redirect that some clients do not follow.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if len(path) > 1 && strings.HasSuffix(path, "/") {
+		if _, ok := s.matchRoute(path, false); !ok {
+			if canonical, ok := s.matchRoute(strings.TrimSuffix(path, "/"), false); ok {
+				r = r.Clone(r.Context())
+				r.URL.Path = canonical
+				r.URL.RawPath = strings.TrimSuffix(r.URL.RawPath, "/")
+				w.Header().Set(canonicalLinkHeader, `<`+r.URL.RequestURI()+`>; rel="canonical"`)
+			}
+		}
+	}
+	s.router.ServeHTTP(w, r)
+}
+
+// matchRoute reports whether path matches a registered route template and returns the
+// path spelled as the route spells it. With foldCase, static segments match regardless
+// of case; when several templates match, the one with the most static segments wins, as
+// it does in gin.
+func (s *Server) matchRoute(path string, foldCase bool) (string, bool) {
+	var (
+		best        string
+		bestStatics = -1
+	)
+	for _, route := range s.router.Routes() {
+		if canonical, statics, ok := matchTemplate(route.Path, path, foldCase); ok && statics > bestStatics {
+			best, bestStatics = canonical, statics
+		}
+	}
+	return best, bestStatics >= 0
+}
+
+// matchTemplate matches path against a gin route template with :param and *catchAll
+// segments, returning the path with the template's static segments and the number of
+// static segments matched
+func matchTemplate(template, path string, foldCase bool) (string, int, bool) {
+	templateSegments := strings.Split(template, "/")
+	pathSegments := strings.Split(path, "/")
+
+	canonical := make([]string, 0, len(pathSegments))
+	statics := 0
+	for i, segment := range templateSegments {
+		if i >= len(pathSegments) {
+			return "", 0, false
+		}
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			return strings.Join(append(canonical, pathSegments[i:]...), "/"), statics, true
+		case strings.HasPrefix(segment, ":"):
+			if pathSegments[i] == "" {
+				return "", 0, false
+			}
+			canonical = append(canonical, pathSegments[i])
+		case segment == pathSegments[i], foldCase && strings.EqualFold(segment, pathSegments[i]):
+			canonical = append(canonical, segment)
+			statics++
+		default:
+			return "", 0, false
+		}
+	}
+	if len(pathSegments) != len(templateSegments) {
+		return "", 0, false
+	}
+	return strings.Join(canonical, "/"), statics, true
+}
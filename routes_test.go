@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// routeSampleParams fills route parameters with values the mock service knows
+var routeSampleParams = map[string]string{
+	":name":    "my-app",
+	":group":   "frontend",
+	":project": "web-app",
+}
+
+func TestTrailingSlashVariants(t *testing.T) {
+	server := setupTestServer()
+	server.config.AdminToken = testAdminToken
+	server.setupRouter()
+
+	for _, route := range server.router.Routes() {
+		if route.Method != http.MethodGet || strings.Contains(route.Path, "*") {
+			continue
+		}
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if value, ok := routeSampleParams[segment]; ok {
+				segments[i] = value
+			}
+		}
+		path := strings.Join(segments, "/")
+
+		t.Run(route.Path, func(t *testing.T) {
+			plain := serveMethod(server, http.MethodGet, path, nil)
+			slashed := serveMethod(server, http.MethodGet, path+"/", nil)
+
+			if slashed.Code != plain.Code {
+				t.Errorf("GET %s/ status = %d, want %d as for GET %s", path, slashed.Code, plain.Code, path)
+			}
+			if got, want := slashed.Header().Get(canonicalLinkHeader), `<`+path+`>; rel="canonical"`; got != want {
+				t.Errorf("GET %s/ Link = %q, want %q", path, got, want)
+			}
+			if link := plain.Header().Get(canonicalLinkHeader); link != "" {
+				t.Errorf("GET %s Link = %q, want none", path, link)
+			}
+		})
+	}
+}
+
+func TestTrailingSlashKeepsQuery(t *testing.T) {
+	server := setupTestServer()
+
+	w := serveMethod(server, http.MethodGet, "/applications/?health=Healthy", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get(canonicalLinkHeader), `</applications?health=Healthy>; rel="canonical"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	if w := serveMethod(server, http.MethodPut, "/applications/", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /applications/ status = %d, want 405", w.Code)
+	}
+}
+
+func TestRouteCaseSuggestions(t *testing.T) {
+	tests := []struct {
+		path           string
+		wantSuggestion string
+	}{
+		{path: "/Applications", wantSuggestion: "/applications"},
+		{path: "/APPLICATIONS/Names", wantSuggestion: "/applications/names"},
+		{path: "/Groups/Frontend/Applications", wantSuggestion: "/groups/Frontend/applications"},
+		{path: "/projects/web-app/Roles/", wantSuggestion: "/projects/web-app/roles"},
+		{path: "/nonexistent"},
+		{path: "/applications/my-app/unknown"},
+	}
+
+	server := setupTestServer()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want 404", w.Code)
+			}
+			var response types.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if tt.wantSuggestion == "" {
+				if response.Message != "Endpoint not found" {
+					t.Errorf("message = %q, want no suggestion", response.Message)
+				}
+				return
+			}
+			if !strings.HasSuffix(response.Message, "did you mean "+tt.wantSuggestion+"?") {
+				t.Errorf("message = %q, want a suggestion of %s", response.Message, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	tests := []struct {
+		template      string
+		path          string
+		foldCase      bool
+		wantCanonical string
+		wantStatics   int
+		wantOK        bool
+	}{
+		{template: "/applications", path: "/applications", wantCanonical: "/applications", wantStatics: 2, wantOK: true},
+		{template: "/applications", path: "/Applications"},
+		{template: "/applications", path: "/Applications", foldCase: true, wantCanonical: "/applications", wantStatics: 2, wantOK: true},
+		{template: "/applications/:name", path: "/applications/Web", foldCase: true, wantCanonical: "/applications/Web", wantStatics: 2, wantOK: true},
+		{template: "/applications/:name", path: "/applications/"},
+		{template: "/applications/:name", path: "/applications/web/diff"},
+		{template: "/swagger/*any", path: "/swagger/", wantCanonical: "/swagger/", wantStatics: 2, wantOK: true},
+		{template: "/swagger/*any", path: "/swagger/index.html", wantCanonical: "/swagger/index.html", wantStatics: 2, wantOK: true},
+		{template: "/swagger/*any", path: "/swagger"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.path, func(t *testing.T) {
+			canonical, statics, ok := matchTemplate(tt.template, tt.path, tt.foldCase)
+			if canonical != tt.wantCanonical || statics != tt.wantStatics || ok != tt.wantOK {
+				t.Errorf("matchTemplate() = %q, %d, %v, want %q, %d, %v", canonical, statics, ok, tt.wantCanonical, tt.wantStatics, tt.wantOK)
+			}
+		})
+	}
+}
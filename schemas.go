@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// Schema profiles of the application summary
+const (
+	profileSummaryV1 = "summary-v1"
+	profileSummaryV2 = "summary-v2"
+)
+
+// schemaProfileKey is the gin context key holding the schema profile negotiated for the request
+const schemaProfileKey = "schemaProfile"
+
+// schemaProfile is a response shape of a route. render converts the value the route's
+// handler responds with into the shape; the default shape has none.
+type schemaProfile struct {
+	name        string
+	description string
+	render      func(any) any
+}
+
+// schemaProfiles lists the response shapes of the routes serving several, keyed by route
+// template. The first profile of a route is its default, served to requests naming none.
+// A new shape takes an entry here and its renderer; the handler is left as it is.
+var schemaProfiles = map[string][]schemaProfile{
+	"/bootstrap": {
+		{name: profileSummaryV1, description: "Application summaries with flat destination and status fields"},
+		{name: profileSummaryV2, description: "Application summaries with the destination and status fields grouped", render: renderAs(bootstrapSummaryV2)},
+	},
+}
+
+// renderAs adapts a renderer of the type a handler responds with to a schemaProfile
+// renderer. Values of another type are passed through with a warning.
+func renderAs[T any](render func(T) any) func(any) any {
+	return func(v any) any {
+		value, ok := v.(T)
+		if !ok {
+			log.Printf("WARNING: Schema profile renderer for %T got a %T response, serving it unchanged", value, v)
+			return v
+		}
+		return render(value)
+	}
+}
+
+// bootstrapApplicationsV2 is the applications section of /bootstrap in the summary-v2
+// profile; its summaries shadow the embedded ones
+type bootstrapApplicationsV2 struct {
+	types.BootstrapApplications
+	Summaries []types.ApplicationSummaryV2 `json:"summaries,omitzero"`
+}
+
+// bootstrapResponseV2 is /bootstrap in the summary-v2 profile
+type bootstrapResponseV2 struct {
+	types.BootstrapResponse
+	Applications *bootstrapApplicationsV2 `json:"applications"`
+}
+
+// bootstrapSummaryV2 renders a /bootstrap response in the summary-v2 profile
+func bootstrapSummaryV2(response types.BootstrapResponse) any {
+	v2 := bootstrapResponseV2{BootstrapResponse: response}
+	if response.Applications != nil {
+		v2.Applications = &bootstrapApplicationsV2{BootstrapApplications: *response.Applications}
+		if response.Applications.Summaries != nil {
+			v2.Applications.Summaries = services.SummariesV2(response.Applications.Summaries)
+		}
+	}
+	return v2
+}
+
+// schemaProfileNames returns the names of profiles
+func schemaProfileNames(profiles []schemaProfile) []string {
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		names[i] = profile.name
+	}
+	return names
+}
+
+// acceptedRange is a media range of an Accept header
+type acceptedRange struct {
+	mediaType string
+	profiles  []string
+	quality   float64
+}
+
+// parseAccept returns the media ranges of an Accept header by decreasing quality, keeping
+// the header order among equal ones. Unparsable ranges are skipped.
+func parseAccept(accept string) []acceptedRange {
+	var ranges []acceptedRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		// A profile parameter may list several space-separated profiles
+		ranges = append(ranges, acceptedRange{mediaType: mediaType, profiles: strings.Fields(params["profile"]), quality: quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+	return ranges
+}
+
+// negotiateSchemaProfile picks the profile the Accept header asks for among profiles.
+// Headers naming no profile get the default. Otherwise the JSON media ranges are tried by
+// decreasing quality: one naming profiles selects the first the route serves, and one
+// naming none selects the default. ok is false when no range is satisfied.
+func negotiateSchemaProfile(accept string, profiles []schemaProfile) (schemaProfile, bool) {
+	ranges := parseAccept(accept)
+	named := false
+	for _, r := range ranges {
+		named = named || len(r.profiles) > 0
+	}
+	if !named {
+		return profiles[0], true
+	}
+
+	for _, r := range ranges {
+		if r.quality <= 0 || (r.mediaType != gin.MIMEJSON && r.mediaType != "application/*" && r.mediaType != "*/*") {
+			continue
+		}
+		if len(r.profiles) == 0 {
+			return profiles[0], true
+		}
+		for _, name := range r.profiles {
+			for _, profile := range profiles {
+				if profile.name == name {
+					return profile, true
+				}
+			}
+		}
+	}
+	return schemaProfile{}, false
+}
+
+// schemaProfileMiddleware negotiates the schema profile of routes serving several, with
+// the profile parameter of the Accept header: "application/json;profile=summary-v2".
+// Requests naming only profiles the route does not serve get 406 with the supported ones.
+// Routes with a single shape ignore the parameter.
+func (s *Server) schemaProfileMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profiles := schemaProfiles[c.FullPath()]
+		if len(profiles) == 0 {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept")
+
+		profile, ok := negotiateSchemaProfile(c.GetHeader("Accept"), profiles)
+		if !ok {
+			names := schemaProfileNames(profiles)
+			s.renderError(c, types.ErrorResponse{
+				Error:     http.StatusText(http.StatusNotAcceptable),
+				Message:   fmt.Sprintf("%s serves none of the requested profiles, want one of %s", c.FullPath(), strings.Join(names, ", ")),
+				Code:      http.StatusNotAcceptable,
+				ErrorCode: types.ErrorCodeNotAcceptable,
+				Profiles:  names,
+			})
+			c.Abort()
+			return
+		}
+		c.Set(schemaProfileKey, profile.name)
+		c.Next()
+	}
+}
+
+// renderProfile responds with value rendered in the schema profile negotiated for the
+// request, naming the profile in the Content-Type. Routes without profiles respond with
+// value as it is.
+func (s *Server) renderProfile(c *gin.Context, status int, value any) {
+	name := c.GetString(schemaProfileKey)
+	for _, profile := range schemaProfiles[c.FullPath()] {
+		if profile.name != name {
+			continue
+		}
+		if profile.render != nil {
+			value = profile.render(value)
+		}
+		// gin keeps a Content-Type that is already set when rendering JSON
+		c.Header("Content-Type", "application/json; charset=utf-8; profile="+name)
+		break
+	}
+	c.JSON(status, value)
+}
+
+// getSchemas handles the schema profiles endpoint
+// @Summary List schema profiles
+// @Description List the routes serving several response shapes, with the profiles each one serves. A profile is selected with the profile parameter of the Accept header, e.g. "Accept: application/json;profile=summary-v2"; requests naming none get the default profile. Requests naming only unknown profiles get 406 with the supported ones in profiles
+// @Tags health
+// @Produce json
+// @Success 200 {object} types.SchemasResponse "Schema profiles by route"
+// @Router /schemas [get]
+func (s *Server) getSchemas(c *gin.Context) {
+	response := types.SchemasResponse{Routes: make([]types.SchemaRoute, 0, len(schemaProfiles))}
+	for route, profiles := range schemaProfiles {
+		schemaRoute := types.SchemaRoute{Route: route}
+		for i, profile := range profiles {
+			schemaRoute.Profiles = append(schemaRoute.Profiles, types.SchemaProfile{
+				Name:        profile.name,
+				Description: profile.description,
+				Default:     i == 0,
+			})
+		}
+		response.Routes = append(response.Routes, schemaRoute)
+	}
+	sort.Slice(response.Routes, func(i, j int) bool { return response.Routes[i].Route < response.Routes[j].Route })
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// serveBootstrapProfile requests /bootstrap with an Accept header and returns the response
+// and the keys of its first application summary
+func serveBootstrapProfile(t *testing.T, server *Server, accept string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/bootstrap", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+
+	var response struct {
+		Applications struct {
+			Summaries []map[string]json.RawMessage `json:"summaries"`
+		} `json:"applications"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Applications.Summaries) == 0 {
+		t.Fatalf("response has no application summaries: %s", w.Body.String())
+	}
+	return w, response.Applications.Summaries[0]
+}
+
+func TestSchemaProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		wantProfile string
+	}{
+		{name: "no Accept header", wantProfile: profileSummaryV1},
+		{name: "plain JSON", accept: "application/json", wantProfile: profileSummaryV1},
+		{name: "no profile named", accept: "text/html, */*;q=0.8", wantProfile: profileSummaryV1},
+		{name: "v1 profile", accept: "application/json;profile=summary-v1", wantProfile: profileSummaryV1},
+		{name: "v2 profile", accept: "application/json;profile=summary-v2", wantProfile: profileSummaryV2},
+		{name: "quoted profile list", accept: `application/json; profile="summary-v3 summary-v2"`, wantProfile: profileSummaryV2},
+		{name: "preferred by quality", accept: "application/json;profile=summary-v1;q=0.5, application/json;profile=summary-v2", wantProfile: profileSummaryV2},
+		{name: "unknown profile with plain JSON fallback", accept: "application/json;profile=summary-v3, application/json;q=0.5", wantProfile: profileSummaryV1},
+		{name: "unknown profile", accept: "application/json;profile=summary-v3"},
+		{name: "refused profile", accept: "application/json;profile=summary-v2;q=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupBootstrapServer(t, &bootstrapUpstream{})
+
+			w, summary := serveBootstrapProfile(t, server, tt.accept)
+			if vary := w.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Accept") {
+				t.Errorf("Vary = %v, want Accept listed", vary)
+			}
+
+			if tt.wantProfile == "" {
+				if w.Code != http.StatusNotAcceptable {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusNotAcceptable)
+				}
+				var response types.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				wantProfiles := []string{profileSummaryV1, profileSummaryV2}
+				if response.ErrorCode != types.ErrorCodeNotAcceptable || !reflect.DeepEqual(response.Profiles, wantProfiles) {
+					t.Errorf("response = %+v, want errorCode %q with profiles %v", response, types.ErrorCodeNotAcceptable, wantProfiles)
+				}
+				return
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/json; charset=utf-8; profile="+tt.wantProfile; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			_, flat := summary["health"]
+			_, grouped := summary["status"]
+			if tt.wantProfile == profileSummaryV1 && (!flat || grouped) {
+				t.Errorf("summary keys = %v, want the flat v1 shape", summary)
+			}
+			if tt.wantProfile == profileSummaryV2 {
+				if flat || !grouped {
+					t.Fatalf("summary keys = %v, want the grouped v2 shape", summary)
+				}
+				var destination types.ApplicationSummaryDestination
+				if err := json.Unmarshal(summary["destination"], &destination); err != nil || destination.Namespace == "" {
+					t.Errorf("destination = %s, want the grouped destination", summary["destination"])
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaProfilesResponseCache(t *testing.T) {
+	server := setupBootstrapServer(t, &bootstrapUpstream{})
+	server.config.ResponseCacheTTL = time.Minute
+	server.config.ResponseCacheSize = 10
+	server.setupRouter()
+
+	// Each profile is cached separately
+	for _, accept := range []string{"", "application/json;profile=summary-v2", ""} {
+		_, summary := serveBootstrapProfile(t, server, accept)
+		_, grouped := summary["status"]
+		if grouped != (accept != "") {
+			t.Errorf("Accept %q served summary keys %v", accept, summary)
+		}
+	}
+}
+
+func TestSchemaProfilesIgnoredWithoutProfiles(t *testing.T) {
+	server := setupTestServer()
+
+	w := serveMethod(server, http.MethodGet, "/project-groups", map[string]string{"Accept": "application/json;profile=summary-v3"})
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestGetSchemas(t *testing.T) {
+	server := setupTestServer()
+
+	w := serveMethod(server, http.MethodGet, "/schemas", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var response types.SchemasResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := types.SchemasResponse{Routes: []types.SchemaRoute{{
+		Route: "/bootstrap",
+		Profiles: []types.SchemaProfile{
+			{Name: profileSummaryV1, Description: schemaProfiles["/bootstrap"][0].description, Default: true},
+			{Name: profileSummaryV2, Description: schemaProfiles["/bootstrap"][1].description},
+		},
+	}}}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("GET /schemas = %+v, want %+v", response, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		err            error
+		expectedStatus int
+		expectedFrame  string
+	}{
+		{"success", "/projects", nil, http.StatusOK, "DENY"},
+		{"upstream error", "/projects", errors.New("ArgoCD API returned status 500"), http.StatusBadGateway, "DENY"},
+		{"invalid parameters", "/applications?health=Sleepy", nil, http.StatusBadRequest, "DENY"},
+		{"unknown route", "/does-not-exist", nil, http.StatusNotFound, "DENY"},
+		{"swagger UI", "/swagger/index.html", nil, http.StatusOK, "SAMEORIGIN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.SwaggerEnabled = true
+			server.config.HSTSMaxAge = 24 * time.Hour
+			server.setupRouter()
+			server.argocdService.(*MockArgocdService).err = tt.err
+
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GET %s status = %v, want %v", tt.path, w.Code, tt.expectedStatus)
+			}
+
+			want := map[string]string{
+				contentTypeOptionsHeader: "nosniff",
+				frameOptionsHeader:       tt.expectedFrame,
+				referrerPolicyHeader:     "no-referrer",
+				// Plain HTTP requests never get HSTS
+				hstsHeader: "",
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("GET %s %s = %q, want %q", tt.path, header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersHSTS(t *testing.T) {
+	tests := []struct {
+		name        string
+		tls         bool
+		externalURL string
+		maxAge      time.Duration
+		want        string
+	}{
+		{"TLS request", true, "", 8760 * time.Hour, "max-age=31536000"},
+		{"https external URL", false, "https://argocd-proxy.example.com", time.Hour, "max-age=3600"},
+		{"plain HTTP", false, "http://argocd-proxy.example.com", time.Hour, ""},
+		{"disabled by zero max age", true, "", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServer()
+			server.config.ExternalURL = tt.externalURL
+			server.config.HSTSMaxAge = tt.maxAge
+			server.setupRouter()
+
+			req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+
+			if got := w.Header().Get(hstsHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", hstsHeader, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersDisabled(t *testing.T) {
+	server := setupTestServer()
+	server.config.SecurityHeadersDisabled = true
+	server.config.HSTSMaxAge = time.Hour
+	server.setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/does-not-exist", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	for _, header := range []string{contentTypeOptionsHeader, frameOptionsHeader, referrerPolicyHeader, hstsHeader} {
+		if got := w.Header().Get(header); got != "" {
+			t.Errorf("%s = %q with SECURITY_HEADERS_DISABLED, want none", header, got)
+		}
+	}
+}
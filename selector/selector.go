@@ -0,0 +1,365 @@
+// Package selector parses and evaluates Kubernetes-style selectors over string maps such as
+// application labels and annotations.
+//
+// A selector is a comma-separated list of requirements, all of which must hold:
+//
+//	key              the key is present
+//	!key             the key is absent
+//	key=value        the key is present with this value; "==" is accepted as well
+//	key!=value       the key is absent or has another value
+//	key in (a,b)     the key is present with one of the values
+//	key notin (a,b)  the key is absent or has none of the values
+//
+// Whitespace between tokens is ignored. Values containing whitespace or any of ,()=!"'
+// are written in double or single quotes, inside which a backslash escapes the next
+// character.
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operator is the comparison of a requirement
+type Operator string
+
+// Operators of a requirement
+const (
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+)
+
+// Requirement is a single condition on a key
+type Requirement struct {
+	Key      string
+	Operator Operator
+	// Values holds the value of Equals and NotEquals and the set of In and NotIn
+	Values []string
+}
+
+// Matches reports whether m satisfies the requirement
+func (r Requirement) Matches(m map[string]string) bool {
+	value, ok := m[r.Key]
+	switch r.Operator {
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	case Equals, In:
+		return ok && slices.Contains(r.Values, value)
+	case NotEquals, NotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	}
+	return false
+}
+
+// Selector is a list of requirements that must all hold. The empty selector matches
+// every map.
+type Selector []Requirement
+
+// Matches reports whether m satisfies every requirement of the selector
+func (s Selector) Matches(m map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(m) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmpty reports whether the selector has no requirements
+func (s Selector) IsEmpty() bool {
+	return len(s) == 0
+}
+
+// SyntaxError reports where a selector could not be parsed
+type SyntaxError struct {
+	// Position is the 1-based character offset of the offending token
+	Position int
+	Message  string
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// Parse parses a selector. A blank string is the empty selector.
+func Parse(input string) (Selector, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+
+	var selector Selector
+	for {
+		r, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, r)
+
+		switch t := p.next(); t.kind {
+		case tokenEOF:
+			return selector, nil
+		case tokenComma:
+		default:
+			return nil, t.errorf("expected ',' or end of selector, found %s", t)
+		}
+	}
+}
+
+// tokenKind classifies a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenQuoted
+	tokenComma
+	tokenOpenParen
+	tokenCloseParen
+	tokenEquals
+	tokenNotEquals
+	tokenNot
+)
+
+// token is a lexed token; pos is its 1-based character offset
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// String describes the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of selector"
+	case tokenQuoted:
+		return fmt.Sprintf("quoted %q", t.value)
+	case tokenWord:
+		return fmt.Sprintf("%q", t.value)
+	}
+	return fmt.Sprintf("'%s'", t.value)
+}
+
+// errorf returns a syntax error at the token
+func (t token) errorf(format string, args ...any) error {
+	return &SyntaxError{Position: t.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// special lists the characters that end an unquoted word
+const special = ",()=!\"'"
+
+// lex splits input into tokens, ending with tokenEOF
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		pos := i + 1
+		switch {
+		case isSpace(c):
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: pos})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, value: "(", pos: pos})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, value: ")", pos: pos})
+			i++
+		case c == '=':
+			// "==" is the same as "="
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenEquals, value: string(runes[pos-1 : i]), pos: pos})
+		case c == '!':
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+				tokens = append(tokens, token{kind: tokenNotEquals, value: "!=", pos: pos})
+			} else {
+				tokens = append(tokens, token{kind: tokenNot, value: "!", pos: pos})
+			}
+		case c == '"' || c == '\'':
+			var value strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					value.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == c {
+					closed = true
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, &SyntaxError{Position: pos, Message: "unterminated quoted string"}
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, value: value.String(), pos: pos})
+		default:
+			start := i
+			for i < len(runes) && !isSpace(runes[i]) && !strings.ContainsRune(special, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: string(runes[start:i]), pos: pos})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// isSpace reports whether c separates tokens
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// parser reads requirements from a token list
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the next token; tokenEOF is never consumed
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// requirement parses one requirement
+func (p *parser) requirement() (Requirement, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		key, err := p.key()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: DoesNotExist}, nil
+	}
+
+	key, err := p.key()
+	if err != nil {
+		return Requirement{}, err
+	}
+
+	switch t := p.peek(); {
+	case t.kind == tokenComma || t.kind == tokenEOF:
+		return Requirement{Key: key, Operator: Exists}, nil
+	case t.kind == tokenEquals || t.kind == tokenNotEquals:
+		p.next()
+		value, err := p.value(t)
+		if err != nil {
+			return Requirement{}, err
+		}
+		op := Equals
+		if t.kind == tokenNotEquals {
+			op = NotEquals
+		}
+		return Requirement{Key: key, Operator: op, Values: []string{value}}, nil
+	case t.kind == tokenWord && (t.value == string(In) || t.value == string(NotIn)):
+		p.next()
+		values, err := p.set()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: Operator(t.value), Values: values}, nil
+	default:
+		return Requirement{}, t.errorf("expected an operator after key %q, found %s", key, t)
+	}
+}
+
+// key parses a requirement key
+func (p *parser) key() (string, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return "", t.errorf("expected a key, found %s", t)
+	}
+	if !validKey(t.value) {
+		return "", t.errorf("invalid key %q: keys contain only letters, digits and -_./", t.value)
+	}
+	return t.value, nil
+}
+
+// value parses the value following the operator op. The value may be omitted to match
+// the empty string, as in "key=".
+func (p *parser) value(op token) (string, error) {
+	switch t := p.peek(); t.kind {
+	case tokenWord, tokenQuoted:
+		p.next()
+		return t.value, nil
+	case tokenComma, tokenEOF:
+		return "", nil
+	default:
+		return "", t.errorf("expected a value after '%s', found %s", op.value, t)
+	}
+}
+
+// set parses a parenthesised, comma-separated, non-empty list of values
+func (p *parser) set() ([]string, error) {
+	if t := p.next(); t.kind != tokenOpenParen {
+		return nil, t.errorf("expected '(' to start a value set, found %s", t)
+	}
+
+	var values []string
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokenWord, tokenQuoted:
+			values = append(values, t.value)
+		case tokenCloseParen:
+			if len(values) == 0 {
+				return nil, t.errorf("value set is empty")
+			}
+			return nil, t.errorf("expected a value after ',', found %s", t)
+		default:
+			return nil, t.errorf("expected a value, found %s", t)
+		}
+
+		switch t := p.next(); t.kind {
+		case tokenCloseParen:
+			return values, nil
+		case tokenComma:
+		default:
+			return nil, t.errorf("expected ',' or ')' in value set, found %s", t)
+		}
+	}
+}
+
+// validKey reports whether key is made only of letters, digits and -_./, as Kubernetes
+// label and annotation keys are
+func validKey(key string) bool {
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == '/':
+		default:
+			return false
+		}
+	}
+	return key != ""
+}
@@ -0,0 +1,174 @@
+package selector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Selector
+	}{
+		{"empty", "", nil},
+		{"blank", "  \t ", nil},
+		{"exists", "team", Selector{{Key: "team", Operator: Exists}}},
+		{"does not exist", "!team", Selector{{Key: "team", Operator: DoesNotExist}}},
+		{"does not exist with space", "! team", Selector{{Key: "team", Operator: DoesNotExist}}},
+		{"equals", "team=payments", Selector{{Key: "team", Operator: Equals, Values: []string{"payments"}}}},
+		{"double equals", "team==payments", Selector{{Key: "team", Operator: Equals, Values: []string{"payments"}}}},
+		{"not equals", "tier!=internal", Selector{{Key: "tier", Operator: NotEquals, Values: []string{"internal"}}}},
+		{"empty value", "team=", Selector{{Key: "team", Operator: Equals, Values: []string{""}}}},
+		{"empty value before comma", "team=,tier", Selector{
+			{Key: "team", Operator: Equals, Values: []string{""}},
+			{Key: "tier", Operator: Exists},
+		}},
+		{"in", "env in (prod,staging)", Selector{{Key: "env", Operator: In, Values: []string{"prod", "staging"}}}},
+		{"notin", "env notin (dev)", Selector{{Key: "env", Operator: NotIn, Values: []string{"dev"}}}},
+		{"in without spaces around parenthesis", "env in(prod)", Selector{{Key: "env", Operator: In, Values: []string{"prod"}}}},
+		{"key named in", "in=yes", Selector{{Key: "in", Operator: Equals, Values: []string{"yes"}}}},
+		{"value named in", "mode=in", Selector{{Key: "mode", Operator: Equals, Values: []string{"in"}}}},
+		{"prefixed key", "company.io/owner=payments", Selector{{Key: "company.io/owner", Operator: Equals, Values: []string{"payments"}}}},
+		{"dotted key", "deploy.freeze=true", Selector{{Key: "deploy.freeze", Operator: Equals, Values: []string{"true"}}}},
+		{"value with dots, slashes and colons", "image=ghcr.io/team/web:1.2", Selector{{Key: "image", Operator: Equals, Values: []string{"ghcr.io/team/web:1.2"}}}},
+		{"whitespace around tokens", "  team = payments ,  tier != internal , env in ( prod , staging ) ", Selector{
+			{Key: "team", Operator: Equals, Values: []string{"payments"}},
+			{Key: "tier", Operator: NotEquals, Values: []string{"internal"}},
+			{Key: "env", Operator: In, Values: []string{"prod", "staging"}},
+		}},
+		{"tabs and newlines", "team\t=\tpayments,\ntier", Selector{
+			{Key: "team", Operator: Equals, Values: []string{"payments"}},
+			{Key: "tier", Operator: Exists},
+		}},
+		{"multiple requirements", "team=payments,tier!=internal,!legacy,region", Selector{
+			{Key: "team", Operator: Equals, Values: []string{"payments"}},
+			{Key: "tier", Operator: NotEquals, Values: []string{"internal"}},
+			{Key: "legacy", Operator: DoesNotExist},
+			{Key: "region", Operator: Exists},
+		}},
+		{"double quoted value", `owner="payments team"`, Selector{{Key: "owner", Operator: Equals, Values: []string{"payments team"}}}},
+		{"single quoted value", `owner='payments team'`, Selector{{Key: "owner", Operator: Equals, Values: []string{"payments team"}}}},
+		{"quoted special characters", `note="a,b (c)=!d"`, Selector{{Key: "note", Operator: Equals, Values: []string{"a,b (c)=!d"}}}},
+		{"quoted empty value", `team=""`, Selector{{Key: "team", Operator: Equals, Values: []string{""}}}},
+		{"quoted whitespace is kept", `team=" payments "`, Selector{{Key: "team", Operator: Equals, Values: []string{" payments "}}}},
+		{"escaped quote", `quote="say \"hi\""`, Selector{{Key: "quote", Operator: Equals, Values: []string{`say "hi"`}}}},
+		{"escaped backslash", `path="a\\b"`, Selector{{Key: "path", Operator: Equals, Values: []string{`a\b`}}}},
+		{"other quote inside quotes", `quote="it's"`, Selector{{Key: "quote", Operator: Equals, Values: []string{"it's"}}}},
+		{"quoted set values", `owner in ("payments team", 'web,team', api)`, Selector{{Key: "owner", Operator: In, Values: []string{"payments team", "web,team", "api"}}}},
+		{"unicode value", "owner=équipe", Selector{{Key: "owner", Operator: Equals, Values: []string{"équipe"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"leading comma", ",team", `expected a key, found ',' at position 1`},
+		{"trailing comma", "team,", `expected a key, found end of selector at position 6`},
+		{"double comma", "team,,tier", `expected a key, found ',' at position 6`},
+		{"missing key", "=payments", `expected a key, found '=' at position 1`},
+		{"quoted key", `"team"=payments`, `expected a key, found quoted "team" at position 1`},
+		{"invalid key", "te@m=payments", `invalid key "te@m": keys contain only letters, digits and -_./ at position 1`},
+		{"not without key", "!", `expected a key, found end of selector at position 2`},
+		{"not with operator", "!team=payments", `expected ',' or end of selector, found '=' at position 6`},
+		{"triple equals", "team===payments", `expected a value after '==', found '=' at position 7`},
+		{"value after value", "team=payments web", `expected ',' or end of selector, found "web" at position 15`},
+		{"key followed by word", "team payments", `expected an operator after key "team", found "payments" at position 6`},
+		{"unknown operator", "team<payments", `invalid key "team<payments": keys contain only letters, digits and -_./ at position 1`},
+		{"unterminated double quote", `team="payments`, `unterminated quoted string at position 6`},
+		{"unterminated single quote", `team='payments`, `unterminated quoted string at position 6`},
+		{"trailing backslash", `team="payments\`, `unterminated quoted string at position 6`},
+		{"in without set", "env in prod", `expected '(' to start a value set, found "prod" at position 8`},
+		{"in at end", "env in", `expected '(' to start a value set, found end of selector at position 7`},
+		{"empty set", "env in ()", `value set is empty at position 9`},
+		{"trailing comma in set", "env in (prod,)", `expected a value after ',', found ')' at position 14`},
+		{"unclosed set", "env in (prod", `expected ',' or ')' in value set, found end of selector at position 13`},
+		{"set values without comma", "env in (prod staging)", `expected ',' or ')' in value set, found "staging" at position 14`},
+		{"nested set", "env in ((prod))", `expected a value, found '(' at position 9`},
+		{"stray parenthesis", "team)", `expected an operator after key "team", found ')' at position 5`},
+		{"equals with set", "env=(prod)", `expected a value after '=', found '(' at position 5`},
+		{"position counts characters", "owner=équipe x", `expected ',' or end of selector, found "x" at position 14`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %+v, want an error", tt.input, got)
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %T, want *SyntaxError", tt.input, err)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.input, err.Error(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "tier": "web", "empty": ""}
+
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{"", true},
+		{"team", true},
+		{"missing", false},
+		{"!missing", true},
+		{"!team", false},
+		{"team=payments", true},
+		{"team=web", false},
+		{"missing=payments", false},
+		{"team!=web", true},
+		{"team!=payments", false},
+		{"missing!=payments", true},
+		{"empty=", true},
+		{"empty", true},
+		{"missing=", false},
+		{"tier in (web,api)", true},
+		{"tier in (api)", false},
+		{"missing in (web)", false},
+		{"tier notin (api,worker)", true},
+		{"tier notin (web)", false},
+		{"missing notin (web)", true},
+		{"team=payments,tier=web", true},
+		{"team=payments,tier=api", false},
+		{"team=payments,!legacy,tier in (web)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.selector, err)
+			}
+			if got := s.Matches(labels); got != tt.expected {
+				t.Errorf("Parse(%q).Matches(%v) = %v, want %v", tt.selector, labels, got, tt.expected)
+			}
+		})
+	}
+
+	// A nil map has no keys
+	if s, _ := Parse("!team,tier!=web"); !s.Matches(nil) {
+		t.Error("Matches(nil) = false, want true for absence requirements")
+	}
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/auth"
+	"argocd-proxy/clientstats"
+	"argocd-proxy/config"
+	"argocd-proxy/lifecycle"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// selfTestGroup is the project group added for the self-test when PROJECT_GROUPS is empty,
+// so that the group routes have a group to serve
+const selfTestGroup = "self-test"
+
+// selfTestProbe is how the self-test calls a route and the status it expects back
+type selfTestProbe struct {
+	query  string
+	body   string
+	status int
+}
+
+// selfTestProbes are the probes of the routes that need a query, a body or a status
+// other than 200, keyed by method and route template. GET and HEAD routes not listed are
+// expected to answer 200 without a query; routes with other methods must be listed, so a
+// new write route cannot go unchecked. Write probes must not change the server's state.
+var selfTestProbes = map[string]selfTestProbe{
+	"GET /admin/explain":            {query: "project={project}&application={name}", status: http.StatusOK},
+	"HEAD /admin/explain":           {query: "project={project}&application={name}", status: http.StatusOK},
+	"PUT /admin/ignored-projects":   {body: "null", status: http.StatusBadRequest},
+	"POST /admin/filters/simulate":  {body: `{"ignoredProjects":[],"projectGroups":[]}`, status: http.StatusOK},
+	"POST /admin/maintenance":       {body: "{}", status: http.StatusBadRequest},
+	"DELETE /admin/maintenance":     {status: http.StatusOK},
+	"GET /applications/:name/logs":  {query: "tailLines=10", status: http.StatusOK},
+	"HEAD /applications/:name/logs": {query: "tailLines=10", status: http.StatusOK},
+}
+
+// selfTestResult is the outcome of probing one route
+type selfTestResult struct {
+	Method string
+	Route  string
+	// URL is the request the route was probed with, empty when it could not be built
+	URL      string
+	Status   int
+	Want     int
+	Duration time.Duration
+	// Problem says why the probe failed, empty when it passed
+	Problem string
+}
+
+// selfTestReport lists the outcome of every probed route
+type selfTestReport struct {
+	Results []selfTestResult
+}
+
+// Failed returns the number of routes whose probe failed
+func (r selfTestReport) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Problem != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write prints one line per route and a summary
+func (r selfTestReport) Write(w io.Writer) {
+	for _, result := range r.Results {
+		if result.Problem == "" {
+			fmt.Fprintf(w, "PASS %-6s %-45s %d %s\n", result.Method, result.Route, result.Status, result.Duration.Round(time.Millisecond))
+			continue
+		}
+		fmt.Fprintf(w, "FAIL %-6s %-45s %s\n", result.Method, result.Route, result.Problem)
+		if result.URL != "" {
+			fmt.Fprintf(w, "     request: %s %s\n", result.Method, result.URL)
+		}
+	}
+	fmt.Fprintf(w, "%d routes checked: %d passed, %d failed\n", len(r.Results), len(r.Results)-r.Failed(), r.Failed())
+}
+
+// selfTestFixture is an in-process ArgoCD serving one application per project
+type selfTestFixture struct {
+	*httptest.Server
+	// Project and Application are the project and application the route parameters are
+	// filled with
+	Project     string
+	Application string
+	Group       string
+}
+
+// selfTestMain runs the self-test with the configuration from the environment, printing
+// the report to w, and returns the process exit code. The ArgoCD settings are replaced
+// by the fixture, so they need not be set.
+func selfTestMain(w io.Writer) int {
+	for _, key := range []string{"ARGOCD_API_URL", "ARGOCD_USERNAME", "ARGOCD_PASSWORD"} {
+		if os.Getenv(key) == "" {
+			os.Setenv(key, "http://self-test.invalid")
+		}
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(w, "FAIL configuration: %v\n", err)
+		return 1
+	}
+	// Request logs go to stderr so that stdout holds only the report
+	gin.DefaultWriter = os.Stderr
+
+	server, fixture := newSelfTestServer(cfg)
+	defer fixture.Close()
+	defer server.lifecycle.Shutdown(context.Background())
+
+	report := server.runSelfTest(fixture)
+	report.Write(w)
+	if report.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// newSelfTestServer starts the ArgoCD fixture and builds a server for cfg talking to it.
+// Settings that reach outside the process (token cache file, alert webhook, Redis,
+// audit log, outbound proxy, background poller) are turned off, as are API keys and
+// upstream impersonation, which the self-test does not exercise.
+func newSelfTestServer(cfg *config.Config) (*Server, *selfTestFixture) {
+	fixture := newSelfTestFixture(cfg)
+
+	cfg.ArgocdAPIURL = fixture.URL + "/api/v1"
+	cfg.ArgocdAPIURLs = []string{cfg.ArgocdAPIURL}
+	cfg.ArgocdUsername = "self-test"
+	cfg.ArgocdPassword = "self-test"
+	cfg.ArgocdProjectTokens = nil
+	cfg.TokenCacheFile = ""
+	cfg.AlertWebhookURL = ""
+	cfg.CacheBackend = config.CacheBackendMemory
+	cfg.PollInterval = 0
+	cfg.AuditLog = false
+	cfg.OutboundProxy = ""
+	cfg.APIKeys = nil
+	cfg.UpstreamImpersonationHeader = ""
+
+	server := &Server{
+		config:    cfg,
+		lifecycle: lifecycle.New(context.Background()),
+		startTime: time.Now(),
+	}
+	server.endpoints = upstream.NewEndpointsFor(cfg)
+	server.authService = auth.NewAuthService(cfg, server.endpoints)
+	server.argocdService = services.NewArgocdService(cfg, server.authService, server.endpoints)
+	if cfg.ClientStats {
+		server.clientStats = clientstats.New(clientstats.Options{
+			MaxClients:  cfg.ClientStatsMaxClients,
+			HalfLife:    cfg.ClientStatsHalfLife,
+			IdleTimeout: cfg.ClientStatsIdleTimeout,
+			TopRoutes:   clientStatsTopRoutes,
+			Salt:        cfg.ClientStatsSalt,
+		})
+	}
+	server.setupRouter()
+	return server, fixture
+}
+
+// runSelfTest probes every route registered on the router, in path order, against the
+// fixture. Route parameters are filled with the fixture's project, application and group.
+func (s *Server) runSelfTest(fixture *selfTestFixture) selfTestReport {
+	values := map[string]string{
+		"name":    fixture.Application,
+		"project": fixture.Project,
+		"group":   fixture.Group,
+		"any":     "index.html",
+	}
+
+	routes := s.router.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	var report selfTestReport
+	for _, route := range routes {
+		report.Results = append(report.Results, s.probeRoute(route.Method, route.Path, values))
+	}
+	return report
+}
+
+// probeRoute calls one route through the server and checks its status
+func (s *Server) probeRoute(method, route string, values map[string]string) selfTestResult {
+	result := selfTestResult{Method: method, Route: route, Want: http.StatusOK}
+
+	probe, ok := selfTestProbes[method+" "+route]
+	switch {
+	case ok:
+		result.Want = probe.status
+	case method != http.MethodGet && method != http.MethodHead:
+		result.Problem = "no self-test probe for this route; add one to selfTestProbes"
+		return result
+	}
+
+	path, err := fillRouteTemplate(route, values)
+	if err != nil {
+		result.Problem = err.Error()
+		return result
+	}
+	target := path
+	if probe.query != "" {
+		target += "?" + fillProbeQuery(probe.query, values)
+	}
+	result.URL = target
+
+	var body io.Reader
+	if probe.body != "" {
+		body = strings.NewReader(probe.body)
+	}
+	req := httptest.NewRequest(method, target, body)
+	if probe.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.config.AdminToken != "" && strings.HasPrefix(route, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+s.config.AdminToken)
+	}
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	s.ServeHTTP(w, req)
+	result.Duration = time.Since(start)
+	result.Status = w.Code
+	if w.Code != result.Want {
+		result.Problem = fmt.Sprintf("status %d, want %d", w.Code, result.Want)
+		if message := strings.TrimSpace(w.Body.String()); message != "" && len(message) < 300 {
+			result.Problem += ": " + message
+		}
+	}
+	return result
+}
+
+// fillRouteTemplate replaces the :param and *catchAll segments of a route template with
+// values, failing for a parameter the self-test has no value for
+func fillRouteTemplate(template string, values map[string]string) (string, error) {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		value, ok := values[segment[1:]]
+		if !ok {
+			return "", fmt.Errorf("no self-test value for route parameter %s", segment)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// fillProbeQuery replaces the {param} placeholders of a probe query with values
+func fillProbeQuery(query string, values map[string]string) string {
+	for name, value := range values {
+		query = strings.ReplaceAll(query, "{"+name+"}", url.QueryEscape(value))
+	}
+	return query
+}
+
+// newSelfTestFixture starts an ArgoCD fixture with one healthy, synced application in
+// "default" and in each project of PROJECT_GROUPS. The route parameters use the first
+// project of the first group that IGNORED_PROJECTS does not hide. When no group is
+// configured, a self-test group of that project is added to cfg.
+func newSelfTestFixture(cfg *config.Config) *selfTestFixture {
+	fixture := &selfTestFixture{Project: "default"}
+	projects := []string{"default"}
+	seen := map[string]bool{"default": true}
+	for _, group := range cfg.ProjectGroups {
+		for _, project := range group.Projects {
+			if !seen[project] {
+				seen[project] = true
+				projects = append(projects, project)
+			}
+		}
+	}
+	snapshot := cfg.Snapshot()
+	for _, group := range cfg.ProjectGroups {
+		if fixture.Group != "" {
+			break
+		}
+		for _, project := range group.Projects {
+			if !snapshot.IsProjectIgnored(project) {
+				fixture.Project, fixture.Group = project, group.Name
+				break
+			}
+		}
+	}
+	if fixture.Group == "" {
+		if len(cfg.ProjectGroups) > 0 {
+			fixture.Group = cfg.ProjectGroups[0].Name
+		} else {
+			fixture.Group = selfTestGroup
+			cfg.ProjectGroups = []config.ProjectGroup{{Name: selfTestGroup, Description: "Added by the self-test", Projects: []string{fixture.Project}}}
+		}
+	}
+	fixture.Application = fixture.Project + "-self-test"
+
+	now := time.Now().UTC().Truncate(time.Second)
+	apps := types.ArgocdApplicationList{APIVersion: "argoproj.io/v1alpha1", Kind: "ApplicationList"}
+	apps.Metadata.ResourceVersion = "1"
+	projectList := types.ArgocdProjectList{APIVersion: "argoproj.io/v1alpha1", Kind: "AppProjectList"}
+	projectList.Metadata.ResourceVersion = "1"
+	for _, project := range projects {
+		apps.Items = append(apps.Items, selfTestApplication(project+"-self-test", project, now))
+		projectList.Items = append(projectList.Items, types.ArgocdProject{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "AppProject",
+			Metadata:   types.ArgocdProjectMetadata{Name: project, Namespace: "argocd"},
+			Spec: types.ArgocdProjectSpec{
+				SourceRepos:  []string{"*"},
+				Destinations: []types.ArgocdProjectDestination{{Server: "https://kubernetes.default.svc", Namespace: "*"}},
+				Roles:        []types.ArgocdProjectRole{{Name: "read-only", Policies: []string{"p, proj:" + project + ":read-only, applications, get, " + project + "/*, allow"}}},
+			},
+		})
+	}
+	byName := make(map[string]types.ArgocdApplication, len(apps.Items))
+	for _, app := range apps.Items {
+		byName[app.Metadata.Name] = app
+	}
+
+	writeJSON := func(w http.ResponseWriter, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+	withApplication := func(handler func(w http.ResponseWriter, app types.ArgocdApplication)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			app, ok := byName[r.PathValue("name")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"application not found","code":5}`))
+				return
+			}
+			handler(w, app)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/session", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.ArgocdSessionResponse{Token: "self-test-token"})
+	})
+	mux.HandleFunc("GET /api/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"Version": "v2.12.0"})
+	})
+	mux.HandleFunc("GET /api/v1/settings", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"url": fixture.URL})
+	})
+	mux.HandleFunc("GET /api/v1/clusters", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.ArgocdClusterList{Items: []types.ArgocdCluster{{Server: "https://kubernetes.default.svc", Name: "in-cluster"}}})
+	})
+	mux.HandleFunc("GET /api/v1/projects", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, projectList)
+	})
+	mux.HandleFunc("GET /api/v1/applications", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, apps)
+	})
+	mux.HandleFunc("GET /api/v1/applications/{name}", withApplication(func(w http.ResponseWriter, app types.ArgocdApplication) {
+		writeJSON(w, app)
+	}))
+	mux.HandleFunc("GET /api/v1/applications/{name}/syncwindows", withApplication(func(w http.ResponseWriter, app types.ArgocdApplication) {
+		writeJSON(w, types.ArgocdApplicationSyncWindowsResponse{AssignedWindows: []types.ArgocdSyncWindow{}, ActiveWindows: []types.ArgocdSyncWindow{}, CanSync: true})
+	}))
+	mux.HandleFunc("GET /api/v1/applications/{name}/managed-resources", withApplication(func(w http.ResponseWriter, app types.ArgocdApplication) {
+		state := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"` + app.Metadata.Name + `"}}`
+		writeJSON(w, types.ArgocdManagedResourcesResponse{Items: []types.ArgocdManagedResource{
+			{Group: "apps", Kind: "Deployment", Namespace: app.Spec.Destination.Namespace, Name: app.Metadata.Name, TargetState: state, LiveState: state},
+		}})
+	}))
+	mux.HandleFunc("GET /api/v1/applications/{name}/logs", withApplication(func(w http.ResponseWriter, app types.ArgocdApplication) {
+		encoder := json.NewEncoder(w)
+		for _, line := range []string{"starting", "ready"} {
+			encoder.Encode(map[string]any{"result": map[string]any{"content": line, "podName": app.Metadata.Name + "-0"}})
+		}
+		encoder.Encode(map[string]any{"result": map[string]any{"last": true}})
+	}))
+	fixture.Server = httptest.NewServer(mux)
+	return fixture
+}
+
+// selfTestApplication returns a healthy, synced application of project deployed once
+func selfTestApplication(name, project string, now time.Time) types.ArgocdApplication {
+	created := now.Add(-24 * time.Hour)
+	app := types.ArgocdApplication{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: types.ArgocdApplicationMetadata{
+			Name:              name,
+			Namespace:         "argocd",
+			CreationTimestamp: created,
+			UID:               name + "-uid",
+		},
+	}
+	app.Spec.Project = project
+	app.Spec.Source = types.ArgocdApplicationSource{RepoURL: "https://github.com/example/" + name + ".git", Path: "deploy", TargetRevision: "main"}
+	app.Spec.Destination = types.ArgocdApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: project}
+	app.Status.Health.Status = "Healthy"
+	app.Status.Sync.Status = "Synced"
+	app.Status.Sync.Revision = "0123456789abcdef0123456789abcdef01234567"
+	app.Status.ReconciledAt = now
+	app.Status.Summary = &types.ArgocdApplicationSummary{Images: []string{"example/" + name + ":1.0.0"}, ExternalURLs: []string{"https://" + name + ".example.com"}}
+	app.Status.History = []types.ArgocdRevisionHistory{{ID: 1, Revision: app.Status.Sync.Revision, DeployedAt: created.Add(time.Hour)}}
+	return app
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+)
+
+// setupSelfTestServer builds a self-test server with every optional route registered
+func setupSelfTestServer(t *testing.T) (*Server, *selfTestFixture) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	for key, value := range map[string]string{
+		"ARGOCD_API_URL":        "http://argocd.invalid/api/v1",
+		"ARGOCD_USERNAME":       "admin",
+		"ARGOCD_PASSWORD":       "secret",
+		"PROJECT_GROUPS":        `[{"name":"Frontend","description":"Frontend apps","projects":["test-web","web-app"]}]`,
+		"IGNORED_PROJECTS":      "test-*",
+		"OWNER_ANNOTATIONS":     "team",
+		"LOGS_ENDPOINT_ENABLED": "true",
+		"ADMIN_TOKEN":           "self-test-admin-token",
+		"CLIENT_STATS":          "true",
+		"DEEP_HEALTH":           "true",
+		"SWAGGER_ENABLED":       "true",
+		"RESPONSE_CACHE_TTL":    "1m",
+	} {
+		t.Setenv(key, value)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	server, fixture := newSelfTestServer(cfg)
+	t.Cleanup(fixture.Close)
+	t.Cleanup(func() { server.lifecycle.Shutdown(context.Background()) })
+	return server, fixture
+}
+
+func TestSelfTestPasses(t *testing.T) {
+	server, fixture := setupSelfTestServer(t)
+	if fixture.Project != "web-app" || fixture.Group != "Frontend" {
+		t.Errorf("fixture project and group = %q, %q, want the first one not ignored", fixture.Project, fixture.Group)
+	}
+
+	report := server.runSelfTest(fixture)
+	var out bytes.Buffer
+	report.Write(&out)
+	if report.Failed() != 0 {
+		t.Fatalf("self-test failed:\n%s", out.String())
+	}
+
+	// Every registered route is probed, including the optional ones
+	probed := make(map[string]bool)
+	for _, result := range report.Results {
+		probed[result.Method+" "+result.Route] = true
+	}
+	for _, route := range server.router.Routes() {
+		if !probed[route.Method+" "+route.Path] {
+			t.Errorf("route %s %s was not probed", route.Method, route.Path)
+		}
+	}
+	for _, want := range []string{"GET /applications/:name/logs", "GET /admin/support-bundle", "GET /owners", "PUT /admin/ignored-projects", "GET /swagger/*any"} {
+		if !probed[want] {
+			t.Errorf("optional route %s was not probed", want)
+		}
+	}
+	if !strings.Contains(out.String(), "0 failed") {
+		t.Errorf("report summary missing from:\n%s", out.String())
+	}
+}
+
+func TestSelfTestDetectsBrokenHandler(t *testing.T) {
+	server, fixture := setupSelfTestServer(t)
+	server.router.GET("/broken", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+	server.router.POST("/unprobed", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	report := server.runSelfTest(fixture)
+	if got := report.Failed(); got != 2 {
+		t.Errorf("Failed() = %d, want 2", got)
+	}
+	var out bytes.Buffer
+	report.Write(&out)
+	for _, want := range []string{
+		"FAIL GET    /broken",
+		"status 500, want 200",
+		"FAIL POST   /unprobed",
+		"no self-test probe for this route",
+		"2 failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestFillRouteTemplate(t *testing.T) {
+	values := map[string]string{"name": "web app", "any": "index.html"}
+	if got, err := fillRouteTemplate("/applications/:name/diff", values); err != nil || got != "/applications/web%20app/diff" {
+		t.Errorf("fillRouteTemplate() = %q, %v", got, err)
+	}
+	if got, err := fillRouteTemplate("/swagger/*any", values); err != nil || got != "/swagger/index.html" {
+		t.Errorf("fillRouteTemplate() = %q, %v", got, err)
+	}
+	if _, err := fillRouteTemplate("/clusters/:cluster", values); err == nil {
+		t.Error("fillRouteTemplate() succeeded for a parameter without a value")
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// Application sort fields accepted by ApplicationSort
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+)
+
+// ApplicationSort orders an application list. An empty Field keeps the upstream order.
+type ApplicationSort struct {
+	Field      string
+	Descending bool
+}
+
+// Apply returns a copy of the list ordered by the sort field. Ties are broken by name,
+// and applications without a creation timestamp sort last in either direction.
+func (s ApplicationSort) Apply(list types.ArgocdApplicationList) types.ArgocdApplicationList {
+	if s.Field == "" {
+		return list
+	}
+
+	items := append([]types.ArgocdApplication(nil), list.Items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].Metadata, items[j].Metadata
+		if s.Field == SortByCreated && !a.CreationTimestamp.Equal(b.CreationTimestamp) {
+			if a.CreationTimestamp.IsZero() || b.CreationTimestamp.IsZero() {
+				return b.CreationTimestamp.IsZero()
+			}
+			if s.Descending {
+				return a.CreationTimestamp.After(b.CreationTimestamp)
+			}
+			return a.CreationTimestamp.Before(b.CreationTimestamp)
+		}
+		if s.Descending && s.Field == SortByName {
+			return a.Name > b.Name
+		}
+		return a.Name < b.Name
+	})
+
+	list.Items = items
+	return list
+}
+
+// WithApplicationAges returns a copy of the list with each application's age computed
+// relative to now. Ages are derived at response time rather than cached with the list.
+func WithApplicationAges(list types.ArgocdApplicationList, now time.Time) types.ArgocdApplicationList {
+	items := make([]types.ArgocdApplication, len(list.Items))
+	for i, app := range list.Items {
+		items[i] = WithApplicationAge(app, now)
+	}
+	list.Items = items
+	return list
+}
+
+// WithApplicationAge sets the application's age relative to now. Applications without a
+// creation timestamp get a nil age.
+func WithApplicationAge(app types.ArgocdApplication, now time.Time) types.ArgocdApplication {
+	app.Age = nil
+	if created := app.Metadata.CreationTimestamp; !created.IsZero() {
+		age := max(now.Sub(created), 0)
+		app.Age = &types.ApplicationAge{
+			Seconds: int64(age / time.Second),
+			Human:   humanizeAge(age),
+		}
+	}
+	return app
+}
+
+// RecentApplications returns the applications created within since of now, newest first
+func RecentApplications(list types.ArgocdApplicationList, since time.Duration, now time.Time) types.ArgocdApplicationList {
+	cutoff := now.Add(-since)
+
+	var recent []types.ArgocdApplication
+	for _, app := range list.Items {
+		if created := app.Metadata.CreationTimestamp; !created.IsZero() && !created.Before(cutoff) {
+			recent = append(recent, app)
+		}
+	}
+
+	list.Items = recent
+	return ApplicationSort{Field: SortByCreated, Descending: true}.Apply(list)
+}
+
+// humanizeAge formats an age the way kubectl prints resource ages, keeping the two most
+// significant units for short ages and coarsening as the age grows
+func humanizeAge(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	minutes := int64(d / time.Minute)
+	hours := int64(d / time.Hour)
+	days := hours / 24
+	years := days / 365
+
+	switch {
+	case d < 2*time.Minute:
+		return fmt.Sprintf("%ds", seconds)
+	case d < 10*time.Minute:
+		if s := seconds % 60; s != 0 {
+			return fmt.Sprintf("%dm%ds", minutes, s)
+		}
+		return fmt.Sprintf("%dm", minutes)
+	case d < 3*time.Hour:
+		return fmt.Sprintf("%dm", minutes)
+	case d < 8*time.Hour:
+		if m := minutes % 60; m != 0 {
+			return fmt.Sprintf("%dh%dm", hours, m)
+		}
+		return fmt.Sprintf("%dh", hours)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", hours)
+	case d < 8*24*time.Hour:
+		if h := hours % 24; h != 0 {
+			return fmt.Sprintf("%dd%dh", days, h)
+		}
+		return fmt.Sprintf("%dd", days)
+	case days < 2*365:
+		return fmt.Sprintf("%dd", days)
+	case years < 8:
+		if dd := days % 365; dd != 0 {
+			return fmt.Sprintf("%dy%dd", years, dd)
+		}
+		return fmt.Sprintf("%dy", years)
+	default:
+		return fmt.Sprintf("%dy", years)
+	}
+}
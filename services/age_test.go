@@ -0,0 +1,136 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// ageFixtureNow is the fixed reference time for age computations in tests
+var ageFixtureNow = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+func createdApp(name string, created time.Time) types.ArgocdApplication {
+	return types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: name, CreationTimestamp: created},
+	}
+}
+
+func appNames(list types.ArgocdApplicationList) []string {
+	names := make([]string, 0, len(list.Items))
+	for _, app := range list.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	return names
+}
+
+func TestHumanizeAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{119 * time.Second, "119s"},
+		{5*time.Minute + 30*time.Second, "5m30s"},
+		{5 * time.Minute, "5m"},
+		{95 * time.Minute, "95m"},
+		{5*time.Hour + 12*time.Minute, "5h12m"},
+		{30 * time.Hour, "30h"},
+		{3*24*time.Hour + 4*time.Hour, "3d4h"},
+		{400 * 24 * time.Hour, "400d"},
+		{3*365*24*time.Hour + 10*24*time.Hour, "3y10d"},
+		{10 * 365 * 24 * time.Hour, "10y"},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeAge(tt.age); got != tt.want {
+			t.Errorf("humanizeAge(%s) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestWithApplicationAge(t *testing.T) {
+	tests := []struct {
+		name    string
+		created time.Time
+		want    *types.ApplicationAge
+	}{
+		{"created earlier", ageFixtureNow.Add(-(3*24*time.Hour + 4*time.Hour)), &types.ApplicationAge{Seconds: 273600, Human: "3d4h"}},
+		{"zero timestamp", time.Time{}, nil},
+		{"clock skew clamps to zero", ageFixtureNow.Add(time.Minute), &types.ApplicationAge{Seconds: 0, Human: "0s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WithApplicationAge(createdApp("web", tt.created), ageFixtureNow)
+			if !reflect.DeepEqual(got.Age, tt.want) {
+				t.Errorf("WithApplicationAge() age = %+v, want %+v", got.Age, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithApplicationAgesDoesNotMutate(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		createdApp("web", ageFixtureNow.Add(-time.Hour)),
+	}}
+
+	aged := WithApplicationAges(list, ageFixtureNow)
+	if aged.Items[0].Age == nil || aged.Items[0].Age.Seconds != 3600 {
+		t.Errorf("WithApplicationAges() age = %+v, want 3600 seconds", aged.Items[0].Age)
+	}
+	if list.Items[0].Age != nil {
+		t.Errorf("WithApplicationAges() mutated the input list")
+	}
+}
+
+func TestApplicationSort(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		createdApp("legacy", time.Time{}),
+		createdApp("web", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		createdApp("api", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		createdApp("worker", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		createdApp("cron", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}}
+
+	tests := []struct {
+		name string
+		sort ApplicationSort
+		want []string
+	}{
+		{"unsorted keeps upstream order", ApplicationSort{}, []string{"legacy", "web", "api", "worker", "cron"}},
+		{"created ascending", ApplicationSort{Field: SortByCreated}, []string{"cron", "web", "worker", "api", "legacy"}},
+		{"created descending", ApplicationSort{Field: SortByCreated, Descending: true}, []string{"api", "web", "worker", "cron", "legacy"}},
+		{"name ascending", ApplicationSort{Field: SortByName}, []string{"api", "cron", "legacy", "web", "worker"}},
+		{"name descending", ApplicationSort{Field: SortByName, Descending: true}, []string{"worker", "web", "legacy", "cron", "api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appNames(tt.sort.Apply(list)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if list.Items[0].Metadata.Name != "legacy" {
+		t.Errorf("Apply() reordered the input list")
+	}
+}
+
+func TestRecentApplications(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		createdApp("legacy", time.Time{}),
+		createdApp("old", ageFixtureNow.Add(-10*24*time.Hour)),
+		createdApp("yesterday", ageFixtureNow.Add(-24*time.Hour)),
+		createdApp("boundary", ageFixtureNow.Add(-72*time.Hour)),
+		createdApp("fresh", ageFixtureNow.Add(-time.Hour)),
+	}}
+
+	got := appNames(RecentApplications(list, 72*time.Hour, ageFixtureNow))
+	if want := []string{"fresh", "yesterday", "boundary"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecentApplications() = %v, want %v", got, want)
+	}
+}
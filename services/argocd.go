@@ -3,90 +3,214 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
+	"argocd-proxy/breaker"
 	"argocd-proxy/cache"
+	"argocd-proxy/canonical"
+	"argocd-proxy/clock"
 	"argocd-proxy/config"
+	"argocd-proxy/events"
 	"argocd-proxy/metrics"
+	"argocd-proxy/provenance"
+	"argocd-proxy/redact"
+	"argocd-proxy/shadow"
+	"argocd-proxy/timing"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+	"argocd-proxy/workpool"
 )
 
 // ArgocdService provides access to ArgoCD API endpoints
 type ArgocdService struct {
-	config            *config.Config
-	authService       types.AuthServiceInterface
-	httpClient        *http.Client
-	projectsCache     *cache.Cache[[]types.ArgocdProject]
-	applicationsCache *cache.Cache[types.ArgocdApplicationList]
+	config      *config.Config
+	authService types.AuthServiceInterface
+	httpClient  *http.Client
+	// client makes the authenticated, instrumented calls to ArgoCD over httpClient
+	client *upstream.Client
+	// pool bounds the concurrent ArgoCD calls made by fan-outs
+	pool *workpool.Pool
+	// appCircuits fails reads of applications that keep failing fast; nil when disabled
+	appCircuits *breaker.Set
+	// stateAges tracks when applications entered their status, fed by the background poller
+	stateAges *stateAges
+	// projectsCache and applicationsCache are shared between replicas with CACHE_BACKEND=redis
+	projectsCache     cache.Store[types.ArgocdProjectList]
+	applicationsCache cache.Store[types.ArgocdApplicationList]
+	clustersCache     *cache.Cache[types.ArgocdClusterList]
+	// redisClient backs the shared caches; nil with the memory backend
+	redisClient redis.UniversalClient
+	// invalidateMu guards invalidations, counted by generation, so that a fetch that began
+	// before the last one does not store its list
+	invalidateMu sync.Mutex
+	generation   uint64
+
+	// changeMu guards the change notification state for the cached application set
+	changeMu         sync.Mutex
+	applicationsHash string
+	// snapshots keeps recent application sets for delta responses, guarded by changeMu
+	snapshots           *snapshotHistory
+	applicationsChanged chan struct{}
+
+	// fetchMu guards the last successful fetch time per upstream resource
+	fetchMu   sync.RWMutex
+	lastFetch map[string]time.Time
+
+	healthHistory *HealthHistory
+
+	// compatMu guards the latest compatibility probe report
+	compatMu      sync.RWMutex
+	compatibility *types.CompatibilityReport
+
+	// shadow runs candidate implementations of service calls for comparison; nil unless
+	// SHADOW_MODE=compare
+	shadow *shadow.Runner
+
+	// clock tells the time for cache ages, fetch times and health records
+	clock clock.Clock
 }
 
-// NewArgocdService creates a new ArgoCD service instance
-func NewArgocdService(cfg *config.Config, authSvc types.AuthServiceInterface) *ArgocdService {
-	return &ArgocdService{
-		config:      cfg,
-		authService: authSvc,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		projectsCache:     cache.New[[]types.ArgocdProject](cfg.CacheTTL),
-		applicationsCache: cache.New[types.ArgocdApplicationList](cfg.CacheTTL),
-	}
-}
+// Errors wrapped by service methods so that callers can classify failures with errors.Is
+var (
+	// ErrNotFound is wrapped when a requested application, project or group does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrFiltered is wrapped when a requested resource is hidden by the ignored-project rules
+	ErrFiltered = errors.New("filtered by ignored projects")
+	// ErrUnauthorized is wrapped when ArgoCD rejects the proxy's credentials
+	ErrUnauthorized = upstream.ErrUnauthorized
+	// ErrUpstreamTooLarge is wrapped when an ArgoCD response exceeds UPSTREAM_MAX_BODY_BYTES
+	ErrUpstreamTooLarge = upstream.ErrUpstreamTooLarge
+	// ErrSuppressed is wrapped when a read needs ArgoCD while calls are suppressed by
+	// maintenance mode and nothing is cached
+	ErrSuppressed = upstream.ErrSuppressed
+	// ErrRedirectNotAllowed is wrapped when ArgoCD redirects to a host outside
+	// ARGOCD_REDIRECT_HOSTS, or from HTTPS to plain HTTP
+	ErrRedirectNotAllowed = upstream.ErrRedirectNotAllowed
+	// ErrGroupNotFound is returned when a requested project group is not configured
+	ErrGroupNotFound = fmt.Errorf("project group %w", ErrNotFound)
+)
 
-// doInstrumented executes an HTTP request and records ArgoCD API metrics.
-func (s *ArgocdService) doInstrumented(req *http.Request, endpoint string) (*http.Response, error) {
-	start := time.Now()
-	resp, err := s.httpClient.Do(req)
-	duration := time.Since(start).Seconds()
+// StatusError is wrapped when ArgoCD answered with an unexpected status. It carries the
+// status code and the redacted, truncated response body, retrieved with errors.As.
+type StatusError = upstream.StatusError
 
-	metrics.ArgocdAPIRequestDuration.WithLabelValues(endpoint).Observe(duration)
+// Upstream resources tracked for last successful fetch times
+const (
+	ResourceProjects     = "projects"
+	ResourceApplications = "applications"
+	ResourceHealth       = "health"
+)
 
-	status := "error"
-	if err == nil {
-		status = strconv.Itoa(resp.StatusCode)
+// NewArgocdService creates a new ArgoCD service instance calling ArgoCD at endpoints,
+// which should be those of the login so that both fail over together. Nil endpoints are
+// built from the ARGOCD_API_URL URLs of cfg.
+func NewArgocdService(cfg *config.Config, authSvc types.AuthServiceInterface, endpoints *upstream.Endpoints) *ArgocdService {
+	return NewArgocdServiceWithClock(cfg, authSvc, endpoints, clock.Real)
+}
+
+// NewArgocdServiceWithClock creates an ArgoCD service like NewArgocdService whose caches
+// and timestamps follow clk
+func NewArgocdServiceWithClock(cfg *config.Config, authSvc types.AuthServiceInterface, endpoints *upstream.Endpoints, clk clock.Clock) *ArgocdService {
+	httpClient := upstream.NewClient(cfg)
+	var redisClient redis.UniversalClient
+	if cfg.CacheBackend == config.CacheBackendRedis {
+		redisClient = redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, Password: cfg.RedisPassword})
 	}
-	metrics.ArgocdAPIRequestsTotal.WithLabelValues(endpoint, status).Inc()
+	return &ArgocdService{
+		config:              cfg,
+		authService:         authSvc,
+		httpClient:          httpClient,
+		client:              upstream.New(cfg, authSvc, httpClient, endpoints),
+		pool:                workpool.New("upstream", cfg.UpstreamConcurrency),
+		appCircuits:         newAppCircuits(cfg, clk),
+		stateAges:           newStateAges(),
+		projectsCache:       newStore[types.ArgocdProjectList](cfg, redisClient, "projects", clk),
+		applicationsCache:   newStore[types.ArgocdApplicationList](cfg, redisClient, "applications", clk),
+		clustersCache:       cache.NewWithClock[types.ArgocdClusterList](cfg.CacheTTL, clk),
+		redisClient:         redisClient,
+		applicationsChanged: make(chan struct{}),
+		snapshots:           newSnapshotHistory(cfg.SnapshotHistoryDepth),
+		lastFetch:           make(map[string]time.Time),
+		healthHistory:       NewHealthHistory(cfg.HealthHistorySize),
+		shadow:              newShadow(cfg),
+		clock:               clk,
+	}
+}
 
-	return resp, err
+// Close releases the connections of the shared cache backend. The service must not be
+// used afterwards.
+func (s *ArgocdService) Close() error {
+	if s.redisClient == nil {
+		return nil
+	}
+	return s.redisClient.Close()
 }
 
 // GetProjects retrieves all projects from ArgoCD
 func (s *ArgocdService) GetProjects(ctx context.Context) ([]types.ArgocdProject, error) {
 	if cached, ok := s.projectsCache.Get(); ok {
 		metrics.CacheHitsTotal.WithLabelValues("projects").Inc()
-		return cached, nil
+		provenance.Record(ctx, provenance.Hit, s.projectsCache.Age())
+		return cached.Items, nil
 	}
 	metrics.CacheMissesTotal.WithLabelValues("projects").Inc()
 
-	url := fmt.Sprintf("%s/projects", s.config.ArgocdAPIURL)
+	// While ArgoCD calls are suppressed an expired list is served as is
+	if stale, ok := s.projectsCache.GetStale(); ok && upstream.Suppressed(ctx) {
+		provenance.Record(ctx, provenance.Stale, s.projectsCache.Age())
+		return stale.Items, nil
+	}
 
-	req, err := s.authService.CreateAuthenticatedRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create authenticated request: %w", err)
+	// Revalidate an expired list cheaply before re-downloading it
+	if stale, ok := s.projectsCache.GetStale(); ok && s.resourceVersionUnchanged(ctx, "/projects", stale.Metadata.ResourceVersion) {
+		provenance.Record(ctx, provenance.Stale, s.projectsCache.Age())
+		s.projectsCache.Touch()
+		metrics.CacheRefreshSkippedTotal.WithLabelValues("projects").Inc()
+		s.recordFetchSuccess(ResourceProjects)
+		return stale.Items, nil
 	}
 
-	resp, err := s.doInstrumented(req, "/projects")
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request to ArgoCD: %w", err)
+	var projectList types.ArgocdProjectList
+	if err := s.client.GetJSON(ctx, "/projects", &projectList, upstream.StrictDecode()); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ArgoCD API returned status %d: %s", resp.StatusCode, string(body))
+	s.projectsCache.Set(projectList)
+	s.recordFetchSuccess(ResourceProjects)
+	provenance.Record(ctx, s.fetchSource(), 0)
+	return projectList.Items, nil
+}
+
+// GetProject retrieves a single project from the cached project list.
+// Projects filtered by the ignore rules are reported as not found.
+func (s *ArgocdService) GetProject(ctx context.Context, name string) (types.ArgocdProject, error) {
+	projects, err := s.GetProjects(ctx)
+	if err != nil {
+		return types.ArgocdProject{}, err
 	}
 
-	var projectList types.ArgocdProjectList
-	if err := json.NewDecoder(resp.Body).Decode(&projectList); err != nil {
-		return nil, fmt.Errorf("failed to decode projects response: %w", err)
+	for _, project := range projects {
+		if project.Metadata.Name != name {
+			continue
+		}
+		if s.filtered(s.config.SnapshotFor(ctx), name, "project "+strconv.Quote(name)) {
+			break
+		}
+		return project, nil
 	}
 
-	s.projectsCache.Set(projectList.Items)
-	return projectList.Items, nil
+	return types.ArgocdProject{}, fmt.Errorf("project '%s' %w", name, ErrNotFound)
 }
 
 // GetFilteredProjects retrieves projects from ArgoCD with filtering applied
@@ -96,12 +220,15 @@ func (s *ArgocdService) GetFilteredProjects(ctx context.Context) ([]types.Argocd
 		return nil, err
 	}
 
+	stopFilter := timing.Start(ctx, timing.PhaseFilter)
+	filters := s.config.SnapshotFor(ctx)
 	var filteredProjects []types.ArgocdProject
 	for _, project := range projects {
-		if !s.config.ShouldFilterProject(project.Metadata.Name) {
+		if !s.filtered(filters, project.Metadata.Name, "project "+strconv.Quote(project.Metadata.Name)) {
 			filteredProjects = append(filteredProjects, project)
 		}
 	}
+	stopFilter()
 
 	return filteredProjects, nil
 }
@@ -110,154 +237,489 @@ func (s *ArgocdService) GetFilteredProjects(ctx context.Context) ([]types.Argocd
 func (s *ArgocdService) GetApplications(ctx context.Context) (types.ArgocdApplicationList, error) {
 	if cached, ok := s.applicationsCache.Get(); ok {
 		metrics.CacheHitsTotal.WithLabelValues("applications").Inc()
+		provenance.Record(ctx, provenance.Hit, s.applicationsCache.Age())
 		return cached, nil
 	}
 	metrics.CacheMissesTotal.WithLabelValues("applications").Inc()
 
-	url := fmt.Sprintf("%s/applications", s.config.ArgocdAPIURL)
+	return s.RefreshApplications(ctx)
+}
 
-	req, err := s.authService.CreateAuthenticatedRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return types.ArgocdApplicationList{}, fmt.Errorf("failed to create authenticated request: %w", err)
-	}
+// RefreshApplications fetches the application set from ArgoCD regardless of whether the
+// cached copy has expired, storing the result and notifying watchers when it changed.
+// A previously cached list is revalidated by resourceVersion before being re-downloaded.
+func (s *ArgocdService) RefreshApplications(ctx context.Context) (types.ArgocdApplicationList, error) {
+	generation := s.cacheGeneration()
 
-	resp, err := s.doInstrumented(req, "/applications")
-	if err != nil {
-		return types.ArgocdApplicationList{}, fmt.Errorf("failed to execute request to ArgoCD: %w", err)
+	// While ArgoCD calls are suppressed an expired list is served as is
+	if stale, ok := s.applicationsCache.GetStale(); ok && upstream.Suppressed(ctx) {
+		provenance.Record(ctx, provenance.Stale, s.applicationsCache.Age())
+		return stale, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return types.ArgocdApplicationList{}, fmt.Errorf("ArgoCD API returned status %d: %s", resp.StatusCode, string(body))
+	// Revalidate an expired list cheaply before re-downloading it
+	if stale, ok := s.applicationsCache.GetStale(); ok && s.resourceVersionUnchanged(ctx, "/applications", stale.Metadata.ResourceVersion) {
+		provenance.Record(ctx, provenance.Stale, s.applicationsCache.Age())
+		s.applicationsCache.Touch()
+		metrics.CacheRefreshSkippedTotal.WithLabelValues("applications").Inc()
+		s.recordFetchSuccess(ResourceApplications)
+		return stale, nil
 	}
 
 	var appList types.ArgocdApplicationList
-	if err := json.NewDecoder(resp.Body).Decode(&appList); err != nil {
-		return types.ArgocdApplicationList{}, fmt.Errorf("failed to decode applications response: %w", err)
+	if err := s.client.GetJSON(ctx, "/applications", &appList, upstream.StrictDecode()); err != nil {
+		return types.ArgocdApplicationList{}, err
 	}
 
-	// Filter applications based on ignored projects
+	clusterNames := s.clusterNames(ctx)
+
+	// Filter applications based on ignored projects. The list is cached for every caller,
+	// so it is filtered by the rules in effect rather than those of the triggering request.
+	stopFilter := timing.Start(ctx, timing.PhaseFilter)
+	filters := s.config.Snapshot()
 	var filteredApps []types.ArgocdApplication
 	for _, app := range appList.Items {
-		if !s.config.ShouldFilterProject(app.Spec.Project) {
-			// Get ingress URLs for this application
-			s.extractURLsFromApplication(&app)
+		if !s.filtered(filters, app.Spec.Project, "application "+strconv.Quote(app.Metadata.Name)) {
+			s.enrichApplication(&app, clusterNames)
 			filteredApps = append(filteredApps, app)
 		}
 	}
 
+	stopFilter()
+
 	// Update the application list with filtered results
 	appList.Items = filteredApps
 
-	s.applicationsCache.Set(appList)
+	s.recordFetchSuccess(ResourceApplications)
+	if s.storeApplications(appList, generation) {
+		s.publishApplications(appList)
+	}
+	provenance.Record(ctx, s.fetchSource(), 0)
 	return appList, nil
 }
 
-// GetApplication retrieves a specific application from ArgoCD
-func (s *ArgocdService) GetApplication(ctx context.Context, name string) (types.ArgocdApplication, error) {
-	url := fmt.Sprintf("%s/applications/%s", s.config.ArgocdAPIURL, name)
+// InvalidateCaches drops the cached project, application and cluster lists, so the next
+// read fetches them again. Application lists are filtered by the ignore rules when they are
+// fetched, so this must be called after the rules change; lists still being fetched then
+// are not cached.
+func (s *ArgocdService) InvalidateCaches() {
+	s.invalidateMu.Lock()
+	s.generation++
+	s.projectsCache.Invalidate()
+	s.applicationsCache.Invalidate()
+	s.clustersCache.Invalidate()
+	s.invalidateMu.Unlock()
+	events.Record(events.CacheInvalidated, "Cached project, application and cluster lists dropped", nil)
+}
+
+// cacheGeneration returns the number of cache invalidations so far
+func (s *ArgocdService) cacheGeneration() uint64 {
+	s.invalidateMu.Lock()
+	defer s.invalidateMu.Unlock()
+	return s.generation
+}
+
+// storeApplications caches list unless the caches were invalidated since generation, as
+// the list may then have been filtered by replaced rules. It reports whether list was stored.
+func (s *ArgocdService) storeApplications(list types.ArgocdApplicationList, generation uint64) bool {
+	s.invalidateMu.Lock()
+	defer s.invalidateMu.Unlock()
+	if s.generation != generation {
+		return false
+	}
+	s.applicationsCache.Set(list)
+	return true
+}
+
+// filtered reports whether the ignore rules of filters hide a project, logging the ignore
+// pattern that hides subject in debug mode so that missing resources can be traced
+func (s *ArgocdService) filtered(filters *config.FilterSnapshot, project, subject string) bool {
+	pattern, filtered := filters.FilteringPattern(project)
+	if filtered && s.config.Debug {
+		log.Printf("DEBUG: Filtered %s: project %q matches ignore pattern %q", subject, project, pattern)
+	}
+	return filtered
+}
 
-	req, err := s.authService.CreateAuthenticatedRequest(ctx, "GET", url, nil)
+// fetchSource is the provenance of a list fetched from ArgoCD: a cache miss, or a
+// bypass when caching is disabled
+func (s *ArgocdService) fetchSource() provenance.Source {
+	if s.config.CacheTTL <= 0 {
+		return provenance.Bypass
+	}
+	return provenance.Miss
+}
+
+// resourceVersionUnchanged asks ArgoCD for only the list resourceVersion of endpoint and
+// reports whether it still equals version. Any failure reports false so that callers
+// fall back to a full fetch.
+func (s *ArgocdService) resourceVersionUnchanged(ctx context.Context, endpoint, version string) bool {
+	if version == "" {
+		return false
+	}
+
+	var list struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	err := s.client.GetJSON(ctx, endpoint, &list,
+		upstream.Query("fields", "metadata.resourceVersion"),
+		upstream.Endpoint(endpoint+"?fields=metadata.resourceVersion"))
 	if err != nil {
-		return types.ArgocdApplication{}, fmt.Errorf("failed to create authenticated request: %w", err)
+		return false
 	}
 
-	resp, err := s.doInstrumented(req, "/applications/:name")
+	return list.Metadata.ResourceVersion == version
+}
+
+// ApplicationsChanged returns a channel that is closed the next time a refresh
+// of the application set produces a different hash
+func (s *ArgocdService) ApplicationsChanged() <-chan struct{} {
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+	return s.applicationsChanged
+}
+
+// publishApplications records the hash of a freshly fetched application set and
+// wakes up watchers when it differs from the previous one
+func (s *ArgocdService) publishApplications(appList types.ArgocdApplicationList) {
+	s.publishApplicationsHash(ApplicationsHash(appList), appList)
+}
+
+// publishApplicationsHash is publishApplications for a list whose hash is known. A new
+// set is also recorded in the snapshot history.
+func (s *ArgocdService) publishApplicationsHash(hash string, appList types.ArgocdApplicationList) {
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+
+	if hash == s.applicationsHash {
+		return
+	}
+	s.applicationsHash = hash
+	s.snapshots.record(hash, appList)
+	close(s.applicationsChanged)
+	s.applicationsChanged = make(chan struct{})
+}
+
+// newStore creates the cache called name: in Redis when a client is given, in memory otherwise
+func newStore[T any](cfg *config.Config, redisClient redis.UniversalClient, name string, clk clock.Clock) cache.Store[T] {
+	if redisClient != nil {
+		return cache.NewRedisStore[T](redisClient, cfg.RedisKeyPrefix, name, cfg.CacheTTL, cfg.RedisStaleWindow, clk)
+	}
+	return cache.NewWithClock[T](cfg.CacheTTL, clk)
+}
+
+// cachedApplicationProject returns the project of the named application in the cached
+// application list, even an expired one, or "" when it is not cached. It is only a hint
+// for picking a project-scoped token before the application itself is fetched.
+func (s *ArgocdService) cachedApplicationProject(name string) string {
+	list, ok := s.applicationsCache.GetStale()
+	if !ok {
+		return ""
+	}
+	for _, app := range list.Items {
+		if app.Metadata.Name == name {
+			return app.Spec.Project
+		}
+	}
+	return ""
+}
+
+// ApplicationsHash returns a stable content hash of an application list, used
+// by watchers to detect changes and to name snapshots
+func ApplicationsHash(appList types.ArgocdApplicationList) string {
+	hash, err := canonical.Hash(appList.Items)
 	if err != nil {
-		return types.ArgocdApplication{}, fmt.Errorf("failed to execute request to ArgoCD: %w", err)
+		return ""
 	}
-	defer resp.Body.Close()
+	return hash[:16]
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return types.ArgocdApplication{}, fmt.Errorf("application '%s' not found", name)
+// GetApplication retrieves a specific application from ArgoCD
+func (s *ArgocdService) GetApplication(ctx context.Context, name string) (types.ArgocdApplication, error) {
+	if upstream.Suppressed(ctx) {
+		return s.cachedApplication(ctx, name)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return types.ArgocdApplication{}, fmt.Errorf("ArgoCD API returned status %d: %s", resp.StatusCode, string(body))
+	if err := s.allowApplicationRead(name); err != nil {
+		return types.ArgocdApplication{}, err
 	}
 
 	var app types.ArgocdApplication
-	if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
-		return types.ArgocdApplication{}, fmt.Errorf("failed to decode application response: %w", err)
+	err := s.client.GetJSON(ctx, "/applications/"+name, &app,
+		upstream.Project(s.cachedApplicationProject(name)),
+		upstream.Endpoint("/applications/:name"),
+		upstream.NotFound(fmt.Errorf("application '%s' %w", name, ErrNotFound)))
+	s.recordApplicationRead(ctx, name, err)
+	if err != nil {
+		return types.ArgocdApplication{}, err
 	}
 
 	// Check if the application's project should be filtered
-	if s.config.ShouldFilterProject(app.Spec.Project) {
-		return types.ArgocdApplication{}, fmt.Errorf("application '%s' belongs to filtered project '%s'", name, app.Spec.Project)
+	if s.filtered(s.config.SnapshotFor(ctx), app.Spec.Project, "application "+strconv.Quote(name)) {
+		return types.ArgocdApplication{}, fmt.Errorf("application '%s' belongs to filtered project '%s': %w", name, app.Spec.Project, ErrFiltered)
 	}
 
-	// Get ingress URLs for this application
-	s.extractURLsFromApplication(&app)
+	s.enrichApplication(&app, s.clusterNames(ctx))
 
+	// Single applications are always read from ArgoCD
+	provenance.Record(ctx, provenance.Bypass, 0)
 	return app, nil
 }
 
-// ProxyRequest proxies a generic request to ArgoCD with authentication
-func (s *ArgocdService) ProxyRequest(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
-	url := fmt.Sprintf("%s%s", s.config.ArgocdAPIURL, path)
+// cachedApplication returns the named application from the cached application list, even
+// an expired one, for reads made while ArgoCD calls are suppressed
+func (s *ArgocdService) cachedApplication(ctx context.Context, name string) (types.ArgocdApplication, error) {
+	list, ok := s.applicationsCache.GetStale()
+	if ok {
+		for _, app := range list.Items {
+			if app.Metadata.Name == name {
+				provenance.Record(ctx, provenance.Stale, s.applicationsCache.Age())
+				return app, nil
+			}
+		}
+	}
+	return types.ArgocdApplication{}, fmt.Errorf("%w: application '%s' is not cached", ErrSuppressed, name)
+}
 
-	req, err := s.authService.CreateAuthenticatedRequest(ctx, method, url, body)
+// GetApplicationRaw retrieves an application from ArgoCD as its upstream JSON, including
+// fields the typed structs drop. Only spec.project is decoded, for the project filter;
+// ingress URLs and the other derived fields are not added.
+func (s *ArgocdService) GetApplicationRaw(ctx context.Context, name string) (json.RawMessage, error) {
+	if err := s.allowApplicationRead(name); err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	err := s.client.GetJSON(ctx, "/applications/"+name, &raw,
+		upstream.Project(s.cachedApplicationProject(name)),
+		upstream.Endpoint("/applications/:name"),
+		upstream.NotFound(fmt.Errorf("application '%s' %w", name, ErrNotFound)))
+	s.recordApplicationRead(ctx, name, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create authenticated request: %w", err)
+		return nil, err
 	}
 
-	resp, err := s.doInstrumented(req, "/proxy")
+	var app struct {
+		Spec struct {
+			Project string `json:"project"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &app); err != nil {
+		return nil, fmt.Errorf("failed to decode /applications/:name response: %w", err)
+	}
+	if s.filtered(s.config.SnapshotFor(ctx), app.Spec.Project, "application "+strconv.Quote(name)) {
+		return nil, fmt.Errorf("application '%s' belongs to filtered project '%s': %w", name, app.Spec.Project, ErrFiltered)
+	}
+
+	provenance.Record(ctx, provenance.Bypass, 0)
+	return raw, nil
+}
+
+// GetApplicationSyncWindows retrieves the sync windows affecting an application.
+// Applications in filtered projects are reported as not found.
+func (s *ArgocdService) GetApplicationSyncWindows(ctx context.Context, name string) (types.ApplicationSyncWindows, error) {
+	// Resolve the application first so that filtered projects are never exposed
+	app, err := s.GetApplication(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request to ArgoCD: %w", err)
+		return types.ApplicationSyncWindows{}, err
 	}
 
-	return resp, nil
+	var windows types.ArgocdApplicationSyncWindowsResponse
+	err = s.client.GetJSON(ctx, "/applications/"+name+"/syncwindows", &windows,
+		upstream.Project(app.Spec.Project),
+		upstream.Endpoint("/applications/:name/syncwindows"),
+		upstream.NotFound(fmt.Errorf("application '%s' %w", name, ErrNotFound)))
+	if err != nil {
+		return types.ApplicationSyncWindows{}, err
+	}
+
+	return BuildApplicationSyncWindows(name, windows), nil
+}
+
+// BuildApplicationSyncWindows splits the active windows of an upstream sync windows
+// response into allows and denies. Slices are never nil so they render as [].
+func BuildApplicationSyncWindows(name string, windows types.ArgocdApplicationSyncWindowsResponse) types.ApplicationSyncWindows {
+	result := types.ApplicationSyncWindows{
+		Application:     name,
+		CanSync:         windows.CanSync,
+		ActiveAllows:    []types.ArgocdSyncWindow{},
+		ActiveDenies:    []types.ArgocdSyncWindow{},
+		AssignedWindows: []types.ArgocdSyncWindow{},
+	}
+
+	result.AssignedWindows = append(result.AssignedWindows, windows.AssignedWindows...)
+	for _, window := range windows.ActiveWindows {
+		switch window.Kind {
+		case "allow":
+			result.ActiveAllows = append(result.ActiveAllows, window)
+		case "deny":
+			result.ActiveDenies = append(result.ActiveDenies, window)
+		}
+	}
+
+	return result
+}
+
+// ProxyRequest proxies a generic request to ArgoCD with authentication
+func (s *ArgocdService) ProxyRequest(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	return s.client.Do(ctx, method, path, body, upstream.Endpoint("/proxy"))
 }
 
-// GetProjectNames retrieves only the names of all projects (for grouping purposes)
+// GetProjectNames retrieves the sorted, deduplicated names of all projects (for grouping
+// purposes). Duplicate names indicate an upstream problem, such as HA replicas returning
+// overlapping lists, and are logged and counted before being dropped.
 func (s *ArgocdService) GetProjectNames(ctx context.Context) ([]string, error) {
 	projects, err := s.GetProjects(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var projectNames []string
+	seen := make(map[string]bool, len(projects))
+	projectNames := make([]string, 0, len(projects))
+	var duplicates []string
 	for _, project := range projects {
-		projectNames = append(projectNames, project.Metadata.Name)
+		name := project.Metadata.Name
+		if seen[name] {
+			duplicates = append(duplicates, name)
+			continue
+		}
+		seen[name] = true
+		projectNames = append(projectNames, name)
+	}
+	sort.Strings(projectNames)
+
+	if len(duplicates) > 0 {
+		metrics.DuplicateProjectsTotal.Add(float64(len(duplicates)))
+		log.Printf("WARNING: ArgoCD returned %d duplicate project entries: %s", len(duplicates), strings.Join(duplicates, ", "))
 	}
 
 	return projectNames, nil
 }
 
-// HealthCheck performs a health check by attempting to get projects
+// HealthCheck performs a health check by attempting to get projects and records
+// the outcome in the health history
 func (s *ArgocdService) HealthCheck(ctx context.Context) error {
+	start := s.clock.Now()
+	err := s.checkHealth(ctx)
+
+	record := types.HealthCheckRecord{
+		Timestamp: start,
+		Status:    HealthStatusHealthy,
+		LatencyMs: float64(s.clock.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		record.Status = HealthStatusDegraded
+		record.Error = redact.String(err.Error())
+	}
+	s.healthHistory.Record(record)
+
+	return err
+}
+
+// GetHealthHistory returns the most recent health checks with uptime aggregates
+func (s *ArgocdService) GetHealthHistory() types.HealthHistoryResponse {
+	return s.healthHistory.Snapshot()
+}
+
+// checkHealth verifies the token and ArgoCD API connectivity
+func (s *ArgocdService) checkHealth(ctx context.Context) error {
 	// Try to get a valid token first
 	_, err := s.authService.GetValidToken(ctx)
 	if err != nil {
 		return fmt.Errorf("token validation failed: %w", err)
 	}
 
-	// Try to make a simple API call to verify connectivity
-	url := fmt.Sprintf("%s/projects", s.config.ArgocdAPIURL)
-	req, err := s.authService.CreateAuthenticatedRequest(ctx, "GET", url, nil)
+	// Try to make a simple API call to verify connectivity, with a shorter timeout
+	err = s.client.GetJSON(ctx, "/projects", nil, upstream.Endpoint("/healthcheck"), upstream.Timeout(5*time.Second))
+	var statusErr *upstream.StatusError
+	if errors.As(err, &statusErr) {
+		return fmt.Errorf("health check failed with status %d", statusErr.StatusCode)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+		return fmt.Errorf("health check request failed: %w", err)
 	}
 
-	// Use a shorter timeout for health checks
-	healthCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	s.recordFetchSuccess(ResourceHealth)
+	return nil
+}
 
-	req = req.WithContext(healthCtx)
+// recordFetchSuccess stores the time of a successful upstream fetch and exports it as a gauge
+func (s *ArgocdService) recordFetchSuccess(resource string) {
+	now := s.clock.Now()
 
-	resp, err := s.doInstrumented(req, "/healthcheck")
-	if err != nil {
-		return fmt.Errorf("health check request failed: %w", err)
+	s.fetchMu.Lock()
+	s.lastFetch[resource] = now
+	s.fetchMu.Unlock()
+
+	metrics.LastSuccessfulFetch.WithLabelValues(resource).Set(float64(now.Unix()))
+}
+
+// GetUpstreamStatus returns the last successful fetch time of each upstream resource
+func (s *ArgocdService) GetUpstreamStatus() types.UpstreamStatus {
+	s.fetchMu.RLock()
+	defer s.fetchMu.RUnlock()
+
+	lastFetch := func(resource string) *time.Time {
+		t, ok := s.lastFetch[resource]
+		if !ok {
+			return nil
+		}
+		return &t
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("health check failed with status %d", resp.StatusCode)
+	return types.UpstreamStatus{
+		Projects:     lastFetch(ResourceProjects),
+		Applications: lastFetch(ResourceApplications),
+		Health:       lastFetch(ResourceHealth),
 	}
+}
 
-	return nil
+// LastUpstreamError returns the latest failed ArgoCD call, or nil when none failed
+func (s *ArgocdService) LastUpstreamError() *types.UpstreamError {
+	return s.client.LastError()
+}
+
+// CacheStats describes the cached project, application and cluster lists. It reads the
+// caches only and never calls ArgoCD.
+func (s *ArgocdService) CacheStats() []types.CacheStatus {
+	backend := config.CacheBackendMemory
+	if s.config.CacheBackend == config.CacheBackendRedis {
+		backend = config.CacheBackendRedis
+	}
+	return []types.CacheStatus{
+		cacheStatus(s, "projects", backend, s.projectsCache, func(l types.ArgocdProjectList) int { return len(l.Items) }),
+		cacheStatus(s, "applications", backend, s.applicationsCache, func(l types.ArgocdApplicationList) int { return len(l.Items) }),
+		cacheStatus(s, "clusters", config.CacheBackendMemory, s.clustersCache, func(l types.ArgocdClusterList) int { return len(l.Items) }),
+	}
+}
+
+// cacheStatus describes store, counting the entries of its list with items
+func cacheStatus[T any](s *ArgocdService, name, backend string, store cache.Store[T], items func(T) int) types.CacheStatus {
+	status := types.CacheStatus{Name: name, Backend: backend, TTL: s.config.CacheTTL.String()}
+	value, ok := store.GetStale()
+	if !ok {
+		return status
+	}
+	status.Populated = true
+	status.Items = items(value)
+	if cachedAt, ok := store.CachedAt(); ok {
+		age := s.clock.Since(cachedAt)
+		status.CachedAt = &cachedAt
+		status.AgeSeconds = age.Seconds()
+		status.Fresh = age <= s.config.CacheTTL
+	}
+	return status
+}
+
+// enrichApplication fills in the fields the proxy adds to applications read from ArgoCD:
+// ingress URLs, deletion state, destination cluster name and ownership
+func (s *ArgocdService) enrichApplication(app *types.ArgocdApplication, clusterNames map[string]string) {
+	s.extractURLsFromApplication(app)
+	app.Deleting = IsDeleting(*app)
+	SetClusterName(app, clusterNames)
+	SetOwnership(app, s.config.OwnerAnnotations)
 }
 
 // extractURLsFromApplication extracts external URLs from an application's status summary
@@ -286,43 +748,56 @@ func (s *ArgocdService) ExtractIngressURLs(ctx context.Context, appName string)
 	return app.IngressURLs, nil
 }
 
-// GetApplicationsByGroup retrieves applications from a specific project group
-func (s *ArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string, cfg interface{}) (types.ArgocdApplicationList, error) {
-	// Type assert the config to access project groups
-	configObj, ok := cfg.(*config.Config)
+// GetApplicationsByGroup retrieves applications from a specific project group.
+// It returns an error wrapping ErrGroupNotFound when the group is not configured.
+func (s *ArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string) (types.ArgocdApplicationList, error) {
+	// Find the specified group, ignoring case and surrounding whitespace
+	targetGroup, ok := s.config.SnapshotFor(ctx).FindProjectGroup(groupName)
 	if !ok {
-		return types.ArgocdApplicationList{}, fmt.Errorf("invalid config type")
-	}
-
-	// Find the specified group
-	var targetGroup *config.ProjectGroup
-	for i, group := range configObj.ProjectGroups {
-		if group.Name == groupName {
-			targetGroup = &configObj.ProjectGroups[i]
-			break
-		}
+		return types.ArgocdApplicationList{}, fmt.Errorf("%w: '%s'", ErrGroupNotFound, strings.TrimSpace(groupName))
 	}
 
-	if targetGroup == nil {
-		return types.ArgocdApplicationList{}, fmt.Errorf("project group '%s' not found", groupName)
+	// Reads served from the cache while ArgoCD calls are suppressed are not shadowed, as
+	// the candidate would call ArgoCD
+	runner := s.shadow
+	if upstream.Suppressed(ctx) {
+		runner = nil
 	}
+	return shadow.Compare(ctx, runner, "GetApplicationsByGroup",
+		func(ctx context.Context) (types.ArgocdApplicationList, error) {
+			return s.applicationsByGroup(ctx, targetGroup)
+		},
+		func(ctx context.Context) (types.ArgocdApplicationList, error) {
+			return s.applicationsByGroupServerSide(ctx, targetGroup)
+		},
+		applicationKeys)
+}
 
+// applicationsByGroup filters the cached application list down to the projects of group
+func (s *ArgocdService) applicationsByGroup(ctx context.Context, targetGroup *config.ProjectGroup) (types.ArgocdApplicationList, error) {
 	// Get all applications
 	allApplications, err := s.GetApplications(ctx)
 	if err != nil {
 		return types.ArgocdApplicationList{}, fmt.Errorf("failed to get applications: %w", err)
 	}
 
-	// Filter applications that belong to projects in this group
+	// Filter applications that belong to projects in this group and are not hidden by
+	// the group's own ignore patterns
+	stopFilter := timing.Start(ctx, timing.PhaseFilter)
 	var filteredApps []types.ArgocdApplication
 	for _, app := range allApplications.Items {
-		for _, projectName := range targetGroup.Projects {
-			if app.Spec.Project == projectName {
-				filteredApps = append(filteredApps, app)
-				break
+		if !slices.Contains(targetGroup.Projects, app.Spec.Project) {
+			continue
+		}
+		if pattern, ignored := targetGroup.MatchApplicationIgnore(app.Metadata.Name, app.Spec.Project); ignored {
+			if s.config.Debug {
+				log.Printf("DEBUG: Application %q hidden from group %q by its ignore pattern %q", app.Metadata.Name, targetGroup.Name, pattern)
 			}
+			continue
 		}
+		filteredApps = append(filteredApps, app)
 	}
+	stopFilter()
 
 	// Return filtered applications in the same format
 	return types.ArgocdApplicationList{
@@ -342,12 +817,14 @@ func (s *ArgocdService) GetApplicationsByProject(ctx context.Context, projectNam
 	}
 
 	// Filter applications that belong to the specified project
+	stopFilter := timing.Start(ctx, timing.PhaseFilter)
 	var filteredApps []types.ArgocdApplication
 	for _, app := range allApplications.Items {
 		if app.Spec.Project == projectName {
 			filteredApps = append(filteredApps, app)
 		}
 	}
+	stopFilter()
 
 	// Return filtered applications in the same format
 	return types.ArgocdApplicationList{
@@ -3,31 +3,60 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/auth"
 	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/redact"
+	"argocd-proxy/testutils"
 	"argocd-proxy/types"
+	"argocd-proxy/upstream"
 )
 
 // MockAuthService implements a mock auth service for testing
 type MockAuthService struct {
-	token   string
-	err     error
+	token string
+	err   error
+
+	// mu guards callLog, as calls are made concurrently, e.g. by shadow comparisons
+	mu      sync.Mutex
 	callLog []string
 }
 
+// record appends call to the call log
+func (m *MockAuthService) record(call string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callLog = append(m.callLog, call)
+}
+
+// calls returns a copy of the call log
+func (m *MockAuthService) calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.callLog...)
+}
+
 func (m *MockAuthService) GetValidToken(ctx context.Context) (string, error) {
-	m.callLog = append(m.callLog, "GetValidToken")
+	m.record("GetValidToken")
 	return m.token, m.err
 }
 
-func (m *MockAuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
-	m.callLog = append(m.callLog, fmt.Sprintf("CreateAuthenticatedRequest-%s", method))
+func (m *MockAuthService) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error) {
+	m.record(fmt.Sprintf("CreateAuthenticatedRequest-%s", method))
 
 	if m.err != nil {
 		return nil, m.err
@@ -45,15 +74,15 @@ func (m *MockAuthService) CreateAuthenticatedRequest(ctx context.Context, method
 }
 
 func (m *MockAuthService) GetTokenStatus() map[string]interface{} {
-	m.callLog = append(m.callLog, "GetTokenStatus")
+	m.record("GetTokenStatus")
 	return map[string]interface{}{
 		"hasToken": m.token != "",
 		"isValid":  m.err == nil,
 	}
 }
 
-func (m *MockAuthService) StartTokenRefreshRoutine(ctx context.Context) {
-	m.callLog = append(m.callLog, "StartTokenRefreshRoutine")
+func (m *MockAuthService) RunTokenRefreshRoutine(ctx context.Context) {
+	m.record("RunTokenRefreshRoutine")
 	// Mock implementation - do nothing
 }
 
@@ -179,7 +208,7 @@ func (m *MockArgocdService) ExtractIngressURLs(ctx context.Context, appName stri
 	return m.ingressURLs, nil
 }
 
-func (m *MockArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string, cfg interface{}) (types.ArgocdApplicationList, error) {
+func (m *MockArgocdService) GetApplicationsByGroup(ctx context.Context, groupName string) (types.ArgocdApplicationList, error) {
 	if m.err != nil {
 		return types.ArgocdApplicationList{}, m.err
 	}
@@ -214,7 +243,7 @@ func TestNewArgocdService(t *testing.T) {
 	}
 	authSvc := &MockAuthService{token: "test-token"}
 
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 
 	if service.config != cfg {
 		t.Errorf("NewArgocdService() config not set correctly")
@@ -225,8 +254,19 @@ func TestNewArgocdService(t *testing.T) {
 	if service.httpClient == nil {
 		t.Errorf("NewArgocdService() httpClient not initialized")
 	}
-	if service.httpClient.Timeout != 10*time.Second {
-		t.Errorf("NewArgocdService() httpClient timeout = %v, want %v", service.httpClient.Timeout, 10*time.Second)
+	if service.httpClient.Timeout != 0 {
+		t.Errorf("NewArgocdService() httpClient timeout = %v, want none so that calls follow their context", service.httpClient.Timeout)
+	}
+	if upstream.BaseTransport(service.httpClient.Transport) != upstream.Default() {
+		t.Errorf("NewArgocdService() httpClient does not use the shared upstream transport")
+	}
+	if service.client.Endpoints() == nil || service.client.Endpoints().Active() != cfg.ArgocdAPIURL {
+		t.Errorf("NewArgocdService() with nil endpoints does not call ARGOCD_API_URL")
+	}
+
+	endpoints := upstream.NewEndpointsFor(cfg)
+	if NewArgocdService(cfg, authSvc, endpoints).client.Endpoints() != endpoints {
+		t.Errorf("NewArgocdService() does not call ArgoCD at the endpoints it was given")
 	}
 }
 
@@ -298,7 +338,7 @@ func TestGetProjects(t *testing.T) {
 
 			cfg := &config.Config{ArgocdAPIURL: server.URL}
 			authSvc := &MockAuthService{token: "test-token"}
-			service := NewArgocdService(cfg, authSvc)
+			service := NewArgocdService(cfg, authSvc, nil)
 
 			ctx := context.Background()
 			projects, err := service.GetProjects(ctx)
@@ -342,7 +382,7 @@ func TestGetFilteredProjects(t *testing.T) {
 		IgnoredProjects: []string{"test-*", "*-app"},
 	}
 	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 
 	ctx := context.Background()
 	projects, err := service.GetFilteredProjects(ctx)
@@ -428,7 +468,7 @@ func TestGetApplications(t *testing.T) {
 				IgnoredProjects: tt.ignoredProjects,
 			}
 			authSvc := &MockAuthService{token: "test-token"}
-			service := NewArgocdService(cfg, authSvc)
+			service := NewArgocdService(cfg, authSvc, nil)
 
 			ctx := context.Background()
 			appList, err := service.GetApplications(ctx)
@@ -468,6 +508,7 @@ func TestGetApplication(t *testing.T) {
 		expectError     bool
 		expectedApp     string
 		errorContains   string
+		wantErr         error
 	}{
 		{
 			name:    "successful application retrieval",
@@ -502,6 +543,7 @@ func TestGetApplication(t *testing.T) {
 			},
 			expectError:   true,
 			errorContains: "filtered project",
+			wantErr:       ErrFiltered,
 		},
 		{
 			name:    "application not found",
@@ -512,6 +554,17 @@ func TestGetApplication(t *testing.T) {
 			},
 			expectError:   true,
 			errorContains: "not found",
+			wantErr:       ErrNotFound,
+		},
+		{
+			name:    "credentials rejected",
+			appName: "secret-app",
+			serverResponse: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("invalid session"))
+			},
+			expectError: true,
+			wantErr:     ErrUnauthorized,
 		},
 		{
 			name:    "ArgoCD API error",
@@ -534,7 +587,7 @@ func TestGetApplication(t *testing.T) {
 				IgnoredProjects: tt.ignoredProjects,
 			}
 			authSvc := &MockAuthService{token: "test-token"}
-			service := NewArgocdService(cfg, authSvc)
+			service := NewArgocdService(cfg, authSvc, nil)
 
 			ctx := context.Background()
 			app, err := service.GetApplication(ctx, tt.appName)
@@ -546,6 +599,9 @@ func TestGetApplication(t *testing.T) {
 				if tt.errorContains != "" && !strings.Contains(err.Error(), tt.errorContains) {
 					t.Errorf("GetApplication() error = %v, should contain %s", err, tt.errorContains)
 				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetApplication() error = %v, want errors.Is %v", err, tt.wantErr)
+				}
 				return
 			}
 
@@ -561,6 +617,64 @@ func TestGetApplication(t *testing.T) {
 	}
 }
 
+func TestGetApplicationRaw(t *testing.T) {
+	upstreamApp := `{"metadata":{"name":"my-app"},"spec":{"project":"production","pluginSettings":{"depth":3}},` +
+		`"status":{"futureField":{"nested":true}}}`
+
+	tests := []struct {
+		name            string
+		ignoredProjects []string
+		body            string
+		wantErr         error
+	}{
+		{name: "unknown fields are kept", body: upstreamApp},
+		{name: "filtered project", ignoredProjects: []string{"prod*"}, body: upstreamApp, wantErr: ErrFiltered},
+		{name: "not found", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.body == "" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL, IgnoredProjects: tt.ignoredProjects}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			raw, err := service.GetApplicationRaw(context.Background(), "my-app")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetApplicationRaw() error = %v, want errors.Is %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetApplicationRaw() unexpected error: %v", err)
+			}
+			if string(raw) != tt.body {
+				t.Errorf("GetApplicationRaw() = %s, want the upstream JSON verbatim", raw)
+			}
+
+			// The typed decode drops what the proxy does not model
+			app, err := service.GetApplication(context.Background(), "my-app")
+			if err != nil {
+				t.Fatalf("GetApplication() unexpected error: %v", err)
+			}
+			typed, _ := json.Marshal(app)
+			for _, field := range []string{"futureField", "pluginSettings"} {
+				if strings.Contains(string(typed), field) {
+					t.Errorf("typed application contains %q, want it dropped", field)
+				}
+			}
+		})
+	}
+}
+
 func TestProxyRequest(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -607,7 +721,7 @@ func TestProxyRequest(t *testing.T) {
 
 			cfg := &config.Config{ArgocdAPIURL: server.URL}
 			authSvc := &MockAuthService{token: "test-token"}
-			service := NewArgocdService(cfg, authSvc)
+			service := NewArgocdService(cfg, authSvc, nil)
 
 			ctx := context.Background()
 			resp, err := service.ProxyRequest(ctx, tt.method, tt.path, tt.body)
@@ -634,41 +748,59 @@ func TestProxyRequest(t *testing.T) {
 }
 
 func TestGetProjectNames(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		projectList := types.ArgocdProjectList{
-			Items: []types.ArgocdProject{
-				{Metadata: types.ArgocdProjectMetadata{Name: "project1"}},
-				{Metadata: types.ArgocdProjectMetadata{Name: "project2"}},
-				{Metadata: types.ArgocdProjectMetadata{Name: "project3"}},
-			},
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(projectList)
-	}))
-	defer server.Close()
-
-	cfg := &config.Config{ArgocdAPIURL: server.URL}
-	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
-
-	ctx := context.Background()
-	names, err := service.GetProjectNames(ctx)
-
-	if err != nil {
-		t.Errorf("GetProjectNames() error = %v", err)
-		return
+	tests := []struct {
+		name           string
+		projects       []string
+		wantNames      []string
+		wantDuplicates float64
+	}{
+		{
+			name:      "unique names are sorted",
+			projects:  []string{"project3", "project1", "project2"},
+			wantNames: []string{"project1", "project2", "project3"},
+		},
+		{
+			name:           "duplicates are dropped and counted",
+			projects:       []string{"web-app", "api-service", "web-app", "api-service", "web-app"},
+			wantNames:      []string{"api-service", "web-app"},
+			wantDuplicates: 3,
+		},
+		{
+			name:      "no projects",
+			wantNames: []string{},
+		},
 	}
 
-	expectedNames := []string{"project1", "project2", "project3"}
-	if len(names) != len(expectedNames) {
-		t.Errorf("GetProjectNames() count = %v, want %v", len(names), len(expectedNames))
-		return
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := metrics.Default()
+			metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+			defer metrics.SetDefault(original)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				projectList := types.ArgocdProjectList{}
+				for _, name := range tt.projects {
+					projectList.Items = append(projectList.Items, types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: name}})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(projectList)
+			}))
+			defer server.Close()
 
-	for i, expectedName := range expectedNames {
-		if names[i] != expectedName {
-			t.Errorf("GetProjectNames() name[%d] = %v, want %v", i, names[i], expectedName)
-		}
+			cfg := &config.Config{ArgocdAPIURL: server.URL}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			names, err := service.GetProjectNames(context.Background())
+			if err != nil {
+				t.Fatalf("GetProjectNames() error = %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("GetProjectNames() = %v, want %v", names, tt.wantNames)
+			}
+			if got := testutil.ToFloat64(metrics.DuplicateProjectsTotal); got != tt.wantDuplicates {
+				t.Errorf("argocd_proxy_duplicate_projects_total = %v, want %v", got, tt.wantDuplicates)
+			}
+		})
 	}
 }
 
@@ -722,7 +854,7 @@ func TestHealthCheck(t *testing.T) {
 				token: "test-token",
 				err:   tt.authError,
 			}
-			service := NewArgocdService(cfg, authSvc)
+			service := NewArgocdService(cfg, authSvc, nil)
 
 			ctx := context.Background()
 			err := service.HealthCheck(ctx)
@@ -755,7 +887,7 @@ func TestContextTimeout(t *testing.T) {
 
 	cfg := &config.Config{ArgocdAPIURL: server.URL}
 	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 
 	// Create context with very short timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
@@ -786,7 +918,7 @@ func TestAuthServiceIntegration(t *testing.T) {
 
 	cfg := &config.Config{ArgocdAPIURL: server.URL}
 	authSvc := &MockAuthService{token: "integration-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 
 	ctx := context.Background()
 
@@ -799,8 +931,8 @@ func TestAuthServiceIntegration(t *testing.T) {
 
 	// Verify auth service was called
 	expectedCalls := authCallCount // Each method calls CreateAuthenticatedRequest once
-	if len(authSvc.callLog) < expectedCalls {
-		t.Errorf("Expected at least %d auth service calls, got %d", expectedCalls, len(authSvc.callLog))
+	if calls := authSvc.calls(); len(calls) < expectedCalls {
+		t.Errorf("Expected at least %d auth service calls, got %d", expectedCalls, len(calls))
 	}
 }
 
@@ -822,7 +954,7 @@ func BenchmarkGetProjects(b *testing.B) {
 
 	cfg := &config.Config{ArgocdAPIURL: server.URL}
 	authSvc := &MockAuthService{token: "bench-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 	ctx := context.Background()
 
 	b.ResetTimer()
@@ -856,7 +988,7 @@ func BenchmarkGetApplicationsWithFiltering(b *testing.B) {
 		IgnoredProjects: []string{"test-*"},
 	}
 	authSvc := &MockAuthService{token: "bench-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 	ctx := context.Background()
 
 	b.ResetTimer()
@@ -884,7 +1016,7 @@ func TestGetProjectsCaching(t *testing.T) {
 		CacheTTL:     30 * time.Second,
 	}
 	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 	ctx := context.Background()
 
 	// First call should hit the server
@@ -936,7 +1068,7 @@ func TestGetApplicationsCaching(t *testing.T) {
 		CacheTTL:     30 * time.Second,
 	}
 	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 	ctx := context.Background()
 
 	// First call hits server
@@ -964,6 +1096,53 @@ func TestGetApplicationsCaching(t *testing.T) {
 	}
 }
 
+func TestRefreshApplicationsDropsListFromBeforeInvalidation(t *testing.T) {
+	var service *ArgocdService
+	var cfg *config.Config
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The ignore rules change while the first fetch is in flight
+		if calls.Add(1) == 1 {
+			if _, err := cfg.SetIgnoredPatterns([]string{"payments"}); err != nil {
+				t.Errorf("SetIgnoredPatterns() error: %v", err)
+			}
+			service.InvalidateCaches()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			{Metadata: types.ArgocdApplicationMetadata{Name: "checkout"}, Spec: types.ArgocdApplicationSpec{Project: "payments"}},
+			{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "default"}},
+		}})
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}
+	service = NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	// The request took its snapshot before the rules changed
+	ctx := config.WithSnapshot(context.Background(), cfg.Snapshot())
+
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() error: %v", err)
+	}
+	if _, ok := service.applicationsCache.Get(); ok {
+		t.Fatal("list fetched before the invalidation was cached")
+	}
+
+	apps, err := service.GetApplications(ctx)
+	if err != nil {
+		t.Fatalf("second GetApplications() error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server calls = %d, want 2", calls.Load())
+	}
+	if len(apps.Items) != 1 || apps.Items[0].Metadata.Name != "web" {
+		t.Errorf("GetApplications() = %+v, want only web", apps.Items)
+	}
+	if cached, ok := service.applicationsCache.Get(); !ok || len(cached.Items) != 1 {
+		t.Errorf("cached list = %+v, %v, want only web", cached.Items, ok)
+	}
+}
+
 func TestCacheDisabledWithZeroTTL(t *testing.T) {
 	callCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -983,7 +1162,7 @@ func TestCacheDisabledWithZeroTTL(t *testing.T) {
 		CacheTTL:     0,
 	}
 	authSvc := &MockAuthService{token: "test-token"}
-	service := NewArgocdService(cfg, authSvc)
+	service := NewArgocdService(cfg, authSvc, nil)
 	ctx := context.Background()
 
 	service.GetProjects(ctx)
@@ -993,3 +1172,787 @@ func TestCacheDisabledWithZeroTTL(t *testing.T) {
 		t.Errorf("with TTL=0, expected 2 server calls, got %d", callCount)
 	}
 }
+
+func TestApplicationsChangedNotification(t *testing.T) {
+	apps := []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "app1"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: apps})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: 0}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := context.Background()
+
+	changed := service.ApplicationsChanged()
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() unexpected error: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("ApplicationsChanged() not closed after first fetch")
+	}
+
+	// Refetching an identical set must not wake watchers
+	changed = service.ApplicationsChanged()
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() unexpected error: %v", err)
+	}
+	select {
+	case <-changed:
+		t.Fatal("ApplicationsChanged() closed although the application set is unchanged")
+	default:
+	}
+
+	// A different set wakes watchers again
+	apps = append(apps, types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "app2"}, Spec: types.ArgocdApplicationSpec{Project: "production"}})
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() unexpected error: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("ApplicationsChanged() not closed after the application set changed")
+	}
+}
+
+func TestApplicationsHashStable(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "app1", Labels: map[string]string{"b": "2", "a": "1"}}},
+	}}
+	if ApplicationsHash(list) != ApplicationsHash(list) {
+		t.Error("ApplicationsHash() is not deterministic")
+	}
+	other := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "app2"}},
+	}}
+	if ApplicationsHash(list) == ApplicationsHash(other) {
+		t.Error("ApplicationsHash() collided for different application sets")
+	}
+}
+
+func TestUpstreamStatusTracksSuccessfulFetches(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	defer metrics.SetDefault(original)
+
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects":
+			json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{{Metadata: types.ArgocdProjectMetadata{Name: "p"}}}})
+		case "/applications":
+			json.NewEncoder(w).Encode(types.ArgocdApplicationList{})
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := context.Background()
+
+	// Never succeeded: timestamps are nil and no gauge series exist
+	if _, err := service.GetProjects(ctx); err == nil {
+		t.Fatal("GetProjects() expected error from failing upstream")
+	}
+	if err := service.HealthCheck(ctx); err == nil {
+		t.Fatal("HealthCheck() expected error from failing upstream")
+	}
+	status := service.GetUpstreamStatus()
+	if status.Projects != nil || status.Applications != nil || status.Health != nil {
+		t.Fatalf("GetUpstreamStatus() = %+v, want all nil before any success", status)
+	}
+	if count := testutil.CollectAndCount(metrics.LastSuccessfulFetch); count != 0 {
+		t.Fatalf("last successful fetch gauge has %d series before any success, want 0", count)
+	}
+
+	// Success records the time for that resource only
+	failing = false
+	before := time.Now()
+	if _, err := service.GetProjects(ctx); err != nil {
+		t.Fatalf("GetProjects() unexpected error: %v", err)
+	}
+	status = service.GetUpstreamStatus()
+	if status.Projects == nil || status.Projects.Before(before) {
+		t.Fatalf("GetUpstreamStatus().Projects = %v, want a time after %v", status.Projects, before)
+	}
+	if status.Applications != nil || status.Health != nil {
+		t.Errorf("GetUpstreamStatus() recorded unrelated resources: %+v", status)
+	}
+	if got := testutil.ToFloat64(metrics.LastSuccessfulFetch.WithLabelValues(ResourceProjects)); got != float64(status.Projects.Unix()) {
+		t.Errorf("last successful fetch gauge = %v, want %v", got, status.Projects.Unix())
+	}
+	projectsFetchedAt := *status.Projects
+
+	// A later failure keeps the previous success time
+	failing = true
+	service.projectsCache.Invalidate()
+	if _, err := service.GetProjects(ctx); err == nil {
+		t.Fatal("GetProjects() expected error from failing upstream")
+	}
+	status = service.GetUpstreamStatus()
+	if status.Projects == nil || !status.Projects.Equal(projectsFetchedAt) {
+		t.Errorf("GetUpstreamStatus().Projects = %v after failure, want %v", status.Projects, projectsFetchedAt)
+	}
+
+	// Applications and health succeed independently
+	failing = false
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() unexpected error: %v", err)
+	}
+	if err := service.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck() unexpected error: %v", err)
+	}
+	status = service.GetUpstreamStatus()
+	if status.Applications == nil || status.Health == nil {
+		t.Errorf("GetUpstreamStatus() = %+v, want applications and health set", status)
+	}
+	if count := testutil.CollectAndCount(metrics.LastSuccessfulFetch); count != 3 {
+		t.Errorf("last successful fetch gauge has %d series, want 3", count)
+	}
+}
+
+func TestConditionalRefreshByResourceVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		cachedVersion    string
+		probeVersion     string
+		probeStatus      int
+		expectedRequests []string
+		expectedSkipped  float64
+		expectedApps     int
+	}{
+		{
+			name:             "unchanged version skips full fetch",
+			cachedVersion:    "100",
+			probeVersion:     "100",
+			probeStatus:      http.StatusOK,
+			expectedRequests: []string{"full", "version"},
+			expectedSkipped:  1,
+			expectedApps:     1,
+		},
+		{
+			name:             "changed version triggers full fetch",
+			cachedVersion:    "100",
+			probeVersion:     "101",
+			probeStatus:      http.StatusOK,
+			expectedRequests: []string{"full", "version", "full"},
+			expectedApps:     2,
+		},
+		{
+			name:             "failed probe falls back to full fetch",
+			cachedVersion:    "100",
+			probeStatus:      http.StatusInternalServerError,
+			expectedRequests: []string{"full", "version", "full"},
+			expectedApps:     2,
+		},
+		{
+			name:             "missing cached version skips probe",
+			probeStatus:      http.StatusOK,
+			expectedRequests: []string{"full", "full"},
+			expectedApps:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := metrics.Default()
+			metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+			defer metrics.SetDefault(original)
+
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("fields") == "metadata.resourceVersion" {
+					requests = append(requests, "version")
+					w.WriteHeader(tt.probeStatus)
+					fmt.Fprintf(w, `{"metadata":{"resourceVersion":%q}}`, tt.probeVersion)
+					return
+				}
+
+				requests = append(requests, "full")
+				list := types.ArgocdApplicationList{}
+				for i := 0; i < len(requests) && i < 2; i++ {
+					list.Items = append(list.Items, types.ArgocdApplication{
+						Metadata: types.ArgocdApplicationMetadata{Name: fmt.Sprintf("app%d", i)},
+						Spec:     types.ArgocdApplicationSpec{Project: "production"},
+					})
+				}
+				list.Metadata.ResourceVersion = tt.cachedVersion
+				json.NewEncoder(w).Encode(list)
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}
+			clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+			service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+			ctx := context.Background()
+
+			if _, err := service.GetApplications(ctx); err != nil {
+				t.Fatalf("first GetApplications() error: %v", err)
+			}
+			clk.Advance(time.Minute + time.Nanosecond)
+
+			apps, err := service.GetApplications(ctx)
+			if err != nil {
+				t.Fatalf("refresh GetApplications() error: %v", err)
+			}
+			if len(apps.Items) != tt.expectedApps {
+				t.Errorf("refresh GetApplications() count = %d, want %d", len(apps.Items), tt.expectedApps)
+			}
+			if strings.Join(requests, ",") != strings.Join(tt.expectedRequests, ",") {
+				t.Errorf("upstream requests = %v, want %v", requests, tt.expectedRequests)
+			}
+			if got := testutil.ToFloat64(metrics.CacheRefreshSkippedTotal.WithLabelValues("applications")); got != tt.expectedSkipped {
+				t.Errorf("skipped refresh counter = %v, want %v", got, tt.expectedSkipped)
+			}
+
+			// A skipped refresh extends the cache for another TTL
+			if tt.expectedSkipped > 0 {
+				if _, err := service.GetApplications(ctx); err != nil {
+					t.Fatalf("cached GetApplications() error: %v", err)
+				}
+				if len(requests) != len(tt.expectedRequests) {
+					t.Errorf("expected cache hit after skipped refresh, got requests %v", requests)
+				}
+			}
+		})
+	}
+}
+
+func TestConditionalRefreshProjects(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("fields") != "" {
+			requests = append(requests, "version")
+			fmt.Fprint(w, `{"metadata":{"resourceVersion":"7"}}`)
+			return
+		}
+		requests = append(requests, "full")
+		list := types.ArgocdProjectList{Items: []types.ArgocdProject{{Metadata: types.ArgocdProjectMetadata{Name: "p"}}}}
+		list.Metadata.ResourceVersion = "7"
+		json.NewEncoder(w).Encode(list)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+	ctx := context.Background()
+
+	service.GetProjects(ctx)
+	clk.Advance(time.Minute + time.Nanosecond)
+	projects, err := service.GetProjects(ctx)
+	if err != nil {
+		t.Fatalf("GetProjects() error: %v", err)
+	}
+	if len(projects) != 1 {
+		t.Errorf("GetProjects() count = %d, want 1", len(projects))
+	}
+	if strings.Join(requests, ",") != "full,version" {
+		t.Errorf("upstream requests = %v, want [full version]", requests)
+	}
+}
+
+func TestCacheTTLBoundary(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{})
+	}))
+	defer server.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := testutils.NewFakeClock(start)
+	service := NewArgocdServiceWithClock(&config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}, &MockAuthService{token: "test-token"}, nil, clk)
+	ctx := context.Background()
+
+	service.GetApplications(ctx)
+	clk.Advance(time.Minute)
+	service.GetApplications(ctx)
+	if requests != 1 {
+		t.Errorf("upstream requests at exactly the TTL = %d, want 1", requests)
+	}
+	if fetched := service.GetUpstreamStatus().Applications; fetched == nil || !fetched.Equal(start) {
+		t.Errorf("last applications fetch = %v, want %v", fetched, start)
+	}
+
+	clk.Advance(time.Nanosecond)
+	service.GetApplications(ctx)
+	if requests != 2 {
+		t.Errorf("upstream requests just after the TTL = %d, want 2", requests)
+	}
+	if fetched := service.GetUpstreamStatus().Applications; fetched == nil || !fetched.Equal(clk.Now()) {
+		t.Errorf("last applications fetch = %v, want %v", fetched, clk.Now())
+	}
+}
+
+func TestRedisCacheSharedBetweenServices(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+		}})
+	}))
+	defer server.Close()
+	redisServer := miniredis.RunT(t)
+
+	cfg := &config.Config{
+		ArgocdAPIURL:   server.URL,
+		CacheTTL:       time.Minute,
+		CacheBackend:   config.CacheBackendRedis,
+		RedisAddr:      redisServer.Addr(),
+		RedisKeyPrefix: "argocd-proxy:",
+	}
+	first := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	second := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := context.Background()
+
+	if _, err := first.GetApplications(ctx); err != nil {
+		t.Fatalf("first replica: %v", err)
+	}
+	apps, err := second.GetApplications(ctx)
+	if err != nil {
+		t.Fatalf("second replica: %v", err)
+	}
+	if len(apps.Items) != 1 || apps.Items[0].Metadata.Name != "web" {
+		t.Errorf("second replica applications = %+v, want the list cached by the first", apps.Items)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("upstream requests = %d, want 1 for both replicas", got)
+	}
+	if !redisServer.Exists("argocd-proxy:applications") {
+		t.Error("expected the application list under argocd-proxy:applications")
+	}
+
+	second.InvalidateCaches()
+	if _, err := first.GetApplications(ctx); err != nil {
+		t.Fatalf("first replica after invalidation: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("upstream requests after invalidation = %d, want 2", got)
+	}
+}
+
+// syncWindowsPayload is a response captured from ArgoCD's /applications/{name}/syncwindows
+const syncWindowsPayload = `{
+  "assignedWindows": [
+    {"kind": "allow", "schedule": "0 8 * * 1-5", "duration": "10h", "applications": ["*"], "timeZone": "Europe/Bucharest"},
+    {"kind": "deny", "schedule": "0 22 * * *", "duration": "8h", "applications": ["*"], "manualSync": true}
+  ],
+  "activeWindows": [
+    {"kind": "deny", "schedule": "0 22 * * *", "duration": "8h", "applications": ["*"], "manualSync": true}
+  ],
+  "canSync": false
+}`
+
+func TestGetApplicationSyncWindows(t *testing.T) {
+	tests := []struct {
+		name           string
+		appProject     string
+		windowsStatus  int
+		expectError    bool
+		errorContains  string
+		expectedDenies int
+		expectedAllows int
+	}{
+		{
+			name:           "decodes captured payload",
+			appProject:     "production",
+			windowsStatus:  http.StatusOK,
+			expectedDenies: 1,
+		},
+		{
+			name:          "filtered project is not exposed",
+			appProject:    "test-project",
+			windowsStatus: http.StatusOK,
+			expectError:   true,
+			errorContains: "filtered project",
+		},
+		{
+			name:          "upstream error",
+			appProject:    "production",
+			windowsStatus: http.StatusInternalServerError,
+			expectError:   true,
+			errorContains: "status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var windowsRequested bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/applications/web":
+					json.NewEncoder(w).Encode(types.ArgocdApplication{
+						Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+						Spec:     types.ArgocdApplicationSpec{Project: tt.appProject},
+					})
+				case "/applications/web/syncwindows":
+					windowsRequested = true
+					w.WriteHeader(tt.windowsStatus)
+					fmt.Fprint(w, syncWindowsPayload)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL, IgnoredProjects: []string{"test-*"}}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			windows, err := service.GetApplicationSyncWindows(context.Background(), "web")
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Fatalf("GetApplicationSyncWindows() error = %v, want containing %q", err, tt.errorContains)
+				}
+				if tt.errorContains == "filtered project" && windowsRequested {
+					t.Error("sync windows should not be requested for filtered applications")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetApplicationSyncWindows() unexpected error: %v", err)
+			}
+
+			if windows.Application != "web" || windows.CanSync {
+				t.Errorf("GetApplicationSyncWindows() = %+v, want application web with canSync false", windows)
+			}
+			if len(windows.ActiveDenies) != tt.expectedDenies || len(windows.ActiveAllows) != tt.expectedAllows {
+				t.Errorf("active windows = %d denies / %d allows, want %d / %d",
+					len(windows.ActiveDenies), len(windows.ActiveAllows), tt.expectedDenies, tt.expectedAllows)
+			}
+			if len(windows.AssignedWindows) != 2 {
+				t.Fatalf("assigned windows count = %d, want 2", len(windows.AssignedWindows))
+			}
+			if windows.AssignedWindows[0].TimeZone != "Europe/Bucharest" || !windows.ActiveDenies[0].ManualSync {
+				t.Errorf("window fields not decoded: %+v", windows)
+			}
+		})
+	}
+}
+
+func TestBuildApplicationSyncWindowsEmpty(t *testing.T) {
+	windows := BuildApplicationSyncWindows("web", types.ArgocdApplicationSyncWindowsResponse{CanSync: true})
+
+	data, err := json.Marshal(windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"application":"web","canSync":true,"activeAllows":[],"activeDenies":[],"assignedWindows":[]}`
+	if string(data) != want {
+		t.Errorf("BuildApplicationSyncWindows() JSON = %s, want %s", data, want)
+	}
+}
+
+func TestGetProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{
+			{Metadata: types.ArgocdProjectMetadata{Name: "payments"}},
+			{Metadata: types.ArgocdProjectMetadata{Name: "test-sandbox"}},
+		}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, IgnoredProjects: []string{"test-*"}}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		project     string
+		expectError bool
+	}{
+		{"existing project", "payments", false},
+		{"filtered project", "test-sandbox", true},
+		{"unknown project", "ledger", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, err := service.GetProject(ctx, tt.project)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "not found") {
+					t.Errorf("GetProject() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetProject() unexpected error: %v", err)
+			}
+			if project.Metadata.Name != tt.project {
+				t.Errorf("GetProject() name = %q, want %q", project.Metadata.Name, tt.project)
+			}
+		})
+	}
+}
+
+func TestGetProjectUsesRequestSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{
+			{Metadata: types.ArgocdProjectMetadata{Name: "payments"}},
+		}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := config.WithSnapshot(context.Background(), cfg.Snapshot())
+
+	// Rules stored after the request took its snapshot do not apply to it
+	cfg.Store(&config.FilterSnapshot{IgnoredProjects: []string{"payments"}})
+
+	if _, err := service.GetProject(ctx, "payments"); err != nil {
+		t.Errorf("GetProject() with request snapshot error = %v, want nil", err)
+	}
+	if _, err := service.GetProject(context.Background(), "payments"); err == nil {
+		t.Error("GetProject() without request snapshot error = nil, want not found")
+	}
+}
+
+func TestApplicationsMarkedDeleting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/applications":
+			fmt.Fprint(w, `{"items": [
+				{"metadata": {"name": "web"}, "spec": {"project": "production"}},
+				{"metadata": {"name": "legacy-reports", "deletionTimestamp": "2024-05-02T08:00:00Z"}, "spec": {"project": "production"}}
+			]}`)
+		case "/applications/legacy-reports":
+			fmt.Fprint(w, `{"metadata": {"name": "legacy-reports", "deletionTimestamp": "2024-05-02T08:00:00Z"}, "spec": {"project": "production"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewArgocdService(&config.Config{ArgocdAPIURL: server.URL}, &MockAuthService{token: "test-token"}, nil)
+
+	applications, err := service.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+	if len(applications.Items) != 2 || applications.Items[0].Deleting || !applications.Items[1].Deleting {
+		t.Errorf("GetApplications() deleting flags = %+v, want only legacy-reports deleting", applications.Items)
+	}
+
+	app, err := service.GetApplication(context.Background(), "legacy-reports")
+	if err != nil {
+		t.Fatalf("GetApplication() error = %v", err)
+	}
+	wantDeletedAt := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	if !app.Deleting || app.Metadata.DeletionTimestamp == nil || !app.Metadata.DeletionTimestamp.Equal(wantDeletedAt) {
+		t.Errorf("GetApplication() deleting = %v, deletionTimestamp = %v, want true, %v", app.Deleting, app.Metadata.DeletionTimestamp, wantDeletedAt)
+	}
+}
+
+func TestGetApplicationsByGroupNotFound(t *testing.T) {
+	cfg := &config.Config{
+		ArgocdAPIURL:  "http://127.0.0.1:0",
+		ProjectGroups: []config.ProjectGroup{{Name: "frontend", Projects: []string{"web"}}},
+	}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+	_, err := service.GetApplicationsByGroup(context.Background(), " payments ")
+	if !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("GetApplicationsByGroup() error = %v, want ErrGroupNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "'payments'") {
+		t.Errorf("GetApplicationsByGroup() error = %q, want trimmed group name", err.Error())
+	}
+}
+
+func TestGetApplicationsByGroupIgnoredProjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		app := func(name, project string) types.ArgocdApplication {
+			return types.ArgocdApplication{
+				Metadata: types.ArgocdApplicationMetadata{Name: name},
+				Spec:     types.ArgocdApplicationSpec{Project: project},
+			}
+		}
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			app("web", "frontend"),
+			app("web-pr-12-preview", "frontend"),
+			app("api", "backend"),
+			app("api-pr-3-preview", "backend"),
+			app("docs", "docs-dev"),
+			app("scratch", "sandbox"),
+		}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ArgocdAPIURL:    server.URL,
+		IgnoredProjects: []string{"*-dev", "sandbox"},
+		ProjectGroups: []config.ProjectGroup{
+			{Name: "team-a", Projects: []string{"frontend", "docs-dev"}, IgnoredProjects: []string{"*-preview"}},
+			{Name: "team-b", Projects: []string{"backend"}},
+			{Name: "team-c", Projects: []string{"frontend", "sandbox"}, IgnoredProjects: []string{"frontend"}},
+		},
+	}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+	tests := []struct {
+		name  string
+		group string
+		want  []string
+	}{
+		{"group patterns hide matching application names", "team-a", []string{"web", "docs"}},
+		{"other groups are unaffected", "team-b", []string{"api", "api-pr-3-preview"}},
+		{"group patterns hide matching projects", "team-c", []string{"scratch"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applications, err := service.GetApplicationsByGroup(context.Background(), tt.group)
+			if err != nil {
+				t.Fatalf("GetApplicationsByGroup() error = %v", err)
+			}
+			names := []string{}
+			for _, app := range applications.Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("GetApplicationsByGroup(%q) = %v, want %v", tt.group, names, tt.want)
+			}
+		})
+	}
+
+	// Global endpoints only apply IGNORED_PROJECTS, which never hides grouped projects
+	applications, err := service.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+	if len(applications.Items) != 6 {
+		t.Errorf("GetApplications() returned %d applications, want all 6", len(applications.Items))
+	}
+}
+
+func TestUpstreamErrorBodyRedacted(t *testing.T) {
+	previous := redact.Default()
+	defer redact.SetDefault(previous)
+	redactor := redact.New(64)
+	redactor.SetSecret("password", "hunter2-hunter2")
+	redact.SetDefault(redactor)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("session for password hunter2-hunter2 expired; " + strings.Repeat("x", 500)))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+	_, err := service.GetApplications(context.Background())
+	if err == nil {
+		t.Fatal("GetApplications() expected error")
+	}
+	if strings.Contains(err.Error(), "hunter2-hunter2") {
+		t.Errorf("GetApplications() error leaked the password: %v", err)
+	}
+	if !strings.Contains(err.Error(), "...(truncated)") || len(err.Error()) > 150 {
+		t.Errorf("GetApplications() error = %q, want body truncated to 64 bytes", err.Error())
+	}
+}
+
+func TestProjectScopedTokens(t *testing.T) {
+	apps := map[string]string{"web": "payments", "api": "platform"}
+
+	var mu sync.Mutex
+	authHeaders := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/session" {
+			json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "session-token"})
+			return
+		}
+
+		mu.Lock()
+		authHeaders[r.URL.Path] = append(authHeaders[r.URL.Path], r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/applications"), "/")
+		switch {
+		case r.URL.Path == "/applications":
+			list := types.ArgocdApplicationList{}
+			for name, project := range apps {
+				list.Items = append(list.Items, types.ArgocdApplication{
+					Metadata: types.ArgocdApplicationMetadata{Name: name},
+					Spec:     types.ArgocdApplicationSpec{Project: project},
+				})
+			}
+			json.NewEncoder(w).Encode(list)
+		case len(parts) == 2:
+			json.NewEncoder(w).Encode(types.ArgocdApplication{
+				Metadata: types.ArgocdApplicationMetadata{Name: parts[1]},
+				Spec:     types.ArgocdApplicationSpec{Project: apps[parts[1]]},
+			})
+		case len(parts) == 3 && parts[2] == "managed-resources":
+			fmt.Fprint(w, `{"items":[]}`)
+		case len(parts) == 3 && parts[2] == "syncwindows":
+			fmt.Fprint(w, `{"canSync":true}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ArgocdAPIURL:        server.URL,
+		ArgocdUsername:      "proxy",
+		ArgocdPassword:      "secret",
+		ArgocdProjectTokens: map[string]string{"payments": "payments-token"},
+		CacheTTL:            time.Minute,
+	}
+	service := NewArgocdService(cfg, auth.NewAuthService(cfg, nil), nil)
+	ctx := context.Background()
+
+	// Before the application list is cached the project of "web" is unknown
+	if _, err := service.GetApplication(ctx, "web"); err != nil {
+		t.Fatalf("GetApplication() error = %v", err)
+	}
+	if _, err := service.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+	if _, err := service.GetApplication(ctx, "web"); err != nil {
+		t.Fatalf("GetApplication() error = %v", err)
+	}
+	if _, err := service.GetApplicationDiff(ctx, "web"); err != nil {
+		t.Fatalf("GetApplicationDiff() error = %v", err)
+	}
+	if _, err := service.GetApplicationSyncWindows(ctx, "web"); err != nil {
+		t.Fatalf("GetApplicationSyncWindows() error = %v", err)
+	}
+	if _, err := service.GetApplicationDiff(ctx, "api"); err != nil {
+		t.Fatalf("GetApplicationDiff() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"/applications":                       {"Bearer session-token"},
+		"/applications/web":                   {"Bearer session-token", "Bearer payments-token", "Bearer payments-token", "Bearer payments-token"},
+		"/applications/web/managed-resources": {"Bearer payments-token"},
+		"/applications/web/syncwindows":       {"Bearer payments-token"},
+		"/applications/api":                   {"Bearer session-token"},
+		"/applications/api/managed-resources": {"Bearer session-token"},
+	}
+	if !reflect.DeepEqual(authHeaders, want) {
+		t.Errorf("Authorization headers by path = %v, want %v", authHeaders, want)
+	}
+}
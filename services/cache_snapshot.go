@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"argocd-proxy/cache"
+	"argocd-proxy/types"
+)
+
+// ExportCacheSnapshot returns the cached project and application lists with the times
+// they were fetched, even expired ones, so that another replica can import them. It
+// reads the caches only and never calls ArgoCD.
+func (s *ArgocdService) ExportCacheSnapshot() types.CacheSnapshot {
+	snapshot := types.CacheSnapshot{SchemaVersion: types.CacheSnapshotSchemaVersion, ExportedAt: s.clock.Now()}
+	if list, cachedAt, ok := cachedEntry(s.projectsCache); ok {
+		snapshot.Projects = &types.CachedProjects{CachedAt: cachedAt, List: list}
+	}
+	if list, cachedAt, ok := cachedEntry(s.applicationsCache); ok {
+		snapshot.Applications = &types.CachedApplications{CachedAt: cachedAt, List: list}
+	}
+	return snapshot
+}
+
+// ImportCacheSnapshot pre-warms the project and application caches from a snapshot
+// exported by another replica, keeping the time each list was fetched so that it expires
+// as it would have there. Lists older than CACHE_TTL, or older than what is already
+// cached, are skipped. It returns the names of the imported caches, and an error when
+// the snapshot has another schema version.
+//
+// The exported application list was filtered by the exporting replica's ignore rules,
+// which a rolling restart may have changed, so it is filtered again by the rules in
+// effect here. Applications only this replica shows are missing until the next refresh.
+func (s *ArgocdService) ImportCacheSnapshot(snapshot types.CacheSnapshot) ([]string, error) {
+	if snapshot.SchemaVersion != types.CacheSnapshotSchemaVersion {
+		return nil, fmt.Errorf("cache snapshot schema version %d is not supported, want %d", snapshot.SchemaVersion, types.CacheSnapshotSchemaVersion)
+	}
+
+	var imported []string
+	if p := snapshot.Projects; p != nil && importable(s, s.projectsCache, p.CachedAt) {
+		s.projectsCache.SetAt(p.List, p.CachedAt)
+		imported = append(imported, ResourceProjects)
+	}
+	if a := snapshot.Applications; a != nil && importable(s, s.applicationsCache, a.CachedAt) {
+		list := a.List
+		filters := s.config.Snapshot()
+		list.Items = nil
+		for _, app := range a.List.Items {
+			if !s.filtered(filters, app.Spec.Project, "application "+strconv.Quote(app.Metadata.Name)) {
+				list.Items = append(list.Items, app)
+			}
+		}
+		s.applicationsCache.SetAt(list, a.CachedAt)
+		s.publishApplications(list)
+		imported = append(imported, ResourceApplications)
+	}
+	return imported, nil
+}
+
+// importable reports whether a list fetched at cachedAt is still fresh and newer than
+// the one store holds
+func importable[T any](s *ArgocdService, store cache.Store[T], cachedAt time.Time) bool {
+	if s.clock.Since(cachedAt) > s.config.CacheTTL {
+		return false
+	}
+	current, ok := store.CachedAt()
+	return !ok || cachedAt.After(current)
+}
+
+// cachedEntry returns the value stored in store, even an expired one, with the time it
+// was stored
+func cachedEntry[T any](store cache.Store[T]) (T, time.Time, bool) {
+	value, ok := store.GetStale()
+	if !ok {
+		var zero T
+		return zero, time.Time{}, false
+	}
+	cachedAt, ok := store.CachedAt()
+	return value, cachedAt, ok
+}
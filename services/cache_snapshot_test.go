@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+// newSnapshotReplica returns a service over a fake ArgoCD serving one project and one
+// application, and the number of ArgoCD requests it has answered
+func newSnapshotReplica(t *testing.T, clk *testutils.FakeClock) (*ArgocdService, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/projects":
+			json.NewEncoder(w).Encode(types.ArgocdProjectList{Items: []types.ArgocdProject{{Metadata: types.ArgocdProjectMetadata{Name: "production"}}}})
+		case "/applications":
+			json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+				{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}
+	return NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk), &requests
+}
+
+// transferSnapshot sends snapshot through JSON like the admin endpoint and startup import do
+func transferSnapshot(t *testing.T, snapshot types.CacheSnapshot) types.CacheSnapshot {
+	t.Helper()
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded types.CacheSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return decoded
+}
+
+func TestCacheSnapshotRoundTrip(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := testutils.NewFakeClock(start)
+	ctx := context.Background()
+
+	old, _ := newSnapshotReplica(t, clk)
+	if empty := old.ExportCacheSnapshot(); empty.Projects != nil || empty.Applications != nil {
+		t.Fatalf("ExportCacheSnapshot() of empty caches = %+v, want no lists", empty)
+	}
+	if _, err := old.GetProjects(ctx); err != nil {
+		t.Fatalf("GetProjects() error = %v", err)
+	}
+	if _, err := old.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+	clk.Advance(20 * time.Second)
+	snapshot := transferSnapshot(t, old.ExportCacheSnapshot())
+	if snapshot.SchemaVersion != types.CacheSnapshotSchemaVersion || !snapshot.ExportedAt.Equal(clk.Now()) {
+		t.Errorf("snapshot version %d exported at %v, want %d at %v", snapshot.SchemaVersion, snapshot.ExportedAt, types.CacheSnapshotSchemaVersion, clk.Now())
+	}
+
+	replica, requests := newSnapshotReplica(t, clk)
+	imported, err := replica.ImportCacheSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("ImportCacheSnapshot() error = %v", err)
+	}
+	if !reflect.DeepEqual(imported, []string{ResourceProjects, ResourceApplications}) {
+		t.Errorf("ImportCacheSnapshot() = %v, want both caches", imported)
+	}
+
+	// The imported lists serve requests without calling ArgoCD
+	projects, err := replica.GetFilteredProjects(ctx)
+	if err != nil || len(projects) != 1 || projects[0].Metadata.Name != "production" {
+		t.Errorf("GetFilteredProjects() = %+v, %v, want the imported project", projects, err)
+	}
+	apps, err := replica.GetApplications(ctx)
+	if err != nil || len(apps.Items) != 1 || apps.Items[0].Metadata.Name != "web" {
+		t.Errorf("GetApplications() = %+v, %v, want the imported application", apps.Items, err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("upstream requests = %d, want none after the import", got)
+	}
+	if age := replica.applicationsCache.Age(); age != 20*time.Second {
+		t.Errorf("imported applications age = %v, want the exported 20s", age)
+	}
+
+	// The imported lists expire as they would have on the exporting replica
+	clk.Advance(41 * time.Second)
+	if _, err := replica.GetApplications(ctx); err != nil {
+		t.Fatalf("GetApplications() after the TTL error = %v", err)
+	}
+	if got := requests.Load(); got == 0 {
+		t.Error("upstream requests = 0, want a fetch once the imported list expired")
+	}
+}
+
+func TestCacheSnapshotImportSkipsStaleLists(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := testutils.NewFakeClock(start)
+	replica, _ := newSnapshotReplica(t, clk)
+
+	snapshot := types.CacheSnapshot{
+		SchemaVersion: types.CacheSnapshotSchemaVersion,
+		Projects:      &types.CachedProjects{CachedAt: start.Add(-2 * time.Minute)},
+		Applications: &types.CachedApplications{
+			CachedAt: start.Add(-30 * time.Second),
+			List:     types.ArgocdApplicationList{Items: []types.ArgocdApplication{{Metadata: types.ArgocdApplicationMetadata{Name: "web"}}}},
+		},
+	}
+	imported, err := replica.ImportCacheSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("ImportCacheSnapshot() error = %v", err)
+	}
+	if !reflect.DeepEqual(imported, []string{ResourceApplications}) {
+		t.Errorf("ImportCacheSnapshot() = %v, want only the fresh applications", imported)
+	}
+	if _, ok := replica.projectsCache.GetStale(); ok {
+		t.Error("expected the stale project list not to be imported")
+	}
+
+	// A list older than the cached one does not replace it
+	snapshot.Applications.CachedAt = start.Add(-40 * time.Second)
+	snapshot.Applications.List.Items = nil
+	if imported, _ := replica.ImportCacheSnapshot(snapshot); len(imported) != 0 {
+		t.Errorf("ImportCacheSnapshot() of older lists = %v, want none", imported)
+	}
+	if apps, _ := replica.applicationsCache.Get(); len(apps.Items) != 1 {
+		t.Errorf("cached applications = %+v, want the newer list kept", apps.Items)
+	}
+}
+
+func TestCacheSnapshotImportAppliesLocalIgnoreRules(t *testing.T) {
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	// The exporting replica ignores nothing, the importing one was restarted with
+	// IGNORED_PROJECTS=staging
+	exporter, _ := newSnapshotReplica(t, clk)
+	exporter.ImportCacheSnapshot(types.CacheSnapshot{
+		SchemaVersion: types.CacheSnapshotSchemaVersion,
+		Applications: &types.CachedApplications{CachedAt: clk.Now(), List: types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "production"}},
+			{Metadata: types.ArgocdApplicationMetadata{Name: "web-staging"}, Spec: types.ArgocdApplicationSpec{Project: "staging"}},
+		}}},
+	})
+	snapshot := transferSnapshot(t, exporter.ExportCacheSnapshot())
+	if got := len(snapshot.Applications.List.Items); got != 2 {
+		t.Fatalf("exported applications = %d, want 2", got)
+	}
+
+	importer, requests := newSnapshotReplica(t, clk)
+	importer.config.IgnoredProjects = []string{"staging"}
+	if _, err := importer.ImportCacheSnapshot(snapshot); err != nil {
+		t.Fatalf("ImportCacheSnapshot() error = %v", err)
+	}
+
+	apps, err := importer.GetApplications(context.Background())
+	if err != nil || len(apps.Items) != 1 || apps.Items[0].Metadata.Name != "web" {
+		t.Errorf("GetApplications() = %+v, %v, want only web with staging ignored", apps.Items, err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("upstream requests = %d, want the filtered import served", got)
+	}
+}
+
+func TestCacheSnapshotImportRejectsSchemaVersion(t *testing.T) {
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	replica, _ := newSnapshotReplica(t, clk)
+
+	for _, version := range []int{0, types.CacheSnapshotSchemaVersion + 1} {
+		snapshot := types.CacheSnapshot{
+			SchemaVersion: version,
+			Applications:  &types.CachedApplications{CachedAt: clk.Now()},
+		}
+		imported, err := replica.ImportCacheSnapshot(snapshot)
+		if err == nil || !strings.Contains(err.Error(), "schema version") {
+			t.Errorf("ImportCacheSnapshot() of version %d error = %v, want a schema version error", version, err)
+		}
+		if len(imported) != 0 {
+			t.Errorf("ImportCacheSnapshot() of version %d = %v, want nothing imported", version, imported)
+		}
+	}
+	if _, ok := replica.applicationsCache.GetStale(); ok {
+		t.Error("expected nothing cached from a snapshot of another schema version")
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"argocd-proxy/breaker"
+	"argocd-proxy/clock"
+	"argocd-proxy/config"
+	"argocd-proxy/events"
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// ErrCircuitOpen is wrapped when an application read fails fast because its circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned for reads of an application whose circuit breaker opened
+// after repeated failures
+type CircuitOpenError struct {
+	Application string
+	// RetryAfter is how long until the circuit lets a read through again
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("application '%s': %v after repeated failures, retry in %s", e.Application, ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap returns ErrCircuitOpen
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// newAppCircuits returns the per-application circuit breakers, or nil when
+// APP_CIRCUIT_THRESHOLD disables them
+func newAppCircuits(cfg *config.Config, clk clock.Clock) *breaker.Set {
+	if cfg.AppCircuitThreshold <= 0 {
+		return nil
+	}
+	return breaker.NewWithClock(breaker.Options{
+		Threshold: cfg.AppCircuitThreshold,
+		Window:    cfg.AppCircuitWindow,
+		Cooldown:  cfg.AppCircuitCooldown,
+		MaxKeys:   cfg.AppCircuitMaxApps,
+		OnChange: func(name string, open bool) {
+			if open {
+				log.Printf("WARNING: Circuit breaker opened for application %s after %d consecutive failures; reads fail fast for %s",
+					name, cfg.AppCircuitThreshold, cfg.AppCircuitCooldown)
+				metrics.AppCircuitOpen.WithLabelValues(name).Set(1)
+				events.Record(events.CircuitOpened, "Circuit breaker opened for application "+name, map[string]string{
+					"application": name,
+					"cooldown":    cfg.AppCircuitCooldown.String(),
+				})
+				return
+			}
+			metrics.AppCircuitOpen.DeleteLabelValues(name)
+		},
+	}, clk)
+}
+
+// allowApplicationRead fails with a *CircuitOpenError when the circuit breaker of the
+// named application is open
+func (s *ArgocdService) allowApplicationRead(name string) error {
+	if s.appCircuits == nil {
+		return nil
+	}
+	if retryAfter, ok := s.appCircuits.Allow(name); !ok {
+		metrics.AppCircuitRejectedTotal.Inc()
+		return &CircuitOpenError{Application: name, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordApplicationRead reports the outcome of reading the named application from ArgoCD
+// to its circuit breaker. Missing and hidden applications are answers rather than
+// failures, and reads abandoned by the client say nothing about the application.
+func (s *ArgocdService) recordApplicationRead(ctx context.Context, name string, err error) {
+	if s.appCircuits == nil {
+		return
+	}
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound), errors.Is(err, ErrFiltered):
+		s.appCircuits.Success(name)
+	case errors.Is(ctx.Err(), context.Canceled):
+	default:
+		s.appCircuits.Failure(name)
+	}
+}
+
+// OpenCircuits returns the applications whose circuit breaker is open, sorted by name
+func (s *ArgocdService) OpenCircuits() []types.ApplicationCircuit {
+	if s.appCircuits == nil {
+		return nil
+	}
+	now := clock.OrReal(s.clock).Now()
+	var circuits []types.ApplicationCircuit
+	for _, circuit := range s.appCircuits.Open() {
+		circuits = append(circuits, types.ApplicationCircuit{
+			Application:       circuit.Key,
+			OpenedAt:          circuit.OpenedAt,
+			OpenUntil:         circuit.OpenUntil,
+			RetryAfterSeconds: max(1, int(math.Ceil(circuit.OpenUntil.Sub(now).Seconds()))),
+		})
+	}
+	return circuits
+}
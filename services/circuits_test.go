@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+func TestApplicationCircuitBreaker(t *testing.T) {
+	var heavyCalls atomic.Int32
+	var heavyFails atomic.Bool
+	heavyFails.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/applications/heavy":
+			heavyCalls.Add(1)
+			if heavyFails.Load() {
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			json.NewEncoder(w).Encode(types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "heavy"}})
+		case "/applications/light":
+			json.NewEncoder(w).Encode(types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "light"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	clk := testutils.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	cfg := &config.Config{
+		ArgocdAPIURL:        server.URL,
+		AppCircuitThreshold: 3,
+		AppCircuitWindow:    time.Minute,
+		AppCircuitCooldown:  30 * time.Second,
+		AppCircuitMaxApps:   10,
+	}
+	service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetApplication(ctx, "heavy"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetApplication() %d error = %v, want the upstream failure", i, err)
+		}
+	}
+
+	// The circuit is open: reads fail fast without calling ArgoCD
+	_, err := service.GetApplication(ctx, "heavy")
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || open.RetryAfter != 30*time.Second {
+		t.Fatalf("GetApplication() error = %v, want a CircuitOpenError retrying in 30s", err)
+	}
+	if _, err := service.GetApplicationRaw(ctx, "heavy"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetApplicationRaw() error = %v, want ErrCircuitOpen", err)
+	}
+	if got := heavyCalls.Load(); got != 3 {
+		t.Errorf("ArgoCD calls = %d, want 3", got)
+	}
+	want := []types.ApplicationCircuit{{Application: "heavy", OpenedAt: clk.Now(), OpenUntil: clk.Now().Add(30 * time.Second), RetryAfterSeconds: 30}}
+	if got := service.OpenCircuits(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("OpenCircuits() = %+v, want %+v", got, want)
+	}
+	if got := testutil.ToFloat64(metrics.AppCircuitOpen.WithLabelValues("heavy")); got != 1 {
+		t.Errorf("open circuit gauge = %v, want 1", got)
+	}
+
+	// Other applications are unaffected, and missing ones are not failures
+	if _, err := service.GetApplication(ctx, "light"); err != nil {
+		t.Errorf("GetApplication(light) error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetApplication(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("GetApplication(missing) error = %v, want ErrNotFound", err)
+		}
+	}
+	if _, err := service.GetApplication(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetApplication(missing) after repeated misses error = %v, want ErrNotFound", err)
+	}
+
+	// After the cooldown a read goes through again and closes the circuit when it succeeds
+	clk.Advance(30 * time.Second)
+	heavyFails.Store(false)
+	if _, err := service.GetApplication(ctx, "heavy"); err != nil {
+		t.Fatalf("GetApplication() after the cooldown error = %v", err)
+	}
+	if got := service.OpenCircuits(); len(got) != 0 {
+		t.Errorf("OpenCircuits() after recovery = %+v, want none", got)
+	}
+	if got := testutil.CollectAndCount(metrics.AppCircuitOpen); got != 0 {
+		t.Errorf("open circuit gauge series after recovery = %d, want 0", got)
+	}
+}
+
+func TestApplicationCircuitBreakerDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	service := NewArgocdService(&config.Config{ArgocdAPIURL: server.URL}, &MockAuthService{token: "test-token"}, nil)
+	for i := 0; i < 10; i++ {
+		if _, err := service.GetApplication(context.Background(), "heavy"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetApplication() %d failed fast with the breakers disabled", i)
+		}
+	}
+	if got := service.OpenCircuits(); got != nil {
+		t.Errorf("OpenCircuits() = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// GetClusters retrieves the clusters registered in ArgoCD, cached for CACHE_TTL
+func (s *ArgocdService) GetClusters(ctx context.Context) ([]types.ArgocdCluster, error) {
+	if cached, ok := s.clustersCache.Get(); ok {
+		metrics.CacheHitsTotal.WithLabelValues("clusters").Inc()
+		return cached.Items, nil
+	}
+	metrics.CacheMissesTotal.WithLabelValues("clusters").Inc()
+
+	var clusterList types.ArgocdClusterList
+	if err := s.client.GetJSON(ctx, "/clusters", &clusterList, upstream.StrictDecode()); err != nil {
+		return nil, err
+	}
+
+	s.clustersCache.Set(clusterList)
+	return clusterList.Items, nil
+}
+
+// clusterNames returns the display names of ArgoCD's clusters keyed by server URL. It
+// returns nil when cluster names are not resolved or the clusters cannot be read, so
+// that destinations fall back to their server URL instead of failing the request.
+func (s *ArgocdService) clusterNames(ctx context.Context) map[string]string {
+	if !s.config.ResolveClusterNames {
+		return nil
+	}
+
+	clusters, err := s.GetClusters(ctx)
+	if err != nil {
+		log.Printf("Failed to resolve cluster names, using server URLs: %v", err)
+		return map[string]string{}
+	}
+
+	names := make(map[string]string, len(clusters))
+	for _, cluster := range clusters {
+		if cluster.Server != "" && cluster.Name != "" {
+			names[cluster.Server] = cluster.Name
+		}
+	}
+	return names
+}
+
+// SetClusterName fills in the destination cluster name of app from names, keyed by
+// server URL. Destinations addressed by cluster name keep that name, and unknown servers
+// fall back to the server URL. A nil map leaves the destination untouched.
+func SetClusterName(app *types.ArgocdApplication, names map[string]string) {
+	if names == nil {
+		return
+	}
+
+	dest := &app.Spec.Destination
+	switch {
+	case names[dest.Server] != "":
+		dest.ClusterName = names[dest.Server]
+	case dest.Name != "":
+		dest.ClusterName = dest.Name
+	default:
+		dest.ClusterName = dest.Server
+	}
+}
+
+// MatchesCluster reports whether the destination is the named cluster, by display name,
+// ArgoCD destination name or server URL
+func MatchesCluster(dest types.ArgocdApplicationDestination, cluster string) bool {
+	return cluster == dest.ClusterName || cluster == dest.Name || cluster == dest.Server
+}
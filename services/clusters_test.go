@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// newClustersServer serves applications and, unless clustersStatus is not 200, clusters
+func newClustersServer(t *testing.T, clustersStatus int, apps ...types.ArgocdApplication) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/clusters":
+			if clustersStatus != http.StatusOK {
+				w.WriteHeader(clustersStatus)
+				return
+			}
+			json.NewEncoder(w).Encode(types.ArgocdClusterList{Items: []types.ArgocdCluster{
+				{Server: "https://10.0.0.1:6443", Name: "prod-eu"},
+				{Server: "https://kubernetes.default.svc", Name: "in-cluster"},
+			}})
+		case "/applications":
+			json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: apps})
+		case "/applications/web":
+			json.NewEncoder(w).Encode(apps[0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClusterNameEnrichment(t *testing.T) {
+	apps := []types.ArgocdApplication{
+		destinationApp("web", "https://10.0.0.1:6443", "", "web"),
+		destinationApp("api", "", "staging", "api"),
+		destinationApp("batch", "https://10.9.9.9:6443", "", "batch"),
+	}
+
+	tests := []struct {
+		name           string
+		resolve        bool
+		clustersStatus int
+		want           map[string]string
+	}{
+		{
+			name:           "resolved from clusters",
+			resolve:        true,
+			clustersStatus: http.StatusOK,
+			want:           map[string]string{"web": "prod-eu", "api": "staging", "batch": "https://10.9.9.9:6443"},
+		},
+		{
+			name:           "clusters unavailable falls back to server URLs",
+			resolve:        true,
+			clustersStatus: http.StatusForbidden,
+			want:           map[string]string{"web": "https://10.0.0.1:6443", "api": "staging", "batch": "https://10.9.9.9:6443"},
+		},
+		{
+			name:           "resolution disabled",
+			clustersStatus: http.StatusOK,
+			want:           map[string]string{"web": "", "api": "", "batch": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newClustersServer(t, tt.clustersStatus, apps...)
+			cfg := &config.Config{ArgocdAPIURL: server.URL, ResolveClusterNames: tt.resolve}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			list, err := service.GetApplications(context.Background())
+			if err != nil {
+				t.Fatalf("GetApplications() error = %v, want the request to succeed", err)
+			}
+			for _, app := range list.Items {
+				if got := app.Spec.Destination.ClusterName; got != tt.want[app.Metadata.Name] {
+					t.Errorf("%s clusterName = %q, want %q", app.Metadata.Name, got, tt.want[app.Metadata.Name])
+				}
+			}
+
+			app, err := service.GetApplication(context.Background(), "web")
+			if err != nil {
+				t.Fatalf("GetApplication() error = %v", err)
+			}
+			if got := app.Spec.Destination.ClusterName; got != tt.want["web"] {
+				t.Errorf("GetApplication() clusterName = %q, want %q", got, tt.want["web"])
+			}
+		})
+	}
+}
+
+func TestApplicationFilterDestCluster(t *testing.T) {
+	names := map[string]string{"https://10.0.0.1:6443": "prod-eu"}
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		destinationApp("web", "https://10.0.0.1:6443", "", "web"),
+		destinationApp("api", "", "staging", "api"),
+		destinationApp("batch", "https://10.9.9.9:6443", "", "batch"),
+	}}
+	for i := range list.Items {
+		SetClusterName(&list.Items[i], names)
+	}
+
+	tests := []struct {
+		cluster string
+		want    []string
+	}{
+		{"prod-eu", []string{"web"}},
+		{"https://10.0.0.1:6443", []string{"web"}},
+		{"staging", []string{"api"}},
+		{"https://10.9.9.9:6443", []string{"batch"}},
+		{"unknown", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cluster, func(t *testing.T) {
+			got := appNames(ApplicationFilter{DestCluster: tt.cluster}.Apply(list))
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("Apply(destCluster=%q) = %v, want %v", tt.cluster, got, tt.want)
+			}
+		})
+	}
+}
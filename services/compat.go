@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"argocd-proxy/events"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// Supported ArgoCD versions: at least minSupportedVersion and below maxSupportedVersion
+var (
+	minSupportedVersion = [3]int{2, 4, 0}
+	maxSupportedVersion = [3]int{4, 0, 0}
+)
+
+// SupportedVersionRange describes the supported ArgoCD versions in messages
+const SupportedVersionRange = ">=2.4.0 <4.0.0"
+
+// argocdVersionResponse is the body of ArgoCD's /api/version endpoint
+type argocdVersionResponse struct {
+	Version string `json:"Version"`
+}
+
+// CheckCompatibility probes the upstream ArgoCD version and sanity-checks that projects
+// and applications decode into the shape the proxy relies on, so that an upgrade that
+// changes the API surfaces as a degraded health status instead of silently empty data.
+// The report is kept for GetCompatibility and incompatibilities are logged prominently.
+// Connectivity failures are not incompatibilities; they leave the affected check out.
+func (s *ArgocdService) CheckCompatibility(ctx context.Context) types.CompatibilityReport {
+	report := types.CompatibilityReport{
+		Compatible:     true,
+		SupportedRange: SupportedVersionRange,
+		CheckedAt:      s.clock.Now().UTC(),
+	}
+	addProblem := func(format string, args ...interface{}) {
+		report.Compatible = false
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// Probes abandoned in the pool queue when ctx ends keep the not-run error
+	notRun := errors.New("probe cancelled before it ran")
+	var (
+		version      string
+		projects     []types.ArgocdProject
+		applications types.ArgocdApplicationList
+
+		versionErr, projectsErr, applicationsErr = notRun, notRun, notRun
+	)
+	// The probes fill the project and application caches, so at startup they double as
+	// the cache warm-up and run through the upstream pool like other fan-outs
+	s.pool.Run(ctx,
+		func(ctx context.Context) error {
+			version, versionErr = s.fetchArgocdVersion(ctx)
+			return versionErr
+		},
+		func(ctx context.Context) error {
+			projects, projectsErr = s.GetProjects(ctx)
+			return projectsErr
+		},
+		func(ctx context.Context) error {
+			applications, applicationsErr = s.GetApplications(ctx)
+			return applicationsErr
+		},
+	)
+
+	if versionErr != nil {
+		log.Printf("ArgoCD compatibility probe could not read the upstream version: %v", versionErr)
+	} else {
+		report.ArgocdVersion = version
+		if problem := checkVersionSupported(version); problem != "" {
+			addProblem("%s", problem)
+		}
+	}
+
+	if projectsErr != nil {
+		log.Printf("ArgoCD compatibility probe could not list projects: %v", projectsErr)
+	} else {
+		for i, project := range projects {
+			if project.Metadata.Name == "" {
+				addProblem("project %d of %d decoded without metadata.name", i, len(projects))
+				break
+			}
+		}
+	}
+
+	if applicationsErr != nil {
+		log.Printf("ArgoCD compatibility probe could not list applications: %v", applicationsErr)
+	} else {
+		for i, app := range applications.Items {
+			if app.Metadata.Name == "" || app.Spec.Project == "" {
+				addProblem("application %d of %d decoded without metadata.name or spec.project", i, len(applications.Items))
+				break
+			}
+		}
+	}
+
+	s.compatMu.Lock()
+	previous := s.compatibility
+	s.compatibility = &report
+	s.compatMu.Unlock()
+
+	if !report.Compatible {
+		log.Printf("WARNING: ArgoCD API incompatibility detected (version %q, supported %s): %s",
+			report.ArgocdVersion, SupportedVersionRange, strings.Join(report.Problems, "; "))
+		// Repeated probes keep finding the same problems; only the change is an event
+		if previous == nil || previous.Compatible {
+			events.Record(events.UpstreamIncompatible, "ArgoCD API incompatibility detected", map[string]string{
+				"version":  report.ArgocdVersion,
+				"problems": strings.Join(report.Problems, "; "),
+			})
+		}
+	} else if previous != nil && !previous.Compatible {
+		log.Printf("ArgoCD API compatibility restored (version %q)", report.ArgocdVersion)
+		events.Record(events.UpstreamCompatible, "ArgoCD API compatibility restored", map[string]string{"version": report.ArgocdVersion})
+	}
+	return report
+}
+
+// GetCompatibility returns the latest compatibility report, or nil before the first probe
+func (s *ArgocdService) GetCompatibility() *types.CompatibilityReport {
+	s.compatMu.RLock()
+	defer s.compatMu.RUnlock()
+
+	if s.compatibility == nil {
+		return nil
+	}
+	report := *s.compatibility
+	return &report
+}
+
+// RunCompatibilityChecks probes compatibility immediately and then every interval until
+// ctx is cancelled. A zero interval probes only once, at startup.
+func (s *ArgocdService) RunCompatibilityChecks(ctx context.Context, interval time.Duration) {
+	probe := func() {
+		probeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		s.CheckCompatibility(probeCtx)
+	}
+
+	probe()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			probe()
+		}
+	}
+}
+
+// fetchArgocdVersion reads the server version from ArgoCD's /api/version endpoint,
+// which sits beside rather than under the versioned /api/v1 prefix of the active URL
+func (s *ArgocdService) fetchArgocdVersion(ctx context.Context) (string, error) {
+	apiRoot := strings.TrimSuffix(strings.TrimSuffix(s.client.Endpoints().Active(), "/"), "/v1")
+
+	var body argocdVersionResponse
+	if err := s.client.GetJSON(ctx, "/version", &body, upstream.BaseURL(apiRoot)); err != nil {
+		return "", fmt.Errorf("version request failed: %w", err)
+	}
+	return body.Version, nil
+}
+
+// checkVersionSupported returns a problem description when version is outside the
+// supported range or cannot be parsed, and "" when it is supported
+func checkVersionSupported(version string) string {
+	parsed, ok := parseArgocdVersion(version)
+	if !ok {
+		return fmt.Sprintf("unrecognized ArgoCD version %q", version)
+	}
+	if compareVersions(parsed, minSupportedVersion) < 0 || compareVersions(parsed, maxSupportedVersion) >= 0 {
+		return fmt.Sprintf("ArgoCD version %s is outside the supported range %s", version, SupportedVersionRange)
+	}
+	return ""
+}
+
+// parseArgocdVersion parses versions such as "v2.10.4+a1b2c3d" or "2.9.0-rc1" into
+// major, minor and patch numbers, ignoring pre-release and build suffixes
+func parseArgocdVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "+-"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is lower than, equal to or higher than b
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
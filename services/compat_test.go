@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"argocd-proxy/config"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	const (
+		goodProjects     = `{"items":[{"metadata":{"name":"production"}}]}`
+		goodApplications = `{"items":[{"metadata":{"name":"web"},"spec":{"project":"production"}}]}`
+	)
+
+	tests := []struct {
+		name           string
+		versionStatus  int
+		version        string
+		projects       string
+		applications   string
+		wantCompatible bool
+		wantVersion    string
+		wantProblem    string
+	}{
+		{
+			name:           "supported version and schema",
+			versionStatus:  http.StatusOK,
+			version:        `{"Version":"v2.10.4+a1b2c3d"}`,
+			projects:       goodProjects,
+			applications:   goodApplications,
+			wantCompatible: true,
+			wantVersion:    "v2.10.4+a1b2c3d",
+		},
+		{
+			name:          "version too old",
+			versionStatus: http.StatusOK,
+			version:       `{"Version":"v2.3.17"}`,
+			projects:      goodProjects,
+			applications:  goodApplications,
+			wantVersion:   "v2.3.17",
+			wantProblem:   "outside the supported range",
+		},
+		{
+			name:          "version too new",
+			versionStatus: http.StatusOK,
+			version:       `{"Version":"v4.0.0"}`,
+			projects:      goodProjects,
+			applications:  goodApplications,
+			wantVersion:   "v4.0.0",
+			wantProblem:   "outside the supported range",
+		},
+		{
+			name:          "unexpected application schema",
+			versionStatus: http.StatusOK,
+			version:       `{"Version":"v2.10.4"}`,
+			projects:      goodProjects,
+			applications:  `{"items":[{"meta":{"appName":"web"},"definition":{"project":"production"}}]}`,
+			wantVersion:   "v2.10.4",
+			wantProblem:   "application 0 of 1 decoded without metadata.name or spec.project",
+		},
+		{
+			name:          "unexpected project schema",
+			versionStatus: http.StatusOK,
+			version:       `{"Version":"v2.10.4"}`,
+			projects:      `{"items":[{"meta":{"projectName":"production"}}]}`,
+			applications:  goodApplications,
+			wantVersion:   "v2.10.4",
+			wantProblem:   "project 0 of 1 decoded without metadata.name",
+		},
+		{
+			name:           "empty lists are compatible",
+			versionStatus:  http.StatusOK,
+			version:        `{"Version":"v3.1.0"}`,
+			projects:       `{"items":[]}`,
+			applications:   `{"items":[]}`,
+			wantCompatible: true,
+			wantVersion:    "v3.1.0",
+		},
+		{
+			name:           "unreadable version is not an incompatibility",
+			versionStatus:  http.StatusInternalServerError,
+			projects:       goodProjects,
+			applications:   goodApplications,
+			wantCompatible: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/version"):
+					w.WriteHeader(tt.versionStatus)
+					w.Write([]byte(tt.version))
+				case strings.HasSuffix(r.URL.Path, "/projects"):
+					w.Write([]byte(tt.projects))
+				case strings.HasSuffix(r.URL.Path, "/applications"):
+					w.Write([]byte(tt.applications))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL + "/api/v1"}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			if service.GetCompatibility() != nil {
+				t.Fatal("GetCompatibility() before the first probe should be nil")
+			}
+
+			report := service.CheckCompatibility(context.Background())
+			if report.Compatible != tt.wantCompatible {
+				t.Errorf("Compatible = %v, want %v (problems: %v)", report.Compatible, tt.wantCompatible, report.Problems)
+			}
+			if report.ArgocdVersion != tt.wantVersion {
+				t.Errorf("ArgocdVersion = %q, want %q", report.ArgocdVersion, tt.wantVersion)
+			}
+			if tt.wantProblem != "" && !strings.Contains(strings.Join(report.Problems, "; "), tt.wantProblem) {
+				t.Errorf("Problems = %v, want one containing %q", report.Problems, tt.wantProblem)
+			}
+			if stored := service.GetCompatibility(); stored == nil || stored.Compatible != report.Compatible {
+				t.Errorf("GetCompatibility() = %+v, want the latest report", stored)
+			}
+		})
+	}
+}
+
+func TestCheckVersionSupported(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"v2.4.0", true},
+		{"2.9.0-rc1", true},
+		{"v2.14.2+abc1234", true},
+		{"v3.0.6", true},
+		{"v2.3.99", false},
+		{"v4.0.0", false},
+		{"v1.8.7", false},
+		{"", false},
+		{"v2.10", false},
+		{"latest", false},
+	}
+
+	for _, tt := range tests {
+		if got := checkVersionSupported(tt.version) == ""; got != tt.want {
+			t.Errorf("checkVersionSupported(%q) supported = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
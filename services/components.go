@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// ArgoCD components reported by CheckComponents
+const (
+	ComponentAPI                   = "api"
+	ComponentRepoServer            = "repoServer"
+	ComponentApplicationController = "applicationController"
+)
+
+// Component statuses reported by CheckComponents
+const (
+	ComponentStatusOK       = "ok"
+	ComponentStatusDegraded = "degraded"
+	ComponentStatusUnknown  = "unknown"
+)
+
+// controllerStaleAfter is how long after the newest reconciliation the application
+// controller is considered stuck. ArgoCD reconciles every application at least every
+// three minutes by default, so this leaves room for jitter and large installations.
+const controllerStaleAfter = 10 * time.Minute
+
+// repoServerErrorCodes are the gRPC codes in ComparisonError messages that mean the
+// repo server could not be reached, as opposed to a manifest that fails to render
+var repoServerErrorCodes = []string{"code = Unavailable", "code = DeadlineExceeded"}
+
+// CheckComponents reports the status of ArgoCD's API server, repo server and application
+// controller. The API server is probed through /settings, which it answers without
+// calling other components. The repo server and controller are not reachable through
+// the API, so their status is inferred from the (usually cached) application list:
+// comparison errors caused by an unreachable repo server, and the age of the most recent
+// reconciliation. Both probes run through the upstream pool and are bounded by ctx.
+func (s *ArgocdService) CheckComponents(ctx context.Context) map[string]string {
+	var (
+		apiStatus    = ComponentStatusUnknown
+		applications types.ArgocdApplicationList
+	)
+
+	// The settings probe records its outcome in apiStatus, so any error is the list's
+	appsErr := s.pool.Run(ctx,
+		func(ctx context.Context) error {
+			apiStatus = ComponentStatusOK
+			if err := s.checkSettings(ctx); err != nil {
+				log.Printf("ArgoCD API server component check failed: %v", err)
+				apiStatus = ComponentStatusDegraded
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			applications, err = s.GetApplications(ctx)
+			return err
+		},
+	)
+
+	components := map[string]string{
+		ComponentAPI:                   apiStatus,
+		ComponentRepoServer:            ComponentStatusUnknown,
+		ComponentApplicationController: ComponentStatusUnknown,
+	}
+	if appsErr != nil {
+		log.Printf("ArgoCD component check could not list applications: %v", appsErr)
+		return components
+	}
+
+	components[ComponentRepoServer] = repoServerStatus(applications)
+	components[ComponentApplicationController] = controllerStatus(applications, s.clock.Now())
+	return components
+}
+
+// checkSettings verifies the API server answers its settings endpoint
+func (s *ArgocdService) checkSettings(ctx context.Context) error {
+	return s.client.GetJSON(ctx, "/settings", nil)
+}
+
+// repoServerStatus reports degraded when any application failed to compare because the
+// repo server was unreachable. Without applications there is nothing to infer from.
+func repoServerStatus(appList types.ArgocdApplicationList) string {
+	if len(appList.Items) == 0 {
+		return ComponentStatusUnknown
+	}
+	for _, app := range appList.Items {
+		for _, condition := range app.Status.Conditions {
+			fields, ok := condition.(map[string]interface{})
+			if !ok || fields["type"] != "ComparisonError" {
+				continue
+			}
+			message, _ := fields["message"].(string)
+			for _, code := range repoServerErrorCodes {
+				if strings.Contains(message, code) {
+					return ComponentStatusDegraded
+				}
+			}
+		}
+	}
+	return ComponentStatusOK
+}
+
+// controllerStatus reports degraded when no application has been reconciled within
+// controllerStaleAfter of now. Applications never reconciled are ignored.
+func controllerStatus(appList types.ArgocdApplicationList, now time.Time) string {
+	var latest time.Time
+	for _, app := range appList.Items {
+		if app.Status.ReconciledAt.After(latest) {
+			latest = app.Status.ReconciledAt
+		}
+	}
+	if latest.IsZero() {
+		return ComponentStatusUnknown
+	}
+	if now.Sub(latest) > controllerStaleAfter {
+		return ComponentStatusDegraded
+	}
+	return ComponentStatusOK
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func TestCheckComponents(t *testing.T) {
+	now := time.Now().UTC()
+	app := func(name string, reconciledAt time.Time, conditions ...interface{}) types.ArgocdApplication {
+		return types.ArgocdApplication{
+			Metadata: types.ArgocdApplicationMetadata{Name: name},
+			Spec:     types.ArgocdApplicationSpec{Project: "production"},
+			Status:   types.ArgocdApplicationStatus{ReconciledAt: reconciledAt, Conditions: conditions},
+		}
+	}
+	comparisonError := func(message string) interface{} {
+		return map[string]interface{}{"type": "ComparisonError", "message": message}
+	}
+
+	tests := []struct {
+		name           string
+		settingsStatus int
+		appsStatus     int
+		applications   []types.ArgocdApplication
+		want           map[string]string
+	}{
+		{
+			name:           "all ok",
+			settingsStatus: http.StatusOK,
+			appsStatus:     http.StatusOK,
+			applications:   []types.ArgocdApplication{app("web", now.Add(-time.Minute)), app("api", time.Time{})},
+			want:           map[string]string{"api": "ok", "repoServer": "ok", "applicationController": "ok"},
+		},
+		{
+			name:           "repo server unreachable",
+			settingsStatus: http.StatusOK,
+			appsStatus:     http.StatusOK,
+			applications: []types.ArgocdApplication{
+				app("web", now.Add(-time.Minute), comparisonError("rpc error: code = Unavailable desc = connection error: dial tcp 10.0.0.5:8081: connect: connection refused")),
+			},
+			want: map[string]string{"api": "ok", "repoServer": "degraded", "applicationController": "ok"},
+		},
+		{
+			name:           "broken manifests do not degrade repo server",
+			settingsStatus: http.StatusOK,
+			appsStatus:     http.StatusOK,
+			applications: []types.ArgocdApplication{
+				app("web", now.Add(-time.Minute), comparisonError("rpc error: code = Unknown desc = Manifest generation error")),
+			},
+			want: map[string]string{"api": "ok", "repoServer": "ok", "applicationController": "ok"},
+		},
+		{
+			name:           "stale reconciliation",
+			settingsStatus: http.StatusOK,
+			appsStatus:     http.StatusOK,
+			applications:   []types.ArgocdApplication{app("web", now.Add(-time.Hour))},
+			want:           map[string]string{"api": "ok", "repoServer": "ok", "applicationController": "degraded"},
+		},
+		{
+			name:           "no applications",
+			settingsStatus: http.StatusOK,
+			appsStatus:     http.StatusOK,
+			want:           map[string]string{"api": "ok", "repoServer": "unknown", "applicationController": "unknown"},
+		},
+		{
+			name:           "api server failing",
+			settingsStatus: http.StatusInternalServerError,
+			appsStatus:     http.StatusInternalServerError,
+			want:           map[string]string{"api": "degraded", "repoServer": "unknown", "applicationController": "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/settings"):
+					w.WriteHeader(tt.settingsStatus)
+					w.Write([]byte("{}"))
+				case strings.HasSuffix(r.URL.Path, "/applications"):
+					w.WriteHeader(tt.appsStatus)
+					json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: tt.applications})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			got := service.CheckComponents(context.Background())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
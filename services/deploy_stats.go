@@ -0,0 +1,101 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// defaultRevisionHistoryLimit is the number of history entries ArgoCD keeps when an
+// application does not set spec.revisionHistoryLimit
+const defaultRevisionHistoryLimit = 10
+
+// BuildApplicationDeployStats computes how often an application was deployed within the
+// window ending at now, from the deployments recorded in its status.history
+func BuildApplicationDeployStats(app types.ArgocdApplication, window time.Duration, now time.Time) types.ApplicationDeployStats {
+	since := now.Add(-window)
+	return types.ApplicationDeployStats{
+		Name:        app.Metadata.Name,
+		Project:     app.Spec.Project,
+		DeployStats: summarizeDeployments(deploymentTimes(app, since, now), since, window, historyTruncated(app, since)),
+	}
+}
+
+// BuildGroupDeployStats computes the deployment frequency of each application in the list
+// and of the group as a whole, treating the group's deployments as a single timeline
+func BuildGroupDeployStats(groupName string, appList types.ArgocdApplicationList, window time.Duration, now time.Time) types.GroupDeployStats {
+	since := now.Add(-window)
+	stats := types.GroupDeployStats{
+		Group:        groupName,
+		Applications: make([]types.ApplicationDeployStats, 0, len(appList.Items)),
+	}
+
+	var all []time.Time
+	truncated := false
+	for _, app := range appList.Items {
+		all = append(all, deploymentTimes(app, since, now)...)
+		appStats := BuildApplicationDeployStats(app, window, now)
+		truncated = truncated || appStats.Truncated
+		stats.Applications = append(stats.Applications, appStats)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Before(all[j]) })
+
+	stats.DeployStats = summarizeDeployments(all, since, window, truncated)
+	return stats
+}
+
+// deploymentTimes returns the sorted deployment times of app within [since, now]
+func deploymentTimes(app types.ArgocdApplication, since, now time.Time) []time.Time {
+	var times []time.Time
+	for _, entry := range app.Status.History {
+		if deployed := entry.DeployedAt; !deployed.IsZero() && !deployed.Before(since) && !deployed.After(now) {
+			times = append(times, deployed)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// historyTruncated reports whether ArgoCD may have dropped deployments within the window:
+// the history is at its retention limit and its oldest entry is inside the window
+func historyTruncated(app types.ArgocdApplication, since time.Time) bool {
+	limit := int64(defaultRevisionHistoryLimit)
+	if app.Spec.RevisionHistoryLimit != nil {
+		limit = *app.Spec.RevisionHistoryLimit
+	}
+	history := app.Status.History
+	if len(history) == 0 || int64(len(history)) < limit {
+		return false
+	}
+
+	oldest := history[0].DeployedAt
+	for _, entry := range history[1:] {
+		if entry.DeployedAt.Before(oldest) {
+			oldest = entry.DeployedAt
+		}
+	}
+	return oldest.After(since)
+}
+
+// summarizeDeployments aggregates sorted deployment times into deployment statistics
+func summarizeDeployments(times []time.Time, since time.Time, window time.Duration, truncated bool) types.DeployStats {
+	stats := types.DeployStats{
+		WindowStart:       since.UTC(),
+		WindowSeconds:     int64(window / time.Second),
+		Deployments:       len(times),
+		DeploymentsPerDay: float64(len(times)) / (window.Hours() / 24),
+		Truncated:         truncated,
+	}
+	if len(times) == 0 {
+		return stats
+	}
+
+	last := times[len(times)-1].UTC()
+	stats.LastDeployedAt = &last
+	if len(times) > 1 {
+		mean := last.Sub(times[0]).Seconds() / float64(len(times)-1)
+		stats.MeanIntervalSeconds = &mean
+	}
+	return stats
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// deployStatsNow is the fixed reference time for the deployment history fixture
+var deployStatsNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// deployHistoryFixture is a recorded group application list with multi-entry histories:
+// checkout deploys every two days, search's retained history is at its limit of three
+// entries and starts inside a 30 day window, and billing was never deployed
+const deployHistoryFixture = `{
+  "items": [
+    {
+      "metadata": {"name": "checkout"},
+      "spec": {"project": "shop"},
+      "status": {"history": [
+        {"id": 1, "revision": "a1", "deployedAt": "2024-04-01T12:00:00Z"},
+        {"id": 2, "revision": "a2", "deployedAt": "2024-05-26T12:00:00Z"},
+        {"id": 3, "revision": "a3", "deployedAt": "2024-05-28T12:00:00Z"},
+        {"id": 4, "revision": "a4", "deployedAt": "2024-05-30T12:00:00Z"}
+      ]}
+    },
+    {
+      "metadata": {"name": "search"},
+      "spec": {"project": "shop", "revisionHistoryLimit": 3},
+      "status": {"history": [
+        {"id": 7, "revision": "b7", "deployedAt": "2024-05-20T12:00:00Z"},
+        {"id": 8, "revision": "b8", "deployedAt": "2024-05-29T00:00:00Z"},
+        {"id": 9, "revision": "b9", "deployedAt": "2024-05-31T12:00:00Z"}
+      ]}
+    },
+    {
+      "metadata": {"name": "billing"},
+      "spec": {"project": "finance"},
+      "status": {}
+    }
+  ]
+}`
+
+func loadDeployHistoryFixture(t *testing.T) types.ArgocdApplicationList {
+	t.Helper()
+	var appList types.ArgocdApplicationList
+	if err := json.Unmarshal([]byte(deployHistoryFixture), &appList); err != nil {
+		t.Fatalf("failed to decode deployment history fixture: %v", err)
+	}
+	return appList
+}
+
+func TestBuildApplicationDeployStats(t *testing.T) {
+	apps := loadDeployHistoryFixture(t).Items
+	day := 24 * time.Hour
+	lastCheckout := time.Date(2024, 5, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		app           types.ArgocdApplication
+		window        time.Duration
+		wantCount     int
+		wantMean      float64
+		wantLast      *time.Time
+		wantTruncated bool
+	}{
+		{"entries outside the window are ignored", apps[0], 30 * day, 3, (2 * day).Seconds(), &lastCheckout, false},
+		{"window start is inclusive", apps[0], 4 * day, 2, (2 * day).Seconds(), &lastCheckout, false},
+		{"history at its limit inside the window", apps[1], 30 * day, 3, (5*day + 12*time.Hour).Seconds(), nil, true},
+		{"history at its limit before the window", apps[1], 7 * day, 2, (2*day + 12*time.Hour).Seconds(), nil, false},
+		{"never deployed", apps[2], 30 * day, 0, 0, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := BuildApplicationDeployStats(tt.app, tt.window, deployStatsNow)
+
+			if stats.Name != tt.app.Metadata.Name || stats.WindowSeconds != int64(tt.window/time.Second) {
+				t.Errorf("stats identify %s over %ds, want %s over %v", stats.Name, stats.WindowSeconds, tt.app.Metadata.Name, tt.window)
+			}
+			if stats.Deployments != tt.wantCount {
+				t.Errorf("deployments = %d, want %d", stats.Deployments, tt.wantCount)
+			}
+			if tt.wantCount < 2 {
+				if stats.MeanIntervalSeconds != nil {
+					t.Errorf("meanIntervalSeconds = %v, want null", *stats.MeanIntervalSeconds)
+				}
+			} else if stats.MeanIntervalSeconds == nil || *stats.MeanIntervalSeconds != tt.wantMean {
+				t.Errorf("meanIntervalSeconds = %v, want %v", stats.MeanIntervalSeconds, tt.wantMean)
+			}
+			if tt.wantLast != nil && (stats.LastDeployedAt == nil || !stats.LastDeployedAt.Equal(*tt.wantLast)) {
+				t.Errorf("lastDeployedAt = %v, want %v", stats.LastDeployedAt, tt.wantLast)
+			}
+			if tt.wantCount == 0 && stats.LastDeployedAt != nil {
+				t.Errorf("lastDeployedAt = %v, want null", stats.LastDeployedAt)
+			}
+			if stats.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", stats.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestBuildGroupDeployStats(t *testing.T) {
+	stats := BuildGroupDeployStats("Shop", loadDeployHistoryFixture(t), 30*24*time.Hour, deployStatsNow)
+
+	if stats.Group != "Shop" || len(stats.Applications) != 3 {
+		t.Fatalf("group stats = %s with %d applications, want Shop with 3", stats.Group, len(stats.Applications))
+	}
+	if stats.Deployments != 6 {
+		t.Errorf("deployments = %d, want 6", stats.Deployments)
+	}
+	if stats.DeploymentsPerDay != 0.2 {
+		t.Errorf("deploymentsPerDay = %v, want 0.2", stats.DeploymentsPerDay)
+	}
+	// Combined timeline runs from search's May 20 deployment to its May 31 deployment
+	wantMean := (11 * 24 * time.Hour).Seconds() / 5
+	if stats.MeanIntervalSeconds == nil || *stats.MeanIntervalSeconds != wantMean {
+		t.Errorf("meanIntervalSeconds = %v, want %v", stats.MeanIntervalSeconds, wantMean)
+	}
+	wantLast := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	if stats.LastDeployedAt == nil || !stats.LastDeployedAt.Equal(wantLast) {
+		t.Errorf("lastDeployedAt = %v, want %v", stats.LastDeployedAt, wantLast)
+	}
+	if !stats.Truncated {
+		t.Error("truncated = false, want true when any application's history is truncated")
+	}
+}
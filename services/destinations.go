@@ -0,0 +1,111 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"argocd-proxy/types"
+)
+
+// ReconcileProjectDestinations merges the destinations declared in a project's spec with
+// the destinations its applications actually deploy to. Declared entries come first in
+// spec order; observed destinations follow sorted by server, name and namespace. Observed
+// destinations not permitted by any declared entry are flagged as undeclared.
+func ReconcileProjectDestinations(project types.ArgocdProject, apps []types.ArgocdApplication) types.ProjectDestinationsResponse {
+	response := types.ProjectDestinationsResponse{
+		Project:      project.Metadata.Name,
+		Destinations: []types.ProjectDestination{},
+	}
+
+	index := make(map[string]int)
+	for _, dest := range project.Spec.Destinations {
+		key := destinationKey(dest.Server, dest.Name, dest.Namespace)
+		if _, seen := index[key]; seen {
+			continue
+		}
+		index[key] = len(response.Destinations)
+		response.Destinations = append(response.Destinations, types.ProjectDestination{
+			Server:       dest.Server,
+			Name:         dest.Name,
+			Namespace:    dest.Namespace,
+			Applications: []string{},
+		})
+	}
+
+	var observed []types.ProjectDestination
+	observedIndex := make(map[string]int)
+	for _, app := range apps {
+		dest := app.Spec.Destination
+		key := destinationKey(dest.Server, dest.Name, dest.Namespace)
+
+		if i, ok := index[key]; ok {
+			response.Destinations[i].Applications = append(response.Destinations[i].Applications, app.Metadata.Name)
+			continue
+		}
+		if i, ok := observedIndex[key]; ok {
+			observed[i].Applications = append(observed[i].Applications, app.Metadata.Name)
+			continue
+		}
+
+		observedIndex[key] = len(observed)
+		observed = append(observed, types.ProjectDestination{
+			Server:       dest.Server,
+			Name:         dest.Name,
+			Namespace:    dest.Namespace,
+			Undeclared:   !destinationPermitted(project.Spec.Destinations, dest),
+			Applications: []string{app.Metadata.Name},
+		})
+	}
+
+	sort.Slice(observed, func(i, j int) bool {
+		return destinationKey(observed[i].Server, observed[i].Name, observed[i].Namespace) <
+			destinationKey(observed[j].Server, observed[j].Name, observed[j].Namespace)
+	})
+
+	response.Destinations = append(response.Destinations, observed...)
+	return response
+}
+
+// destinationKey identifies a destination for deduplication
+func destinationKey(server, name, namespace string) string {
+	return server + "|" + name + "|" + namespace
+}
+
+// destinationPermitted reports whether any declared project destination allows dest,
+// honouring ArgoCD's '*' wildcards in server, name and namespace
+func destinationPermitted(declared []types.ArgocdProjectDestination, dest types.ArgocdApplicationDestination) bool {
+	for _, allowed := range declared {
+		clusterMatches := (dest.Server != "" && WildcardMatch(allowed.Server, dest.Server)) ||
+			(dest.Name != "" && WildcardMatch(allowed.Name, dest.Name))
+		if clusterMatches && WildcardMatch(allowed.Namespace, dest.Namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// WildcardMatch matches value against a pattern in which '*' matches any sequence of characters
+func WildcardMatch(pattern, value string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+func destinationApp(name, server, clusterName, namespace string) types.ArgocdApplication {
+	return types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: name},
+		Spec: types.ArgocdApplicationSpec{
+			Project:     "payments",
+			Destination: types.ArgocdApplicationDestination{Server: server, Name: clusterName, Namespace: namespace},
+		},
+	}
+}
+
+func TestReconcileProjectDestinations(t *testing.T) {
+	const inCluster = "https://kubernetes.default.svc"
+
+	project := types.ArgocdProject{
+		Metadata: types.ArgocdProjectMetadata{Name: "payments"},
+		Spec: types.ArgocdProjectSpec{Destinations: []types.ArgocdProjectDestination{
+			{Server: inCluster, Namespace: "payments"},
+			{Server: inCluster, Namespace: "payments"}, // duplicate
+			{Server: inCluster, Namespace: "payments-*"},
+			{Name: "edge", Namespace: "*"},
+		}},
+	}
+
+	apps := []types.ArgocdApplication{
+		destinationApp("api", inCluster, "", "payments"),
+		destinationApp("worker", inCluster, "", "payments"),
+		destinationApp("preview", inCluster, "", "payments-pr-42"),
+		destinationApp("cdn", "", "edge", "cache"),
+		destinationApp("rogue", inCluster, "", "kube-system"),
+		destinationApp("rogue-2", inCluster, "", "kube-system"),
+		destinationApp("remote", "https://other.example.com", "", "payments"),
+	}
+
+	got := ReconcileProjectDestinations(project, apps)
+
+	want := types.ProjectDestinationsResponse{
+		Project: "payments",
+		Destinations: []types.ProjectDestination{
+			{Server: inCluster, Namespace: "payments", Applications: []string{"api", "worker"}},
+			{Server: inCluster, Namespace: "payments-*", Applications: []string{}},
+			{Name: "edge", Namespace: "*", Applications: []string{}},
+			{Server: inCluster, Namespace: "kube-system", Undeclared: true, Applications: []string{"rogue", "rogue-2"}},
+			{Server: inCluster, Namespace: "payments-pr-42", Applications: []string{"preview"}},
+			{Server: "https://other.example.com", Namespace: "payments", Undeclared: true, Applications: []string{"remote"}},
+			{Name: "edge", Namespace: "cache", Applications: []string{"cdn"}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReconcileProjectDestinations() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReconcileProjectDestinationsEmpty(t *testing.T) {
+	got := ReconcileProjectDestinations(types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: "empty"}}, nil)
+	if got.Destinations == nil || len(got.Destinations) != 0 {
+		t.Errorf("ReconcileProjectDestinations() destinations = %#v, want empty non-nil slice", got.Destinations)
+	}
+}
+
+func TestReconcileProjectDestinationsNoSpecMarksAllUndeclared(t *testing.T) {
+	project := types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: "bare"}}
+	apps := []types.ArgocdApplication{destinationApp("api", "https://kubernetes.default.svc", "", "default")}
+
+	got := ReconcileProjectDestinations(project, apps)
+	if len(got.Destinations) != 1 || !got.Destinations[0].Undeclared {
+		t.Errorf("ReconcileProjectDestinations() = %+v, want a single undeclared destination", got.Destinations)
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{"payments", "payments", true},
+		{"payments", "payments-dev", false},
+		{"*", "anything", true},
+		{"*", "", true},
+		{"payments-*", "payments-pr-1", true},
+		{"payments-*", "ledger", false},
+		{"*-dev", "payments-dev", true},
+		{"https://*.example.com", "https://eu.example.com", true},
+		{"https://*.example.com", "https://eu.example.org", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxcyyb", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := WildcardMatch(tt.pattern, tt.value); got != tt.expected {
+			t.Errorf("WildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.expected)
+		}
+	}
+}
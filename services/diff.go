@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// GetApplicationDiff summarizes what syncing an application would change, from ArgoCD's
+// managed-resources API. Filtered applications are rejected before ArgoCD is asked.
+func (s *ArgocdService) GetApplicationDiff(ctx context.Context, name string) (types.ApplicationDiff, error) {
+	// Resolve the application first so that filtered projects are never exposed
+	app, err := s.GetApplication(ctx, name)
+	if err != nil {
+		return types.ApplicationDiff{}, err
+	}
+
+	var resources types.ArgocdManagedResourcesResponse
+	err = s.client.GetJSON(ctx, "/applications/"+name+"/managed-resources", &resources,
+		upstream.Project(app.Spec.Project),
+		upstream.Endpoint("/applications/:name/managed-resources"),
+		upstream.NotFound(fmt.Errorf("application '%s' %w", name, ErrNotFound)))
+	if err != nil {
+		return types.ApplicationDiff{}, err
+	}
+
+	return BuildApplicationDiff(name, resources), nil
+}
+
+// BuildApplicationDiff classifies each managed resource as added (only desired), pruned
+// (only live), modified or unchanged. Hook resources are not part of the desired state
+// and are skipped. Resources never renders as null.
+func BuildApplicationDiff(name string, resources types.ArgocdManagedResourcesResponse) types.ApplicationDiff {
+	diff := types.ApplicationDiff{
+		Application: name,
+		Resources:   []types.ApplicationDiffEntry{},
+	}
+
+	for _, resource := range resources.Items {
+		if resource.Hook {
+			continue
+		}
+
+		entry := types.ApplicationDiffEntry{
+			Group:     resource.Group,
+			Kind:      resource.Kind,
+			Name:      resource.Name,
+			Namespace: resource.Namespace,
+		}
+		switch desired, live := stateExists(resource.TargetState), stateExists(resource.LiveState); {
+		case desired && !live:
+			entry.Change = types.ResourceChangeAdded
+			diff.Added++
+		case !desired && live:
+			entry.Change = types.ResourceChangePruned
+			diff.Pruned++
+		case resource.Modified:
+			entry.Change = types.ResourceChangeModified
+			diff.Modified++
+		default:
+			entry.Change = types.ResourceChangeUnchanged
+			diff.Unchanged++
+		}
+		entry.Modified = entry.Change != types.ResourceChangeUnchanged
+
+		diff.Resources = append(diff.Resources, entry)
+	}
+
+	diff.HasChanges = diff.Added+diff.Modified+diff.Pruned > 0
+	return diff
+}
+
+// stateExists reports whether a JSON-encoded resource state describes a resource
+func stateExists(state string) bool {
+	state = strings.TrimSpace(state)
+	return state != "" && state != "null"
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// managedResourcesPayload is a response captured from ArgoCD's
+// /applications/{name}/managed-resources, with the states trimmed
+const managedResourcesPayload = `{
+  "items": [
+    {
+      "kind": "ConfigMap", "namespace": "web", "name": "web-config",
+      "targetState": "{\"apiVersion\":\"v1\",\"kind\":\"ConfigMap\",\"metadata\":{\"name\":\"web-config\"}}",
+      "liveState": "null",
+      "normalizedLiveState": "null"
+    },
+    {
+      "group": "apps", "kind": "Deployment", "namespace": "web", "name": "web",
+      "targetState": "{\"apiVersion\":\"apps/v1\",\"kind\":\"Deployment\",\"spec\":{\"replicas\":3}}",
+      "liveState": "{\"apiVersion\":\"apps/v1\",\"kind\":\"Deployment\",\"spec\":{\"replicas\":2}}",
+      "diff": "{\"spec\":{\"replicas\":3}}",
+      "modified": true
+    },
+    {
+      "kind": "Service", "namespace": "web", "name": "web",
+      "targetState": "{\"apiVersion\":\"v1\",\"kind\":\"Service\"}",
+      "liveState": "{\"apiVersion\":\"v1\",\"kind\":\"Service\"}"
+    },
+    {
+      "group": "batch", "kind": "CronJob", "namespace": "web", "name": "web-cleanup",
+      "targetState": "null",
+      "liveState": "{\"apiVersion\":\"batch/v1\",\"kind\":\"CronJob\"}",
+      "modified": true
+    },
+    {
+      "group": "batch", "kind": "Job", "namespace": "web", "name": "web-migrate",
+      "targetState": "{\"apiVersion\":\"batch/v1\",\"kind\":\"Job\"}",
+      "liveState": "null",
+      "hook": true
+    }
+  ]
+}`
+
+func TestGetApplicationDiff(t *testing.T) {
+	tests := []struct {
+		name            string
+		appProject      string
+		resourcesStatus int
+		expectError     bool
+		errorContains   string
+	}{
+		{
+			name:            "decodes captured payload",
+			appProject:      "production",
+			resourcesStatus: http.StatusOK,
+		},
+		{
+			name:            "filtered project is not exposed",
+			appProject:      "test-project",
+			resourcesStatus: http.StatusOK,
+			expectError:     true,
+			errorContains:   "filtered project",
+		},
+		{
+			name:            "application disappears",
+			appProject:      "production",
+			resourcesStatus: http.StatusNotFound,
+			expectError:     true,
+			errorContains:   "not found",
+		},
+		{
+			name:            "upstream error",
+			appProject:      "production",
+			resourcesStatus: http.StatusInternalServerError,
+			expectError:     true,
+			errorContains:   "status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resourcesRequested bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/applications/web":
+					json.NewEncoder(w).Encode(types.ArgocdApplication{
+						Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+						Spec:     types.ArgocdApplicationSpec{Project: tt.appProject},
+					})
+				case "/applications/web/managed-resources":
+					resourcesRequested = true
+					w.WriteHeader(tt.resourcesStatus)
+					fmt.Fprint(w, managedResourcesPayload)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL, IgnoredProjects: []string{"test-*"}}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			diff, err := service.GetApplicationDiff(context.Background(), "web")
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Fatalf("GetApplicationDiff() error = %v, want containing %q", err, tt.errorContains)
+				}
+				if tt.errorContains == "filtered project" && resourcesRequested {
+					t.Error("managed resources should not be requested for filtered applications")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetApplicationDiff() unexpected error: %v", err)
+			}
+
+			want := types.ApplicationDiff{
+				Application: "web",
+				HasChanges:  true,
+				Added:       1,
+				Modified:    1,
+				Pruned:      1,
+				Unchanged:   1,
+				Resources: []types.ApplicationDiffEntry{
+					{Kind: "ConfigMap", Name: "web-config", Namespace: "web", Change: types.ResourceChangeAdded, Modified: true},
+					{Group: "apps", Kind: "Deployment", Name: "web", Namespace: "web", Change: types.ResourceChangeModified, Modified: true},
+					{Kind: "Service", Name: "web", Namespace: "web", Change: types.ResourceChangeUnchanged},
+					{Group: "batch", Kind: "CronJob", Name: "web-cleanup", Namespace: "web", Change: types.ResourceChangePruned, Modified: true},
+				},
+			}
+			if !reflect.DeepEqual(diff, want) {
+				t.Errorf("GetApplicationDiff() = %+v, want %+v", diff, want)
+			}
+		})
+	}
+}
+
+func TestBuildApplicationDiffEmpty(t *testing.T) {
+	diff := BuildApplicationDiff("web", types.ArgocdManagedResourcesResponse{})
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"application":"web","hasChanges":false,"added":0,"modified":0,"pruned":0,"unchanged":0,"resources":[]}`
+	if string(data) != want {
+		t.Errorf("BuildApplicationDiff() JSON = %s, want %s", data, want)
+	}
+}
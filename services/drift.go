@@ -0,0 +1,56 @@
+package services
+
+import (
+	"argocd-proxy/types"
+)
+
+// syncStatusOutOfSync is the ArgoCD sync status reported for drifted applications
+const syncStatusOutOfSync = "OutOfSync"
+
+// BuildGroupDriftReport summarizes the out-of-sync applications of a group's application
+// list. Applications pending deletion are only counted as deleting: their drift no longer
+// matters and they are about to leave the group.
+func BuildGroupDriftReport(groupName string, appList types.ArgocdApplicationList) types.GroupDriftReport {
+	report := types.GroupDriftReport{
+		Group:        groupName,
+		Applications: []types.GroupDriftEntry{},
+	}
+
+	for _, app := range appList.Items {
+		if IsDeleting(app) {
+			report.Deleting++
+			continue
+		}
+		report.TotalApplications++
+		autoSync := AutoSync(app)
+		if autoSync {
+			report.AutoSync++
+		} else {
+			report.Manual++
+		}
+
+		if app.Status.Sync.Status != syncStatusOutOfSync {
+			continue
+		}
+
+		entry := types.GroupDriftEntry{
+			Name:            app.Metadata.Name,
+			Project:         app.Spec.Project,
+			CurrentRevision: app.Status.Sync.Revision,
+			TargetRevision:  app.Spec.Source.TargetRevision,
+			IngressURLs:     app.IngressURLs,
+			AutoSync:        autoSync,
+		}
+		if entry.IngressURLs == nil {
+			entry.IngressURLs = []string{}
+		}
+		if app.Status.OperationState != nil {
+			entry.LastOperationFinishedAt = app.Status.OperationState.FinishedAt
+		}
+
+		report.Applications = append(report.Applications, entry)
+	}
+
+	report.OutOfSync = len(report.Applications)
+	return report
+}
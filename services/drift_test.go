@@ -0,0 +1,129 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// driftFixture is a recorded group application list with mixed sync states
+const driftFixture = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "metadata": {"name": "payments-api"},
+      "spec": {
+        "project": "payments", "source": {"repoURL": "https://github.com/company/payments", "targetRevision": "v2.1.0"},
+        "syncPolicy": {"automated": {"prune": true, "selfHeal": true}}
+      },
+      "status": {
+        "sync": {"status": "OutOfSync", "revision": "a1b2c3d"},
+        "health": {"status": "Healthy"},
+        "operationState": {"phase": "Succeeded", "startedAt": "2024-05-01T10:00:00Z", "finishedAt": "2024-05-01T10:02:30Z", "syncResult": {"revision": "a1b2c3d"}}
+      },
+      "ingressUrls": ["https://payments.example.com"]
+    },
+    {
+      "metadata": {"name": "payments-worker"},
+      "spec": {"project": "payments", "source": {"repoURL": "https://github.com/company/payments", "targetRevision": "HEAD"}},
+      "status": {"sync": {"status": "Synced", "revision": "e4f5a6b"}, "health": {"status": "Healthy"}}
+    },
+    {
+      "metadata": {"name": "ledger"},
+      "spec": {
+        "project": "ledger", "source": {"repoURL": "https://github.com/company/ledger", "targetRevision": "main"},
+        "syncPolicy": {"automated": {"enabled": false}, "syncOptions": ["CreateNamespace=true"]}
+      },
+      "status": {"sync": {"status": "OutOfSync", "revision": "0f9e8d7"}, "health": {"status": "Degraded"}}
+    },
+    {
+      "metadata": {"name": "legacy-reports", "deletionTimestamp": "2024-05-02T08:00:00Z"},
+      "spec": {
+        "project": "payments", "source": {"repoURL": "https://github.com/company/reports", "targetRevision": "main"},
+        "syncPolicy": {"automated": {}}
+      },
+      "status": {"sync": {"status": "OutOfSync", "revision": "9a8b7c6"}, "health": {"status": "Progressing"}}
+    },
+    {
+      "metadata": {"name": "fraud-check"},
+      "spec": {"project": "payments", "source": {"repoURL": "https://github.com/company/fraud", "targetRevision": "v1.0.0"}},
+      "status": {"sync": {"status": "Unknown"}, "health": {"status": "Missing"}}
+    }
+  ]
+}`
+
+func TestBuildGroupDriftReport(t *testing.T) {
+	var appList types.ArgocdApplicationList
+	if err := json.Unmarshal([]byte(driftFixture), &appList); err != nil {
+		t.Fatalf("failed to decode drift fixture: %v", err)
+	}
+
+	report := BuildGroupDriftReport("Payments", appList)
+
+	if report.Group != "Payments" {
+		t.Errorf("BuildGroupDriftReport() group = %v, want Payments", report.Group)
+	}
+	if report.TotalApplications != 4 {
+		t.Errorf("BuildGroupDriftReport() totalApplications = %v, want 4", report.TotalApplications)
+	}
+	if report.OutOfSync != 2 {
+		t.Fatalf("BuildGroupDriftReport() outOfSync = %v, want 2", report.OutOfSync)
+	}
+	if report.Deleting != 1 {
+		t.Errorf("BuildGroupDriftReport() deleting = %v, want 1", report.Deleting)
+	}
+	// ledger turns automated sync off with enabled: false, and legacy-reports is deleting
+	if report.AutoSync != 1 || report.Manual != 3 {
+		t.Errorf("BuildGroupDriftReport() autoSync = %v, manual = %v, want 1 and 3", report.AutoSync, report.Manual)
+	}
+
+	first := report.Applications[0]
+	if first.Name != "payments-api" || first.Project != "payments" {
+		t.Errorf("BuildGroupDriftReport() first entry = %s/%s, want payments/payments-api", first.Project, first.Name)
+	}
+	if first.CurrentRevision != "a1b2c3d" || first.TargetRevision != "v2.1.0" {
+		t.Errorf("BuildGroupDriftReport() revisions = %s -> %s, want a1b2c3d -> v2.1.0", first.CurrentRevision, first.TargetRevision)
+	}
+	wantFinished := time.Date(2024, 5, 1, 10, 2, 30, 0, time.UTC)
+	if first.LastOperationFinishedAt == nil || !first.LastOperationFinishedAt.Equal(wantFinished) {
+		t.Errorf("BuildGroupDriftReport() lastOperationFinishedAt = %v, want %v", first.LastOperationFinishedAt, wantFinished)
+	}
+	if len(first.IngressURLs) != 1 || first.IngressURLs[0] != "https://payments.example.com" {
+		t.Errorf("BuildGroupDriftReport() ingressUrls = %v", first.IngressURLs)
+	}
+	if !first.AutoSync {
+		t.Errorf("BuildGroupDriftReport() payments-api autoSync = false, want true")
+	}
+
+	second := report.Applications[1]
+	if second.Name != "ledger" {
+		t.Errorf("BuildGroupDriftReport() second entry = %s, want ledger", second.Name)
+	}
+	if second.LastOperationFinishedAt != nil {
+		t.Errorf("BuildGroupDriftReport() ledger without operationState should have nil finish time")
+	}
+	if second.IngressURLs == nil {
+		t.Errorf("BuildGroupDriftReport() ingressUrls should be an empty list, not nil")
+	}
+	if second.AutoSync {
+		t.Errorf("BuildGroupDriftReport() ledger autoSync = true, want false")
+	}
+}
+
+func TestBuildGroupDriftReportAllSynced(t *testing.T) {
+	appList := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "app"}, Status: types.ArgocdApplicationStatus{Sync: types.ArgocdApplicationSync{Status: "Synced"}}},
+	}}
+
+	report := BuildGroupDriftReport("Frontend", appList)
+
+	if report.OutOfSync != 0 || len(report.Applications) != 0 {
+		t.Errorf("BuildGroupDriftReport() expected no drift, got %+v", report)
+	}
+	if report.Applications == nil {
+		t.Errorf("BuildGroupDriftReport() applications should be an empty list, not nil")
+	}
+}
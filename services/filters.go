@@ -0,0 +1,170 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"argocd-proxy/selector"
+	"argocd-proxy/types"
+)
+
+// ImageMatchMode controls how the image filter is compared against application images
+type ImageMatchMode string
+
+const (
+	// ImageMatchSubstring matches any image reference containing the filter value
+	ImageMatchSubstring ImageMatchMode = "substring"
+	// ImageMatchExact matches only image references equal to the filter value
+	ImageMatchExact ImageMatchMode = "exact"
+)
+
+// ApplicationFilter describes client-supplied filters applied on top of project filtering
+type ApplicationFilter struct {
+	// NamePrefix keeps applications whose name starts with the prefix, ignoring case
+	NamePrefix string
+	Image      string
+	ImageMatch ImageMatchMode
+	// Health and Sync keep applications whose status is any of the listed values
+	Health []string
+	Sync   []string
+	// ExcludeDeleting drops applications pending deletion
+	ExcludeDeleting bool
+	// DestCluster keeps applications deploying to the cluster, by name or server URL
+	DestCluster string
+	// Owner keeps applications whose OwnerAnnotation has exactly this value
+	Owner           string
+	OwnerAnnotation string
+	// Labels and Annotations keep applications whose labels and annotations satisfy the
+	// selectors
+	Labels      selector.Selector
+	Annotations selector.Selector
+	// AutoSync, when set, keeps the applications ArgoCD syncs automatically if true and
+	// the manual ones if false
+	AutoSync *bool
+}
+
+// IsEmpty reports whether the filter would keep every application
+func (f ApplicationFilter) IsEmpty() bool {
+	return f.NamePrefix == "" && f.Image == "" && len(f.Health) == 0 && len(f.Sync) == 0 && !f.ExcludeDeleting && f.DestCluster == "" && f.Owner == "" && f.Labels.IsEmpty() && f.Annotations.IsEmpty() && f.AutoSync == nil
+}
+
+// Apply returns a copy of the list containing only applications matching the filter.
+// When an image filter is set, matched images are recorded on each returned application.
+func (f ApplicationFilter) Apply(list types.ArgocdApplicationList) types.ArgocdApplicationList {
+	if f.IsEmpty() {
+		return list
+	}
+
+	var filteredApps []types.ArgocdApplication
+	for _, app := range list.Items {
+		if !HasNamePrefix(app.Metadata.Name, f.NamePrefix) {
+			continue
+		}
+		if f.ExcludeDeleting && IsDeleting(app) {
+			continue
+		}
+		if f.DestCluster != "" && !MatchesCluster(app.Spec.Destination, f.DestCluster) {
+			continue
+		}
+		if f.Owner != "" && app.Metadata.Annotations[f.OwnerAnnotation] != f.Owner {
+			continue
+		}
+		if !f.Labels.Matches(app.Metadata.Labels) || !f.Annotations.Matches(app.Metadata.Annotations) {
+			continue
+		}
+		if f.AutoSync != nil && AutoSync(app) != *f.AutoSync {
+			continue
+		}
+		if len(f.Health) > 0 && !containsString(f.Health, app.Status.Health.Status) {
+			continue
+		}
+		if len(f.Sync) > 0 && !containsString(f.Sync, app.Status.Sync.Status) {
+			continue
+		}
+		if f.Image != "" {
+			matched := f.matchImages(app)
+			if len(matched) == 0 {
+				continue
+			}
+			app.MatchedImages = matched
+		}
+		filteredApps = append(filteredApps, app)
+	}
+
+	return types.ArgocdApplicationList{
+		APIVersion: list.APIVersion,
+		Kind:       list.Kind,
+		Items:      filteredApps,
+		Metadata:   list.Metadata,
+	}
+}
+
+// IsDeleting reports whether an application has a deletion timestamp, meaning ArgoCD
+// still returns it while its finalizers clean up the deployed resources
+func IsDeleting(app types.ArgocdApplication) bool {
+	return app.Metadata.DeletionTimestamp != nil
+}
+
+// ApplicationNames returns the sorted names of the applications in the list
+func ApplicationNames(list types.ArgocdApplicationList) []string {
+	names := make([]string, 0, len(list.Items))
+	for _, app := range list.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FilterProjectsByNamePrefix returns the projects whose name starts with prefix, ignoring case
+func FilterProjectsByNamePrefix(projects []types.ArgocdProject, prefix string) []types.ArgocdProject {
+	if prefix == "" {
+		return projects
+	}
+
+	filtered := []types.ArgocdProject{}
+	for _, project := range projects {
+		if HasNamePrefix(project.Metadata.Name, prefix) {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered
+}
+
+// HasNamePrefix reports whether name starts with prefix, ignoring case.
+// An empty prefix matches every name.
+func HasNamePrefix(name, prefix string) bool {
+	return len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
+
+// matchImages returns the application's images that satisfy the image filter
+func (f ApplicationFilter) matchImages(app types.ArgocdApplication) []string {
+	if app.Status.Summary == nil {
+		return nil
+	}
+
+	var matched []string
+	for _, image := range app.Status.Summary.Images {
+		if matchesImage(image, f.Image, f.ImageMatch) {
+			matched = append(matched, image)
+		}
+	}
+	return matched
+}
+
+// matchesImage compares a single image reference against the filter value
+func matchesImage(image, filter string, mode ImageMatchMode) bool {
+	if mode == ImageMatchExact {
+		return image == filter
+	}
+	return strings.Contains(image, filter)
+}
+
+// containsString reports whether value is present in values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/selector"
+	"argocd-proxy/types"
+)
+
+func appWithImages(name string, images ...string) types.ArgocdApplication {
+	return types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: name},
+		Status: types.ArgocdApplicationStatus{
+			Summary: &types.ArgocdApplicationSummary{Images: images},
+		},
+	}
+}
+
+func TestApplicationFilterImage(t *testing.T) {
+	const digest = "sha256:4b1d8a1c2f0e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a392817060f5e"
+
+	list := types.ArgocdApplicationList{
+		APIVersion: "v1",
+		Kind:       "List",
+		Items: []types.ArgocdApplication{
+			appWithImages("web", "registry.example.com/team/web:1.4.2", "docker.io/library/nginx:1.25"),
+			appWithImages("api", "registry.example.com/team/api@"+digest),
+			appWithImages("worker", "ghcr.io/company/worker:2.0.0"),
+			{Metadata: types.ArgocdApplicationMetadata{Name: "no-summary"}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		filter        ApplicationFilter
+		expectedApps  []string
+		expectedMatch map[string][]string
+	}{
+		{
+			name:         "empty filter keeps everything",
+			filter:       ApplicationFilter{},
+			expectedApps: []string{"web", "api", "worker", "no-summary"},
+		},
+		{
+			name:          "substring matches registry prefix",
+			filter:        ApplicationFilter{Image: "registry.example.com/team", ImageMatch: ImageMatchSubstring},
+			expectedApps:  []string{"web", "api"},
+			expectedMatch: map[string][]string{"web": {"registry.example.com/team/web:1.4.2"}, "api": {"registry.example.com/team/api@" + digest}},
+		},
+		{
+			name:          "substring matches tag",
+			filter:        ApplicationFilter{Image: "nginx:1.25", ImageMatch: ImageMatchSubstring},
+			expectedApps:  []string{"web"},
+			expectedMatch: map[string][]string{"web": {"docker.io/library/nginx:1.25"}},
+		},
+		{
+			name:          "substring matches digest",
+			filter:        ApplicationFilter{Image: digest, ImageMatch: ImageMatchSubstring},
+			expectedApps:  []string{"api"},
+			expectedMatch: map[string][]string{"api": {"registry.example.com/team/api@" + digest}},
+		},
+		{
+			name:          "exact matches full registry-prefixed reference",
+			filter:        ApplicationFilter{Image: "registry.example.com/team/web:1.4.2", ImageMatch: ImageMatchExact},
+			expectedApps:  []string{"web"},
+			expectedMatch: map[string][]string{"web": {"registry.example.com/team/web:1.4.2"}},
+		},
+		{
+			name:          "exact matches digest-pinned reference",
+			filter:        ApplicationFilter{Image: "registry.example.com/team/api@" + digest, ImageMatch: ImageMatchExact},
+			expectedApps:  []string{"api"},
+			expectedMatch: map[string][]string{"api": {"registry.example.com/team/api@" + digest}},
+		},
+		{
+			name:         "exact does not match partial reference",
+			filter:       ApplicationFilter{Image: "team/web:1.4.2", ImageMatch: ImageMatchExact},
+			expectedApps: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.filter.Apply(list)
+
+			var names []string
+			for _, app := range result.Items {
+				names = append(names, app.Metadata.Name)
+				if tt.expectedMatch != nil && !reflect.DeepEqual(app.MatchedImages, tt.expectedMatch[app.Metadata.Name]) {
+					t.Errorf("Apply() matched images for %s = %v, want %v", app.Metadata.Name, app.MatchedImages, tt.expectedMatch[app.Metadata.Name])
+				}
+			}
+
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+			if result.APIVersion != list.APIVersion || result.Kind != list.Kind {
+				t.Errorf("Apply() did not preserve list apiVersion/kind")
+			}
+		})
+	}
+
+	// The source list must not be mutated by filtering
+	for _, app := range list.Items {
+		if app.MatchedImages != nil {
+			t.Errorf("Apply() mutated source application %s", app.Metadata.Name)
+		}
+	}
+}
+
+func TestApplicationFilterHealthAndSync(t *testing.T) {
+	withStatus := func(name, health, sync string, images ...string) types.ArgocdApplication {
+		app := appWithImages(name, images...)
+		app.Status.Health.Status = health
+		app.Status.Sync.Status = sync
+		return app
+	}
+
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		withStatus("a", "Healthy", "Synced", "nginx:1.25"),
+		withStatus("b", "Degraded", "OutOfSync", "nginx:1.25"),
+		withStatus("c", "Progressing", "OutOfSync", "redis:7"),
+		withStatus("d", "Degraded", "Synced", "redis:7"),
+	}}
+
+	tests := []struct {
+		name         string
+		filter       ApplicationFilter
+		expectedApps []string
+	}{
+		{"health only", ApplicationFilter{Health: []string{"Degraded"}}, []string{"b", "d"}},
+		{"health set", ApplicationFilter{Health: []string{"Degraded", "Progressing"}}, []string{"b", "c", "d"}},
+		{"sync only", ApplicationFilter{Sync: []string{"OutOfSync"}}, []string{"b", "c"}},
+		{"health and sync", ApplicationFilter{Health: []string{"Degraded"}, Sync: []string{"OutOfSync"}}, []string{"b"}},
+		{"health and image", ApplicationFilter{Health: []string{"Degraded"}, Image: "redis", ImageMatch: ImageMatchSubstring}, []string{"d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, app := range tt.filter.Apply(list).Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationFilterNamePrefix(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		appWithImages("Payments-API"),
+		appWithImages("payments-worker"),
+		appWithImages("pay"),
+		appWithImages("checkout"),
+	}}
+
+	tests := []struct {
+		name         string
+		prefix       string
+		expectedApps []string
+	}{
+		{"lower-case prefix matches mixed case", "payments", []string{"Payments-API", "payments-worker"}},
+		{"upper-case prefix", "PAYMENTS-A", []string{"Payments-API"}},
+		{"prefix longer than name", "payment", []string{"Payments-API", "payments-worker"}},
+		{"no match", "inventory", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, app := range (ApplicationFilter{NamePrefix: tt.prefix}).Apply(list).Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationFilterExcludeDeleting(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	deleting := appWithImages("legacy-reports")
+	deleting.Metadata.DeletionTimestamp = &deletedAt
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{appWithImages("web"), deleting}}
+
+	tests := []struct {
+		name         string
+		filter       ApplicationFilter
+		expectedApps []string
+	}{
+		{"deleting applications are listed by default", ApplicationFilter{}, []string{"web", "legacy-reports"}},
+		{"excluded on request", ApplicationFilter{ExcludeDeleting: true}, []string{"web"}},
+		{"combined with name prefix", ApplicationFilter{ExcludeDeleting: true, NamePrefix: "legacy"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, app := range tt.filter.Apply(list).Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationFilterSelectors(t *testing.T) {
+	app := func(name string, labels, annotations map[string]string) types.ArgocdApplication {
+		a := appWithImages(name)
+		a.Metadata.Labels = labels
+		a.Metadata.Annotations = annotations
+		return a
+	}
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		app("payments-api", map[string]string{"team": "payments", "tier": "public"}, map[string]string{"deploy.freeze": "true"}),
+		app("payments-ledger", map[string]string{"team": "payments", "tier": "internal"}, nil),
+		app("web", map[string]string{"team": "web"}, map[string]string{"deploy.freeze": "false"}),
+		app("unlabelled", nil, nil),
+	}}
+	mustParse := func(s string) selector.Selector {
+		parsed, err := selector.Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", s, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name         string
+		filter       ApplicationFilter
+		expectedApps []string
+	}{
+		{"label equality and inequality", ApplicationFilter{Labels: mustParse("team=payments,tier!=internal")}, []string{"payments-api"}},
+		{"inequality keeps applications without the label", ApplicationFilter{Labels: mustParse("team!=payments")}, []string{"web", "unlabelled"}},
+		{"label set", ApplicationFilter{Labels: mustParse("team in (web,platform)")}, []string{"web"}},
+		{"label absence", ApplicationFilter{Labels: mustParse("!team")}, []string{"unlabelled"}},
+		{"annotation", ApplicationFilter{Annotations: mustParse("deploy.freeze=true")}, []string{"payments-api"}},
+		{"annotation existence", ApplicationFilter{Annotations: mustParse("deploy.freeze")}, []string{"payments-api", "web"}},
+		{"labels and annotations", ApplicationFilter{Labels: mustParse("team=payments"), Annotations: mustParse("!deploy.freeze")}, []string{"payments-ledger"}},
+		{"combined with name prefix", ApplicationFilter{Labels: mustParse("team"), NamePrefix: "payments-l"}, []string{"payments-ledger"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.IsEmpty() {
+				t.Fatal("IsEmpty() = true, want false with a selector")
+			}
+			var names []string
+			for _, app := range tt.filter.Apply(list).Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestApplicationFilterAutoSync(t *testing.T) {
+	automated := appWithImages("web")
+	automated.Spec.SyncPolicy = &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{Prune: true}}
+	disabled := appWithImages("api")
+	disabled.Spec.SyncPolicy = &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{Enabled: new(false)}}
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{automated, disabled, appWithImages("worker")}}
+
+	tests := []struct {
+		name         string
+		filter       ApplicationFilter
+		expectedApps []string
+	}{
+		{"unset keeps all", ApplicationFilter{}, []string{"web", "api", "worker"}},
+		{"auto-sync", ApplicationFilter{AutoSync: new(true)}, []string{"web"}},
+		{"manual", ApplicationFilter{AutoSync: new(false)}, []string{"api", "worker"}},
+		{"combined with name prefix", ApplicationFilter{AutoSync: new(false), NamePrefix: "w"}, []string{"worker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, app := range tt.filter.Apply(list).Items {
+				names = append(names, app.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedApps) {
+				t.Errorf("Apply() apps = %v, want %v", names, tt.expectedApps)
+			}
+		})
+	}
+}
+
+func TestFilterProjectsByNamePrefix(t *testing.T) {
+	projects := []types.ArgocdProject{
+		{Metadata: types.ArgocdProjectMetadata{Name: "Platform"}},
+		{Metadata: types.ArgocdProjectMetadata{Name: "platform-tools"}},
+		{Metadata: types.ArgocdProjectMetadata{Name: "payments"}},
+	}
+
+	var names []string
+	for _, project := range FilterProjectsByNamePrefix(projects, "PLAT") {
+		names = append(names, project.Metadata.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Platform", "platform-tools"}) {
+		t.Errorf("FilterProjectsByNamePrefix() = %v, want [Platform platform-tools]", names)
+	}
+
+	if got := FilterProjectsByNamePrefix(projects, ""); len(got) != len(projects) {
+		t.Errorf("FilterProjectsByNamePrefix() with empty prefix = %d projects, want %d", len(got), len(projects))
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"sync"
+
+	"argocd-proxy/types"
+)
+
+// Overall health statuses recorded in the health history, matching /health
+const (
+	HealthStatusHealthy  = "healthy"
+	HealthStatusDegraded = "degraded"
+)
+
+// HealthHistory is a bounded, thread-safe ring buffer of the most recent health checks
+type HealthHistory struct {
+	mu      sync.Mutex
+	records []types.HealthCheckRecord
+	// next is the slot the next record is written to
+	next  int
+	count int
+}
+
+// NewHealthHistory creates a history keeping the last size checks. A size of 0 disables recording.
+func NewHealthHistory(size int) *HealthHistory {
+	return &HealthHistory{records: make([]types.HealthCheckRecord, size)}
+}
+
+// Record stores a check, overwriting the oldest one once the buffer is full
+func (h *HealthHistory) Record(record types.HealthCheckRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.records) == 0 {
+		return
+	}
+
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	if h.count < len(h.records) {
+		h.count++
+	}
+}
+
+// Snapshot returns the recorded checks oldest first together with the uptime percentage
+// over the window and the time of the last status transition within it
+func (h *HealthHistory) Snapshot() types.HealthHistoryResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	response := types.HealthHistoryResponse{
+		Capacity: len(h.records),
+		Checks:   make([]types.HealthCheckRecord, 0, h.count),
+	}
+
+	start := (h.next - h.count + len(h.records)) % max(len(h.records), 1)
+	healthy := 0
+	for i := 0; i < h.count; i++ {
+		record := h.records[(start+i)%len(h.records)]
+		if record.Status == HealthStatusHealthy {
+			healthy++
+		}
+		if i > 0 && record.Status != response.Checks[i-1].Status {
+			transition := record.Timestamp
+			response.LastTransition = &transition
+		}
+		response.Checks = append(response.Checks, record)
+	}
+
+	if h.count > 0 {
+		response.UptimePercent = float64(healthy) * 100 / float64(h.count)
+	}
+	return response
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func TestHealthHistoryAggregates(t *testing.T) {
+	history := NewHealthHistory(10)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Alternate pass, fail, pass, fail, then two passes
+	statuses := []string{HealthStatusHealthy, HealthStatusDegraded, HealthStatusHealthy, HealthStatusDegraded, HealthStatusHealthy, HealthStatusHealthy}
+	for i, status := range statuses {
+		history.Record(types.HealthCheckRecord{Timestamp: start.Add(time.Duration(i) * time.Minute), Status: status})
+	}
+
+	snapshot := history.Snapshot()
+	if len(snapshot.Checks) != len(statuses) {
+		t.Fatalf("Snapshot() checks = %d, want %d", len(snapshot.Checks), len(statuses))
+	}
+	if want := float64(4) * 100 / 6; snapshot.UptimePercent != want {
+		t.Errorf("Snapshot() uptime = %v, want %v", snapshot.UptimePercent, want)
+	}
+	// The last change is degraded -> healthy at the fifth check
+	if snapshot.LastTransition == nil || !snapshot.LastTransition.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("Snapshot() last transition = %v, want %v", snapshot.LastTransition, start.Add(4*time.Minute))
+	}
+}
+
+func TestHealthHistoryBounded(t *testing.T) {
+	history := NewHealthHistory(3)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		status := HealthStatusHealthy
+		if i%2 == 1 {
+			status = HealthStatusDegraded
+		}
+		history.Record(types.HealthCheckRecord{Timestamp: start.Add(time.Duration(i) * time.Minute), Status: status})
+	}
+
+	snapshot := history.Snapshot()
+	if snapshot.Capacity != 3 || len(snapshot.Checks) != 3 {
+		t.Fatalf("Snapshot() capacity/checks = %d/%d, want 3/3", snapshot.Capacity, len(snapshot.Checks))
+	}
+	// Oldest first: checks 2, 3 and 4 survive
+	for i, check := range snapshot.Checks {
+		if want := start.Add(time.Duration(i+2) * time.Minute); !check.Timestamp.Equal(want) {
+			t.Errorf("Snapshot() check[%d] = %v, want %v", i, check.Timestamp, want)
+		}
+	}
+	if want := float64(2) * 100 / 3; snapshot.UptimePercent != want {
+		t.Errorf("Snapshot() uptime = %v, want %v", snapshot.UptimePercent, want)
+	}
+}
+
+func TestHealthHistoryEmptyAndDisabled(t *testing.T) {
+	for _, size := range []int{0, 5} {
+		history := NewHealthHistory(size)
+		if size == 0 {
+			history.Record(types.HealthCheckRecord{Status: HealthStatusHealthy})
+		}
+
+		snapshot := history.Snapshot()
+		if len(snapshot.Checks) != 0 || snapshot.UptimePercent != 0 || snapshot.LastTransition != nil {
+			t.Errorf("Snapshot() with size %d = %+v, want no checks", size, snapshot)
+		}
+	}
+}
+
+func TestHealthHistoryConcurrent(t *testing.T) {
+	history := NewHealthHistory(16)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				history.Record(types.HealthCheckRecord{Timestamp: time.Now(), Status: HealthStatusHealthy})
+				history.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(history.Snapshot().Checks); got != 16 {
+		t.Errorf("Snapshot() checks = %d, want 16", got)
+	}
+}
+
+func TestHealthCheckRecordsHistory(t *testing.T) {
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, HealthHistorySize: 10}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		service.HealthCheck(ctx)
+		fail = !fail
+	}
+
+	history := service.GetHealthHistory()
+	if len(history.Checks) != 4 {
+		t.Fatalf("GetHealthHistory() checks = %d, want 4", len(history.Checks))
+	}
+	if history.Checks[0].Status != HealthStatusDegraded || history.Checks[0].Error == "" {
+		t.Errorf("GetHealthHistory() first check = %+v, want degraded with error", history.Checks[0])
+	}
+	if history.Checks[1].Status != HealthStatusHealthy || history.Checks[1].Error != "" {
+		t.Errorf("GetHealthHistory() second check = %+v, want healthy without error", history.Checks[1])
+	}
+	if history.UptimePercent != 50 {
+		t.Errorf("GetHealthHistory() uptime = %v, want 50", history.UptimePercent)
+	}
+	if history.LastTransition == nil || !history.LastTransition.Equal(history.Checks[3].Timestamp) {
+		t.Errorf("GetHealthHistory() last transition = %v, want time of the last check", history.LastTransition)
+	}
+}
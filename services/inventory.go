@@ -0,0 +1,81 @@
+package services
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// BuildInventory lists the images, external URLs, source and health of each application
+// by project. When group is not nil only the applications its project listing shows are
+// kept, and when project is not empty only the applications of that project. Slices are
+// never nil so they render as [].
+func BuildInventory(list types.ArgocdApplicationList, group *config.ProjectGroup, project string, now time.Time) types.InventoryResponse {
+	response := types.InventoryResponse{
+		GeneratedAt: now.UTC(),
+		Projects:    make(map[string]types.InventoryProject),
+	}
+	for _, app := range list.Items {
+		if project != "" && app.Spec.Project != project {
+			continue
+		}
+		if group != nil {
+			if !slices.Contains(group.Projects, app.Spec.Project) {
+				continue
+			}
+			if _, ignored := group.MatchApplicationIgnore(app.Metadata.Name, app.Spec.Project); ignored {
+				continue
+			}
+		}
+
+		entry := response.Projects[app.Spec.Project]
+		entry.Applications = append(entry.Applications, InventoryApplication(app))
+		response.Projects[app.Spec.Project] = entry
+		response.ApplicationCount++
+	}
+
+	for name, entry := range response.Projects {
+		sort.Slice(entry.Applications, func(i, j int) bool {
+			a, b := entry.Applications[i], entry.Applications[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Namespace < b.Namespace
+		})
+		response.Projects[name] = entry
+	}
+	response.ProjectCount = len(response.Projects)
+	return response
+}
+
+// InventoryApplication returns the inventory entry of an application
+func InventoryApplication(app types.ArgocdApplication) types.InventoryApplication {
+	entry := types.InventoryApplication{
+		Name:        app.Metadata.Name,
+		Namespace:   app.Metadata.Namespace,
+		Images:      []string{},
+		IngressURLs: sortedCopy(app.IngressURLs),
+		Revision:    app.Status.Sync.Revision,
+		Health:      app.Status.Health.Status,
+	}
+	if app.Status.Summary != nil {
+		entry.Images = sortedCopy(app.Status.Summary.Images)
+	}
+	if sources := applicationSources(app); len(sources) > 0 {
+		entry.Repo = sources[0].RepoURL
+		if entry.Revision == "" {
+			entry.Revision = sources[0].TargetRevision
+		}
+	}
+	return entry
+}
+
+// sortedCopy returns a sorted copy of values, empty rather than nil
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
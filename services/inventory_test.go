@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// inventoryApp builds an application deploying images from repoURL
+func inventoryApp(name, project, repoURL string, images ...string) types.ArgocdApplication {
+	app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"}}
+	app.Spec.Project = project
+	app.Spec.Source = types.ArgocdApplicationSource{RepoURL: repoURL, TargetRevision: "main"}
+	app.Status.Summary = &types.ArgocdApplicationSummary{Images: images}
+	app.Status.Health.Status = "Healthy"
+	return app
+}
+
+func TestBuildInventory(t *testing.T) {
+	web := inventoryApp("web", "frontend", "https://github.com/company/web.git", "nginx:1.27", "company/web:2.1.0")
+	web.IngressURLs = []string{"https://web.example.com", "https://admin.example.com"}
+	web.Status.Sync.Revision = "4f1c2d3"
+	api := inventoryApp("api", "backend", "https://github.com/company/api.git")
+	api.Status.Health.Status = "Degraded"
+	worker := inventoryApp("worker", "backend", "")
+	worker.Spec.Sources = []types.ArgocdApplicationSource{
+		{RepoURL: "https://github.com/company/worker.git", TargetRevision: "v3"},
+		{RepoURL: "https://charts.example.com", TargetRevision: "1.0.0"},
+	}
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{worker, web, api}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	got := BuildInventory(list, nil, "", now)
+	want := types.InventoryResponse{
+		GeneratedAt: now.UTC(),
+		Projects: map[string]types.InventoryProject{
+			"frontend": {Applications: []types.InventoryApplication{{
+				Name: "web", Namespace: "argocd",
+				Images:      []string{"company/web:2.1.0", "nginx:1.27"},
+				IngressURLs: []string{"https://admin.example.com", "https://web.example.com"},
+				Repo:        "https://github.com/company/web.git", Revision: "4f1c2d3", Health: "Healthy",
+			}}},
+			"backend": {Applications: []types.InventoryApplication{
+				{Name: "api", Namespace: "argocd", Images: []string{}, IngressURLs: []string{}, Repo: "https://github.com/company/api.git", Revision: "main", Health: "Degraded"},
+				{Name: "worker", Namespace: "argocd", Images: []string{}, IngressURLs: []string{}, Repo: "https://github.com/company/worker.git", Revision: "v3", Health: "Healthy"},
+			}},
+		},
+		ProjectCount:     2,
+		ApplicationCount: 3,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildInventory() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestBuildInventoryScoping(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		inventoryApp("web", "frontend", "https://github.com/company/web.git"),
+		inventoryApp("web-preview", "frontend", "https://github.com/company/web.git"),
+		inventoryApp("api", "backend", "https://github.com/company/api.git"),
+		inventoryApp("billing", "payments", "https://github.com/company/billing.git"),
+	}}
+	group := &config.ProjectGroup{Name: "Product", Projects: []string{"frontend", "backend"}, IgnoredProjects: []string{"*-preview"}}
+
+	tests := []struct {
+		name    string
+		group   *config.ProjectGroup
+		project string
+		want    map[string][]string
+	}{
+		{name: "everything", want: map[string][]string{"frontend": {"web", "web-preview"}, "backend": {"api"}, "payments": {"billing"}}},
+		{name: "group", group: group, want: map[string][]string{"frontend": {"web"}, "backend": {"api"}}},
+		{name: "project", project: "payments", want: map[string][]string{"payments": {"billing"}}},
+		{name: "project outside the group", group: group, project: "payments", want: map[string][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := BuildInventory(list, tt.group, tt.project, time.Now())
+			got := make(map[string][]string)
+			for project, entry := range inventory.Projects {
+				for _, app := range entry.Applications {
+					got[project] = append(got[project], app.Name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applications = %v, want %v", got, tt.want)
+			}
+			if inventory.ProjectCount != len(tt.want) {
+				t.Errorf("projectCount = %d, want %d", inventory.ProjectCount, len(tt.want))
+			}
+		})
+	}
+}
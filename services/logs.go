@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// StreamApplicationLogs reads the container logs of an application from ArgoCD without
+// following them. The returned reader yields one line of plain text per log entry as
+// ArgoCD sends it, so that callers can relay the logs without buffering them. Filtered
+// applications are rejected before ArgoCD is asked.
+func (s *ArgocdService) StreamApplicationLogs(ctx context.Context, name string, opts types.ApplicationLogOptions) (io.ReadCloser, error) {
+	// Resolve the application first so that filtered projects are never exposed
+	app, err := s.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []upstream.Option{
+		upstream.Project(app.Spec.Project),
+		upstream.Endpoint("/applications/:name/logs"),
+		upstream.NotFound(fmt.Errorf("application '%s' %w", name, ErrNotFound)),
+		upstream.Query("follow", "false"),
+		upstream.Query("tailLines", strconv.Itoa(opts.TailLines)),
+	}
+	if opts.Container != "" {
+		options = append(options, upstream.Query("container", opts.Container))
+	}
+	if opts.PodName != "" {
+		options = append(options, upstream.Query("podName", opts.PodName))
+	}
+	body, err := s.client.Stream(ctx, "/applications/"+name+"/logs", options...)
+	if err != nil {
+		return nil, err
+	}
+	return &logLines{body: body, decoder: json.NewDecoder(body)}, nil
+}
+
+// logLines reads ArgoCD's newline-delimited JSON log stream as plain text, decoding one
+// entry at a time so that each line is available as soon as ArgoCD sends it
+type logLines struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	// pending is the part of the current line not read yet
+	pending []byte
+}
+
+func (l *logLines) Read(p []byte) (int, error) {
+	for len(l.pending) == 0 {
+		var entry types.ArgocdLogEntry
+		if err := l.decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, upstream.ErrUpstreamTooLarge) {
+				return 0, err
+			}
+			return 0, fmt.Errorf("failed to decode /applications/:name/logs response: %w", err)
+		}
+		if entry.Error != nil {
+			return 0, fmt.Errorf("ArgoCD log stream failed: %s", entry.Error.Message)
+		}
+		// The final entry only marks the end of the stream
+		if entry.Result == nil || (entry.Result.Last && entry.Result.Content == "") {
+			continue
+		}
+		l.pending = append(append(l.pending[:0], entry.Result.Content...), '\n')
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+func (l *logLines) Close() error {
+	return l.body.Close()
+}
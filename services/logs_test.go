@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+// logsPayload is a non-followed stream captured from ArgoCD's /applications/{name}/logs,
+// ending with the entry marking its end
+const logsPayload = `{"result":{"content":"starting server","timeStamp":"2024-05-01T10:00:00Z","podName":"web-7d9f","timeStampStr":"2024-05-01T10:00:00.000000000Z"}}
+{"result":{"content":"listening on :8080","timeStamp":"2024-05-01T10:00:01Z","podName":"web-7d9f","timeStampStr":"2024-05-01T10:00:01.000000000Z"}}
+{"result":{"content":"","timeStamp":"0001-01-01T00:00:00Z","last":true,"timeStampStr":""}}
+`
+
+func TestStreamApplicationLogs(t *testing.T) {
+	tests := []struct {
+		name          string
+		appProject    string
+		logsStatus    int
+		logsBody      string
+		want          string
+		errorContains string
+		readContains  string
+	}{
+		{
+			name:       "relays the log lines as text",
+			appProject: "production",
+			logsStatus: http.StatusOK,
+			logsBody:   logsPayload,
+			want:       "starting server\nlistening on :8080\n",
+		},
+		{
+			name:          "filtered project is not exposed",
+			appProject:    "test-project",
+			logsStatus:    http.StatusOK,
+			logsBody:      logsPayload,
+			errorContains: "filtered project",
+		},
+		{
+			name:          "application disappears",
+			appProject:    "production",
+			logsStatus:    http.StatusNotFound,
+			errorContains: "not found",
+		},
+		{
+			name:          "upstream error",
+			appProject:    "production",
+			logsStatus:    http.StatusInternalServerError,
+			logsBody:      `{"error":"pods not found"}`,
+			errorContains: "status 500",
+		},
+		{
+			name:         "error entry ends the stream",
+			appProject:   "production",
+			logsStatus:   http.StatusOK,
+			logsBody:     `{"result":{"content":"starting server"}}` + "\n" + `{"error":{"grpc_code":2,"message":"container web is terminated"}}` + "\n",
+			want:         "starting server\n",
+			readContains: "container web is terminated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logsQuery url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/applications/web":
+					json.NewEncoder(w).Encode(types.ArgocdApplication{
+						Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+						Spec:     types.ArgocdApplicationSpec{Project: tt.appProject},
+					})
+				case "/applications/web/logs":
+					logsQuery = r.URL.Query()
+					w.WriteHeader(tt.logsStatus)
+					fmt.Fprint(w, tt.logsBody)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArgocdAPIURL: server.URL, IgnoredProjects: []string{"test-*"}}
+			service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+			body, err := service.StreamApplicationLogs(context.Background(), "web", types.ApplicationLogOptions{Container: "web", TailLines: 50})
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Fatalf("StreamApplicationLogs() error = %v, want containing %q", err, tt.errorContains)
+				}
+				if tt.errorContains == "filtered project" && logsQuery != nil {
+					t.Error("logs should not be requested for filtered applications")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StreamApplicationLogs() unexpected error: %v", err)
+			}
+			defer body.Close()
+
+			if got := logsQuery.Get("follow"); got != "false" {
+				t.Errorf("follow = %q, want false", got)
+			}
+			if logsQuery.Get("tailLines") != "50" || logsQuery.Get("container") != "web" || logsQuery.Has("podName") {
+				t.Errorf("query = %v, want tailLines=50 and container=web only", logsQuery)
+			}
+
+			got, err := io.ReadAll(body)
+			if tt.readContains == "" && err != nil {
+				t.Fatalf("reading logs failed: %v", err)
+			}
+			if tt.readContains != "" && (err == nil || !strings.Contains(err.Error(), tt.readContains)) {
+				t.Errorf("read error = %v, want containing %q", err, tt.readContains)
+			}
+			if string(got) != tt.want {
+				t.Errorf("logs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"argocd-proxy/types"
+)
+
+// notificationSubscribePrefix starts the annotation keys subscribing to ArgoCD
+// notifications: "<prefix><trigger>.<service>" or "<prefix><service>" for the service's
+// default triggers, with the channels as a ";"-separated value
+const notificationSubscribePrefix = "notifications.argoproj.io/subscribe."
+
+// ApplicationNotifications lists the notification subscriptions declared by the
+// annotations of app, one per channel, ordered by annotation then channel. Subscribe
+// annotations that cannot be parsed are reported as malformed and never subscribe.
+func ApplicationNotifications(app types.ArgocdApplication) types.ApplicationNotifications {
+	response := types.ApplicationNotifications{
+		Application:   app.Metadata.Name,
+		Subscriptions: []types.NotificationSubscription{},
+	}
+
+	keys := make([]string, 0, len(app.Metadata.Annotations))
+	for key := range app.Metadata.Annotations {
+		if strings.HasPrefix(key, notificationSubscribePrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := app.Metadata.Annotations[key]
+		subscriptions, reason := ParseNotificationAnnotation(key, value)
+		if reason != "" {
+			response.Malformed = append(response.Malformed, types.MalformedNotificationAnnotation{Annotation: key, Value: value, Reason: reason})
+			continue
+		}
+		response.Subscriptions = append(response.Subscriptions, subscriptions...)
+	}
+	response.HasSubscriptions = len(response.Subscriptions) > 0
+	return response
+}
+
+// ParseNotificationAnnotation parses a subscribe annotation into one subscription per
+// channel. The last dot-separated segment after the prefix names the service and the
+// rest, which may itself contain dots, the trigger. Channels are separated by ";" with
+// surrounding whitespace and empty entries dropped. A non-empty reason is returned
+// instead when the annotation is malformed.
+func ParseNotificationAnnotation(key, value string) ([]types.NotificationSubscription, string) {
+	name, ok := strings.CutPrefix(key, notificationSubscribePrefix)
+	if !ok {
+		return nil, "not a notifications.argoproj.io/subscribe annotation"
+	}
+
+	trigger, service := "", name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		trigger, service = name[:i], name[i+1:]
+		if slices.Contains(strings.Split(trigger, "."), "") {
+			return nil, "empty trigger name"
+		}
+	}
+	if service == "" {
+		return nil, "missing service name"
+	}
+	if strings.ContainsAny(trigger+service, " \t") {
+		return nil, "trigger and service names must not contain whitespace"
+	}
+
+	var subscriptions []types.NotificationSubscription
+	for _, channel := range strings.Split(value, ";") {
+		channel = strings.TrimSpace(channel)
+		if channel == "" {
+			continue
+		}
+		subscriptions = append(subscriptions, types.NotificationSubscription{
+			Trigger:    trigger,
+			Service:    service,
+			Channel:    channel,
+			Annotation: key,
+		})
+	}
+	if len(subscriptions) == 0 {
+		return nil, "no channels in the annotation value"
+	}
+	return subscriptions, ""
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+func TestParseNotificationAnnotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		value      string
+		want       []types.NotificationSubscription
+		wantReason string
+	}{
+		{
+			name:  "trigger and service",
+			key:   "notifications.argoproj.io/subscribe.on-sync-succeeded.slack",
+			value: "deploys",
+			want: []types.NotificationSubscription{
+				{Trigger: "on-sync-succeeded", Service: "slack", Channel: "deploys", Annotation: "notifications.argoproj.io/subscribe.on-sync-succeeded.slack"},
+			},
+		},
+		{
+			name:  "multiple channels",
+			key:   "notifications.argoproj.io/subscribe.on-health-degraded.email",
+			value: " oncall@example.com ;; team@example.com; ",
+			want: []types.NotificationSubscription{
+				{Trigger: "on-health-degraded", Service: "email", Channel: "oncall@example.com", Annotation: "notifications.argoproj.io/subscribe.on-health-degraded.email"},
+				{Trigger: "on-health-degraded", Service: "email", Channel: "team@example.com", Annotation: "notifications.argoproj.io/subscribe.on-health-degraded.email"},
+			},
+		},
+		{
+			name:  "default triggers",
+			key:   "notifications.argoproj.io/subscribe.teams",
+			value: "platform",
+			want: []types.NotificationSubscription{
+				{Service: "teams", Channel: "platform", Annotation: "notifications.argoproj.io/subscribe.teams"},
+			},
+		},
+		{
+			name:  "dotted trigger",
+			key:   "notifications.argoproj.io/subscribe.team.on-deployed.webhook",
+			value: "github",
+			want: []types.NotificationSubscription{
+				{Trigger: "team.on-deployed", Service: "webhook", Channel: "github", Annotation: "notifications.argoproj.io/subscribe.team.on-deployed.webhook"},
+			},
+		},
+		{name: "empty trigger", key: "notifications.argoproj.io/subscribe..slack", value: "deploys", wantReason: "empty trigger name"},
+		{name: "empty trigger segment", key: "notifications.argoproj.io/subscribe.team..slack", value: "deploys", wantReason: "empty trigger name"},
+		{name: "missing service", key: "notifications.argoproj.io/subscribe.on-deployed.", value: "deploys", wantReason: "missing service name"},
+		{name: "nothing after the prefix", key: "notifications.argoproj.io/subscribe.", value: "deploys", wantReason: "missing service name"},
+		{name: "whitespace in the name", key: "notifications.argoproj.io/subscribe.on deployed.slack", value: "deploys", wantReason: "trigger and service names must not contain whitespace"},
+		{name: "no channels", key: "notifications.argoproj.io/subscribe.on-deployed.slack", value: " ; ", wantReason: "no channels in the annotation value"},
+		{name: "other annotation", key: "notifications.argoproj.io/subscriptions", value: "- recipients: [slack:deploys]", wantReason: "not a notifications.argoproj.io/subscribe annotation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := ParseNotificationAnnotation(tt.key, tt.value)
+			if reason != tt.wantReason {
+				t.Fatalf("ParseNotificationAnnotation() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNotificationAnnotation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationNotifications(t *testing.T) {
+	app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{
+		Name: "web",
+		Annotations: map[string]string{
+			"notifications.argoproj.io/subscribe.on-sync-failed.slack": "deploys;web-team",
+			"notifications.argoproj.io/subscribe.email":                "oncall@example.com",
+			"notifications.argoproj.io/subscribe..slack":               "broken",
+			"example.com/owner": "team-web",
+		},
+	}}
+
+	got := ApplicationNotifications(app)
+	want := types.ApplicationNotifications{
+		Application:      "web",
+		HasSubscriptions: true,
+		Subscriptions: []types.NotificationSubscription{
+			{Service: "email", Channel: "oncall@example.com", Annotation: "notifications.argoproj.io/subscribe.email"},
+			{Trigger: "on-sync-failed", Service: "slack", Channel: "deploys", Annotation: "notifications.argoproj.io/subscribe.on-sync-failed.slack"},
+			{Trigger: "on-sync-failed", Service: "slack", Channel: "web-team", Annotation: "notifications.argoproj.io/subscribe.on-sync-failed.slack"},
+		},
+		Malformed: []types.MalformedNotificationAnnotation{
+			{Annotation: "notifications.argoproj.io/subscribe..slack", Value: "broken", Reason: "empty trigger name"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplicationNotifications() = %+v, want %+v", got, want)
+	}
+
+	empty := ApplicationNotifications(types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "bare"}})
+	if empty.HasSubscriptions || empty.Subscriptions == nil || len(empty.Subscriptions) != 0 || empty.Malformed != nil {
+		t.Errorf("ApplicationNotifications() without annotations = %+v, want an empty subscription list", empty)
+	}
+}
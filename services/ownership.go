@@ -0,0 +1,46 @@
+package services
+
+import (
+	"sort"
+
+	"argocd-proxy/types"
+)
+
+// SetOwnership fills in the ownership map of app from its annotations listed in keys.
+// Keys the application is not annotated with are left out; no keys leaves it untouched.
+func SetOwnership(app *types.ArgocdApplication, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	app.Ownership = make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value := app.Metadata.Annotations[key]; value != "" {
+			app.Ownership[key] = value
+		}
+	}
+}
+
+// CountOwners returns the distinct values of the annotation key across the applications,
+// sorted by owner, with the number of applications each owns
+func CountOwners(list types.ArgocdApplicationList, key string) types.OwnersResponse {
+	response := types.OwnersResponse{Annotation: key, Owners: []types.OwnerCount{}}
+
+	counts := make(map[string]int)
+	for _, app := range list.Items {
+		owner := app.Metadata.Annotations[key]
+		if owner == "" {
+			response.Unowned++
+			continue
+		}
+		counts[owner]++
+	}
+
+	for owner, count := range counts {
+		response.Owners = append(response.Owners, types.OwnerCount{Owner: owner, Applications: count})
+	}
+	sort.Slice(response.Owners, func(i, j int) bool {
+		return response.Owners[i].Owner < response.Owners[j].Owner
+	})
+	return response
+}
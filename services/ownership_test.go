@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// appOwnedBy returns an application annotated with the given annotations
+func appOwnedBy(name string, annotations map[string]string) types.ArgocdApplication {
+	return types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name, Annotations: annotations}}
+}
+
+func TestSetOwnership(t *testing.T) {
+	keys := []string{"company.io/owner", "company.io/slack-channel"}
+
+	tests := []struct {
+		name        string
+		keys        []string
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "all annotations",
+			keys:        keys,
+			annotations: map[string]string{"company.io/owner": "payments", "company.io/slack-channel": "#payments", "other": "x"},
+			want:        map[string]string{"company.io/owner": "payments", "company.io/slack-channel": "#payments"},
+		},
+		{
+			name:        "some annotations",
+			keys:        keys,
+			annotations: map[string]string{"company.io/owner": "payments"},
+			want:        map[string]string{"company.io/owner": "payments"},
+		},
+		{name: "no annotations", keys: keys, want: map[string]string{}},
+		{name: "not configured", annotations: map[string]string{"company.io/owner": "payments"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := appOwnedBy("web", tt.annotations)
+			SetOwnership(&app, tt.keys)
+			if !reflect.DeepEqual(app.Ownership, tt.want) {
+				t.Errorf("Ownership = %#v, want %#v", app.Ownership, tt.want)
+			}
+
+			// Configured ownership renders even when empty, and is omitted otherwise
+			body, _ := json.Marshal(app)
+			if rendered := strings.Contains(string(body), `"ownership":`); rendered != (tt.keys != nil) {
+				t.Errorf("ownership rendered = %v, want %v: %s", rendered, tt.keys != nil, body)
+			}
+		})
+	}
+}
+
+func TestCountOwners(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		appOwnedBy("web", map[string]string{"company.io/owner": "web-team"}),
+		appOwnedBy("api", map[string]string{"company.io/owner": "payments"}),
+		appOwnedBy("worker", map[string]string{"company.io/owner": "payments"}),
+		appOwnedBy("legacy", nil),
+	}}
+
+	got := CountOwners(list, "company.io/owner")
+	want := types.OwnersResponse{
+		Annotation: "company.io/owner",
+		Owners: []types.OwnerCount{
+			{Owner: "payments", Applications: 2},
+			{Owner: "web-team", Applications: 1},
+		},
+		Unowned: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountOwners() = %+v, want %+v", got, want)
+	}
+
+	if empty := CountOwners(types.ArgocdApplicationList{}, "company.io/owner"); empty.Owners == nil {
+		t.Errorf("CountOwners() owners = nil, want an empty slice")
+	}
+}
+
+func TestApplicationFilterOwner(t *testing.T) {
+	list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		appOwnedBy("api", map[string]string{"company.io/owner": "payments"}),
+		appOwnedBy("web", map[string]string{"company.io/owner": "web-team", "company.io/slack-channel": "payments"}),
+		appOwnedBy("legacy", nil),
+	}}
+
+	filter := ApplicationFilter{Owner: "payments", OwnerAnnotation: "company.io/owner"}
+	var names []string
+	for _, app := range filter.Apply(list).Items {
+		names = append(names, app.Metadata.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"api"}) {
+		t.Errorf("Apply() apps = %v, want [api]", names)
+	}
+}
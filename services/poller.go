@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// ApplicationTransition describes a health or sync status change of a single application
+// between two consecutive polls
+type ApplicationTransition struct {
+	Application string
+	Project     string
+	FromHealth  string
+	ToHealth    string
+	FromSync    string
+	ToSync      string
+}
+
+// applicationState is the part of an application compared between polls
+type applicationState struct {
+	name    string
+	project string
+	health  string
+	sync    string
+}
+
+// Poller refreshes the applications cache on a fixed interval so that status changes are
+// noticed even when no client is querying the API. Each refresh wakes watchers through
+// the service's change notifications, and health or sync transitions are logged and
+// counted in argocd_proxy_app_transitions_total. The time each application entered its
+// status is tracked for argocd_proxy_app_state_duration_seconds and the summaries, and the
+// reconcile ages per group are exported for argocd_proxy_app_reconcile_age_seconds. Every
+// poll also runs a health check so the health history keeps filling while /health is not
+// being probed.
+type Poller struct {
+	service  *ArgocdService
+	interval time.Duration
+
+	// previous is the last observed state keyed by namespace/name; nil until the first poll
+	previous map[string]applicationState
+}
+
+// NewPoller creates a poller refreshing service's applications every interval
+func NewPoller(service *ArgocdService, interval time.Duration) *Poller {
+	return &Poller{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run polls until ctx is cancelled. It polls once immediately so the first snapshot
+// is available without waiting a full interval.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := p.service.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Poll checks upstream health and refreshes the application set once, returning the
+// transitions since the previous successful poll. The first poll only records a baseline
+// and reports no transitions.
+func (p *Poller) Poll(ctx context.Context) []ApplicationTransition {
+	pollCtx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	// The outcome is kept in the health history; failures surface through the refresh below
+	p.service.HealthCheck(pollCtx)
+
+	applications, err := p.service.RefreshApplications(pollCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Background poll of applications failed: %v", err)
+		}
+		return nil
+	}
+
+	now := p.service.clock.Now()
+	current := snapshotApplications(applications)
+	p.service.stateAges.observe(current, now)
+	metrics.ReconcileAge.Update(groupReconcileAges(p.service.config.Snapshot(), applications.Items, now, p.service.config.ReconcileAgeThreshold))
+	if p.previous == nil {
+		p.previous = current
+		return nil
+	}
+
+	transitions := diffApplicationStates(p.previous, current)
+	p.previous = current
+
+	for _, t := range transitions {
+		log.Printf("Application transition: app=%s project=%s health=%s->%s sync=%s->%s",
+			t.Application, t.Project, t.FromHealth, t.ToHealth, t.FromSync, t.ToSync)
+		if t.FromHealth != t.ToHealth {
+			metrics.AppTransitionsTotal.WithLabelValues(t.FromHealth, t.ToHealth).Inc()
+		}
+		if t.FromSync != t.ToSync {
+			metrics.AppTransitionsTotal.WithLabelValues(t.FromSync, t.ToSync).Inc()
+		}
+	}
+
+	return transitions
+}
+
+// snapshotApplications captures the compared state of every application
+func snapshotApplications(appList types.ArgocdApplicationList) map[string]applicationState {
+	states := make(map[string]applicationState, len(appList.Items))
+	for _, app := range appList.Items {
+		states[app.Metadata.Namespace+"/"+app.Metadata.Name] = applicationState{
+			name:    app.Metadata.Name,
+			project: app.Spec.Project,
+			health:  app.Status.Health.Status,
+			sync:    app.Status.Sync.Status,
+		}
+	}
+	return states
+}
+
+// diffApplicationStates returns the applications present in both snapshots whose health
+// or sync status changed, sorted by application key. Added and removed applications
+// are not transitions.
+func diffApplicationStates(previous, current map[string]applicationState) []ApplicationTransition {
+	var keys []string
+	for key, now := range current {
+		before, ok := previous[key]
+		if ok && (before.health != now.health || before.sync != now.sync) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	transitions := make([]ApplicationTransition, 0, len(keys))
+	for _, key := range keys {
+		before, now := previous[key], current[key]
+		transitions = append(transitions, ApplicationTransition{
+			Application: now.name,
+			Project:     now.project,
+			FromHealth:  before.health,
+			ToHealth:    now.health,
+			FromSync:    before.sync,
+			ToSync:      now.sync,
+		})
+	}
+	return transitions
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+func pollerTestApp(name, health, sync string) types.ArgocdApplication {
+	app := types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"},
+		Spec:     types.ArgocdApplicationSpec{Project: "production"},
+	}
+	app.Status.Health.Status = health
+	app.Status.Sync.Status = sync
+	return app
+}
+
+func TestPollerReportsTransitions(t *testing.T) {
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+
+	var mu sync.Mutex
+	apps := []types.ArgocdApplication{
+		pollerTestApp("api", "Healthy", "Synced"),
+		pollerTestApp("web", "Healthy", "Synced"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: apps})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Hour}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+	poller := NewPoller(service, time.Second)
+	ctx := context.Background()
+
+	if transitions := poller.Poll(ctx); len(transitions) != 0 {
+		t.Fatalf("first Poll() reported %d transitions, want baseline only", len(transitions))
+	}
+
+	mu.Lock()
+	apps = []types.ArgocdApplication{
+		pollerTestApp("api", "Degraded", "OutOfSync"),
+		pollerTestApp("web", "Healthy", "Synced"),
+		pollerTestApp("worker", "Progressing", "Synced"),
+	}
+	mu.Unlock()
+
+	// The poll must bypass the fresh cache and wake watchers
+	changed := service.ApplicationsChanged()
+	transitions := poller.Poll(ctx)
+
+	select {
+	case <-changed:
+	default:
+		t.Error("ApplicationsChanged() not closed after a poll observed a new application set")
+	}
+
+	want := []ApplicationTransition{{
+		Application: "api",
+		Project:     "production",
+		FromHealth:  "Healthy",
+		ToHealth:    "Degraded",
+		FromSync:    "Synced",
+		ToSync:      "OutOfSync",
+	}}
+	if len(transitions) != len(want) || transitions[0] != want[0] {
+		t.Fatalf("Poll() transitions = %+v, want %+v", transitions, want)
+	}
+
+	if got := testutil.ToFloat64(metrics.AppTransitionsTotal.WithLabelValues("Healthy", "Degraded")); got != 1 {
+		t.Errorf("app_transitions_total{Healthy,Degraded} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.AppTransitionsTotal.WithLabelValues("Synced", "OutOfSync")); got != 1 {
+		t.Errorf("app_transitions_total{Synced,OutOfSync} = %v, want 1", got)
+	}
+
+	cached, err := service.GetApplications(ctx)
+	if err != nil {
+		t.Fatalf("GetApplications() unexpected error: %v", err)
+	}
+	if len(cached.Items) != 3 {
+		t.Errorf("cached applications = %d, want 3 after poll", len(cached.Items))
+	}
+}
+
+func TestPollerRunStopsOnCancel(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArgocdAPIURL: server.URL}
+	clk := testutils.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+	poller := NewPoller(service, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		poller.Run(ctx)
+		close(done)
+	}()
+
+	// Polls after the first one wait for the clock to pass an interval
+	polled := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+	deadline := time.Now().Add(time.Second)
+	for polled() < 2 && time.Now().Before(deadline) {
+		clk.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after cancellation")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests < 2 {
+		t.Errorf("Run() made %d requests, want at least 2", requests)
+	}
+}
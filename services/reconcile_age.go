@@ -0,0 +1,42 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// groupReconcileAges returns the reconcile ages of apps at now per project group, as
+// listed by the group: applications hidden by the group's own ignore patterns are left
+// out, and applications of ungrouped projects are not counted at all. Every configured
+// group has an entry, so groups without applications export zero counts. Applications
+// ArgoCD has not reconciled yet have an infinite age and always exceed threshold.
+func groupReconcileAges(filters *config.FilterSnapshot, apps []types.ArgocdApplication, now time.Time, threshold time.Duration) map[string]metrics.GroupReconcileAges {
+	groups := make(map[string]metrics.GroupReconcileAges, len(filters.ProjectGroups))
+	for _, group := range filters.ProjectGroups {
+		groups[group.Name] = metrics.GroupReconcileAges{Ages: []float64{}}
+	}
+
+	for _, app := range apps {
+		age := math.Inf(1)
+		if !app.Status.ReconciledAt.IsZero() {
+			// A reconcile time ahead of the proxy's clock counts as just reconciled
+			age = max(now.Sub(app.Status.ReconciledAt).Seconds(), 0)
+		}
+		for _, group := range filters.GroupsListing(app.Spec.Project) {
+			if group.IsApplicationIgnored(app.Metadata.Name, app.Spec.Project) {
+				continue
+			}
+			ages := groups[group.Name]
+			ages.Ages = append(ages.Ages, age)
+			if age > threshold.Seconds() {
+				ages.Exceeding++
+			}
+			groups[group.Name] = ages
+		}
+	}
+	return groups
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+// reconciledApp returns an application of project last reconciled at reconciledAt
+func reconciledApp(name, project string, reconciledAt time.Time) types.ArgocdApplication {
+	app := types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"},
+		Spec:     types.ArgocdApplicationSpec{Project: project},
+	}
+	app.Status.ReconciledAt = reconciledAt
+	return app
+}
+
+func TestGroupReconcileAges(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	filters := (&config.Config{ProjectGroups: []config.ProjectGroup{
+		{Name: "production", Projects: []string{"payments", "checkout"}, IgnoredProjects: []string{"*-canary"}},
+		{Name: "payments-team", Projects: []string{"payments"}},
+		{Name: "empty", Projects: []string{"unused"}},
+	}}).Snapshot()
+	apps := []types.ArgocdApplication{
+		reconciledApp("api", "payments", now.Add(-2*time.Minute)),
+		reconciledApp("api-canary", "payments", now.Add(-time.Hour)),
+		reconciledApp("web", "checkout", now.Add(-20*time.Minute)),
+		reconciledApp("new", "checkout", time.Time{}),
+		reconciledApp("skewed", "checkout", now.Add(time.Minute)),
+		reconciledApp("tools", "ungrouped", now.Add(-time.Hour)),
+	}
+
+	got := groupReconcileAges(filters, apps, now, 10*time.Minute)
+	want := map[string]metrics.GroupReconcileAges{
+		"production":    {Ages: []float64{120, 1200, math.Inf(1), 0}, Exceeding: 2},
+		"payments-team": {Ages: []float64{120, 3600}, Exceeding: 1},
+		"empty":         {Ages: []float64{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupReconcileAges() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPollerExportsReconcileAges(t *testing.T) {
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+			reconciledApp("api", "payments", start.Add(-time.Minute)),
+			reconciledApp("web", "payments", start.Add(-5*time.Minute)),
+			reconciledApp("new", "payments", time.Time{}),
+		}})
+	}))
+	defer server.Close()
+
+	clk := testutils.NewFakeClock(start)
+	cfg := &config.Config{
+		ArgocdAPIURL:          server.URL,
+		CacheTTL:              time.Hour,
+		ReconcileAgeThreshold: 10 * time.Minute,
+		ProjectGroups:         []config.ProjectGroup{{Name: "production", Projects: []string{"payments"}}},
+	}
+	service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+	poller := NewPoller(service, time.Second)
+
+	poller.Poll(context.Background())
+	exceeded := func() float64 {
+		t.Helper()
+		families, err := metrics.Default().Registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		for _, family := range families {
+			if family.GetName() == "argocd_proxy_apps_reconcile_age_exceeded" {
+				return family.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+		t.Fatal("argocd_proxy_apps_reconcile_age_exceeded not exported")
+		return 0
+	}
+	// Only the never reconciled application exceeds the threshold
+	if got := exceeded(); got != 1 {
+		t.Errorf("apps exceeding threshold = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(metrics.ReconcileAge, "argocd_proxy_app_reconcile_age_seconds"); got != 1 {
+		t.Errorf("reconcile age histograms = %d, want 1 for the production group", got)
+	}
+
+	// Without new reconciles, the next poll finds web past the threshold
+	clk.Advance(6 * time.Minute)
+	poller.Poll(context.Background())
+	if got := exceeded(); got != 2 {
+		t.Errorf("apps exceeding threshold after 6 minutes = %v, want 2", got)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"strings"
+
+	"argocd-proxy/types"
+)
+
+// ProjectRoles lists the RBAC roles of a project in spec order. With flatten, each role's
+// policy lines are parsed into rules instead of being returned verbatim.
+func ProjectRoles(project types.ArgocdProject, flatten bool) types.ProjectRolesResponse {
+	response := types.ProjectRolesResponse{
+		Project: project.Metadata.Name,
+		Roles:   []types.ProjectRole{},
+	}
+	for _, role := range project.Spec.Roles {
+		entry := types.ProjectRole{
+			Name:        role.Name,
+			Description: role.Description,
+			Groups:      role.Groups,
+			Policies:    role.Policies,
+		}
+		if entry.Groups == nil {
+			entry.Groups = []string{}
+		}
+		if flatten {
+			entry.Policies = nil
+			entry.Rules = []types.ProjectPolicyRule{}
+			for _, policy := range role.Policies {
+				entry.Rules = append(entry.Rules, ParseProjectPolicy(policy))
+			}
+		}
+		response.Roles = append(response.Roles, entry)
+	}
+	return response
+}
+
+// ParseProjectPolicy parses a Casbin policy line of the form
+// "p, subject, resource, action, object, effect". Lines of any other shape are returned
+// with only Raw set and Malformed true.
+func ParseProjectPolicy(line string) types.ProjectPolicyRule {
+	rule := types.ProjectPolicyRule{Raw: line}
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if len(fields) != 6 || fields[0] != "p" {
+		rule.Malformed = true
+		return rule
+	}
+	rule.Subject = fields[1]
+	rule.Resource = fields[2]
+	rule.Action = fields[3]
+	rule.Object = fields[4]
+	rule.Effect = fields[5]
+	return rule
+}
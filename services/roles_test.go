@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// appProjectPayload is an AppProject as returned by ArgoCD's /projects/{name}
+const appProjectPayload = `{
+  "metadata": {
+    "name": "payments",
+    "namespace": "argocd",
+    "uid": "5d4b2c1e-8f0a-4a57-9f43-3b1c2a7e9d10",
+    "resourceVersion": "184213",
+    "generation": 7,
+    "creationTimestamp": "2024-03-11T09:24:51Z"
+  },
+  "spec": {
+    "description": "Payments team services",
+    "sourceRepos": ["https://github.com/example/payments-*"],
+    "destinations": [
+      {"server": "https://kubernetes.default.svc", "namespace": "payments-*"}
+    ],
+    "clusterResourceWhitelist": [
+      {"group": "", "kind": "Namespace"},
+      {"group": "rbac.authorization.k8s.io", "kind": "ClusterRole"}
+    ],
+    "namespaceResourceBlacklist": [
+      {"group": "", "kind": "ResourceQuota"}
+    ],
+    "roles": [
+      {
+        "name": "read-only",
+        "description": "Read-only access for auditors",
+        "policies": [
+          "p, proj:payments:read-only, applications, get, payments/*, allow"
+        ],
+        "groups": ["example:auditors"]
+      },
+      {
+        "name": "ci",
+        "policies": [
+          "p, proj:payments:ci, applications, sync, payments/*, allow",
+          "p, proj:payments:ci, applications, delete, payments/*, deny"
+        ],
+        "jwtTokens": [{"iat": 1710149091, "id": "6b0f4a6e"}]
+      }
+    ],
+    "orphanedResources": {"warn": false}
+  },
+  "status": {
+    "jwtTokensByRole": {"ci": {"items": [{"iat": 1710149091, "id": "6b0f4a6e"}]}}
+  }
+}`
+
+func TestDecodeAppProjectRoles(t *testing.T) {
+	var project types.ArgocdProject
+	if err := json.Unmarshal([]byte(appProjectPayload), &project); err != nil {
+		t.Fatalf("failed to decode project: %v", err)
+	}
+
+	wantRoles := []types.ArgocdProjectRole{
+		{
+			Name:        "read-only",
+			Description: "Read-only access for auditors",
+			Policies:    []string{"p, proj:payments:read-only, applications, get, payments/*, allow"},
+			Groups:      []string{"example:auditors"},
+		},
+		{
+			Name: "ci",
+			Policies: []string{
+				"p, proj:payments:ci, applications, sync, payments/*, allow",
+				"p, proj:payments:ci, applications, delete, payments/*, deny",
+			},
+		},
+	}
+	if !reflect.DeepEqual(project.Spec.Roles, wantRoles) {
+		t.Errorf("roles = %+v, want %+v", project.Spec.Roles, wantRoles)
+	}
+	wantWhitelist := []types.ArgocdGroupKind{{Group: "", Kind: "Namespace"}, {Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}}
+	if !reflect.DeepEqual(project.Spec.ClusterResourceWhitelist, wantWhitelist) {
+		t.Errorf("clusterResourceWhitelist = %+v, want %+v", project.Spec.ClusterResourceWhitelist, wantWhitelist)
+	}
+}
+
+func TestProjectRoles(t *testing.T) {
+	var project types.ArgocdProject
+	if err := json.Unmarshal([]byte(appProjectPayload), &project); err != nil {
+		t.Fatalf("failed to decode project: %v", err)
+	}
+
+	roles := ProjectRoles(project, false)
+	if roles.Project != "payments" || len(roles.Roles) != 2 {
+		t.Fatalf("ProjectRoles() = %+v, want the 2 roles of payments", roles)
+	}
+	if ci := roles.Roles[1]; len(ci.Policies) != 2 || ci.Rules != nil || ci.Groups == nil {
+		t.Errorf("ci role = %+v, want verbatim policies and empty groups", ci)
+	}
+
+	flat := ProjectRoles(project, true)
+	want := []types.ProjectPolicyRule{
+		{Raw: "p, proj:payments:ci, applications, sync, payments/*, allow", Subject: "proj:payments:ci", Resource: "applications", Action: "sync", Object: "payments/*", Effect: "allow"},
+		{Raw: "p, proj:payments:ci, applications, delete, payments/*, deny", Subject: "proj:payments:ci", Resource: "applications", Action: "delete", Object: "payments/*", Effect: "deny"},
+	}
+	if ci := flat.Roles[1]; ci.Policies != nil || !reflect.DeepEqual(ci.Rules, want) {
+		t.Errorf("flattened ci role = %+v, want rules %+v", ci, want)
+	}
+
+	if empty := ProjectRoles(types.ArgocdProject{Metadata: types.ArgocdProjectMetadata{Name: "bare"}}, true); empty.Roles == nil || len(empty.Roles) != 0 {
+		t.Errorf("ProjectRoles() of a project without roles = %+v, want an empty list", empty)
+	}
+}
+
+func TestParseProjectPolicy(t *testing.T) {
+	tests := []struct {
+		line string
+		want types.ProjectPolicyRule
+	}{
+		{
+			line: "p,proj:web:dev,applications,override,web/*,allow",
+			want: types.ProjectPolicyRule{Raw: "p,proj:web:dev,applications,override,web/*,allow", Subject: "proj:web:dev", Resource: "applications", Action: "override", Object: "web/*", Effect: "allow"},
+		},
+		{line: "g, example:admins, proj:web:dev", want: types.ProjectPolicyRule{Raw: "g, example:admins, proj:web:dev", Malformed: true}},
+		{line: "p, proj:web:dev, applications, get", want: types.ProjectPolicyRule{Raw: "p, proj:web:dev, applications, get", Malformed: true}},
+		{line: "", want: types.ProjectPolicyRule{Malformed: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := ParseProjectPolicy(tt.line); got != tt.want {
+				t.Errorf("ParseProjectPolicy(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"argocd-proxy/config"
+	"argocd-proxy/shadow"
+	"argocd-proxy/timing"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// shadowMaxInFlight bounds the candidate runs in progress, so that shadowing a burst of
+// requests cannot multiply the load on ArgoCD
+const shadowMaxInFlight = 4
+
+// shadowSampleSize bounds the application names logged per side of a mismatch
+const shadowSampleSize = 10
+
+// newShadow returns the runner for candidate implementations, or nil when SHADOW_MODE is
+// not "compare"
+func newShadow(cfg *config.Config) *shadow.Runner {
+	if cfg.ShadowMode != config.ShadowModeCompare {
+		return nil
+	}
+	log.Printf("Shadow comparisons enabled (timeout %s, latency budget %s)", cfg.ShadowTimeout, cfg.ShadowBudget)
+	return shadow.New(shadow.Options{
+		Timeout:     cfg.ShadowTimeout,
+		Budget:      cfg.ShadowBudget,
+		MaxInFlight: shadowMaxInFlight,
+		SampleSize:  shadowSampleSize,
+	})
+}
+
+// applicationsByGroupServerSide is the candidate replacement of applicationsByGroup. It
+// asks ArgoCD for the group's projects only instead of filtering the full application
+// list, bypassing the application cache.
+func (s *ArgocdService) applicationsByGroupServerSide(ctx context.Context, targetGroup *config.ProjectGroup) (types.ArgocdApplicationList, error) {
+	if len(targetGroup.Projects) == 0 {
+		return types.ArgocdApplicationList{}, nil
+	}
+	opts := []upstream.Option{upstream.Endpoint("/applications?projects")}
+	for _, project := range targetGroup.Projects {
+		opts = append(opts, upstream.Query("projects", project))
+	}
+	var appList types.ArgocdApplicationList
+	if err := s.client.GetJSON(ctx, "/applications", &appList, opts...); err != nil {
+		return types.ArgocdApplicationList{}, err
+	}
+
+	clusterNames := s.clusterNames(ctx)
+
+	stopFilter := timing.Start(ctx, timing.PhaseFilter)
+	filters := s.config.SnapshotFor(ctx)
+	var filteredApps []types.ArgocdApplication
+	for _, app := range appList.Items {
+		if s.filtered(filters, app.Spec.Project, "application "+strconv.Quote(app.Metadata.Name)) {
+			continue
+		}
+		if _, ignored := targetGroup.MatchApplicationIgnore(app.Metadata.Name, app.Spec.Project); ignored {
+			continue
+		}
+		s.enrichApplication(&app, clusterNames)
+		filteredApps = append(filteredApps, app)
+	}
+	stopFilter()
+
+	appList.Items = filteredApps
+	return appList, nil
+}
+
+// applicationKeys identifies the applications of a list for shadow comparisons
+func applicationKeys(list types.ArgocdApplicationList) []string {
+	keys := make([]string, 0, len(list.Items))
+	for _, app := range list.Items {
+		keys = append(keys, app.Metadata.Namespace+"/"+app.Metadata.Name)
+	}
+	return keys
+}
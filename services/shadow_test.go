@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/shadow"
+	"argocd-proxy/types"
+	"argocd-proxy/upstream"
+)
+
+// newShadowService returns a service over a fake ArgoCD that filters the application list
+// by ?projects= only when honourProjects is set, and a function waiting for the shadow
+// comparisons made so far
+func newShadowService(t *testing.T, honourProjects bool) (*ArgocdService, func() []shadow.Comparison) {
+	t.Helper()
+	apps := []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "web", Namespace: "argocd"}, Spec: types.ArgocdApplicationSpec{Project: "frontend"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "web-preview", Namespace: "argocd"}, Spec: types.ArgocdApplicationSpec{Project: "frontend"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "api", Namespace: "argocd"}, Spec: types.ArgocdApplicationSpec{Project: "backend"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "worker", Namespace: "jobs"}, Spec: types.ArgocdApplicationSpec{Project: "backend"}},
+		{Metadata: types.ArgocdApplicationMetadata{Name: "scratch", Namespace: "argocd"}, Spec: types.ArgocdApplicationSpec{Project: "sandbox"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/applications" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		projects := r.URL.Query()["projects"]
+		var items []types.ArgocdApplication
+		for _, app := range apps {
+			if !honourProjects || len(projects) == 0 || slices.Contains(projects, app.Spec.Project) {
+				items = append(items, app)
+			}
+		}
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: items})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		ArgocdAPIURL:    server.URL,
+		CacheTTL:        time.Minute,
+		IgnoredProjects: []string{"sandbox"},
+		ProjectGroups: []config.ProjectGroup{
+			{Name: "team-a", Projects: []string{"frontend"}, IgnoredProjects: []string{"*-preview"}},
+		},
+	}
+	service := NewArgocdService(cfg, &MockAuthService{token: "test-token"}, nil)
+
+	var mu sync.Mutex
+	var comparisons []shadow.Comparison
+	service.shadow = shadow.New(shadow.Options{
+		Timeout:     time.Second,
+		Budget:      time.Second,
+		MaxInFlight: 1,
+		SampleSize:  shadowSampleSize,
+		OnCompare: func(c shadow.Comparison) {
+			mu.Lock()
+			defer mu.Unlock()
+			comparisons = append(comparisons, c)
+		},
+	})
+	return service, func() []shadow.Comparison {
+		service.shadow.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(comparisons)
+	}
+}
+
+// applicationNames returns the names of the applications of list
+func applicationNames(list types.ArgocdApplicationList) []string {
+	names := []string{}
+	for _, app := range list.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	return names
+}
+
+func TestGetApplicationsByGroupShadowMatch(t *testing.T) {
+	service, comparisons := newShadowService(t, true)
+
+	applications, err := service.GetApplicationsByGroup(context.Background(), "team-a")
+	if err != nil {
+		t.Fatalf("GetApplicationsByGroup() error = %v", err)
+	}
+	if got := applicationNames(applications); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("GetApplicationsByGroup() = %v, want [web]", got)
+	}
+
+	c := comparisons()
+	if len(c) != 1 || c[0].Method != "GetApplicationsByGroup" || c[0].Result != shadow.ResultMatch {
+		t.Errorf("comparisons = %+v, want one match", c)
+	}
+}
+
+func TestGetApplicationsByGroupShadowMismatch(t *testing.T) {
+	// An ArgoCD ignoring ?projects= makes the candidate return every visible application
+	service, comparisons := newShadowService(t, false)
+
+	applications, err := service.GetApplicationsByGroup(context.Background(), "team-a")
+	if err != nil {
+		t.Fatalf("GetApplicationsByGroup() error = %v", err)
+	}
+	if got := applicationNames(applications); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("GetApplicationsByGroup() = %v, want the current implementation's [web]", got)
+	}
+
+	c := comparisons()
+	if len(c) != 1 || c[0].Result != shadow.ResultMismatch {
+		t.Fatalf("comparisons = %+v, want one mismatch", c)
+	}
+	if c[0].PrimaryCount != 1 || c[0].CandidateCount != 3 || len(c[0].OnlyPrimary) != 0 || !reflect.DeepEqual(c[0].OnlyCandidate, []string{"argocd/api", "jobs/worker"}) {
+		t.Errorf("comparison = %+v, want api and worker returned by the candidate only", c[0])
+	}
+}
+
+func TestGetApplicationsByGroupShadowSuppressed(t *testing.T) {
+	service, comparisons := newShadowService(t, true)
+	if _, err := service.GetApplications(context.Background()); err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+
+	// Serving from the cache while ArgoCD calls are suppressed makes no shadow call
+	if _, err := service.GetApplicationsByGroup(upstream.Suppress(context.Background()), "team-a"); err != nil {
+		t.Fatalf("GetApplicationsByGroup() error = %v", err)
+	}
+	if c := comparisons(); len(c) != 0 {
+		t.Errorf("comparisons = %+v, want none while suppressed", c)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"argocd-proxy/canonical"
+	"argocd-proxy/types"
+)
+
+// applicationsSnapshot is an application set as it was published under a sequence number
+type applicationsSnapshot struct {
+	seq  uint64
+	hash string
+	list types.ArgocdApplicationList
+}
+
+// snapshotHistory keeps the most recent application set snapshots so that polling
+// clients can ask for the changes since one they already have. Tokens name this
+// process's sequence, so tokens from another replica or an earlier run are unknown.
+type snapshotHistory struct {
+	instance string
+	depth    int
+	seq      uint64
+	entries  []applicationsSnapshot
+}
+
+// newSnapshotHistory creates a history keeping depth snapshots; 0 keeps none
+func newSnapshotHistory(depth int) *snapshotHistory {
+	instance := make([]byte, 4)
+	_, _ = rand.Read(instance)
+	return &snapshotHistory{instance: hex.EncodeToString(instance), depth: depth}
+}
+
+// record stores a newly published application set, dropping the oldest snapshot beyond
+// the depth. The caller must hold the service's changeMu.
+func (h *snapshotHistory) record(hash string, list types.ArgocdApplicationList) {
+	if h.depth <= 0 {
+		return
+	}
+	h.seq++
+	h.entries = append(h.entries, applicationsSnapshot{seq: h.seq, hash: hash, list: list})
+	if len(h.entries) > h.depth {
+		h.entries = h.entries[len(h.entries)-h.depth:]
+	}
+}
+
+// token returns the token of the latest snapshot with the given hash, or "" when none
+// is retained. The caller must hold the service's changeMu.
+func (h *snapshotHistory) token(hash string) string {
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].hash == hash {
+			return fmt.Sprintf("%s-%d", h.instance, h.entries[i].seq)
+		}
+	}
+	return ""
+}
+
+// lookup returns the retained snapshot named by token. The caller must hold the
+// service's changeMu.
+func (h *snapshotHistory) lookup(token string) (types.ArgocdApplicationList, bool) {
+	for _, entry := range h.entries {
+		if fmt.Sprintf("%s-%d", h.instance, entry.seq) == token {
+			return entry.list, true
+		}
+	}
+	return types.ArgocdApplicationList{}, false
+}
+
+// GetApplicationsSnapshot returns the application list like GetApplications together
+// with the token of its snapshot, or "" when SNAPSHOT_HISTORY_DEPTH is 0
+func (s *ArgocdService) GetApplicationsSnapshot(ctx context.Context) (types.ArgocdApplicationList, string, error) {
+	applications, err := s.GetApplications(ctx)
+	if err != nil {
+		return types.ArgocdApplicationList{}, "", err
+	}
+
+	// A list refreshed by another replica through a shared cache is published here first
+	hash := ApplicationsHash(applications)
+	s.publishApplicationsHash(hash, applications)
+
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+	return applications, s.snapshots.token(hash), nil
+}
+
+// ApplicationsSnapshot returns the application list of a retained snapshot, and false
+// when the token is unknown or has aged out of the history
+func (s *ArgocdService) ApplicationsSnapshot(token string) (types.ArgocdApplicationList, bool) {
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+	return s.snapshots.lookup(token)
+}
+
+// DiffApplications compares two application lists by namespace and name, returning the
+// applications of current that are new or differ from previous, in current's order, and
+// the applications of previous missing from current, sorted by namespace and name
+func DiffApplications(previous, current []types.ArgocdApplication) ([]types.ArgocdApplication, []types.ApplicationRef) {
+	before := make(map[string]string, len(previous))
+	for _, app := range previous {
+		before[applicationKey(app)] = applicationHash(app)
+	}
+
+	changed := []types.ArgocdApplication{}
+	seen := make(map[string]bool, len(current))
+	for _, app := range current {
+		key := applicationKey(app)
+		seen[key] = true
+		if hash, ok := before[key]; !ok || hash != applicationHash(app) {
+			changed = append(changed, app)
+		}
+	}
+
+	removed := []types.ApplicationRef{}
+	for _, app := range previous {
+		if !seen[applicationKey(app)] {
+			removed = append(removed, types.ApplicationRef{Namespace: app.Metadata.Namespace, Name: app.Metadata.Name})
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].Namespace != removed[j].Namespace {
+			return removed[i].Namespace < removed[j].Namespace
+		}
+		return removed[i].Name < removed[j].Name
+	})
+	return changed, removed
+}
+
+// applicationKey identifies an application across snapshots
+func applicationKey(app types.ArgocdApplication) string {
+	return app.Metadata.Namespace + "/" + app.Metadata.Name
+}
+
+// applicationHash is a content hash of a single application
+func applicationHash(app types.ArgocdApplication) string {
+	hash, err := canonical.Hash(app)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/types"
+)
+
+func snapshotApp(name, health string) types.ArgocdApplication {
+	app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"}}
+	app.Status.Health.Status = health
+	return app
+}
+
+func namespacedSnapshotApp(namespace, name, health string) types.ArgocdApplication {
+	app := snapshotApp(name, health)
+	app.Metadata.Namespace = namespace
+	return app
+}
+
+func TestDiffApplications(t *testing.T) {
+	tests := []struct {
+		name        string
+		previous    []types.ArgocdApplication
+		current     []types.ArgocdApplication
+		wantChanged []string
+		wantRemoved []types.ApplicationRef
+	}{
+		{
+			name:        "unchanged",
+			previous:    []types.ArgocdApplication{snapshotApp("web", "Healthy")},
+			current:     []types.ArgocdApplication{snapshotApp("web", "Healthy")},
+			wantChanged: []string{},
+			wantRemoved: []types.ApplicationRef{},
+		},
+		{
+			name:        "added, modified and removed",
+			previous:    []types.ArgocdApplication{snapshotApp("web", "Healthy"), snapshotApp("api", "Healthy"), snapshotApp("old", "Healthy")},
+			current:     []types.ArgocdApplication{snapshotApp("web", "Healthy"), snapshotApp("new", "Progressing"), snapshotApp("api", "Degraded")},
+			wantChanged: []string{"argocd/new", "argocd/api"},
+			wantRemoved: []types.ApplicationRef{{Namespace: "argocd", Name: "old"}},
+		},
+		{
+			name: "same name in two namespaces",
+			previous: []types.ArgocdApplication{
+				snapshotApp("web", "Healthy"), namespacedSnapshotApp("team-b", "web", "Healthy"), namespacedSnapshotApp("team-a", "web", "Healthy"),
+			},
+			current:     []types.ArgocdApplication{namespacedSnapshotApp("team-b", "web", "Healthy"), namespacedSnapshotApp("team-c", "web", "Healthy")},
+			wantChanged: []string{"team-c/web"},
+			wantRemoved: []types.ApplicationRef{{Namespace: "argocd", Name: "web"}, {Namespace: "team-a", Name: "web"}},
+		},
+		{
+			name:        "from nothing",
+			current:     []types.ArgocdApplication{snapshotApp("web", "Healthy")},
+			wantChanged: []string{"argocd/web"},
+			wantRemoved: []types.ApplicationRef{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, removed := DiffApplications(tt.previous, tt.current)
+			names := []string{}
+			for _, app := range changed {
+				names = append(names, applicationKey(app))
+			}
+			if !reflect.DeepEqual(names, tt.wantChanged) || !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("DiffApplications() = %v, %v, want %v, %v", names, removed, tt.wantChanged, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestSnapshotHistoryDepth(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		apps []types.ArgocdApplication
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: apps})
+	}))
+	defer server.Close()
+
+	service := NewArgocdService(&config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute, SnapshotHistoryDepth: 2}, &MockAuthService{token: "test-token"}, nil)
+	poller := NewPoller(service, time.Second)
+	ctx := context.Background()
+
+	var tokens []string
+	for _, health := range []string{"Healthy", "Degraded", "Progressing"} {
+		mu.Lock()
+		apps = []types.ArgocdApplication{snapshotApp("web", health)}
+		mu.Unlock()
+		poller.Poll(ctx)
+
+		_, token, err := service.GetApplicationsSnapshot(ctx)
+		if err != nil || token == "" {
+			t.Fatalf("GetApplicationsSnapshot() token = %q, err = %v", token, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	// An unchanged poll keeps the token
+	poller.Poll(ctx)
+	if _, token, _ := service.GetApplicationsSnapshot(ctx); token != tokens[2] {
+		t.Errorf("token after an unchanged poll = %q, want %q", token, tokens[2])
+	}
+
+	if _, ok := service.ApplicationsSnapshot(tokens[0]); ok {
+		t.Error("expected the oldest snapshot to be dropped beyond the depth")
+	}
+	if list, ok := service.ApplicationsSnapshot(tokens[1]); !ok || list.Items[0].Status.Health.Status != "Degraded" {
+		t.Errorf("ApplicationsSnapshot(%q) = %+v, %v, want the Degraded snapshot", tokens[1], list.Items, ok)
+	}
+	if _, ok := service.ApplicationsSnapshot("unknown-1"); ok {
+		t.Error("expected an unknown token to be rejected")
+	}
+}
+
+func TestSnapshotHistoryDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: []types.ArgocdApplication{snapshotApp("web", "Healthy")}})
+	}))
+	defer server.Close()
+
+	service := NewArgocdService(&config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Minute}, &MockAuthService{token: "test-token"}, nil)
+	if _, token, err := service.GetApplicationsSnapshot(context.Background()); err != nil || token != "" {
+		t.Errorf("GetApplicationsSnapshot() token = %q, err = %v, want no token", token, err)
+	}
+}
+
+// randomApplication returns an application with random labels, annotations and timestamps
+func randomApplication(rng *rand.Rand) types.ArgocdApplication {
+	at := func() time.Time {
+		return time.Unix(1_700_000_000+rng.Int64N(100_000_000), rng.Int64N(1_000_000_000)).UTC()
+	}
+	stringMap := func(prefix string) map[string]string {
+		m := map[string]string{}
+		for i := rng.IntN(8); i > 0; i-- {
+			m[fmt.Sprintf("%s/%d", prefix, rng.IntN(100))] = fmt.Sprintf("value-%d", rng.IntN(1000))
+		}
+		return m
+	}
+
+	started, deleted := at(), at()
+	app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{
+		Name:              fmt.Sprintf("app-%d", rng.IntN(1000)),
+		Namespace:         "argocd",
+		Labels:            stringMap("team.example.com"),
+		Annotations:       stringMap("notes.example.com"),
+		CreationTimestamp: at(),
+	}}
+	if rng.IntN(2) == 0 {
+		app.Metadata.DeletionTimestamp = &deleted
+	}
+	app.Status.ReconciledAt = at()
+	app.Status.OperationState = &types.ArgocdOperationState{Phase: "Succeeded", StartedAt: &started}
+	for i := rng.IntN(4); i > 0; i-- {
+		app.Status.History = append(app.Status.History, types.ArgocdRevisionHistory{ID: int64(i), DeployedAt: at()})
+	}
+	return app
+}
+
+// equivalentApplication returns a copy of app with its maps rebuilt and its timestamps
+// in zone, which is the same application
+func equivalentApplication(app types.ArgocdApplication, zone *time.Location) types.ArgocdApplication {
+	rebuilt := func(m map[string]string) map[string]string {
+		keys := slices.Sorted(maps.Keys(m))
+		slices.Reverse(keys)
+		copied := make(map[string]string, len(m))
+		for _, key := range keys {
+			copied[key] = m[key]
+		}
+		return copied
+	}
+	in := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		zoned := t.In(zone)
+		return &zoned
+	}
+
+	equal := app
+	equal.Metadata.Labels = rebuilt(app.Metadata.Labels)
+	equal.Metadata.Annotations = rebuilt(app.Metadata.Annotations)
+	equal.Metadata.CreationTimestamp = app.Metadata.CreationTimestamp.In(zone)
+	equal.Metadata.DeletionTimestamp = in(app.Metadata.DeletionTimestamp)
+	equal.Status.ReconciledAt = app.Status.ReconciledAt.In(zone)
+	operation := *app.Status.OperationState
+	operation.StartedAt = in(operation.StartedAt)
+	equal.Status.OperationState = &operation
+	equal.Status.History = nil
+	for _, entry := range app.Status.History {
+		entry.DeployedAt = entry.DeployedAt.In(zone)
+		equal.Status.History = append(equal.Status.History, entry)
+	}
+	return equal
+}
+
+func TestApplicationHashesAreCanonical(t *testing.T) {
+	rng := rand.New(rand.NewPCG(2026, 227))
+	zones := []*time.Location{time.UTC, time.FixedZone("IST", 5*3600+1800), time.FixedZone("PST", -8*3600)}
+
+	for i := 0; i < 200; i++ {
+		app := randomApplication(rng)
+		equal := equivalentApplication(app, zones[rng.IntN(len(zones))])
+		if applicationHash(app) != applicationHash(equal) {
+			t.Fatalf("applicationHash() differs for the same application:\n%+v\n%+v", app, equal)
+		}
+		list := types.ArgocdApplicationList{Items: []types.ArgocdApplication{app}}
+		equalList := types.ArgocdApplicationList{Items: []types.ArgocdApplication{equal}}
+		if ApplicationsHash(list) != ApplicationsHash(equalList) {
+			t.Fatalf("ApplicationsHash() differs for the same application list:\n%+v\n%+v", app, equal)
+		}
+
+		changed := equivalentApplication(app, time.UTC)
+		changed.Status.ReconciledAt = changed.Status.ReconciledAt.Add(time.Second)
+		if applicationHash(app) == applicationHash(changed) {
+			t.Fatalf("applicationHash() is the same for a changed application:\n%+v", app)
+		}
+	}
+}
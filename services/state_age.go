@@ -0,0 +1,93 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// trackedState is an application's current health and sync status with when the
+// background poller first saw each of them
+type trackedState struct {
+	applicationState
+	healthSince time.Time
+	syncSince   time.Time
+}
+
+// stateAges tracks how long every application has been in its current health and sync
+// status. Only applications of the latest poll are kept, so the map never outgrows the
+// application set.
+type stateAges struct {
+	mu sync.RWMutex
+	// states is keyed by namespace/name, like the poller snapshots
+	states map[string]trackedState
+}
+
+func newStateAges() *stateAges {
+	return &stateAges{states: map[string]trackedState{}}
+}
+
+// observe records the application states of a poll made at now. A status keeps its entry
+// time while unchanged and is reset when it changes; applications missing from current
+// are forgotten. The state duration gauges are updated to match.
+func (a *stateAges) observe(current map[string]applicationState, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	next := make(map[string]trackedState, len(current))
+	for key, state := range current {
+		tracked := trackedState{applicationState: state, healthSince: now, syncSince: now}
+		if before, ok := a.states[key]; ok {
+			if before.health == state.health {
+				tracked.healthSince = before.healthSince
+			}
+			if before.sync == state.sync {
+				tracked.syncSince = before.syncSince
+			}
+			if before.project != state.project || before.health != state.health {
+				deleteStateDuration(before)
+			}
+		}
+		next[key] = tracked
+		metrics.AppStateDuration.WithLabelValues(state.name, state.project, state.health).Set(now.Sub(tracked.healthSince).Seconds())
+	}
+	for key, before := range a.states {
+		if _, ok := next[key]; !ok {
+			deleteStateDuration(before)
+		}
+	}
+	a.states = next
+}
+
+// deleteStateDuration removes the state duration series of an application state
+func deleteStateDuration(state trackedState) {
+	metrics.AppStateDuration.DeleteLabelValues(state.name, state.project, state.health)
+}
+
+// lookup returns the application's health and sync status at the latest poll and when it
+// entered them
+func (a *stateAges) lookup(namespace, name string) (types.ApplicationStateSince, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	tracked, ok := a.states[namespace+"/"+name]
+	if !ok {
+		return types.ApplicationStateSince{}, false
+	}
+	return types.ApplicationStateSince{
+		Health:      tracked.health,
+		HealthSince: tracked.healthSince,
+		Sync:        tracked.sync,
+		SyncSince:   tracked.syncSince,
+	}, true
+}
+
+// ApplicationStateSince returns the application's health and sync status at the latest
+// background poll and when the poller first saw each of them. It reports false for
+// applications the poller has not seen, including every application while the poller
+// is disabled.
+func (s *ArgocdService) ApplicationStateSince(namespace, name string) (types.ApplicationStateSince, bool) {
+	return s.stateAges.lookup(namespace, name)
+}
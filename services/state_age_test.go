@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/testutils"
+	"argocd-proxy/types"
+)
+
+func TestPollerTracksStateAges(t *testing.T) {
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+
+	var mu sync.Mutex
+	apps := []types.ArgocdApplication{
+		pollerTestApp("api", "Healthy", "Synced"),
+		pollerTestApp("web", "Healthy", "Synced"),
+	}
+	setApps := func(next ...types.ArgocdApplication) {
+		mu.Lock()
+		defer mu.Unlock()
+		apps = next
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ArgocdApplicationList{Items: apps})
+	}))
+	defer server.Close()
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := testutils.NewFakeClock(start)
+	cfg := &config.Config{ArgocdAPIURL: server.URL, CacheTTL: time.Hour}
+	service := NewArgocdServiceWithClock(cfg, &MockAuthService{token: "test-token"}, nil, clk)
+	poller := NewPoller(service, time.Second)
+	ctx := context.Background()
+
+	duration := func(app, health string) float64 {
+		return testutil.ToFloat64(metrics.AppStateDuration.WithLabelValues(app, "production", health))
+	}
+	since := func(app string) types.ApplicationStateSince {
+		t.Helper()
+		state, ok := service.ApplicationStateSince("argocd", app)
+		if !ok {
+			t.Fatalf("ApplicationStateSince(%s) not tracked", app)
+		}
+		return state
+	}
+
+	poller.Poll(ctx)
+	clk.Advance(10 * time.Minute)
+	poller.Poll(ctx)
+	if got := duration("api", "Healthy"); got != 600 {
+		t.Errorf("api Healthy duration = %v, want 600", got)
+	}
+
+	// api degrades: its health resets while its unchanged sync status keeps its age
+	setApps(pollerTestApp("api", "Degraded", "Synced"), pollerTestApp("web", "Healthy", "Synced"))
+	clk.Advance(5 * time.Minute)
+	poller.Poll(ctx)
+	degradedAt := clk.Now()
+	clk.Advance(31 * time.Minute)
+	poller.Poll(ctx)
+
+	if got := duration("api", "Degraded"); got != 31*60 {
+		t.Errorf("api Degraded duration = %v, want %v", got, 31*60)
+	}
+	if got := duration("web", "Healthy"); got != 46*60 {
+		t.Errorf("web Healthy duration = %v, want %v", got, 46*60)
+	}
+	want := types.ApplicationStateSince{Health: "Degraded", HealthSince: degradedAt, Sync: "Synced", SyncSince: start}
+	if got := since("api"); got != want {
+		t.Errorf("ApplicationStateSince(api) = %+v, want %+v", got, want)
+	}
+
+	// web disappears and api recovers: the old series are deleted
+	setApps(pollerTestApp("api", "Healthy", "OutOfSync"))
+	clk.Advance(time.Minute)
+	poller.Poll(ctx)
+
+	if got := testutil.CollectAndCount(metrics.AppStateDuration); got != 1 {
+		t.Errorf("state duration series = %d, want 1", got)
+	}
+	if got := duration("api", "Healthy"); got != 0 {
+		t.Errorf("api Healthy duration after recovery = %v, want 0", got)
+	}
+	if got := since("api"); got.HealthSince != clk.Now() || got.SyncSince != clk.Now() {
+		t.Errorf("ApplicationStateSince(api) = %+v, want both reset to %s", got, clk.Now())
+	}
+	if _, ok := service.ApplicationStateSince("argocd", "web"); ok {
+		t.Error("ApplicationStateSince(web) still tracked after it disappeared")
+	}
+}
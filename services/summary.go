@@ -0,0 +1,99 @@
+package services
+
+import (
+	"slices"
+
+	"argocd-proxy/types"
+)
+
+// ApplicationSummaryFields are the ApplicationSummary fields ?fields= can select
+var ApplicationSummaryFields = []string{"name", "project", "cluster", "namespace", "health", "sync", "revision", "healthSince", "syncSince", "autoSync"}
+
+// StateLookup returns when the background poller first saw an application in its health
+// and sync status, like ArgocdService.ApplicationStateSince
+type StateLookup func(namespace, name string) (types.ApplicationStateSince, bool)
+
+// SummarizeApplications returns the compact form of each application, keeping only the
+// given fields besides the name; no fields keeps them all. healthSince and syncSince are
+// read from states, if set, and only filled while the poller saw the application in the
+// status it has now.
+func SummarizeApplications(applications []types.ArgocdApplication, fields []string, states StateLookup) []types.ApplicationSummary {
+	selected := func(field string) bool {
+		return len(fields) == 0 || slices.Contains(fields, field)
+	}
+
+	summaries := make([]types.ApplicationSummary, 0, len(applications))
+	for _, app := range applications {
+		summary := types.ApplicationSummary{Name: app.Metadata.Name}
+		if selected("project") {
+			summary.Project = app.Spec.Project
+		}
+		if selected("cluster") {
+			summary.Cluster = destinationCluster(app.Spec.Destination)
+		}
+		if selected("namespace") {
+			summary.Namespace = app.Spec.Destination.Namespace
+		}
+		if selected("health") {
+			summary.Health = app.Status.Health.Status
+		}
+		if selected("sync") {
+			summary.Sync = app.Status.Sync.Status
+		}
+		if selected("revision") {
+			summary.Revision = app.Status.Sync.Revision
+		}
+		if selected("autoSync") {
+			autoSync := AutoSync(app)
+			summary.AutoSync = &autoSync
+		}
+		if states != nil && (selected("healthSince") || selected("syncSince")) {
+			if since, ok := states(app.Metadata.Namespace, app.Metadata.Name); ok {
+				if selected("healthSince") && since.Health == app.Status.Health.Status {
+					summary.HealthSince = &since.HealthSince
+				}
+				if selected("syncSince") && since.Sync == app.Status.Sync.Status {
+					summary.SyncSince = &since.SyncSince
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// destinationCluster names the destination cluster: the resolved cluster name, the
+// destination name, or the server URL, whichever is known first
+func destinationCluster(destination types.ArgocdApplicationDestination) string {
+	switch {
+	case destination.ClusterName != "":
+		return destination.ClusterName
+	case destination.Name != "":
+		return destination.Name
+	default:
+		return destination.Server
+	}
+}
+
+// SummariesV2 converts summaries to the summary-v2 schema profile, which groups the
+// destination and status fields
+func SummariesV2(summaries []types.ApplicationSummary) []types.ApplicationSummaryV2 {
+	converted := make([]types.ApplicationSummaryV2, 0, len(summaries))
+	for _, summary := range summaries {
+		v2 := types.ApplicationSummaryV2{Name: summary.Name, Project: summary.Project, AutoSync: summary.AutoSync}
+		if summary.Cluster != "" || summary.Namespace != "" {
+			v2.Destination = &types.ApplicationSummaryDestination{Cluster: summary.Cluster, Namespace: summary.Namespace}
+		}
+		if summary.Health != "" || summary.Sync != "" || summary.Revision != "" || summary.HealthSince != nil || summary.SyncSince != nil {
+			v2.Status = &types.ApplicationSummaryStatus{
+				Health:      summary.Health,
+				Sync:        summary.Sync,
+				Revision:    summary.Revision,
+				HealthSince: summary.HealthSince,
+				SyncSince:   summary.SyncSince,
+			}
+		}
+		converted = append(converted, v2)
+	}
+	return converted
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+func TestSummarizeApplications(t *testing.T) {
+	app := types.ArgocdApplication{
+		Metadata: types.ArgocdApplicationMetadata{Name: "web"},
+		Spec: types.ArgocdApplicationSpec{
+			Project:     "production",
+			Destination: types.ArgocdApplicationDestination{Server: "https://10.0.0.1:6443", Namespace: "web"},
+			SyncPolicy:  &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{Prune: true}},
+		},
+	}
+	app.Status.Health.Status = "Degraded"
+	app.Status.Sync.Status = "OutOfSync"
+	app.Status.Sync.Revision = "abc123"
+
+	named := app
+	named.Spec.Destination.Name = "prod"
+	resolved := named
+	resolved.Spec.Destination.ClusterName = "prod-eu"
+	manual := app
+	manual.Spec.SyncPolicy = nil
+
+	tests := []struct {
+		name   string
+		app    types.ArgocdApplication
+		fields []string
+		want   types.ApplicationSummary
+	}{
+		{
+			name: "all fields",
+			app:  app,
+			want: types.ApplicationSummary{Name: "web", Project: "production", Cluster: "https://10.0.0.1:6443", Namespace: "web", Health: "Degraded", Sync: "OutOfSync", Revision: "abc123", AutoSync: new(true)},
+		},
+		{
+			name:   "selected fields keep the name",
+			app:    app,
+			fields: []string{"health", "revision"},
+			want:   types.ApplicationSummary{Name: "web", Health: "Degraded", Revision: "abc123"},
+		},
+		{name: "destination name", app: named, fields: []string{"cluster"}, want: types.ApplicationSummary{Name: "web", Cluster: "prod"}},
+		{name: "resolved cluster name", app: resolved, fields: []string{"cluster"}, want: types.ApplicationSummary{Name: "web", Cluster: "prod-eu"}},
+		{name: "manual sync", app: manual, fields: []string{"autoSync"}, want: types.ApplicationSummary{Name: "web", AutoSync: new(false)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummarizeApplications([]types.ArgocdApplication{tt.app}, tt.fields, nil)
+			if len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("SummarizeApplications() = %+v, want [%+v]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeApplicationsStateSince(t *testing.T) {
+	app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: "web", Namespace: "argocd"}}
+	app.Status.Health.Status = "Degraded"
+	app.Status.Sync.Status = "OutOfSync"
+	healthSince := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	syncSince := healthSince.Add(-time.Hour)
+
+	// The poller last saw the application OutOfSync but still Healthy
+	states := func(namespace, name string) (types.ApplicationStateSince, bool) {
+		if namespace != "argocd" || name != "web" {
+			return types.ApplicationStateSince{}, false
+		}
+		return types.ApplicationStateSince{Health: "Healthy", HealthSince: healthSince, Sync: "OutOfSync", SyncSince: syncSince}, true
+	}
+
+	got := SummarizeApplications([]types.ArgocdApplication{app}, []string{"healthSince", "syncSince"}, states)
+	if len(got) != 1 || got[0].HealthSince != nil || got[0].SyncSince == nil || !got[0].SyncSince.Equal(syncSince) {
+		t.Errorf("SummarizeApplications() = %+v, want only syncSince %s", got, syncSince)
+	}
+
+	if got := SummarizeApplications([]types.ArgocdApplication{app}, []string{"health"}, states); got[0].SyncSince != nil {
+		t.Errorf("SummarizeApplications() without the fields = %+v, want no syncSince", got)
+	}
+}
+
+func TestSummariesV2(t *testing.T) {
+	summaries := []types.ApplicationSummary{
+		{Name: "web", Project: "production", Cluster: "prod", Namespace: "web", Health: "Healthy", Sync: "Synced", Revision: "abc123"},
+		{Name: "api", Health: "Degraded", AutoSync: new(false)},
+		{Name: "db"},
+	}
+	want := []types.ApplicationSummaryV2{
+		{
+			Name:        "web",
+			Project:     "production",
+			Destination: &types.ApplicationSummaryDestination{Cluster: "prod", Namespace: "web"},
+			Status:      &types.ApplicationSummaryStatus{Health: "Healthy", Sync: "Synced", Revision: "abc123"},
+		},
+		{Name: "api", Status: &types.ApplicationSummaryStatus{Health: "Degraded"}, AutoSync: new(false)},
+		{Name: "db"},
+	}
+
+	if got := SummariesV2(summaries); !reflect.DeepEqual(got, want) {
+		t.Errorf("SummariesV2() = %+v, want %+v", got, want)
+	}
+}
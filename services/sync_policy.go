@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sort"
+
+	"argocd-proxy/types"
+)
+
+// AutoSync reports whether ArgoCD syncs an application automatically: its sync policy has
+// an automated section that is not turned off with enabled: false
+func AutoSync(app types.ArgocdApplication) bool {
+	policy := app.Spec.SyncPolicy
+	if policy == nil || policy.Automated == nil {
+		return false
+	}
+	return policy.Automated.Enabled == nil || *policy.Automated.Enabled
+}
+
+// BuildAutoSyncReport lists the automated sync policy of each auto-sync application of the
+// list, sorted by name, and counts the manual ones. Slices are never nil so they render
+// as [].
+func BuildAutoSyncReport(list types.ArgocdApplicationList) types.AutoSyncResponse {
+	report := types.AutoSyncResponse{Applications: []types.AutoSyncApplication{}}
+	for _, app := range list.Items {
+		if !AutoSync(app) {
+			report.Manual++
+			continue
+		}
+
+		automated := app.Spec.SyncPolicy.Automated
+		entry := types.AutoSyncApplication{
+			Name:        app.Metadata.Name,
+			Namespace:   app.Metadata.Namespace,
+			Project:     app.Spec.Project,
+			Prune:       automated.Prune,
+			SelfHeal:    automated.SelfHeal,
+			AllowEmpty:  automated.AllowEmpty,
+			SyncOptions: append([]string{}, app.Spec.SyncPolicy.SyncOptions...),
+		}
+		if entry.Prune {
+			report.Prune++
+		}
+		if entry.SelfHeal {
+			report.SelfHeal++
+		}
+		report.Applications = append(report.Applications, entry)
+	}
+
+	sort.Slice(report.Applications, func(i, j int) bool {
+		a, b := report.Applications[i], report.Applications[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Namespace < b.Namespace
+	})
+	report.AutoSync = len(report.Applications)
+	return report
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// syncPolicyFixture is a recorded application list with every shape of sync policy
+const syncPolicyFixture = `{
+  "items": [
+    {
+      "metadata": {"name": "payments-api", "namespace": "argocd"},
+      "spec": {
+        "project": "payments",
+        "syncPolicy": {
+          "automated": {"prune": true, "selfHeal": true, "allowEmpty": false},
+          "syncOptions": ["CreateNamespace=true", "PruneLast=true"],
+          "retry": {"limit": 5, "backoff": {"duration": "5s", "factor": 2, "maxDuration": "3m"}}
+        }
+      }
+    },
+    {
+      "metadata": {"name": "ledger", "namespace": "argocd"},
+      "spec": {"project": "ledger", "syncPolicy": {"automated": {"selfHeal": true}}}
+    },
+    {
+      "metadata": {"name": "reports", "namespace": "argocd"},
+      "spec": {"project": "payments", "syncPolicy": {"automated": {"prune": true, "enabled": false}}}
+    },
+    {
+      "metadata": {"name": "fraud-check", "namespace": "argocd"},
+      "spec": {"project": "payments", "syncPolicy": {"syncOptions": ["Validate=false"]}}
+    },
+    {
+      "metadata": {"name": "billing", "namespace": "argocd"},
+      "spec": {"project": "billing"}
+    }
+  ]
+}`
+
+func decodeSyncPolicyFixture(t *testing.T) types.ArgocdApplicationList {
+	t.Helper()
+	var list types.ArgocdApplicationList
+	if err := json.Unmarshal([]byte(syncPolicyFixture), &list); err != nil {
+		t.Fatalf("failed to decode sync policy fixture: %v", err)
+	}
+	return list
+}
+
+func TestDecodeSyncPolicy(t *testing.T) {
+	list := decodeSyncPolicyFixture(t)
+
+	want := &types.ArgocdSyncPolicy{
+		Automated:   &types.ArgocdSyncPolicyAutomated{Prune: true, SelfHeal: true},
+		Retry:       &types.ArgocdSyncPolicyRetry{Limit: 5, Backoff: &types.ArgocdSyncPolicyRetryBackoff{Duration: "5s", Factor: new(int64(2)), MaxDuration: "3m"}},
+		SyncOptions: []string{"CreateNamespace=true", "PruneLast=true"},
+	}
+	if got := list.Items[0].Spec.SyncPolicy; !reflect.DeepEqual(got, want) {
+		t.Errorf("payments-api syncPolicy = %+v, want %+v", got, want)
+	}
+	if got := list.Items[3].Spec.SyncPolicy; got == nil || got.Automated != nil || !reflect.DeepEqual(got.SyncOptions, []string{"Validate=false"}) {
+		t.Errorf("fraud-check syncPolicy = %+v, want only its sync options", got)
+	}
+	if got := list.Items[4].Spec.SyncPolicy; got != nil {
+		t.Errorf("billing syncPolicy = %+v, want nil without a syncPolicy", got)
+	}
+
+	// A policy survives a round trip, so cached and served applications keep it
+	data, err := json.Marshal(list.Items[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded types.ArgocdApplication
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded.Spec.SyncPolicy, want) {
+		t.Errorf("round-tripped syncPolicy = %+v, %v, want %+v", decoded.Spec.SyncPolicy, err, want)
+	}
+}
+
+func TestAutoSync(t *testing.T) {
+	want := map[string]bool{"payments-api": true, "ledger": true, "reports": false, "fraud-check": false, "billing": false}
+	for _, app := range decodeSyncPolicyFixture(t).Items {
+		if got := AutoSync(app); got != want[app.Metadata.Name] {
+			t.Errorf("AutoSync(%s) = %v, want %v", app.Metadata.Name, got, want[app.Metadata.Name])
+		}
+	}
+}
+
+func TestBuildAutoSyncReport(t *testing.T) {
+	report := BuildAutoSyncReport(decodeSyncPolicyFixture(t))
+
+	want := types.AutoSyncResponse{
+		Applications: []types.AutoSyncApplication{
+			{Name: "ledger", Namespace: "argocd", Project: "ledger", SelfHeal: true, SyncOptions: []string{}},
+			{Name: "payments-api", Namespace: "argocd", Project: "payments", Prune: true, SelfHeal: true, SyncOptions: []string{"CreateNamespace=true", "PruneLast=true"}},
+		},
+		AutoSync: 2,
+		Prune:    1,
+		SelfHeal: 2,
+		Manual:   3,
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("BuildAutoSyncReport() = %+v, want %+v", report, want)
+	}
+
+	if empty := BuildAutoSyncReport(types.ArgocdApplicationList{}); empty.Applications == nil {
+		t.Error("BuildAutoSyncReport() applications should be an empty list, not nil")
+	}
+}
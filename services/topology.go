@@ -0,0 +1,104 @@
+package services
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"argocd-proxy/types"
+)
+
+// NormalizeRepoURL returns a repository URL as host/path, so the https, ssh and scp-like
+// spellings of one repository compare equal: the scheme, user, port and a trailing .git
+// or slash are dropped and the host is lowercased. "https://GitHub.com/company/api.git",
+// "ssh://git@github.com:22/company/api" and "git@github.com:company/api" all become
+// "github.com/company/api". Values that are not URLs are returned trimmed.
+func NormalizeRepoURL(repoURL string) string {
+	repoURL = strings.TrimSpace(repoURL)
+
+	var host, path string
+	if strings.Contains(repoURL, "://") {
+		parsed, err := url.Parse(repoURL)
+		if err != nil || parsed.Host == "" {
+			return repoURL
+		}
+		host, path = parsed.Hostname(), parsed.Path
+	} else if at, rest, ok := strings.Cut(repoURL, "@"); ok && !strings.Contains(at, "/") {
+		// scp-like syntax: user@host:path
+		h, p, ok := strings.Cut(rest, ":")
+		if !ok {
+			return repoURL
+		}
+		host, path = h, p
+	} else {
+		return repoURL
+	}
+
+	host = strings.ToLower(host)
+	if path = strings.TrimSuffix(strings.Trim(path, "/"), ".git"); path == "" {
+		return host
+	}
+	return host + "/" + path
+}
+
+// applicationSources returns the sources of an application, one for single-source ones
+func applicationSources(app types.ArgocdApplication) []types.ArgocdApplicationSource {
+	if len(app.Spec.Sources) > 0 {
+		return app.Spec.Sources
+	}
+	if app.Spec.Source.RepoURL == "" {
+		return nil
+	}
+	return []types.ArgocdApplicationSource{app.Spec.Source}
+}
+
+// BuildRepositoryTopology groups the applications by the normalized URLs of the
+// repositories they deploy from, keeping the repositories used by at least minApps
+// distinct applications. Each repository lists its applications sorted by name and path.
+func BuildRepositoryTopology(list types.ArgocdApplicationList, minApps int) types.RepositoryTopologyResponse {
+	repositories := make(map[string]types.RepositoryApplications)
+	for _, app := range list.Items {
+		counted := make(map[string]bool)
+		for _, source := range applicationSources(app) {
+			repo := NormalizeRepoURL(source.RepoURL)
+			entry := repositories[repo]
+			entry.Applications = append(entry.Applications, types.RepositoryApplication{
+				Name:           app.Metadata.Name,
+				Project:        app.Spec.Project,
+				Path:           source.Path,
+				TargetRevision: source.TargetRevision,
+			})
+			if !counted[repo] {
+				counted[repo] = true
+				entry.Count++
+			}
+			repositories[repo] = entry
+		}
+	}
+
+	response := types.RepositoryTopologyResponse{Repositories: make(map[string]types.RepositoryApplications)}
+	for repo, entry := range repositories {
+		if entry.Count < minApps {
+			continue
+		}
+		sort.Slice(entry.Applications, func(i, j int) bool {
+			a, b := entry.Applications[i], entry.Applications[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Path < b.Path
+		})
+		response.Repositories[repo] = entry
+	}
+	response.RepositoryCount = len(response.Repositories)
+	for _, app := range list.Items {
+		if slices.ContainsFunc(applicationSources(app), func(source types.ArgocdApplicationSource) bool {
+			_, ok := response.Repositories[NormalizeRepoURL(source.RepoURL)]
+			return ok
+		}) {
+			response.ApplicationCount++
+		}
+	}
+	return response
+}
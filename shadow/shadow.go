@@ -0,0 +1,211 @@
+// Package shadow runs a candidate implementation of an operation alongside the one in
+// use, to check that a replacement returns the same results before switching to it. The
+// caller always gets the current implementation's result. The candidate runs in the
+// background under its own deadline, and the two results are compared once both are in.
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"argocd-proxy/metrics"
+)
+
+// Comparison results, the result label of the shadow comparisons metric
+const (
+	// ResultMatch means both implementations returned the same keys
+	ResultMatch = "match"
+	// ResultMismatch means the implementations returned different keys
+	ResultMismatch = "mismatch"
+	// ResultError means one of the implementations failed, so nothing was compared
+	ResultError = "error"
+	// ResultTimeout means the candidate did not finish within the shadow timeout
+	ResultTimeout = "timeout"
+	// ResultSkipped means no shadow slot freed up within the latency budget
+	ResultSkipped = "skipped"
+)
+
+// Options configures a Runner
+type Options struct {
+	// Timeout bounds each candidate run
+	Timeout time.Duration
+	// Budget is the longest a call waits for a free shadow slot before it skips its
+	// shadow; it is all the latency shadowing can add to a call
+	Budget time.Duration
+	// MaxInFlight bounds the candidate runs in progress
+	MaxInFlight int
+	// SampleSize bounds the number of differing keys reported per side of a mismatch
+	SampleSize int
+	// OnCompare, if set, is called with every comparison after it is counted and logged
+	OnCompare func(Comparison)
+}
+
+// Comparison is the outcome of shadowing one call
+type Comparison struct {
+	Method string
+	Result string
+	// PrimaryCount and CandidateCount are the numbers of keys each implementation returned
+	PrimaryCount   int
+	CandidateCount int
+	// OnlyPrimary and OnlyCandidate are sorted samples of the keys returned by only one
+	// of the implementations
+	OnlyPrimary   []string
+	OnlyCandidate []string
+	// Err is the failure of an implementation for ResultError and ResultTimeout
+	Err error
+}
+
+// Runner shadows calls, running at most Options.MaxInFlight candidates at a time
+type Runner struct {
+	opts  Options
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New returns a Runner with opts
+func New(opts Options) *Runner {
+	return &Runner{opts: opts, slots: make(chan struct{}, max(opts.MaxInFlight, 1))}
+}
+
+// Wait blocks until the candidates in progress have finished and been compared
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Compare returns the result of primary and, when r is not nil, runs candidate as its
+// shadow. The keys of both results are compared in the background; keys must not modify
+// the result. A call whose shadow cannot start within the budget runs without it.
+func Compare[T any](ctx context.Context, r *Runner, method string, primary, candidate func(context.Context) (T, error), keys func(T) []string) (T, error) {
+	if r == nil || !r.acquire(ctx) {
+		if r != nil {
+			r.report(Comparison{Method: method, Result: ResultSkipped})
+		}
+		return primary(ctx)
+	}
+
+	primaryKeys := make(chan []string, 1)
+	primaryErr := make(chan error, 1)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.release()
+
+		// The candidate must not be canceled by the end of the request nor report into
+		// it, so it runs on a context of its own
+		shadowCtx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+		defer cancel()
+		result, err := candidate(shadowCtx)
+
+		comparison := Comparison{Method: method}
+		wantErr := <-primaryErr
+		want := <-primaryKeys
+		switch {
+		case err != nil && errors.Is(shadowCtx.Err(), context.DeadlineExceeded):
+			comparison.Result, comparison.Err = ResultTimeout, err
+		case err != nil:
+			comparison.Result, comparison.Err = ResultError, fmt.Errorf("candidate: %w", err)
+		case wantErr != nil:
+			comparison.Result, comparison.Err = ResultError, fmt.Errorf("primary: %w", wantErr)
+		default:
+			r.diff(&comparison, want, keys(result))
+		}
+		r.report(comparison)
+	}()
+
+	result, err := primary(ctx)
+	if err == nil {
+		// Keys are taken before the caller gets the result and may modify it
+		primaryKeys <- keys(result)
+	} else {
+		primaryKeys <- nil
+	}
+	primaryErr <- err
+	return result, err
+}
+
+// acquire takes a shadow slot, waiting at most the budget or until ctx ends
+func (r *Runner) acquire(ctx context.Context) bool {
+	select {
+	case r.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if r.opts.Budget <= 0 {
+		return false
+	}
+	timer := time.NewTimer(r.opts.Budget)
+	defer timer.Stop()
+	select {
+	case r.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a shadow slot
+func (r *Runner) release() {
+	<-r.slots
+}
+
+// diff fills comparison with the keys returned by only one side
+func (r *Runner) diff(comparison *Comparison, primary, candidate []string) {
+	comparison.PrimaryCount, comparison.CandidateCount = len(primary), len(candidate)
+	inPrimary := make(map[string]bool, len(primary))
+	for _, key := range primary {
+		inPrimary[key] = true
+	}
+	inCandidate := make(map[string]bool, len(candidate))
+	for _, key := range candidate {
+		inCandidate[key] = true
+	}
+	for key := range inPrimary {
+		if !inCandidate[key] {
+			comparison.OnlyPrimary = append(comparison.OnlyPrimary, key)
+		}
+	}
+	for key := range inCandidate {
+		if !inPrimary[key] {
+			comparison.OnlyCandidate = append(comparison.OnlyCandidate, key)
+		}
+	}
+
+	// Duplicate keys only show up in the counts
+	comparison.Result = ResultMatch
+	if len(comparison.OnlyPrimary) > 0 || len(comparison.OnlyCandidate) > 0 || len(primary) != len(candidate) {
+		comparison.Result = ResultMismatch
+	}
+	comparison.OnlyPrimary = r.sample(comparison.OnlyPrimary)
+	comparison.OnlyCandidate = r.sample(comparison.OnlyCandidate)
+}
+
+// sample returns the first SampleSize of keys in sorted order
+func (r *Runner) sample(keys []string) []string {
+	slices.Sort(keys)
+	if r.opts.SampleSize > 0 && len(keys) > r.opts.SampleSize {
+		keys = keys[:r.opts.SampleSize]
+	}
+	return keys
+}
+
+// report counts the comparison and logs mismatches and failures
+func (r *Runner) report(comparison Comparison) {
+	metrics.ShadowComparisonsTotal.WithLabelValues(comparison.Method, comparison.Result).Inc()
+	switch comparison.Result {
+	case ResultMismatch:
+		log.Printf("WARNING: Shadow mismatch method=%s result=%s primaryCount=%d candidateCount=%d onlyPrimary=%q onlyCandidate=%q",
+			comparison.Method, comparison.Result, comparison.PrimaryCount, comparison.CandidateCount, comparison.OnlyPrimary, comparison.OnlyCandidate)
+	case ResultError, ResultTimeout:
+		log.Printf("Shadow comparison failed method=%s result=%s error=%q", comparison.Method, comparison.Result, comparison.Err)
+	}
+	if r.opts.OnCompare != nil {
+		r.opts.OnCompare(comparison)
+	}
+}
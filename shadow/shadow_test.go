@@ -0,0 +1,210 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/metrics"
+)
+
+// collect returns a Runner with opts whose comparisons are appended to the returned slice
+func collect(opts Options) (*Runner, func() []Comparison) {
+	var mu sync.Mutex
+	var comparisons []Comparison
+	opts.OnCompare = func(c Comparison) {
+		mu.Lock()
+		defer mu.Unlock()
+		comparisons = append(comparisons, c)
+	}
+	r := New(opts)
+	return r, func() []Comparison {
+		r.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(comparisons)
+	}
+}
+
+// names returns an implementation returning keys
+func names(keys ...string) func(context.Context) ([]string, error) {
+	return func(context.Context) ([]string, error) { return slices.Clone(keys), nil }
+}
+
+// identity copies keys, as key functions must not return memory shared with the result
+func identity(keys []string) []string { return slices.Clone(keys) }
+
+func TestCompareMismatch(t *testing.T) {
+	before := testutil.ToFloat64(metrics.ShadowComparisonsTotal.WithLabelValues("List", ResultMismatch))
+	r, comparisons := collect(Options{Timeout: time.Second, MaxInFlight: 1, SampleSize: 2})
+
+	got, err := Compare(context.Background(), r, "List",
+		names("a", "b", "c", "d", "e"),
+		names("a", "x", "y", "z"),
+		identity)
+	if err != nil || !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatalf("Compare() = %v, %v, want the primary result", got, err)
+	}
+
+	c := comparisons()
+	if len(c) != 1 {
+		t.Fatalf("comparisons = %+v, want 1", c)
+	}
+	if c[0].Result != ResultMismatch || c[0].PrimaryCount != 5 || c[0].CandidateCount != 4 {
+		t.Errorf("comparison = %+v, want a mismatch of 5 and 4 keys", c[0])
+	}
+	// Samples are sorted and bounded by SampleSize
+	if !slices.Equal(c[0].OnlyPrimary, []string{"b", "c"}) || !slices.Equal(c[0].OnlyCandidate, []string{"x", "y"}) {
+		t.Errorf("samples = %v, %v, want [b c] and [x y]", c[0].OnlyPrimary, c[0].OnlyCandidate)
+	}
+	if got := testutil.ToFloat64(metrics.ShadowComparisonsTotal.WithLabelValues("List", ResultMismatch)) - before; got != 1 {
+		t.Errorf("mismatch counter increased by %v, want 1", got)
+	}
+}
+
+func TestCompareMatch(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: time.Second, Budget: time.Second, MaxInFlight: 1})
+
+	// Order does not matter, duplicates do
+	Compare(context.Background(), r, "List", names("a", "b"), names("b", "a"), identity)
+	Compare(context.Background(), r, "List", names("a", "b"), names("a", "b", "b"), identity)
+
+	c := comparisons()
+	if len(c) != 2 || c[0].Result != ResultMatch || c[1].Result != ResultMismatch {
+		t.Errorf("comparisons = %+v, want a match then a mismatch", c)
+	}
+}
+
+func TestCompareKeysTakenBeforeReturn(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: time.Second, MaxInFlight: 1})
+	release := make(chan struct{})
+
+	got, _ := Compare(context.Background(), r, "List",
+		names("a", "b"),
+		func(context.Context) ([]string, error) {
+			<-release
+			return []string{"a", "b"}, nil
+		},
+		identity)
+	// The caller owns the result as soon as it is returned
+	got[0] = "changed"
+	close(release)
+
+	if c := comparisons(); len(c) != 1 || c[0].Result != ResultMatch {
+		t.Errorf("comparisons = %+v, want a match of the result as returned", c)
+	}
+}
+
+func TestCompareCandidateTimeout(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: 200 * time.Millisecond, MaxInFlight: 1})
+
+	start := time.Now()
+	_, err := Compare(context.Background(), r, "List",
+		names("a"),
+		func(ctx context.Context) ([]string, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		identity)
+	if elapsed := time.Since(start); err != nil || elapsed > 100*time.Millisecond {
+		t.Errorf("Compare() took %s, error = %v, want the primary result without waiting for the candidate", elapsed, err)
+	}
+
+	c := comparisons()
+	if len(c) != 1 || c[0].Result != ResultTimeout || !errors.Is(c[0].Err, context.DeadlineExceeded) {
+		t.Errorf("comparisons = %+v, want a timeout", c)
+	}
+}
+
+func TestCompareCandidateOutlivesRequest(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: time.Second, MaxInFlight: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+
+	Compare(ctx, r, "List",
+		names("a"),
+		func(ctx context.Context) ([]string, error) {
+			<-release
+			return []string{"a"}, ctx.Err()
+		},
+		identity)
+	// The request ending does not cancel its shadow
+	cancel()
+	close(release)
+
+	if c := comparisons(); len(c) != 1 || c[0].Result != ResultMatch {
+		t.Errorf("comparisons = %+v, want a match", c)
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: time.Second, Budget: time.Second, MaxInFlight: 1})
+	failing := func(context.Context) ([]string, error) { return nil, fmt.Errorf("upstream down") }
+
+	// A failing candidate does not affect the caller
+	got, err := Compare(context.Background(), r, "List", names("a"), failing, identity)
+	if err != nil || !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Compare() = %v, %v, want the primary result", got, err)
+	}
+	// A failing primary is returned as is
+	if _, err := Compare(context.Background(), r, "List", failing, names("a"), identity); err == nil {
+		t.Error("Compare() error = nil, want the primary's error")
+	}
+
+	c := comparisons()
+	if len(c) != 2 || c[0].Result != ResultError || c[1].Result != ResultError {
+		t.Fatalf("comparisons = %+v, want two errors", c)
+	}
+	if c[0].Err.Error() != "candidate: upstream down" || c[1].Err.Error() != "primary: upstream down" {
+		t.Errorf("errors = %v, %v, want them to name the failing side", c[0].Err, c[1].Err)
+	}
+}
+
+func TestCompareSkippedWithinBudget(t *testing.T) {
+	r, comparisons := collect(Options{Timeout: time.Second, Budget: 20 * time.Millisecond, MaxInFlight: 1})
+	release := make(chan struct{})
+
+	// The only slot is held by a slow candidate
+	Compare(context.Background(), r, "List", names("a"), func(context.Context) ([]string, error) {
+		<-release
+		return []string{"a"}, nil
+	}, identity)
+
+	start := time.Now()
+	candidateRan := false
+	got, err := Compare(context.Background(), r, "List", names("b"), func(context.Context) ([]string, error) {
+		candidateRan = true
+		return nil, nil
+	}, identity)
+	elapsed := time.Since(start)
+	close(release)
+
+	if err != nil || !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Compare() = %v, %v, want the primary result", got, err)
+	}
+	if elapsed < 20*time.Millisecond || elapsed > 200*time.Millisecond {
+		t.Errorf("Compare() took %s, want it to wait out the 20ms budget and no longer", elapsed)
+	}
+
+	c := comparisons()
+	if candidateRan || len(c) != 2 || c[0].Result != ResultSkipped || c[1].Result != ResultMatch {
+		t.Errorf("comparisons = %+v, candidate ran = %v, want the second call skipped", c, candidateRan)
+	}
+}
+
+func TestCompareNilRunner(t *testing.T) {
+	candidateRan := false
+	got, err := Compare(context.Background(), nil, "List", names("a"), func(context.Context) ([]string, error) {
+		candidateRan = true
+		return nil, nil
+	}, identity)
+	if err != nil || !slices.Equal(got, []string{"a"}) || candidateRan {
+		t.Errorf("Compare() = %v, %v, candidate ran = %v, want only the primary", got, err, candidateRan)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/timing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serverTimingHeader carries the request's timing breakdown for browser devtools
+const serverTimingHeader = "Server-Timing"
+
+// timingWriter sets the Server-Timing header just before the response headers are sent,
+// when the handler has made its upstream calls and serialized the body. gin renders a
+// body by setting the status and then serializing it in full before the first write, so
+// the time between the two is the encode phase.
+type timingWriter struct {
+	gin.ResponseWriter
+	recorder    *timing.Recorder
+	renderStart time.Time
+	done        bool
+}
+
+// WriteHeader marks the start of rendering; gin only sends the status with the headers
+func (w *timingWriter) WriteHeader(code int) {
+	if w.renderStart.IsZero() {
+		w.renderStart = time.Now()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// setTimingHeader adds the Server-Timing header once, unless the headers are already sent
+func (w *timingWriter) setTimingHeader() {
+	if w.done {
+		return
+	}
+	w.done = true
+	if !w.renderStart.IsZero() {
+		w.recorder.Add(timing.PhaseEncode, time.Since(w.renderStart))
+	}
+	if !w.ResponseWriter.Written() {
+		w.Header().Set(serverTimingHeader, w.recorder.Breakdown().ServerTiming())
+	}
+}
+
+// WriteHeaderNow sets the Server-Timing header before sending the headers
+func (w *timingWriter) WriteHeaderNow() {
+	w.setTimingHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write sets the Server-Timing header before sending the first body bytes
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.setTimingHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString sets the Server-Timing header before sending the first body bytes
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.setTimingHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestTimingMiddleware times every request, splitting its duration into phases: token
+// handling, waiting on ArgoCD and filtering (reported by the auth and services layers
+// through the request context), serialization, and the rest of the proxy's processing. The breakdown is sent as a Server-Timing header, and requests
+// slower than their route's threshold increment http_slow_requests_total and are logged.
+// Long-poll requests (?watchAfter=) wait by design and are never counted as slow.
+func (s *Server) requestTimingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, recorder := timing.NewContext(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &timingWriter{ResponseWriter: c.Writer, recorder: recorder}
+		c.Writer = writer
+		c.Header("Timing-Allow-Origin", "*")
+		c.Next()
+		// Responses without a body (e.g. 304) have not sent their headers yet
+		writer.setTimingHeader()
+		c.Writer = writer.ResponseWriter
+
+		route := c.FullPath()
+		threshold := s.config.SlowRequestThresholdFor(route)
+		if route == "" || threshold <= 0 || c.Query("watchAfter") != "" {
+			return
+		}
+
+		breakdown := recorder.Breakdown()
+		if breakdown.Total < threshold {
+			return
+		}
+		metrics.HTTPSlowRequestsTotal.WithLabelValues(route).Inc()
+		log.Printf("WARNING: slow request method=%s path=%s route=%s status=%d total_ms=%d auth_ms=%d upstream_ms=%d filter_ms=%d encode_ms=%d app_ms=%d threshold_ms=%d",
+			c.Request.Method, c.Request.URL.Path, route, c.Writer.Status(),
+			breakdown.Total.Milliseconds(), breakdown.Auth.Milliseconds(), breakdown.Upstream.Milliseconds(),
+			breakdown.Filter.Milliseconds(), breakdown.Encode.Milliseconds(), breakdown.App.Milliseconds(),
+			threshold.Milliseconds())
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// serverTimingDuration returns the dur= value of a metric in a Server-Timing header
+func serverTimingDuration(t *testing.T, header, name string) float64 {
+	t.Helper()
+	for _, metric := range strings.Split(header, ",") {
+		parts := strings.Split(strings.TrimSpace(metric), ";")
+		if parts[0] != name {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if value, ok := strings.CutPrefix(param, "dur="); ok {
+				dur, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					t.Fatalf("invalid %s duration in Server-Timing %q: %v", name, header, err)
+				}
+				return dur
+			}
+		}
+	}
+	t.Fatalf("Server-Timing %q has no %s duration", header, name)
+	return 0
+}
+
+func TestSlowRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		delay      time.Duration
+		threshold  time.Duration
+		overrides  map[string]time.Duration
+		wantRoute  string
+		wantSlow   bool
+		wantStatus int
+	}{
+		{
+			name:       "delayed upstream exceeds threshold",
+			path:       "/applications",
+			delay:      30 * time.Millisecond,
+			threshold:  20 * time.Millisecond,
+			wantRoute:  "/applications",
+			wantSlow:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "route override raises threshold",
+			path:       "/applications",
+			delay:      30 * time.Millisecond,
+			threshold:  20 * time.Millisecond,
+			overrides:  map[string]time.Duration{"/applications": time.Second},
+			wantRoute:  "/applications",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "route override enables a single route",
+			path:       "/applications",
+			delay:      30 * time.Millisecond,
+			overrides:  map[string]time.Duration{"/applications": 20 * time.Millisecond},
+			wantRoute:  "/applications",
+			wantSlow:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "disabled by default",
+			path:       "/applications",
+			delay:      30 * time.Millisecond,
+			wantRoute:  "/applications",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "fast route stays below threshold",
+			path:       "/projects",
+			delay:      30 * time.Millisecond,
+			threshold:  20 * time.Millisecond,
+			wantRoute:  "/projects",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := metrics.Default()
+			metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+			defer metrics.SetDefault(original)
+
+			server := setupTestServer()
+			server.config.SlowRequestThreshold = tt.threshold
+			server.config.SlowRequestRouteThresholds = tt.overrides
+			server.argocdService.(*MockArgocdService).delay = tt.delay
+
+			w := serveMethod(server, http.MethodGet, tt.path, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			wantCount := 0.0
+			if tt.wantSlow {
+				wantCount = 1
+			}
+			if got := testutil.ToFloat64(metrics.HTTPSlowRequestsTotal.WithLabelValues(tt.wantRoute)); got != wantCount {
+				t.Errorf("http_slow_requests_total{path=%q} = %v, want %v", tt.wantRoute, got, wantCount)
+			}
+		})
+	}
+}
+
+func TestServerTimingHeader(t *testing.T) {
+	server := setupTestServer()
+	server.argocdService.(*MockArgocdService).delay = 30 * time.Millisecond
+
+	w := serveMethod(server, http.MethodGet, "/applications", nil)
+	header := w.Header().Get(serverTimingHeader)
+	if header == "" {
+		t.Fatal("response has no Server-Timing header")
+	}
+
+	upstream := serverTimingDuration(t, header, "upstream")
+	app := serverTimingDuration(t, header, "app")
+	total := serverTimingDuration(t, header, "total")
+	if upstream < 30 {
+		t.Errorf("upstream duration = %vms, want at least the 30ms upstream delay", upstream)
+	}
+	if total < upstream || app < 0 {
+		t.Errorf("Server-Timing %q: total must include upstream and app time", header)
+	}
+
+	// Responses without a body carry the header too
+	head := serveMethod(server, http.MethodHead, "/applications", nil)
+	if head.Header().Get(serverTimingHeader) == "" {
+		t.Error("HEAD response has no Server-Timing header")
+	}
+
+	cached, _ := setupResponseCacheServer()
+	etag := serveMethod(cached, http.MethodGet, "/applications", nil).Header().Get("ETag")
+	notModified := serveMethod(cached, http.MethodGet, "/applications", map[string]string{"If-None-Match": etag})
+	if notModified.Code != http.StatusNotModified || notModified.Header().Get(serverTimingHeader) == "" {
+		t.Errorf("conditional request = %d with Server-Timing %q, want %d with the header",
+			notModified.Code, notModified.Header().Get(serverTimingHeader), http.StatusNotModified)
+	}
+}
+
+func TestServerTimingPhases(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.delay = 20 * time.Millisecond
+	mockService.applications = types.ArgocdApplicationList{Items: []types.ArgocdApplication{
+		{Metadata: types.ArgocdApplicationMetadata{Name: "web"}, Spec: types.ArgocdApplicationSpec{Project: "web-app"}},
+	}}
+
+	w := serveMethod(server, http.MethodGet, "/applications?health=Healthy", nil)
+	header := w.Header().Get(serverTimingHeader)
+
+	// Phases are listed in request order, with the total last
+	var names []string
+	for _, metric := range strings.Split(header, ",") {
+		names = append(names, strings.Split(strings.TrimSpace(metric), ";")[0])
+	}
+	if want := []string{"auth", "upstream", "filter", "encode", "app", "total"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Server-Timing metrics = %v, want %v", names, want)
+	}
+
+	var sum float64
+	for _, phase := range []string{"auth", "upstream", "filter", "encode", "app"} {
+		dur := serverTimingDuration(t, header, phase)
+		if dur < 0 {
+			t.Errorf("%s duration = %vms, want non-negative", phase, dur)
+		}
+		sum += dur
+	}
+	total := serverTimingDuration(t, header, "total")
+	if upstream := serverTimingDuration(t, header, "upstream"); upstream < 20 {
+		t.Errorf("upstream duration = %vms, want at least the 20ms upstream delay", upstream)
+	}
+	// Each phase is rounded to a microsecond
+	if diff := sum - total; diff > 0.01 || diff < -0.01 {
+		t.Errorf("phases sum to %vms, want the %vms total", sum, total)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// snapshotUpstream is a fake ArgoCD whose application set the test edits between polls
+type snapshotUpstream struct {
+	mu   sync.Mutex
+	apps map[string]string // name -> health
+}
+
+func (u *snapshotUpstream) set(name, health string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if health == "" {
+		delete(u.apps, name)
+		return
+	}
+	u.apps[name] = health
+}
+
+func (u *snapshotUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	list := types.ArgocdApplicationList{}
+	for name, health := range u.apps {
+		app := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name, Namespace: "argocd"}, Spec: types.ArgocdApplicationSpec{Project: "web-app"}}
+		app.Status.Health.Status = health
+		list.Items = append(list.Items, app)
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func getDelta(t *testing.T, server *Server, query string) types.ArgocdApplicationList {
+	t.Helper()
+	w := serveMethod(server, http.MethodGet, "/applications?sort=name"+query, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /applications?sort=name%s status = %d, want 200: %s", query, w.Code, w.Body.String())
+	}
+	var list types.ArgocdApplicationList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return list
+}
+
+func itemNames(list types.ArgocdApplicationList) []string {
+	names := []string{}
+	for _, app := range list.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	return names
+}
+
+func TestApplicationsSince(t *testing.T) {
+	upstream := &snapshotUpstream{apps: map[string]string{"web": "Healthy", "api": "Healthy", "docs": "Healthy"}}
+	fake := httptest.NewServer(upstream)
+	defer fake.Close()
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.CacheTTL = time.Minute
+	server.config.SnapshotHistoryDepth = 3
+	service := services.NewArgocdService(server.config, server.authService, nil)
+	server.argocdService = service
+	server.setupRouter()
+	poller := services.NewPoller(service, time.Second)
+	ctx := context.Background()
+
+	poller.Poll(ctx)
+	first := getDelta(t, server, "")
+	if first.SnapshotToken == "" || len(first.Items) != 3 || first.Full {
+		t.Fatalf("initial list = %v, token %q, want 3 applications with a token", itemNames(first), first.SnapshotToken)
+	}
+
+	// Nothing changed: an empty delta with the same token
+	unchanged := getDelta(t, server, "&since="+first.SnapshotToken)
+	if len(unchanged.Items) != 0 || len(unchanged.Removed) != 0 || unchanged.Full || unchanged.SnapshotToken != first.SnapshotToken {
+		t.Errorf("unchanged delta = %v removed %v full %v token %q, want empty", itemNames(unchanged), unchanged.Removed, unchanged.Full, unchanged.SnapshotToken)
+	}
+
+	// Modify one application and add another
+	upstream.set("api", "Degraded")
+	upstream.set("blog", "Progressing")
+	poller.Poll(ctx)
+	second := getDelta(t, server, "&since="+first.SnapshotToken)
+	if got := itemNames(second); !reflect.DeepEqual(got, []string{"api", "blog"}) || len(second.Removed) != 0 {
+		t.Errorf("delta after add and modify = %v removed %v, want [api blog] and none removed", got, second.Removed)
+	}
+	if second.SnapshotToken == first.SnapshotToken {
+		t.Error("expected a new token after the application set changed")
+	}
+
+	// Remove one application
+	upstream.set("docs", "")
+	poller.Poll(ctx)
+	third := getDelta(t, server, "&since="+second.SnapshotToken)
+	if len(third.Items) != 0 || !reflect.DeepEqual(third.Removed, []types.ApplicationRef{{Namespace: "argocd", Name: "docs"}}) {
+		t.Errorf("delta after remove = %v removed %v, want only docs removed", itemNames(third), third.Removed)
+	}
+
+	// Changes accumulate across snapshots
+	cumulative := getDelta(t, server, "&since="+first.SnapshotToken)
+	if got := itemNames(cumulative); !reflect.DeepEqual(got, []string{"api", "blog"}) || !reflect.DeepEqual(cumulative.Removed, []types.ApplicationRef{{Namespace: "argocd", Name: "docs"}}) {
+		t.Errorf("delta since the first snapshot = %v removed %v, want [api blog] and docs removed", got, cumulative.Removed)
+	}
+
+	// Filters apply to both snapshots: leaving the filter counts as removed
+	filtered := getDelta(t, server, "&health=Healthy&since="+first.SnapshotToken)
+	if len(filtered.Items) != 0 || !reflect.DeepEqual(filtered.Removed, []types.ApplicationRef{{Namespace: "argocd", Name: "api"}, {Namespace: "argocd", Name: "docs"}}) {
+		t.Errorf("filtered delta = %v removed %v, want api and docs removed", itemNames(filtered), filtered.Removed)
+	}
+
+	// One more change pushes the first snapshot out of a history of 3
+	upstream.set("web", "Degraded")
+	poller.Poll(ctx)
+	expired := getDelta(t, server, "&since="+first.SnapshotToken)
+	if !expired.Full || len(expired.Items) != 3 || len(expired.Removed) != 0 {
+		t.Errorf("response for an expired token = %v full %v, want the full list of 3", itemNames(expired), expired.Full)
+	}
+	if unknown := getDelta(t, server, "&since=bogus"); !unknown.Full || len(unknown.Items) != 3 {
+		t.Errorf("response for an unknown token = %v full %v, want the full list", itemNames(unknown), unknown.Full)
+	}
+
+	if w := serveMethod(server, http.MethodGet, "/applications?watchAfter=abc&since="+first.SnapshotToken, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("since with watchAfter status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"argocd-proxy/types"
+)
+
+// startupAuthRetry is the delay before the first retry of the initial authentication;
+// each further retry waits twice as long, up to startupAuthRetryMax
+var startupAuthRetry = time.Second
+
+// startupAuthRetryMax caps the delay between initial authentication attempts
+const startupAuthRetryMax = 30 * time.Second
+
+// awaitFirstToken obtains an ArgoCD token, retrying with a growing delay until it
+// succeeds or ctx ends. When ctx ends first, the error of the last completed attempt is
+// returned, so that wrong credentials are reported rather than the deadline.
+func awaitFirstToken(ctx context.Context, auth types.AuthServiceInterface) error {
+	delay := startupAuthRetry
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		_, err := auth.GetValidToken(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			return lastErr
+		}
+		lastErr = err
+		log.Printf("Initial ArgoCD authentication failed (attempt %d), retrying in %s: %v", attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, startupAuthRetryMax)
+	}
+}
+
+// requireFirstToken blocks until ArgoCD issues a token, for STARTUP_REQUIRE_AUTH. It
+// fails once STARTUP_AUTH_TIMEOUT passes without one.
+func (s *Server) requireFirstToken() error {
+	ctx, cancel := context.WithTimeout(s.lifecycle.Context(), s.config.StartupAuthTimeout)
+	defer cancel()
+
+	if err := awaitFirstToken(ctx, s.authService); err != nil {
+		return fmt.Errorf("no ArgoCD token obtained within STARTUP_AUTH_TIMEOUT (%s); check ARGOCD_API_URL, ARGOCD_USERNAME and ARGOCD_PASSWORD: %w",
+			s.config.StartupAuthTimeout, err)
+	}
+	log.Println("Obtained the first ArgoCD token")
+	return nil
+}
+
+// gateReadinessOnFirstToken makes /readyz report not ready until ArgoCD issues the first
+// token, which is obtained in the background, so that no traffic is routed to a proxy
+// whose credentials do not work
+func (s *Server) gateReadinessOnFirstToken() {
+	s.awaitingToken.Store(true)
+	s.lifecycle.Go("initial authentication", func(ctx context.Context) {
+		if awaitFirstToken(ctx, s.authService) == nil {
+			s.awaitingToken.Store(false)
+			log.Println("Obtained the first ArgoCD token; reporting ready")
+		}
+	})
+}
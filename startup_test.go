@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/auth"
+	"argocd-proxy/types"
+)
+
+// sessionEndpoint is a fake ArgoCD session API rejecting the credentials until accept is set
+type sessionEndpoint struct {
+	calls  atomic.Int32
+	accept atomic.Bool
+	// acceptAfter accepts the credentials from that call on, when positive
+	acceptAfter int32
+}
+
+func (e *sessionEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := e.calls.Add(1)
+	if r.URL.Path != "/session" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !e.accept.Load() && (e.acceptAfter == 0 || call < e.acceptAfter) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Invalid username or password","code":16}`))
+		return
+	}
+	json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "session-token"})
+}
+
+// setupStartupServer returns a test server authenticating against endpoint
+func setupStartupServer(t *testing.T, endpoint *sessionEndpoint, timeout time.Duration) *Server {
+	t.Helper()
+	fake := httptest.NewServer(endpoint)
+	t.Cleanup(fake.Close)
+
+	original := startupAuthRetry
+	startupAuthRetry = time.Millisecond
+	t.Cleanup(func() { startupAuthRetry = original })
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.ArgocdUsername = "admin"
+	server.config.ArgocdPassword = "wrong"
+	server.config.StartupAuthTimeout = timeout
+	server.authService = auth.NewAuthService(server.config, nil)
+	t.Cleanup(func() { server.lifecycle.Shutdown(context.Background()) })
+	return server
+}
+
+func TestRequireFirstTokenRetriesUntilAccepted(t *testing.T) {
+	endpoint := &sessionEndpoint{acceptAfter: 3}
+	server := setupStartupServer(t, endpoint, 5*time.Second)
+
+	if err := server.requireFirstToken(); err != nil {
+		t.Fatalf("requireFirstToken() error = %v, want success once the credentials are accepted", err)
+	}
+	if got := endpoint.calls.Load(); got != 3 {
+		t.Errorf("session calls = %d, want 3", got)
+	}
+}
+
+func TestRequireFirstTokenGivesUp(t *testing.T) {
+	endpoint := &sessionEndpoint{}
+	server := setupStartupServer(t, endpoint, 100*time.Millisecond)
+
+	start := time.Now()
+	err := server.requireFirstToken()
+	if err == nil {
+		t.Fatal("requireFirstToken() succeeded with rejected credentials")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("requireFirstToken() took %s, want it bounded by STARTUP_AUTH_TIMEOUT", elapsed)
+	}
+	// The credential failure is reported rather than the deadline
+	for _, want := range []string{"STARTUP_AUTH_TIMEOUT", "ARGOCD_PASSWORD", "status 401"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want it to mention %q", err, want)
+		}
+	}
+	if endpoint.calls.Load() < 2 {
+		t.Errorf("session calls = %d, want retries", endpoint.calls.Load())
+	}
+}
+
+func TestReadinessAwaitsFirstToken(t *testing.T) {
+	endpoint := &sessionEndpoint{}
+	server := setupStartupServer(t, endpoint, time.Minute)
+	server.gateReadinessOnFirstToken()
+
+	readyz := func() (int, string) {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var response types.ReadinessResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Status
+	}
+
+	// Wait for a few rejected attempts before checking that the proxy is still not ready
+	for deadline := time.Now().Add(5 * time.Second); endpoint.calls.Load() < 3 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if code, status := readyz(); code != http.StatusServiceUnavailable || status != "awaiting authentication" {
+		t.Fatalf("readyz with rejected credentials = %d %q, want 503 \"awaiting authentication\"", code, status)
+	}
+
+	endpoint.accept.Store(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, status := readyz()
+		if code == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("readyz after the credentials were accepted = %d %q, want 200", code, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/config"
+	"argocd-proxy/events"
+	"argocd-proxy/params"
+	"argocd-proxy/redact"
+	"argocd-proxy/types"
+)
+
+// secretConfigFields are the configuration fields masked in the support bundle whatever
+// their value, as some are not registered with the redactor
+var secretConfigFields = map[string]bool{
+	"ArgocdPassword":        true,
+	"TokenCacheKey":         true,
+	"AlertWebhookURL":       true,
+	"RedisPassword":         true,
+	"ClientStatsSalt":       true,
+	"OutboundProxyPassword": true,
+	"AdminToken":            true,
+	"ArgocdProjectTokens":   true,
+	"APIKeys":               true,
+}
+
+// supportBundle gathers the support bundle from state the proxy already holds, without
+// calling ArgoCD
+func (s *Server) supportBundle(now time.Time) types.SupportBundle {
+	bundle := types.SupportBundle{
+		GeneratedAt:       now.UTC(),
+		Info:              s.runtimeInfo(now),
+		Config:            sanitizedConfig(s.config),
+		HealthHistory:     s.argocdService.GetHealthHistory(),
+		TokenStatus:       redactStrings(s.authService.GetTokenStatus()),
+		Caches:            s.argocdService.CacheStats(),
+		Events:            events.Default().Recent(0),
+		LastUpstreamError: s.argocdService.LastUpstreamError(),
+	}
+	// The project groups and ignore patterns in effect may have changed since startup
+	filters := s.config.Snapshot()
+	bundle.Config["ProjectGroups"] = filters.ProjectGroups
+	bundle.Config["IgnoredProjects"] = filters.IgnoredProjects
+	if bundle.HealthHistory.Checks == nil {
+		bundle.HealthHistory.Checks = []types.HealthCheckRecord{}
+	}
+	if bundle.TokenStatus == nil {
+		bundle.TokenStatus = map[string]interface{}{}
+	}
+	if bundle.Caches == nil {
+		bundle.Caches = []types.CacheStatus{}
+	}
+	return bundle
+}
+
+// sanitizedConfig returns the exported configuration fields by name. Secret fields are
+// masked when set, credentials embedded in URLs are dropped, durations are formatted and
+// every other string goes through the central redactor.
+func sanitizedConfig(cfg *config.Config) map[string]any {
+	sanitized := make(map[string]any)
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		switch {
+		case secretConfigFields[field.Name]:
+			sanitized[field.Name] = maskedSecret(value)
+		case field.Type == reflect.TypeFor[time.Duration]():
+			sanitized[field.Name] = time.Duration(value.Int()).String()
+		case field.Type == reflect.TypeFor[map[string]time.Duration]():
+			durations := make(map[string]string, value.Len())
+			for route, d := range value.Interface().(map[string]time.Duration) {
+				durations[route] = d.String()
+			}
+			sanitized[field.Name] = durations
+		case field.Type.Kind() == reflect.String:
+			sanitized[field.Name] = redactConfigString(value.String())
+		case field.Type == reflect.TypeFor[[]string]():
+			values := make([]string, value.Len())
+			for j := range values {
+				values[j] = redactConfigString(value.Index(j).String())
+			}
+			sanitized[field.Name] = values
+		default:
+			sanitized[field.Name] = value.Interface()
+		}
+	}
+	return sanitized
+}
+
+// maskedSecret describes a secret field without its value: the mask when it is set, ""
+// when it is not, and the masked entries of secret maps and API keys so their names stay
+// visible
+func maskedSecret(value reflect.Value) any {
+	switch secret := value.Interface().(type) {
+	case map[string]string:
+		masked := make(map[string]string, len(secret))
+		for name := range secret {
+			masked[name] = redact.Mask
+		}
+		return masked
+	case []config.APIKey:
+		masked := make([]config.APIKey, len(secret))
+		for i, key := range secret {
+			masked[i] = config.APIKey{Name: key.Name, Key: redact.Mask, Groups: key.Groups}
+		}
+		return masked
+	}
+	if value.IsZero() {
+		return ""
+	}
+	return redact.Mask
+}
+
+// redactConfigString drops the password of a URL and redacts registered secrets
+func redactConfigString(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		value = u.Redacted()
+	}
+	return redact.String(value)
+}
+
+// redactStrings redacts the string values of a status map
+func redactStrings(status map[string]interface{}) map[string]interface{} {
+	for key, value := range status {
+		if s, ok := value.(string); ok {
+			status[key] = redact.String(s)
+		}
+	}
+	return status
+}
+
+// zipSupportBundle writes each section of bundle as a pretty-printed JSON file
+func zipSupportBundle(bundle types.SupportBundle) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		section any
+	}{
+		{"info.json", bundle.Info},
+		{"config.json", bundle.Config},
+		{"health-history.json", bundle.HealthHistory},
+		{"token-status.json", bundle.TokenStatus},
+		{"caches.json", bundle.Caches},
+		{"events.json", bundle.Events},
+		{"last-upstream-error.json", bundle.LastUpstreamError},
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.section, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: bundle.GeneratedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getSupportBundle handles the support bundle admin endpoint
+// @Summary Get a support bundle
+// @Description Get the information support requests usually need in one document: version and build, the configuration with secrets masked, health check history, token status, cache states, recent operational events and the last failed ArgoCD call. Every section is present even when empty. The bundle is built from state the proxy already holds and never calls ArgoCD. With format=zip the sections are returned as pretty-printed JSON files in a zip archive. Requires the ADMIN_TOKEN bearer token; only available when ADMIN_TOKEN is set
+// @Tags admin
+// @Produce json
+// @Produce application/zip
+// @Security AdminToken
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} types.SupportBundle "Support bundle"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} types.ErrorResponse "Missing or invalid admin token"
+// @Router /admin/support-bundle [get]
+func (s *Server) getSupportBundle(c *gin.Context) {
+	b := params.NewBinder(c.Request.URL.Query())
+	format := b.Enum("format", "json", "json", "zip")
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	bundle := s.supportBundle(time.Now())
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+
+	archive, err := zipSupportBundle(bundle)
+	if err != nil {
+		// Every section encodes as JSON, so this only happens with a broken build
+		log.Printf("Failed to build the support bundle archive: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="argocd-proxy-support-%s.zip"`, bundle.GeneratedAt.Format("20060102T150405Z")))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
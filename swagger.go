@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.yaml.in/yaml/v3"
+
+	"argocd-proxy/config"
+	"argocd-proxy/docs"
+)
+
+// Routes serving the OpenAPI document compiled into the binary
+const (
+	openAPIJSONPath = "/openapi.json"
+	openAPIYAMLPath = "/openapi.yaml"
+)
+
+// swaggerPath returns the path the Swagger UI is mounted under, without a trailing slash
+func (s *Server) swaggerPath() string {
+	if s.config.SwaggerPath == "" {
+		return config.DefaultSwaggerPath
+	}
+	return s.config.SwaggerPath
+}
+
+// isSwaggerPath reports whether a request path or route is under the Swagger UI path or
+// serves the OpenAPI document
+func (s *Server) isSwaggerPath(p string) bool {
+	return strings.HasPrefix(p, s.swaggerPath()+"/") || (s.config.SwaggerEnabled && (p == openAPIJSONPath || p == openAPIYAMLPath))
+}
+
+// openAPISpec returns the OpenAPI (Swagger 2.0) document generated from the handler
+// annotations at build time, with the host, schemes and base path configured by
+// configureSwaggerInfo
+func openAPISpec() []byte {
+	return []byte(docs.SwaggerInfo.ReadDoc())
+}
+
+// openAPISpecYAML returns the OpenAPI document converted to YAML
+func openAPISpecYAML() ([]byte, error) {
+	var spec any
+	if err := json.Unmarshal(openAPISpec(), &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse the OpenAPI document: %w", err)
+	}
+	return yaml.Marshal(spec)
+}
+
+// dumpOpenAPI writes the OpenAPI document for the default address to w, for the
+// --dump-openapi flag
+func dumpOpenAPI(w io.Writer) error {
+	configureSwaggerInfo(&config.Config{})
+	_, err := w.Write(append(openAPISpec(), '\n'))
+	return err
+}
+
+// getOpenAPIJSON handles the OpenAPI JSON document endpoint
+// @Summary Get the OpenAPI document
+// @Description Get the OpenAPI (Swagger 2.0) document of this API as JSON, with the host and base path the proxy is reached at. Only served when SWAGGER_ENABLED is true
+// @Tags health
+// @Produce json
+// @Success 200 "OpenAPI document"
+// @Router /openapi.json [get]
+func (s *Server) getOpenAPIJSON(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec())
+}
+
+// getOpenAPIYAML handles the OpenAPI YAML document endpoint
+// @Summary Get the OpenAPI document as YAML
+// @Description Get the OpenAPI (Swagger 2.0) document of this API as YAML, with the host and base path the proxy is reached at. Only served when SWAGGER_ENABLED is true
+// @Tags health
+// @Produce application/yaml
+// @Success 200 "OpenAPI document"
+// @Router /openapi.yaml [get]
+func (s *Server) getOpenAPIYAML(c *gin.Context) {
+	spec, err := openAPISpecYAML()
+	if err != nil {
+		// The document is generated, so this only happens with a broken build
+		log.Printf("Failed to serve the OpenAPI document as YAML: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", spec)
+}
+
+// configureSwaggerInfo points the served swagger document at the address clients use to
+// reach the proxy instead of the compile-time @host annotation. Without EXTERNAL_URL the
+// host is left empty, so the UI sends "try it out" requests to the host it was loaded from.
+func configureSwaggerInfo(cfg *config.Config) {
+	docs.SwaggerInfo.Host = ""
+	docs.SwaggerInfo.Schemes = []string{}
+	docs.SwaggerInfo.BasePath = "/"
+
+	if cfg.ExternalURL != "" {
+		// The URL was validated when the configuration was loaded
+		if u, err := url.Parse(cfg.ExternalURL); err == nil {
+			docs.SwaggerInfo.Host = u.Host
+			docs.SwaggerInfo.Schemes = []string{u.Scheme}
+			if u.Path != "" {
+				docs.SwaggerInfo.BasePath = u.Path
+			}
+		}
+	}
+	if cfg.BasePath != "" {
+		docs.SwaggerInfo.BasePath = path.Clean(cfg.BasePath)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+
+	"argocd-proxy/config"
+	"argocd-proxy/docs"
+)
+
+// openAPIDocument is the part of the OpenAPI document the tests inspect
+type openAPIDocument struct {
+	Swagger     string                    `json:"swagger" yaml:"swagger"`
+	Host        string                    `json:"host" yaml:"host"`
+	BasePath    string                    `json:"basePath" yaml:"basePath"`
+	Paths       map[string]map[string]any `json:"paths" yaml:"paths"`
+	Definitions map[string]any            `json:"definitions" yaml:"definitions"`
+}
+
+// checkOpenAPIDocument verifies a document lists the newer endpoints and the error schema
+func checkOpenAPIDocument(t *testing.T, doc openAPIDocument) {
+	t.Helper()
+	if doc.Swagger != "2.0" {
+		t.Errorf("swagger = %q, want 2.0", doc.Swagger)
+	}
+	for _, path := range []string{"/applications", "/bootstrap", "/permissions", "/projects/{project}/roles", "/openapi.json"} {
+		if _, ok := doc.Paths[path]["get"]; !ok {
+			t.Errorf("paths lack GET %s", path)
+		}
+	}
+	if _, ok := doc.Definitions["types.ErrorResponse"]; !ok {
+		t.Error("definitions lack types.ErrorResponse")
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	previous := *docs.SwaggerInfo
+	defer func() { *docs.SwaggerInfo = previous }()
+
+	server := setupTestServer()
+	server.config.SwaggerEnabled = true
+	server.config.ExternalURL = "https://tools.example.com/argocd-proxy"
+	server.setupRouter()
+
+	w := serveMethod(server, http.MethodGet, openAPIJSONPath, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", openAPIJSONPath, w.Code)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON document: %v", err)
+	}
+	checkOpenAPIDocument(t, doc)
+	if doc.Host != "tools.example.com" || doc.BasePath != "/argocd-proxy" {
+		t.Errorf("host = %q, basePath = %q, want the external URL", doc.Host, doc.BasePath)
+	}
+
+	w = serveMethod(server, http.MethodGet, openAPIYAMLPath, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/yaml; charset=utf-8" {
+		t.Fatalf("GET %s status = %d, Content-Type = %q, want 200 YAML", openAPIYAMLPath, w.Code, w.Header().Get("Content-Type"))
+	}
+	var yamlDoc openAPIDocument
+	if err := yaml.Unmarshal(w.Body.Bytes(), &yamlDoc); err != nil {
+		t.Fatalf("invalid YAML document: %v", err)
+	}
+	checkOpenAPIDocument(t, yamlDoc)
+	if yamlDoc.Host != doc.Host {
+		t.Errorf("YAML host = %q, want %q", yamlDoc.Host, doc.Host)
+	}
+}
+
+func TestOpenAPIEndpointsDisabled(t *testing.T) {
+	server := setupTestServer()
+	server.config.SwaggerEnabled = false
+	server.setupRouter()
+
+	for _, path := range []string{openAPIJSONPath, openAPIYAMLPath} {
+		if w := serveMethod(server, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s with Swagger disabled status = %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestOpenAPIEndpointsWithoutAPIKey(t *testing.T) {
+	server := setupTestServer()
+	server.config.SwaggerEnabled = true
+	server.config.APIKeys = []config.APIKey{{Name: "statuspage", Key: testAPIKey}}
+	server.setupRouter()
+
+	if w := serveMethod(server, http.MethodGet, openAPIJSONPath, nil); w.Code != http.StatusOK {
+		t.Errorf("GET %s without an API key status = %d, want 200 like the Swagger UI", openAPIJSONPath, w.Code)
+	}
+}
+
+func TestDumpOpenAPI(t *testing.T) {
+	previous := *docs.SwaggerInfo
+	defer func() { *docs.SwaggerInfo = previous }()
+
+	var out bytes.Buffer
+	if err := dumpOpenAPI(&out); err != nil {
+		t.Fatalf("dumpOpenAPI() error = %v", err)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON document: %v", err)
+	}
+	checkOpenAPIDocument(t, doc)
+	if doc.Host != "" || doc.BasePath != "/" {
+		t.Errorf("host = %q, basePath = %q, want the default address", doc.Host, doc.BasePath)
+	}
+}
+
+func TestSwaggerPathCannotShadowRoutes(t *testing.T) {
+	server := setupTestServer()
+	server.config.AdminToken = "test-admin-token-0123456789"
+	server.config.OwnerAnnotations = []string{"example.com/owner"}
+	server.config.LogsEndpointEnabled = true
+	server.config.SwaggerEnabled = true
+	server.setupRouter()
+
+	os.Setenv("ARGOCD_API_URL", "https://argocd.example.com/api/v1")
+	os.Setenv("ARGOCD_USERNAME", "testuser")
+	os.Setenv("ARGOCD_PASSWORD", "testpass")
+	defer func() {
+		for _, env := range []string{"ARGOCD_API_URL", "ARGOCD_USERNAME", "ARGOCD_PASSWORD", "SWAGGER_PATH"} {
+			os.Unsetenv(env)
+		}
+	}()
+
+	checked := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		if strings.HasPrefix(route.Path, server.swaggerPath()+"/") {
+			continue
+		}
+		first, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if checked[first] {
+			continue
+		}
+		checked[first] = true
+
+		os.Setenv("SWAGGER_PATH", "/"+first+"/docs")
+		if _, err := config.LoadConfig(); err == nil {
+			t.Errorf("SWAGGER_PATH=/%s/docs accepted, but it would shadow %s", first, route.Path)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+)
+
+// getAutoSyncApplications handles the auto-sync applications endpoint
+// @Summary Get auto-sync applications
+// @Description Get the filtered applications ArgoCD syncs automatically with their prune, selfHeal and allowEmpty settings and sync options, sorted by name, together with how many of them prune or self-heal and how many filtered applications only sync manually
+// @Tags applications
+// @Produce json
+// @Param namePrefix query string false "Only include applications whose name starts with this prefix (case-insensitive)"
+// @Param image query string false "Only include applications running an image matching this reference"
+// @Param imageMatch query string false "Image match mode: substring (default) or exact"
+// @Param health query string false "Comma-separated health statuses to include (e.g. Degraded,Progressing)"
+// @Param sync query string false "Comma-separated sync statuses to include (e.g. OutOfSync)"
+// @Param includeDeleting query bool false "Include applications pending deletion (default true)"
+// @Param destCluster query string false "Only include applications deploying to this cluster, by name or server URL"
+// @Param owner query string false "Only include applications whose owner annotation, the first of OWNER_ANNOTATIONS, has this value"
+// @Param labelSelector query string false "Only include applications whose labels match this selector, e.g. team=payments,tier!=internal,env in (prod,staging)"
+// @Param annotationSelector query string false "Only include applications whose annotations match this selector, in the labelSelector syntax"
+// @Param autoSync query bool false "Only include applications ArgoCD syncs automatically (true) or only manually synced ones (false)"
+// @Success 200 {object} types.AutoSyncResponse "Auto-sync applications"
+// @Failure 400 {object} types.ErrorResponse "Invalid filter parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /applications/auto-sync [get]
+func (s *Server) getAutoSyncApplications(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	filter := s.bindApplicationFilter(b)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.BuildAutoSyncReport(filterApplications(ctx, filter, applications)))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// setupAutoSyncServer builds a server with an application that prunes automatically, one
+// that only self-heals and a manually synced one
+func setupAutoSyncServer() *Server {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	app := func(name string, policy *types.ArgocdSyncPolicy) types.ArgocdApplication {
+		a := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: name}}
+		a.Spec.Project = "web-app"
+		a.Spec.SyncPolicy = policy
+		return a
+	}
+	mockService.applications.Items = []types.ArgocdApplication{
+		app("web", &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{Prune: true}, SyncOptions: []string{"CreateNamespace=true"}}),
+		app("api", &types.ArgocdSyncPolicy{Automated: &types.ArgocdSyncPolicyAutomated{SelfHeal: true}}),
+		app("worker", nil),
+	}
+	server.setupRouter()
+	return server
+}
+
+func TestGetAutoSyncApplications(t *testing.T) {
+	server := setupAutoSyncServer()
+
+	w := serve(server, "/applications/auto-sync", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response types.AutoSyncResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	want := types.AutoSyncResponse{
+		Applications: []types.AutoSyncApplication{
+			{Name: "api", Project: "web-app", SelfHeal: true, SyncOptions: []string{}},
+			{Name: "web", Project: "web-app", Prune: true, SyncOptions: []string{"CreateNamespace=true"}},
+		},
+		AutoSync: 2,
+		Prune:    1,
+		SelfHeal: 1,
+		Manual:   1,
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("GET /applications/auto-sync = %+v, want %+v", response, want)
+	}
+
+	// Manual counts only the applications left by the filters
+	w = serve(server, "/applications/auto-sync?namePrefix=w", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.AutoSync != 1 || response.Manual != 1 {
+		t.Errorf("GET /applications/auto-sync?namePrefix=w = %s, want web and one manual application", w.Body.String())
+	}
+}
+
+func TestApplicationListsFilterByAutoSync(t *testing.T) {
+	server := setupAutoSyncServer()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantNames  []string
+	}{
+		{"/applications/names?autoSync=true", http.StatusOK, []string{"api", "web"}},
+		{"/applications/names?autoSync=false", http.StatusOK, []string{"worker"}},
+		{"/applications/names", http.StatusOK, []string{"api", "web", "worker"}},
+		{"/applications/names?autoSync=sometimes", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serve(server, tt.path, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var names []string
+			if err := json.Unmarshal(w.Body.Bytes(), &names); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+
+	var list types.ArgocdApplicationList
+	w := serve(server, "/applications?autoSync=false", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Items) != 1 || list.Items[0].Metadata.Name != "worker" {
+		t.Errorf("GET /applications?autoSync=false = %s, want only worker", w.Body.String())
+	}
+}
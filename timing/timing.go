@@ -0,0 +1,136 @@
+package timing
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// recorderKey is the context key under which a request's Recorder is stored
+type recorderKey struct{}
+
+// Phase is a part of a request's processing that is timed separately
+type Phase int
+
+// Timed phases, in the order they usually occur during a request
+const (
+	// PhaseAuth is time spent obtaining an ArgoCD token, including session refreshes
+	PhaseAuth Phase = iota
+	// PhaseUpstream is time spent waiting on the ArgoCD API
+	PhaseUpstream
+	// PhaseFilter is time spent applying ignore rules and query filters
+	PhaseFilter
+	// PhaseEncode is time spent serializing the response body
+	PhaseEncode
+
+	numPhases
+)
+
+// Recorder accumulates the time a request spends in each phase. The services layer
+// reports its phases through the request context, so the HTTP layer can split a
+// request's duration into token, upstream, filtering and encoding time and the rest of
+// the proxy's own processing.
+type Recorder struct {
+	start  time.Time
+	phases [numPhases]atomic.Int64
+}
+
+// Breakdown is a request's duration split into phases
+type Breakdown struct {
+	Total    time.Duration
+	Auth     time.Duration
+	Upstream time.Duration
+	Filter   time.Duration
+	Encode   time.Duration
+	// App is the time spent in the proxy outside the other phases, such as cache lookups
+	// and decoding upstream responses
+	App time.Duration
+}
+
+// NewContext returns a context carrying a new Recorder that starts timing now
+func NewContext(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{start: time.Now()}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// FromContext returns the Recorder carried by ctx, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Add adds d to the phase of the Recorder carried by ctx, if any. Concurrent work of one
+// request in the same phase is summed.
+func Add(ctx context.Context, phase Phase, d time.Duration) {
+	if r := FromContext(ctx); r != nil {
+		r.Add(phase, d)
+	}
+}
+
+// AddUpstream adds d to the upstream time of the Recorder carried by ctx, if any
+func AddUpstream(ctx context.Context, d time.Duration) {
+	Add(ctx, PhaseUpstream, d)
+}
+
+// Start begins timing a phase and returns the function that ends it. Without a Recorder
+// in ctx nothing is timed and the returned function does nothing.
+func Start(ctx context.Context, phase Phase) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		r.Add(phase, time.Since(start))
+	}
+}
+
+// Add adds d to the phase
+func (r *Recorder) Add(phase Phase, d time.Duration) {
+	r.phases[phase].Add(int64(d))
+}
+
+// Phase returns the time recorded so far for the phase
+func (r *Recorder) Phase(phase Phase) time.Duration {
+	return time.Duration(r.phases[phase].Load())
+}
+
+// Upstream returns the upstream time recorded so far
+func (r *Recorder) Upstream() time.Duration {
+	return r.Phase(PhaseUpstream)
+}
+
+// Breakdown splits the time elapsed since the Recorder was created. Concurrent work can
+// add up to more than the total, so the phases are capped in order at the time the
+// earlier ones leave, and App is what remains.
+func (r *Recorder) Breakdown() Breakdown {
+	total := time.Since(r.start)
+	remaining := total
+	take := func(phase Phase) time.Duration {
+		d := min(r.Phase(phase), remaining)
+		remaining -= d
+		return d
+	}
+
+	b := Breakdown{Total: total}
+	b.Upstream = take(PhaseUpstream)
+	b.Auth = take(PhaseAuth)
+	b.Filter = take(PhaseFilter)
+	b.Encode = take(PhaseEncode)
+	b.App = remaining
+	return b
+}
+
+// ServerTiming formats the breakdown as a Server-Timing header value in milliseconds,
+// listing the phases in the order they usually occur
+func (b Breakdown) ServerTiming() string {
+	return fmt.Sprintf("auth;desc=\"ArgoCD token\";dur=%s, upstream;desc=\"ArgoCD API\";dur=%s, filter;desc=\"Filtering\";dur=%s, encode;desc=\"Serialization\";dur=%s, app;desc=\"Other processing\";dur=%s, total;dur=%s",
+		milliseconds(b.Auth), milliseconds(b.Upstream), milliseconds(b.Filter), milliseconds(b.Encode),
+		milliseconds(b.App), milliseconds(b.Total))
+}
+
+// milliseconds formats d as fractional milliseconds with microsecond precision
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
+}
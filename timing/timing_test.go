@@ -0,0 +1,90 @@
+package timing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx, r := NewContext(context.Background())
+	if FromContext(ctx) != r {
+		t.Fatal("FromContext() did not return the recorder")
+	}
+
+	AddUpstream(ctx, 20*time.Millisecond)
+	AddUpstream(ctx, 5*time.Millisecond)
+	if got := r.Upstream(); got != 25*time.Millisecond {
+		t.Errorf("Upstream() = %v, want %v", got, 25*time.Millisecond)
+	}
+
+	// Without a recorder AddUpstream is a no-op
+	AddUpstream(context.Background(), time.Second)
+}
+
+func TestBreakdownCapsUpstream(t *testing.T) {
+	ctx, r := NewContext(context.Background())
+	AddUpstream(ctx, time.Hour)
+
+	b := r.Breakdown()
+	if b.Upstream != b.Total || b.App != 0 {
+		t.Errorf("Breakdown() = %+v, want upstream capped at total and no app time", b)
+	}
+}
+
+func TestServerTiming(t *testing.T) {
+	b := Breakdown{Total: 1500 * time.Microsecond, Upstream: 1200 * time.Microsecond, App: 300 * time.Microsecond}
+
+	got := b.ServerTiming()
+	for _, want := range []string{"upstream;", "dur=1.200", "app;", "dur=0.300", "total;dur=1.500"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ServerTiming() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	// Without a recorder the returned function is a no-op
+	Start(context.Background(), PhaseFilter)()
+
+	ctx, r := NewContext(context.Background())
+	stop := Start(ctx, PhaseFilter)
+	time.Sleep(2 * time.Millisecond)
+	stop()
+	if got := r.Phase(PhaseFilter); got < 2*time.Millisecond {
+		t.Errorf("Phase(PhaseFilter) = %v, want at least 2ms", got)
+	}
+	if got := r.Phase(PhaseEncode); got != 0 {
+		t.Errorf("Phase(PhaseEncode) = %v, want 0", got)
+	}
+}
+
+func TestBreakdownCapsPhasesInOrder(t *testing.T) {
+	ctx, r := NewContext(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	Add(ctx, PhaseAuth, time.Millisecond)
+	Add(ctx, PhaseFilter, time.Hour)
+	Add(ctx, PhaseEncode, time.Hour)
+
+	b := r.Breakdown()
+	if b.Auth != time.Millisecond {
+		t.Errorf("Auth = %v, want %v", b.Auth, time.Millisecond)
+	}
+	if b.Filter != b.Total-b.Auth || b.Encode != 0 || b.App != 0 {
+		t.Errorf("Breakdown() = %+v, want filter capped at the remaining time", b)
+	}
+}
+
+func TestServerTimingPhaseOrder(t *testing.T) {
+	got := Breakdown{}.ServerTiming()
+
+	last := -1
+	for _, name := range []string{"auth;", "upstream;", "filter;", "encode;", "app;", "total;"} {
+		i := strings.Index(got, name)
+		if i <= last {
+			t.Fatalf("ServerTiming() = %q, want %q after the earlier phases", got, name)
+		}
+		last = i
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// repositoryTopologyCSVHeader is the header row of the CSV export of /topology/repositories
+var repositoryTopologyCSVHeader = []string{"repository", "application", "project", "path", "targetRevision"}
+
+// getRepositoryTopology handles the repository topology endpoint
+// @Summary Get applications by source repository
+// @Description Group the filtered applications by the repositories they deploy from, including every source of multi-source applications. Repository URLs are normalized to host/path, so https, ssh and scp-like URLs of one repository are grouped together. Clients sending "Accept: text/csv" get one row per application source instead
+// @Tags applications
+// @Produce json
+// @Produce text/csv
+// @Param minApps query int false "Only include repositories used by at least this many applications (default 1); 2 lists shared repositories"
+// @Success 200 {object} types.RepositoryTopologyResponse "Applications by repository"
+// @Failure 400 {object} types.ErrorResponse "Invalid query parameters"
+// @Failure 502 {object} types.ErrorResponse "Failed to retrieve applications from ArgoCD"
+// @Router /topology/repositories [get]
+func (s *Server) getRepositoryTopology(c *gin.Context) {
+	ctx, cancel := s.requestContext(c.Request.Context())
+	defer cancel()
+
+	b := params.NewBinder(c.Request.URL.Query())
+	minApps := b.Int("minApps", 1, 1, 10000)
+	if err := b.Err(); err != nil {
+		s.invalidParamsResponse(c, err)
+		return
+	}
+
+	applications, err := s.argocdService.GetApplications(ctx)
+	if err != nil {
+		log.Printf("Failed to get applications: %v", err)
+		s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+		return
+	}
+
+	topology := services.BuildRepositoryTopology(applications, minApps)
+	c.Writer.Header().Add("Vary", "Accept")
+	if prefersCSV(c) {
+		renderCSV(c, http.StatusOK, repositoryTopologyCSVHeader, repositoryTopologyRows(topology))
+		return
+	}
+	c.JSON(http.StatusOK, topology)
+}
+
+// repositoryTopologyRows flattens a topology into CSV rows sorted by repository
+func repositoryTopologyRows(topology types.RepositoryTopologyResponse) [][]string {
+	repos := make([]string, 0, len(topology.Repositories))
+	for repo := range topology.Repositories {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	var rows [][]string
+	for _, repo := range repos {
+		for _, app := range topology.Repositories[repo].Applications {
+			rows = append(rows, []string{repo, app.Name, app.Project, app.Path, app.TargetRevision})
+		}
+	}
+	return rows
+}
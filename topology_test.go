@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"argocd-proxy/types"
+)
+
+// setupTopologyServer builds a server whose applications deploy from the same repository
+// spelled as https and ssh URLs, and from one other repository
+func setupTopologyServer() *Server {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	for _, app := range []struct{ name, repoURL, path string }{
+		{"api", "https://github.com/company/platform.git", "apps/api"},
+		{"web", "git@github.com:company/platform.git", "apps/web"},
+		{"legacy", "https://git.example.com/legacy/monolith", "deploy"},
+	} {
+		application := types.ArgocdApplication{Metadata: types.ArgocdApplicationMetadata{Name: app.name}}
+		application.Spec.Project = "production"
+		application.Spec.Source = types.ArgocdApplicationSource{RepoURL: app.repoURL, Path: app.path, TargetRevision: "main"}
+		mockService.applications.Items = append(mockService.applications.Items, application)
+	}
+	return server
+}
+
+func TestGetRepositoryTopology(t *testing.T) {
+	server := setupTopologyServer()
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/topology/repositories?minApps=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response types.RepositoryTopologyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	platform, ok := response.Repositories["github.com/company/platform"]
+	if len(response.Repositories) != 1 || !ok || platform.Count != 2 {
+		t.Errorf("repositories = %+v, want only github.com/company/platform with 2 applications", response.Repositories)
+	}
+	if response.RepositoryCount != 1 || response.ApplicationCount != 2 {
+		t.Errorf("counts = %d repositories, %d applications, want 1 and 2", response.RepositoryCount, response.ApplicationCount)
+	}
+
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest("GET", "/topology/repositories?minApps=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("minApps=0 status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetRepositoryTopologyCSV(t *testing.T) {
+	server := setupTopologyServer()
+
+	req := httptest.NewRequest("GET", "/topology/repositories", nil)
+	req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	want := "repository,application,project,path,targetRevision\n" +
+		"git.example.com/legacy/monolith,legacy,production,deploy,main\n" +
+		"github.com/company/platform,api,production,apps/api,main\n" +
+		"github.com/company/platform,web,production,apps/web,main\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+
+	// JSON stays the default for clients preferring it
+	req = httptest.NewRequest("GET", "/topology/repositories", nil)
+	req.Header.Set("Accept", "application/json, text/csv;q=0.5")
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type preferring JSON = %q, want application/json", got)
+	}
+}
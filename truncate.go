@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/params"
+	"argocd-proxy/types"
+)
+
+// Headers signalling a list response cut to the maximum number of items
+const (
+	truncatedHeader  = "X-Truncated"
+	totalCountHeader = "X-Total-Count"
+)
+
+// maxItemsQueryParam overrides MAX_RESPONSE_ITEMS for one request, for admin token holders
+const maxItemsQueryParam = "maxItems"
+
+// bindItemLimit returns the maximum number of items in the list response: MAX_RESPONSE_ITEMS,
+// or ?maxItems= when the caller presents the admin token. Zero means unlimited.
+func (s *Server) bindItemLimit(c *gin.Context, b *params.Binder) int {
+	if !s.hasAdminToken(c) {
+		b.Forbid(maxItemsQueryParam, "requires the admin token")
+		return s.config.MaxResponseItems
+	}
+	return b.Int(maxItemsQueryParam, s.config.MaxResponseItems, 0, math.MaxInt32)
+}
+
+// truncateApplications cuts the list to limit applications, recording the truncation in
+// the list's meta, the response headers and the truncation metric
+func truncateApplications(c *gin.Context, list types.ArgocdApplicationList, limit int) types.ArgocdApplicationList {
+	meta, ok := truncated(c, len(list.Items), limit)
+	if !ok {
+		return list
+	}
+	list.Items = list.Items[:limit]
+	list.Meta = &meta
+	return list
+}
+
+// truncateNames cuts names to limit entries. The bare array has no room for meta, so the
+// truncation is only signalled in the response headers.
+func truncateNames(c *gin.Context, names []string, limit int) []string {
+	if _, ok := truncated(c, len(names), limit); !ok {
+		return names
+	}
+	return names[:limit]
+}
+
+// truncated reports whether a list of total items exceeds limit and, if so, sets the
+// truncation headers, counts the truncation and returns the meta describing it
+func truncated(c *gin.Context, total, limit int) (types.ListMeta, bool) {
+	if limit <= 0 || total <= limit {
+		return types.ListMeta{}, false
+	}
+	c.Header(truncatedHeader, "true")
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	metrics.ResponsesTruncatedTotal.WithLabelValues(c.FullPath()).Inc()
+	return types.ListMeta{Truncated: true, Total: total, Limit: limit}, true
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// setupTruncationServer builds a server capping list responses at maxItems over three
+// applications and three projects
+func setupTruncationServer(maxItems int) *Server {
+	server := setupTestServer()
+	server.config.MaxResponseItems = maxItems
+	server.config.AdminToken = testAdminToken
+	server.setupRouter()
+
+	mockService := server.argocdService.(*MockArgocdService)
+	for _, name := range []string{"api", "web", "worker"} {
+		mockService.applications.Items = append(mockService.applications.Items, types.ArgocdApplication{
+			Metadata: types.ArgocdApplicationMetadata{Name: name},
+			Spec:     types.ArgocdApplicationSpec{Project: "web-app"},
+		})
+		mockService.projects = append(mockService.projects, types.ArgocdProject{
+			Metadata: types.ArgocdProjectMetadata{Name: name},
+		})
+	}
+	mockService.projectNames = []string{"web-app"}
+	return server
+}
+
+func TestResponseTruncation(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	defer metrics.SetDefault(original)
+
+	tests := []struct {
+		name          string
+		maxItems      int
+		path          string
+		token         string
+		wantStatus    int
+		wantItems     int
+		wantTruncated bool
+	}{
+		{"applications over the limit", 2, "/applications", "", http.StatusOK, 2, true},
+		{"applications at the limit", 3, "/applications", "", http.StatusOK, 3, false},
+		{"limit disabled", 0, "/applications", "", http.StatusOK, 3, false},
+		{"group applications", 1, "/groups/Frontend/applications", "", http.StatusOK, 1, true},
+		{"project applications", 1, "/projects/web-app/applications", "", http.StatusOK, 1, true},
+		{"projects", 2, "/projects", "", http.StatusOK, 2, true},
+		{"application names", 2, "/applications/names", "", http.StatusOK, 2, true},
+		{"admin raises the limit", 2, "/applications?maxItems=0", testAdminToken, http.StatusOK, 3, false},
+		{"admin lowers the limit", 2, "/applications?maxItems=1", testAdminToken, http.StatusOK, 1, true},
+		{"override without admin token", 2, "/applications?maxItems=0", "", http.StatusBadRequest, 0, false},
+		{"override with wrong token", 2, "/applications?maxItems=0", "not-the-admin-token", http.StatusBadRequest, 0, false},
+		{"invalid override", 2, "/applications?maxItems=-1", testAdminToken, http.StatusBadRequest, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTruncationServer(tt.maxItems)
+			headers := map[string]string{}
+			if tt.token != "" {
+				headers["Authorization"] = "Bearer " + tt.token
+			}
+
+			w := serveMethod(server, http.MethodGet, tt.path, headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %v, want %v: %s", tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var items []json.RawMessage
+			var meta *types.ListMeta
+			if strings.HasPrefix(tt.path, "/applications/names") {
+				if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+					t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+				}
+			} else {
+				var response struct {
+					Items []json.RawMessage `json:"items"`
+					Meta  *types.ListMeta   `json:"meta"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("GET %s invalid JSON response: %v", tt.path, err)
+				}
+				items, meta = response.Items, response.Meta
+				if tt.wantTruncated && !reflect.DeepEqual(meta, &types.ListMeta{Truncated: true, Total: 3, Limit: tt.wantItems}) {
+					t.Errorf("GET %s meta = %+v, want truncated with total 3 and limit %d", tt.path, meta, tt.wantItems)
+				}
+				if !tt.wantTruncated && meta != nil {
+					t.Errorf("GET %s meta = %+v, want none", tt.path, meta)
+				}
+			}
+			if len(items) != tt.wantItems {
+				t.Errorf("GET %s returned %d items, want %d", tt.path, len(items), tt.wantItems)
+			}
+
+			wantHeader, wantTotal := "", ""
+			if tt.wantTruncated {
+				wantHeader, wantTotal = "true", "3"
+			}
+			if got := w.Header().Get(truncatedHeader); got != wantHeader {
+				t.Errorf("GET %s %s = %q, want %q", tt.path, truncatedHeader, got, wantHeader)
+			}
+			if got := w.Header().Get(totalCountHeader); got != wantTotal {
+				t.Errorf("GET %s %s = %q, want %q", tt.path, totalCountHeader, got, wantTotal)
+			}
+		})
+	}
+
+	if got := testutil.ToFloat64(metrics.ResponsesTruncatedTotal.WithLabelValues("/applications")); got != 2 {
+		t.Errorf("responses_truncated_total{path=/applications} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.ResponsesTruncatedTotal.WithLabelValues("/projects")); got != 1 {
+		t.Errorf("responses_truncated_total{path=/projects} = %v, want 1", got)
+	}
+}
+
+func TestResponseTruncationWithResponseCache(t *testing.T) {
+	server := setupTruncationServer(2)
+	server.config.ResponseCacheTTL = time.Minute
+	server.config.ResponseCacheSize = 16
+	server.setupRouter()
+	mockService := server.argocdService.(*MockArgocdService)
+
+	for i := range 2 {
+		w := serveMethod(server, http.MethodGet, "/applications", nil)
+		if w.Header().Get(truncatedHeader) != "true" || w.Header().Get(totalCountHeader) != "3" {
+			t.Errorf("request %d truncation headers = %q/%q, want them kept on cache hits", i, w.Header().Get(truncatedHeader), w.Header().Get(totalCountHeader))
+		}
+	}
+	if mockService.applicationsCalls != 1 {
+		t.Fatalf("GetApplications() called %d times, want 1 with the response cache", mockService.applicationsCalls)
+	}
+
+	// An admin override is never stored, so a later caller without the token cannot reuse it
+	admin := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	if w := serveMethod(server, http.MethodGet, "/applications?maxItems=0", admin); w.Code != http.StatusOK {
+		t.Fatalf("admin override status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if w := serveMethod(server, http.MethodGet, "/applications?maxItems=0", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("override without admin token after an admin request status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
@@ -2,16 +2,22 @@ package types
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/events"
 )
 
 // AuthServiceInterface defines the interface for authentication services
 type AuthServiceInterface interface {
 	GetValidToken(ctx context.Context) (string, error)
-	CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error)
+	CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error)
 	GetTokenStatus() map[string]interface{}
-	StartTokenRefreshRoutine(ctx context.Context)
+	// RunTokenRefreshRoutine blocks, refreshing tokens until ctx is cancelled
+	RunTokenRefreshRoutine(ctx context.Context)
 }
 
 // ArgocdServiceInterface defines the interface for ArgoCD services
@@ -20,11 +26,72 @@ type ArgocdServiceInterface interface {
 	GetFilteredProjects(ctx context.Context) ([]ArgocdProject, error)
 	GetApplications(ctx context.Context) (ArgocdApplicationList, error)
 	GetApplication(ctx context.Context, name string) (ArgocdApplication, error)
+	// GetApplicationRaw returns the upstream JSON of an application verbatim
+	GetApplicationRaw(ctx context.Context, name string) (json.RawMessage, error)
 	GetProjectNames(ctx context.Context) ([]string, error)
 	HealthCheck(ctx context.Context) error
 	ExtractIngressURLs(ctx context.Context, appName string) ([]string, error)
-	GetApplicationsByGroup(ctx context.Context, groupName string, cfg interface{}) (ArgocdApplicationList, error)
+	GetApplicationsByGroup(ctx context.Context, groupName string) (ArgocdApplicationList, error)
 	GetApplicationsByProject(ctx context.Context, projectName string) (ArgocdApplicationList, error)
+	ApplicationsChanged() <-chan struct{}
+	// GetApplicationsSnapshot returns the application list and the token of its snapshot
+	GetApplicationsSnapshot(ctx context.Context) (ArgocdApplicationList, string, error)
+	// ApplicationsSnapshot returns the application list of a retained snapshot
+	ApplicationsSnapshot(token string) (ArgocdApplicationList, bool)
+	GetUpstreamStatus() UpstreamStatus
+	// LastUpstreamError returns the latest failed ArgoCD call, or nil when none failed
+	LastUpstreamError() *UpstreamError
+	// CacheStats describes the cached ArgoCD lists without calling ArgoCD
+	CacheStats() []CacheStatus
+	// ExportCacheSnapshot returns the cached project and application lists, even expired
+	// ones, without calling ArgoCD
+	ExportCacheSnapshot() CacheSnapshot
+	GetHealthHistory() HealthHistoryResponse
+	CheckComponents(ctx context.Context) map[string]string
+	GetCompatibility() *CompatibilityReport
+	GetApplicationSyncWindows(ctx context.Context, name string) (ApplicationSyncWindows, error)
+	GetApplicationDiff(ctx context.Context, name string) (ApplicationDiff, error)
+	// StreamApplicationLogs returns the container logs of an application as plain text
+	// lines, read from ArgoCD while the caller reads them
+	StreamApplicationLogs(ctx context.Context, name string, opts ApplicationLogOptions) (io.ReadCloser, error)
+	GetProject(ctx context.Context, name string) (ArgocdProject, error)
+	InvalidateCaches()
+	// OpenCircuits returns the applications whose circuit breaker is open
+	OpenCircuits() []ApplicationCircuit
+	// ApplicationStateSince returns when the background poller first saw an application
+	// in its health and sync status, or false when it has not seen the application
+	ApplicationStateSince(namespace, name string) (ApplicationStateSince, bool)
+}
+
+// Caller access levels reported by PermissionsResponse
+const (
+	// AccessOpen means API keys are not enabled and every caller is served
+	AccessOpen = "open"
+	// AccessKey means the caller presented a valid API key
+	AccessKey = "key"
+	// AccessAnonymous means API keys are enabled and the caller presented none
+	AccessAnonymous = "anonymous"
+)
+
+// PermissionsResponse describes what the caller is allowed to see
+type PermissionsResponse struct {
+	// Access is open, key or anonymous
+	Access string `json:"access" enums:"open,key,anonymous"`
+	// Client is the name of the caller's API key
+	Client string `json:"client,omitempty"`
+	// Restricted is set when the API key is limited to Groups by API_KEY_GROUPS
+	Restricted bool `json:"restricted"`
+	// Groups are the project groups the caller can see
+	Groups []string `json:"groups"`
+	// Projects are the sorted names of the projects the caller can see
+	Projects []string `json:"projects"`
+	// DisabledEndpoints are the route templates the caller may not call, sorted
+	DisabledEndpoints []string `json:"disabledEndpoints"`
+}
+
+// ReadinessResponse represents the readiness probe response
+type ReadinessResponse struct {
+	Status string `json:"status"`
 }
 
 // HealthResponse represents the health check response
@@ -35,13 +102,313 @@ type HealthResponse struct {
 	BuildTime   string                 `json:"buildTime"`
 	TokenStatus map[string]interface{} `json:"tokenStatus"`
 	ArgocdAPI   string                 `json:"argocdApiStatus"`
+	Upstream    *UpstreamStatus        `json:"upstream,omitempty"`
+	// ArgocdEndpoint is the host of the ARGOCD_API_URL URL that ArgoCD calls go to; not set
+	// during maintenance, when ArgoCD is not checked
+	ArgocdEndpoint string `json:"argocdEndpoint,omitempty"`
+	// ArgocdComponents maps ArgoCD components to ok, degraded or unknown; only set with DEEP_HEALTH
+	ArgocdComponents map[string]string `json:"argocdComponents,omitempty"`
+	// Compatibility is the latest upstream compatibility probe; only set with ?verbose=true
+	Compatibility *CompatibilityReport `json:"compatibility,omitempty"`
+	// Maintenance is the active maintenance window; only set while status is maintenance
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+	// HealthState is the debounced state of the ArgoCD checks that status follows; not set
+	// during maintenance, when ArgoCD is not checked
+	HealthState *HealthState `json:"healthState,omitempty"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
+// HealthState is the state of the ArgoCD checks after flap suppression: it turns degraded
+// after FailureThreshold consecutive failed checks and healthy again after
+// RecoveryThreshold consecutive passed ones
+type HealthState struct {
+	State                string `json:"state"`
+	ConsecutiveFailures  int    `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int    `json:"consecutiveSuccesses"`
+	FailureThreshold     int    `json:"failureThreshold"`
+	RecoveryThreshold    int    `json:"recoveryThreshold"`
+	// Since is when the state was entered, or when the proxy started
+	Since time.Time `json:"since"`
+	// LastTransition is the most recent state change; unset before the first one
+	LastTransition *HealthStateTransition `json:"lastTransition,omitempty"`
+	// Transitions are the most recent state changes, oldest first
+	Transitions []HealthStateTransition `json:"transitions"`
+}
+
+// HealthStateTransition is a change of the debounced health state
+type HealthStateTransition struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// ApplicationStateSince is an application's health and sync status at the latest
+// background poll with when the poller first saw each of them
+type ApplicationStateSince struct {
+	Health      string
+	HealthSince time.Time
+	Sync        string
+	SyncSince   time.Time
+}
+
+// ApplicationCircuit is an application whose reads fail fast after repeated failures
+type ApplicationCircuit struct {
+	Application string    `json:"application"`
+	OpenedAt    time.Time `json:"openedAt"`
+	// OpenUntil is when reads of the application are let through again
+	OpenUntil time.Time `json:"openUntil"`
+	// RetryAfterSeconds is the whole seconds until OpenUntil, at least 1
+	RetryAfterSeconds int `json:"retryAfterSeconds"`
+}
+
+// ApplicationCircuitsResponse lists the open application circuit breakers and their settings
+type ApplicationCircuitsResponse struct {
+	// Enabled is false when APP_CIRCUIT_THRESHOLD is 0
+	Enabled   bool   `json:"enabled"`
+	Threshold int    `json:"threshold"`
+	Window    string `json:"window"`
+	Cooldown  string `json:"cooldown"`
+	// Open lists the open circuits, sorted by application
+	Open []ApplicationCircuit `json:"open"`
+}
+
+// MaintenanceRequest puts the proxy into maintenance mode until Until
+type MaintenanceRequest struct {
+	// Until is when maintenance mode ends by itself, in RFC 3339 format
+	Until time.Time `json:"until"`
+	// Message is returned to clients of data endpoints during maintenance
 	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+	// ServeStale keeps data endpoints serving cached data, even expired, without calling ArgoCD
+	ServeStale bool `json:"serveStale,omitempty"`
+}
+
+// MaintenanceStatus reports the maintenance mode of the proxy
+type MaintenanceStatus struct {
+	Active     bool       `json:"active"`
+	Since      *time.Time `json:"since,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	ServeStale bool       `json:"serveStale,omitempty"`
+}
+
+// InfoResponse represents static runtime information about a proxy instance
+type InfoResponse struct {
+	Version       string `json:"version"`
+	BuildTime     string `json:"buildTime"`
+	GoVersion     string `json:"goVersion"`
+	StartTime     string `json:"startTime"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+	// ArgocdHost is the host of ARGOCD_API_URL, without scheme, path or credentials
+	ArgocdHost     string `json:"argocdHost"`
+	ProjectGroups  int    `json:"projectGroups"`
+	IgnorePatterns int    `json:"ignorePatterns"`
+	// AuthMode is how the proxy authenticates to ArgoCD
+	AuthMode string       `json:"authMode"`
+	Features InfoFeatures `json:"features"`
+	// DisabledEndpoints are the route templates not served because of DISABLED_ENDPOINTS
+	DisabledEndpoints []string `json:"disabledEndpoints"`
+	// DeprecatedConfig are the deprecated environment variables set, with their replacements
+	DeprecatedConfig []config.Deprecation `json:"deprecatedConfig"`
+}
+
+// InfoFeatures reports which optional proxy features are enabled
+type InfoFeatures struct {
+	Cache             bool `json:"cache"`
+	ResponseCache     bool `json:"responseCache"`
+	Poller            bool `json:"poller"`
+	DeepHealth        bool `json:"deepHealth"`
+	AuditLog          bool `json:"auditLog"`
+	StrictQueryParams bool `json:"strictQueryParams"`
+	SlowRequestLog    bool `json:"slowRequestLog"`
+	Swagger           bool `json:"swagger"`
+	AdminAPI          bool `json:"adminApi"`
+	ClientStats       bool `json:"clientStats"`
+}
+
+// CompatibilityReport is the outcome of probing the upstream ArgoCD version and API shape
+type CompatibilityReport struct {
+	// ArgocdVersion is the version reported by ArgoCD; empty when it could not be read
+	ArgocdVersion  string    `json:"argocdVersion,omitempty"`
+	SupportedRange string    `json:"supportedRange"`
+	Compatible     bool      `json:"compatible"`
+	Problems       []string  `json:"problems,omitempty"`
+	CheckedAt      time.Time `json:"checkedAt"`
+}
+
+// HealthCheckRecord is a single health evaluation kept in the health history
+type HealthCheckRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Status is the overall status reported by /health: healthy or degraded
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthHistoryResponse lists the most recent health checks, oldest first, with aggregates
+type HealthHistoryResponse struct {
+	// Capacity is the maximum number of checks kept
+	Capacity int                 `json:"capacity"`
+	Checks   []HealthCheckRecord `json:"checks"`
+	// UptimePercent is the share of healthy checks in the window
+	UptimePercent float64 `json:"uptimePercent"`
+	// LastTransition is when the status last changed within the window
+	LastTransition *time.Time `json:"lastTransition"`
+}
+
+// UpstreamStatus reports when each resource was last fetched successfully from ArgoCD.
+// A nil timestamp means the fetch has never succeeded since startup.
+type UpstreamStatus struct {
+	Projects     *time.Time `json:"projects"`
+	Applications *time.Time `json:"applications"`
+	Health       *time.Time `json:"health"`
+}
+
+// UpstreamError is the latest failed ArgoCD call: a transport error or an error status
+// other than 404
+type UpstreamError struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`
+	Endpoint string    `json:"endpoint"`
+	// Status is the response status, or 0 when no response was received
+	Status int `json:"status,omitempty"`
+	// Message describes the failure, redacted
+	Message string `json:"message"`
+}
+
+// SupportBundle gathers the state support requests usually ask for. Every section is
+// present, empty when its subsystem has no data; secrets are redacted.
+type SupportBundle struct {
+	GeneratedAt time.Time    `json:"generatedAt"`
+	Info        InfoResponse `json:"info"`
+	// Config is the loaded configuration by field name, with secrets masked
+	Config        map[string]any         `json:"config"`
+	HealthHistory HealthHistoryResponse  `json:"healthHistory"`
+	TokenStatus   map[string]interface{} `json:"tokenStatus"`
+	Caches        []CacheStatus          `json:"caches"`
+	Events        events.Report          `json:"events"`
+	// LastUpstreamError is null when no ArgoCD call has failed since startup
+	LastUpstreamError *UpstreamError `json:"lastUpstreamError"`
+}
+
+// CacheStatus describes one of the cached ArgoCD lists
+type CacheStatus struct {
+	Name string `json:"name"`
+	// Backend is memory or redis
+	Backend string `json:"backend" enums:"memory,redis"`
+	// TTL is the cache TTL as a Go duration; 0s means caching is disabled
+	TTL       string `json:"ttl"`
+	Populated bool   `json:"populated"`
+	// Fresh is set when the stored list is younger than the TTL
+	Fresh      bool       `json:"fresh"`
+	CachedAt   *time.Time `json:"cachedAt"`
+	AgeSeconds float64    `json:"ageSeconds"`
+	// Items is the number of entries in the stored list
+	Items int `json:"items"`
+}
+
+// CacheSnapshotSchemaVersion is the version of the CacheSnapshot document. A replica
+// only imports snapshots of its own version.
+const CacheSnapshotSchemaVersion = 1
+
+// CacheSnapshot is the content of a replica's project and application caches, exported
+// by GET /admin/cache/snapshot to pre-warm another replica
+type CacheSnapshot struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	// Projects and Applications are omitted when the cache is empty
+	Projects     *CachedProjects     `json:"projects,omitempty"`
+	Applications *CachedApplications `json:"applications,omitempty"`
+}
+
+// CachedProjects is the cached project list with the time it was fetched
+type CachedProjects struct {
+	CachedAt time.Time         `json:"cachedAt"`
+	List     ArgocdProjectList `json:"list"`
+}
+
+// CachedApplications is the cached application list, already filtered by the ignore
+// rules, with the time it was fetched
+type CachedApplications struct {
+	CachedAt time.Time             `json:"cachedAt"`
+	List     ArgocdApplicationList `json:"list"`
+}
+
+// ErrorCode is a machine-readable error category clients can branch on
+type ErrorCode string
+
+// Error codes returned in ErrorResponse.ErrorCode
+const (
+	// ErrorCodeUpstreamDown means ArgoCD could not be reached or returned an unexpected response
+	ErrorCodeUpstreamDown ErrorCode = "upstream_down"
+	// ErrorCodeNotFound means the requested resource or endpoint does not exist
+	ErrorCodeNotFound ErrorCode = "not_found"
+	// ErrorCodeFiltered means the requested resource is hidden by the ignored-project rules
+	ErrorCodeFiltered ErrorCode = "filtered"
+	// ErrorCodeInvalidParam means a path or query parameter was rejected
+	ErrorCodeInvalidParam ErrorCode = "invalid_param"
+	// ErrorCodeUnauthorized means ArgoCD rejected the proxy's credentials
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeUpstreamTooLarge means an ArgoCD response exceeded UPSTREAM_MAX_BODY_BYTES
+	ErrorCodeUpstreamTooLarge ErrorCode = "upstream_too_large"
+	// ErrorCodeUpstreamRedirect means ArgoCD redirected to a host outside ARGOCD_REDIRECT_HOSTS,
+	// or from HTTPS to plain HTTP
+	ErrorCodeUpstreamRedirect ErrorCode = "upstream_redirect"
+	// ErrorCodeMethodNotAllowed means the endpoint exists but does not serve the request method
+	ErrorCodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	// ErrorCodeAdminUnauthorized means an admin endpoint was called without a valid ADMIN_TOKEN
+	ErrorCodeAdminUnauthorized ErrorCode = "admin_unauthorized"
+	// ErrorCodeClientUnauthorized means API keys are enabled and the request carried no valid key
+	ErrorCodeClientUnauthorized ErrorCode = "client_unauthorized"
+	// ErrorCodeClientForbidden means the caller's API key is restricted to project groups
+	// the request is outside of
+	ErrorCodeClientForbidden ErrorCode = "client_forbidden"
+	// ErrorCodeNotAcceptable means the Accept header names no schema profile the route serves
+	ErrorCodeNotAcceptable ErrorCode = "not_acceptable"
+	// ErrorCodeCircuitOpen means reads of the application fail fast after repeated failures
+	ErrorCodeCircuitOpen ErrorCode = "circuit_open"
+	// ErrorCodeMaintenance means the proxy is in maintenance mode and cannot serve the request
+	ErrorCodeMaintenance ErrorCode = "maintenance"
+)
+
+// ErrorResponse represents an error response. Code is the numeric HTTP status and is
+// kept for compatibility; ErrorCode is the machine-readable category.
+type ErrorResponse struct {
+	Error     string    `json:"error"`
+	Message   string    `json:"message,omitempty"`
+	Code      int       `json:"code"`
+	ErrorCode ErrorCode `json:"errorCode" enums:"upstream_down,not_found,filtered,invalid_param,unauthorized,admin_unauthorized,client_unauthorized"`
+	Fields    []string  `json:"fields,omitempty"`
+	// Profiles lists the schema profiles the route serves on not_acceptable errors
+	Profiles []string `json:"profiles,omitempty"`
+	// Upstream is ArgoCD's own answer to the failed call, included on request
+	Upstream *UpstreamErrorDetails `json:"upstream,omitempty"`
+}
+
+// UpstreamErrorDetails is the response ArgoCD gave to a failed call, sent with
+// ?upstreamError=true or UPSTREAM_ERROR_PASSTHROUGH
+type UpstreamErrorDetails struct {
+	// Status is the HTTP status ArgoCD answered with
+	Status int `json:"status"`
+	// Body is ArgoCD's response body, redacted and truncated to UPSTREAM_ERROR_BODY_LIMIT
+	// bytes; it usually holds the gRPC code and ArgoCD's message
+	Body string `json:"body"`
+}
+
+// ProblemDetails represents an RFC 7807 application/problem+json error document,
+// sent instead of ErrorResponse with ERROR_FORMAT=problem
+type ProblemDetails struct {
+	// Type identifies the error category as urn:argocd-proxy:error:<errorCode>
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance identifies this occurrence by its request ID, as urn:request:<id>
+	Instance  string    `json:"instance,omitempty"`
+	ErrorCode ErrorCode `json:"errorCode" enums:"upstream_down,not_found,filtered,invalid_param,unauthorized,admin_unauthorized,client_unauthorized"`
+	Fields    []string  `json:"fields,omitempty"`
+	// Profiles lists the schema profiles the route serves on not_acceptable errors
+	Profiles []string `json:"profiles,omitempty"`
+	// Upstream is ArgoCD's own answer to the failed call, included on request
+	Upstream *UpstreamErrorDetails `json:"upstream,omitempty"`
 }
 
 // ArgocdSessionResponse represents the response from ArgoCD session endpoint
@@ -60,6 +427,83 @@ type ArgocdProjectSpec struct {
 	SourceRepos  []string                   `json:"sourceRepos"`
 	Destinations []ArgocdProjectDestination `json:"destinations"`
 	Description  string                     `json:"description,omitempty"`
+	// Roles are the project's RBAC roles
+	Roles []ArgocdProjectRole `json:"roles,omitempty"`
+	// ClusterResourceWhitelist lists the cluster-scoped resources the project may deploy
+	ClusterResourceWhitelist []ArgocdGroupKind `json:"clusterResourceWhitelist,omitempty"`
+}
+
+// ArgocdProjectRole is an RBAC role defined by an ArgoCD project
+type ArgocdProjectRole struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Policies are Casbin policy lines such as
+	// "p, proj:my-project:read-only, applications, get, my-project/*, allow"
+	Policies []string `json:"policies,omitempty"`
+	// Groups are the SSO groups bound to the role
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ArgocdGroupKind identifies a Kubernetes resource type by API group and kind
+type ArgocdGroupKind struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+}
+
+// ProjectPolicyRule is a role policy line parsed into its fields
+type ProjectPolicyRule struct {
+	// Raw is the policy line as defined in the project
+	Raw      string `json:"raw"`
+	Subject  string `json:"subject,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Action   string `json:"action,omitempty"`
+	Object   string `json:"object,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+	// Malformed marks lines that are not a six-field "p, ..." policy; only Raw is set
+	Malformed bool `json:"malformed,omitempty"`
+}
+
+// ProjectRole is a project role as served by /projects/{project}/roles
+type ProjectRole struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Groups      []string `json:"groups"`
+	// Policies are the policy lines as defined, omitted with flattenPolicies=true
+	Policies []string `json:"policies,omitempty"`
+	// Rules are the parsed policy lines, only set with flattenPolicies=true
+	Rules []ProjectPolicyRule `json:"rules,omitempty"`
+}
+
+// ProjectRolesResponse lists the RBAC roles of a project
+type ProjectRolesResponse struct {
+	Project string        `json:"project"`
+	Roles   []ProjectRole `json:"roles"`
+}
+
+// NotificationSubscription is one recipient of an application's ArgoCD notifications,
+// parsed from a notifications.argoproj.io/subscribe.* annotation
+type NotificationSubscription struct {
+	// Trigger is the notification trigger; empty means the default triggers of the service
+	Trigger string `json:"trigger,omitempty"`
+	Service string `json:"service"`
+	Channel string `json:"channel"`
+	// Annotation is the annotation the subscription was parsed from
+	Annotation string `json:"annotation"`
+}
+
+// MalformedNotificationAnnotation is a subscribe annotation that could not be parsed
+type MalformedNotificationAnnotation struct {
+	Annotation string `json:"annotation"`
+	Value      string `json:"value"`
+	Reason     string `json:"reason"`
+}
+
+// ApplicationNotifications lists the notification subscriptions of an application
+type ApplicationNotifications struct {
+	Application      string                            `json:"application"`
+	HasSubscriptions bool                              `json:"hasSubscriptions"`
+	Subscriptions    []NotificationSubscription        `json:"subscriptions"`
+	Malformed        []MalformedNotificationAnnotation `json:"malformed,omitempty"`
 }
 
 // ArgocdProjectDestination represents a destination in an ArgoCD project
@@ -69,6 +513,24 @@ type ArgocdProjectDestination struct {
 	Name      string `json:"name,omitempty"`
 }
 
+// ProjectDestination is a destination declared by a project or observed on its applications
+type ProjectDestination struct {
+	Server    string `json:"server,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace"`
+	// Undeclared marks destinations used by applications but not permitted by the project spec
+	Undeclared   bool     `json:"undeclared,omitempty"`
+	Applications []string `json:"applications"`
+}
+
+// ProjectDestinationsResponse lists the reconciled destinations of a project
+type ProjectDestinationsResponse struct {
+	Project      string               `json:"project"`
+	Destinations []ProjectDestination `json:"destinations"`
+	// Warnings names the portions missing from a partial response
+	Warnings []string `json:"warnings,omitempty"`
+}
+
 // ArgocdProjectStatus represents the status of an ArgoCD project
 type ArgocdProjectStatus struct {
 	Phase   string `json:"phase,omitempty"`
@@ -96,15 +558,21 @@ type ArgocdProject struct {
 
 // ArgocdApplicationSource represents the source of an ArgoCD application
 type ArgocdApplicationSource struct {
-	RepoURL        string `json:"repoURL"`
-	Path           string `json:"path,omitempty"`
-	TargetRevision string `json:"targetRevision,omitempty"`
-	Helm           *struct {
-		ValueFiles []string `json:"valueFiles,omitempty"`
-	} `json:"helm,omitempty"`
-	Kustomize *struct {
-		NamePrefix string `json:"namePrefix,omitempty"`
-	} `json:"kustomize,omitempty"`
+	RepoURL        string                            `json:"repoURL"`
+	Path           string                            `json:"path,omitempty"`
+	TargetRevision string                            `json:"targetRevision,omitempty"`
+	Helm           *ArgocdApplicationSourceHelm      `json:"helm,omitempty"`
+	Kustomize      *ArgocdApplicationSourceKustomize `json:"kustomize,omitempty"`
+}
+
+// ArgocdApplicationSourceHelm holds the Helm options of an application source
+type ArgocdApplicationSourceHelm struct {
+	ValueFiles []string `json:"valueFiles,omitempty"`
+}
+
+// ArgocdApplicationSourceKustomize holds the Kustomize options of an application source
+type ArgocdApplicationSourceKustomize struct {
+	NamePrefix string `json:"namePrefix,omitempty"`
 }
 
 // ArgocdApplicationDestination represents the destination of an ArgoCD application
@@ -112,6 +580,20 @@ type ArgocdApplicationDestination struct {
 	Server    string `json:"server"`
 	Namespace string `json:"namespace"`
 	Name      string `json:"name,omitempty"`
+	// ClusterName is the display name of the destination cluster, computed by the proxy
+	// from ArgoCD's clusters; it falls back to the server URL when the name is unknown
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// ArgocdCluster represents a cluster registered in ArgoCD
+type ArgocdCluster struct {
+	Server string `json:"server"`
+	Name   string `json:"name"`
+}
+
+// ArgocdClusterList represents a list of ArgoCD clusters
+type ArgocdClusterList struct {
+	Items []ArgocdCluster `json:"items"`
 }
 
 // ArgocdApplicationSpec represents the specification of an ArgoCD application
@@ -119,12 +601,47 @@ type ArgocdApplicationSpec struct {
 	Project     string                       `json:"project"`
 	Source      ArgocdApplicationSource      `json:"source"`
 	Destination ArgocdApplicationDestination `json:"destination"`
-	SyncPolicy  *struct {
-		Automated *struct {
-			Prune    bool `json:"prune,omitempty"`
-			SelfHeal bool `json:"selfHeal,omitempty"`
-		} `json:"automated,omitempty"`
-	} `json:"syncPolicy,omitempty"`
+	SyncPolicy  *ArgocdSyncPolicy            `json:"syncPolicy,omitempty"`
+	// RevisionHistoryLimit is the number of history entries ArgoCD keeps; nil means ArgoCD's default of 10
+	RevisionHistoryLimit *int64 `json:"revisionHistoryLimit,omitempty"`
+	// Sources are the sources of a multi-source application, which has no Source
+	Sources []ArgocdApplicationSource `json:"sources,omitempty"`
+}
+
+// ArgocdSyncPolicy controls when and how ArgoCD syncs an application
+type ArgocdSyncPolicy struct {
+	// Automated is set when ArgoCD syncs the application without a manual trigger
+	Automated *ArgocdSyncPolicyAutomated `json:"automated,omitempty"`
+	Retry     *ArgocdSyncPolicyRetry     `json:"retry,omitempty"`
+	// SyncOptions are the sync options of every sync, e.g. CreateNamespace=true
+	SyncOptions []string `json:"syncOptions,omitempty"`
+}
+
+// ArgocdSyncPolicyAutomated is the automated sync policy of an application
+type ArgocdSyncPolicyAutomated struct {
+	// Prune deletes resources no longer in git during automated syncs
+	Prune bool `json:"prune,omitempty"`
+	// SelfHeal reverts changes made in the cluster outside of git
+	SelfHeal bool `json:"selfHeal,omitempty"`
+	// AllowEmpty allows an automated sync to prune every resource of the application
+	AllowEmpty bool `json:"allowEmpty,omitempty"`
+	// Enabled turns automated sync off when false, keeping the other settings; nil means on
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ArgocdSyncPolicyRetry is how often and how fast ArgoCD retries a failed sync
+type ArgocdSyncPolicyRetry struct {
+	// Limit is the number of retries; a negative limit retries forever
+	Limit   int64                         `json:"limit,omitempty"`
+	Backoff *ArgocdSyncPolicyRetryBackoff `json:"backoff,omitempty"`
+}
+
+// ArgocdSyncPolicyRetryBackoff is the backoff between sync retries
+type ArgocdSyncPolicyRetryBackoff struct {
+	// Duration and MaxDuration are Go durations such as 5s or 3m
+	Duration    string `json:"duration,omitempty"`
+	Factor      *int64 `json:"factor,omitempty"`
+	MaxDuration string `json:"maxDuration,omitempty"`
 }
 
 // ArgocdApplicationHealth represents the health status of an ArgoCD application
@@ -135,22 +652,50 @@ type ArgocdApplicationHealth struct {
 
 // ArgocdApplicationSync represents the sync status of an ArgoCD application
 type ArgocdApplicationSync struct {
-	Status     string `json:"status"`
-	ComparedTo *struct {
-		Source      ArgocdApplicationSource      `json:"source"`
-		Destination ArgocdApplicationDestination `json:"destination"`
-	} `json:"comparedTo,omitempty"`
+	Status     string                       `json:"status"`
+	ComparedTo *ArgocdApplicationComparedTo `json:"comparedTo,omitempty"`
+	Revision   string                       `json:"revision,omitempty"`
+}
+
+// ArgocdApplicationComparedTo is the source and destination the sync status was computed against
+type ArgocdApplicationComparedTo struct {
+	Source      ArgocdApplicationSource      `json:"source"`
+	Destination ArgocdApplicationDestination `json:"destination"`
+}
+
+// ArgocdSyncOperationResult represents the result of the last sync operation
+type ArgocdSyncOperationResult struct {
 	Revision string `json:"revision,omitempty"`
 }
 
+// ArgocdOperationState represents the state of the last operation performed on an application
+type ArgocdOperationState struct {
+	Phase      string                     `json:"phase,omitempty"`
+	Message    string                     `json:"message,omitempty"`
+	StartedAt  *time.Time                 `json:"startedAt,omitempty"`
+	FinishedAt *time.Time                 `json:"finishedAt,omitempty"`
+	SyncResult *ArgocdSyncOperationResult `json:"syncResult,omitempty"`
+}
+
 // ArgocdApplicationStatus represents the status of an ArgoCD application
 type ArgocdApplicationStatus struct {
-	Health       ArgocdApplicationHealth   `json:"health"`
-	Sync         ArgocdApplicationSync     `json:"sync"`
-	Resources    []interface{}             `json:"resources,omitempty"`
-	Conditions   []interface{}             `json:"conditions,omitempty"`
-	ReconciledAt time.Time                 `json:"reconciledAt,omitempty"`
-	Summary      *ArgocdApplicationSummary `json:"summary,omitempty"`
+	Health         ArgocdApplicationHealth   `json:"health"`
+	Sync           ArgocdApplicationSync     `json:"sync"`
+	Resources      []interface{}             `json:"resources,omitempty"`
+	Conditions     []interface{}             `json:"conditions,omitempty"`
+	ReconciledAt   time.Time                 `json:"reconciledAt,omitempty"`
+	Summary        *ArgocdApplicationSummary `json:"summary,omitempty"`
+	OperationState *ArgocdOperationState     `json:"operationState,omitempty"`
+	// History lists the application's most recent deployments, oldest first
+	History []ArgocdRevisionHistory `json:"history,omitempty"`
+}
+
+// ArgocdRevisionHistory is a single deployment in an application's history
+type ArgocdRevisionHistory struct {
+	ID              int64      `json:"id"`
+	Revision        string     `json:"revision,omitempty"`
+	DeployedAt      time.Time  `json:"deployedAt"`
+	DeployStartedAt *time.Time `json:"deployStartedAt,omitempty"`
 }
 
 // ArgocdApplicationSummary represents the summary information of an ArgoCD application
@@ -167,6 +712,8 @@ type ArgocdApplicationMetadata struct {
 	Annotations       map[string]string `json:"annotations,omitempty"`
 	CreationTimestamp time.Time         `json:"creationTimestamp,omitempty"`
 	UID               string            `json:"uid,omitempty"`
+	// DeletionTimestamp is set once deletion was requested, while finalizers are pending
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"`
 }
 
 // ArgocdApplication represents an ArgoCD application
@@ -177,6 +724,218 @@ type ArgocdApplication struct {
 	Spec        ArgocdApplicationSpec     `json:"spec"`
 	Status      ArgocdApplicationStatus   `json:"status,omitempty"`
 	IngressURLs []string                  `json:"ingressUrls,omitempty"` // Enhanced with ingress URLs
+	// MatchedImages lists the images that satisfied an ?image= filter, when one was supplied
+	MatchedImages []string `json:"matchedImages,omitempty"`
+	// Age is the time since creation, computed by the proxy; null without a creation timestamp
+	Age *ApplicationAge `json:"age"`
+	// Deleting is set by the proxy while the application has a deletion timestamp
+	Deleting bool `json:"deleting,omitempty"`
+	// Ownership maps each OWNER_ANNOTATIONS key the application is annotated with to its
+	// value; empty when it has none, and omitted when OWNER_ANNOTATIONS is not set
+	Ownership map[string]string `json:"ownership,omitzero"`
+}
+
+// OwnersResponse lists the distinct owners of the visible applications
+type OwnersResponse struct {
+	// Annotation is the annotation key identifying owners, the first of OWNER_ANNOTATIONS
+	Annotation string       `json:"annotation"`
+	Owners     []OwnerCount `json:"owners"`
+	// Unowned counts the applications without the owner annotation
+	Unowned int `json:"unowned"`
+}
+
+// OwnerCount is an owner with the number of applications it owns
+type OwnerCount struct {
+	Owner        string `json:"owner"`
+	Applications int    `json:"applications"`
+}
+
+// RepositoryTopologyResponse groups the visible applications by the source repositories
+// they deploy from
+type RepositoryTopologyResponse struct {
+	// Repositories is keyed by normalized repository URL, e.g. github.com/company/api
+	Repositories map[string]RepositoryApplications `json:"repositories"`
+	// RepositoryCount is the number of repositories listed
+	RepositoryCount int `json:"repositoryCount"`
+	// ApplicationCount is the number of distinct applications deploying from them
+	ApplicationCount int `json:"applicationCount"`
+}
+
+// RepositoryApplications lists the applications deploying from one repository. A
+// multi-source application with several sources in the repository is listed once per
+// source and counted once.
+type RepositoryApplications struct {
+	Count        int                     `json:"count"`
+	Applications []RepositoryApplication `json:"applications"`
+}
+
+// RepositoryApplication is an application source pointing at a repository
+type RepositoryApplication struct {
+	Name           string `json:"name"`
+	Project        string `json:"project"`
+	Path           string `json:"path,omitempty"`
+	TargetRevision string `json:"targetRevision,omitempty"`
+}
+
+// InventoryResponse is the inventory of the visible applications by project, for
+// compliance reviews
+type InventoryResponse struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Projects is keyed by project name
+	Projects map[string]InventoryProject `json:"projects"`
+	// ProjectCount is the number of projects with at least one listed application
+	ProjectCount int `json:"projectCount"`
+	// ApplicationCount is the number of applications listed
+	ApplicationCount int `json:"applicationCount"`
+}
+
+// InventoryProject lists the applications of one project, sorted by name
+type InventoryProject struct {
+	Applications []InventoryApplication `json:"applications"`
+}
+
+// InventoryApplication is what an application deploys and where it is reachable
+type InventoryApplication struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Images are the container images the application runs, sorted
+	Images []string `json:"images"`
+	// IngressURLs are the external URLs of the application, sorted
+	IngressURLs []string `json:"ingressUrls"`
+	// Repo is the repository of the application's first source
+	Repo string `json:"repo"`
+	// Revision is the revision the application last synced, or the target revision of its
+	// first source when it never synced
+	Revision string `json:"revision"`
+	Health   string `json:"health"`
+}
+
+// AutoSyncResponse lists the applications ArgoCD syncs automatically, for reviewing which
+// of them may prune resources or revert changes made in the cluster
+type AutoSyncResponse struct {
+	Applications []AutoSyncApplication `json:"applications"`
+	// AutoSync counts the applications listed, and Prune and SelfHeal those of them with
+	// the setting enabled
+	AutoSync int `json:"autoSync"`
+	Prune    int `json:"prune"`
+	SelfHeal int `json:"selfHeal"`
+	// Manual counts the filtered applications that are not listed because they only sync
+	// when triggered
+	Manual int `json:"manual"`
+}
+
+// AutoSyncApplication is the automated sync policy of an application
+type AutoSyncApplication struct {
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Project     string   `json:"project"`
+	Prune       bool     `json:"prune"`
+	SelfHeal    bool     `json:"selfHeal"`
+	AllowEmpty  bool     `json:"allowEmpty"`
+	SyncOptions []string `json:"syncOptions"`
+}
+
+// ApplicationAge is the time elapsed since an application was created
+type ApplicationAge struct {
+	Seconds int64 `json:"seconds"`
+	// Human is the age formatted like kubectl's AGE column (e.g. 45s, 5h12m, 3d4h, 400d)
+	Human string `json:"human"`
+}
+
+// ArgocdSyncWindow represents an ArgoCD sync window assigned to an application
+type ArgocdSyncWindow struct {
+	Kind       string `json:"kind"`
+	Schedule   string `json:"schedule"`
+	Duration   string `json:"duration"`
+	ManualSync bool   `json:"manualSync,omitempty"`
+	TimeZone   string `json:"timeZone,omitempty"`
+}
+
+// ArgocdApplicationSyncWindowsResponse represents the response from ArgoCD's
+// /applications/{name}/syncwindows endpoint
+type ArgocdApplicationSyncWindowsResponse struct {
+	AssignedWindows []ArgocdSyncWindow `json:"assignedWindows"`
+	ActiveWindows   []ArgocdSyncWindow `json:"activeWindows"`
+	CanSync         bool               `json:"canSync"`
+}
+
+// ApplicationSyncWindows summarizes the sync windows affecting an application,
+// splitting the currently active windows into allows and denies
+type ApplicationSyncWindows struct {
+	Application     string             `json:"application"`
+	CanSync         bool               `json:"canSync"`
+	ActiveAllows    []ArgocdSyncWindow `json:"activeAllows"`
+	ActiveDenies    []ArgocdSyncWindow `json:"activeDenies"`
+	AssignedWindows []ArgocdSyncWindow `json:"assignedWindows"`
+}
+
+// ArgocdManagedResource is an entry of ArgoCD's /applications/{name}/managed-resources
+// response. The states are JSON documents encoded as strings, "null" when absent.
+type ArgocdManagedResource struct {
+	Group       string `json:"group,omitempty"`
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name"`
+	TargetState string `json:"targetState,omitempty"`
+	LiveState   string `json:"liveState,omitempty"`
+	Modified    bool   `json:"modified,omitempty"`
+	Hook        bool   `json:"hook,omitempty"`
+}
+
+// ArgocdManagedResourcesResponse represents the response from ArgoCD's
+// /applications/{name}/managed-resources endpoint
+type ArgocdManagedResourcesResponse struct {
+	Items []ArgocdManagedResource `json:"items"`
+}
+
+// Resource changes reported in an application diff
+const (
+	ResourceChangeAdded     = "added"
+	ResourceChangeModified  = "modified"
+	ResourceChangePruned    = "pruned"
+	ResourceChangeUnchanged = "unchanged"
+)
+
+// ApplicationDiffEntry describes what a sync would do to a single managed resource
+type ApplicationDiffEntry struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Change    string `json:"change" enums:"added,modified,pruned,unchanged"`
+	Modified  bool   `json:"modified"`
+}
+
+// ApplicationDiff summarizes the differences between an application's desired and live
+// state without the manifests themselves
+type ApplicationDiff struct {
+	Application string                 `json:"application"`
+	HasChanges  bool                   `json:"hasChanges"`
+	Added       int                    `json:"added"`
+	Modified    int                    `json:"modified"`
+	Pruned      int                    `json:"pruned"`
+	Unchanged   int                    `json:"unchanged"`
+	Resources   []ApplicationDiffEntry `json:"resources"`
+}
+
+// ArgocdLogEntry is one line of ArgoCD's /applications/{name}/logs stream, which is
+// newline-delimited JSON. A failure after the stream started is sent as an error entry.
+type ArgocdLogEntry struct {
+	Result *struct {
+		Content string `json:"content"`
+		PodName string `json:"podName,omitempty"`
+		Last    bool   `json:"last,omitempty"`
+	} `json:"result,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ApplicationLogOptions select the container logs read from an application
+type ApplicationLogOptions struct {
+	Container string
+	PodName   string
+	TailLines int
 }
 
 // ArgocdApplicationList represents a list of ArgoCD applications
@@ -187,6 +946,141 @@ type ArgocdApplicationList struct {
 	Metadata   struct {
 		ResourceVersion string `json:"resourceVersion,omitempty"`
 	} `json:"metadata,omitempty"`
+	// Meta is set by the proxy when the list was truncated
+	Meta *ListMeta `json:"meta,omitempty"`
+	// SnapshotToken names the application set this list was read from; pass it as
+	// ?since= to receive only the changes made after it
+	SnapshotToken string `json:"snapshotToken,omitempty"`
+	// Full is set on a ?since= response when the token was unknown or too old, so the
+	// items are the whole list rather than the changes
+	Full bool `json:"full,omitempty"`
+	// Removed lists the applications removed since the ?since= snapshot
+	Removed []ApplicationRef `json:"removed,omitempty"`
+}
+
+// ApplicationRef identifies an application by namespace and name, as names are only
+// unique within a namespace
+type ApplicationRef struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ApplicationSummary is the compact form of an application served by /bootstrap. Fields
+// not selected with ?fields= are omitted.
+type ApplicationSummary struct {
+	Name    string `json:"name"`
+	Project string `json:"project,omitempty"`
+	// Cluster is the destination cluster name, falling back to its server URL
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Health    string `json:"health,omitempty"`
+	Sync      string `json:"sync,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	// HealthSince and SyncSince are when the background poller first saw the current
+	// health and sync status; omitted while the poller has not seen it
+	HealthSince *time.Time `json:"healthSince,omitempty"`
+	SyncSince   *time.Time `json:"syncSince,omitempty"`
+	// AutoSync is whether ArgoCD syncs the application automatically; a pointer so that
+	// manual applications report false
+	AutoSync *bool `json:"autoSync,omitempty"`
+}
+
+// ApplicationSummaryV2 is ApplicationSummary in the summary-v2 schema profile, with the
+// destination and status fields grouped. Groups without a selected field are omitted.
+type ApplicationSummaryV2 struct {
+	Name        string                         `json:"name"`
+	Project     string                         `json:"project,omitempty"`
+	Destination *ApplicationSummaryDestination `json:"destination,omitempty"`
+	Status      *ApplicationSummaryStatus      `json:"status,omitempty"`
+	AutoSync    *bool                          `json:"autoSync,omitempty"`
+}
+
+// ApplicationSummaryDestination is where a summary-v2 application is deployed
+type ApplicationSummaryDestination struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ApplicationSummaryStatus is the health and sync state of a summary-v2 application
+type ApplicationSummaryStatus struct {
+	Health      string     `json:"health,omitempty"`
+	Sync        string     `json:"sync,omitempty"`
+	Revision    string     `json:"revision,omitempty"`
+	HealthSince *time.Time `json:"healthSince,omitempty"`
+	SyncSince   *time.Time `json:"syncSince,omitempty"`
+}
+
+// SchemaProfile is a response shape a route serves, selected with the profile parameter
+// of the Accept header
+type SchemaProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Default is set on the profile served when the request names none
+	Default bool `json:"default,omitempty"`
+}
+
+// SchemaRoute lists the profiles of a route template
+type SchemaRoute struct {
+	Route    string          `json:"route"`
+	Profiles []SchemaProfile `json:"profiles"`
+}
+
+// SchemasResponse lists the routes serving several response shapes, sorted by route
+type SchemasResponse struct {
+	Routes []SchemaRoute `json:"routes"`
+}
+
+// BootstrapResponse bundles the data a dashboard loads on startup. In a partial response
+// the sections that could not be read are null and named in Warnings.
+type BootstrapResponse struct {
+	ProjectGroups *BootstrapProjectGroups `json:"projectGroups"`
+	Projects      *BootstrapProjects      `json:"projects"`
+	Applications  *BootstrapApplications  `json:"applications"`
+	Health        BootstrapHealth         `json:"health"`
+	Warnings      []string                `json:"warnings,omitempty"`
+}
+
+// BootstrapProjectGroups is the /project-groups payload of a bootstrap response
+type BootstrapProjectGroups struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	config.ProjectGroupsResponse
+}
+
+// BootstrapProjects lists the sorted names of the projects not hidden by the ignore rules
+type BootstrapProjects struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Names       []string  `json:"names"`
+}
+
+// BootstrapApplications lists the applications of a bootstrap response, as summaries by
+// default or as full objects with view=full
+type BootstrapApplications struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// View is summary or full
+	View  string `json:"view"`
+	Total int    `json:"total"`
+	// Summaries is set with view=summary, Items with view=full
+	Summaries []ApplicationSummary `json:"summaries,omitzero"`
+	Items     []ArgocdApplication  `json:"items,omitzero"`
+}
+
+// BootstrapHealth is a health snapshot derived from the data fetched for the bootstrap
+// response, without a separate ArgoCD connectivity check
+type BootstrapHealth struct {
+	GeneratedAt time.Time              `json:"generatedAt"`
+	Status      string                 `json:"status"`
+	Version     string                 `json:"version"`
+	TokenStatus map[string]interface{} `json:"tokenStatus"`
+	ArgocdAPI   string                 `json:"argocdApiStatus"`
+}
+
+// ListMeta describes a list response truncated to the maximum number of items
+type ListMeta struct {
+	Truncated bool `json:"truncated"`
+	// Total is the number of items before truncation
+	Total int `json:"total"`
+	// Limit is the maximum number of items returned
+	Limit int `json:"limit"`
 }
 
 // ArgocdProjectList represents a list of ArgoCD projects
@@ -198,3 +1092,58 @@ type ArgocdProjectList struct {
 		ResourceVersion string `json:"resourceVersion,omitempty"`
 	} `json:"metadata,omitempty"`
 }
+
+// DeployStats summarizes the deployments within a time window
+type DeployStats struct {
+	WindowStart       time.Time `json:"windowStart"`
+	WindowSeconds     int64     `json:"windowSeconds"`
+	Deployments       int       `json:"deployments"`
+	DeploymentsPerDay float64   `json:"deploymentsPerDay"`
+	// MeanIntervalSeconds is the mean time between consecutive deployments; null with fewer than two
+	MeanIntervalSeconds *float64   `json:"meanIntervalSeconds"`
+	LastDeployedAt      *time.Time `json:"lastDeployedAt"`
+	// Truncated is set when ArgoCD's retained history may not reach back to the window start,
+	// so the counts are a lower bound
+	Truncated bool `json:"truncated"`
+}
+
+// ApplicationDeployStats is the deployment frequency of a single application
+type ApplicationDeployStats struct {
+	Name    string `json:"name"`
+	Project string `json:"project"`
+	DeployStats
+}
+
+// GroupDeployStats is the deployment frequency of a project group across its applications
+type GroupDeployStats struct {
+	Group string `json:"group"`
+	DeployStats
+	Applications []ApplicationDeployStats `json:"applications"`
+}
+
+// GroupDriftEntry describes a single out-of-sync application in a drift report
+type GroupDriftEntry struct {
+	Name                    string     `json:"name"`
+	Project                 string     `json:"project"`
+	CurrentRevision         string     `json:"currentRevision"`
+	TargetRevision          string     `json:"targetRevision"`
+	LastOperationFinishedAt *time.Time `json:"lastOperationFinishedAt"`
+	IngressURLs             []string   `json:"ingressUrls"`
+	// AutoSync is set when ArgoCD syncs the application automatically, so the drift is
+	// about to be reverted or a sync keeps failing
+	AutoSync bool `json:"autoSync"`
+}
+
+// GroupDriftReport lists the out-of-sync applications of a project group
+type GroupDriftReport struct {
+	Group             string `json:"group"`
+	TotalApplications int    `json:"totalApplications"`
+	OutOfSync         int    `json:"outOfSync"`
+	// AutoSync and Manual split TotalApplications by whether ArgoCD syncs them
+	// automatically
+	AutoSync int `json:"autoSync"`
+	Manual   int `json:"manual"`
+	// Deleting counts applications pending deletion; they are not part of the other counts
+	Deleting     int               `json:"deleting"`
+	Applications []GroupDriftEntry `json:"applications"`
+}
@@ -0,0 +1,460 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/redact"
+	"argocd-proxy/timing"
+	"argocd-proxy/types"
+)
+
+// Authenticator creates requests carrying ArgoCD credentials. project is an optional hint
+// naming the project the request targets, for project-scoped tokens.
+type Authenticator interface {
+	CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error)
+}
+
+// Errors wrapped by the client so that callers can classify failures with errors.Is
+var (
+	// ErrUnauthorized is wrapped when ArgoCD rejects the proxy's credentials
+	ErrUnauthorized = errors.New("unauthorized by ArgoCD")
+	// ErrUpstreamTooLarge is wrapped when a response body exceeds the call's size limit
+	ErrUpstreamTooLarge = errors.New("ArgoCD response body too large")
+	// ErrSuppressed is returned instead of calling ArgoCD when the context suppresses calls
+	ErrSuppressed = errors.New("ArgoCD calls suppressed during maintenance")
+)
+
+// suppressKey is the context key marking contexts whose ArgoCD calls are suppressed
+type suppressKey struct{}
+
+// Suppress returns a context whose calls fail with ErrSuppressed without reaching ArgoCD
+func Suppress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressKey{}, true)
+}
+
+// Suppressed reports whether ArgoCD calls made with ctx are suppressed
+func Suppressed(ctx context.Context) bool {
+	suppressed, _ := ctx.Value(suppressKey{}).(bool)
+	return suppressed
+}
+
+// MaxErrorBodyBytes is the number of bytes drained from a response body that is discarded,
+// so that the connection can be reused
+const MaxErrorBodyBytes = 1 << 10
+
+// retryBackoff is the delay before the first retry; each further retry waits one more step
+var retryBackoff = 100 * time.Millisecond
+
+// StatusError is returned when ArgoCD answers with an unexpected status. It wraps
+// ErrUnauthorized for 401 and 403 responses, and Err when the status was mapped to it.
+type StatusError struct {
+	StatusCode int
+	// Body is the response body, truncated and redacted
+	Body string
+	// Err is the error the status was mapped to, such as the NotFound option's; when set
+	// it provides the message and is what the error wraps
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Unauthorized() {
+		return fmt.Sprintf("%v: ArgoCD API returned status %d: %s", ErrUnauthorized, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("ArgoCD API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns Err when set, and otherwise ErrUnauthorized when ArgoCD rejected the
+// proxy's credentials
+func (e *StatusError) Unwrap() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	if e.Unauthorized() {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Unauthorized reports whether ArgoCD rejected the proxy's credentials
+func (e *StatusError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Client performs authenticated, instrumented calls to the ArgoCD API. Paths are relative
+// to the active URL of its endpoints, which is read on every call.
+type Client struct {
+	config     *config.Config
+	auth       Authenticator
+	httpClient *http.Client
+	endpoints  *Endpoints
+	// lastError is the latest failed call, for the support bundle
+	lastError atomic.Pointer[types.UpstreamError]
+	// unknownFields holds the endpoint and path of each unknown field already logged
+	unknownFields sync.Map
+}
+
+// New returns a client calling ArgoCD at endpoints through httpClient with credentials
+// from auth. Nil endpoints are built from the ARGOCD_API_URL URLs of cfg.
+func New(cfg *config.Config, auth Authenticator, httpClient *http.Client, endpoints *Endpoints) *Client {
+	if endpoints == nil {
+		endpoints = NewEndpointsFor(cfg)
+	}
+	return &Client{
+		config:     cfg,
+		auth:       auth,
+		httpClient: httpClient,
+		endpoints:  endpoints,
+	}
+}
+
+// Endpoints returns the ArgoCD API URLs the client calls
+func (c *Client) Endpoints() *Endpoints {
+	return c.endpoints
+}
+
+// callOptions are the per-call settings built from Options
+type callOptions struct {
+	// baseURL replaces the URLs of ARGOCD_API_URL when set
+	baseURL  string
+	query    url.Values
+	project  string
+	endpoint string
+	timeout  time.Duration
+	notFound error
+	retries  int
+	maxBody  int64
+	strict   bool
+}
+
+// Option configures a single call
+type Option func(*callOptions)
+
+// Query adds a query parameter to the request URL
+func Query(key, value string) Option {
+	return func(o *callOptions) {
+		if o.query == nil {
+			o.query = url.Values{}
+		}
+		o.query.Add(key, value)
+	}
+}
+
+// Project authenticates the call with the scoped token of the project, when one is configured
+func Project(name string) Option {
+	return func(o *callOptions) { o.project = name }
+}
+
+// Endpoint sets the endpoint label of the call's metrics, which defaults to the path
+func Endpoint(label string) Option {
+	return func(o *callOptions) { o.endpoint = label }
+}
+
+// BaseURL resolves the path against base instead of ARGOCD_API_URL, without failover
+func BaseURL(base string) Option {
+	return func(o *callOptions) { o.baseURL = base }
+}
+
+// Timeout bounds each attempt of the call by d instead of UPSTREAM_TIMEOUT, in addition to
+// the caller's context. Obtaining the token is not bounded by it.
+func Timeout(d time.Duration) Option {
+	return func(o *callOptions) { o.timeout = d }
+}
+
+// NotFound makes the call fail with err when ArgoCD answers 404, wrapped in the
+// *StatusError carrying the response
+func NotFound(err error) Option {
+	return func(o *callOptions) { o.notFound = err }
+}
+
+// Retries retries the call up to n times after transport errors, 429 responses and 5xx
+// responses, with a growing delay. Only idempotent calls should be retried.
+func Retries(n int) Option {
+	return func(o *callOptions) { o.retries = n }
+}
+
+// MaxBodySize fails the call with ErrUpstreamTooLarge when the response body exceeds n
+// bytes, instead of UPSTREAM_MAX_BODY_BYTES
+func MaxBodySize(n int64) Option {
+	return func(o *callOptions) { o.maxBody = n }
+}
+
+// GetJSON fetches path and decodes the JSON response into out. A nil out discards the body.
+// Unexpected statuses are returned as *StatusError.
+func (c *Client) GetJSON(ctx context.Context, path string, out any, opts ...Option) error {
+	o := c.options(path, opts)
+
+	resp, err := c.do(ctx, http.MethodGet, path, nil, o)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, o)
+	}
+
+	switch {
+	case out == nil:
+		_, err = io.Copy(io.Discard, resp.Body)
+	case o.strict && c.config.UpstreamStrictDecode != "" && c.config.UpstreamStrictDecode != config.StrictDecodeOff:
+		err = c.decodeChecked(resp.Body, out, o.endpoint)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(out)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUpstreamTooLarge) {
+			return fmt.Errorf("%w: %s exceeds %d bytes", ErrUpstreamTooLarge, o.endpoint, o.maxBody)
+		}
+		return fmt.Errorf("failed to decode %s response: %w", o.endpoint, err)
+	}
+	return nil
+}
+
+// Stream fetches path and returns the body of a 200 response unread, for responses relayed
+// while they arrive. Unexpected statuses are returned as *StatusError. The caller must
+// close the body.
+func (c *Client) Stream(ctx context.Context, path string, opts ...Option) (io.ReadCloser, error) {
+	o := c.options(path, opts)
+
+	resp, err := c.do(ctx, http.MethodGet, path, nil, o)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+	return nil, statusError(resp, o)
+}
+
+// Do sends a request to path and returns the response unread, whatever its status. Reading
+// the body fails with ErrUpstreamTooLarge past the call's size limit. The caller must close
+// the response body.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte, opts ...Option) (*http.Response, error) {
+	return c.do(ctx, method, path, body, c.options(path, opts))
+}
+
+// options applies opts over the defaults for a call to path
+func (c *Client) options(path string, opts []Option) callOptions {
+	o := callOptions{
+		endpoint: path,
+		timeout:  c.config.UpstreamTimeout,
+		maxBody:  c.config.UpstreamMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// do sends the request, retrying as configured. Each attempt is authenticated afresh, so
+// a token refreshed in between is picked up, and tries the URLs of ARGOCD_API_URL in turn
+// until one is reached.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, o callOptions) (*http.Response, error) {
+	if Suppressed(ctx) {
+		return nil, fmt.Errorf("%w: %s", ErrSuppressed, o.endpoint)
+	}
+	target := path
+	if len(o.query) > 0 {
+		target += "?" + o.query.Encode()
+	}
+	endpoints := c.endpoints
+	if o.baseURL != "" {
+		endpoints = NewEndpoints([]string{o.baseURL}, 0, nil)
+	}
+
+	for attempt := 0; ; attempt++ {
+		var authErr error
+		resp, err := endpoints.Call(ctx, func(baseURL string) (*http.Response, error) {
+			req, err := c.auth.CreateAuthenticatedRequest(ctx, method, baseURL+target, body, o.project)
+			if err != nil {
+				authErr = fmt.Errorf("failed to create authenticated request: %w", err)
+				return nil, authErr
+			}
+			return c.send(req, o)
+		})
+		if authErr != nil {
+			return nil, authErr
+		}
+		if attempt >= o.retries || !retryable(resp, err) || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("failed to execute request to ArgoCD: %w", err)
+			}
+			resp.Body = limitBody(resp.Body, o.maxBody)
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, MaxErrorBodyBytes))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to execute request to ArgoCD: %w", ctx.Err())
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		}
+	}
+}
+
+// send executes one attempt, bounded by the call timeout once authenticated. The
+// attempt also ends as soon as the caller's context is canceled, e.g. when the client
+// disconnects. The response body releases the timeout when it is closed.
+func (c *Client) send(req *http.Request, o callOptions) (*http.Response, error) {
+	if o.timeout <= 0 {
+		return c.instrumented(req, o.endpoint)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), o.timeout)
+	resp, err := c.instrumented(req.WithContext(ctx), o.endpoint)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// instrumented executes an HTTP request, records ArgoCD API metrics and reports the
+// upstream time to the request timing carried by the request context
+func (c *Client) instrumented(req *http.Request, endpoint string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	elapsed := time.Since(start)
+
+	metrics.Observe(req.Context(), metrics.ArgocdAPIRequestDuration.WithLabelValues(endpoint), elapsed.Seconds())
+	timing.AddUpstream(req.Context(), elapsed)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.ArgocdAPIRequestsTotal.WithLabelValues(endpoint, status).Inc()
+	c.recordFailure(req, endpoint, resp, err, start)
+
+	if soft := c.config.UpstreamSoftTimeout; soft > 0 && elapsed > soft {
+		metrics.ArgocdAPISlowRequestsTotal.WithLabelValues(endpoint).Inc()
+		log.Printf("WARNING: Slow ArgoCD call: %s %s took %v (soft deadline %v)", req.Method, endpoint, elapsed.Round(time.Millisecond), soft)
+	}
+
+	return resp, err
+}
+
+// recordFailure keeps the call as the latest failure when it got no response or an error
+// status. 404 is an answer callers expect for missing resources and is not kept.
+func (c *Client) recordFailure(req *http.Request, endpoint string, resp *http.Response, err error, at time.Time) {
+	failure := types.UpstreamError{Time: at, Method: req.Method, Endpoint: endpoint}
+	switch {
+	case err != nil:
+		failure.Message = redact.String(err.Error())
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound:
+		failure.Status = resp.StatusCode
+		failure.Message = fmt.Sprintf("ArgoCD API returned status %d", resp.StatusCode)
+	default:
+		return
+	}
+	c.lastError.Store(&failure)
+}
+
+// LastError returns the latest failed call, or nil when no call has failed
+func (c *Client) LastError() *types.UpstreamError {
+	if failure := c.lastError.Load(); failure != nil {
+		copied := *failure
+		return &copied
+	}
+	return nil
+}
+
+// retryable reports whether an attempt failed in a way worth retrying
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		// A refused redirect would be refused again, and an unsupported encoding sent again
+		return !errors.Is(err, ErrRedirectNotAllowed) && !errors.Is(err, ErrUnsupportedEncoding)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// statusError builds the error for an unexpected response status, with the body
+// truncated and redacted. A 404 is mapped to the call's NotFound error when it has one.
+func statusError(resp *http.Response, o callOptions) error {
+	err := &StatusError{StatusCode: resp.StatusCode, Body: redact.Body(ErrorBody(resp.Body))}
+	if resp.StatusCode == http.StatusNotFound {
+		err.Err = o.notFound
+	}
+	return err
+}
+
+// ErrorBody reads as much of a body used to describe an error as redact.Body keeps, so
+// that UPSTREAM_ERROR_BODY_LIMIT bytes reach the error while an endless error response
+// cannot exhaust memory. Read errors are ignored.
+func ErrorBody(r io.Reader) []byte {
+	body, _ := io.ReadAll(io.LimitReader(r, redact.ReadLimit()))
+	return body
+}
+
+// LimitReader returns a reader that fails with ErrUpstreamTooLarge once more than limit
+// bytes are read from r. A limit of 0 or less leaves r unbounded.
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &limitedReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+// limitedReader reads from a reader limited to one byte past limit and fails with
+// ErrUpstreamTooLarge when that byte is reached
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, ErrUpstreamTooLarge
+	}
+	return n, err
+}
+
+// limitBody bounds a response body to limit bytes, as LimitReader
+func limitBody(body io.ReadCloser, limit int64) io.ReadCloser {
+	if limit <= 0 {
+		return body
+	}
+	return limitedBody{Reader: LimitReader(body, limit), Closer: body}
+}
+
+// limitedBody is a response body read through a LimitReader
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// cancelOnClose releases a call's timeout once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
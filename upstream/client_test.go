@@ -0,0 +1,489 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/redact"
+)
+
+// fakeAuthenticator creates bearer-token requests and records the project hints it was given
+type fakeAuthenticator struct {
+	err      error
+	projects []string
+}
+
+func (a *fakeAuthenticator) CreateAuthenticatedRequest(ctx context.Context, method, url string, body []byte, project string) (*http.Request, error) {
+	a.projects = append(a.projects, project)
+	if a.err != nil {
+		return nil, a.err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = strings.NewReader(string(body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer test-token")
+	return req, nil
+}
+
+// newTestClient returns a client calling handler through a test server
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *fakeAuthenticator) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	auth := &fakeAuthenticator{}
+	return New(&config.Config{ArgocdAPIURL: server.URL + "/api/v1"}, auth, server.Client(), nil), auth
+}
+
+func TestClientGetJSON(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	client, auth := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		w.Write([]byte(`{"name":"guestbook"}`))
+	})
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	err := client.GetJSON(context.Background(), "/applications/guestbook", &out,
+		Query("fields", "metadata.resourceVersion"), Project("team-a"))
+	if err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+
+	if out.Name != "guestbook" {
+		t.Errorf("decoded name = %q, want guestbook", out.Name)
+	}
+	if gotPath != "/api/v1/applications/guestbook" {
+		t.Errorf("path = %q, want /api/v1/applications/guestbook", gotPath)
+	}
+	if gotQuery != "fields=metadata.resourceVersion" {
+		t.Errorf("query = %q, want fields=metadata.resourceVersion", gotQuery)
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Errorf("Authorization = %q, want Bearer test-token", gotAuth)
+	}
+	if len(auth.projects) != 1 || auth.projects[0] != "team-a" {
+		t.Errorf("project hints = %v, want [team-a]", auth.projects)
+	}
+}
+
+func TestClientGetJSONBaseURL(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New(&config.Config{ArgocdAPIURL: server.URL + "/api/v1"}, &fakeAuthenticator{}, server.Client(), nil)
+	if err := client.GetJSON(context.Background(), "/version", nil, BaseURL(server.URL+"/api")); err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	if gotPath != "/api/version" {
+		t.Errorf("path = %q, want /api/version", gotPath)
+	}
+}
+
+func TestClientGetJSONErrors(t *testing.T) {
+	errMissing := errors.New("application missing")
+
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		authErr      error
+		opts         []Option
+		wantIs       error
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:         "unauthorized",
+			status:       http.StatusUnauthorized,
+			body:         "bad token",
+			wantIs:       ErrUnauthorized,
+			wantStatus:   http.StatusUnauthorized,
+			wantContains: "unauthorized by ArgoCD: ArgoCD API returned status 401: bad token",
+		},
+		{
+			name:       "forbidden",
+			status:     http.StatusForbidden,
+			wantIs:     ErrUnauthorized,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:         "server error",
+			status:       http.StatusInternalServerError,
+			body:         "boom",
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: "ArgoCD API returned status 500: boom",
+		},
+		{
+			name:       "not found without mapping",
+			status:     http.StatusNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "not found mapped",
+			status:       http.StatusNotFound,
+			body:         `{"error":"applications.argoproj.io \"web\" not found","code":5}`,
+			opts:         []Option{NotFound(errMissing)},
+			wantIs:       errMissing,
+			wantStatus:   http.StatusNotFound,
+			wantContains: "application missing",
+		},
+		{
+			name:         "invalid JSON",
+			status:       http.StatusOK,
+			body:         "{",
+			wantContains: "failed to decode /projects response",
+		},
+		{
+			name:         "body too large",
+			status:       http.StatusOK,
+			body:         `{"items":["a","b","c"]}`,
+			opts:         []Option{MaxBodySize(8)},
+			wantIs:       ErrUpstreamTooLarge,
+			wantContains: "/projects exceeds 8 bytes",
+		},
+		{
+			name:         "authentication failure",
+			authErr:      errors.New("no token"),
+			wantContains: "failed to create authenticated request: no token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, auth := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			auth.err = tt.authErr
+
+			var out map[string]any
+			err := client.GetJSON(context.Background(), "/projects", &out, tt.opts...)
+			if err == nil {
+				t.Fatal("GetJSON() error = nil, want an error")
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("GetJSON() error = %v, want it to wrap %v", err, tt.wantIs)
+			}
+			if tt.wantIs == nil && errors.Is(err, ErrUnauthorized) {
+				t.Errorf("GetJSON() error = %v, should not wrap ErrUnauthorized", err)
+			}
+
+			var statusErr *StatusError
+			if tt.wantStatus != 0 {
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Errorf("GetJSON() error = %v, want a StatusError with status %d", err, tt.wantStatus)
+				}
+			} else if errors.As(err, &statusErr) {
+				t.Errorf("GetJSON() error = %v, want no StatusError", err)
+			}
+			if tt.wantContains != "" && !strings.Contains(err.Error(), tt.wantContains) {
+				t.Errorf("GetJSON() error = %q, should contain %q", err, tt.wantContains)
+			}
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	original := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = original }()
+
+	tests := []struct {
+		name      string
+		failures  int
+		status    int
+		retries   int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "no retries by default", failures: 1, status: http.StatusServiceUnavailable, wantCalls: 1, wantErr: true},
+		{name: "recovers within retries", failures: 2, status: http.StatusServiceUnavailable, retries: 2, wantCalls: 3},
+		{name: "retries rate limiting", failures: 1, status: http.StatusTooManyRequests, retries: 1, wantCalls: 2},
+		{name: "gives up after retries", failures: 5, status: http.StatusBadGateway, retries: 2, wantCalls: 3, wantErr: true},
+		{name: "client errors are not retried", failures: 1, status: http.StatusBadRequest, retries: 3, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			client, auth := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= int32(tt.failures) {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(`{}`))
+			})
+
+			err := client.GetJSON(context.Background(), "/projects", nil, Retries(tt.retries))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", got, tt.wantCalls)
+			}
+			// Every attempt is authenticated afresh
+			if len(auth.projects) != int(tt.wantCalls) {
+				t.Errorf("authenticated requests = %d, want %d", len(auth.projects), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	start := time.Now()
+	err := client.GetJSON(context.Background(), "/projects", nil, Timeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetJSON() error = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetJSON() took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestClientUpstreamTimeout(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	client.config.UpstreamTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	err := client.GetJSON(context.Background(), "/projects", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetJSON() error = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetJSON() took %v, want it bounded by UPSTREAM_TIMEOUT", elapsed)
+	}
+}
+
+func TestClientCanceledContextAbortsCall(t *testing.T) {
+	dropped := make(chan struct{})
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(dropped)
+		case <-time.After(time.Second):
+		}
+	})
+	client.config.UpstreamTimeout = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.GetJSON(ctx, "/projects", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetJSON() error = %v, want the caller's deadline error", err)
+	}
+	select {
+	case <-dropped:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("upstream call kept running after the caller's context ended")
+	}
+}
+
+func TestClientSoftTimeout(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	defer metrics.SetDefault(original)
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/applications" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.Write([]byte(`{}`))
+	})
+	client.config.UpstreamSoftTimeout = 20 * time.Millisecond
+
+	if err := client.GetJSON(context.Background(), "/projects", nil); err != nil {
+		t.Fatalf("GetJSON(/projects) error = %v", err)
+	}
+	if err := client.GetJSON(context.Background(), "/applications", nil); err != nil {
+		t.Fatalf("GetJSON(/applications) error = %v, want the slow call to complete", err)
+	}
+
+	for _, tt := range []struct {
+		endpoint string
+		want     float64
+	}{
+		{"/projects", 0},
+		{"/applications", 1},
+	} {
+		if got := testutil.ToFloat64(metrics.ArgocdAPISlowRequestsTotal.WithLabelValues(tt.endpoint)); got != tt.want {
+			t.Errorf("argocd_api_slow_requests_total{endpoint=%q} = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestClientDoReturnsResponseUnread(t *testing.T) {
+	var gotMethod, gotBody string
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotBody = r.Method, string(body)
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("conflict"))
+	})
+
+	resp, err := client.Do(context.Background(), http.MethodPost, "/applications", []byte(`{"a":1}`), Timeout(time.Second))
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusConflict || string(body) != "conflict" {
+		t.Errorf("Do() = %d %q, want 409 \"conflict\"", resp.StatusCode, body)
+	}
+	if gotMethod != http.MethodPost || gotBody != `{"a":1}` {
+		t.Errorf("upstream saw %s %q, want POST {\"a\":1}", gotMethod, gotBody)
+	}
+}
+
+// endlessBody serves status followed by prefix and then an endless stream of JSON array
+// elements, until the client goes away
+func endlessBody(status int, prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(prefix))
+		chunk := []byte(strings.Repeat(`"padding",`, 100))
+		for r.Context().Err() == nil {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestClientBoundsResponseBodies(t *testing.T) {
+	const limit = 4096
+	newClient := func(handler http.HandlerFunc) *Client {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		cfg := &config.Config{ArgocdAPIURL: server.URL + "/api/v1", UpstreamMaxBodyBytes: limit}
+		return New(cfg, &fakeAuthenticator{}, server.Client(), nil)
+	}
+
+	t.Run("decoded body", func(t *testing.T) {
+		client := newClient(endlessBody(http.StatusOK, `{"items":[`))
+		var out struct{ Items []string }
+		err := client.GetJSON(context.Background(), "/applications", &out)
+		if !errors.Is(err, ErrUpstreamTooLarge) {
+			t.Fatalf("GetJSON() error = %v, want ErrUpstreamTooLarge", err)
+		}
+		if !strings.Contains(err.Error(), "/applications exceeds 4096 bytes") {
+			t.Errorf("GetJSON() error = %q, want the endpoint and limit", err)
+		}
+	})
+
+	t.Run("error body", func(t *testing.T) {
+		client := newClient(endlessBody(http.StatusInternalServerError, ""))
+		err := client.GetJSON(context.Background(), "/applications", nil)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("GetJSON() error = %v, want a 500 StatusError", err)
+		}
+		if len(statusErr.Body) != redact.DefaultMaxBodyLength+len("...(truncated)") {
+			t.Errorf("StatusError body is %d bytes, want %d and the truncation marker", len(statusErr.Body), redact.DefaultMaxBodyLength)
+		}
+	})
+
+	t.Run("error body above the default read size", func(t *testing.T) {
+		previous := redact.Default()
+		redact.SetDefault(redact.New(2 << 10))
+		defer redact.SetDefault(previous)
+
+		client := newClient(endlessBody(http.StatusInternalServerError, ""))
+		err := client.GetJSON(context.Background(), "/applications", nil)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("GetJSON() error = %v, want a StatusError", err)
+		}
+		if want := 2<<10 + len("...(truncated)"); len(statusErr.Body) != want {
+			t.Errorf("StatusError body is %d bytes, want %d", len(statusErr.Body), want)
+		}
+	})
+
+	t.Run("unread body", func(t *testing.T) {
+		client := newClient(endlessBody(http.StatusOK, "["))
+		resp, err := client.Do(context.Background(), http.MethodGet, "/applications", nil)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if !errors.Is(err, ErrUpstreamTooLarge) {
+			t.Errorf("reading the body error = %v, want ErrUpstreamTooLarge", err)
+		}
+		if len(body) > limit {
+			t.Errorf("read %d bytes, want at most %d", len(body), limit)
+		}
+	})
+
+	t.Run("body within the limit", func(t *testing.T) {
+		client := newClient(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"items":["` + strings.Repeat("a", limit-20) + `"]}`))
+		})
+		var out struct{ Items []string }
+		if err := client.GetJSON(context.Background(), "/applications", &out); err != nil {
+			t.Errorf("GetJSON() error = %v, want nil", err)
+		}
+	})
+}
+
+func TestClientRecordsMetrics(t *testing.T) {
+	original := metrics.Default()
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+	defer metrics.SetDefault(original)
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/v1/applications/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	client.GetJSON(context.Background(), "/projects", nil)
+	client.GetJSON(context.Background(), "/applications/guestbook", nil, Endpoint("/applications/:name"))
+
+	for _, tt := range []struct{ endpoint, status string }{
+		{"/projects", "200"},
+		{"/applications/:name", "404"},
+	} {
+		got := testutil.ToFloat64(metrics.ArgocdAPIRequestsTotal.WithLabelValues(tt.endpoint, tt.status))
+		if got != 1 {
+			t.Errorf("argocd_api_requests_total{endpoint=%q,status=%q} = %v, want 1", tt.endpoint, tt.status, got)
+		}
+	}
+	if got := testutil.CollectAndCount(metrics.ArgocdAPIRequestDuration); got != 2 {
+		t.Errorf("argocd_api_request_duration series = %d, want 2", got)
+	}
+}
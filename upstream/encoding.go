@@ -0,0 +1,121 @@
+package upstream
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding is the Accept-Encoding sent to ArgoCD. Responses encoded with deflate or
+// br are decoded as well, for ingresses that compress regardless of what was asked for.
+const acceptEncoding = "gzip"
+
+// ErrUnsupportedEncoding is wrapped when ArgoCD answers with a content encoding the
+// client cannot decode
+var ErrUnsupportedEncoding = errors.New("ArgoCD response has an unsupported content encoding")
+
+// EncodingError is returned when ArgoCD answers with a content encoding the client cannot
+// decode. It names the encoding, which usually comes from an ingress in front of ArgoCD.
+type EncodingError struct {
+	// Encoding is the Content-Encoding header of the response
+	Encoding string
+}
+
+func (e *EncodingError) Error() string {
+	return fmt.Sprintf("%v: Content-Encoding %q; supported encodings are gzip, deflate and br", ErrUnsupportedEncoding, e.Encoding)
+}
+
+// Unwrap returns ErrUnsupportedEncoding
+func (e *EncodingError) Unwrap() error {
+	return ErrUnsupportedEncoding
+}
+
+// decoders opens a reader decoding one content encoding
+var decoders = map[string]func(io.Reader) (io.Reader, error){
+	"gzip":   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"x-gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"deflate": func(r io.Reader) (io.Reader, error) {
+		return zlib.NewReader(r)
+	},
+	"br": func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+}
+
+// decodingTransport negotiates the content encoding of ArgoCD responses itself rather
+// than relying on http.Transport, which only decodes gzip and only when it added the
+// Accept-Encoding header. Responses are decoded before the client reads them, so size
+// limits apply to the decoded body.
+type decodingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip asks for gzip unless the request names its own encodings and decodes the
+// response body, failing with an EncodingError for encodings it cannot decode
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Get("Content-Encoding")
+	if header == "" {
+		return resp, nil
+	}
+	// Encodings are listed in the order they were applied, so they are undone in reverse
+	var encodings []string
+	for _, encoding := range strings.Split(header, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		if _, ok := decoders[encoding]; !ok {
+			resp.Body.Close()
+			return nil, &EncodingError{Encoding: header}
+		}
+		encodings = append(encodings, encoding)
+	}
+
+	resp.Body = &decodedBody{raw: resp.Body, encodings: encodings}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// decodedBody decodes a response body on first read, so that empty bodies, such as
+// those of HEAD requests, are not decoded at all
+type decodedBody struct {
+	raw       io.ReadCloser
+	encodings []string
+	reader    io.Reader
+	err       error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader = b.raw
+		for i := len(b.encodings) - 1; i >= 0 && b.err == nil; i-- {
+			if b.reader, b.err = decoders[b.encodings[i]](b.reader); b.err != nil {
+				b.err = fmt.Errorf("failed to decode %s response body: %w", b.encodings[i], b.err)
+			}
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	return b.raw.Close()
+}
@@ -0,0 +1,164 @@
+package upstream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"argocd-proxy/config"
+)
+
+// applicationsFixture is the application list the encoding tests serve
+const applicationsFixture = `{"items":[{"metadata":{"name":"guestbook"}},{"metadata":{"name":"billing"}}]}`
+
+// encode compresses body with each encoding in turn
+func encode(t *testing.T, body string, encodings ...string) []byte {
+	t.Helper()
+	data := []byte(body)
+	for _, encoding := range encodings {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "br":
+			w = brotli.NewWriter(&buf)
+		default:
+			t.Fatalf("unknown encoding %q", encoding)
+		}
+		w.Write(data)
+		w.Close()
+		data = buf.Bytes()
+	}
+	return data
+}
+
+// newEncodingClient returns a client on NewClient's HTTP client for handler
+func newEncodingClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	cfg := &config.Config{ArgocdAPIURL: server.URL + "/api/v1"}
+	return New(cfg, &fakeAuthenticator{}, NewClient(cfg), nil)
+}
+
+func TestClientDecodesContentEncodings(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		encodings []string
+	}{
+		{name: "identity"},
+		{name: "gzip", header: "gzip", encodings: []string{"gzip"}},
+		{name: "brotli", header: "br", encodings: []string{"br"}},
+		{name: "deflate", header: "deflate", encodings: []string{"deflate"}},
+		{name: "stacked", header: "gzip, br", encodings: []string{"gzip", "br"}},
+		{name: "case and identity", header: "identity, GZIP", encodings: []string{"gzip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAcceptEncoding string
+			client := newEncodingClient(t, func(w http.ResponseWriter, r *http.Request) {
+				gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+				if tt.header != "" {
+					w.Header().Set("Content-Encoding", tt.header)
+				}
+				w.Write(encode(t, applicationsFixture, tt.encodings...))
+			})
+
+			var out struct {
+				Items []struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := client.GetJSON(context.Background(), "/applications", &out); err != nil {
+				t.Fatalf("GetJSON() error = %v", err)
+			}
+			if len(out.Items) != 2 || out.Items[0].Metadata.Name != "guestbook" {
+				t.Errorf("decoded items = %+v, want guestbook and billing", out.Items)
+			}
+			if gotAcceptEncoding != "gzip" {
+				t.Errorf("Accept-Encoding = %q, want gzip", gotAcceptEncoding)
+			}
+		})
+	}
+}
+
+func TestClientStreamDecodesBrotli(t *testing.T) {
+	client := newEncodingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(encode(t, "line 1\nline 2\n", "br"))
+	})
+
+	body, err := client.Stream(context.Background(), "/applications/guestbook/logs")
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil || string(got) != "line 1\nline 2\n" {
+		t.Errorf("Stream() body = %q, %v, want the decoded lines", got, err)
+	}
+}
+
+func TestClientDecodedSizeLimit(t *testing.T) {
+	// The limit applies to the decoded body, so a small compressed body cannot expand
+	// past it
+	client := newEncodingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encode(t, `{"items":["`+strings.Repeat("a", 4096)+`"]}`, "gzip"))
+	})
+
+	var out map[string]any
+	err := client.GetJSON(context.Background(), "/applications", &out, MaxBodySize(1024))
+	if !errors.Is(err, ErrUpstreamTooLarge) {
+		t.Errorf("GetJSON() error = %v, want ErrUpstreamTooLarge", err)
+	}
+}
+
+func TestClientUnsupportedEncoding(t *testing.T) {
+	var calls atomic.Int32
+	client := newEncodingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Encoding", "zstd")
+		w.Write([]byte{0x28, 0xb5, 0x2f, 0xfd})
+	})
+
+	err := client.GetJSON(context.Background(), "/applications", nil)
+	var encodingErr *EncodingError
+	if !errors.As(err, &encodingErr) || encodingErr.Encoding != "zstd" {
+		t.Fatalf("GetJSON() error = %v, want an EncodingError naming zstd", err)
+	}
+	if !errors.Is(err, ErrUnsupportedEncoding) || !strings.Contains(err.Error(), `Content-Encoding "zstd"`) {
+		t.Errorf("GetJSON() error = %q, want it to wrap ErrUnsupportedEncoding and name the encoding", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1: an unsupported encoding is not retried", got)
+	}
+}
+
+func TestClientCorruptEncodedBody(t *testing.T) {
+	client := newEncodingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte(applicationsFixture))
+	})
+
+	err := client.GetJSON(context.Background(), "/applications", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to decode gzip response body") {
+		t.Errorf("GetJSON() error = %v, want a gzip decoding failure", err)
+	}
+}
@@ -0,0 +1,124 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"argocd-proxy/clock"
+	"argocd-proxy/config"
+	"argocd-proxy/events"
+)
+
+// Endpoints are the ArgoCD API URLs of ARGOCD_API_URL in order of preference. Calls go
+// to the active URL and move on to the next one when it cannot be reached; HTTP errors
+// are answers and never move them on. While a fallback is active, the primary is tried
+// again once per probe interval and becomes active as soon as it answers.
+type Endpoints struct {
+	urls          []string
+	probeInterval time.Duration
+	clock         clock.Clock
+
+	mu     sync.Mutex
+	active int
+	// probedAt is when calls left the primary or last tried it again
+	probedAt time.Time
+}
+
+// NewEndpoints returns endpoints over urls, the primary first, re-probing the primary every
+// probeInterval while a fallback is active. A nil clock is the real clock.
+func NewEndpoints(urls []string, probeInterval time.Duration, clk clock.Clock) *Endpoints {
+	return &Endpoints{urls: urls, probeInterval: probeInterval, clock: clock.OrReal(clk)}
+}
+
+// NewEndpointsFor returns endpoints over the ARGOCD_API_URL URLs of cfg. The login and
+// the API calls should be given the same endpoints, so that they fail over together.
+func NewEndpointsFor(cfg *config.Config) *Endpoints {
+	return NewEndpoints(cfg.APIURLs(), cfg.ArgocdPrimaryProbeInterval, clock.Real)
+}
+
+// Active returns the URL calls currently go to
+func (e *Endpoints) Active() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.urls[e.active]
+}
+
+// Call calls call with the URL of each endpoint in turn, starting from the active one,
+// until one is reached, and makes that one active. The result of the last URL tried is
+// returned when none is reached. The attempts stop as soon as ctx ends.
+func (e *Endpoints) Call(ctx context.Context, call func(baseURL string) (*http.Response, error)) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for _, index := range e.order() {
+		if resp, err = call(e.urls[index]); !unreachable(ctx, err) {
+			if err == nil {
+				e.reached(index)
+			}
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// order returns the indexes of the URLs in the order a call tries them: from the active
+// one, or from the primary when it is due to be probed again
+func (e *Endpoints) order() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	start := e.active
+	if start != 0 && e.clock.Since(e.probedAt) >= e.probeInterval {
+		// Calls made meanwhile stay on the fallback rather than all waiting on the primary
+		start = 0
+		e.probedAt = e.clock.Now()
+	}
+	order := make([]int, len(e.urls))
+	for i := range order {
+		order[i] = (start + i) % len(e.urls)
+	}
+	return order
+}
+
+// reached makes the URL at index active after a call reached it
+func (e *Endpoints) reached(index int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if index == e.active {
+		return
+	}
+
+	from, to := e.urls[e.active], e.urls[index]
+	e.active = index
+	e.probedAt = e.clock.Now()
+	message := "ArgoCD calls failed over to a fallback URL"
+	if index == 0 {
+		message = "ArgoCD calls returned to the primary URL"
+	}
+	log.Printf("%s: %s -> %s", message, hostOf(from), hostOf(to))
+	events.Record(events.UpstreamFailedOver, message, map[string]string{"from": hostOf(from), "to": hostOf(to)})
+}
+
+// unreachable reports whether err means that a URL could not be reached, so that another
+// may be tried. Only errors of the HTTP client itself qualify, not those of the caller:
+// a refused redirect or an unsupported encoding would be repeated by any URL, and a
+// canceled call must not be sent again.
+func unreachable(ctx context.Context, err error) bool {
+	urlErr, ok := err.(*url.Error)
+	return ok && ctx.Err() == nil &&
+		!errors.Is(urlErr, ErrRedirectNotAllowed) && !errors.Is(urlErr, ErrUnsupportedEncoding)
+}
+
+// hostOf returns the host of rawURL, which is logged instead of the URL in case it embeds
+// credentials
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
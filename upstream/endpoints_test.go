@@ -0,0 +1,203 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+	"argocd-proxy/testutils"
+)
+
+// endpointsCaller answers calls with a 200 response, or a connection error for the URLs
+// marked down, and records the URLs called
+type endpointsCaller struct {
+	down   map[string]bool
+	called []string
+}
+
+func (c *endpointsCaller) call(baseURL string) (*http.Response, error) {
+	c.called = append(c.called, baseURL)
+	if c.down[baseURL] {
+		return nil, &url.Error{Op: "Get", URL: baseURL, Err: errors.New("connection refused")}
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestEndpointsFailoverAndRecovery(t *testing.T) {
+	const primary, secondary = "https://argocd.svc/api/v1", "https://argocd.example.com/api/v1"
+	clk := testutils.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	endpoints := NewEndpoints([]string{primary, secondary}, time.Minute, clk)
+	caller := &endpointsCaller{down: map[string]bool{primary: true}}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		primaryUp  bool
+		wantCalled []string
+		wantActive string
+	}{
+		{name: "primary down", wantCalled: []string{primary, secondary}, wantActive: secondary},
+		{name: "stays on the fallback", advance: 30 * time.Second, wantCalled: []string{secondary}, wantActive: secondary},
+		{name: "probes the primary", advance: 30 * time.Second, wantCalled: []string{primary, secondary}, wantActive: secondary},
+		{name: "waits again after a failed probe", advance: 59 * time.Second, primaryUp: true, wantCalled: []string{secondary}, wantActive: secondary},
+		{name: "recovers", advance: time.Second, primaryUp: true, wantCalled: []string{primary}, wantActive: primary},
+		{name: "stays on the primary", primaryUp: true, wantCalled: []string{primary}, wantActive: primary},
+	}
+
+	for _, step := range steps {
+		clk.Advance(step.advance)
+		caller.down[primary] = !step.primaryUp
+		caller.called = nil
+
+		resp, err := endpoints.Call(context.Background(), caller.call)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: Call() = %v, %v, want a response", step.name, resp, err)
+		}
+		if !reflect.DeepEqual(caller.called, step.wantCalled) {
+			t.Errorf("%s: called %v, want %v", step.name, caller.called, step.wantCalled)
+		}
+		if got := endpoints.Active(); got != step.wantActive {
+			t.Errorf("%s: Active() = %s, want %s", step.name, got, step.wantActive)
+		}
+	}
+}
+
+func TestEndpointsAllDown(t *testing.T) {
+	endpoints := NewEndpoints([]string{"https://a", "https://b", "https://c"}, time.Minute, nil)
+	caller := &endpointsCaller{down: map[string]bool{"https://a": true, "https://b": true, "https://c": true}}
+
+	_, err := endpoints.Call(context.Background(), caller.call)
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || urlErr.URL != "https://c" {
+		t.Errorf("Call() error = %v, want the error of the last URL", err)
+	}
+	if len(caller.called) != 3 || endpoints.Active() != "https://a" {
+		t.Errorf("called %v with %s active, want every URL tried and the primary kept", caller.called, endpoints.Active())
+	}
+}
+
+func TestEndpointsKeepOtherFailures(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{name: "caller error", ctx: context.Background(), err: errors.New("failed to create authenticated request")},
+		{name: "refused redirect", ctx: context.Background(), err: &url.Error{Op: "Get", URL: "https://a", Err: &RedirectError{From: "https://a", Location: "https://evil"}}},
+		{name: "unsupported encoding", ctx: context.Background(), err: &url.Error{Op: "Get", URL: "https://a", Err: ErrUnsupportedEncoding}},
+		{name: "canceled call", ctx: canceled, err: &url.Error{Op: "Get", URL: "https://a", Err: context.Canceled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := NewEndpoints([]string{"https://a", "https://b"}, time.Minute, nil)
+			calls := 0
+			_, err := endpoints.Call(tt.ctx, func(string) (*http.Response, error) {
+				calls++
+				return nil, tt.err
+			})
+			if err != tt.err || calls != 1 {
+				t.Errorf("Call() = %v after %d calls, want %v after 1", err, calls, tt.err)
+			}
+		})
+	}
+}
+
+// downTransport fails requests to host with a connection error while down is set
+type downTransport struct {
+	host string
+	down atomic.Bool
+}
+
+func (d *downTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if d.down.Load() && req.URL.Host == d.host {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientFailsOverToSecondaryURL(t *testing.T) {
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.Write([]byte(`{"name":"primary"}`))
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		if r.URL.Path != "/api/v1/applications" {
+			t.Errorf("secondary path = %s, want /api/v1/applications", r.URL.Path)
+		}
+		w.Write([]byte(`{"name":"secondary"}`))
+	}))
+	defer secondary.Close()
+
+	primaryURL, _ := url.Parse(primary.URL)
+	transport := &downTransport{host: primaryURL.Host}
+	transport.down.Store(true)
+	cfg := &config.Config{
+		ArgocdAPIURL:  primary.URL + "/api/v1",
+		ArgocdAPIURLs: []string{primary.URL + "/api/v1", secondary.URL + "/api/v1"},
+		// Every call on the fallback probes the primary again
+		ArgocdPrimaryProbeInterval: time.Nanosecond,
+	}
+	client := New(cfg, &fakeAuthenticator{}, &http.Client{Transport: transport}, nil)
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := client.GetJSON(context.Background(), "/applications", &out); err != nil || out.Name != "secondary" {
+		t.Fatalf("GetJSON() with the primary down = %q, %v, want the secondary's answer", out.Name, err)
+	}
+	if got := client.Endpoints().Active(); got != secondary.URL+"/api/v1" {
+		t.Errorf("Active() = %s, want the secondary", got)
+	}
+
+	// Once the primary is back, the next probe returns calls to it
+	transport.down.Store(false)
+	if err := client.GetJSON(context.Background(), "/applications", &out); err != nil || out.Name != "primary" {
+		t.Fatalf("GetJSON() with the primary back = %q, %v, want the primary's answer", out.Name, err)
+	}
+	if got := client.Endpoints().Active(); got != primary.URL+"/api/v1" {
+		t.Errorf("Active() = %s, want the primary", got)
+	}
+	if primaryCalls.Load() != 1 || secondaryCalls.Load() != 1 {
+		t.Errorf("calls = %d primary, %d secondary, want 1 each", primaryCalls.Load(), secondaryCalls.Load())
+	}
+}
+
+func TestClientDoesNotFailOverOnHTTPErrors(t *testing.T) {
+	var secondaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+	}))
+	defer secondary.Close()
+
+	cfg := &config.Config{
+		ArgocdAPIURL:  primary.URL + "/api/v1",
+		ArgocdAPIURLs: []string{primary.URL + "/api/v1", secondary.URL + "/api/v1"},
+	}
+	client := New(cfg, &fakeAuthenticator{}, primary.Client(), nil)
+
+	var statusErr *StatusError
+	if err := client.GetJSON(context.Background(), "/applications", nil); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GetJSON() error = %v, want the primary's 503", err)
+	}
+	if secondaryCalls.Load() != 0 {
+		t.Errorf("secondary calls = %d, want 0", secondaryCalls.Load())
+	}
+}
@@ -0,0 +1,67 @@
+package upstream
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"argocd-proxy/types"
+)
+
+// knownFields lists, per type, the JSON names of the fields ArgoCD sends that the proxy
+// deliberately does not model. The strict decode check leaves them out, so that it only
+// reports fields a new ArgoCD release adds or renames rather than the same long list on
+// every refresh. A field is only known in the type listing it: a "source" added to
+// ArgocdApplicationStatus is still reported.
+var knownFields = map[reflect.Type][]string{
+	reflect.TypeFor[types.ArgocdApplicationMetadata](): {
+		"resourceVersion", "generation", "finalizers", "managedFields", "ownerReferences",
+		"deletionGracePeriodSeconds",
+	},
+	reflect.TypeFor[types.ArgocdApplicationSpec]():   {"ignoreDifferences", "info", "sourceHydrator"},
+	reflect.TypeFor[types.ArgocdApplicationSource](): {"chart", "ref", "name", "directory", "plugin"},
+	reflect.TypeFor[types.ArgocdApplicationSourceHelm](): {
+		"parameters", "fileParameters", "values", "valuesObject", "releaseName", "passCredentials",
+		"ignoreMissingValueFiles", "skipCrds", "skipSchemaValidation", "skipTests", "version",
+		"kubeVersion", "apiVersions", "namespace",
+	},
+	reflect.TypeFor[types.ArgocdApplicationSourceKustomize](): {
+		"nameSuffix", "images", "commonLabels", "commonAnnotations", "commonAnnotationsEnvsubst",
+		"forceCommonLabels", "forceCommonAnnotations", "namespace", "version", "replicas",
+		"patches", "components", "labelWithoutSelector", "kubeVersion", "apiVersions",
+	},
+	reflect.TypeFor[types.ArgocdSyncPolicy](): {"managedNamespaceMetadata"},
+	reflect.TypeFor[types.ArgocdApplicationStatus](): {
+		"sourceType", "sourceTypes", "observedAt", "controllerNamespace", "resourceHealthSource",
+		"sourceHydrator",
+	},
+	reflect.TypeFor[types.ArgocdApplicationHealth]():     {"lastTransitionTime"},
+	reflect.TypeFor[types.ArgocdApplicationSync]():       {"revisions"},
+	reflect.TypeFor[types.ArgocdApplicationComparedTo](): {"sources", "ignoreDifferences"},
+	reflect.TypeFor[types.ArgocdRevisionHistory]():       {"source", "sources", "revisions", "initiatedBy"},
+	reflect.TypeFor[types.ArgocdOperationState]():        {"operation", "retryCount"},
+	reflect.TypeFor[types.ArgocdSyncOperationResult](): {
+		"resources", "source", "sources", "revisions", "managedNamespaceMetadata",
+	},
+	reflect.TypeFor[types.ArgocdProjectMetadata](): {"resourceVersion", "generation", "finalizers", "managedFields"},
+	reflect.TypeFor[types.ArgocdProjectSpec](): {
+		"clusterResourceBlacklist", "namespaceResourceWhitelist", "namespaceResourceBlacklist",
+		"orphanedResources", "syncWindows", "signatureKeys", "sourceNamespaces",
+		"destinationServiceAccounts", "permitOnlyProjectScopedClusters",
+	},
+	reflect.TypeFor[types.ArgocdProjectRole]():   {"jwtTokens"},
+	reflect.TypeFor[types.ArgocdProjectStatus](): {"jwtTokensByRole"},
+	reflect.TypeFor[types.ArgocdClusterList]():   {"metadata"},
+	reflect.TypeFor[types.ArgocdCluster](): {
+		"config", "connectionState", "serverVersion", "info", "namespaces", "clusterResources",
+		"project", "labels", "annotations", "shard", "refreshRequestedAt",
+	},
+}
+
+// knownField reports whether key names a field of t listed in knownFields. Like
+// encoding/json, keys match regardless of case.
+func knownField(t reflect.Type, key string) bool {
+	return slices.ContainsFunc(knownFields[t], func(name string) bool {
+		return strings.EqualFold(name, key)
+	})
+}
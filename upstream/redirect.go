@@ -0,0 +1,115 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"argocd-proxy/config"
+)
+
+// maxRedirects is the number of redirects followed per request, as by Go's default policy
+const maxRedirects = 10
+
+// ErrRedirectNotAllowed is wrapped when ArgoCD redirects a request to a host outside
+// ARGOCD_REDIRECT_HOSTS, or from HTTPS to plain HTTP
+var ErrRedirectNotAllowed = errors.New("ArgoCD redirected to a host not in ARGOCD_REDIRECT_HOSTS")
+
+// RedirectError is returned when ArgoCD redirects a request to another host that is not
+// allowed to receive the proxy's credentials, or from HTTPS to plain HTTP. It names the
+// target so that ARGOCD_API_URL can be pointed at it directly.
+type RedirectError struct {
+	// From is the URL that answered with the redirect
+	From string
+	// Location is the redirect target
+	Location string
+	// Insecure is set when the redirect leaves HTTPS for plain HTTP
+	Insecure bool
+}
+
+func (e *RedirectError) Error() string {
+	if e.Insecure {
+		return fmt.Sprintf("ArgoCD redirected from HTTPS to plain HTTP: %s redirects to %s; the credentials are not sent unencrypted",
+			e.From, e.Location)
+	}
+	return fmt.Sprintf("%v: %s redirects to %s; set ARGOCD_API_URL to the redirect target or add its host to ARGOCD_REDIRECT_HOSTS",
+		ErrRedirectNotAllowed, e.From, e.Location)
+}
+
+// Unwrap returns ErrRedirectNotAllowed
+func (e *RedirectError) Unwrap() error {
+	return ErrRedirectNotAllowed
+}
+
+// credentialHeaders are the headers Go's client drops when a redirect leaves the
+// original host, and that carry the ArgoCD credentials
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// redirectPolicy follows ArgoCD redirects. Redirects within the original host keep the
+// credentials as usual; redirects to a host in ARGOCD_REDIRECT_HOSTS get them re-attached,
+// and redirects to any other host fail with a RedirectError instead of reaching it
+// without credentials and failing with a puzzling 401, or receiving the login password.
+// Redirects from HTTPS to plain HTTP fail as well, whatever the host, so the credentials
+// are never sent unencrypted. Requests to other services than ArgoCD, such as the alert
+// webhook, are redirected as usual.
+type redirectPolicy struct {
+	config *config.Config
+	hosts  []string
+	// logged makes only the first redirect a warning, since it usually repeats on every call
+	logged sync.Once
+}
+
+// newRedirectPolicy returns the redirect policy of ARGOCD_REDIRECT_HOSTS
+func newRedirectPolicy(cfg *config.Config) *redirectPolicy {
+	hosts := make([]string, len(cfg.ArgocdRedirectHosts))
+	for i, host := range cfg.ArgocdRedirectHosts {
+		hosts[i] = strings.ToLower(host)
+	}
+	return &redirectPolicy{config: cfg, hosts: hosts}
+}
+
+// callsArgocd reports whether req was sent to the host of one of the ARGOCD_API_URL URLs
+func (p *redirectPolicy) callsArgocd(req *http.Request) bool {
+	for _, apiURL := range p.config.APIURLs() {
+		if argocd, err := url.Parse(apiURL); err == nil && strings.EqualFold(req.URL.Host, argocd.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRedirect implements http.Client.CheckRedirect
+func (p *redirectPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	original, previous := via[0], via[len(via)-1]
+	if !p.callsArgocd(original) {
+		return nil
+	}
+	p.logged.Do(func() {
+		log.Printf("WARNING: ArgoCD redirected %s %s to %s; point ARGOCD_API_URL at the redirect target to save a round trip",
+			previous.Method, previous.URL.Redacted(), req.URL.Redacted())
+	})
+
+	if original.URL.Scheme == "https" && req.URL.Scheme == "http" {
+		return &RedirectError{From: previous.URL.Redacted(), Location: req.URL.Redacted(), Insecure: true}
+	}
+	if strings.EqualFold(req.URL.Host, original.URL.Host) {
+		return nil
+	}
+	if !slices.Contains(p.hosts, strings.ToLower(req.URL.Hostname())) {
+		return &RedirectError{From: previous.URL.Redacted(), Location: req.URL.Redacted()}
+	}
+	for _, header := range credentialHeaders {
+		if values := original.Header.Values(header); len(values) > 0 && req.Header.Get(header) == "" {
+			req.Header[header] = values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package upstream
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"argocd-proxy/config"
+)
+
+// redirectTarget is a test server that records the Authorization header of each call
+type redirectTarget struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	auths []string
+}
+
+func newRedirectTarget() *redirectTarget {
+	target := &redirectTarget{}
+	target.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target.mu.Lock()
+		defer target.mu.Unlock()
+		target.auths = append(target.auths, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	return target
+}
+
+// localURL is the target's URL with the host name localhost, which Go's client treats as
+// another host than the 127.0.0.1 of the redirecting server
+func (t *redirectTarget) localURL() string {
+	return strings.Replace(t.URL, "127.0.0.1", "localhost", 1)
+}
+
+func (t *redirectTarget) seen() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.auths...)
+}
+
+// newRedirectingArgocd starts an ArgoCD stand-in that redirects every call to location
+func newRedirectingArgocd(location string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+}
+
+func getWithToken(t *testing.T, client *http.Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer test-token")
+	return client.Do(req)
+}
+
+func TestRedirectToAllowedHostKeepsCredentials(t *testing.T) {
+	target := newRedirectTarget()
+	defer target.Close()
+	argocd := newRedirectingArgocd(target.localURL())
+	defer argocd.Close()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	client := NewClient(&config.Config{ArgocdAPIURL: argocd.URL + "/api/v1", ArgocdRedirectHosts: []string{"LocalHost"}})
+	for range 2 {
+		resp, err := getWithToken(t, client, argocd.URL+"/api/v1/applications")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+
+	if got := target.seen(); len(got) != 2 || got[0] != "Bearer test-token" || got[1] != "Bearer test-token" {
+		t.Errorf("target saw Authorization %q, want the token on both calls", got)
+	}
+	if got := strings.Count(logs.String(), "WARNING: ArgoCD redirected"); got != 1 {
+		t.Errorf("logged %d redirect warnings, want 1:\n%s", got, logs.String())
+	}
+	if !strings.Contains(logs.String(), target.localURL()+"/api/v1/applications") {
+		t.Errorf("warning does not name the redirect target:\n%s", logs.String())
+	}
+}
+
+func TestRedirectToOtherHostFails(t *testing.T) {
+	target := newRedirectTarget()
+	defer target.Close()
+	argocd := newRedirectingArgocd(target.localURL())
+	defer argocd.Close()
+
+	client := NewClient(&config.Config{ArgocdAPIURL: argocd.URL + "/api/v1", ArgocdRedirectHosts: []string{"argocd.example.com"}})
+	_, err := getWithToken(t, client, argocd.URL+"/api/v1/applications")
+
+	var redirectErr *RedirectError
+	if !errors.As(err, &redirectErr) {
+		t.Fatalf("error = %v, want a *RedirectError", err)
+	}
+	if redirectErr.Location != target.localURL()+"/api/v1/applications" {
+		t.Errorf("Location = %q, want %q", redirectErr.Location, target.localURL()+"/api/v1/applications")
+	}
+	if !errors.Is(err, ErrRedirectNotAllowed) || !strings.Contains(err.Error(), target.localURL()) {
+		t.Errorf("error = %v, want ErrRedirectNotAllowed naming %s", err, target.localURL())
+	}
+	if got := target.seen(); len(got) != 0 {
+		t.Errorf("target was called %d times, want 0", len(got))
+	}
+}
+
+func TestRedirectWithinArgocdHost(t *testing.T) {
+	var auth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old/applications", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api/v1/applications", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/api/v1/applications", func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"items":[]}`))
+	})
+	argocd := httptest.NewServer(mux)
+	defer argocd.Close()
+
+	client := NewClient(&config.Config{ArgocdAPIURL: argocd.URL + "/old"})
+	resp, err := getWithToken(t, client, argocd.URL+"/old/applications")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if auth != "Bearer test-token" {
+		t.Errorf("Authorization after redirect = %q, want the token", auth)
+	}
+}
+
+func TestRedirectOfOtherServicesIsFollowed(t *testing.T) {
+	target := newRedirectTarget()
+	defer target.Close()
+	webhook := newRedirectingArgocd(target.localURL())
+	defer webhook.Close()
+
+	client := NewClient(&config.Config{ArgocdAPIURL: "https://argocd.example.com/api/v1"})
+	resp, err := getWithToken(t, client, webhook.URL+"/hook")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	// Go's default policy applies: the redirect is followed without the credentials
+	if got := target.seen(); len(got) != 1 || got[0] != "" {
+		t.Errorf("target saw Authorization %q, want one call without it", got)
+	}
+}
+
+func TestRedirectFromHTTPSToHTTPFails(t *testing.T) {
+	policy := newRedirectPolicy(&config.Config{
+		ArgocdAPIURL:        "https://argocd.example.com/api/v1",
+		ArgocdRedirectHosts: []string{"argocd-sso.example.com"},
+	})
+
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{"same host", "http://argocd.example.com/api/v1/applications"},
+		{"allowed host", "http://argocd-sso.example.com/api/v1/applications"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := httptest.NewRequest(http.MethodGet, "https://argocd.example.com/api/v1/applications", nil)
+			original.Header.Set("Authorization", "Bearer test-token")
+			req := httptest.NewRequest(http.MethodGet, tt.location, nil)
+
+			err := policy.checkRedirect(req, []*http.Request{original})
+			var redirectErr *RedirectError
+			if !errors.As(err, &redirectErr) || !redirectErr.Insecure {
+				t.Fatalf("checkRedirect() error = %v, want an insecure *RedirectError", err)
+			}
+			if !errors.Is(err, ErrRedirectNotAllowed) {
+				t.Errorf("checkRedirect() error = %v, want ErrRedirectNotAllowed", err)
+			}
+			if got := req.Header.Get("Authorization"); got != "" {
+				t.Errorf("redirected request carries Authorization %q, want none", got)
+			}
+		})
+	}
+}
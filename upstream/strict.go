@@ -0,0 +1,193 @@
+package upstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+)
+
+// ErrUnknownFields is wrapped when UPSTREAM_STRICT_DECODE is error and a checked response
+// has fields the proxy's types do not declare
+var ErrUnknownFields = errors.New("ArgoCD response has fields the proxy does not declare")
+
+// unknownFieldsSampleSize bounds the field paths named per warning or error
+const unknownFieldsSampleSize = 10
+
+// StrictDecode checks the response for fields its type does not declare, as set by
+// UPSTREAM_STRICT_DECODE. The response is still decoded leniently. The check reads the
+// whole body and decodes it twice, so it is meant for the list downloads that refresh
+// the caches rather than for per-request calls.
+func StrictDecode() Option {
+	return func(o *callOptions) { o.strict = true }
+}
+
+// decodeChecked decodes body into out leniently, then decodes it again into a fresh value
+// of the same type with unknown fields disallowed. Fields that only the second pass
+// rejects are counted and logged, and fail the call in error mode.
+func (c *Client) decodeChecked(body io.Reader, out any, endpoint string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(out).Elem()
+	shadow := json.NewDecoder(bytes.NewReader(data))
+	shadow.DisallowUnknownFields()
+	if shadow.Decode(reflect.New(t).Interface()) == nil {
+		return nil
+	}
+
+	// The decoder stops at the first unknown field and does not say where it is, so the
+	// document is walked against the type to name all of them
+	fields := UnknownFields(data, t)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, field := range fields {
+		metrics.ArgocdAPIUnknownFieldsTotal.WithLabelValues(endpoint, field).Inc()
+	}
+	c.warnUnknownFields(endpoint, fields)
+	if c.config.UpstreamStrictDecode == config.StrictDecodeError {
+		return fmt.Errorf("%w: %s", ErrUnknownFields, sampleFields(fields))
+	}
+	return nil
+}
+
+// warnUnknownFields logs the fields of endpoint not logged before, so that a refresh
+// repeating every CACHE_TTL does not repeat the warning
+func (c *Client) warnUnknownFields(endpoint string, fields []string) {
+	var fresh []string
+	for _, field := range fields {
+		if _, seen := c.unknownFields.LoadOrStore(endpoint+" "+field, struct{}{}); !seen {
+			fresh = append(fresh, field)
+		}
+	}
+	if len(fresh) > 0 {
+		log.Printf("WARNING: ArgoCD %s response has fields the proxy does not declare: %s", endpoint, sampleFields(fresh))
+	}
+}
+
+// sampleFields joins the first unknownFieldsSampleSize field paths, noting how many more
+// there are
+func sampleFields(fields []string) string {
+	if len(fields) <= unknownFieldsSampleSize {
+		return strings.Join(fields, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(fields[:unknownFieldsSampleSize], ", "), len(fields)-unknownFieldsSampleSize)
+}
+
+// UnknownFields returns the sorted paths of the fields of the JSON document data that
+// decoding into a value of type t ignores, such as "items[].status.newField". Elements of
+// arrays are written "[]" and values of maps "*". Values of types with their own
+// UnmarshalJSON are not looked into, and the fields listed in knownFields are not
+// reported. Invalid JSON has no unknown fields.
+func UnknownFields(data []byte, t reflect.Type) []string {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil
+	}
+	found := map[string]bool{}
+	collectUnknownFields(document, t, "", found)
+
+	fields := make([]string, 0, len(found))
+	for field := range found {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// unmarshalerType is the type of json.Unmarshaler
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// collectUnknownFields adds the paths of the fields of value under path that t does not
+// declare to found
+func collectUnknownFields(value any, t reflect.Type, path string, found map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for key, child := range value {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				// Like encoding/json, keys match field names regardless of case
+				fieldType, ok := fields[strings.ToLower(key)]
+				if !ok {
+					if !knownField(t, key) {
+						found[childPath] = true
+					}
+					continue
+				}
+				collectUnknownFields(child, fieldType, childPath, found)
+			}
+		case reflect.Map:
+			for _, child := range value {
+				collectUnknownFields(child, t.Elem(), path+".*", found)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, child := range value {
+				collectUnknownFields(child, t.Elem(), path+"[]", found)
+			}
+		}
+	}
+}
+
+// jsonFields returns the types of the fields encoding/json decodes into a struct type,
+// keyed by their lowercased JSON names, including those promoted from embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, fieldType := range jsonFields(embedded) {
+					if _, shadowed := fields[key]; !shadowed {
+						fields[key] = fieldType
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,190 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"argocd-proxy/config"
+	"argocd-proxy/metrics"
+	"argocd-proxy/types"
+)
+
+// strictTestTime decodes from any JSON value, so its contents are never checked
+type strictTestTime struct{}
+
+func (*strictTestTime) UnmarshalJSON([]byte) error { return nil }
+
+type strictTestMeta struct {
+	Name string `json:"name"`
+}
+
+type strictTestItem struct {
+	strictTestMeta
+	Labels  map[string]string          `json:"labels"`
+	Ports   map[string]strictTestMeta  `json:"ports"`
+	Raw     json.RawMessage            `json:"raw"`
+	Any     any                        `json:"any"`
+	Time    *strictTestTime            `json:"time"`
+	Skipped string                     `json:"-"`
+	Nested  *struct{ Kind string }     `json:"nested"`
+	Matrix  [][]strictTestMeta         `json:"matrix"`
+	Extra   map[string]json.RawMessage `json:"extra"`
+}
+
+func TestUnknownFields(t *testing.T) {
+	payload := `{"items":[{
+		"name":"a","NAME":"case-insensitive","labels":{"any":"key"},
+		"ports":{"http":{"name":"web","port":80}},
+		"raw":{"free":"form"},"any":{"free":"form"},"time":{"free":"form"},
+		"Skipped":"unknown since ignored by its tag",
+		"nested":{"kind":"k","version":1},
+		"matrix":[[{"name":"x","unknown":true}]],
+		"extra":{"k":{"free":"form"}},
+		"added":{"deep":{"deeper":1}}
+	}],"metadata":{}}`
+
+	got := UnknownFields([]byte(payload), reflect.TypeFor[struct {
+		Items []strictTestItem `json:"items"`
+	}]())
+	want := []string{"items[].Skipped", "items[].added", "items[].matrix[][].unknown", "items[].nested.version", "items[].ports.*.port", "metadata"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownFields() = %v, want %v", got, want)
+	}
+
+	if got := UnknownFields([]byte(`not json`), reflect.TypeFor[strictTestItem]()); len(got) != 0 {
+		t.Errorf("UnknownFields() of invalid JSON = %v, want none", got)
+	}
+}
+
+func TestUnknownFieldsOfArgocdApplications(t *testing.T) {
+	payload := `{"metadata":{"resourceVersion":"1"},"items":[{"metadata":{"name":"web"},"spec":{"project":"default"},
+		"status":{"health":{"status":"Healthy"},"sync":{"status":"Synced"},"sourceHydrator":{"currentOperation":{}},
+		"promotion":{"stage":"canary"},"history":[{"id":1,"deployedAt":"2024-06-03T09:13:02Z","trigger":"webhook"}]}}]}`
+
+	// sourceHydrator is known; fields ArgoCD adds or renames are still reported
+	got := UnknownFields([]byte(payload), reflect.TypeFor[types.ArgocdApplicationList]())
+	if want := []string{"items[].status.history[].trigger", "items[].status.promotion"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownFields() = %v, want %v", got, want)
+	}
+}
+
+func TestUnknownFieldsOfArgocdResponses(t *testing.T) {
+	tests := []struct {
+		file string
+		t    reflect.Type
+	}{
+		{"applications.json", reflect.TypeFor[types.ArgocdApplicationList]()},
+		{"projects.json", reflect.TypeFor[types.ArgocdProjectList]()},
+		{"clusters.json", reflect.TypeFor[types.ArgocdClusterList]()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := UnknownFields(data, tt.t); len(got) != 0 {
+				t.Errorf("UnknownFields() of an ArgoCD %s = %v, want none", tt.file, got)
+			}
+		})
+	}
+}
+
+func TestGetJSONStrictDecode(t *testing.T) {
+	const payload = `{"name":"guestbook","renamedField":"x","spec":{"project":"default","newSetting":true}}`
+	type application struct {
+		Name string `json:"name"`
+		Spec struct {
+			Project string `json:"project"`
+		} `json:"spec"`
+	}
+
+	tests := []struct {
+		name       string
+		mode       string
+		strict     bool
+		wantErr    bool
+		wantCounts float64
+	}{
+		{name: "off", mode: config.StrictDecodeOff, strict: true},
+		{name: "warn", mode: config.StrictDecodeWarn, strict: true, wantCounts: 2},
+		{name: "error", mode: config.StrictDecodeError, strict: true, wantErr: true, wantCounts: 2},
+		{name: "call not checked", mode: config.StrictDecodeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := metrics.Default()
+			defer metrics.SetDefault(previous)
+			metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(payload))
+			})
+			client.config.UpstreamStrictDecode = tt.mode
+			var opts []Option
+			if tt.strict {
+				opts = append(opts, StrictDecode(), Endpoint("/applications/:name"))
+			}
+
+			// A refresh repeating the same fields counts them again but logs them once
+			for i := 0; i < 2; i++ {
+				var out application
+				err := client.GetJSON(context.Background(), "/applications/guestbook", &out, opts...)
+				if tt.wantErr {
+					if !errors.Is(err, ErrUnknownFields) || !strings.Contains(err.Error(), "renamedField, spec.newSetting") {
+						t.Fatalf("GetJSON() error = %v, want %v naming the fields", err, ErrUnknownFields)
+					}
+				} else if err != nil {
+					t.Fatalf("GetJSON() unexpected error: %v", err)
+				}
+				if out.Name != "guestbook" || out.Spec.Project != "default" {
+					t.Errorf("GetJSON() = %+v, want the payload decoded leniently", out)
+				}
+			}
+
+			for _, field := range []string{"renamedField", "spec.newSetting"} {
+				got := testutil.ToFloat64(metrics.ArgocdAPIUnknownFieldsTotal.WithLabelValues("/applications/:name", field))
+				if got != tt.wantCounts {
+					t.Errorf("unknown field %s count = %v, want %v", field, got, tt.wantCounts)
+				}
+			}
+			wantWarnings := 0
+			if tt.wantCounts > 0 {
+				wantWarnings = 1
+			}
+			if got := strings.Count(logs.String(), "fields the proxy does not declare: renamedField, spec.newSetting"); got != wantWarnings {
+				t.Errorf("warnings = %d, want %d, logs:\n%s", got, wantWarnings, logs.String())
+			}
+		})
+	}
+}
+
+func TestSampleFields(t *testing.T) {
+	fields := make([]string, unknownFieldsSampleSize+3)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("f%d", i)
+	}
+	if got := sampleFields(fields); !strings.HasSuffix(got, "f9 and 3 more") {
+		t.Errorf("sampleFields() = %q, want the first %d and a count of the rest", got, unknownFieldsSampleSize)
+	}
+	if got := sampleFields(fields[:2]); got != "f0, f1" {
+		t.Errorf("sampleFields() = %q, want all fields", got)
+	}
+}
@@ -0,0 +1,132 @@
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"argocd-proxy/config"
+)
+
+// Transport sharing: every client that talks to ArgoCD is built on the default transport,
+// so proxy settings and connection pooling are the same for authentication and API calls.
+var (
+	defaultMu        sync.RWMutex
+	defaultTransport = NewTransport(&config.Config{})
+)
+
+// NewTransport returns an HTTP transport that reaches ArgoCD through ARGOCD_OUTBOUND_PROXY
+// when it is configured, and otherwise through the proxy selected by the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY environment variables read when the transport is created.
+func NewTransport(cfg *config.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(cfg)
+	// Content encodings are negotiated and decoded by the clients' decodingTransport
+	transport.DisableCompression = true
+	return transport
+}
+
+// proxyFunc selects the proxy of each outbound request
+func proxyFunc(cfg *config.Config) func(*http.Request) (*url.URL, error) {
+	if proxyURL := outboundProxyURL(cfg); proxyURL != nil {
+		return http.ProxyURL(proxyURL)
+	}
+
+	fromEnv := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return fromEnv(req.URL)
+	}
+}
+
+// outboundProxyURL returns ARGOCD_OUTBOUND_PROXY with its configured credentials, or nil
+func outboundProxyURL(cfg *config.Config) *url.URL {
+	if cfg.OutboundProxy == "" {
+		return nil
+	}
+	// The URL was validated when the configuration was loaded
+	proxyURL, err := url.Parse(cfg.OutboundProxy)
+	if err != nil {
+		return nil
+	}
+	if cfg.OutboundProxyUsername != "" {
+		proxyURL.User = url.UserPassword(cfg.OutboundProxyUsername, cfg.OutboundProxyPassword)
+	}
+	return proxyURL
+}
+
+// DescribeProxy reports which proxy, if any, requests to ArgoCD will use, with
+// credentials masked, for the startup log. When the ARGOCD_API_URL URLs are reached in
+// different ways, each is described after its host.
+func DescribeProxy(cfg *config.Config) string {
+	if proxyURL := outboundProxyURL(cfg); proxyURL != nil {
+		return fmt.Sprintf("outbound proxy %s (ARGOCD_OUTBOUND_PROXY)", proxyURL.Redacted())
+	}
+
+	urls := cfg.APIURLs()
+	descriptions := make([]string, len(urls))
+	for i, apiURL := range urls {
+		descriptions[i] = describeEnvironmentProxy(apiURL)
+	}
+	if !slices.ContainsFunc(descriptions, func(d string) bool { return d != descriptions[0] }) {
+		return descriptions[0]
+	}
+	for i, apiURL := range urls {
+		descriptions[i] = hostOf(apiURL) + ": " + descriptions[i]
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// describeEnvironmentProxy reports which proxy of the proxy environment variables, if
+// any, requests to apiURL will use
+func describeEnvironmentProxy(apiURL string) string {
+	target, err := url.Parse(apiURL)
+	if err != nil {
+		return "direct connection"
+	}
+	proxyURL, err := httpproxy.FromEnvironment().ProxyFunc()(target)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("direct connection (invalid proxy environment: %v)", err)
+	case proxyURL == nil:
+		return "direct connection"
+	default:
+		return fmt.Sprintf("outbound proxy %s (proxy environment variables)", proxyURL.Redacted())
+	}
+}
+
+// Default returns the transport shared by the upstream clients
+func Default() *http.Transport {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTransport
+}
+
+// SetDefault makes transport the one shared by upstream clients created afterwards.
+// It must be called before the services are created.
+func SetDefault(transport *http.Transport) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTransport = transport
+}
+
+// NewClient returns an HTTP client on the shared transport, following redirects as
+// allowed by ARGOCD_REDIRECT_HOSTS and decoding gzip, deflate and br responses. It has no
+// overall timeout: requests are bounded by their context, so that a canceled caller
+// aborts the call.
+func NewClient(cfg *config.Config) *http.Client {
+	return &http.Client{Transport: &decodingTransport{base: Default()}, CheckRedirect: newRedirectPolicy(cfg).checkRedirect}
+}
+
+// BaseTransport returns the transport under the content decoding of a client built by
+// NewClient, and rt itself otherwise
+func BaseTransport(rt http.RoundTripper) http.RoundTripper {
+	if decoding, ok := rt.(*decodingTransport); ok {
+		return decoding.base
+	}
+	return rt
+}
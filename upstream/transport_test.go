@@ -0,0 +1,241 @@
+package upstream
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/config"
+)
+
+// forwardProxy is a test HTTP forward proxy that tunnels CONNECT requests, forwards
+// absolute-URI requests and records what it saw
+type forwardProxy struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	requests   []string
+	authHeader string
+}
+
+// newForwardProxy starts a forward proxy; a non-empty wantAuth makes it require that
+// Proxy-Authorization header
+func newForwardProxy(t *testing.T, wantAuth string) *forwardProxy {
+	p := &forwardProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests = append(p.requests, r.Method+" "+r.Host)
+		p.authHeader = r.Header.Get("Proxy-Authorization")
+		p.mu.Unlock()
+
+		if wantAuth != "" && r.Header.Get("Proxy-Authorization") != wantAuth {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		if r.Method == http.MethodConnect {
+			target, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			client, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				target.Close()
+				return
+			}
+			go func() {
+				defer target.Close()
+				defer client.Close()
+				go io.Copy(target, client)
+				io.Copy(client, target)
+			}()
+			return
+		}
+
+		// Plain HTTP requests arrive with an absolute URI
+		r.RequestURI = ""
+		r.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+// seen returns the requests the proxy received
+func (p *forwardProxy) seen() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.requests...)
+}
+
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func TestOutboundProxyTunnelsHTTPS(t *testing.T) {
+	argocd := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer argocd.Close()
+
+	tests := []struct {
+		name       string
+		config     func(proxyURL string) *config.Config
+		wantAuth   string
+		wantStatus int
+	}{
+		{
+			name: "anonymous proxy",
+			config: func(proxyURL string) *config.Config {
+				return &config.Config{OutboundProxy: proxyURL}
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "credentials from config",
+			config: func(proxyURL string) *config.Config {
+				return &config.Config{OutboundProxy: proxyURL, OutboundProxyUsername: "svc-argocd", OutboundProxyPassword: "s3cret"}
+			},
+			wantAuth:   basicAuth("svc-argocd", "s3cret"),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "credentials in the proxy URL",
+			config: func(proxyURL string) *config.Config {
+				return &config.Config{OutboundProxy: strings.Replace(proxyURL, "http://", "http://svc-argocd:s3cret@", 1)}
+			},
+			wantAuth:   basicAuth("svc-argocd", "s3cret"),
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newForwardProxy(t, tt.wantAuth)
+
+			transport := NewTransport(tt.config(proxy.URL))
+			transport.TLSClientConfig = argocd.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+			client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+
+			resp, err := client.Get(argocd.URL + "/api/v1/projects")
+			if err != nil {
+				t.Fatalf("request through proxy failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			target := strings.TrimPrefix(argocd.URL, "https://")
+			if seen := proxy.seen(); len(seen) != 1 || seen[0] != "CONNECT "+target {
+				t.Errorf("proxy saw %v, want a single CONNECT %s", seen, target)
+			}
+		})
+	}
+}
+
+func TestOutboundProxyForwardsHTTP(t *testing.T) {
+	argocd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer argocd.Close()
+	proxy := newForwardProxy(t, "")
+
+	SetDefault(NewTransport(&config.Config{OutboundProxy: proxy.URL}))
+	defer SetDefault(NewTransport(&config.Config{}))
+
+	resp, err := NewClient(&config.Config{}).Get(argocd.URL + "/api/v1/projects")
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if seen := proxy.seen(); len(seen) != 1 || seen[0] != "GET "+strings.TrimPrefix(argocd.URL, "http://") {
+		t.Errorf("proxy saw %v, want a single forwarded GET", seen)
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		noProxy   string
+		target    string
+		wantProxy string
+	}{
+		{"https target uses HTTPS_PROXY", "", "https://argocd.example.com/api/v1", "http://proxy.internal:3128"},
+		{"NO_PROXY bypasses the proxy", "example.com", "https://argocd.example.com/api/v1", ""},
+		{"http target uses HTTP_PROXY", "", "http://argocd.example.com/api/v1", "http://plain-proxy.internal:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTPS_PROXY", "http://proxy.internal:3128")
+			t.Setenv("HTTP_PROXY", "http://plain-proxy.internal:8080")
+			t.Setenv("NO_PROXY", tt.noProxy)
+
+			cfg := &config.Config{ArgocdAPIURL: tt.target}
+			target, _ := url.Parse(tt.target)
+			got, err := NewTransport(cfg).Proxy(&http.Request{URL: target})
+			if err != nil {
+				t.Fatalf("Proxy() error = %v", err)
+			}
+			if (got == nil && tt.wantProxy != "") || (got != nil && got.String() != tt.wantProxy) {
+				t.Errorf("Proxy() = %v, want %q", got, tt.wantProxy)
+			}
+
+			description := DescribeProxy(cfg)
+			if tt.wantProxy == "" && description != "direct connection" {
+				t.Errorf("DescribeProxy() = %q, want direct connection", description)
+			}
+			if tt.wantProxy != "" && !strings.Contains(description, tt.wantProxy) {
+				t.Errorf("DescribeProxy() = %q, want it to name %s", description, tt.wantProxy)
+			}
+		})
+	}
+}
+
+func TestDescribeProxyMasksCredentials(t *testing.T) {
+	cfg := &config.Config{
+		OutboundProxy:         "http://proxy.internal:3128",
+		OutboundProxyUsername: "svc-argocd",
+		OutboundProxyPassword: "s3cret",
+	}
+
+	got := DescribeProxy(cfg)
+	if strings.Contains(got, "s3cret") || !strings.Contains(got, "proxy.internal:3128") {
+		t.Errorf("DescribeProxy() = %q, want the proxy host without its password", got)
+	}
+}
+
+func TestDescribeProxyOfEveryURL(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.internal:3128")
+	t.Setenv("NO_PROXY", "argocd-ingress.example.com")
+
+	cfg := &config.Config{ArgocdAPIURLs: []string{"https://argocd.example.com/api/v1", "https://argocd-ingress.example.com/api/v1"}}
+	want := "argocd.example.com: outbound proxy http://proxy.internal:3128 (proxy environment variables); argocd-ingress.example.com: direct connection"
+	if got := DescribeProxy(cfg); got != want {
+		t.Errorf("DescribeProxy() = %q, want %q", got, want)
+	}
+
+	t.Setenv("NO_PROXY", "")
+	if got := DescribeProxy(cfg); got != "outbound proxy http://proxy.internal:3128 (proxy environment variables)" {
+		t.Errorf("DescribeProxy() with one proxy for both URLs = %q, want it described once", got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/params"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// upstreamErrorQueryParam asks for ArgoCD's status and body excerpt in error responses
+const upstreamErrorQueryParam = "upstreamError"
+
+// serviceErrorResponse sends the error response for a failed ArgoCD service call, with
+// the error code classifying err. When ArgoCD answered the call with an error status and
+// the client asked for it, the response includes that status and body excerpt.
+func (s *Server) serviceErrorResponse(c *gin.Context, statusCode int, message string, err error) {
+	var upstreamDetails *types.UpstreamErrorDetails
+	var statusErr *services.StatusError
+	if errors.As(err, &statusErr) && s.wantsUpstreamError(c) {
+		upstreamDetails = &types.UpstreamErrorDetails{Status: statusErr.StatusCode, Body: statusErr.Body}
+	}
+	s.writeErrorResponse(c, statusCode, serviceErrorCode(err), message, err.Error(), upstreamDetails)
+}
+
+// wantsUpstreamError reports whether error responses to the request include ArgoCD's
+// answer: ?upstreamError= when given, UPSTREAM_ERROR_PASSTHROUGH otherwise. The value was
+// validated by the query params middleware.
+func (s *Server) wantsUpstreamError(c *gin.Context) bool {
+	b := params.NewBinder(c.Request.URL.Query())
+	want := b.Bool(upstreamErrorQueryParam, s.config.UpstreamErrorPassthrough)
+	return b.Err() == nil && want
+}
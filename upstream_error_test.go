@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"argocd-proxy/auth"
+	"argocd-proxy/redact"
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// rbacDenial is the body ArgoCD answers a read denied by RBAC with, naming the proxy's
+// user and padded past the error body limit
+var rbacDenial = `{"error":"permission denied: applications, get, payments/web, sub: deploy-bot, iat: 2026-10-16T10:00:00Z","code":7,"message":"permission denied: applications, get, payments/web, sub: deploy-bot` + strings.Repeat(".", 200) + `"}`
+
+// setupUpstreamErrorServer returns a test server using the real ArgoCD service against a
+// fake ArgoCD that denies web and does not know gone, with error bodies truncated to 120
+// bytes and the proxy's username redacted
+func setupUpstreamErrorServer(t *testing.T) *Server {
+	t.Helper()
+	previous := redact.Default()
+	t.Cleanup(func() { redact.SetDefault(previous) })
+	redactor := redact.New(120)
+	redactor.SetSecret("username", "deploy-bot")
+	redact.SetDefault(redactor)
+
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/session":
+			json.NewEncoder(w).Encode(types.ArgocdSessionResponse{Token: "session-token"})
+		case "/applications/web":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(rbacDenial))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"applications.argoproj.io \"gone\" not found","code":5}`))
+		}
+	}))
+	t.Cleanup(fake.Close)
+
+	server := setupTestServer()
+	server.config.ArgocdAPIURL = fake.URL
+	server.config.ArgocdUsername = "deploy-bot"
+	server.config.ArgocdPassword = "secret"
+	server.authService = auth.NewAuthService(server.config, nil)
+	server.argocdService = services.NewArgocdService(server.config, server.authService, nil)
+	return server
+}
+
+// serveUpstreamError requests path and decodes the error response
+func serveUpstreamError(t *testing.T, server *Server, path string, headers map[string]string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	w := serveMethod(server, http.MethodGet, path, headers)
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GET %s: invalid JSON response: %v", path, err)
+	}
+	return w.Code, response
+}
+
+func TestUpstreamErrorPassThrough(t *testing.T) {
+	server := setupUpstreamErrorServer(t)
+
+	code, response := serveUpstreamError(t, server, "/applications/web", nil)
+	if code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", code, http.StatusBadGateway)
+	}
+	if _, ok := response["upstream"]; ok {
+		t.Error("upstream included without ?upstreamError=true")
+	}
+
+	code, response = serveUpstreamError(t, server, "/applications/web?upstreamError=true", nil)
+	if code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", code, http.StatusBadGateway)
+	}
+	var details types.UpstreamErrorDetails
+	if err := json.Unmarshal(response["upstream"], &details); err != nil {
+		t.Fatalf("upstream = %s, want the upstream error details: %v", response["upstream"], err)
+	}
+	if details.Status != http.StatusForbidden {
+		t.Errorf("upstream.status = %d, want %d", details.Status, http.StatusForbidden)
+	}
+	if !strings.HasPrefix(details.Body, `{"error":"permission denied: applications, get, payments/web, sub: `+redact.Mask) {
+		t.Errorf("upstream.body = %q, want ArgoCD's message with the username redacted", details.Body)
+	}
+	if strings.Contains(details.Body, "deploy-bot") {
+		t.Errorf("upstream.body leaked the username: %q", details.Body)
+	}
+	if !strings.HasSuffix(details.Body, "...(truncated)") || len(details.Body) > 120+len("...(truncated)") {
+		t.Errorf("upstream.body = %q (%d bytes), want it truncated to 120 bytes", details.Body, len(details.Body))
+	}
+}
+
+func TestUpstreamErrorPassThroughDefault(t *testing.T) {
+	server := setupUpstreamErrorServer(t)
+	server.config.UpstreamErrorPassthrough = true
+
+	// A mapped 404 keeps ArgoCD's answer
+	code, response := serveUpstreamError(t, server, "/applications/gone", nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", code, http.StatusNotFound)
+	}
+	var details types.UpstreamErrorDetails
+	if err := json.Unmarshal(response["upstream"], &details); err != nil || details.Status != http.StatusNotFound || !strings.Contains(details.Body, `"code":5`) {
+		t.Errorf("upstream = %s, want ArgoCD's 404 answer", response["upstream"])
+	}
+
+	// The query parameter overrides the default
+	_, response = serveUpstreamError(t, server, "/applications/gone?upstreamError=false", nil)
+	if _, ok := response["upstream"]; ok {
+		t.Error("upstream included with ?upstreamError=false")
+	}
+
+	// Problem details carry it too
+	_, response = serveUpstreamError(t, server, "/applications/web", map[string]string{"Accept": "application/problem+json"})
+	if _, ok := response["upstream"]; !ok {
+		t.Errorf("problem details = %v, want upstream", response)
+	}
+
+	// Errors that did not come from ArgoCD have nothing to include
+	_, response = serveUpstreamError(t, server, "/groups/unknown/applications?upstreamError=true", nil)
+	if _, ok := response["upstream"]; ok {
+		t.Error("upstream included for an error ArgoCD did not answer")
+	}
+}
+
+func TestUpstreamErrorInvalidParam(t *testing.T) {
+	server := setupUpstreamErrorServer(t)
+	server.config.StrictQueryParams = true
+	server.setupRouter()
+
+	code, _ := serveUpstreamError(t, server, "/applications/web?upstreamError=maybe", nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("?upstreamError=maybe status = %d, want %d", code, http.StatusBadRequest)
+	}
+	// Strict mode accepts it on every route
+	code, _ = serveUpstreamError(t, server, "/applications/web?upstreamError=true", nil)
+	if code != http.StatusBadGateway {
+		t.Errorf("?upstreamError=true in strict mode status = %d, want %d", code, http.StatusBadGateway)
+	}
+}
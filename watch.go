@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"argocd-proxy/services"
+)
+
+// resourceVersionHeader carries the content hash of the cached application set,
+// which clients pass back as ?watchAfter= to wait for changes
+const resourceVersionHeader = "X-Resource-Version"
+
+// watchApplications holds a ?watchAfter= request open until the cached application
+// set differs from the supplied version, or returns 304 once WATCH_MAX_WAIT elapses.
+// Changes are detected from the service's cache refresh notifications. When the background
+// poller is enabled it is the only source of refreshes; otherwise the handler re-reads the
+// cache once per CACHE_TTL so that idle watchers still observe expiry-driven refreshes
+// without polling ArgoCD per request. When the server starts shutting down, waiting
+// watchers return 304 immediately so clients reconnect to another replica instead of
+// being cut off at the end of the grace period.
+func (s *Server) watchApplications(c *gin.Context, filter services.ApplicationFilter, order services.ApplicationSort, limit int, watchAfter string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.config.WatchMaxWait)
+	defer cancel()
+
+	var refresh <-chan time.Time
+	if s.config.PollInterval <= 0 && s.config.CacheTTL > 0 {
+		ticker := time.NewTicker(s.config.CacheTTL)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		// Subscribe before reading so a refresh between the read and the wait is not missed
+		changed := s.argocdService.ApplicationsChanged()
+
+		fetchCtx, fetchCancel := s.requestContext(ctx)
+		applications, err := s.argocdService.GetApplications(fetchCtx)
+		fetchCancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				c.Header(resourceVersionHeader, watchAfter)
+				c.Status(http.StatusNotModified)
+				return
+			}
+			log.Printf("Failed to get applications while watching: %v", err)
+			s.serviceErrorResponse(c, http.StatusBadGateway, "Failed to retrieve applications from ArgoCD", err)
+			return
+		}
+
+		hash := services.ApplicationsHash(applications)
+		if watchAfter != hash && watchAfter != applications.Metadata.ResourceVersion {
+			c.Header(resourceVersionHeader, hash)
+			c.JSON(http.StatusOK, truncateApplications(c, applicationListResponse(ctx, applications, filter, order), limit))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Header(resourceVersionHeader, hash)
+			c.Status(http.StatusNotModified)
+			return
+		case <-s.lifecycle.Draining():
+			c.Header(resourceVersionHeader, hash)
+			c.Header("Connection", "close")
+			c.Status(http.StatusNotModified)
+			return
+		case <-changed:
+		case <-refresh:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"argocd-proxy/services"
+	"argocd-proxy/types"
+)
+
+// watchTestApplications returns a small application list for watch tests
+func watchTestApplications(names ...string) types.ArgocdApplicationList {
+	list := types.ArgocdApplicationList{APIVersion: "v1", Kind: "List"}
+	for _, name := range names {
+		list.Items = append(list.Items, types.ArgocdApplication{
+			Metadata: types.ArgocdApplicationMetadata{Name: name},
+		})
+	}
+	return list
+}
+
+func TestApplicationsResourceVersionHeader(t *testing.T) {
+	server := setupTestServer()
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = watchTestApplications("app1", "app2")
+
+	req := httptest.NewRequest("GET", "/applications", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	want := services.ApplicationsHash(mockService.applications)
+	if got := w.Header().Get(resourceVersionHeader); got != want {
+		t.Errorf("getApplications() %s = %q, want %q", resourceVersionHeader, got, want)
+	}
+}
+
+func TestWatchApplicationsReturnsImmediatelyOnStaleVersion(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 5 * time.Second
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = watchTestApplications("app1")
+
+	req := httptest.NewRequest("GET", "/applications?watchAfter=outdated", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("watch status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("watch with stale version should return immediately, took %v", time.Since(start))
+	}
+	if w.Header().Get(resourceVersionHeader) != services.ApplicationsHash(mockService.applications) {
+		t.Errorf("watch response missing current %s", resourceVersionHeader)
+	}
+}
+
+func TestWatchApplicationsAppliesSort(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 5 * time.Second
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = watchTestApplications("api", "web", "cron")
+
+	req := httptest.NewRequest("GET", "/applications?watchAfter=outdated&sort=name&order=desc", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("watch status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var body types.ArgocdApplicationList
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode watch response: %v", err)
+	}
+	var names []string
+	for _, app := range body.Items {
+		names = append(names, app.Metadata.Name)
+	}
+	want := []string{"web", "cron", "api"}
+	if len(names) != len(want) {
+		t.Fatalf("watch names = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("watch names = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestWatchApplicationsNotModifiedAtDeadline(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 50 * time.Millisecond
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = watchTestApplications("app1")
+	current := services.ApplicationsHash(mockService.applications)
+
+	req := httptest.NewRequest("GET", "/applications?watchAfter="+current, nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("watch status = %v, want %v", w.Code, http.StatusNotModified)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("watch returned before the deadline: %v", elapsed)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("watch 304 response should have an empty body, got %q", w.Body.String())
+	}
+}
+
+func TestWatchApplicationsAcceptsUpstreamResourceVersion(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 20 * time.Millisecond
+	mockService := server.argocdService.(*MockArgocdService)
+	mockService.applications = watchTestApplications("app1")
+	mockService.applications.Metadata.ResourceVersion = "12345"
+
+	req := httptest.NewRequest("GET", "/applications?watchAfter=12345", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("watch status = %v, want %v", w.Code, http.StatusNotModified)
+	}
+}
+
+// changingArgocdService is a race-safe mock whose application set can be swapped
+// while a watch request is in flight
+type changingArgocdService struct {
+	MockArgocdService
+	mu         sync.Mutex
+	subscribed chan struct{}
+}
+
+func (m *changingArgocdService) GetApplications(ctx context.Context) (types.ArgocdApplicationList, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.applications, nil
+}
+
+func (m *changingArgocdService) ApplicationsChanged() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subscribed != nil {
+		close(m.subscribed)
+		m.subscribed = nil
+	}
+	return m.applicationsChanged
+}
+
+// publish replaces the application set and wakes up watchers
+func (m *changingArgocdService) publish(list types.ArgocdApplicationList) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applications = list
+	close(m.applicationsChanged)
+	m.applicationsChanged = make(chan struct{})
+}
+
+func TestWatchApplicationsWakesOnChange(t *testing.T) {
+	server := setupTestServer()
+	server.config.WatchMaxWait = 5 * time.Second
+	server.config.CacheTTL = 0 // rely solely on change notifications
+	subscribed := make(chan struct{})
+	mockService := &changingArgocdService{subscribed: subscribed}
+	mockService.applications = watchTestApplications("app1")
+	mockService.applicationsChanged = make(chan struct{})
+	server.argocdService = mockService
+	current := services.ApplicationsHash(mockService.applications)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest("GET", "/applications?watchAfter="+current, nil)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		done <- w
+	}()
+
+	// Simulate a cache refresh producing a new application set once the watcher is waiting
+	<-subscribed
+	mockService.publish(watchTestApplications("app1", "app2"))
+
+	select {
+	case w := <-done:
+		if w.Code != http.StatusOK {
+			t.Fatalf("watch status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var response types.ArgocdApplicationList
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("watch invalid JSON response: %v", err)
+		}
+		if len(response.Items) != 2 {
+			t.Errorf("watch items count = %v, want 2", len(response.Items))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not return after the application set changed")
+	}
+}
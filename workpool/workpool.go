@@ -0,0 +1,92 @@
+// Package workpool bounds how many upstream calls the proxy makes at once, so that a
+// fan-out over many applications cannot open an unbounded number of connections to
+// ArgoCD. Work waiting for a slot is exported as queue depth and wait time metrics.
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"argocd-proxy/metrics"
+)
+
+// Pool runs at most Size tasks at a time; further tasks queue until a slot frees up
+type Pool struct {
+	name  string
+	slots chan struct{}
+}
+
+// New creates a pool named name, used as the metrics label, with size slots. Sizes
+// below one are raised to one.
+func New(name string, size int) *Pool {
+	return &Pool{
+		name:  name,
+		slots: make(chan struct{}, max(size, 1)),
+	}
+}
+
+// Size returns the number of tasks the pool runs concurrently
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Do runs fn once a slot is free and returns its error. If ctx is done before a slot
+// frees up, fn is not run and the context's error is returned.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+	return fn(ctx)
+}
+
+// Run runs every task through the pool and waits for all of them. Tasks still queued
+// when ctx is done are abandoned. The returned error joins the task errors in order.
+func (p *Pool) Run(ctx context.Context, tasks ...func(ctx context.Context) error) error {
+	errs := make([]error, len(tasks))
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.Do(ctx, task)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// acquire waits for a free slot, recording the queue depth while waiting and the wait
+// time once a slot is taken
+func (p *Pool) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	queued := metrics.WorkpoolQueueDepth.WithLabelValues(p.name)
+	queued.Inc()
+	defer queued.Dec()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// A slot and cancellation can become ready together; cancellation wins
+	if err := ctx.Err(); err != nil {
+		p.release()
+		return err
+	}
+	metrics.WorkpoolWaitDuration.WithLabelValues(p.name).Observe(time.Since(start).Seconds())
+	return nil
+}
+
+// release frees the slot taken by acquire
+func (p *Pool) release() {
+	<-p.slots
+}
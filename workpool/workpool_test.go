@@ -0,0 +1,213 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"argocd-proxy/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingServer records the highest number of requests it served at once
+type countingServer struct {
+	*httptest.Server
+	inFlight, peak, total atomic.Int64
+}
+
+func newCountingServer(delay time.Duration) *countingServer {
+	s := &countingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		s.total.Add(1)
+		for {
+			peak := s.peak.Load()
+			if current <= peak || s.peak.CompareAndSwap(peak, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	return s
+}
+
+// get returns a task requesting the counting server
+func (s *countingServer) get(path string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
+
+func TestPoolNeverExceedsLimit(t *testing.T) {
+	for _, size := range []int{1, 3, 8} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			server := newCountingServer(10 * time.Millisecond)
+			defer server.Close()
+
+			pool := New("test", size)
+			tasks := make([]func(ctx context.Context) error, 40)
+			for i := range tasks {
+				tasks[i] = server.get(fmt.Sprintf("/applications/app-%d", i))
+			}
+
+			if err := pool.Run(context.Background(), tasks...); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := server.total.Load(); got != int64(len(tasks)) {
+				t.Errorf("server saw %d requests, want %d", got, len(tasks))
+			}
+			if peak := server.peak.Load(); peak > int64(size) {
+				t.Errorf("peak concurrency = %d, want at most %d", peak, size)
+			}
+		})
+	}
+}
+
+func TestPoolSharedAcrossRuns(t *testing.T) {
+	server := newCountingServer(10 * time.Millisecond)
+	defer server.Close()
+
+	pool := New("test", 4)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Run(context.Background(),
+				server.get("/a"), server.get("/b"), server.get("/c"), server.get("/d"))
+		}()
+	}
+	wg.Wait()
+
+	if peak := server.peak.Load(); peak > 4 {
+		t.Errorf("peak concurrency across runs = %d, want at most 4", peak)
+	}
+}
+
+func TestPoolCancellationAbortsQueuedWork(t *testing.T) {
+	pool := New("test", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go pool.Do(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	var ran atomic.Int64
+	queued := func(ctx context.Context) error {
+		ran.Add(1)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, queued, queued, queued)
+	}()
+
+	// Give the queued tasks time to reach the queue before cancelling
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(release)
+
+	err := <-done
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if got := ran.Load(); got != 0 {
+		t.Errorf("%d queued tasks ran after cancellation, want none", got)
+	}
+}
+
+func TestPoolDoCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := New("test", 2).Do(ctx, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("Do() error = %v, called = %v, want context.Canceled without running", err, called)
+	}
+}
+
+func TestPoolRunJoinsErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	err := New("test", 2).Run(context.Background(),
+		func(ctx context.Context) error { return errA },
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return errB },
+	)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Run() error = %v, want both task errors", err)
+	}
+}
+
+func TestNewMinimumSize(t *testing.T) {
+	if got := New("test", 0).Size(); got != 1 {
+		t.Errorf("New(0).Size() = %d, want 1", got)
+	}
+}
+
+func TestPoolMetrics(t *testing.T) {
+	original := metrics.Default()
+	defer metrics.SetDefault(original)
+	metrics.SetDefault(metrics.New(metrics.DefaultOptions()))
+
+	pool := New("metrics-test", 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go pool.Do(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Do(context.Background(), func(ctx context.Context) error { return nil })
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(metrics.WorkpoolQueueDepth.WithLabelValues("metrics-test")) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("queue depth never reached 1")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.WorkpoolQueueDepth.WithLabelValues("metrics-test")); got != 0 {
+		t.Errorf("queue depth after run = %v, want 0", got)
+	}
+	if got := testutil.CollectAndCount(metrics.WorkpoolWaitDuration); got != 1 {
+		t.Errorf("wait duration series = %d, want 1", got)
+	}
+}